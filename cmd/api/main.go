@@ -13,6 +13,7 @@ import (
 
 	"tea-logistics/pkg/config"
 	"tea-logistics/pkg/database"
+	"tea-logistics/pkg/geo"
 	"tea-logistics/pkg/handlers"
 	"tea-logistics/pkg/health"
 	"tea-logistics/pkg/logger"
@@ -122,22 +123,98 @@ func main() {
 	trackingRepo := repository.NewTrackingRepository(db)
 	notifyRepo := repository.NewSQLNotificationRepository(dbWrapper)
 	deliveryRepo := repository.NewSQLDeliveryRepository(dbWrapper)
+	reservationRepo := repository.NewSQLReservationRepository(dbWrapper)
+	warehouseRepo := repository.NewSQLWarehouseRepository(dbWrapper)
+	locationRepo := repository.NewSQLLocationRepository(dbWrapper)
+	lotRepo := repository.NewSQLLotRepository(dbWrapper)
+	allocationRepo := repository.NewSQLAllocationRepository(dbWrapper)
+	expiryRepo := repository.NewSQLExpiryRepository(dbWrapper)
+	replenishmentRepo := repository.NewSQLReplenishmentRepository(dbWrapper)
+	purchaseOrderRepo := repository.NewSQLPurchaseOrderRepository(dbWrapper)
+	stockCountRepo := repository.NewSQLStockCountRepository(dbWrapper)
+	valuationRepo := repository.NewSQLValuationRepository(dbWrapper)
+	ledgerRepo := repository.NewSQLLedgerRepository(dbWrapper)
+	variantRepo := repository.NewSQLProductVariantRepository(dbWrapper)
+	vehicleRepo := repository.NewSQLVehicleRepository(dbWrapper)
+	scheduleRepo := repository.NewSQLScheduleRepository(dbWrapper)
+	driverRepo := repository.NewSQLDriverRepository(dbWrapper)
+	routeRepo := repository.NewSQLRouteRepository(dbWrapper)
+	etaRepo := repository.NewSQLETARepository(dbWrapper)
+	unitOfWork := repository.NewSQLUnitOfWork(db)
+
+	// 同梱の地名辞書によるジオコーダの初期化
+	geocoder, err := geo.NewGazetteer()
+	if err != nil {
+		logger.Fatal("ジオコーダの初期化に失敗しました", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
 
 	// サービスの初期化
 	userService := services.NewUserService(userRepo)
 	productService := services.NewProductService(productRepo)
-	inventoryService := services.NewInventoryService(inventoryRepo)
-	trackingService := services.NewTrackingService(trackingRepo)
+	variantService := services.NewProductVariantService(variantRepo, productRepo)
+	inventoryService := services.NewInventoryService(inventoryRepo, reservationRepo, unitOfWork)
 	notifyService := services.NewNotificationService(notifyRepo, deliveryRepo)
-	deliveryService := services.NewDeliveryService(deliveryRepo, inventoryRepo, notifyService)
+	routePlanner := services.NewRoutePlanner(nil)
+	etaService := services.NewETAService(etaRepo, deliveryRepo, routeRepo, routePlanner, notifyService, services.DefaultETADelayThreshold)
+	trackingService := services.NewTrackingService(trackingRepo, geocoder, etaService)
+	deliveryService := services.NewDeliveryService(deliveryRepo, inventoryRepo, unitOfWork, notifyService, routePlanner, geocoder)
+	warehouseService := services.NewWarehouseService(warehouseRepo, unitOfWork, geocoder)
+	locationService := services.NewLocationService(locationRepo)
+	lotService := services.NewLotService(lotRepo)
+	allocationService := services.NewAllocationService(allocationRepo)
+	expiryService := services.NewExpiryService(expiryRepo, notifyService)
+	replenishmentService := services.NewReplenishmentService(replenishmentRepo, unitOfWork, notifyService)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, unitOfWork)
+	stockCountService := services.NewStockCountService(stockCountRepo, unitOfWork)
+	valuationService := services.NewValuationService(valuationRepo, unitOfWork)
+	ledgerService := services.NewLedgerService(ledgerRepo)
+	vehicleService := services.NewVehicleService(vehicleRepo, scheduleRepo, deliveryService, routePlanner, unitOfWork)
+	driverService := services.NewDriverService(driverRepo)
+	schedulingService := services.NewSchedulingService(scheduleRepo, driverService, vehicleService, deliveryService, unitOfWork)
+	routeService := services.NewRouteService(routeRepo, routePlanner, vehicleService, unitOfWork)
+	bulkService := services.NewBulkService(productService, inventoryService, warehouseRepo, locationRepo, unitOfWork)
+
+	// 在庫引当の期限切れ処理を開始
+	go inventoryService.StartReservationExpiry(ctx, time.Minute)
+
+	// 賞味期限の監視を開始
+	go expiryService.StartExpiryMonitor(ctx, time.Hour)
+
+	// 発注点による在庫評価を開始
+	go replenishmentService.StartReplenishmentEvaluator(ctx, 15*time.Minute)
+
+	// 日次在庫スナップショットの作成を開始
+	go valuationService.StartSnapshotScheduler(ctx, time.Hour)
+
+	// 配送の到着予定日時の予測を開始
+	go etaService.StartETAUpdater(ctx, 5*time.Minute)
 
 	// ハンドラの初期化
 	userHandler := handlers.NewUserHandler(userService)
 	productHandler := handlers.NewProductHandler(productService)
+	variantHandler := handlers.NewProductVariantHandler(variantService)
 	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
 	trackingHandler := handlers.NewTrackingHandler(trackingService)
 	notifyHandler := handlers.NewNotificationHandler(notifyService)
 	deliveryHandler := handlers.NewDeliveryHandler(deliveryService)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseService)
+	locationHandler := handlers.NewLocationHandler(locationService)
+	lotHandler := handlers.NewLotHandler(lotService)
+	allocationHandler := handlers.NewAllocationHandler(allocationService)
+	expiryHandler := handlers.NewExpiryHandler(expiryService)
+	replenishmentHandler := handlers.NewReplenishmentHandler(replenishmentService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	stockCountHandler := handlers.NewStockCountHandler(stockCountService)
+	valuationHandler := handlers.NewValuationHandler(valuationService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	vehicleHandler := handlers.NewVehicleHandler(vehicleService)
+	driverHandler := handlers.NewDriverHandler(driverService, schedulingService)
+	scheduleHandler := handlers.NewScheduleHandler(schedulingService)
+	routeHandler := handlers.NewRouteHandler(routeService)
+	etaHandler := handlers.NewETAHandler(etaService)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
 
 	// Ginルーターの設定
 	router := gin.Default()
@@ -157,10 +234,27 @@ func main() {
 	// ルーティングの設定
 	routes.SetupAuthRoutes(router, userHandler)
 	routes.SetupProductRoutes(router, productHandler)
+	routes.SetupProductVariantRoutes(router, variantHandler)
 	routes.SetupInventoryRoutes(router, inventoryHandler)
 	routes.SetupTrackingRoutes(router, trackingHandler)
 	routes.SetupNotificationRoutes(router, notifyHandler)
 	routes.SetupDeliveryRoutes(router, deliveryHandler)
+	routes.SetupWarehouseRoutes(router, warehouseHandler)
+	routes.SetupLocationRoutes(router, locationHandler)
+	routes.SetupLotRoutes(router, lotHandler)
+	routes.SetupAllocationRoutes(router, allocationHandler)
+	routes.SetupExpiryRoutes(router, expiryHandler)
+	routes.SetupReplenishmentRoutes(router, replenishmentHandler)
+	routes.SetupPurchaseOrderRoutes(router, purchaseOrderHandler)
+	routes.SetupStockCountRoutes(router, stockCountHandler)
+	routes.SetupValuationRoutes(router, valuationHandler)
+	routes.SetupLedgerRoutes(router, ledgerHandler)
+	routes.SetupVehicleRoutes(router, vehicleHandler)
+	routes.SetupDriverRoutes(router, driverHandler)
+	routes.SetupScheduleRoutes(router, scheduleHandler)
+	routes.SetupRoutePlanRoutes(router, routeHandler)
+	routes.SetupETARoutes(router, etaHandler)
+	routes.SetupBulkRoutes(router, bulkHandler)
 
 	// ヘルスチェックルートの設定
 	health.SetupGlobalHealthRoutes(router)
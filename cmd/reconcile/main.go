@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"tea-logistics/pkg/config"
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services"
+
+	_ "github.com/lib/pq"
+
+	"github.com/joho/godotenv"
+)
+
+/*
+ * 在庫元帳照合コマンド
+ * 在庫行の数量を在庫元帳から再計算した残高と照合し、差異を出力する
+ * -fixを指定した場合は差異のある在庫行の数量を元帳残高に戻す
+ */
+
+func init() {
+	// 環境変数の読み込み
+	if err := godotenv.Load(); err != nil {
+		log.Printf("警告: .envファイルが読み込めません: %v\n", err)
+	}
+}
+
+func main() {
+	fix := flag.Bool("fix", false, "差異のある在庫行の数量を元帳残高に戻す")
+	flag.Parse()
+
+	// ログ機能の初期化
+	if err := logger.InitFromEnv(); err != nil {
+		log.Fatalf("ログ機能の初期化に失敗しました: %v", err)
+	}
+
+	// 設定の読み込み
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Fatal("設定の読み込みに失敗しました", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// データベース接続
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("データベース接続に失敗しました", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		logger.Fatal("データベース接続の確認に失敗しました", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	ledgerService := services.NewLedgerService(repository.NewSQLLedgerRepository(repository.NewSQLDatabase(db)))
+
+	report, err := ledgerService.Reconcile(context.Background(), *fix, time.Now())
+	if err != nil {
+		logger.Fatal("在庫元帳照合エラー", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("照合結果の出力に失敗しました: %v", err)
+	}
+
+	// 差異を修正していない場合は終了コードで差異の有無を通知する
+	if len(report.Drifts) > 0 && !*fix {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,32 @@
+-- +migrate Up
+-- 在庫引当テーブル
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER REFERENCES products(id),
+    location VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    delivery_id INTEGER REFERENCES deliveries(id) ON DELETE CASCADE,
+    reference_number VARCHAR(100),
+    status VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product_location ON stock_reservations(product_id, location) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_delivery_id ON stock_reservations(delivery_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations(expires_at) WHERE status = 'active';
+
+-- トリガーの作成
+DO $$
+BEGIN
+    -- トリガー関数の存在確認
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_stock_reservations_updated_at ON stock_reservations;
+        CREATE TRIGGER update_stock_reservations_updated_at
+            BEFORE UPDATE ON stock_reservations
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,6 @@
+-- +migrate Down
+DROP TRIGGER IF EXISTS update_stock_reservations_updated_at ON stock_reservations;
+DROP INDEX IF EXISTS idx_stock_reservations_expires_at;
+DROP INDEX IF EXISTS idx_stock_reservations_delivery_id;
+DROP INDEX IF EXISTS idx_stock_reservations_product_location;
+DROP TABLE IF EXISTS stock_reservations;
@@ -0,0 +1,16 @@
+-- +migrate Up
+-- 配送明細の部分キャンセル対応
+ALTER TABLE delivery_items
+    ADD COLUMN IF NOT EXISTS cancelled_quantity INTEGER NOT NULL DEFAULT 0 CHECK (cancelled_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+ALTER TABLE delivery_items
+    ADD CONSTRAINT chk_delivery_items_cancelled_quantity CHECK (cancelled_quantity <= quantity);
+
+-- 在庫引当と配送明細の紐づけ
+ALTER TABLE stock_reservations
+    ADD COLUMN IF NOT EXISTS delivery_item_id INTEGER REFERENCES delivery_items(id) ON DELETE CASCADE;
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_delivery_items_delivery_id ON delivery_items(delivery_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_delivery_item_id ON stock_reservations(delivery_item_id);
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_stock_reservations_delivery_item_id;
+DROP INDEX IF EXISTS idx_delivery_items_delivery_id;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS delivery_item_id;
+ALTER TABLE delivery_items DROP CONSTRAINT IF EXISTS chk_delivery_items_cancelled_quantity;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS status;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS cancelled_quantity;
@@ -0,0 +1,14 @@
+-- +migrate Up
+-- 配送ステータス変更履歴テーブル
+CREATE TABLE IF NOT EXISTS delivery_status_history (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER REFERENCES deliveries(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    changed_by INTEGER REFERENCES users(id),
+    reason TEXT,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_delivery_status_history_delivery_id ON delivery_status_history(delivery_id, changed_at);
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_delivery_status_history_delivery_id;
+DROP TABLE IF EXISTS delivery_status_history;
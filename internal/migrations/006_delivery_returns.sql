@@ -0,0 +1,7 @@
+-- +migrate Up
+-- 配送明細の返品数量
+ALTER TABLE delivery_items
+    ADD COLUMN IF NOT EXISTS returned_quantity INTEGER NOT NULL DEFAULT 0 CHECK (returned_quantity >= 0);
+
+-- 返品・隔離在庫の検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_type ON inventory_movements(movement_type);
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_inventory_movements_type;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS returned_quantity;
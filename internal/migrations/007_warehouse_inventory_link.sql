@@ -0,0 +1,15 @@
+-- +migrate Up
+-- 倉庫名は在庫ロケーションとして使用するため一意とする
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_name ON warehouses(name);
+
+-- 在庫と倉庫の紐付け
+ALTER TABLE inventory
+    ADD COLUMN IF NOT EXISTS warehouse_id INTEGER REFERENCES warehouses(id);
+
+-- 既存の在庫はロケーション名が一致する倉庫に紐付ける
+UPDATE inventory i
+SET warehouse_id = w.id
+FROM warehouses w
+WHERE i.location = w.name AND i.warehouse_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_inventory_warehouse_id ON inventory(warehouse_id);
@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_inventory_warehouse_id;
+ALTER TABLE inventory DROP COLUMN IF EXISTS warehouse_id;
+DROP INDEX IF EXISTS idx_warehouses_name;
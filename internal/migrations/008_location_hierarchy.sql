@@ -0,0 +1,76 @@
+-- +migrate Up
+-- ゾーンテーブル（倉庫内の保管環境ごとの区画）
+CREATE TABLE IF NOT EXISTS zones (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    zone_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (warehouse_id, code)
+);
+
+-- ゾーン保管ルールテーブル（ゾーンに保管できる商品カテゴリ）
+CREATE TABLE IF NOT EXISTS zone_allowed_categories (
+    zone_id INTEGER NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL,
+    PRIMARY KEY (zone_id, category)
+);
+
+-- 通路テーブル
+CREATE TABLE IF NOT EXISTS aisles (
+    id SERIAL PRIMARY KEY,
+    zone_id INTEGER NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (zone_id, code)
+);
+
+-- ビンテーブル
+CREATE TABLE IF NOT EXISTS bins (
+    id SERIAL PRIMARY KEY,
+    aisle_id INTEGER NOT NULL REFERENCES aisles(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (aisle_id, code)
+);
+
+-- 在庫・在庫移動とビンの紐付け
+ALTER TABLE inventory
+    ADD COLUMN IF NOT EXISTS bin_id INTEGER REFERENCES bins(id);
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS from_bin_id INTEGER REFERENCES bins(id),
+    ADD COLUMN IF NOT EXISTS to_bin_id INTEGER REFERENCES bins(id);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_zones_warehouse_id ON zones(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_aisles_zone_id ON aisles(zone_id);
+CREATE INDEX IF NOT EXISTS idx_bins_aisle_id ON bins(aisle_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_bin_id ON inventory(bin_id);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_zones_updated_at ON zones;
+        CREATE TRIGGER update_zones_updated_at
+            BEFORE UPDATE ON zones
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+
+        DROP TRIGGER IF EXISTS update_aisles_updated_at ON aisles;
+        CREATE TRIGGER update_aisles_updated_at
+            BEFORE UPDATE ON aisles
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+
+        DROP TRIGGER IF EXISTS update_bins_updated_at ON bins;
+        CREATE TRIGGER update_bins_updated_at
+            BEFORE UPDATE ON bins
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
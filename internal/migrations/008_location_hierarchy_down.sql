@@ -0,0 +1,10 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_inventory_bin_id;
+ALTER TABLE inventory_movements
+    DROP COLUMN IF EXISTS to_bin_id,
+    DROP COLUMN IF EXISTS from_bin_id;
+ALTER TABLE inventory DROP COLUMN IF EXISTS bin_id;
+DROP TABLE IF EXISTS bins;
+DROP TABLE IF EXISTS aisles;
+DROP TABLE IF EXISTS zone_allowed_categories;
+DROP TABLE IF EXISTS zones;
@@ -0,0 +1,52 @@
+-- +migrate Up
+-- ロットテーブル（収穫ロット）
+CREATE TABLE IF NOT EXISTS lots (
+    id SERIAL PRIMARY KEY,
+    lot_number VARCHAR(100) NOT NULL UNIQUE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    harvest_flush VARCHAR(50) NOT NULL,
+    harvest_date DATE NOT NULL,
+    origin_estate VARCHAR(255) NOT NULL,
+    grade VARCHAR(100) NOT NULL DEFAULT '',
+    best_before_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 在庫・在庫移動・在庫引当・配送明細とロットの紐付け
+ALTER TABLE inventory
+    ADD COLUMN IF NOT EXISTS lot_id INTEGER REFERENCES lots(id);
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS lot_id INTEGER REFERENCES lots(id);
+ALTER TABLE stock_reservations
+    ADD COLUMN IF NOT EXISTS lot_id INTEGER REFERENCES lots(id);
+ALTER TABLE delivery_items
+    ADD COLUMN IF NOT EXISTS lot_id INTEGER REFERENCES lots(id);
+
+-- 出荷ロットテーブル（出荷確定時に払い出したロット）
+CREATE TABLE IF NOT EXISTS delivery_item_lots (
+    id SERIAL PRIMARY KEY,
+    delivery_item_id INTEGER NOT NULL REFERENCES delivery_items(id) ON DELETE CASCADE,
+    lot_id INTEGER NOT NULL REFERENCES lots(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_lots_product_id ON lots(product_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_lot_id ON inventory(lot_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_lot_id ON inventory_movements(lot_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_lot_id ON stock_reservations(lot_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_item_lots_lot_id ON delivery_item_lots(lot_id);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_lots_updated_at ON lots;
+        CREATE TRIGGER update_lots_updated_at
+            BEFORE UPDATE ON lots
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,12 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_delivery_item_lots_lot_id;
+DROP INDEX IF EXISTS idx_stock_reservations_lot_id;
+DROP INDEX IF EXISTS idx_inventory_movements_lot_id;
+DROP INDEX IF EXISTS idx_inventory_lot_id;
+DROP INDEX IF EXISTS idx_lots_product_id;
+DROP TABLE IF EXISTS delivery_item_lots;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS lot_id;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS lot_id;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS lot_id;
+ALTER TABLE inventory DROP COLUMN IF EXISTS lot_id;
+DROP TABLE IF EXISTS lots;
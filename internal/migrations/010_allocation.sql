@@ -0,0 +1,30 @@
+-- +migrate Up
+-- 倉庫の位置情報（最寄り倉庫割当に使用）
+ALTER TABLE warehouses
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+-- 在庫割当方式テーブル（商品カテゴリごと）
+CREATE TABLE IF NOT EXISTS allocation_policies (
+    category VARCHAR(100) PRIMARY KEY,
+    strategy VARCHAR(50) NOT NULL CHECK (strategy IN ('fifo', 'fefo', 'nearest_warehouse')),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 配送明細の在庫割当テーブル
+CREATE TABLE IF NOT EXISTS delivery_allocations (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id) ON DELETE CASCADE,
+    delivery_item_id INTEGER NOT NULL REFERENCES delivery_items(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    location VARCHAR(255) NOT NULL,
+    lot_id INTEGER REFERENCES lots(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    strategy VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_delivery_allocations_delivery_id ON delivery_allocations(delivery_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_allocations_delivery_item_id ON delivery_allocations(delivery_item_id);
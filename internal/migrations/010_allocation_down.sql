@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_delivery_allocations_delivery_item_id;
+DROP INDEX IF EXISTS idx_delivery_allocations_delivery_id;
+DROP TABLE IF EXISTS delivery_allocations;
+DROP TABLE IF EXISTS allocation_policies;
+ALTER TABLE warehouses
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
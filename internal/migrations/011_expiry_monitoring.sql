@@ -0,0 +1,19 @@
+-- +migrate Up
+-- 賞味期限接近の通知日数テーブル（商品カテゴリごと）
+CREATE TABLE IF NOT EXISTS expiry_alert_policies (
+    category VARCHAR(100) PRIMARY KEY,
+    lead_days INTEGER NOT NULL CHECK (lead_days > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 賞味期限通知テーブル（同じ在庫行への重複通知を防ぐ）
+CREATE TABLE IF NOT EXISTS expiry_alerts (
+    id SERIAL PRIMARY KEY,
+    inventory_id INTEGER NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (inventory_id, kind)
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_lots_best_before_date ON lots(best_before_date);
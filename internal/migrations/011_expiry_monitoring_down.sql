@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_lots_best_before_date;
+DROP TABLE IF EXISTS expiry_alerts;
+DROP TABLE IF EXISTS expiry_alert_policies;
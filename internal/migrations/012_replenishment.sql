@@ -0,0 +1,45 @@
+-- +migrate Up
+-- 発注点設定テーブル（商品・ロケーションごと）
+CREATE TABLE IF NOT EXISTS reorder_settings (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location VARCHAR(255) NOT NULL,
+    reorder_point INTEGER NOT NULL CHECK (reorder_point >= 0),
+    safety_stock INTEGER NOT NULL DEFAULT 0 CHECK (safety_stock >= 0),
+    reorder_quantity INTEGER NOT NULL CHECK (reorder_quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, location)
+);
+
+-- 在庫補充提案テーブル
+CREATE TABLE IF NOT EXISTS replenishment_proposals (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    location VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    source_location VARCHAR(255),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    available_to_promise INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL,
+    safety_stock INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_replenishment_proposals_open ON replenishment_proposals(product_id, location) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_replenishment_proposals_status ON replenishment_proposals(status, created_at);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_reorder_settings_updated_at ON reorder_settings;
+        CREATE TRIGGER update_reorder_settings_updated_at
+            BEFORE UPDATE ON reorder_settings
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_replenishment_proposals_status;
+DROP INDEX IF EXISTS idx_replenishment_proposals_open;
+DROP TABLE IF EXISTS replenishment_proposals;
+DROP TABLE IF EXISTS reorder_settings;
@@ -0,0 +1,77 @@
+-- +migrate Up
+-- 仕入先テーブル
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 発注番号の採番シーケンス
+CREATE SEQUENCE IF NOT EXISTS purchase_order_number_seq;
+
+-- 発注書テーブル
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    po_number VARCHAR(50) UNIQUE NOT NULL,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id),
+    location VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    expected_arrival TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 発注明細テーブル
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    ordered_quantity INTEGER NOT NULL CHECK (ordered_quantity > 0),
+    received_quantity INTEGER NOT NULL DEFAULT 0 CHECK (received_quantity >= 0),
+    UNIQUE (purchase_order_id, product_id)
+);
+
+-- 入荷実績テーブル（発注明細ごとの予定数量と入荷数量）
+CREATE TABLE IF NOT EXISTS purchase_order_receipts (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    line_id INTEGER NOT NULL REFERENCES purchase_order_lines(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    lot_id INTEGER REFERENCES lots(id),
+    expected_quantity INTEGER NOT NULL,
+    received_quantity INTEGER NOT NULL CHECK (received_quantity > 0),
+    received_by INTEGER REFERENCES users(id),
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status, expected_arrival);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_receipts_po ON purchase_order_receipts(purchase_order_id, received_at);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_suppliers_updated_at ON suppliers;
+        CREATE TRIGGER update_suppliers_updated_at
+            BEFORE UPDATE ON suppliers
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+
+        DROP TRIGGER IF EXISTS update_purchase_orders_updated_at ON purchase_orders;
+        CREATE TRIGGER update_purchase_orders_updated_at
+            BEFORE UPDATE ON purchase_orders
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
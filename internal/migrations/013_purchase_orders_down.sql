@@ -0,0 +1,9 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_purchase_order_receipts_po;
+DROP INDEX IF EXISTS idx_purchase_orders_supplier_id;
+DROP INDEX IF EXISTS idx_purchase_orders_status;
+DROP TABLE IF EXISTS purchase_order_receipts;
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP SEQUENCE IF EXISTS purchase_order_number_seq;
+DROP TABLE IF EXISTS suppliers;
@@ -0,0 +1,49 @@
+-- +migrate Up
+-- 棚卸テーブル
+CREATE TABLE IF NOT EXISTS stock_counts (
+    id SERIAL PRIMARY KEY,
+    location VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    notes TEXT NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id),
+    reviewed_by INTEGER REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 棚卸明細テーブル
+CREATE TABLE IF NOT EXISTS stock_count_lines (
+    id SERIAL PRIMARY KEY,
+    stock_count_id INTEGER NOT NULL REFERENCES stock_counts(id) ON DELETE CASCADE,
+    inventory_id INTEGER REFERENCES inventory(id) ON DELETE SET NULL,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    bin_id INTEGER REFERENCES bins(id),
+    lot_id INTEGER REFERENCES lots(id),
+    status VARCHAR(50) NOT NULL,
+    expected_quantity INTEGER NOT NULL CHECK (expected_quantity >= 0),
+    counted_quantity INTEGER CHECK (counted_quantity >= 0),
+    reason_code VARCHAR(30)
+);
+
+-- 棚卸による調整理由
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS reason_code VARCHAR(30);
+
+-- インデックスの作成（ロケーションごとに進行中の棚卸は1件まで）
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_counts_active_location ON stock_counts(location) WHERE status IN ('open', 'submitted');
+CREATE INDEX IF NOT EXISTS idx_stock_counts_status ON stock_counts(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_count_lines_stock_count_id ON stock_count_lines(stock_count_id);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_stock_counts_updated_at ON stock_counts;
+        CREATE TRIGGER update_stock_counts_updated_at
+            BEFORE UPDATE ON stock_counts
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
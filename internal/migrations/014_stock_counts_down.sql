@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_stock_count_lines_stock_count_id;
+DROP INDEX IF EXISTS idx_stock_counts_status;
+DROP INDEX IF EXISTS idx_stock_counts_active_location;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS reason_code;
+DROP TABLE IF EXISTS stock_count_lines;
+DROP TABLE IF EXISTS stock_counts;
@@ -0,0 +1,67 @@
+-- +migrate Up
+-- 商品単価（在庫評価に使用する）
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS price DECIMAL(12, 2) NOT NULL DEFAULT 0;
+
+-- 商品単価の履歴テーブル（評価日時点の単価を求めるために使用する）
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(12, 2) NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 既存商品の現在の単価を登録日から有効な単価として記録する
+INSERT INTO product_price_history (product_id, price, effective_from)
+SELECT p.id, p.price, p.created_at
+FROM products p
+WHERE NOT EXISTS (
+    SELECT 1 FROM product_price_history h WHERE h.product_id = p.id
+);
+
+-- 商品の登録・単価変更時に単価の履歴を記録する
+DO $price_history$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_proc WHERE proname = 'record_product_price_history'
+    ) THEN
+        EXECUTE 'CREATE FUNCTION record_product_price_history()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            IF TG_OP = ''INSERT'' OR NEW.price IS DISTINCT FROM OLD.price THEN
+                INSERT INTO product_price_history (product_id, price, effective_from)
+                VALUES (NEW.id, NEW.price, CURRENT_TIMESTAMP);
+            END IF;
+            RETURN NEW;
+        END;
+        $$ LANGUAGE plpgsql';
+    END IF;
+END
+$price_history$;
+
+DROP TRIGGER IF EXISTS record_products_price_history ON products;
+CREATE TRIGGER record_products_price_history
+    AFTER INSERT OR UPDATE OF price ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION record_product_price_history();
+
+-- 日次在庫スナップショットテーブル
+CREATE TABLE IF NOT EXISTS inventory_snapshots (
+    snapshot_date DATE PRIMARY KEY,
+    line_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 日次在庫スナップショット明細テーブル（商品・ロケーションごとの日末在庫数）
+CREATE TABLE IF NOT EXISTS inventory_snapshot_lines (
+    id SERIAL PRIMARY KEY,
+    snapshot_date DATE NOT NULL REFERENCES inventory_snapshots(snapshot_date) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    location VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL,
+    UNIQUE (snapshot_date, product_id, location)
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_product_price_history_product ON product_price_history(product_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_movement_date ON inventory_movements(movement_date);
@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_inventory_movements_movement_date;
+DROP INDEX IF EXISTS idx_product_price_history_product;
+DROP TABLE IF EXISTS inventory_snapshot_lines;
+DROP TABLE IF EXISTS inventory_snapshots;
+DROP TRIGGER IF EXISTS record_products_price_history ON products;
+DROP FUNCTION IF EXISTS record_product_price_history();
+DROP TABLE IF EXISTS product_price_history;
@@ -0,0 +1,56 @@
+-- +migrate Up
+-- 在庫元帳テーブル（在庫数の増減をすべて追記する）
+-- inventory_idは在庫行の削除後も記帳を残すため外部キーにしない
+CREATE TABLE IF NOT EXISTS inventory_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    inventory_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    location VARCHAR(255) NOT NULL,
+    bin_id INTEGER,
+    lot_id INTEGER,
+    quantity INTEGER NOT NULL,
+    balance INTEGER NOT NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    movement_id INTEGER REFERENCES inventory_movements(id),
+    actor_id INTEGER REFERENCES users(id),
+    request_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 既存の在庫数を期首残高として記帳する
+INSERT INTO inventory_ledger (
+    inventory_id, product_id, location, bin_id, lot_id, quantity, balance, entry_type
+)
+SELECT i.id, i.product_id, i.location, i.bin_id, i.lot_id, i.quantity, i.quantity, 'opening'
+FROM inventory i
+WHERE i.quantity <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM inventory_ledger l WHERE l.inventory_id = i.id
+  );
+
+-- 在庫元帳の更新・削除を禁止する（追記のみ）
+DO $ledger_guard$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_proc WHERE proname = 'prevent_inventory_ledger_modification'
+    ) THEN
+        EXECUTE 'CREATE FUNCTION prevent_inventory_ledger_modification()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            RAISE EXCEPTION ''在庫元帳は追記のみ可能です'';
+        END;
+        $$ LANGUAGE plpgsql';
+    END IF;
+END
+$ledger_guard$;
+
+DROP TRIGGER IF EXISTS prevent_inventory_ledger_modification ON inventory_ledger;
+CREATE TRIGGER prevent_inventory_ledger_modification
+    BEFORE UPDATE OR DELETE ON inventory_ledger
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_inventory_ledger_modification();
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_inventory_ledger_inventory_id ON inventory_ledger(inventory_id, id);
+CREATE INDEX IF NOT EXISTS idx_inventory_ledger_movement_id ON inventory_ledger(movement_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_ledger_request_id ON inventory_ledger(request_id);
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_inventory_ledger_request_id;
+DROP INDEX IF EXISTS idx_inventory_ledger_movement_id;
+DROP INDEX IF EXISTS idx_inventory_ledger_inventory_id;
+DROP TRIGGER IF EXISTS prevent_inventory_ledger_modification ON inventory_ledger;
+DROP FUNCTION IF EXISTS prevent_inventory_ledger_modification();
+DROP TABLE IF EXISTS inventory_ledger;
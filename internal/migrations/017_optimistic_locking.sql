@@ -0,0 +1,10 @@
+-- +migrate Up
+-- 楽観的排他制御用のバージョン（更新のたびに1ずつ増やし、読み取り時のバージョンと一致する場合のみ更新する）
+ALTER TABLE inventory
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS version;
+ALTER TABLE inventory DROP COLUMN IF EXISTS version;
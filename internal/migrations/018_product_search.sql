@@ -0,0 +1,13 @@
+-- +migrate Up
+-- 商品検索用のトライグラム拡張（漢字・かなを含む部分一致検索と類似度による順位付けに使用する）
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 検索結果のステータス別件数に使用する商品ステータス（既存の商品は販売中とする）
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- 部分一致検索（ILIKE）用のトライグラムインデックス
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING gin (sku gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_category_trgm ON products USING gin (category gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_description_trgm ON products USING gin (description gin_trgm_ops);
@@ -0,0 +1,46 @@
+-- +migrate Up
+-- 商品の荷姿（包装単位）テーブル
+-- 在庫数量は商品の基本単位（グラム）で管理し、荷姿ごとの換算係数で数量を換算する
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    unit VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    sku VARCHAR(100) NOT NULL UNIQUE,
+    conversion_factor INTEGER NOT NULL CHECK (conversion_factor > 0),
+    price DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    weight_g INTEGER NOT NULL DEFAULT 0 CHECK (weight_g >= 0),
+    volume_ml INTEGER NOT NULL DEFAULT 0 CHECK (volume_ml >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, unit)
+);
+
+-- 在庫移動・配送明細の荷姿と荷姿単位の数量（quantityは基本単位の数量）
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS unit VARCHAR(50) NOT NULL DEFAULT 'g',
+    ADD COLUMN IF NOT EXISTS unit_quantity INTEGER;
+ALTER TABLE delivery_items
+    ADD COLUMN IF NOT EXISTS unit VARCHAR(50) NOT NULL DEFAULT 'g',
+    ADD COLUMN IF NOT EXISTS unit_quantity INTEGER;
+
+-- 既存の在庫移動・配送明細は基本単位で指定したものとする
+UPDATE inventory_movements SET unit_quantity = quantity WHERE unit_quantity IS NULL;
+UPDATE delivery_items SET unit_quantity = quantity WHERE unit_quantity IS NULL;
+ALTER TABLE inventory_movements ALTER COLUMN unit_quantity SET NOT NULL;
+ALTER TABLE delivery_items ALTER COLUMN unit_quantity SET NOT NULL;
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_product_variants_updated_at ON product_variants;
+        CREATE TRIGGER update_product_variants_updated_at
+            BEFORE UPDATE ON product_variants
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,7 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_product_variants_product_id;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE delivery_items DROP COLUMN IF EXISTS unit;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS unit;
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,49 @@
+-- +migrate Up
+-- 車両の荷室容積（L、0の場合は容積を確認しない）と冷蔵・冷凍設備の有無
+ALTER TABLE vehicles
+    ADD COLUMN IF NOT EXISTS volume_capacity DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refrigerated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 車両の整備予定テーブル
+CREATE TABLE IF NOT EXISTS vehicle_maintenance (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL REFERENCES vehicles(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+-- 配送スケジュールテーブル（配送への車両・運転手の割当枠）
+CREATE TABLE IF NOT EXISTS delivery_schedules (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL REFERENCES deliveries(id) ON DELETE CASCADE,
+    driver_id INTEGER,
+    vehicle_id INTEGER NOT NULL REFERENCES vehicles(id),
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'scheduled',
+    weight_g INTEGER NOT NULL DEFAULT 0,
+    volume_ml INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_vehicle_maintenance_vehicle_id ON vehicle_maintenance(vehicle_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_delivery_schedules_vehicle_id ON delivery_schedules(vehicle_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_delivery_schedules_delivery_id ON delivery_schedules(delivery_id);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_delivery_schedules_updated_at ON delivery_schedules;
+        CREATE TRIGGER update_delivery_schedules_updated_at
+            BEFORE UPDATE ON delivery_schedules
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_delivery_schedules_delivery_id;
+DROP INDEX IF EXISTS idx_delivery_schedules_vehicle_id;
+DROP INDEX IF EXISTS idx_vehicle_maintenance_vehicle_id;
+DROP TABLE IF EXISTS delivery_schedules;
+DROP TABLE IF EXISTS vehicle_maintenance;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS refrigerated;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS volume_capacity;
@@ -0,0 +1,34 @@
+-- +migrate Up
+-- 運転手テーブル（ロールがdriverのユーザーの運転手情報）
+-- シフトはサーバーのタイムゾーンの時刻で、終了が開始以前の場合は日をまたぐシフトを表す
+CREATE TABLE IF NOT EXISTS drivers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    license_number VARCHAR(50) NOT NULL,
+    license_expiry DATE,
+    phone VARCHAR(50),
+    shift_start TIME NOT NULL DEFAULT '08:00',
+    shift_end TIME NOT NULL DEFAULT '17:00',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 配送スケジュールの運転手への外部キー
+ALTER TABLE delivery_schedules
+    ADD CONSTRAINT fk_delivery_schedules_driver_id FOREIGN KEY (driver_id) REFERENCES drivers(id);
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_delivery_schedules_driver_id ON delivery_schedules(driver_id, start_time);
+
+-- トリガーの作成
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column') THEN
+        DROP TRIGGER IF EXISTS update_drivers_updated_at ON drivers;
+        CREATE TRIGGER update_drivers_updated_at
+            BEFORE UPDATE ON drivers
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END $$;
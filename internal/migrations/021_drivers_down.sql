@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_delivery_schedules_driver_id;
+ALTER TABLE delivery_schedules DROP CONSTRAINT IF EXISTS fk_delivery_schedules_driver_id;
+DROP TABLE IF EXISTS drivers;
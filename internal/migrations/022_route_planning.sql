@@ -0,0 +1,16 @@
+-- +migrate Up
+-- 配送先の位置情報（配送ルートの計画に使用、未設定の場合はルートに含めない）
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS to_latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS to_longitude DOUBLE PRECISION;
+
+-- 配送ルートを車両・日付ごとの訪問順として保持する
+ALTER TABLE routes
+    ADD COLUMN IF NOT EXISTS vehicle_id INTEGER REFERENCES vehicles(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS route_date DATE,
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+-- インデックスの作成
+CREATE INDEX IF NOT EXISTS idx_routes_vehicle_date ON routes(vehicle_id, route_date, sequence);
+CREATE INDEX IF NOT EXISTS idx_routes_delivery_id ON routes(delivery_id);
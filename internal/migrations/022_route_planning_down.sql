@@ -0,0 +1,11 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_routes_delivery_id;
+DROP INDEX IF EXISTS idx_routes_vehicle_date;
+ALTER TABLE routes
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS route_date,
+    DROP COLUMN IF EXISTS vehicle_id;
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS to_longitude,
+    DROP COLUMN IF EXISTS to_latitude;
@@ -0,0 +1,12 @@
+-- +migrate Up
+-- 住所の確認状態（unverified: 未確認, geocoded: 住所から位置を特定, manual: 位置を指定, needs_review: 要確認）
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS address_status VARCHAR(20) NOT NULL DEFAULT 'unverified',
+    ADD COLUMN IF NOT EXISTS address_note TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE warehouses
+    ADD COLUMN IF NOT EXISTS address_status VARCHAR(20) NOT NULL DEFAULT 'unverified',
+    ADD COLUMN IF NOT EXISTS address_note TEXT NOT NULL DEFAULT '';
+
+-- インデックスの作成（要確認の配送の一覧用）
+CREATE INDEX IF NOT EXISTS idx_deliveries_address_review ON deliveries(id) WHERE address_status = 'needs_review';
@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_deliveries_address_review;
+ALTER TABLE warehouses
+    DROP COLUMN IF EXISTS address_note,
+    DROP COLUMN IF EXISTS address_status;
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS address_note,
+    DROP COLUMN IF EXISTS address_status;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- 配送追跡情報の到着予定日時（estimated_time: 最新の予測, notified_estimated_time: 顧客に最後に通知した予測）
+ALTER TABLE tracking_info
+    ADD COLUMN IF NOT EXISTS current_location TEXT,
+    ADD COLUMN IF NOT EXISTS estimated_time TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS notified_estimated_time TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- 追跡イベントの位置（到着予定日時の予測の現在地に使用する）
+ALTER TABLE tracking_events
+    ADD COLUMN IF NOT EXISTS status VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS latitude DECIMAL(10,8) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS longitude DECIMAL(11,8) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS temperature DECIMAL(5,2) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS humidity DECIMAL(5,2) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- インデックスの作成（配送ごとの最新の追跡イベントの取得用）
+CREATE INDEX IF NOT EXISTS idx_tracking_events_tracking_created ON tracking_events(tracking_id, created_at DESC);
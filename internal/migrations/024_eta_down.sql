@@ -0,0 +1,14 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_tracking_events_tracking_created;
+ALTER TABLE tracking_events
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS humidity,
+    DROP COLUMN IF EXISTS temperature,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS status;
+ALTER TABLE tracking_info
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS notified_estimated_time,
+    DROP COLUMN IF EXISTS estimated_time,
+    DROP COLUMN IF EXISTS current_location;
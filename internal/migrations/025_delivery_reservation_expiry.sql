@@ -0,0 +1,5 @@
+-- +migrate Up
+-- 配送の在庫引当は出荷・キャンセルまで保持するため、既存の有効な配送の在庫引当の有効期限を解除する
+UPDATE stock_reservations
+SET expires_at = NULL
+WHERE delivery_id IS NOT NULL AND status = 'active' AND expires_at IS NOT NULL;
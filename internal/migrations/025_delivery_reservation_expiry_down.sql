@@ -0,0 +1,2 @@
+-- +migrate Down
+-- 解除した有効期限は復元しない（配送の在庫引当は有効期限なしのまま扱える）
@@ -0,0 +1,56 @@
+-- +migrate Up
+-- 在庫行の一意キー（商品・ロケーション・ビン・ロット・隔離区分）
+-- 隔離中の在庫行は販売可能な在庫行とは別の在庫行とし、賞味期限切れの在庫行は一意キーの対象としない
+
+-- 同じキーの在庫行が重複している場合は、最も古い在庫行に数量をまとめてから一意インデックスを作成する
+CREATE TEMP TABLE inventory_stock_key_merge ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (
+    SELECT id,
+        MIN(id) OVER (
+            PARTITION BY product_id, location, COALESCE(bin_id, 0), COALESCE(lot_id, 0), (status = 'quarantined')
+        ) AS keep_id
+    FROM inventory
+    WHERE status <> 'expired'
+) keyed
+WHERE id <> keep_id;
+
+-- まとめ先の在庫行への増加とまとめ元の在庫行の削除を在庫元帳に記帳する
+INSERT INTO inventory_ledger (
+    inventory_id, product_id, location, bin_id, lot_id, quantity, balance, entry_type
+)
+SELECT k.id, k.product_id, k.location, k.bin_id, k.lot_id, m.quantity, k.quantity + m.quantity, 'change'
+FROM inventory k
+JOIN (
+    SELECT mg.keep_id, SUM(i.quantity) AS quantity
+    FROM inventory_stock_key_merge mg
+    JOIN inventory i ON i.id = mg.id
+    GROUP BY mg.keep_id
+) m ON m.keep_id = k.id
+WHERE m.quantity <> 0;
+
+INSERT INTO inventory_ledger (
+    inventory_id, product_id, location, bin_id, lot_id, quantity, balance, entry_type
+)
+SELECT i.id, i.product_id, i.location, i.bin_id, i.lot_id, -i.quantity, 0, 'delete'
+FROM inventory i
+JOIN inventory_stock_key_merge mg ON mg.id = i.id
+WHERE i.quantity <> 0;
+
+UPDATE inventory k
+SET quantity = k.quantity + m.quantity, updated_at = CURRENT_TIMESTAMP, version = k.version + 1
+FROM (
+    SELECT mg.keep_id, SUM(i.quantity) AS quantity
+    FROM inventory_stock_key_merge mg
+    JOIN inventory i ON i.id = mg.id
+    GROUP BY mg.keep_id
+) m
+WHERE k.id = m.keep_id AND m.quantity <> 0;
+
+DELETE FROM inventory i
+USING inventory_stock_key_merge mg
+WHERE i.id = mg.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_stock_key ON inventory (
+    product_id, location, COALESCE(bin_id, 0), COALESCE(lot_id, 0), (status = 'quarantined')
+) WHERE status <> 'expired';
@@ -0,0 +1,3 @@
+-- +migrate Down
+-- まとめた在庫行は元に戻さない
+DROP INDEX IF EXISTS idx_inventory_stock_key;
@@ -0,0 +1,27 @@
+-- +migrate Up
+-- 商品検索の比較用の文字列（NFKC正規化と小文字化により全角英数字・半角カナなどの表記ゆれを吸収する）
+-- 検索語もアプリケーション側で同じ正規化を行ってから比較する（normalizeはデータベースのエンコーディングがUTF8の場合だけ使用できる）
+CREATE OR REPLACE FUNCTION product_search_text(value TEXT) RETURNS TEXT AS $$
+    SELECT lower(normalize(COALESCE(value, ''), NFKC))
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+-- 正規化した文字列の部分一致検索（LIKE）用のトライグラム式インデックス（正規化前の列のインデックスと置き換える）
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_sku_trgm;
+DROP INDEX IF EXISTS idx_products_category_trgm;
+DROP INDEX IF EXISTS idx_products_description_trgm;
+CREATE INDEX IF NOT EXISTS idx_products_name_search_trgm ON products USING gin (product_search_text(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_search_trgm ON products USING gin (product_search_text(sku) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_category_search_trgm ON products USING gin (product_search_text(category) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_description_search_trgm ON products USING gin (product_search_text(description) gin_trgm_ops);
+
+-- pg_trgmは文字の種別をデータベースのLC_CTYPEで判定するため、LC_CTYPEがC/POSIXの場合は漢字・かなからトライグラムを作らない
+-- その場合も検索結果は正しいが、漢字・かなの検索語ではインデックスが絞り込みに効かず、類似度による順位付けも行われない
+-- データベースはja_JP.UTF-8やC.UTF-8などUTF-8のLC_CTYPEで作成すること（作成後は変更できない）
+DO $$
+BEGIN
+    IF COALESCE(array_length(show_trgm('抹茶'), 1), 0) = 0 THEN
+        RAISE WARNING 'データベースのLC_CTYPE（%）では漢字・かなのトライグラムが作成されないため、商品検索の漢字・かなの語にインデックスが効きません', current_setting('lc_ctype');
+    END IF;
+END
+$$;
@@ -0,0 +1,10 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_products_description_search_trgm;
+DROP INDEX IF EXISTS idx_products_category_search_trgm;
+DROP INDEX IF EXISTS idx_products_sku_search_trgm;
+DROP INDEX IF EXISTS idx_products_name_search_trgm;
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING gin (sku gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_category_trgm ON products USING gin (category gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_description_trgm ON products USING gin (description gin_trgm_ops);
+DROP FUNCTION IF EXISTS product_search_text(TEXT);
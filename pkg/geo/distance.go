@@ -0,0 +1,33 @@
+package geo
+
+import "math"
+
+/*
+ * 地理計算
+ * 緯度経度による地点間の距離計算を提供する
+ */
+
+// EarthRadiusKm 地球の平均半径（km）
+const EarthRadiusKm = 6371.0
+
+// Point 緯度経度による地点
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DistanceKm 2地点間の大円距離をハーバーサイン公式で算出する（km）
+func DistanceKm(a, b Point) float64 {
+	lat1 := toRadians(a.Latitude)
+	lat2 := toRadians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
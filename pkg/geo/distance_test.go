@@ -0,0 +1,20 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tokyo := Point{Latitude: 35.6812, Longitude: 139.7671}
+	shizuoka := Point{Latitude: 34.9719, Longitude: 138.3890}
+	osaka := Point{Latitude: 34.7025, Longitude: 135.4959}
+
+	assert.InDelta(t, 0, DistanceKm(tokyo, tokyo), 0.0001)
+	// 東京駅〜静岡駅は直線距離で約146km
+	assert.InDelta(t, 146, DistanceKm(tokyo, shizuoka), 3)
+	// 東京駅〜大阪駅は直線距離で約403km
+	assert.InDelta(t, 403, DistanceKm(tokyo, osaka), 5)
+	assert.InDelta(t, DistanceKm(tokyo, osaka), DistanceKm(osaka, tokyo), 0.0001)
+}
@@ -0,0 +1,253 @@
+package geo
+
+import (
+	"context"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ * 郵便番号・地名辞書によるジオコーダ
+ * 同梱した都道府県・主要市区町村の代表地点と郵便番号の上3桁の対応表から位置を求める（ネットワーク不要）
+ */
+
+//go:embed data/*.csv
+var gazetteerData embed.FS
+
+// prefectureEntry 都道府県の代表地点
+type prefectureEntry struct {
+	code           string
+	name           string
+	point          Point
+	municipalities []*municipalityEntry
+}
+
+// municipalityEntry 市区町村の代表地点
+type municipalityEntry struct {
+	prefecture *prefectureEntry
+	name       string
+	point      Point
+}
+
+// postalRange 郵便番号の上3桁の範囲と都道府県の対応
+type postalRange struct {
+	from, to   int
+	prefecture *prefectureEntry
+}
+
+// Gazetteer 同梱の地名辞書によるジオコーダ
+// 位置の精度は市区町村（辞書にある主要な市区町村のみ）または都道府県の代表地点
+type Gazetteer struct {
+	prefectures    []*prefectureEntry
+	municipalities map[string][]*municipalityEntry
+	postal         []postalRange
+}
+
+var _ Geocoder = (*Gazetteer)(nil)
+
+// NewGazetteer 同梱の地名辞書を読み込んでジオコーダを作成する
+func NewGazetteer() (*Gazetteer, error) {
+	g := &Gazetteer{municipalities: make(map[string][]*municipalityEntry)}
+	byCode := make(map[string]*prefectureEntry)
+
+	err := readGazetteerCSV("data/jp_prefectures.csv", func(record []string) error {
+		point, err := parsePoint(record[2], record[3])
+		if err != nil {
+			return err
+		}
+		prefecture := &prefectureEntry{code: record[0], name: record[1], point: point}
+		g.prefectures = append(g.prefectures, prefecture)
+		byCode[prefecture.code] = prefecture
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readGazetteerCSV("data/jp_municipalities.csv", func(record []string) error {
+		prefecture, ok := byCode[record[0]]
+		if !ok {
+			return fmt.Errorf("都道府県コード %s は存在しません", record[0])
+		}
+		point, err := parsePoint(record[2], record[3])
+		if err != nil {
+			return err
+		}
+		municipality := &municipalityEntry{prefecture: prefecture, name: record[1], point: point}
+		prefecture.municipalities = append(prefecture.municipalities, municipality)
+		g.municipalities[municipality.name] = append(g.municipalities[municipality.name], municipality)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readGazetteerCSV("data/jp_postal_prefixes.csv", func(record []string) error {
+		from, err := strconv.Atoi(record[0])
+		if err != nil {
+			return err
+		}
+		to, err := strconv.Atoi(record[1])
+		if err != nil {
+			return err
+		}
+		prefecture, ok := byCode[record[2]]
+		if !ok {
+			return fmt.Errorf("都道府県コード %s は存在しません", record[2])
+		}
+		g.postal = append(g.postal, postalRange{from: from, to: to, prefecture: prefecture})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Geocode 住所を正規化し、都道府県・市区町村を特定して代表地点を返す
+//
+// 都道府県は住所の先頭の都道府県名、辞書にある市区町村名、郵便番号の上3桁の順で特定し、
+// 住所に都道府県名がない場合は正規化した住所に補う。郵便番号と住所の都道府県が一致しない場合、
+// 都道府県を特定できない場合、市区町村以下の住所がない場合は*AddressErrorを返す
+func (g *Gazetteer) Geocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	normalized := NormalizeAddress(address)
+	if normalized == "" {
+		return nil, &AddressError{Address: address, Reason: "住所が空です"}
+	}
+	postalCode, rest := SplitPostalCode(normalized)
+
+	prefecture := g.matchPrefecture(rest)
+	if prefecture != nil {
+		rest = strings.TrimSpace(rest[len(prefecture.name):])
+	} else if municipality := g.matchUniqueMunicipality(rest); municipality != nil {
+		prefecture = municipality.prefecture
+	}
+
+	if postalCode != "" {
+		postalPrefecture := g.postalPrefecture(postalCode)
+		if postalPrefecture == nil {
+			return nil, &AddressError{Address: address, Reason: fmt.Sprintf("郵便番号 %s に該当する地域がありません", postalCode)}
+		}
+		if prefecture == nil {
+			prefecture = postalPrefecture
+		} else if prefecture != postalPrefecture {
+			return nil, &AddressError{
+				Address: address,
+				Reason:  fmt.Sprintf("郵便番号 %s（%s）と都道府県（%s）が一致しません", postalCode, postalPrefecture.name, prefecture.name),
+			}
+		}
+	}
+	if prefecture == nil {
+		return nil, &AddressError{Address: address, Reason: "都道府県を特定できません"}
+	}
+	if rest == "" {
+		return nil, &AddressError{Address: address, Reason: "市区町村以下の住所がありません"}
+	}
+
+	result := &GeocodeResult{
+		Address:    prefecture.name + rest,
+		PostalCode: postalCode,
+		Prefecture: prefecture.name,
+		Point:      prefecture.point,
+		Precision:  PrecisionPrefecture,
+	}
+	if postalCode != "" {
+		result.Address = "〒" + postalCode + " " + result.Address
+	}
+	if municipality := prefecture.matchMunicipality(rest); municipality != nil {
+		result.Municipality = municipality.name
+		result.Point = municipality.point
+		result.Precision = PrecisionMunicipality
+	}
+
+	return result, nil
+}
+
+// matchPrefecture 住所の先頭の都道府県名に該当する都道府県を返す
+func (g *Gazetteer) matchPrefecture(address string) *prefectureEntry {
+	for _, prefecture := range g.prefectures {
+		if strings.HasPrefix(address, prefecture.name) {
+			return prefecture
+		}
+	}
+	return nil
+}
+
+// matchUniqueMunicipality 住所の先頭の市区町村名に該当する市区町村を返す
+// 同名の市区町村が複数の都道府県にある場合はnilを返す
+func (g *Gazetteer) matchUniqueMunicipality(address string) *municipalityEntry {
+	var matched *municipalityEntry
+	for name, municipalities := range g.municipalities {
+		if len(municipalities) != 1 || !strings.HasPrefix(address, name) {
+			continue
+		}
+		if matched == nil || len(name) > len(matched.name) {
+			matched = municipalities[0]
+		}
+	}
+	return matched
+}
+
+// matchMunicipality 都道府県名を除いた住所の先頭の市区町村名に該当する市区町村を返す（最長一致）
+func (p *prefectureEntry) matchMunicipality(address string) *municipalityEntry {
+	var matched *municipalityEntry
+	for _, municipality := range p.municipalities {
+		if strings.HasPrefix(address, municipality.name) && (matched == nil || len(municipality.name) > len(matched.name)) {
+			matched = municipality
+		}
+	}
+	return matched
+}
+
+// postalPrefecture 郵便番号（NNN-NNNN形式）の上3桁に該当する都道府県を返す
+func (g *Gazetteer) postalPrefecture(postalCode string) *prefectureEntry {
+	prefix, err := strconv.Atoi(postalCode[:3])
+	if err != nil {
+		return nil
+	}
+	for _, r := range g.postal {
+		if r.from <= prefix && prefix <= r.to {
+			return r.prefecture
+		}
+	}
+	return nil
+}
+
+// readGazetteerCSV 同梱のCSVファイルをヘッダー行を除いて1行ずつ読み込む
+func readGazetteerCSV(name string, fn func(record []string) error) error {
+	file, err := gazetteerData.Open(name)
+	if err != nil {
+		return fmt.Errorf("地名辞書読み込みエラー: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return fmt.Errorf("地名辞書読み込みエラー(%s): %v", name, err)
+	}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if err := fn(record); err != nil {
+			return fmt.Errorf("地名辞書読み込みエラー(%s:%d): %v", name, i+1, err)
+		}
+	}
+	return nil
+}
+
+func parsePoint(latitude, longitude string) (Point, error) {
+	lat, err := strconv.ParseFloat(latitude, 64)
+	if err != nil {
+		return Point{}, err
+	}
+	lon, err := strconv.ParseFloat(longitude, 64)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{Latitude: lat, Longitude: lon}, nil
+}
@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	assert.Equal(t, "〒420-8601 静岡県静岡市葵区追手町9-6", NormalizeAddress("〒４２０－８６０１　静岡県静岡市葵区追手町９ー６"))
+	// 数字の間以外の長音符は変換しない
+	assert.Equal(t, "東京都千代田区丸の内1-9-1 グラントウキョウノースタワー", NormalizeAddress("  東京都千代田区丸の内１‐９―１  グラントウキョウノースタワー "))
+	assert.Equal(t, "", NormalizeAddress("　"))
+}
+
+func TestSplitPostalCode(t *testing.T) {
+	code, rest := SplitPostalCode("〒420-8601 静岡県静岡市")
+	assert.Equal(t, "420-8601", code)
+	assert.Equal(t, "静岡県静岡市", rest)
+
+	code, rest = SplitPostalCode("4208601 静岡県静岡市")
+	assert.Equal(t, "420-8601", code)
+	assert.Equal(t, "静岡県静岡市", rest)
+
+	code, rest = SplitPostalCode("静岡県静岡市")
+	assert.Equal(t, "", code)
+	assert.Equal(t, "静岡県静岡市", rest)
+}
+
+func TestGazetteer_Geocode(t *testing.T) {
+	ctx := context.Background()
+	gazetteer, err := NewGazetteer()
+	require.NoError(t, err)
+
+	t.Run("郵便番号と市区町村から代表地点を求める", func(t *testing.T) {
+		result, err := gazetteer.Geocode(ctx, "〒４２０－８６０１　静岡県静岡市葵区追手町９ー６")
+		require.NoError(t, err)
+		assert.Equal(t, "〒420-8601 静岡県静岡市葵区追手町9-6", result.Address)
+		assert.Equal(t, "420-8601", result.PostalCode)
+		assert.Equal(t, "静岡県", result.Prefecture)
+		assert.Equal(t, "静岡市", result.Municipality)
+		assert.Equal(t, PrecisionMunicipality, result.Precision)
+		assert.InDelta(t, 34.97, result.Point.Latitude, 0.05)
+		assert.InDelta(t, 138.38, result.Point.Longitude, 0.05)
+	})
+
+	t.Run("辞書にない市区町村は都道府県の代表地点とする", func(t *testing.T) {
+		result, err := gazetteer.Geocode(ctx, "静岡県菊川市堀之内61")
+		require.NoError(t, err)
+		assert.Equal(t, "静岡県菊川市堀之内61", result.Address)
+		assert.Empty(t, result.Municipality)
+		assert.Equal(t, PrecisionPrefecture, result.Precision)
+	})
+
+	t.Run("都道府県名を補う", func(t *testing.T) {
+		// 郵便番号から都道府県を特定する
+		result, err := gazetteer.Geocode(ctx, "530-0001 北区梅田3-1-1")
+		require.NoError(t, err)
+		assert.Equal(t, "〒530-0001 大阪府北区梅田3-1-1", result.Address)
+		assert.Equal(t, "大阪府", result.Prefecture)
+
+		// 同名のない市区町村名から都道府県を特定する
+		result, err = gazetteer.Geocode(ctx, "浜松市中央区元城町103-2")
+		require.NoError(t, err)
+		assert.Equal(t, "静岡県浜松市中央区元城町103-2", result.Address)
+		assert.Equal(t, "浜松市", result.Municipality)
+	})
+
+	t.Run("解釈できない住所はAddressErrorを返す", func(t *testing.T) {
+		for _, address := range []string{
+			"",
+			"梅田3-1-1",
+			"〒420-8601 大阪府大阪市北区梅田3-1-1",
+			"〒000-0000 東京都新宿区西新宿2-8-1",
+			"静岡県",
+		} {
+			_, err := gazetteer.Geocode(ctx, address)
+			var addressErr *AddressError
+			assert.ErrorAs(t, err, &addressErr, address)
+		}
+	})
+}
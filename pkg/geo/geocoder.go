@@ -0,0 +1,88 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+/*
+ * ジオコーディング
+ * 住所の正規化と、住所から位置を求めるジオコーダのインターフェースを定義する
+ */
+
+// Precision ジオコーディング結果の位置の精度
+type Precision string
+
+const (
+	// PrecisionMunicipality 市区町村の代表地点
+	PrecisionMunicipality Precision = "municipality"
+	// PrecisionPrefecture 都道府県の代表地点（都道府県庁所在地）
+	PrecisionPrefecture Precision = "prefecture"
+)
+
+// Geocoder 住所から位置を求める
+// 外部のジオコーディングサービスなどに差し替えられる
+type Geocoder interface {
+	// Geocode 住所を正規化して位置を求める
+	// 住所を解釈できない場合は*AddressErrorを返す
+	Geocode(ctx context.Context, address string) (*GeocodeResult, error)
+}
+
+// GeocodeResult ジオコーディング結果
+type GeocodeResult struct {
+	// Address 正規化した住所（郵便番号がある場合は「〒NNN-NNNN 」で始まる）
+	Address      string    `json:"address"`
+	PostalCode   string    `json:"postal_code,omitempty"`
+	Prefecture   string    `json:"prefecture"`
+	Municipality string    `json:"municipality,omitempty"`
+	Point        Point     `json:"point"`
+	Precision    Precision `json:"precision"`
+}
+
+// AddressError 住所を解釈できない場合のエラー
+type AddressError struct {
+	Address string
+	Reason  string
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("住所を解釈できません（%s）: %s", e.Reason, e.Address)
+}
+
+// postalCodePattern 住所の先頭の郵便番号（〒・ハイフンは省略可）
+var postalCodePattern = regexp.MustCompile(`^〒?\s*(\d{3})-?(\d{4})(?:\s+|$)`)
+
+// NormalizeAddress 住所の表記を正規化する
+// 全角英数字・記号を半角に、数字の間の長音符・ダッシュ類をハイフンに統一し、連続する空白を1つにまとめる
+func NormalizeAddress(address string) string {
+	runes := []rune(norm.NFKC.String(address))
+	for i, r := range runes {
+		if isDash(r) && i > 0 && i < len(runes)-1 && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+			runes[i] = '-'
+		}
+	}
+	return strings.Join(strings.Fields(string(runes)), " ")
+}
+
+// SplitPostalCode 正規化した住所の先頭の郵便番号（NNN-NNNN形式）と残りの住所を返す
+// 郵便番号がない場合は空文字列と住所をそのまま返す
+func SplitPostalCode(address string) (string, string) {
+	match := postalCodePattern.FindStringSubmatch(address)
+	if match == nil {
+		return "", address
+	}
+	return match[1] + "-" + match[2], address[len(match[0]):]
+}
+
+func isDash(r rune) bool {
+	switch r {
+	case '-', '‐', '‑', '‒', '–', '—', '―', '−', 'ー', '－':
+		return true
+	}
+	return false
+}
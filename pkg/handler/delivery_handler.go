@@ -60,7 +60,7 @@ func (h *DeliveryHandler) CreateDelivery(c *gin.Context) {
 
 // ListDeliveries 配送一覧を取得する
 func (h *DeliveryHandler) ListDeliveries(c *gin.Context) {
-	deliveries, err := h.service.ListDeliveries(c.Request.Context())
+	deliveries, _, err := h.service.ListDeliveries(c.Request.Context(), nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -94,15 +94,13 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Status string `json:"status" binding:"required"`
-	}
+	var req models.UpdateDeliveryStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
 		return
 	}
 
-	if err := h.service.UpdateDeliveryStatus(c.Request.Context(), id, req.Status); err != nil {
+	if _, err := h.service.UpdateDeliveryStatus(c.Request.Context(), id, &req, currentUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -118,7 +116,7 @@ func (h *DeliveryHandler) CompleteDelivery(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CompleteDelivery(c.Request.Context(), id); err != nil {
+	if err := h.service.CompleteDelivery(c.Request.Context(), id, currentUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -166,3 +164,11 @@ func (h *DeliveryHandler) ListDeliveryTrackings(c *gin.Context) {
 
 	c.JSON(http.StatusOK, trackings)
 }
+
+// currentUserID 認証済みユーザーのIDを取得する
+// 未認証の場合は0を返す
+func currentUserID(c *gin.Context) int64 {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(int64)
+	return id
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
 	"tea-logistics/pkg/services"
 	"tea-logistics/pkg/services/mocks"
 
@@ -22,27 +23,46 @@ import (
  * 配送関連のHTTP APIエンドポイントのテストを実装する
  */
 
-func setupDeliveryTest() (*gin.Engine, *mocks.MockDeliveryRepository, *mocks.MockInventoryRepository, services.NotificationService) {
+func setupDeliveryTest() (*gin.Engine, *mocks.MockDeliveryRepository, *mocks.MockInventoryRepository, *mocks.MockReservationRepository, services.NotificationService) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
 	mockDeliveryRepo := new(mocks.MockDeliveryRepository)
 	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	mockWarehouseRepo := &mocks.MockWarehouseRepository{}
+	mockWarehouseRepo.On("GetWarehouse", mock.Anything, int64(1)).Return(&models.Warehouse{ID: 1, Name: "東京倉庫"}, nil)
+	mockReservationRepo := new(mocks.MockReservationRepository)
+	mockReservationRepo.On("LockStock", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	mockAllocationRepo.On("GetPolicyForProduct", mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound).Maybe()
+	mockAllocationRepo.On("CreateDeliveryAllocation", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockAllocationRepo.On("ListDeliveryAllocations", mock.Anything, mock.Anything).Return([]*models.DeliveryAllocation{}, nil).Maybe()
+	mockStockCountRepo := new(mocks.MockStockCountRepository)
+	mockStockCountRepo.On("GetActiveStockCount", mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound).Maybe()
 	mockNotifyService := new(mocks.MockNotificationService)
-	service := services.NewDeliveryService(mockDeliveryRepo, mockInventoryRepo, mockNotifyService)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockDeliveryRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Allocations:  mockAllocationRepo,
+		StockCounts:  mockStockCountRepo,
+	})
+	service := services.NewDeliveryService(mockDeliveryRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
 	handler := NewDeliveryHandler(service)
 	handler.RegisterRoutes(router)
 
-	return router, mockDeliveryRepo, mockInventoryRepo, mockNotifyService
+	return router, mockDeliveryRepo, mockInventoryRepo, mockReservationRepo, mockNotifyService
 }
 
 func TestCreateDelivery(t *testing.T) {
-	router, mockDeliveryRepo, mockInventoryRepo, mockNotifyService := setupDeliveryTest()
+	router, mockDeliveryRepo, mockInventoryRepo, mockReservationRepo, mockNotifyService := setupDeliveryTest()
 
 	req := &models.CreateDeliveryRequest{
-		OrderID:         1,
-		ProductID:       1,
-		Quantity:        10,
+		OrderID: 1,
+		Items: []models.CreateDeliveryItemRequest{
+			{ProductID: 1, Quantity: 10},
+		},
 		FromWarehouseID: 1,
 		ToAddress:       "東京都渋谷区",
 		EstimatedTime:   time.Now().Add(24 * time.Hour),
@@ -57,10 +77,11 @@ func TestCreateDelivery(t *testing.T) {
 		Status:    models.InventoryStatusAvailable,
 	}
 
-	mockInventoryRepo.On("GetInventory", mock.Anything, int64(1)).Return(inventory, nil)
-	mockInventoryRepo.On("UpdateInventory", mock.Anything, mock.AnythingOfType("*models.Inventory")).Return(nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", mock.Anything, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
 	mockDeliveryRepo.On("CreateDelivery", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
 	mockDeliveryRepo.On("CreateDeliveryItem", mock.Anything, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	mockReservationRepo.On("SumActiveReserved", mock.Anything, int64(1), "東京倉庫").Return(0, nil)
+	mockReservationRepo.On("CreateReservation", mock.Anything, mock.AnythingOfType("*models.Reservation")).Return(nil)
 	mockNotifyService.(*mocks.MockNotificationService).On("NotifyDeliveryStatusChange", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
 	body, _ := json.Marshal(req)
@@ -72,11 +93,12 @@ func TestCreateDelivery(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, w.Code)
 	mockDeliveryRepo.AssertExpectations(t)
 	mockInventoryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
 	mockNotifyService.(*mocks.MockNotificationService).AssertExpectations(t)
 }
 
 func TestGetDelivery(t *testing.T) {
-	router, mockDeliveryRepo, _, _ := setupDeliveryTest()
+	router, mockDeliveryRepo, _, _, _ := setupDeliveryTest()
 
 	expectedDelivery := &models.Delivery{
 		ID:              1,
@@ -89,7 +111,13 @@ func TestGetDelivery(t *testing.T) {
 		UpdatedAt:       time.Now(),
 	}
 
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, Status: models.DeliveryItemStatusActive},
+		{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 5, Status: models.DeliveryItemStatusActive},
+	}
+
 	mockDeliveryRepo.On("GetDelivery", mock.Anything, int64(1)).Return(expectedDelivery, nil)
+	mockDeliveryRepo.On("ListDeliveryItems", mock.Anything, int64(1)).Return(items, nil)
 
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/api/deliveries/1", nil)
@@ -102,17 +130,18 @@ func TestGetDelivery(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedDelivery.ID, response.ID)
 	assert.Equal(t, expectedDelivery.Status, response.Status)
+	assert.Len(t, response.Items, 2)
 
 	mockDeliveryRepo.AssertExpectations(t)
 }
 
 func TestUpdateDeliveryStatus(t *testing.T) {
-	router, mockDeliveryRepo, _, mockNotifyService := setupDeliveryTest()
+	router, mockDeliveryRepo, _, mockReservationRepo, mockNotifyService := setupDeliveryTest()
 
 	delivery := &models.Delivery{
 		ID:              1,
 		OrderID:         1,
-		Status:          "pending",
+		Status:          "scheduled",
 		FromWarehouseID: 1,
 		ToAddress:       "東京都渋谷区",
 		EstimatedTime:   time.Now().Add(24 * time.Hour),
@@ -122,6 +151,8 @@ func TestUpdateDeliveryStatus(t *testing.T) {
 
 	mockDeliveryRepo.On("GetDelivery", mock.Anything, int64(1)).Return(delivery, nil)
 	mockDeliveryRepo.On("UpdateDelivery", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockReservationRepo.On("ListReservationsByDelivery", mock.Anything, int64(1)).Return([]*models.Reservation{}, nil)
+	mockDeliveryRepo.On("CreateStatusHistory", mock.Anything, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
 	mockNotifyService.(*mocks.MockNotificationService).On("NotifyDeliveryStatusChange", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
 	req := struct {
@@ -138,11 +169,12 @@ func TestUpdateDeliveryStatus(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockDeliveryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
 	mockNotifyService.(*mocks.MockNotificationService).AssertExpectations(t)
 }
 
 func TestCompleteDelivery(t *testing.T) {
-	router, mockDeliveryRepo, mockInventoryRepo, mockNotifyService := setupDeliveryTest()
+	router, mockDeliveryRepo, mockInventoryRepo, mockReservationRepo, mockNotifyService := setupDeliveryTest()
 
 	delivery := &models.Delivery{
 		ID:              1,
@@ -155,12 +187,15 @@ func TestCompleteDelivery(t *testing.T) {
 		UpdatedAt:       time.Now(),
 	}
 
-	items := []*models.DeliveryItem{
+	deliveryID := int64(1)
+	reservations := []*models.Reservation{
 		{
 			ID:         1,
-			DeliveryID: 1,
 			ProductID:  1,
+			Location:   "東京倉庫",
 			Quantity:   10,
+			DeliveryID: &deliveryID,
+			Status:     models.ReservationStatusActive,
 		},
 	}
 
@@ -173,10 +208,13 @@ func TestCompleteDelivery(t *testing.T) {
 	}
 
 	mockDeliveryRepo.On("GetDelivery", mock.Anything, int64(1)).Return(delivery, nil)
-	mockDeliveryRepo.On("ListDeliveryItems", mock.Anything, int64(1)).Return(items, nil)
-	mockInventoryRepo.On("GetInventory", mock.Anything, int64(1)).Return(inventory, nil)
-	mockInventoryRepo.On("UpdateInventory", mock.Anything, mock.AnythingOfType("*models.Inventory")).Return(nil)
+	mockReservationRepo.On("ListReservationsByDelivery", mock.Anything, int64(1)).Return(reservations, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", mock.Anything, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	mockInventoryRepo.On("CreateMovement", mock.Anything, mock.AnythingOfType("*models.InventoryMovement")).Return(nil)
+	mockInventoryRepo.On("UpdateQuantity", mock.Anything, int64(1), 90, 0).Return(nil)
+	mockReservationRepo.On("UpdateReservationStatus", mock.Anything, int64(1), models.ReservationStatusCommitted).Return(nil)
 	mockDeliveryRepo.On("UpdateDelivery", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockDeliveryRepo.On("CreateStatusHistory", mock.Anything, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
 	mockNotifyService.(*mocks.MockNotificationService).On("NotifyDeliveryComplete", mock.Anything, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
 	w := httptest.NewRecorder()
@@ -186,11 +224,12 @@ func TestCompleteDelivery(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockDeliveryRepo.AssertExpectations(t)
 	mockInventoryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
 	mockNotifyService.(*mocks.MockNotificationService).AssertExpectations(t)
 }
 
 func TestCreateDeliveryTracking(t *testing.T) {
-	router, mockDeliveryRepo, _, mockNotifyService := setupDeliveryTest()
+	router, mockDeliveryRepo, _, _, mockNotifyService := setupDeliveryTest()
 
 	req := &models.CreateTrackingRequest{
 		DeliveryID: 1,
@@ -214,7 +253,7 @@ func TestCreateDeliveryTracking(t *testing.T) {
 }
 
 func TestListDeliveryTrackings(t *testing.T) {
-	router, mockDeliveryRepo, _, _ := setupDeliveryTest()
+	router, mockDeliveryRepo, _, _, _ := setupDeliveryTest()
 
 	expectedTrackings := []*models.DeliveryTracking{
 		{
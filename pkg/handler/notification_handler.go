@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
 
 	"github.com/gorilla/mux"
 )
@@ -19,7 +20,7 @@ import (
 type NotificationService interface {
 	CreateNotification(ctx context.Context, req *models.CreateNotificationRequest) (*models.Notification, error)
 	GetNotification(ctx context.Context, id int64) (*models.Notification, error)
-	ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error)
+	ListNotifications(ctx context.Context, userID int64, spec *repository.QuerySpec) ([]*models.Notification, *repository.PageInfo, error)
 	MarkAsRead(ctx context.Context, id int64) error
 	DeleteNotification(ctx context.Context, id int64) error
 }
@@ -92,7 +93,7 @@ func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	notifications, err := h.service.ListNotifications(r.Context(), userID)
+	notifications, _, err := h.service.ListNotifications(r.Context(), userID, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -110,7 +110,7 @@ func TestListNotifications(t *testing.T) {
 		},
 	}
 
-	mockService.On("ListNotifications", mock.Anything, int64(1)).Return(notifications, nil)
+	mockService.On("ListNotifications", mock.Anything, int64(1), mock.Anything).Return(notifications, nil, nil)
 
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest("GET", "/notifications/user/1", nil)
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫割当ハンドラ
+ * 商品カテゴリごとの在庫割当方式のHTTPリクエストを処理する
+ */
+
+// AllocationHandler 在庫割当ハンドラ
+type AllocationHandler struct {
+	service *services.AllocationService
+}
+
+// NewAllocationHandler 在庫割当ハンドラを作成する
+func NewAllocationHandler(service *services.AllocationService) *AllocationHandler {
+	return &AllocationHandler{service: service}
+}
+
+// ListPolicies 割当方式一覧取得
+func (h *AllocationHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_strategy": services.DefaultAllocationStrategy,
+		"policies":         policies,
+	})
+}
+
+// SetPolicy 割当方式設定
+func (h *AllocationHandler) SetPolicy(c *gin.Context) {
+	var req models.SetAllocationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	policy, err := h.service.SetPolicy(c.Request.Context(), c.Param("category"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy 割当方式削除
+func (h *AllocationHandler) DeletePolicy(c *gin.Context) {
+	if err := h.service.DeletePolicy(c.Request.Context(), c.Param("category")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAllocationPolicyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "割当方式を削除しました"})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+	"tea-logistics/pkg/tabular"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 一括取込・出力ハンドラ
+ * 商品・在庫数・保管場所のCSV/Excelファイルの取込と出力のHTTPリクエストを処理する
+ */
+
+// maxImportFileSize 取込ファイルのサイズ上限（バイト）
+const maxImportFileSize = 10 << 20
+
+// BulkHandler 一括取込・出力ハンドラ
+type BulkHandler struct {
+	service *services.BulkService
+}
+
+// NewBulkHandler 一括取込・出力ハンドラを作成する
+func NewBulkHandler(service *services.BulkService) *BulkHandler {
+	return &BulkHandler{service: service}
+}
+
+// importFunc 取込処理
+type importFunc func(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error)
+
+// exportFunc 出力処理
+type exportFunc func(ctx context.Context) ([][]string, error)
+
+// ImportProducts 商品一括取込
+func (h *BulkHandler) ImportProducts(c *gin.Context) {
+	h.handleImport(c, h.service.ImportProducts)
+}
+
+// ImportInventory 在庫数一括取込
+func (h *BulkHandler) ImportInventory(c *gin.Context) {
+	h.handleImport(c, h.service.ImportInventory)
+}
+
+// ImportLocations 保管場所一括取込
+func (h *BulkHandler) ImportLocations(c *gin.Context) {
+	h.handleImport(c, h.service.ImportLocations)
+}
+
+// ExportProducts 商品一覧出力
+func (h *BulkHandler) ExportProducts(c *gin.Context) {
+	h.handleExport(c, "products", h.service.ExportProducts)
+}
+
+// ExportInventory 在庫一覧出力
+func (h *BulkHandler) ExportInventory(c *gin.Context) {
+	h.handleExport(c, "inventory", h.service.ExportInventory)
+}
+
+// ExportLocations 保管場所一覧出力
+func (h *BulkHandler) ExportLocations(c *gin.Context) {
+	h.handleExport(c, "locations", h.service.ExportLocations)
+}
+
+// handleImport multipartの「file」で受け取ったファイルを取込む
+// 形式はformatクエリ、省略時はファイルの拡張子で判定する。dry_run=trueの場合は検証のみ行う
+// 取込んだ場合は201、検証のみの場合は200、エラーのある行があり取込まなかった場合は422を返す
+func (h *BulkHandler) handleImport(c *gin.Context, importRows importFunc) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "取込ファイルを指定してください"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "取込ファイルが大きすぎます"})
+		return
+	}
+
+	var format tabular.Format
+	if value := c.Query("format"); value != "" {
+		format, err = tabular.ParseFormat(value)
+	} else {
+		format, err = tabular.FormatFromFilename(fileHeader.Filename)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "取込ファイルを開けません"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := tabular.Read(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := importRows(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		c.JSON(inventoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case result.Committed:
+		c.JSON(http.StatusCreated, result)
+	case result.HasErrors() && !dryRun:
+		c.JSON(http.StatusUnprocessableEntity, result)
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// handleExport 一覧をformatクエリの形式（省略時はcsv）のファイルとして出力する
+func (h *BulkHandler) handleExport(c *gin.Context, name string, exportRows exportFunc) {
+	format, err := tabular.ParseFormat(c.DefaultQuery("format", string(tabular.FormatCSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := exportRows(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if err := tabular.Write(c.Writer, format, name, rows); err != nil {
+		c.Error(err)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -35,7 +36,7 @@ func (h *DeliveryHandler) CreateDelivery(c *gin.Context) {
 
 	delivery, err := h.service.CreateDelivery(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -44,13 +45,19 @@ func (h *DeliveryHandler) CreateDelivery(c *gin.Context) {
 
 // ListDeliveries 配送一覧を取得する
 func (h *DeliveryHandler) ListDeliveries(c *gin.Context) {
-	deliveries, err := h.service.ListDeliveries(c.Request.Context())
+	spec, err := parseQuerySpec(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, deliveries)
+	deliveries, page, err := h.service.ListDeliveries(c.Request.Context(), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, deliveries, page)
 }
 
 // GetDelivery 配送を取得する
@@ -67,6 +74,7 @@ func (h *DeliveryHandler) GetDelivery(c *gin.Context) {
 		return
 	}
 
+	setETag(c, delivery.Version)
 	c.JSON(http.StatusOK, delivery)
 }
 
@@ -78,22 +86,60 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Status string `json:"status" binding:"required"`
-	}
+	var req models.UpdateDeliveryStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
 		return
 	}
 
-	if err := h.service.UpdateDeliveryStatus(c.Request.Context(), id, req.Status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// If-Matchで取得時のETagを指定した場合は、その後に更新されていれば409を返す
+	req.ExpectedVersion, err = parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	delivery, err := h.service.UpdateDeliveryStatus(c.Request.Context(), id, &req, currentUserID(c))
+	if err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, delivery.Version)
 	c.Status(http.StatusOK)
 }
 
+// UpdateDeliveryAddress 配送先住所を修正する
+func (h *DeliveryHandler) UpdateDeliveryAddress(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.UpdateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	// If-Matchで取得時のETagを指定した場合は、その後に更新されていれば409を返す
+	req.ExpectedVersion, err = parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delivery, err := h.service.UpdateDeliveryAddress(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, delivery)
+}
+
 // CompleteDelivery 配送を完了する
 func (h *DeliveryHandler) CompleteDelivery(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -102,14 +148,153 @@ func (h *DeliveryHandler) CompleteDelivery(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CompleteDelivery(c.Request.Context(), id); err != nil {
+	if err := h.service.CompleteDelivery(c.Request.Context(), id, currentUserID(c)); err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListStatusHistory 配送ステータス変更履歴を取得する
+func (h *DeliveryHandler) ListStatusHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	histories, err := h.service.ListStatusHistory(c.Request.Context(), id)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, histories)
+}
+
+// ListDeliveryAllocations 配送の在庫割当結果を取得する
+func (h *DeliveryHandler) ListDeliveryAllocations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	allocations, err := h.service.ListDeliveryAllocations(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, allocations)
+}
+
+// GetShipment 配送の出荷重量・容積を取得する
+func (h *DeliveryHandler) GetShipment(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	shipment, err := h.service.GetShipment(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// CancelDeliveryItem 配送明細をキャンセルする
+func (h *DeliveryHandler) CancelDeliveryItem(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な明細ID形式です"})
+		return
+	}
+
+	// ボディ省略時は残数量をすべてキャンセルする
+	var req models.CancelDeliveryItemRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+			return
+		}
+	}
+
+	// If-Matchで取得時のETagを指定した場合は、その後に配送が更新されていれば409を返す
+	req.ExpectedVersion, err = parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delivery, item, err := h.service.CancelDeliveryItem(c.Request.Context(), id, itemID, &req)
+	if err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, delivery.Version)
+	c.JSON(http.StatusOK, item)
+}
+
+// CancelDelivery 出荷前の配送をキャンセルする
+func (h *DeliveryHandler) CancelDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	// ボディ（キャンセル理由）は省略可能
+	var req models.CancelDeliveryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+			return
+		}
+	}
+
+	if err := h.service.CancelDelivery(c.Request.Context(), id, &req, currentUserID(c)); err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
+// ReturnDelivery 配送の返品を登録する
+func (h *DeliveryHandler) ReturnDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.ReturnDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	result, err := h.service.ReturnDelivery(c.Request.Context(), id, &req, currentUserID(c))
+	if err != nil {
+		c.JSON(deliveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
 // CreateDeliveryTracking 配送追跡を作成する
 func (h *DeliveryHandler) CreateDeliveryTracking(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -150,3 +335,52 @@ func (h *DeliveryHandler) ListDeliveryTrackings(c *gin.Context) {
 
 	c.JSON(http.StatusOK, trackings)
 }
+
+// currentUserID 認証済みユーザーのIDを取得する
+// 未認証の場合は0を返す
+func currentUserID(c *gin.Context) int64 {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(int64)
+	return id
+}
+
+// deliveryErrorStatus サービスエラーに対応するHTTPステータスを返す
+func deliveryErrorStatus(err error) int {
+	var validationErr *models.DeliveryValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest
+	}
+	var lotErr *models.InvalidLotError
+	if errors.As(err, &lotErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var stockErr *models.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return http.StatusConflict
+	}
+	var unitErr *models.UnknownUnitError
+	if errors.As(err, &unitErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var quantityErr *models.CancelQuantityError
+	if errors.As(err, &quantityErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var transitionErr *models.StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		return http.StatusConflict
+	}
+	var cancelStatusErr *models.DeliveryItemCancelStatusError
+	if errors.As(err, &cancelStatusErr) {
+		return http.StatusConflict
+	}
+	var frozenErr *models.LocationFrozenError
+	if errors.As(err, &frozenErr) {
+		return http.StatusConflict
+	}
+	var conflictErr *models.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
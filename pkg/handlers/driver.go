@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 運転手ハンドラ
+ * 運転手の登録・更新と配送予定表のHTTPリクエストを処理する
+ */
+
+// DriverHandler 運転手ハンドラ
+type DriverHandler struct {
+	service           *services.DriverService
+	schedulingService *services.SchedulingService
+}
+
+// NewDriverHandler 運転手ハンドラを作成する
+func NewDriverHandler(service *services.DriverService, schedulingService *services.SchedulingService) *DriverHandler {
+	return &DriverHandler{service: service, schedulingService: schedulingService}
+}
+
+// CreateDriver 運転手登録
+func (h *DriverHandler) CreateDriver(c *gin.Context) {
+	var req models.DriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	driver, err := h.service.CreateDriver(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, driver)
+}
+
+// ListDrivers 運転手一覧取得
+func (h *DriverHandler) ListDrivers(c *gin.Context) {
+	spec, err := parseQuerySpec(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	drivers, page, err := h.service.ListDrivers(c.Request.Context(), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, drivers, page)
+}
+
+// GetDriver 運転手取得
+func (h *DriverHandler) GetDriver(c *gin.Context) {
+	id, ok := driverID(c)
+	if !ok {
+		return
+	}
+
+	driver, err := h.service.GetDriver(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, driver)
+}
+
+// UpdateDriver 運転手更新
+func (h *DriverHandler) UpdateDriver(c *gin.Context) {
+	id, ok := driverID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	driver, err := h.service.UpdateDriver(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, driver)
+}
+
+// GetManifest 運転手の配送予定表取得
+// dateはYYYY-MM-DD形式（省略した場合は当日）で、ロールがdriverのユーザーは自分の配送予定表だけを取得できる
+func (h *DriverHandler) GetManifest(c *gin.Context) {
+	id, ok := driverID(c)
+	if !ok {
+		return
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dateはYYYY-MM-DD形式で指定してください"})
+			return
+		}
+		date = parsed
+	}
+
+	manifest, err := h.schedulingService.GetManifest(c.Request.Context(), id, date)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if role, _ := c.Get("role"); role == models.RoleDriver && manifest.Driver.UserID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "他の運転手の配送予定表は閲覧できません"})
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// driverID パスパラメータから運転手IDを取得する
+// 無効な場合は400を返してfalseを返す
+func driverID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な運転手IDです"})
+		return 0, false
+	}
+	return id, true
+}
+
+// driverErrorStatus サービスエラーに対応するHTTPステータスを返す
+// 配送スケジュールの作成で発生する運転手・車両のエラーも対象とする
+func driverErrorStatus(err error) int {
+	if errors.Is(err, services.ErrDriverNotFound) || errors.Is(err, services.ErrDriverUserNotFound) {
+		return http.StatusNotFound
+	}
+	var unavailableErr *models.DriverUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return http.StatusConflict
+	}
+	var shiftErr *models.ShiftViolationError
+	if errors.As(err, &shiftErr) {
+		return http.StatusConflict
+	}
+	var bookingErr *models.DoubleBookingError
+	if errors.As(err, &bookingErr) {
+		return http.StatusConflict
+	}
+	return vehicleErrorStatus(err)
+}
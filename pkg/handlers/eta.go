@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 到着予定日時ハンドラ
+ * 配送の到着予定日時の予測のHTTPリクエストを処理する
+ */
+
+// ETAHandler 到着予定日時ハンドラ
+type ETAHandler struct {
+	service *services.ETAService
+}
+
+// NewETAHandler 到着予定日時ハンドラを作成する
+func NewETAHandler(service *services.ETAService) *ETAHandler {
+	return &ETAHandler{service: service}
+}
+
+// UpdateDeliveryETA 配送の到着予定日時を予測し直す
+func (h *ETAHandler) UpdateDeliveryETA(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	eta, err := h.service.UpdateDeliveryETA(c.Request.Context(), id, time.Now())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrETATrackingNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrETAUnavailable):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, eta)
+}
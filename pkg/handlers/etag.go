@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * ETagヘルパー
+ * 楽観的排他制御のバージョンをETag・If-Matchヘッダーで受け渡す
+ */
+
+// setETag リソースのバージョンをETagヘッダーに設定する
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.Itoa(version)))
+}
+
+// parseIfMatch If-Matchヘッダーから更新前提のバージョンを取得する
+// ヘッダーがない場合と「*」の場合は0（バージョンを確認しない）を返す
+func parseIfMatch(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("If-Matchヘッダーには取得時のETagを指定してください")
+	}
+
+	return version, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 賞味期限監視ハンドラ
+ * 賞味期限の近い在庫の照会と通知日数のHTTPリクエストを処理する
+ */
+
+// ExpiryHandler 賞味期限監視ハンドラ
+type ExpiryHandler struct {
+	service *services.ExpiryService
+}
+
+// NewExpiryHandler 賞味期限監視ハンドラを作成する
+func NewExpiryHandler(service *services.ExpiryService) *ExpiryHandler {
+	return &ExpiryHandler{service: service}
+}
+
+// ListExpiringStock 賞味期限の近い在庫一覧取得
+func (h *ExpiryHandler) ListExpiringStock(c *gin.Context) {
+	days := services.DefaultExpiryLeadDays
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無効な日数です"})
+			return
+		}
+		days = parsed
+	}
+
+	stocks, err := h.service.ListExpiringStock(c.Request.Context(), time.Now(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stocks)
+}
+
+// ScanExpiry 賞味期限の確認を即時実行
+func (h *ExpiryHandler) ScanExpiry(c *gin.Context) {
+	result, err := h.service.ScanExpiry(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListPolicies 通知日数一覧取得
+func (h *ExpiryHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_lead_days": services.DefaultExpiryLeadDays,
+		"policies":          policies,
+	})
+}
+
+// SetPolicy 通知日数設定
+func (h *ExpiryHandler) SetPolicy(c *gin.Context) {
+	var req models.SetExpiryAlertPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	policy, err := h.service.SetPolicy(c.Request.Context(), c.Param("category"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy 通知日数削除
+func (h *ExpiryHandler) DeletePolicy(c *gin.Context) {
+	if err := h.service.DeletePolicy(c.Request.Context(), c.Param("category")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrExpiryPolicyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "通知日数を削除しました"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -27,6 +28,23 @@ func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
 	return &InventoryHandler{service: service}
 }
 
+// ListInventories 在庫一覧を取得する
+func (h *InventoryHandler) ListInventories(c *gin.Context) {
+	spec, err := parseQuerySpec(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inventories, page, err := h.service.ListInventories(c.Request.Context(), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, inventories, page)
+}
+
 // GetInventory 在庫情報を取得する
 func (h *InventoryHandler) GetInventory(c *gin.Context) {
 	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
@@ -80,6 +98,7 @@ func (h *InventoryHandler) GetInventory(c *gin.Context) {
 			"quantity":   inventory.Quantity,
 		})
 
+	setETag(c, inventory.Version)
 	c.JSON(http.StatusOK, inventory)
 }
 
@@ -97,17 +116,26 @@ func (h *InventoryHandler) UpdateInventory(c *gin.Context) {
 		return
 	}
 
-	err := h.service.UpdateInventoryQuantity(
+	// If-Matchで取得時のETagを指定した場合は、その後に更新されていれば409を返す
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inventory, err := h.service.UpdateInventoryQuantity(
 		c.Request.Context(),
 		req.ProductID,
 		req.Location,
 		req.Quantity,
+		expectedVersion,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(inventoryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, inventory.Version)
 	c.JSON(http.StatusOK, gin.H{"message": "在庫を更新しました"})
 }
 
@@ -134,7 +162,7 @@ func (h *InventoryHandler) TransferInventory(c *gin.Context) {
 		req.Quantity,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(inventoryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -161,28 +189,90 @@ func (h *InventoryHandler) CheckAvailability(c *gin.Context) {
 		return
 	}
 
-	available, err := h.service.CheckAvailability(
+	availability, err := h.service.GetAvailability(
 		c.Request.Context(),
 		productID,
 		location,
-		quantity,
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 引当中の数量を除いた引当可能数で判定する
+	available := availability.AvailableToPromise >= quantity
 	message := "在庫が不足しています"
 	if available {
 		message = "在庫は利用可能です"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"available": available,
-		"message":   message,
+		"available":            available,
+		"message":              message,
+		"on_hand":              availability.OnHand,
+		"reserved":             availability.Reserved,
+		"available_to_promise": availability.AvailableToPromise,
 	})
 }
 
+// CreateReservation 在庫を引き当てる
+func (h *InventoryHandler) CreateReservation(c *gin.Context) {
+	var req models.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	reservation, err := h.service.Reserve(c.Request.Context(), &req)
+	if err != nil {
+		logger.WithRequestID(c.GetString("request_id")).
+			WithUserID(c.GetString("user_id")).
+			Error("在庫引当エラー", map[string]interface{}{
+				"product_id":       req.ProductID,
+				"location":         req.Location,
+				"quantity":         req.Quantity,
+				"reference_number": req.ReferenceNumber,
+				"error":            err.Error(),
+			})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// ReleaseReservation 在庫引当を解放する
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な引当IDです"})
+		return
+	}
+
+	if err := h.service.Release(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "在庫引当を解放しました"})
+}
+
+// CommitReservation 在庫引当を確定する
+func (h *InventoryHandler) CommitReservation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な引当IDです"})
+		return
+	}
+
+	if err := h.service.Commit(c.Request.Context(), id); err != nil {
+		c.JSON(inventoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "在庫引当を確定しました"})
+}
+
 // CreateMovement 在庫移動を作成する
 func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 	type MovementRequest struct {
@@ -190,6 +280,7 @@ func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 		FromLocation    string `json:"from_location" binding:"required"`
 		ToLocation      string `json:"to_location" binding:"required"`
 		Quantity        int    `json:"quantity" binding:"required"`
+		Unit            string `json:"unit"`
 		MovementType    string `json:"movement_type" binding:"required"`
 		MovementDate    string `json:"movement_date"`
 		ReferenceNumber string `json:"reference_number"`
@@ -213,6 +304,7 @@ func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 			"from_location":    req.FromLocation,
 			"to_location":      req.ToLocation,
 			"quantity":         req.Quantity,
+			"unit":             req.Unit,
 			"movement_type":    req.MovementType,
 			"reference_number": req.ReferenceNumber,
 		})
@@ -258,6 +350,7 @@ func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 		FromLocation:    req.FromLocation,
 		ToLocation:      req.ToLocation,
 		Quantity:        req.Quantity,
+		Unit:            req.Unit,
 		MovementType:    models.MovementType(req.MovementType),
 		MovementDate:    movementTime,
 		ReferenceNumber: req.ReferenceNumber,
@@ -274,7 +367,7 @@ func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 				"reference_number": req.ReferenceNumber,
 				"error":            err.Error(),
 			})
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(inventoryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -292,3 +385,36 @@ func (h *InventoryHandler) CreateMovement(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, movement)
 }
+
+// inventoryErrorStatus サービスエラーに対応するHTTPステータスを返す
+func inventoryErrorStatus(err error) int {
+	var capacityErr *models.WarehouseCapacityError
+	if errors.As(err, &capacityErr) {
+		return http.StatusConflict
+	}
+	var zoneRuleErr *models.ZoneStorageRuleError
+	if errors.As(err, &zoneRuleErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var unitErr *models.UnknownUnitError
+	if errors.As(err, &unitErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var lotErr *models.InvalidLotError
+	if errors.As(err, &lotErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var stockErr *models.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return http.StatusConflict
+	}
+	var frozenErr *models.LocationFrozenError
+	if errors.As(err, &frozenErr) {
+		return http.StatusConflict
+	}
+	var conflictErr *models.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫元帳ハンドラ
+ * 在庫元帳の照会と在庫数の照合のHTTPリクエストを処理する
+ */
+
+// LedgerHandler 在庫元帳ハンドラ
+type LedgerHandler struct {
+	service *services.LedgerService
+}
+
+// NewLedgerHandler 在庫元帳ハンドラを作成する
+func NewLedgerHandler(service *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{service: service}
+}
+
+// ListLedgerEntries 在庫行の記帳一覧取得
+func (h *LedgerHandler) ListLedgerEntries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な在庫IDです"})
+		return
+	}
+
+	entries, err := h.service.ListLedgerEntries(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Reconcile 在庫数と元帳残高の照合
+// fix=trueの場合は差異のある在庫行の数量を元帳残高に戻す
+func (h *LedgerHandler) Reconcile(c *gin.Context) {
+	fix := c.Query("fix") == "true"
+
+	report, err := h.service.Reconcile(c.Request.Context(), fix, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
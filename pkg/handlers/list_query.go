@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tea-logistics/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 一覧取得ヘルパー
+ * 一覧エンドポイント共通のクエリパラメータ（ページング・絞り込み・並び順）の解釈と、
+ * ページ情報・Linkヘッダーを付けた応答を行う
+ *
+ *   limit=50            1ページの件数（既定50、最大200）
+ *   offset=100          オフセット方式のページング
+ *   cursor=...          カーソル方式のページング（前の応答のnext_cursor）
+ *   sort=-created_at,id 並び順（先頭の「-」で降順）
+ *   status=active       項目の値で絞り込み
+ *   created_at_from=... 日時・数値の範囲で絞り込み（_from: 以上、_to: 以下）
+ */
+
+const (
+	// defaultPageLimit 1ページの既定の件数
+	defaultPageLimit = 50
+	// maxPageLimit 1ページの最大件数
+	maxPageLimit = 200
+)
+
+// pageQueryParams ページング・並び順に使用するクエリパラメータ（絞り込みとして扱わない）
+var pageQueryParams = map[string]bool{"limit": true, "offset": true, "cursor": true, "sort": true}
+
+// listResponse 一覧取得の応答
+type listResponse struct {
+	Data       interface{}          `json:"data"`
+	Pagination *repository.PageInfo `json:"pagination"`
+}
+
+// parseQuerySpec クエリパラメータから一覧取得条件を作成する
+// ignoreに指定したパラメータ（エンドポイント固有の必須パラメータなど）は絞り込みとして扱わない
+// 絞り込み・並び順に使用できる項目かどうかはリポジトリで検証する
+func parseQuerySpec(c *gin.Context, ignore ...string) (*repository.QuerySpec, error) {
+	spec := &repository.QuerySpec{Limit: defaultPageLimit, Cursor: c.Query("cursor")}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return nil, errors.New("limitは1から200までの整数で指定してください")
+		}
+		spec.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return nil, errors.New("offsetは0以上の整数で指定してください")
+		}
+		spec.Offset = offset
+	}
+
+	if value := c.Query("sort"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			key := repository.SortKey{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")}
+			if key.Field == "" {
+				return nil, errors.New("sortに空の項目は指定できません")
+			}
+			spec.Sort = append(spec.Sort, key)
+		}
+	}
+
+	ignored := make(map[string]bool, len(ignore))
+	for _, name := range ignore {
+		ignored[name] = true
+	}
+	query := c.Request.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	// 同じ指定から常に同じSQLになるよう、パラメータ名の順に条件を並べる
+	sort.Strings(names)
+	for _, name := range names {
+		if pageQueryParams[name] || ignored[name] {
+			continue
+		}
+		filter := repository.Filter{Field: name, Operator: repository.FilterEqual}
+		switch {
+		case strings.HasSuffix(name, "_from"):
+			filter.Field, filter.Operator = strings.TrimSuffix(name, "_from"), repository.FilterFrom
+		case strings.HasSuffix(name, "_to"):
+			filter.Field, filter.Operator = strings.TrimSuffix(name, "_to"), repository.FilterTo
+		}
+		for _, value := range query[name] {
+			filter.Value = value
+			spec.Filters = append(spec.Filters, filter)
+		}
+	}
+
+	return spec, nil
+}
+
+// writePage 一覧とページ情報を返し、前後のページへのLinkヘッダーを設定する
+func writePage(c *gin.Context, data interface{}, page *repository.PageInfo) {
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, listResponse{Data: data, Pagination: page})
+}
+
+// setLinkHeader 前後のページへのLinkヘッダーを設定する
+func setLinkHeader(c *gin.Context, page *repository.PageInfo) {
+	var links []string
+	link := func(rel string, set map[string]string) {
+		query := c.Request.URL.Query()
+		for name, value := range set {
+			if value == "" {
+				query.Del(name)
+			} else {
+				query.Set(name, value)
+			}
+		}
+		u := *c.Request.URL
+		u.RawQuery = query.Encode()
+		links = append(links, "<"+u.RequestURI()+`>; rel="`+rel+`"`)
+	}
+
+	limit := strconv.Itoa(page.Limit)
+	if c.Query("cursor") != "" {
+		// カーソル方式: 次のページのみ
+		if page.HasMore {
+			link("next", map[string]string{"cursor": page.NextCursor, "limit": limit})
+		}
+	} else {
+		// オフセット方式: 先頭・前・次・最後のページ
+		if page.HasMore {
+			link("next", map[string]string{"offset": strconv.Itoa(page.Offset + page.Limit), "limit": limit})
+		}
+		if page.Offset > 0 {
+			prev := page.Offset - page.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			link("prev", map[string]string{"offset": strconv.Itoa(prev), "limit": limit})
+			link("first", map[string]string{"offset": "", "limit": limit})
+		}
+		if page.Total != nil && page.Limit > 0 && int64(page.Offset+page.Limit) < *page.Total {
+			last := (*page.Total - 1) / int64(page.Limit) * int64(page.Limit)
+			link("last", map[string]string{"offset": strconv.FormatInt(last, 10), "limit": limit})
+		}
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// listErrorStatus 一覧取得エラーのHTTPステータスを返す
+func listErrorStatus(err error) int {
+	var invalid *repository.InvalidQueryError
+	if errors.As(err, &invalid) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
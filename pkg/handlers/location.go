@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * ロケーションハンドラ
+ * ゾーン・通路・ビンとロケーション在庫集計のHTTPリクエストを処理する
+ */
+
+// LocationHandler ロケーションハンドラ
+type LocationHandler struct {
+	service *services.LocationService
+}
+
+// NewLocationHandler ロケーションハンドラを作成する
+func NewLocationHandler(service *services.LocationService) *LocationHandler {
+	return &LocationHandler{service: service}
+}
+
+// CreateZone ゾーン作成
+func (h *LocationHandler) CreateZone(c *gin.Context) {
+	var req models.CreateZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	zone, err := h.service.CreateZone(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+}
+
+// ListZones ゾーン一覧取得
+func (h *LocationHandler) ListZones(c *gin.Context) {
+	warehouseID, err := strconv.ParseInt(c.Query("warehouse_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な倉庫IDです"})
+		return
+	}
+
+	zones, err := h.service.ListZones(c.Request.Context(), warehouseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, zones)
+}
+
+// GetZone ゾーン取得
+func (h *LocationHandler) GetZone(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なゾーンIDです"})
+		return
+	}
+
+	zone, err := h.service.GetZone(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// UpdateZoneCategories ゾーン保管カテゴリ更新
+func (h *LocationHandler) UpdateZoneCategories(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なゾーンIDです"})
+		return
+	}
+
+	var req models.UpdateZoneCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	zone, err := h.service.UpdateZoneCategories(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// CreateAisle 通路作成
+func (h *LocationHandler) CreateAisle(c *gin.Context) {
+	var req models.CreateAisleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	aisle, err := h.service.CreateAisle(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, aisle)
+}
+
+// ListAisles 通路一覧取得
+func (h *LocationHandler) ListAisles(c *gin.Context) {
+	zoneID, err := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なゾーンIDです"})
+		return
+	}
+
+	aisles, err := h.service.ListAisles(c.Request.Context(), zoneID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aisles)
+}
+
+// CreateBin ビン作成
+func (h *LocationHandler) CreateBin(c *gin.Context) {
+	var req models.CreateBinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	bin, err := h.service.CreateBin(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bin)
+}
+
+// ListBins ビン一覧取得
+func (h *LocationHandler) ListBins(c *gin.Context) {
+	aisleID, err := strconv.ParseInt(c.Query("aisle_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通路IDです"})
+		return
+	}
+
+	bins, err := h.service.ListBins(c.Request.Context(), aisleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bins)
+}
+
+// GetBinLocation ビンの位置取得
+func (h *LocationHandler) GetBinLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なビンIDです"})
+		return
+	}
+
+	location, err := h.service.GetBinLocation(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// GetLocationStock ロケーション在庫集計
+func (h *LocationHandler) GetLocationStock(c *gin.Context) {
+	var query models.LocationStockQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な検索条件です"})
+		return
+	}
+
+	stocks, err := h.service.GetLocationStock(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stocks)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * ロットハンドラ
+ * 収穫ロットとトレーサビリティのHTTPリクエストを処理する
+ */
+
+// LotHandler ロットハンドラ
+type LotHandler struct {
+	service *services.LotService
+}
+
+// NewLotHandler ロットハンドラを作成する
+func NewLotHandler(service *services.LotService) *LotHandler {
+	return &LotHandler{service: service}
+}
+
+// CreateLot ロット作成
+func (h *LotHandler) CreateLot(c *gin.Context) {
+	var req models.CreateLotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	lot, err := h.service.CreateLot(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, lot)
+}
+
+// ListLots ロット一覧取得
+func (h *LotHandler) ListLots(c *gin.Context) {
+	var productID int64
+	if value := c.Query("product_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無効な商品IDです"})
+			return
+		}
+		productID = id
+	}
+
+	lots, err := h.service.ListLots(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lots)
+}
+
+// GetLot ロット取得
+func (h *LotHandler) GetLot(c *gin.Context) {
+	lot, err := h.service.GetLotByNumber(c.Request.Context(), c.Param("lot"))
+	if err != nil {
+		c.JSON(lotErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lot)
+}
+
+// TraceLot ロットのトレーサビリティ取得
+func (h *LotHandler) TraceLot(c *gin.Context) {
+	trace, err := h.service.TraceLot(c.Request.Context(), c.Param("lot"))
+	if err != nil {
+		c.JSON(lotErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// lotErrorStatus サービスエラーに対応するHTTPステータスを返す
+func lotErrorStatus(err error) int {
+	if errors.Is(err, services.ErrLotNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
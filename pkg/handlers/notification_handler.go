@@ -69,13 +69,19 @@ func (h *NotificationHandler) ListNotifications(c *gin.Context) {
 		return
 	}
 
-	notifications, err := h.service.ListNotifications(c.Request.Context(), userID)
+	spec, err := parseQuerySpec(c, "user_id")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifications, page, err := h.service.ListNotifications(c.Request.Context(), userID, spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, notifications)
+	writePage(c, notifications, page)
 }
 
 // MarkAsRead 通知を既読にする
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
 	"tea-logistics/pkg/services"
 
 	"github.com/gin-gonic/gin"
@@ -56,18 +58,57 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, product)
 }
 
 // ListProducts 商品一覧取得
 func (h *ProductHandler) ListProducts(c *gin.Context) {
-	products, err := h.service.ListProducts(c.Request.Context())
+	spec, err := parseQuerySpec(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	products, page, err := h.service.ListProducts(c.Request.Context(), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, products, page)
+}
+
+// searchResponse 商品検索の応答
+type searchResponse struct {
+	Terms      []string                   `json:"terms"`
+	Data       []*models.ProductSearchHit `json:"data"`
+	Facets     models.ProductSearchFacets `json:"facets"`
+	Pagination *repository.PageInfo       `json:"pagination"`
+}
+
+// SearchProducts 商品検索
+// qの検索語に一致する商品を関連度順に返し、カテゴリ・ステータス別の件数を合わせて返す
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	spec, err := parseQuerySpec(c, "q")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, page, err := h.service.SearchProducts(c.Request.Context(), c.Query("q"), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setLinkHeader(c, page)
+	c.JSON(http.StatusOK, searchResponse{
+		Terms:      result.Terms,
+		Data:       result.Hits,
+		Facets:     result.Facets,
+		Pagination: page,
+	})
 }
 
 // UpdateProduct 商品更新
@@ -84,12 +125,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	// If-Matchで取得時のETagを指定した場合は、その後に更新されていれば409を返す
+	req.ExpectedVersion, err = parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	product, err := h.service.UpdateProduct(c.Request.Context(), id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(productErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, product)
 }
 
@@ -102,9 +151,22 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(productErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "商品を削除しました"})
 }
+
+// productErrorStatus サービスエラーに対応するHTTPステータスを返す
+func productErrorStatus(err error) int {
+	var conflictErr *models.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return http.StatusConflict
+	}
+	var inUseErr *models.ProductInUseError
+	if errors.As(err, &inUseErr) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 荷姿ハンドラ
+ * 商品の荷姿と単位換算のHTTPリクエストを処理する
+ */
+
+// ProductVariantHandler 荷姿ハンドラ
+type ProductVariantHandler struct {
+	service *services.ProductVariantService
+}
+
+// NewProductVariantHandler 荷姿ハンドラを作成する
+func NewProductVariantHandler(service *services.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{service: service}
+}
+
+// CreateVariant 荷姿作成
+func (h *ProductVariantHandler) CreateVariant(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な商品IDです"})
+		return
+	}
+
+	var req models.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	variant, err := h.service.CreateVariant(c.Request.Context(), productID, &req)
+	if err != nil {
+		c.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// ListVariants 荷姿一覧取得
+func (h *ProductVariantHandler) ListVariants(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な商品IDです"})
+		return
+	}
+
+	variants, err := h.service.ListVariants(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// UpdateVariant 荷姿更新
+func (h *ProductVariantHandler) UpdateVariant(c *gin.Context) {
+	productID, variantID, ok := variantParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	variant, err := h.service.UpdateVariant(c.Request.Context(), productID, variantID, &req)
+	if err != nil {
+		c.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant 荷姿削除
+func (h *ProductVariantHandler) DeleteVariant(c *gin.Context) {
+	productID, variantID, ok := variantParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteVariant(c.Request.Context(), productID, variantID); err != nil {
+		c.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ConvertQuantity 単位換算
+// quantityとfrom（換算元の荷姿）・to（換算先の荷姿）を指定し、省略した単位は基本単位とする
+func (h *ProductVariantHandler) ConvertQuantity(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な商品IDです"})
+		return
+	}
+
+	quantity, err := strconv.Atoi(c.Query("quantity"))
+	if err != nil || quantity < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantityは1以上の整数で指定してください"})
+		return
+	}
+
+	conversion, err := h.service.ConvertQuantity(c.Request.Context(), productID, quantity, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
+
+// variantParams パスパラメータから商品IDと荷姿IDを取得する
+// 無効な場合は400を返してfalseを返す
+func variantParams(c *gin.Context) (int64, int64, bool) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な商品IDです"})
+		return 0, 0, false
+	}
+	variantID, err := strconv.ParseInt(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な荷姿IDです"})
+		return 0, 0, false
+	}
+	return productID, variantID, true
+}
+
+// variantErrorStatus サービスエラーに対応するHTTPステータスを返す
+func variantErrorStatus(err error) int {
+	if errors.Is(err, services.ErrVariantNotFound) {
+		return http.StatusNotFound
+	}
+	var unitErr *models.UnknownUnitError
+	if errors.As(err, &unitErr) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
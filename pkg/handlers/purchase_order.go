@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 発注・入荷ハンドラ
+ * 仕入先・発注書と入荷のHTTPリクエストを処理する
+ */
+
+// PurchaseOrderHandler 発注・入荷ハンドラ
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+// NewPurchaseOrderHandler 発注・入荷ハンドラを作成する
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+// CreateSupplier 仕入先作成
+func (h *PurchaseOrderHandler) CreateSupplier(c *gin.Context) {
+	var req models.CreateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	supplier, err := h.service.CreateSupplier(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplier)
+}
+
+// GetSupplier 仕入先取得
+func (h *PurchaseOrderHandler) GetSupplier(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	supplier, err := h.service.GetSupplier(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier)
+}
+
+// ListSuppliers 仕入先一覧取得
+func (h *PurchaseOrderHandler) ListSuppliers(c *gin.Context) {
+	suppliers, err := h.service.ListSuppliers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suppliers)
+}
+
+// UpdateSupplier 仕入先更新
+func (h *PurchaseOrderHandler) UpdateSupplier(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.UpdateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	supplier, err := h.service.UpdateSupplier(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier)
+}
+
+// CreatePurchaseOrder 発注書作成
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req models.CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	order, err := h.service.CreatePurchaseOrder(c.Request.Context(), &req, currentUserID(c))
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetPurchaseOrder 発注書取得
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	order, err := h.service.GetPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ListPurchaseOrders 発注書一覧取得
+func (h *PurchaseOrderHandler) ListPurchaseOrders(c *gin.Context) {
+	var supplierID int64
+	if v := c.Query("supplier_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無効な仕入先ID形式です"})
+			return
+		}
+		supplierID = id
+	}
+	status := models.PurchaseOrderStatus(c.Query("status"))
+
+	orders, err := h.service.ListPurchaseOrders(c.Request.Context(), status, supplierID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// ReceivePurchaseOrder 発注に対する入荷
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	receipt, err := h.service.ReceivePurchaseOrder(c.Request.Context(), id, &req, currentUserID(c))
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, receipt)
+}
+
+// CancelPurchaseOrder 発注のキャンセル
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	order, err := h.service.CancelPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ClosePurchaseOrder 一部入荷の発注の完了（残数量の打ち切り）
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	order, err := h.service.ClosePurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// purchaseOrderErrorStatus サービスエラーに対応するHTTPステータスを返す
+func purchaseOrderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSupplierNotFound),
+		errors.Is(err, services.ErrPurchaseOrderNotFound):
+		return http.StatusNotFound
+	}
+	var statusErr *models.PurchaseOrderStatusError
+	if errors.As(err, &statusErr) {
+		return http.StatusConflict
+	}
+	var capacityErr *models.WarehouseCapacityError
+	if errors.As(err, &capacityErr) {
+		return http.StatusConflict
+	}
+	var zoneRuleErr *models.ZoneStorageRuleError
+	if errors.As(err, &zoneRuleErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var frozenErr *models.LocationFrozenError
+	if errors.As(err, &frozenErr) {
+		return http.StatusConflict
+	}
+	var conflictErr *models.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
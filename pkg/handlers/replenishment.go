@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫補充ハンドラ
+ * 発注点設定と在庫補充提案のHTTPリクエストを処理する
+ */
+
+// ReplenishmentHandler 在庫補充ハンドラ
+type ReplenishmentHandler struct {
+	service *services.ReplenishmentService
+}
+
+// NewReplenishmentHandler 在庫補充ハンドラを作成する
+func NewReplenishmentHandler(service *services.ReplenishmentService) *ReplenishmentHandler {
+	return &ReplenishmentHandler{service: service}
+}
+
+// ListSettings 発注点設定一覧取得
+func (h *ReplenishmentHandler) ListSettings(c *gin.Context) {
+	settings, err := h.service.ListSettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetSetting 発注点設定
+func (h *ReplenishmentHandler) SetSetting(c *gin.Context) {
+	var req models.SetReorderSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	setting, err := h.service.SetSetting(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// DeleteSetting 発注点設定削除
+func (h *ReplenishmentHandler) DeleteSetting(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	if err := h.service.DeleteSetting(c.Request.Context(), id); err != nil {
+		c.JSON(replenishmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "発注点設定を削除しました"})
+}
+
+// ListProposals 在庫補充提案一覧取得
+func (h *ReplenishmentHandler) ListProposals(c *gin.Context) {
+	status := models.ReplenishmentProposalStatus(c.Query("status"))
+
+	proposals, err := h.service.ListProposals(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+// UpdateProposalStatus 在庫補充提案のステータス更新
+func (h *ReplenishmentHandler) UpdateProposalStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.UpdateReplenishmentProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	proposal, err := h.service.UpdateProposalStatus(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(replenishmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+// Evaluate 発注点による在庫評価の即時実行
+func (h *ReplenishmentHandler) Evaluate(c *gin.Context) {
+	result, err := h.service.Evaluate(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// replenishmentErrorStatus サービスエラーに対応するHTTPステータスを返す
+func replenishmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReorderSettingNotFound),
+		errors.Is(err, services.ErrReplenishmentProposalNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 配送ルートハンドラ
+ * 車両の1日の配送ルートの取得・再計算のHTTPリクエストを処理する
+ */
+
+// RouteHandler 配送ルートハンドラ
+type RouteHandler struct {
+	service *services.RouteService
+}
+
+// NewRouteHandler 配送ルートハンドラを作成する
+func NewRouteHandler(service *services.RouteService) *RouteHandler {
+	return &RouteHandler{service: service}
+}
+
+// GetVehicleRoute 車両の配送ルート取得
+func (h *RouteHandler) GetVehicleRoute(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+	date, ok := routeDate(c)
+	if !ok {
+		return
+	}
+
+	route, err := h.service.GetVehicleRoute(c.Request.Context(), id, date)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// PlanVehicleRoute 車両の配送ルートの再計算
+func (h *RouteHandler) PlanVehicleRoute(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+	date, ok := routeDate(c)
+	if !ok {
+		return
+	}
+
+	route, err := h.service.PlanVehicleRoute(c.Request.Context(), id, date)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// routeDate クエリパラメータdate（YYYY-MM-DD形式、省略時は当日）から配送ルートの日付を取得する
+// 無効な場合は400を返してfalseを返す
+func routeDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("date")
+	if value == "" {
+		return time.Now(), true
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dateはYYYY-MM-DD形式で指定してください"})
+		return time.Time{}, false
+	}
+	return date, true
+}
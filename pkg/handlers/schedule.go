@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 配送スケジュールハンドラ
+ * 配送への運転手・車両の割当のHTTPリクエストを処理する
+ */
+
+// ScheduleHandler 配送スケジュールハンドラ
+type ScheduleHandler struct {
+	service *services.SchedulingService
+}
+
+// NewScheduleHandler 配送スケジュールハンドラを作成する
+func NewScheduleHandler(service *services.SchedulingService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+// ScheduleDelivery 配送への運転手・車両の割当
+func (h *ScheduleHandler) ScheduleDelivery(c *gin.Context) {
+	var req models.ScheduleDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	schedule, err := h.service.ScheduleDelivery(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// GetSchedule 配送スケジュール取得
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な配送スケジュールIDです"})
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
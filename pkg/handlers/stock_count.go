@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 棚卸ハンドラ
+ * 棚卸の開始・実数入力・承認のHTTPリクエストを処理する
+ */
+
+// StockCountHandler 棚卸ハンドラ
+type StockCountHandler struct {
+	service *services.StockCountService
+}
+
+// NewStockCountHandler 棚卸ハンドラを作成する
+func NewStockCountHandler(service *services.StockCountService) *StockCountHandler {
+	return &StockCountHandler{service: service}
+}
+
+// StartStockCount 棚卸開始
+func (h *StockCountHandler) StartStockCount(c *gin.Context) {
+	var req models.CreateStockCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	count, err := h.service.StartStockCount(c.Request.Context(), &req, currentUserID(c))
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, count)
+}
+
+// GetStockCount 棚卸取得
+func (h *StockCountHandler) GetStockCount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	count, err := h.service.GetStockCount(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// ListStockCounts 棚卸一覧取得
+func (h *StockCountHandler) ListStockCounts(c *gin.Context) {
+	status := models.StockCountStatus(c.Query("status"))
+
+	counts, err := h.service.ListStockCounts(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// RecordCounts 実数入力
+func (h *StockCountHandler) RecordCounts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.RecordStockCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	count, err := h.service.RecordCounts(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// SubmitStockCount 棚卸の提出（承認待ちにする）
+func (h *StockCountHandler) SubmitStockCount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	count, err := h.service.SubmitStockCount(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// ApproveStockCount 棚卸の承認（差異を在庫へ反映する）
+func (h *StockCountHandler) ApproveStockCount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	result, err := h.service.ApproveStockCount(c.Request.Context(), id, currentUserID(c))
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RejectStockCount 棚卸の却下
+func (h *StockCountHandler) RejectStockCount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	var req models.ReviewStockCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	count, err := h.service.RejectStockCount(c.Request.Context(), id, &req, currentUserID(c))
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// CancelStockCount 棚卸の中止
+func (h *StockCountHandler) CancelStockCount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なID形式です"})
+		return
+	}
+
+	count, err := h.service.CancelStockCount(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(stockCountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// stockCountErrorStatus サービスエラーに対応するHTTPステータスを返す
+func stockCountErrorStatus(err error) int {
+	if errors.Is(err, services.ErrStockCountNotFound) {
+		return http.StatusNotFound
+	}
+	var statusErr *models.StockCountStatusError
+	if errors.As(err, &statusErr) {
+		return http.StatusConflict
+	}
+	var frozenErr *models.LocationFrozenError
+	if errors.As(err, &frozenErr) {
+		return http.StatusConflict
+	}
+	var zoneRuleErr *models.ZoneStorageRuleError
+	if errors.As(err, &zoneRuleErr) {
+		return http.StatusUnprocessableEntity
+	}
+	var conflictErr *models.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
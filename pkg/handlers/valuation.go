@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫評価ハンドラ
+ * 評価日時点の在庫照会・在庫評価・日次スナップショットのHTTPリクエストを処理する
+ */
+
+// ValuationHandler 在庫評価ハンドラ
+type ValuationHandler struct {
+	service *services.ValuationService
+}
+
+// NewValuationHandler 在庫評価ハンドラを作成する
+func NewValuationHandler(service *services.ValuationService) *ValuationHandler {
+	return &ValuationHandler{service: service}
+}
+
+// GetStockAsOf 評価日時点の在庫取得
+func (h *ValuationHandler) GetStockAsOf(c *gin.Context) {
+	date, err := parseReportDate(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.GetStockAsOf(c.Request.Context(), date, time.Now())
+	if err != nil {
+		c.JSON(valuationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetValuation 在庫評価取得
+// format=csvの場合は月次決算用にCSV形式で出力する
+func (h *ValuationHandler) GetValuation(c *gin.Context) {
+	date, err := parseReportDate(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method := models.ValuationMethod(c.DefaultQuery("method", string(models.ValuationMethodWeightedAverage)))
+	filter := models.ValuationFilter{
+		Location: c.Query("location"),
+		Category: c.Query("category"),
+	}
+
+	report, err := h.service.GetValuation(c.Request.Context(), date, method, filter, time.Now())
+	if err != nil {
+		c.JSON(valuationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeValuationCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// TakeSnapshot 日次スナップショット作成
+func (h *ValuationHandler) TakeSnapshot(c *gin.Context) {
+	var req models.CreateInventorySnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	date, err := parseReportDate(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snapshot, err := h.service.TakeSnapshot(c.Request.Context(), date, time.Now())
+	if err != nil {
+		c.JSON(valuationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// parseReportDate YYYY-MM-DD形式の日付をサーバーのタイムゾーンで解釈する
+func parseReportDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("日付を指定してください")
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("日付はYYYY-MM-DD形式で指定してください")
+	}
+	return date, nil
+}
+
+// writeValuationCSV 在庫評価の明細をCSV形式で出力する
+func writeValuationCSV(c *gin.Context, report *models.ValuationReport) {
+	filename := fmt.Sprintf("inventory-valuation-%s-%s.csv", report.Date, report.Method)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"評価日", "評価方法", "商品ID", "SKU", "商品名", "カテゴリ", "倉庫", "数量", "単価", "評価額"})
+	for _, line := range report.Lines {
+		writer.Write([]string{
+			report.Date,
+			string(report.Method),
+			strconv.FormatInt(line.ProductID, 10),
+			line.SKU,
+			line.ProductName,
+			line.Category,
+			line.Location,
+			strconv.Itoa(line.Quantity),
+			strconv.FormatFloat(line.UnitCost, 'f', 2, 64),
+			strconv.FormatFloat(line.Value, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+}
+
+// valuationErrorStatus サービスエラーに対応するHTTPステータスを返す
+func valuationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrFutureValuationDate),
+		errors.Is(err, services.ErrSnapshotDateNotClosed):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
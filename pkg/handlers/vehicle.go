@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 車両ハンドラ
+ * 配送車両・整備予定・配送の割当のHTTPリクエストを処理する
+ */
+
+// VehicleHandler 車両ハンドラ
+type VehicleHandler struct {
+	service *services.VehicleService
+}
+
+// NewVehicleHandler 車両ハンドラを作成する
+func NewVehicleHandler(service *services.VehicleService) *VehicleHandler {
+	return &VehicleHandler{service: service}
+}
+
+// CreateVehicle 車両登録
+func (h *VehicleHandler) CreateVehicle(c *gin.Context) {
+	var req models.VehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	vehicle, err := h.service.CreateVehicle(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vehicle)
+}
+
+// ListVehicles 車両一覧取得
+func (h *VehicleHandler) ListVehicles(c *gin.Context) {
+	spec, err := parseQuerySpec(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicles, page, err := h.service.ListVehicles(c.Request.Context(), spec)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, vehicles, page)
+}
+
+// GetVehicle 車両取得
+func (h *VehicleHandler) GetVehicle(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	vehicle, err := h.service.GetVehicle(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// UpdateVehicle 車両更新
+func (h *VehicleHandler) UpdateVehicle(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.VehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	vehicle, err := h.service.UpdateVehicle(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// UpdateVehicleStatus 車両ステータス更新
+func (h *VehicleHandler) UpdateVehicleStatus(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateVehicleStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	vehicle, err := h.service.UpdateVehicleStatus(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// CreateMaintenance 整備予定登録
+func (h *VehicleHandler) CreateMaintenance(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	maintenance, err := h.service.CreateMaintenance(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, maintenance)
+}
+
+// ListMaintenance 整備予定一覧取得
+func (h *VehicleHandler) ListMaintenance(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.ListMaintenance(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ListAvailableVehicles 稼働可能な車両の取得
+// start・end（必須）の期間に割り当てられる車両を返し、weight_g・volume_ml・refrigeratedで絞り込む
+func (h *VehicleHandler) ListAvailableVehicles(c *gin.Context) {
+	query := &models.VehicleAvailabilityQuery{Refrigerated: c.Query("refrigerated") == "true"}
+
+	var err error
+	if query.StartTime, err = parseTimeQuery(c, "start", true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.EndTime, err = parseTimeQuery(c, "end", true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.WeightGrams, err = parseNonNegativeQuery(c, "weight_g"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.VolumeML, err = parseNonNegativeQuery(c, "volume_ml"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicles, err := h.service.ListAvailableVehicles(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicles)
+}
+
+// AssignDelivery 車両への配送割当
+func (h *VehicleHandler) AssignDelivery(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AssignVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	schedule, err := h.service.AssignDelivery(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// CancelAssignment 車両への配送割当の取消
+func (h *VehicleHandler) CancelAssignment(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	scheduleID, err := strconv.ParseInt(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な配送スケジュールIDです"})
+		return
+	}
+
+	schedule, err := h.service.CancelAssignment(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// GetVehicleHistory 車両の配送履歴取得
+// from・toを指定した場合は期間と重なる配送スケジュール・整備予定だけを返す
+func (h *VehicleHandler) GetVehicleHistory(c *gin.Context) {
+	id, ok := vehicleID(c)
+	if !ok {
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseTimeQuery(c, "to", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.service.GetVehicleHistory(c.Request.Context(), id, from, to)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// vehicleID パスパラメータから車両IDを取得する
+// 無効な場合は400を返してfalseを返す
+func vehicleID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な車両IDです"})
+		return 0, false
+	}
+	return id, true
+}
+
+// parseTimeQuery RFC3339形式またはYYYY-MM-DD形式（サーバーのタイムゾーンの0時）の日時のクエリパラメータを解釈する
+// 省略した場合は、requiredならエラーを、そうでなければゼロ値を返す
+func parseTimeQuery(c *gin.Context, name string, required bool) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		if required {
+			return time.Time{}, fmt.Errorf("%sを指定してください", name)
+		}
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%sはRFC3339形式またはYYYY-MM-DD形式で指定してください", name)
+}
+
+// parseNonNegativeQuery 0以上の整数のクエリパラメータを解釈する（省略した場合は0）
+func parseNonNegativeQuery(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%sは0以上の整数で指定してください", name)
+	}
+	return n, nil
+}
+
+// vehicleErrorStatus サービスエラーに対応するHTTPステータスを返す
+func vehicleErrorStatus(err error) int {
+	if errors.Is(err, services.ErrVehicleNotFound) || errors.Is(err, services.ErrScheduleNotFound) {
+		return http.StatusNotFound
+	}
+	var unavailableErr *models.VehicleUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return http.StatusConflict
+	}
+	var capacityErr *models.VehicleCapacityError
+	if errors.As(err, &capacityErr) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 倉庫ハンドラ
+ * 倉庫関連のHTTPリクエストを処理する
+ */
+
+// WarehouseHandler 倉庫ハンドラ
+type WarehouseHandler struct {
+	service *services.WarehouseService
+}
+
+// NewWarehouseHandler 倉庫ハンドラを作成する
+func NewWarehouseHandler(service *services.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{service: service}
+}
+
+// CreateWarehouse 倉庫作成
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var req models.CreateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	warehouse, err := h.service.CreateWarehouse(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// GetWarehouse 倉庫取得
+func (h *WarehouseHandler) GetWarehouse(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な倉庫IDです"})
+		return
+	}
+
+	warehouse, err := h.service.GetWarehouse(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// ListWarehouses 倉庫一覧取得
+func (h *WarehouseHandler) ListWarehouses(c *gin.Context) {
+	warehouses, err := h.service.ListWarehouses(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouses)
+}
+
+// UpdateWarehouse 倉庫更新
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な倉庫IDです"})
+		return
+	}
+
+	var req models.UpdateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエスト形式です"})
+		return
+	}
+
+	warehouse, err := h.service.UpdateWarehouse(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// DeleteWarehouse 倉庫削除
+func (h *WarehouseHandler) DeleteWarehouse(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な倉庫IDです"})
+		return
+	}
+
+	if err := h.service.DeleteWarehouse(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "倉庫を削除しました"})
+}
+
+// ListUtilization 全倉庫の使用率取得
+func (h *WarehouseHandler) ListUtilization(c *gin.Context) {
+	utilizations, err := h.service.ListUtilization(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utilizations)
+}
+
+// GetUtilization 倉庫の使用率取得
+func (h *WarehouseHandler) GetUtilization(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な倉庫IDです"})
+		return
+	}
+
+	utilization, err := h.service.GetUtilization(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utilization)
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"context"
+)
+
+/*
+ * コンテキスト
+ * リクエストIDと操作ユーザーをリクエストのコンテキストで引き継ぐ
+ */
+
+type contextKey string
+
+const (
+	requestIDContextKey contextKey = "request_id"
+	actorIDContextKey   contextKey = "actor_id"
+)
+
+// ContextWithRequestID リクエストIDを設定したコンテキストを返す
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext コンテキストのリクエストIDを返す（未設定の場合は空文字）
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// ContextWithActorID 操作ユーザーIDを設定したコンテキストを返す
+func ContextWithActorID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, actorIDContextKey, userID)
+}
+
+// ActorIDFromContext コンテキストの操作ユーザーIDを返す（未設定の場合は0）
+func ActorIDFromContext(ctx context.Context) int64 {
+	userID, _ := ctx.Value(actorIDContextKey).(int64)
+	return userID
+}
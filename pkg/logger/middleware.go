@@ -222,6 +222,7 @@ func RequestIDMiddleware() gin.HandlerFunc {
 
 		c.Header("X-Request-ID", requestID)
 		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
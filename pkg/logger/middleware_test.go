@@ -213,7 +213,10 @@ func TestRequestIDMiddleware(t *testing.T) {
 	router.Use(RequestIDMiddleware())
 	router.GET("/test", func(c *gin.Context) {
 		requestID := c.GetString("request_id")
-		c.JSON(200, gin.H{"request_id": requestID})
+		c.JSON(200, gin.H{
+			"request_id":         requestID,
+			"context_request_id": RequestIDFromContext(c.Request.Context()),
+		})
 	})
 
 	w := httptest.NewRecorder()
@@ -230,6 +233,8 @@ func TestRequestIDMiddleware(t *testing.T) {
 	requestID := response["request_id"].(string)
 	assert.NotEmpty(t, requestID)
 	assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+	// サービス層で参照できるようにリクエストのコンテキストにも設定される
+	assert.Equal(t, requestID, response["context_request_id"])
 }
 
 func TestTraceIDMiddleware(t *testing.T) {
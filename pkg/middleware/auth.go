@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"tea-logistics/pkg/config"
+	"tea-logistics/pkg/logger"
 	"tea-logistics/pkg/models"
 
 	"github.com/gin-gonic/gin"
@@ -53,6 +54,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			role := models.Role(claims["role"].(string))
 			c.Set("user_id", userID)
 			c.Set("role", role)
+			c.Request = c.Request.WithContext(logger.ContextWithActorID(c.Request.Context(), userID))
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです"})
@@ -0,0 +1,30 @@
+package models
+
+/*
+ * 住所モデル
+ * 配送先・倉庫の住所の確認状態と住所の修正に関するデータ構造を定義する
+ */
+
+// AddressStatus 住所の確認状態
+type AddressStatus string
+
+const (
+	// AddressStatusUnverified 未確認（ジオコーディングを行っていない）
+	AddressStatusUnverified AddressStatus = "unverified"
+	// AddressStatusGeocoded 住所から位置を特定済み
+	AddressStatusGeocoded AddressStatus = "geocoded"
+	// AddressStatusManual 緯度経度を指定済み
+	AddressStatusManual AddressStatus = "manual"
+	// AddressStatusNeedsReview 住所を解釈できないため要確認
+	AddressStatusNeedsReview AddressStatus = "needs_review"
+)
+
+// UpdateAddressRequest 配送先住所の修正リクエスト
+// 緯度経度を指定した場合はその位置を使用し、省略した場合は住所から位置を求める
+type UpdateAddressRequest struct {
+	ToAddress   string   `json:"to_address" binding:"required"`
+	ToLatitude  *float64 `json:"to_latitude" binding:"omitempty,min=-90,max=90"`
+	ToLongitude *float64 `json:"to_longitude" binding:"omitempty,min=-180,max=180"`
+	// ExpectedVersion If-Matchヘッダーで指定された更新前提のバージョン（0の場合は確認しない）
+	ExpectedVersion int `json:"-"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 在庫割当モデル
+ * 配送明細への在庫の割当方式と割当結果を定義する
+ */
+
+// AllocationStrategyType 在庫割当方式
+type AllocationStrategyType string
+
+const (
+	// AllocationStrategyFIFO 先入れ先出し（入庫の古い在庫から割り当てる）
+	AllocationStrategyFIFO AllocationStrategyType = "fifo"
+	// AllocationStrategyFEFO 先に期限の来るものから出荷（賞味期限の近いロットから割り当てる）
+	AllocationStrategyFEFO AllocationStrategyType = "fefo"
+	// AllocationStrategyNearestWarehouse 出荷元倉庫から近い倉庫の在庫から割り当てる
+	AllocationStrategyNearestWarehouse AllocationStrategyType = "nearest_warehouse"
+)
+
+// IsValid 定義済みの割当方式かどうかを判定する
+func (t AllocationStrategyType) IsValid() bool {
+	switch t {
+	case AllocationStrategyFIFO, AllocationStrategyFEFO, AllocationStrategyNearestWarehouse:
+		return true
+	}
+	return false
+}
+
+// AllocationPolicy 商品カテゴリごとの在庫割当方式
+type AllocationPolicy struct {
+	Category  string                 `json:"category"`
+	Strategy  AllocationStrategyType `json:"strategy"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// SetAllocationPolicyRequest 在庫割当方式設定リクエスト
+type SetAllocationPolicyRequest struct {
+	Strategy AllocationStrategyType `json:"strategy" binding:"required,oneof=fifo fefo nearest_warehouse"`
+}
+
+// DeliveryAllocation 配送明細の在庫割当結果
+// 1つの明細を複数の倉庫・ロットに分割して割り当てた場合は複数件となる
+type DeliveryAllocation struct {
+	ID             int64                  `json:"id"`
+	DeliveryID     int64                  `json:"delivery_id"`
+	DeliveryItemID int64                  `json:"delivery_item_id"`
+	ProductID      int64                  `json:"product_id"`
+	WarehouseID    int64                  `json:"warehouse_id"`
+	Location       string                 `json:"location"`
+	LotID          *int64                 `json:"lot_id,omitempty"`
+	Quantity       int                    `json:"quantity"`
+	Strategy       AllocationStrategyType `json:"strategy"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+/*
+ * 一括取込・出力モデル
+ * 商品・在庫数・保管場所のCSV/Excelによる一括取込の結果を定義する
+ */
+
+// ImportRowError 取込ファイルの行のエラー
+// Rowは見出し行を1行目とした行番号（見出し行自体のエラーは1）
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult 一括取込の結果
+// 1行でもエラーがある場合は何も登録せず（Committed=false）、すべての行のエラーを返す
+// DryRunの場合は検証のみ行い、エラーがなくても登録しない
+type ImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Committed bool             `json:"committed"`
+	TotalRows int              `json:"total_rows"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// AddError 行のエラーを追加する
+func (r *ImportResult) AddError(row int, column, message string) {
+	r.Errors = append(r.Errors, ImportRowError{Row: row, Column: column, Message: message})
+}
+
+// HasErrors エラーのある行があるかどうかを判定する
+func (r *ImportResult) HasErrors() bool {
+	return len(r.Errors) > 0
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+)
+
+/*
+ * 楽観的排他制御モデル
+ * バージョンによる更新競合のエラーを定義する
+ */
+
+// VersionConflictError 読み取り後に他の更新が行われていた場合のエラー
+// ExpectedVersionは更新の前提としたバージョン、CurrentVersionは現在のバージョン
+type VersionConflictError struct {
+	Resource        string
+	ID              int64
+	ExpectedVersion int
+	CurrentVersion  int
+}
+
+// Error エラーメッセージを返す
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s（ID %d）は他の操作で更新されています（指定バージョン %d、現在のバージョン %d）。最新の内容を取得してから再度実行してください",
+		e.Resource, e.ID, e.ExpectedVersion, e.CurrentVersion)
+}
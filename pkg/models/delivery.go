@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -23,6 +24,8 @@ const (
 	DeliveryStatusDelivered DeliveryStatus = "delivered"
 	// DeliveryStatusCancelled キャンセル
 	DeliveryStatusCancelled DeliveryStatus = "cancelled"
+	// DeliveryStatusReturned 返品
+	DeliveryStatusReturned DeliveryStatus = "returned"
 )
 
 // DeliveryOrder 配送オーダー
@@ -41,17 +44,38 @@ type DeliveryOrder struct {
 	UpdatedAt     time.Time      `json:"updated_at"`
 }
 
-// DeliverySchedule 配送スケジュール
+// ScheduleStatus 配送スケジュールステータス
+type ScheduleStatus string
+
+const (
+	// ScheduleStatusScheduled 予定
+	ScheduleStatusScheduled ScheduleStatus = "scheduled"
+	// ScheduleStatusCompleted 完了
+	ScheduleStatusCompleted ScheduleStatus = "completed"
+	// ScheduleStatusCancelled キャンセル
+	ScheduleStatusCancelled ScheduleStatus = "cancelled"
+)
+
+// DeliverySchedule 配送スケジュール（配送への車両・運転手の割当枠）
+// WeightGrams・VolumeMLは割当時の配送の出荷重量・容積で、車両の積載量の確認に使用する
+// DriverIDは運転手を割り当てていない場合はnil
 type DeliverySchedule struct {
-	ID         int64     `json:"id"`
-	DeliveryID int64     `json:"delivery_id"`
-	DriverID   int64     `json:"driver_id"`
-	VehicleID  string    `json:"vehicle_id"`
-	StartTime  time.Time `json:"start_time"`
-	EndTime    time.Time `json:"end_time"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          int64          `json:"id"`
+	DeliveryID  int64          `json:"delivery_id"`
+	DriverID    *int64         `json:"driver_id,omitempty"`
+	VehicleID   int64          `json:"vehicle_id"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Status      ScheduleStatus `json:"status"`
+	WeightGrams int            `json:"weight_g"`
+	VolumeML    int            `json:"volume_ml"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Overlaps 配送スケジュールが指定した期間と重なるかどうかを判定する
+func (s *DeliverySchedule) Overlaps(start, end time.Time) bool {
+	return s.StartTime.Before(end) && start.Before(s.EndTime)
 }
 
 // DeliveryTracking 配送追跡情報
@@ -65,32 +89,77 @@ type DeliveryTracking struct {
 }
 
 // Delivery 配送情報
+// Versionは楽観的排他制御用で、更新のたびに1ずつ増える
+// ToLatitude・ToLongitudeは配送先の位置で、配送ルートの計画に使用する（未設定の場合はnil）
+// AddressStatusは配送先住所の確認状態で、要確認の場合はAddressNoteに理由を記録する
 type Delivery struct {
-	ID              int64     `json:"id"`
-	OrderID         int64     `json:"order_id"`
-	Status          string    `json:"status"`
-	FromWarehouseID int64     `json:"from_warehouse_id"`
-	ToAddress       string    `json:"to_address"`
-	EstimatedTime   time.Time `json:"estimated_time"`
-	ActualTime      time.Time `json:"actual_time"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int64          `json:"id"`
+	OrderID         int64          `json:"order_id"`
+	Status          DeliveryStatus `json:"status"`
+	FromWarehouseID int64          `json:"from_warehouse_id"`
+	ToAddress       string         `json:"to_address"`
+	ToLatitude      *float64       `json:"to_latitude,omitempty"`
+	ToLongitude     *float64       `json:"to_longitude,omitempty"`
+	AddressStatus   AddressStatus  `json:"address_status"`
+	AddressNote     string         `json:"address_note,omitempty"`
+	EstimatedTime   time.Time      `json:"estimated_time"`
+	ActualTime      time.Time      `json:"actual_time"`
+	Version         int            `json:"version"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+
+	Items       []*DeliveryItem       `json:"items,omitempty"`
+	Allocations []*DeliveryAllocation `json:"allocations,omitempty"`
+}
+
+// HasDestinationCoordinates 配送先の緯度経度が設定されているかどうかを判定する
+func (d *Delivery) HasDestinationCoordinates() bool {
+	return d.ToLatitude != nil && d.ToLongitude != nil
 }
 
+// DeliveryItemStatus 配送明細ステータス
+type DeliveryItemStatus string
+
+const (
+	// DeliveryItemStatusActive 有効
+	DeliveryItemStatusActive DeliveryItemStatus = "active"
+	// DeliveryItemStatusCancelled キャンセル（全数量）
+	DeliveryItemStatusCancelled DeliveryItemStatus = "cancelled"
+)
+
 // DeliveryItem 配送商品情報
+// Quantity・CancelledQuantity・ReturnedQuantityは基本単位の数量で、
+// UnitとUnitQuantityには配送作成時に指定した荷姿と荷姿の数量を記録する
 type DeliveryItem struct {
-	ID         int64 `json:"id"`
-	DeliveryID int64 `json:"delivery_id"`
-	ProductID  int64 `json:"product_id"`
-	Quantity   int   `json:"quantity"`
+	ID                int64              `json:"id"`
+	DeliveryID        int64              `json:"delivery_id"`
+	ProductID         int64              `json:"product_id"`
+	LotID             *int64             `json:"lot_id,omitempty"`
+	Quantity          int                `json:"quantity"`
+	Unit              string             `json:"unit"`
+	UnitQuantity      int                `json:"unit_quantity"`
+	CancelledQuantity int                `json:"cancelled_quantity"`
+	ReturnedQuantity  int                `json:"returned_quantity"`
+	Status            DeliveryItemStatus `json:"status"`
+}
+
+// RemainingQuantity キャンセル分を除いた配送数量を返す
+func (i *DeliveryItem) RemainingQuantity() int {
+	return i.Quantity - i.CancelledQuantity
 }
 
-// Route 配送ルート情報
+// Route 配送ルート情報（車両の1日の配送ルートの訪問先）
+// Distance・Durationは直前の訪問先（最初の訪問先は出荷元倉庫）からの距離（km）と移動時間（分）で、
+// ArrivalTimeは到着予定日時。位置情報のない配送先はStatusがunlocatedで、到着予定日時を持たない
 type Route struct {
 	ID          int64     `json:"id"`
 	DeliveryID  int64     `json:"delivery_id"`
+	VehicleID   int64     `json:"vehicle_id"`
+	RouteDate   string    `json:"route_date"`
 	Sequence    int       `json:"sequence"`
 	Location    string    `json:"location"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
 	ArrivalTime time.Time `json:"arrival_time"`
 	Distance    float64   `json:"distance"`
 	Duration    int       `json:"duration"` // 分単位
@@ -99,26 +168,130 @@ type Route struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// Vehicle 配送車両情報
-type Vehicle struct {
-	ID            int64     `json:"id"`
-	VehicleNumber string    `json:"vehicle_number"`
-	Type          string    `json:"type"`
-	Capacity      float64   `json:"capacity"`
-	Status        string    `json:"status"`
-	LastLocation  string    `json:"last_location"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+// CreateDeliveryItemRequest 配送明細作成リクエスト
+// LotNumberを指定した場合は指定ロットの在庫を引き当てる
+// Unitに荷姿を指定した場合はQuantityを荷姿の数量とする（省略時は基本単位）
+type CreateDeliveryItemRequest struct {
+	ProductID int64  `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Unit      string `json:"unit"`
+	LotNumber string `json:"lot_number"`
 }
 
 // CreateDeliveryRequest 配送作成リクエスト
+// ToLatitude・ToLongitudeを省略した場合は配送先住所から位置を求める
 type CreateDeliveryRequest struct {
-	OrderID         int64     `json:"order_id" binding:"required"`
-	ProductID       int64     `json:"product_id" binding:"required"`
-	Quantity        int       `json:"quantity" binding:"required,min=1"`
-	FromWarehouseID int64     `json:"from_warehouse_id" binding:"required"`
-	ToAddress       string    `json:"to_address" binding:"required"`
-	EstimatedTime   time.Time `json:"estimated_time" binding:"required"`
+	OrderID         int64                       `json:"order_id" binding:"required"`
+	Items           []CreateDeliveryItemRequest `json:"items" binding:"required,min=1,dive"`
+	FromWarehouseID int64                       `json:"from_warehouse_id" binding:"required"`
+	ToAddress       string                      `json:"to_address" binding:"required"`
+	ToLatitude      *float64                    `json:"to_latitude" binding:"omitempty,min=-90,max=90"`
+	ToLongitude     *float64                    `json:"to_longitude" binding:"omitempty,min=-180,max=180"`
+	EstimatedTime   time.Time                   `json:"estimated_time" binding:"required"`
+}
+
+// CancelDeliveryItemRequest 配送明細キャンセルリクエスト
+// Quantityは基本単位の数量で、省略した場合は残数量をすべてキャンセルする
+type CancelDeliveryItemRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"`
+	// ExpectedVersion If-Matchヘッダーで指定された配送の更新前提のバージョン（0の場合は確認しない）
+	ExpectedVersion int `json:"-"`
+}
+
+// DeliveryValidationError 配送作成リクエストの内容が不正な場合のエラー
+// Lineは明細の行番号（1始まり）で、リクエスト全体のエラーの場合は0
+type DeliveryValidationError struct {
+	Line    int
+	Message string
+}
+
+// Error エラーメッセージを返す
+func (e *DeliveryValidationError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("明細%d: %s", e.Line, e.Message)
+}
+
+// DeliveryLineError 配送明細の処理で発生したエラーに明細の行番号を付けたもの
+// 元のエラーの型で判定できるよう、Unwrapで元のエラーを返す
+type DeliveryLineError struct {
+	Line int
+	Err  error
+}
+
+// Error エラーメッセージを返す
+func (e *DeliveryLineError) Error() string {
+	return fmt.Sprintf("明細%d: %v", e.Line, e.Err)
+}
+
+// Unwrap 元のエラーを返す
+func (e *DeliveryLineError) Unwrap() error {
+	return e.Err
+}
+
+// DeliveryItemCancelStatusError 出荷前ではない配送の明細をキャンセルしようとした場合のエラー
+type DeliveryItemCancelStatusError struct {
+	DeliveryID int64
+	Status     DeliveryStatus
+}
+
+// Error エラーメッセージを返す
+func (e *DeliveryItemCancelStatusError) Error() string {
+	return fmt.Sprintf("ステータスが「%s」の配送（ID %d）の明細はキャンセルできません。出荷前の配送のみ明細をキャンセルできます", e.Status, e.DeliveryID)
+}
+
+// CancelQuantityError 配送明細のキャンセル数量が残数量を超えている場合のエラー
+// Remainingが0の場合は明細がキャンセル済みであることを表す
+type CancelQuantityError struct {
+	ItemID    int64
+	Quantity  int
+	Remaining int
+}
+
+// Error エラーメッセージを返す
+func (e *CancelQuantityError) Error() string {
+	if e.Remaining == 0 {
+		return fmt.Sprintf("配送明細（ID %d）はキャンセル済みです", e.ItemID)
+	}
+	return fmt.Sprintf("キャンセル数量(%d)が配送明細（ID %d）の残数量(%d)を超えています", e.Quantity, e.ItemID, e.Remaining)
+}
+
+// UpdateDeliveryStatusRequest 配送ステータス更新リクエスト
+type UpdateDeliveryStatusRequest struct {
+	Status DeliveryStatus `json:"status" binding:"required"`
+	Reason string         `json:"reason"`
+	// ExpectedVersion If-Matchヘッダーで指定された更新前提のバージョン（0の場合は確認しない）
+	ExpectedVersion int `json:"-"`
+}
+
+// CancelDeliveryRequest 配送キャンセルリクエスト
+type CancelDeliveryRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReturnDeliveryItemRequest 返品明細リクエスト
+type ReturnDeliveryItemRequest struct {
+	ItemID     int64 `json:"item_id" binding:"required"`
+	Quantity   int   `json:"quantity" binding:"required,min=1"`
+	Quarantine bool  `json:"quarantine"`
+}
+
+// ReturnDeliveryRequest 返品リクエスト
+// Itemsを省略した場合は全明細の配送数量を返品とし、Quarantineを全明細に適用する
+type ReturnDeliveryRequest struct {
+	Location   string                      `json:"location" binding:"required"`
+	Items      []ReturnDeliveryItemRequest `json:"items" binding:"omitempty,dive"`
+	Quarantine bool                        `json:"quarantine"`
+	Reason     string                      `json:"reason"`
+}
+
+// DeliveryReturn 返品結果
+type DeliveryReturn struct {
+	DeliveryID int64                `json:"delivery_id"`
+	Status     DeliveryStatus       `json:"status"`
+	Location   string               `json:"location,omitempty"`
+	Movements  []*InventoryMovement `json:"movements"`
 }
 
 // CreateTrackingRequest 配送追跡作成リクエスト
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 配送ステータス遷移
+ * 配送ステータスの状態遷移ルールと変更履歴を定義する
+ */
+
+// deliveryStatusTransitions 配送ステータスごとの遷移可能なステータス
+// pending → scheduled → in_transit → delivered を正常系とし、
+// 出荷前のキャンセルと、出荷後の返品を分岐として許可する
+var deliveryStatusTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryStatusPending:   {DeliveryStatusScheduled, DeliveryStatusCancelled},
+	DeliveryStatusScheduled: {DeliveryStatusInTransit, DeliveryStatusCancelled},
+	DeliveryStatusInTransit: {DeliveryStatusDelivered, DeliveryStatusReturned},
+	DeliveryStatusDelivered: {DeliveryStatusReturned},
+	DeliveryStatusCancelled: {},
+	DeliveryStatusReturned:  {},
+}
+
+// IsValid 定義済みの配送ステータスかどうかを確認する
+func (s DeliveryStatus) IsValid() bool {
+	_, ok := deliveryStatusTransitions[s]
+	return ok
+}
+
+// IsTerminal 終端ステータスかどうかを確認する
+func (s DeliveryStatus) IsTerminal() bool {
+	return s.IsValid() && len(deliveryStatusTransitions[s]) == 0
+}
+
+// CanTransitionTo 指定したステータスへ遷移可能かどうかを確認する
+func (s DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	for _, allowed := range deliveryStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition 指定したステータスへの遷移を検証する
+// 遷移できない場合は*StatusTransitionErrorを返す
+func (s DeliveryStatus) ValidateTransition(next DeliveryStatus) error {
+	if !s.CanTransitionTo(next) {
+		return &StatusTransitionError{From: s, To: next}
+	}
+	return nil
+}
+
+// StatusTransitionError 不正な配送ステータス遷移エラー
+type StatusTransitionError struct {
+	From DeliveryStatus
+	To   DeliveryStatus
+}
+
+// Error エラーメッセージを返す
+func (e *StatusTransitionError) Error() string {
+	if !e.To.IsValid() {
+		return fmt.Sprintf("無効な配送ステータスです: %s", e.To)
+	}
+	return fmt.Sprintf("配送ステータスを「%s」から「%s」に変更できません", e.From, e.To)
+}
+
+// DeliveryStatusHistory 配送ステータス変更履歴
+type DeliveryStatusHistory struct {
+	ID         int64          `json:"id"`
+	DeliveryID int64          `json:"delivery_id"`
+	FromStatus DeliveryStatus `json:"from_status"`
+	ToStatus   DeliveryStatus `json:"to_status"`
+	ChangedBy  *int64         `json:"changed_by,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	ChangedAt  time.Time      `json:"changed_at"`
+}
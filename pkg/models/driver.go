@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 運転手モデル
+ * 運転手（ロールがdriverのユーザー）の勤務シフトと、運転手・車両を割り当てた配送スケジュールに関するデータ構造を定義する
+ */
+
+// Driver 運転手情報
+// ShiftStart・ShiftEndはサーバーのタイムゾーンのHH:MM形式の時刻で、
+// ShiftEndがShiftStart以前の場合は日をまたぐシフトを表す
+// Name・Emailはユーザー情報から取得する
+type Driver struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	LicenseNumber string     `json:"license_number"`
+	LicenseExpiry *time.Time `json:"license_expiry,omitempty"`
+	Phone         string     `json:"phone"`
+	ShiftStart    string     `json:"shift_start"`
+	ShiftEnd      string     `json:"shift_end"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ShiftOn 指定した日に始まるシフトの開始・終了日時を返す
+// 日付はdayのタイムゾーンで解釈する
+func (d *Driver) ShiftOn(day time.Time) (time.Time, time.Time, error) {
+	startMinutes, err := ParseShiftTime(d.ShiftStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endMinutes, err := ParseShiftTime(d.ShiftEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	start := midnight.Add(time.Duration(startMinutes) * time.Minute)
+	end := midnight.Add(time.Duration(endMinutes) * time.Minute)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// CoversSlot 期間全体がいずれかのシフトに収まるかどうかを判定する
+// シフトはサーバーのタイムゾーンで解釈し、日をまたぐシフトに対応するため開始日の前日に始まるシフトも確認する
+func (d *Driver) CoversSlot(start, end time.Time) (bool, error) {
+	local := start.Local()
+	for _, day := range []time.Time{local.AddDate(0, 0, -1), local} {
+		shiftStart, shiftEnd, err := d.ShiftOn(day)
+		if err != nil {
+			return false, err
+		}
+		if !start.Before(shiftStart) && !end.After(shiftEnd) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LicenseValidAt 指定時刻において運転免許が有効かどうかを判定する
+// 有効期限が未登録の場合は有効とみなす（有効期限の日の終わりまで有効）
+func (d *Driver) LicenseValidAt(t time.Time) bool {
+	if d.LicenseExpiry == nil {
+		return true
+	}
+	return t.Before(d.LicenseExpiry.AddDate(0, 0, 1))
+}
+
+// ParseShiftTime HH:MM形式の時刻を0時からの分数に変換する
+func ParseShiftTime(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("シフトの時刻はHH:MM形式で指定してください: %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// DriverRequest 運転手登録・更新リクエスト
+// UserIDは登録時のみ使用する（ロールがdriverのユーザーを指定する）
+// Activeを省略した場合は、登録時は有効、更新時は変更しない
+type DriverRequest struct {
+	UserID        int64      `json:"user_id"`
+	LicenseNumber string     `json:"license_number" binding:"required"`
+	LicenseExpiry *time.Time `json:"license_expiry"`
+	Phone         string     `json:"phone"`
+	ShiftStart    string     `json:"shift_start" binding:"required"`
+	ShiftEnd      string     `json:"shift_end" binding:"required"`
+	Active        *bool      `json:"active"`
+}
+
+// ScheduleDeliveryRequest 配送への運転手・車両の割当リクエスト
+type ScheduleDeliveryRequest struct {
+	DeliveryID            int64     `json:"delivery_id" binding:"required"`
+	DriverID              int64     `json:"driver_id" binding:"required"`
+	VehicleID             int64     `json:"vehicle_id" binding:"required"`
+	StartTime             time.Time `json:"start_time" binding:"required"`
+	EndTime               time.Time `json:"end_time" binding:"required"`
+	RequiresRefrigeration bool      `json:"requires_refrigeration"`
+}
+
+// ManifestStop 運転手の配送予定表の1件
+type ManifestStop struct {
+	Sequence      int               `json:"sequence"`
+	Schedule      *DeliverySchedule `json:"schedule"`
+	VehicleNumber string            `json:"vehicle_number"`
+	Delivery      *Delivery         `json:"delivery"`
+}
+
+// DriverManifest 運転手の1日の配送予定表
+// 指定した日に始まるシフトと重なる、取消されていない配送スケジュールを開始日時の順に並べる
+type DriverManifest struct {
+	Driver           *Driver         `json:"driver"`
+	Date             string          `json:"date"`
+	ShiftStart       time.Time       `json:"shift_start"`
+	ShiftEnd         time.Time       `json:"shift_end"`
+	Stops            []*ManifestStop `json:"stops"`
+	TotalWeightGrams int             `json:"total_weight_g"`
+	TotalVolumeML    int             `json:"total_volume_ml"`
+}
+
+// DriverUnavailableError 運転手に配送を割り当てられない場合のエラー（無効化・免許の期限切れ）
+type DriverUnavailableError struct {
+	DriverID int64
+	Name     string
+	Reason   string
+}
+
+func (e *DriverUnavailableError) Error() string {
+	return fmt.Sprintf("運転手「%s」には割り当てられません: %s", e.Name, e.Reason)
+}
+
+// ShiftViolationError 割当の期間が運転手のシフトに収まらない場合のエラー
+type ShiftViolationError struct {
+	DriverID   int64
+	Name       string
+	ShiftStart string
+	ShiftEnd   string
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+func (e *ShiftViolationError) Error() string {
+	return fmt.Sprintf("運転手「%s」のシフト（%s〜%s）の時間外です: %s〜%s",
+		e.Name, e.ShiftStart, e.ShiftEnd,
+		e.StartTime.Format("2006-01-02 15:04"), e.EndTime.Format("2006-01-02 15:04"))
+}
+
+// DoubleBookingError 運転手または車両が同じ時間帯に別の配送スケジュールで割当済みの場合のエラー
+// Resourceは「運転手」または「車両」
+type DoubleBookingError struct {
+	Resource   string
+	ResourceID int64
+	ScheduleID int64
+	DeliveryID int64
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+func (e *DoubleBookingError) Error() string {
+	return fmt.Sprintf("%s(ID: %d)は配送ID %d のスケジュール（%s〜%s）と重複しています",
+		e.Resource, e.ResourceID, e.DeliveryID,
+		e.StartTime.Format("2006-01-02 15:04"), e.EndTime.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 到着予定日時モデル
+ * 配送の到着予定日時（ETA）の予測に関するデータ構造を定義する
+ */
+
+// ETABasis 到着予定日時の予測の根拠
+type ETABasis string
+
+const (
+	// ETABasisPosition 最新の追跡イベントの位置から残りの訪問先を巡って予測
+	ETABasisPosition ETABasis = "position"
+	// ETABasisPlan 現在地が不明なため、配送ルートの到着予定日時から予測
+	ETABasisPlan ETABasis = "plan"
+	// ETABasisDirect 配送ルートがないため、最新の追跡イベントの位置から配送先へ直行すると予測
+	ETABasisDirect ETABasis = "direct"
+)
+
+// ETATracking 到着予定日時の予測対象の配送追跡情報
+// NotifiedEstimatedTimeは顧客に最後に通知した（遅延の判定の基準とする）到着予定日時
+type ETATracking struct {
+	TrackingID            string     `json:"tracking_id"`
+	DeliveryID            int64      `json:"delivery_id"`
+	EstimatedTime         *time.Time `json:"estimated_time,omitempty"`
+	NotifiedEstimatedTime *time.Time `json:"notified_estimated_time,omitempty"`
+}
+
+// DeliveryETA 配送の到着予定日時の予測結果
+// Delayは基準の到着予定日時からの遅れ（分、早まった場合は負）
+type DeliveryETA struct {
+	DeliveryID     int64      `json:"delivery_id"`
+	TrackingID     string     `json:"tracking_id"`
+	EstimatedTime  time.Time  `json:"estimated_time"`
+	BaselineTime   *time.Time `json:"baseline_time,omitempty"`
+	Delay          int        `json:"delay"`
+	RemainingStops int        `json:"remaining_stops"`
+	Basis          ETABasis   `json:"basis"`
+	DelayFactor    float64    `json:"delay_factor"`
+	Notified       bool       `json:"notified"`
+}
+
+// LegDurationStats 配送ルートの区間の所要時間の実績
+// 計画の到着予定日時の間隔と、実際の配送完了日時の間隔を集計する
+type LegDurationStats struct {
+	Legs           int     `json:"legs"`
+	PlannedMinutes float64 `json:"planned_minutes"`
+	ActualMinutes  float64 `json:"actual_minutes"`
+}
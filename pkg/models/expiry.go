@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 賞味期限監視モデル
+ * ロット在庫の賞味期限の監視と通知の設定を定義する
+ */
+
+// ExpiryAlertKind 賞味期限通知の種類
+type ExpiryAlertKind string
+
+const (
+	// ExpiryAlertApproaching 賞味期限接近（通知日数以内に賞味期限を迎える）
+	ExpiryAlertApproaching ExpiryAlertKind = "approaching"
+	// ExpiryAlertExpired 賞味期限切れ（在庫を賞味期限切れにした）
+	ExpiryAlertExpired ExpiryAlertKind = "expired"
+)
+
+// ExpiryAlertPolicy 商品カテゴリごとの賞味期限接近の通知日数
+type ExpiryAlertPolicy struct {
+	Category  string    `json:"category"`
+	LeadDays  int       `json:"lead_days"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetExpiryAlertPolicyRequest 賞味期限通知日数設定リクエスト
+type SetExpiryAlertPolicyRequest struct {
+	LeadDays int `json:"lead_days" binding:"required,min=1,max=365"`
+}
+
+// ExpiringStock 賞味期限のあるロット在庫
+type ExpiringStock struct {
+	InventoryID    int64           `json:"inventory_id"`
+	ProductID      int64           `json:"product_id"`
+	ProductName    string          `json:"product_name"`
+	Category       string          `json:"category"`
+	Location       string          `json:"location"`
+	BinID          *int64          `json:"bin_id,omitempty"`
+	LotID          int64           `json:"lot_id"`
+	LotNumber      string          `json:"lot_number"`
+	BestBeforeDate time.Time       `json:"best_before_date"`
+	Quantity       int             `json:"quantity"`
+	Status         InventoryStatus `json:"status"`
+}
+
+// DaysRemaining 指定時刻から賞味期限までの残り日数を返す（期限切れの場合は0以下）
+func (s *ExpiringStock) DaysRemaining(now time.Time) int {
+	return int(s.BestBeforeDate.Sub(now).Hours() / 24)
+}
+
+// ExpiryAlert 賞味期限通知
+type ExpiryAlert struct {
+	Kind          ExpiryAlertKind `json:"kind"`
+	Stock         *ExpiringStock  `json:"stock"`
+	DaysRemaining int             `json:"days_remaining"`
+}
+
+// ExpiryScanResult 賞味期限監視の実行結果
+type ExpiryScanResult struct {
+	ScannedAt   time.Time      `json:"scanned_at"`
+	Expired     int            `json:"expired"`
+	Approaching int            `json:"approaching"`
+	Alerts      []*ExpiryAlert `json:"alerts"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -21,8 +22,22 @@ const (
 	InventoryStatusReserved InventoryStatus = "reserved"
 	// InventoryStatusDiscontinued 取扱終了
 	InventoryStatusDiscontinued InventoryStatus = "discontinued"
+	// InventoryStatusQuarantined 隔離中（破損品などの販売不可在庫）
+	InventoryStatusQuarantined InventoryStatus = "quarantined"
+	// InventoryStatusExpired 賞味期限切れ（販売不可在庫）
+	InventoryStatusExpired InventoryStatus = "expired"
 )
 
+// IsSellable 販売可能な在庫ステータスかどうかを判定する
+// 隔離中・賞味期限切れの在庫は引当・出荷の対象としない
+func (s InventoryStatus) IsSellable() bool {
+	switch s {
+	case InventoryStatusQuarantined, InventoryStatusExpired:
+		return false
+	}
+	return true
+}
+
 // MovementType 在庫移動タイプ
 type MovementType string
 
@@ -35,48 +50,57 @@ const (
 	MovementTypeTransfer MovementType = "transfer"
 	// MovementTypeAdjustment 調整
 	MovementTypeAdjustment MovementType = "adjustment"
+	// MovementTypeReturn 返品
+	MovementTypeReturn MovementType = "return"
 )
 
 // Inventory 在庫情報
+// WarehouseIDはLocationが倉庫名と一致する場合に設定される
+// BinIDは倉庫内のビンに格納されている場合に設定される
+// LotIDはロット管理している在庫の場合に設定される
+// Versionは楽観的排他制御用で、更新のたびに1ずつ増える
 type Inventory struct {
-	ID        int64           `json:"id"`
-	ProductID int64           `json:"product_id"`
-	Quantity  int             `json:"quantity"`
-	Location  string          `json:"location"`
-	Status    InventoryStatus `json:"status"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID          int64           `json:"id"`
+	ProductID   int64           `json:"product_id"`
+	Quantity    int             `json:"quantity"`
+	Location    string          `json:"location"`
+	WarehouseID *int64          `json:"warehouse_id,omitempty"`
+	BinID       *int64          `json:"bin_id,omitempty"`
+	LotID       *int64          `json:"lot_id,omitempty"`
+	Status      InventoryStatus `json:"status"`
+	Version     int             `json:"version"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
 // InventoryMovement 在庫移動履歴
+// ReasonCodeは棚卸による調整の場合に調整理由を設定する
+// Quantityは基本単位の数量で、荷姿を指定した場合はUnitとUnitQuantityに指定した荷姿と数量を記録する
 type InventoryMovement struct {
 	ID              int64        `json:"id"`
 	ProductID       int64        `json:"product_id"`
 	FromLocation    string       `json:"from_location"`
+	FromBinID       *int64       `json:"from_bin_id,omitempty"`
 	ToLocation      string       `json:"to_location"`
+	ToBinID         *int64       `json:"to_bin_id,omitempty"`
+	LotID           *int64       `json:"lot_id,omitempty"`
 	Quantity        int          `json:"quantity"`
+	Unit            string       `json:"unit"`
+	UnitQuantity    int          `json:"unit_quantity"`
 	MovementType    MovementType `json:"movement_type"`
 	MovementDate    time.Time    `json:"movement_date"`
 	ReferenceNumber string       `json:"reference_number"`
+	ReasonCode      string       `json:"reason_code,omitempty"`
 	CreatedAt       time.Time    `json:"created_at"`
 }
 
-// Warehouse 倉庫情報
-type Warehouse struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Address   string    `json:"address"`
-	Capacity  int       `json:"capacity"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
-
 // CreateInventoryRequest 在庫作成リクエスト
 type CreateInventoryRequest struct {
 	ProductID int64           `json:"product_id" binding:"required"`
 	Quantity  int             `json:"quantity" binding:"required,min=0"`
 	Location  string          `json:"location" binding:"required"`
+	BinID     *int64          `json:"bin_id"`
+	LotNumber string          `json:"lot_number"`
 	Status    InventoryStatus `json:"status" binding:"required"`
 }
 
@@ -88,12 +112,35 @@ type UpdateInventoryRequest struct {
 }
 
 // CreateMovementRequest 在庫移動作成リクエスト
+// LotNumberを省略した場合はロット管理していない在庫を移動する
+// Unitに荷姿を指定した場合はQuantityを荷姿の数量とし、基本単位に換算して移動する（省略時は基本単位）
 type CreateMovementRequest struct {
 	ProductID       int64        `json:"product_id" binding:"required"`
 	FromLocation    string       `json:"from_location" binding:"required"`
+	FromBinID       *int64       `json:"from_bin_id"`
 	ToLocation      string       `json:"to_location" binding:"required"`
+	ToBinID         *int64       `json:"to_bin_id"`
+	LotNumber       string       `json:"lot_number"`
 	Quantity        int          `json:"quantity" binding:"required,min=1"`
+	Unit            string       `json:"unit"`
 	MovementType    MovementType `json:"movement_type" binding:"required"`
 	MovementDate    time.Time    `json:"movement_date" binding:"required"`
 	ReferenceNumber string       `json:"reference_number" binding:"required"`
 }
+
+// InsufficientStockError 引当可能な在庫が要求数量に足りない場合のエラー
+// LotIDを指定した場合はロット単位の在庫が不足していることを表す
+type InsufficientStockError struct {
+	ProductID int64
+	LotID     *int64
+	Required  int
+	Available int
+}
+
+// Error エラーメッセージを返す
+func (e *InsufficientStockError) Error() string {
+	if e.LotID != nil {
+		return fmt.Sprintf("ロットの在庫が不足しています（商品ID %d: 要求数量 %d, 引当可能数 %d）", e.ProductID, e.Required, e.Available)
+	}
+	return fmt.Sprintf("在庫が不足しています（商品ID %d: 要求数量 %d, 引当可能数 %d）", e.ProductID, e.Required, e.Available)
+}
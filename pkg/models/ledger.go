@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 在庫元帳モデル
+ * 在庫数の増減を追記のみで記録する元帳と、元帳による在庫数の照合結果を定義する
+ */
+
+// LedgerEntryType 在庫元帳の記帳区分
+type LedgerEntryType string
+
+const (
+	// LedgerEntryTypeOpening 元帳導入時の期首残高
+	LedgerEntryTypeOpening LedgerEntryType = "opening"
+	// LedgerEntryTypeCreate 在庫行の作成
+	LedgerEntryTypeCreate LedgerEntryType = "create"
+	// LedgerEntryTypeChange 在庫数の変更
+	LedgerEntryTypeChange LedgerEntryType = "change"
+	// LedgerEntryTypeDelete 在庫行の削除
+	LedgerEntryTypeDelete LedgerEntryType = "delete"
+)
+
+// LedgerEntry 在庫元帳の記帳
+// Quantityは符号付きの増減数、Balanceは記帳後の在庫行の数量
+// MovementIDは在庫移動に伴う変更の場合、ActorID・RequestIDはAPI経由の変更の場合に設定される
+type LedgerEntry struct {
+	ID          int64           `json:"id"`
+	InventoryID int64           `json:"inventory_id"`
+	ProductID   int64           `json:"product_id"`
+	Location    string          `json:"location"`
+	BinID       *int64          `json:"bin_id,omitempty"`
+	LotID       *int64          `json:"lot_id,omitempty"`
+	Quantity    int             `json:"quantity"`
+	Balance     int             `json:"balance"`
+	EntryType   LedgerEntryType `json:"entry_type"`
+	MovementID  *int64          `json:"movement_id,omitempty"`
+	ActorID     int64           `json:"actor_id,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// LedgerBalance 在庫行の数量と元帳から再計算した残高
+type LedgerBalance struct {
+	InventoryID   int64  `json:"inventory_id"`
+	ProductID     int64  `json:"product_id"`
+	Location      string `json:"location"`
+	Quantity      int    `json:"quantity"`
+	Version       int    `json:"version"`
+	LedgerBalance int    `json:"ledger_balance"`
+}
+
+// Drift 在庫行の数量と元帳残高の差を返す（元帳を経由しない変更があった場合に0以外となる）
+func (b *LedgerBalance) Drift() int {
+	return b.Quantity - b.LedgerBalance
+}
+
+// ReconciliationReport 在庫元帳の照合結果
+// Fixedがtrueの場合、差異のある在庫行の数量を元帳残高に戻している
+// Skippedは照合後に並行して変更・削除されたため戻さなかった在庫行（次回の照合で改めて確認する）
+type ReconciliationReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Checked   int              `json:"checked"`
+	Drifts    []*LedgerBalance `json:"drifts"`
+	Fixed     bool             `json:"fixed"`
+	Skipped   []*LedgerBalance `json:"skipped"`
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * ロケーション階層モデル
+ * 倉庫 → ゾーン → 通路 → ビン の保管場所階層を管理する
+ */
+
+// ZoneType ゾーンの保管環境
+type ZoneType string
+
+const (
+	// ZoneTypeAmbient 常温
+	ZoneTypeAmbient ZoneType = "ambient"
+	// ZoneTypeChilled 冷蔵
+	ZoneTypeChilled ZoneType = "chilled"
+	// ZoneTypeHumidityControlled 恒湿
+	ZoneTypeHumidityControlled ZoneType = "humidity_controlled"
+)
+
+// IsValid 定義済みの保管環境かどうかを判定する
+func (t ZoneType) IsValid() bool {
+	switch t {
+	case ZoneTypeAmbient, ZoneTypeChilled, ZoneTypeHumidityControlled:
+		return true
+	}
+	return false
+}
+
+// Zone 倉庫内のゾーン
+// AllowedCategoriesが空の場合はすべての商品カテゴリを保管できる
+type Zone struct {
+	ID                int64     `json:"id"`
+	WarehouseID       int64     `json:"warehouse_id"`
+	Code              string    `json:"code"`
+	Name              string    `json:"name"`
+	ZoneType          ZoneType  `json:"zone_type"`
+	AllowedCategories []string  `json:"allowed_categories"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// AllowsCategory 商品カテゴリをゾーンに保管できるかどうかを判定する
+func (z *Zone) AllowsCategory(category string) bool {
+	if len(z.AllowedCategories) == 0 {
+		return true
+	}
+	for _, allowed := range z.AllowedCategories {
+		if allowed == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Aisle ゾーン内の通路
+type Aisle struct {
+	ID        int64     `json:"id"`
+	ZoneID    int64     `json:"zone_id"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Bin 通路内のビン（最小の保管単位）
+type Bin struct {
+	ID        int64     `json:"id"`
+	AisleID   int64     `json:"aisle_id"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BinLocation ビンの階層上の位置
+type BinLocation struct {
+	BinID         int64    `json:"bin_id"`
+	BinCode       string   `json:"bin_code"`
+	AisleID       int64    `json:"aisle_id"`
+	AisleCode     string   `json:"aisle_code"`
+	ZoneID        int64    `json:"zone_id"`
+	ZoneCode      string   `json:"zone_code"`
+	ZoneType      ZoneType `json:"zone_type"`
+	WarehouseID   int64    `json:"warehouse_id"`
+	WarehouseName string   `json:"warehouse_name"`
+}
+
+// Path ビンの位置を「倉庫/ゾーン/通路/ビン」形式で返す
+func (l *BinLocation) Path() string {
+	return fmt.Sprintf("%s/%s/%s/%s", l.WarehouseName, l.ZoneCode, l.AisleCode, l.BinCode)
+}
+
+// CreateZoneRequest ゾーン作成リクエスト
+type CreateZoneRequest struct {
+	WarehouseID       int64    `json:"warehouse_id" binding:"required"`
+	Code              string   `json:"code" binding:"required"`
+	Name              string   `json:"name" binding:"required"`
+	ZoneType          ZoneType `json:"zone_type" binding:"required,oneof=ambient chilled humidity_controlled"`
+	AllowedCategories []string `json:"allowed_categories"`
+}
+
+// UpdateZoneCategoriesRequest ゾーン保管カテゴリ更新リクエスト
+type UpdateZoneCategoriesRequest struct {
+	AllowedCategories []string `json:"allowed_categories"`
+}
+
+// CreateAisleRequest 通路作成リクエスト
+type CreateAisleRequest struct {
+	ZoneID int64  `json:"zone_id" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+}
+
+// CreateBinRequest ビン作成リクエスト
+type CreateBinRequest struct {
+	AisleID int64  `json:"aisle_id" binding:"required"`
+	Code    string `json:"code" binding:"required"`
+}
+
+// LocationStockQuery ロケーション階層での在庫集計条件
+// 未指定（ゼロ値）の条件は絞り込みに使用しない
+type LocationStockQuery struct {
+	WarehouseID int64    `form:"warehouse_id"`
+	ZoneID      int64    `form:"zone_id"`
+	ZoneType    ZoneType `form:"zone_type"`
+	AisleID     int64    `form:"aisle_id"`
+	BinID       int64    `form:"bin_id"`
+	ProductID   int64    `form:"product_id"`
+	Category    string   `form:"category"`
+}
+
+// LocationStock ロケーション階層での在庫集計結果
+// ビンに割り当てられていない在庫はZoneIDがnilとなる
+type LocationStock struct {
+	WarehouseID   int64    `json:"warehouse_id"`
+	WarehouseName string   `json:"warehouse_name"`
+	ZoneID        *int64   `json:"zone_id,omitempty"`
+	ZoneCode      string   `json:"zone_code,omitempty"`
+	ZoneType      ZoneType `json:"zone_type,omitempty"`
+	ProductID     int64    `json:"product_id"`
+	ProductName   string   `json:"product_name"`
+	Category      string   `json:"category"`
+	Quantity      int      `json:"quantity"`
+}
+
+// ZoneStorageRuleError ゾーンの保管ルール違反エラー
+type ZoneStorageRuleError struct {
+	ZoneID   int64
+	ZoneCode string
+	Category string
+}
+
+func (e *ZoneStorageRuleError) Error() string {
+	return fmt.Sprintf("ゾーン「%s」にはカテゴリ「%s」の商品を保管できません", e.ZoneCode, e.Category)
+}
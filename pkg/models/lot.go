@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * ロットモデル
+ * 茶葉の収穫ロット（摘採時期・産地・等級・賞味期限）とロット単位のトレーサビリティを定義する
+ */
+
+// HarvestFlush 摘採時期
+type HarvestFlush string
+
+const (
+	// HarvestFlushFirst 一番茶
+	HarvestFlushFirst HarvestFlush = "first_flush"
+	// HarvestFlushSecond 二番茶
+	HarvestFlushSecond HarvestFlush = "second_flush"
+	// HarvestFlushThird 三番茶
+	HarvestFlushThird HarvestFlush = "third_flush"
+	// HarvestFlushAutumn 秋冬番茶
+	HarvestFlushAutumn HarvestFlush = "autumn_flush"
+)
+
+// IsValid 定義済みの摘採時期かどうかを判定する
+func (f HarvestFlush) IsValid() bool {
+	switch f {
+	case HarvestFlushFirst, HarvestFlushSecond, HarvestFlushThird, HarvestFlushAutumn:
+		return true
+	}
+	return false
+}
+
+// Lot 収穫ロット
+type Lot struct {
+	ID             int64        `json:"id"`
+	LotNumber      string       `json:"lot_number"`
+	ProductID      int64        `json:"product_id"`
+	HarvestFlush   HarvestFlush `json:"harvest_flush"`
+	HarvestDate    time.Time    `json:"harvest_date"`
+	OriginEstate   string       `json:"origin_estate"`
+	Grade          string       `json:"grade"`
+	BestBeforeDate time.Time    `json:"best_before_date"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// IsExpired 指定時刻において賞味期限を過ぎているかどうかを判定する
+func (l *Lot) IsExpired(now time.Time) bool {
+	return !l.BestBeforeDate.After(now)
+}
+
+// DeliveryItemLot 配送明細の出荷ロット
+// 出荷確定時に払い出したロットと数量を記録する
+type DeliveryItemLot struct {
+	ID             int64     `json:"id"`
+	DeliveryItemID int64     `json:"delivery_item_id"`
+	LotID          int64     `json:"lot_id"`
+	Quantity       int       `json:"quantity"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// LotDelivery ロットを出荷した配送
+type LotDelivery struct {
+	DeliveryID     int64          `json:"delivery_id"`
+	DeliveryItemID int64          `json:"delivery_item_id"`
+	OrderID        int64          `json:"order_id"`
+	Status         DeliveryStatus `json:"status"`
+	ToAddress      string         `json:"to_address"`
+	Quantity       int            `json:"quantity"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+}
+
+// LotTrace ロットのトレーサビリティ
+// 遡及（産地・入庫からの移動履歴）と追跡（現在の保管場所・出荷先の配送）をまとめる
+type LotTrace struct {
+	Lot        *Lot                 `json:"lot"`
+	Movements  []*InventoryMovement `json:"movements"`
+	Stock      []*Inventory         `json:"stock"`
+	Deliveries []*LotDelivery       `json:"deliveries"`
+}
+
+// CreateLotRequest ロット作成リクエスト
+type CreateLotRequest struct {
+	LotNumber      string       `json:"lot_number" binding:"required"`
+	ProductID      int64        `json:"product_id" binding:"required"`
+	HarvestFlush   HarvestFlush `json:"harvest_flush" binding:"required,oneof=first_flush second_flush third_flush autumn_flush"`
+	HarvestDate    time.Time    `json:"harvest_date" binding:"required"`
+	OriginEstate   string       `json:"origin_estate" binding:"required"`
+	Grade          string       `json:"grade"`
+	BestBeforeDate time.Time    `json:"best_before_date" binding:"required"`
+}
+
+// InvalidLotError 指定したロットを使用できない場合のエラー（未登録・別商品のロット・賞味期限切れ）
+type InvalidLotError struct {
+	LotNumber string
+	Reason    string
+}
+
+// Error エラーメッセージを返す
+func (e *InvalidLotError) Error() string {
+	return fmt.Sprintf("ロット「%s」%s", e.LotNumber, e.Reason)
+}
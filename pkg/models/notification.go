@@ -17,6 +17,16 @@ const (
 	NotificationTypeDeliveryComplete NotificationType = "delivery_complete"
 	// NotificationTypeDeliveryTracking 配送追跡通知
 	NotificationTypeDeliveryTracking NotificationType = "delivery_tracking"
+	// NotificationTypeDeliveryReturn 返品通知
+	NotificationTypeDeliveryReturn NotificationType = "delivery_return"
+	// NotificationTypeDeliveryDelayed 到着遅延通知
+	NotificationTypeDeliveryDelayed NotificationType = "delivery_delayed"
+	// NotificationTypeStockExpiring 賞味期限接近通知
+	NotificationTypeStockExpiring NotificationType = "stock_expiring"
+	// NotificationTypeStockExpired 賞味期限切れ通知
+	NotificationTypeStockExpired NotificationType = "stock_expired"
+	// NotificationTypeReplenishment 在庫補充提案通知
+	NotificationTypeReplenishment NotificationType = "replenishment"
 )
 
 // NotificationStatus 通知ステータス
@@ -0,0 +1,35 @@
+package models
+
+/*
+ * 商品検索モデル
+ * 商品の全文検索の結果・ハイライト・絞り込み用の件数を定義する
+ */
+
+// ProductSearchHit 商品検索の結果の1件
+// Highlightsは検索語に一致した項目（name・sku・category・description）の値で、
+// 一致した箇所を<mark>で囲みHTMLエスケープしたもの（descriptionは一致箇所の前後の抜粋）
+type ProductSearchHit struct {
+	Product    *Product          `json:"product"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchFacet 検索結果の値ごとの件数
+type SearchFacet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ProductSearchFacets 検索語に一致した商品のカテゴリ別・ステータス別の件数
+// カテゴリ・ステータスによる絞り込みの前の件数を返す
+type ProductSearchFacets struct {
+	Categories []SearchFacet `json:"category"`
+	Statuses   []SearchFacet `json:"status"`
+}
+
+// ProductSearchResult 商品検索の結果
+type ProductSearchResult struct {
+	Terms  []string            `json:"terms"`
+	Hits   []*ProductSearchHit `json:"hits"`
+	Facets ProductSearchFacets `json:"facets"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -22,6 +23,8 @@ const (
 )
 
 // Product 商品情報
+// 在庫数量は基本単位（グラム）で管理し、Priceは基本単位あたりの単価とする
+// 荷姿ごとの販売価格・重量はProductVariantで管理する
 type Product struct {
 	ID          int64         `json:"id" db:"id"`
 	Name        string        `json:"name" db:"name"`
@@ -30,6 +33,7 @@ type Product struct {
 	Category    string        `json:"category" db:"category"`
 	Price       float64       `json:"price" db:"price"`
 	Status      ProductStatus `json:"status" db:"status"`
+	Version     int           `json:"version" db:"version"`
 	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
 }
@@ -52,4 +56,15 @@ type UpdateProductRequest struct {
 	Category    string        `json:"category" binding:"required"`
 	Price       float64       `json:"price" binding:"required,gt=0"`
 	Status      ProductStatus `json:"status" binding:"required,oneof=active inactive discontinued"`
+	// ExpectedVersion If-Matchヘッダーで指定された更新前提のバージョン（0の場合は確認しない）
+	ExpectedVersion int `json:"-"`
+}
+
+// ProductInUseError 在庫・在庫元帳・発注などから参照されている商品を削除しようとした場合のエラー
+type ProductInUseError struct {
+	ProductID int64
+}
+
+func (e *ProductInUseError) Error() string {
+	return fmt.Sprintf("商品（ID %d）は在庫・在庫元帳・発注などで使用されているため削除できません。販売終了にしてください", e.ProductID)
 }
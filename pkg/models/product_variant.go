@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 荷姿モデル
+ * 商品の荷姿（100g袋・1kg缶・20kg袋など）と単位換算のデータ構造を定義する
+ * 在庫数量は商品の基本単位（グラム）で管理し、荷姿の数量は換算係数で基本単位に換算する
+ */
+
+// BaseUnit 基本単位（在庫数量の単位）
+const BaseUnit = "g"
+
+// IsBaseUnit 基本単位かどうかを判定する（省略した場合は基本単位とする）
+func IsBaseUnit(unit string) bool {
+	return unit == "" || unit == BaseUnit
+}
+
+// ProductVariant 商品の荷姿
+// ConversionFactorは1荷姿あたりの基本単位の数量（100g袋なら100）
+// WeightGramsは包装を含む1荷姿あたりの重量、VolumeMLは1荷姿あたりの容積で、出荷重量・容積の計算に使用する
+type ProductVariant struct {
+	ID               int64     `json:"id"`
+	ProductID        int64     `json:"product_id"`
+	Unit             string    `json:"unit"`
+	Name             string    `json:"name"`
+	SKU              string    `json:"sku"`
+	ConversionFactor int       `json:"conversion_factor"`
+	Price            float64   `json:"price"`
+	WeightGrams      int       `json:"weight_g"`
+	VolumeML         int       `json:"volume_ml"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ToBase 荷姿の数量を基本単位の数量に換算する
+func (v *ProductVariant) ToBase(quantity int) int {
+	return quantity * v.ConversionFactor
+}
+
+// Packages 基本単位の数量を荷姿の数に換算する（端数は1荷姿に切り上げる）
+func (v *ProductVariant) Packages(baseQuantity int) int {
+	return (baseQuantity + v.ConversionFactor - 1) / v.ConversionFactor
+}
+
+// UnknownUnitError 商品に登録されていない荷姿を指定した場合のエラー
+type UnknownUnitError struct {
+	ProductID int64
+	Unit      string
+}
+
+func (e *UnknownUnitError) Error() string {
+	return fmt.Sprintf("荷姿「%s」は商品ID %d に登録されていません", e.Unit, e.ProductID)
+}
+
+// ProductVariantRequest 荷姿作成・更新リクエスト
+type ProductVariantRequest struct {
+	Unit             string  `json:"unit" binding:"required,max=50"`
+	Name             string  `json:"name" binding:"required"`
+	SKU              string  `json:"sku" binding:"required"`
+	ConversionFactor int     `json:"conversion_factor" binding:"required,min=1"`
+	Price            float64 `json:"price" binding:"min=0"`
+	WeightGrams      int     `json:"weight_g" binding:"min=0"`
+	VolumeML         int     `json:"volume_ml" binding:"min=0"`
+}
+
+// UnitConversion 単位換算結果
+type UnitConversion struct {
+	ProductID    int64  `json:"product_id"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Quantity     int    `json:"quantity"`
+	BaseQuantity int    `json:"base_quantity"`
+	Result       int    `json:"result"`
+	// Remainderは換算先の単位に満たない基本単位の数量
+	Remainder int `json:"remainder"`
+}
+
+// ShipmentLine 配送明細の出荷重量・容積
+// Quantityは基本単位、UnitQuantityは明細の荷姿の数量（端数は1荷姿に切り上げる）
+type ShipmentLine struct {
+	DeliveryItemID int64  `json:"delivery_item_id"`
+	ProductID      int64  `json:"product_id"`
+	Unit           string `json:"unit"`
+	UnitQuantity   int    `json:"unit_quantity"`
+	Quantity       int    `json:"quantity"`
+	WeightGrams    int    `json:"weight_g"`
+	VolumeML       int    `json:"volume_ml"`
+}
+
+// Shipment 配送の出荷重量・容積
+// キャンセル分を除いた数量で計算し、容積を登録していない荷姿・基本単位の明細は容積に含めない
+type Shipment struct {
+	DeliveryID  int64           `json:"delivery_id"`
+	WeightGrams int             `json:"weight_g"`
+	VolumeML    int             `json:"volume_ml"`
+	Lines       []*ShipmentLine `json:"lines"`
+}
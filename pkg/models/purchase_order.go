@@ -0,0 +1,166 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 発注・入荷モデル
+ * 仕入先、発注書と発注明細、入荷実績を定義する
+ */
+
+// Supplier 仕入先
+type Supplier struct {
+	ID          int64     `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	ContactName string    `json:"contact_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Address     string    `json:"address"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateSupplierRequest 仕入先作成リクエスト
+type CreateSupplierRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	ContactName string `json:"contact_name"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Phone       string `json:"phone"`
+	Address     string `json:"address"`
+}
+
+// UpdateSupplierRequest 仕入先更新リクエスト
+type UpdateSupplierRequest struct {
+	Name        string `json:"name" binding:"required"`
+	ContactName string `json:"contact_name"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Phone       string `json:"phone"`
+	Address     string `json:"address"`
+}
+
+// PurchaseOrderStatus 発注ステータス
+type PurchaseOrderStatus string
+
+const (
+	// PurchaseOrderStatusOpen 発注済み（未入荷）
+	PurchaseOrderStatusOpen PurchaseOrderStatus = "open"
+	// PurchaseOrderStatusPartiallyReceived 一部入荷
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	// PurchaseOrderStatusClosed 完了（入荷済み、または残数量を打ち切り）
+	PurchaseOrderStatusClosed PurchaseOrderStatus = "closed"
+	// PurchaseOrderStatusCancelled キャンセル
+	PurchaseOrderStatusCancelled PurchaseOrderStatus = "cancelled"
+)
+
+// IsReceivable 入荷を受け付けるステータスかどうかを判定する
+func (s PurchaseOrderStatus) IsReceivable() bool {
+	return s == PurchaseOrderStatusOpen || s == PurchaseOrderStatusPartiallyReceived
+}
+
+// PurchaseOrderStatusError 発注のステータスにより操作できない場合のエラー
+type PurchaseOrderStatusError struct {
+	PONumber string
+	Status   PurchaseOrderStatus
+	Action   string
+}
+
+// Error エラーメッセージを返す
+func (e *PurchaseOrderStatusError) Error() string {
+	return fmt.Sprintf("ステータスが「%s」の発注「%s」は%sできません", e.Status, e.PONumber, e.Action)
+}
+
+// PurchaseOrder 発注書
+// Locationは入荷先のロケーション（倉庫名）
+type PurchaseOrder struct {
+	ID              int64                       `json:"id"`
+	PONumber        string                      `json:"po_number"`
+	SupplierID      int64                       `json:"supplier_id"`
+	Location        string                      `json:"location"`
+	Status          PurchaseOrderStatus         `json:"status"`
+	ExpectedArrival time.Time                   `json:"expected_arrival"`
+	Notes           string                      `json:"notes"`
+	CreatedBy       int64                       `json:"created_by"`
+	ClosedAt        *time.Time                  `json:"closed_at,omitempty"`
+	Lines           []*PurchaseOrderLine        `json:"lines,omitempty"`
+	Receipts        []*PurchaseOrderReceiptLine `json:"receipts,omitempty"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	UpdatedAt       time.Time                   `json:"updated_at"`
+}
+
+// PurchaseOrderLine 発注明細
+type PurchaseOrderLine struct {
+	ID               int64 `json:"id"`
+	PurchaseOrderID  int64 `json:"purchase_order_id"`
+	ProductID        int64 `json:"product_id"`
+	OrderedQuantity  int   `json:"ordered_quantity"`
+	ReceivedQuantity int   `json:"received_quantity"`
+}
+
+// OutstandingQuantity 未入荷の数量を返す（過剰入荷の場合は0）
+func (l *PurchaseOrderLine) OutstandingQuantity() int {
+	if l.ReceivedQuantity >= l.OrderedQuantity {
+		return 0
+	}
+	return l.OrderedQuantity - l.ReceivedQuantity
+}
+
+// CreatePurchaseOrderLineRequest 発注明細作成リクエスト
+type CreatePurchaseOrderLineRequest struct {
+	ProductID int64 `json:"product_id" binding:"required"`
+	Quantity  int   `json:"quantity" binding:"required,min=1"`
+}
+
+// CreatePurchaseOrderRequest 発注書作成リクエスト
+// PONumberを省略した場合は採番する
+type CreatePurchaseOrderRequest struct {
+	PONumber        string                           `json:"po_number"`
+	SupplierID      int64                            `json:"supplier_id" binding:"required"`
+	Location        string                           `json:"location" binding:"required"`
+	ExpectedArrival time.Time                        `json:"expected_arrival" binding:"required"`
+	Notes           string                           `json:"notes"`
+	Lines           []CreatePurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// ReceivePurchaseOrderLineRequest 入荷明細リクエスト
+// LotNumberを指定した場合はロット在庫として入庫し、BinIDを指定した場合はビンに格納する
+type ReceivePurchaseOrderLineRequest struct {
+	LineID    int64  `json:"line_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	LotNumber string `json:"lot_number"`
+	BinID     *int64 `json:"bin_id"`
+}
+
+// ReceivePurchaseOrderRequest 入荷リクエスト
+// CloseShortを指定した場合は未入荷の残数量を打ち切って発注を完了する
+type ReceivePurchaseOrderRequest struct {
+	Lines      []ReceivePurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+	CloseShort bool                              `json:"close_short"`
+}
+
+// PurchaseOrderReceiptLine 発注明細ごとの入荷実績
+// ExpectedQuantityは入荷時点の未入荷数量、Varianceは入荷数量との差（正は過剰、負は不足）
+type PurchaseOrderReceiptLine struct {
+	ID               int64     `json:"id"`
+	PurchaseOrderID  int64     `json:"purchase_order_id"`
+	LineID           int64     `json:"line_id"`
+	ProductID        int64     `json:"product_id"`
+	LotID            *int64    `json:"lot_id,omitempty"`
+	ExpectedQuantity int       `json:"expected_quantity"`
+	ReceivedQuantity int       `json:"received_quantity"`
+	Variance         int       `json:"variance"`
+	ReceivedBy       int64     `json:"received_by"`
+	ReceivedAt       time.Time `json:"received_at"`
+}
+
+// PurchaseOrderReceipt 入荷結果
+type PurchaseOrderReceipt struct {
+	PurchaseOrderID int64                       `json:"purchase_order_id"`
+	PONumber        string                      `json:"po_number"`
+	Status          PurchaseOrderStatus         `json:"status"`
+	Lines           []*PurchaseOrderReceiptLine `json:"lines"`
+	Movements       []*InventoryMovement        `json:"movements"`
+}
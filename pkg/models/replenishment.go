@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 在庫補充モデル
+ * 発注点・安全在庫の設定と在庫補充提案を定義する
+ */
+
+// ReorderSetting 商品・ロケーションごとの発注点設定
+type ReorderSetting struct {
+	ID              int64     `json:"id"`
+	ProductID       int64     `json:"product_id"`
+	Location        string    `json:"location"`
+	ReorderPoint    int       `json:"reorder_point"`
+	SafetyStock     int       `json:"safety_stock"`
+	ReorderQuantity int       `json:"reorder_quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SetReorderSettingRequest 発注点設定リクエスト
+type SetReorderSettingRequest struct {
+	ProductID       int64  `json:"product_id" binding:"required"`
+	Location        string `json:"location" binding:"required"`
+	ReorderPoint    int    `json:"reorder_point" binding:"min=0"`
+	SafetyStock     int    `json:"safety_stock" binding:"min=0"`
+	ReorderQuantity int    `json:"reorder_quantity" binding:"required,min=1"`
+}
+
+// ReplenishmentProposalType 在庫補充提案の種類
+type ReplenishmentProposalType string
+
+const (
+	// ReplenishmentProposalTransfer 他の倉庫からの在庫移動
+	ReplenishmentProposalTransfer ReplenishmentProposalType = "transfer"
+	// ReplenishmentProposalPurchase 仕入先への発注
+	ReplenishmentProposalPurchase ReplenishmentProposalType = "purchase"
+)
+
+// ReplenishmentProposalStatus 在庫補充提案のステータス
+type ReplenishmentProposalStatus string
+
+const (
+	// ReplenishmentProposalOpen 未対応
+	ReplenishmentProposalOpen ReplenishmentProposalStatus = "open"
+	// ReplenishmentProposalAccepted 採用
+	ReplenishmentProposalAccepted ReplenishmentProposalStatus = "accepted"
+	// ReplenishmentProposalDismissed 見送り
+	ReplenishmentProposalDismissed ReplenishmentProposalStatus = "dismissed"
+)
+
+// ReplenishmentProposal 在庫補充提案
+// 移動の提案ではSourceLocationに移動元の倉庫を設定する
+type ReplenishmentProposal struct {
+	ID                 int64                       `json:"id"`
+	ProductID          int64                       `json:"product_id"`
+	Location           string                      `json:"location"`
+	Type               ReplenishmentProposalType   `json:"type"`
+	SourceLocation     string                      `json:"source_location,omitempty"`
+	Quantity           int                         `json:"quantity"`
+	AvailableToPromise int                         `json:"available_to_promise"`
+	ReorderPoint       int                         `json:"reorder_point"`
+	SafetyStock        int                         `json:"safety_stock"`
+	Status             ReplenishmentProposalStatus `json:"status"`
+	CreatedAt          time.Time                   `json:"created_at"`
+	UpdatedAt          time.Time                   `json:"updated_at"`
+}
+
+// BelowSafetyStock 提案時点の引当可能数が安全在庫を下回っていたかどうかを判定する
+func (p *ReplenishmentProposal) BelowSafetyStock() bool {
+	return p.AvailableToPromise < p.SafetyStock
+}
+
+// UpdateReplenishmentProposalRequest 在庫補充提案のステータス更新リクエスト
+type UpdateReplenishmentProposalRequest struct {
+	Status ReplenishmentProposalStatus `json:"status" binding:"required,oneof=accepted dismissed"`
+}
+
+// ReplenishmentEvaluation 発注点評価の実行結果
+type ReplenishmentEvaluation struct {
+	EvaluatedAt time.Time                `json:"evaluated_at"`
+	Evaluated   int                      `json:"evaluated"`
+	OutOfStock  int                      `json:"out_of_stock"`
+	Proposals   []*ReplenishmentProposal `json:"proposals"`
+}
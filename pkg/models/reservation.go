@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 在庫引当モデル
+ * 受注から出荷までの間に確保する在庫の引当情報を定義する
+ */
+
+// ReservationStatus 引当ステータス
+type ReservationStatus string
+
+const (
+	// ReservationStatusActive 引当中
+	ReservationStatusActive ReservationStatus = "active"
+	// ReservationStatusReleased 解放済み
+	ReservationStatusReleased ReservationStatus = "released"
+	// ReservationStatusCommitted 確定済み（出庫済み）
+	ReservationStatusCommitted ReservationStatus = "committed"
+	// ReservationStatusExpired 期限切れ
+	ReservationStatusExpired ReservationStatus = "expired"
+)
+
+// Reservation 在庫引当
+// LotIDを設定した場合は指定ロットの在庫を引き当てる
+type Reservation struct {
+	ID              int64             `json:"id"`
+	ProductID       int64             `json:"product_id"`
+	Location        string            `json:"location"`
+	LotID           *int64            `json:"lot_id,omitempty"`
+	Quantity        int               `json:"quantity"`
+	DeliveryID      *int64            `json:"delivery_id,omitempty"`
+	DeliveryItemID  *int64            `json:"delivery_item_id,omitempty"`
+	ReferenceNumber string            `json:"reference_number"`
+	Status          ReservationStatus `json:"status"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// IsExpired 指定時刻において期限切れかどうかを確認する
+func (r *Reservation) IsExpired(now time.Time) bool {
+	return r.Status == ReservationStatusActive && r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
+
+// InventoryAvailability ロケーション単位の在庫可用性
+type InventoryAvailability struct {
+	ProductID          int64  `json:"product_id"`
+	Location           string `json:"location"`
+	OnHand             int    `json:"on_hand"`
+	Reserved           int    `json:"reserved"`
+	AvailableToPromise int    `json:"available_to_promise"`
+}
+
+// CreateReservationRequest 在庫引当作成リクエスト
+type CreateReservationRequest struct {
+	ProductID       int64  `json:"product_id" binding:"required"`
+	Location        string `json:"location" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	ReferenceNumber string `json:"reference_number" binding:"required"`
+	TTLMinutes      int    `json:"ttl_minutes" binding:"omitempty,min=1"`
+}
@@ -13,12 +13,14 @@ const (
 	RoleManager  Role = "manager"
 	RoleOperator Role = "operator"
 	RoleViewer   Role = "viewer"
+	// RoleDriver 運転手（自分の配送予定表のみ閲覧できる）
+	RoleDriver Role = "driver"
 )
 
 // IsValidRole ロールが有効かどうかを確認する
 func IsValidRole(role Role) bool {
 	switch role {
-	case RoleAdmin, RoleManager, RoleOperator, RoleViewer:
+	case RoleAdmin, RoleManager, RoleOperator, RoleViewer, RoleDriver:
 		return true
 	default:
 		return false
@@ -36,6 +38,8 @@ func HasPermission(role Role, required Role) bool {
 		return required == RoleOperator || required == RoleViewer
 	case RoleViewer:
 		return required == RoleViewer
+	case RoleDriver:
+		return required == RoleDriver
 	default:
 		return false
 	}
@@ -0,0 +1,23 @@
+package models
+
+/*
+ * 配送ルートモデル
+ * 車両の1日の配送ルート（訪問順・到着予定日時）に関するデータ構造を定義する
+ */
+
+const (
+	// RouteStatusPlanned 計画済み
+	RouteStatusPlanned = "planned"
+	// RouteStatusUnlocated 配送先の位置情報がないため訪問順を計画していない
+	RouteStatusUnlocated = "unlocated"
+)
+
+// VehicleRoute 車両の1日の配送ルート
+// TotalDistance・TotalDurationは計画済みの訪問先の距離（km）と移動時間（分）の合計
+type VehicleRoute struct {
+	VehicleID     int64    `json:"vehicle_id"`
+	Date          string   `json:"date"`
+	TotalDistance float64  `json:"total_distance"`
+	TotalDuration int      `json:"total_duration"`
+	Stops         []*Route `json:"stops"`
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 棚卸モデル
+ * ロケーション単位の棚卸（実地棚卸・循環棚卸）と差異の承認を定義する
+ */
+
+// StockCountStatus 棚卸ステータス
+type StockCountStatus string
+
+const (
+	// StockCountStatusOpen 棚卸中（ロケーションを凍結して実数を入力する）
+	StockCountStatusOpen StockCountStatus = "open"
+	// StockCountStatusSubmitted 承認待ち
+	StockCountStatusSubmitted StockCountStatus = "submitted"
+	// StockCountStatusApproved 承認済み（差異を在庫に反映済み）
+	StockCountStatusApproved StockCountStatus = "approved"
+	// StockCountStatusRejected 却下（在庫に反映しない）
+	StockCountStatusRejected StockCountStatus = "rejected"
+	// StockCountStatusCancelled 中止
+	StockCountStatusCancelled StockCountStatus = "cancelled"
+)
+
+// FreezesLocation ロケーションを凍結するステータスかどうかを判定する
+func (s StockCountStatus) FreezesLocation() bool {
+	return s == StockCountStatusOpen || s == StockCountStatusSubmitted
+}
+
+// AdjustmentReason 棚卸差異の調整理由コード
+type AdjustmentReason string
+
+const (
+	// AdjustmentReasonDamaged 破損・汚損
+	AdjustmentReasonDamaged AdjustmentReason = "damaged"
+	// AdjustmentReasonShrinkage 紛失・盗難
+	AdjustmentReasonShrinkage AdjustmentReason = "shrinkage"
+	// AdjustmentReasonFound 帳簿外在庫の発見
+	AdjustmentReasonFound AdjustmentReason = "found"
+	// AdjustmentReasonRecordError 入出庫の記録誤り
+	AdjustmentReasonRecordError AdjustmentReason = "record_error"
+	// AdjustmentReasonOther その他
+	AdjustmentReasonOther AdjustmentReason = "other"
+)
+
+// IsValid 有効な調整理由コードかどうかを判定する
+func (r AdjustmentReason) IsValid() bool {
+	switch r {
+	case AdjustmentReasonDamaged,
+		AdjustmentReasonShrinkage,
+		AdjustmentReasonFound,
+		AdjustmentReasonRecordError,
+		AdjustmentReasonOther:
+		return true
+	}
+	return false
+}
+
+// StockCount 棚卸
+type StockCount struct {
+	ID         int64             `json:"id"`
+	Location   string            `json:"location"`
+	Status     StockCountStatus  `json:"status"`
+	Notes      string            `json:"notes"`
+	CreatedBy  int64             `json:"created_by"`
+	ReviewedBy int64             `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time        `json:"reviewed_at,omitempty"`
+	ReviewNote string            `json:"review_note,omitempty"`
+	Lines      []*StockCountLine `json:"lines,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Reference 棚卸の在庫移動に設定する参照番号を返す
+func (c *StockCount) Reference() string {
+	return fmt.Sprintf("CNT-%d", c.ID)
+}
+
+// StockCountLine 棚卸明細
+// ExpectedQuantityは棚卸開始時点の帳簿数量、CountedQuantityは入力された実数（未入力の場合はnil）
+// InventoryIDは帳簿外の在庫を発見した明細ではnil
+type StockCountLine struct {
+	ID               int64            `json:"id"`
+	StockCountID     int64            `json:"stock_count_id"`
+	InventoryID      *int64           `json:"inventory_id,omitempty"`
+	ProductID        int64            `json:"product_id"`
+	BinID            *int64           `json:"bin_id,omitempty"`
+	LotID            *int64           `json:"lot_id,omitempty"`
+	Status           InventoryStatus  `json:"status"`
+	ExpectedQuantity int              `json:"expected_quantity"`
+	CountedQuantity  *int             `json:"counted_quantity,omitempty"`
+	ReasonCode       AdjustmentReason `json:"reason_code,omitempty"`
+}
+
+// Variance 実数と帳簿数量の差を返す（未入力の場合は0）
+func (l *StockCountLine) Variance() int {
+	if l.CountedQuantity == nil {
+		return 0
+	}
+	return *l.CountedQuantity - l.ExpectedQuantity
+}
+
+// CreateStockCountRequest 棚卸開始リクエスト
+type CreateStockCountRequest struct {
+	Location string `json:"location" binding:"required"`
+	Notes    string `json:"notes"`
+}
+
+// StockCountEntry 棚卸の実数入力
+// 帳簿上の明細はLineIDを、帳簿外の在庫はProductID（必要に応じてBinID・LotNumber）を指定する
+type StockCountEntry struct {
+	LineID          int64            `json:"line_id"`
+	ProductID       int64            `json:"product_id"`
+	BinID           *int64           `json:"bin_id"`
+	LotNumber       string           `json:"lot_number"`
+	CountedQuantity int              `json:"counted_quantity" binding:"min=0"`
+	ReasonCode      AdjustmentReason `json:"reason_code"`
+}
+
+// RecordStockCountRequest 棚卸の実数入力リクエスト
+type RecordStockCountRequest struct {
+	Entries []StockCountEntry `json:"entries" binding:"required,min=1,dive"`
+}
+
+// ReviewStockCountRequest 棚卸の却下リクエスト
+type ReviewStockCountRequest struct {
+	Reason string `json:"reason"`
+}
+
+// StockCountResult 棚卸の承認結果
+type StockCountResult struct {
+	StockCount *StockCount          `json:"stock_count"`
+	Movements  []*InventoryMovement `json:"movements"`
+}
+
+// StockCountStatusError 棚卸のステータスにより操作できない場合のエラー
+type StockCountStatusError struct {
+	StockCountID int64
+	Status       StockCountStatus
+	Action       string
+}
+
+// Error エラーメッセージを返す
+func (e *StockCountStatusError) Error() string {
+	return fmt.Sprintf("ステータスが「%s」の棚卸（棚卸ID %d）は%sできません", e.Status, e.StockCountID, e.Action)
+}
+
+// LocationFrozenError 棚卸中のロケーションで在庫を変更しようとした場合のエラー
+type LocationFrozenError struct {
+	Location     string
+	StockCountID int64
+}
+
+// Error エラーメッセージを返す
+func (e *LocationFrozenError) Error() string {
+	return fmt.Sprintf("ロケーション「%s」は棚卸中（棚卸ID %d）のため在庫を変更できません", e.Location, e.StockCountID)
+}
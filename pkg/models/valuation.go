@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+)
+
+/*
+ * 在庫評価モデル
+ * 評価日時点の在庫数量・日次スナップショット・原価法による在庫評価を定義する
+ */
+
+// ValuationMethod 在庫評価方法
+type ValuationMethod string
+
+const (
+	// ValuationMethodWeightedAverage 総平均法（評価日までの入庫原価の加重平均）
+	ValuationMethodWeightedAverage ValuationMethod = "weighted_average"
+	// ValuationMethodFIFO 先入先出法（残っている在庫は直近の入庫分とみなす）
+	ValuationMethodFIFO ValuationMethod = "fifo"
+)
+
+// IsValid 有効な在庫評価方法かどうかを判定する
+func (m ValuationMethod) IsValid() bool {
+	return m == ValuationMethodWeightedAverage || m == ValuationMethodFIFO
+}
+
+// StockSource 評価日時点の在庫数量の算出元
+type StockSource string
+
+const (
+	// StockSourceSnapshot 日次スナップショット
+	StockSourceSnapshot StockSource = "snapshot"
+	// StockSourceLedger 現在の在庫から評価日以降の在庫移動を戻して算出
+	StockSourceLedger StockSource = "ledger"
+)
+
+// StockLevel 商品・ロケーションごとの在庫数量
+type StockLevel struct {
+	ProductID int64  `json:"product_id"`
+	Location  string `json:"location"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ProductCost 評価日時点の商品情報と単価
+type ProductCost struct {
+	ProductID int64   `json:"product_id"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Category  string  `json:"category"`
+	Price     float64 `json:"price"`
+}
+
+// CostLayer 入庫1件分の原価レイヤー
+// UnitCostは入庫日時点の商品単価
+type CostLayer struct {
+	ProductID    int64     `json:"product_id"`
+	MovementDate time.Time `json:"movement_date"`
+	Quantity     int       `json:"quantity"`
+	UnitCost     float64   `json:"unit_cost"`
+}
+
+// InventorySnapshot 日次在庫スナップショット
+type InventorySnapshot struct {
+	SnapshotDate time.Time     `json:"snapshot_date"`
+	LineCount    int           `json:"line_count"`
+	Lines        []*StockLevel `json:"lines,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// CreateInventorySnapshotRequest 日次スナップショット作成リクエスト
+// Dateは前日以前の日付（YYYY-MM-DD）
+type CreateInventorySnapshotRequest struct {
+	Date string `json:"date" binding:"required"`
+}
+
+// StockAsOfLine 評価日時点の商品・ロケーションごとの在庫
+type StockAsOfLine struct {
+	ProductID   int64  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	SKU         string `json:"sku"`
+	Category    string `json:"category"`
+	Location    string `json:"location"`
+	Quantity    int    `json:"quantity"`
+}
+
+// StockAsOfReport 評価日時点の在庫一覧
+type StockAsOfReport struct {
+	Date   string           `json:"date"`
+	Source StockSource      `json:"source"`
+	Lines  []*StockAsOfLine `json:"lines"`
+}
+
+// ValuationFilter 在庫評価の絞り込み条件（空の場合はすべて）
+type ValuationFilter struct {
+	Location string
+	Category string
+}
+
+// ValuationLine 商品・ロケーションごとの在庫評価額
+type ValuationLine struct {
+	StockAsOfLine
+	UnitCost float64 `json:"unit_cost"`
+	Value    float64 `json:"value"`
+}
+
+// ValuationSummary 倉庫またはカテゴリごとの在庫評価額の集計
+type ValuationSummary struct {
+	Key      string  `json:"key"`
+	Quantity int     `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+// ValuationReport 在庫評価レポート
+type ValuationReport struct {
+	Date          string              `json:"date"`
+	Method        ValuationMethod     `json:"method"`
+	Source        StockSource         `json:"source"`
+	Lines         []*ValuationLine    `json:"lines"`
+	ByWarehouse   []*ValuationSummary `json:"by_warehouse"`
+	ByCategory    []*ValuationSummary `json:"by_category"`
+	TotalQuantity int                 `json:"total_quantity"`
+	TotalValue    float64             `json:"total_value"`
+}
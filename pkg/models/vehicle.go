@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 車両モデル
+ * 配送車両と整備予定、車両への配送の割当に関するデータ構造を定義する
+ */
+
+// VehicleStatus 車両ステータス
+type VehicleStatus string
+
+const (
+	// VehicleStatusAvailable 稼働可能
+	VehicleStatusAvailable VehicleStatus = "available"
+	// VehicleStatusInService 配送中
+	VehicleStatusInService VehicleStatus = "in_service"
+	// VehicleStatusMaintenance 整備中
+	VehicleStatusMaintenance VehicleStatus = "maintenance"
+	// VehicleStatusRetired 廃車
+	VehicleStatusRetired VehicleStatus = "retired"
+)
+
+// IsValid 定義済みの車両ステータスかどうかを確認する
+func (s VehicleStatus) IsValid() bool {
+	switch s {
+	case VehicleStatusAvailable, VehicleStatusInService, VehicleStatusMaintenance, VehicleStatusRetired:
+		return true
+	}
+	return false
+}
+
+// IsAssignable 配送を割り当てられる車両ステータスかどうかを判定する
+// 配送中の車両には、積載量の範囲で別の配送を追加で割り当てられる
+func (s VehicleStatus) IsAssignable() bool {
+	return s == VehicleStatusAvailable || s == VehicleStatusInService
+}
+
+// Vehicle 配送車両情報
+// Capacityは最大積載量（kg）、VolumeCapacityは荷室容積（L、0の場合は容積を確認しない）
+// Refrigeratedは冷蔵・冷凍設備の有無
+type Vehicle struct {
+	ID             int64         `json:"id"`
+	VehicleNumber  string        `json:"vehicle_number"`
+	Type           string        `json:"type"`
+	Capacity       float64       `json:"capacity"`
+	VolumeCapacity float64       `json:"volume_capacity"`
+	Refrigerated   bool          `json:"refrigerated"`
+	Status         VehicleStatus `json:"status"`
+	LastLocation   string        `json:"last_location"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// CapacityGrams 最大積載量をグラムで返す
+func (v *Vehicle) CapacityGrams() int {
+	return int(v.Capacity * 1000)
+}
+
+// VolumeCapacityML 荷室容積をミリリットルで返す
+func (v *Vehicle) VolumeCapacityML() int {
+	return int(v.VolumeCapacity * 1000)
+}
+
+// VehicleRequest 車両登録・更新リクエスト
+type VehicleRequest struct {
+	VehicleNumber  string        `json:"vehicle_number" binding:"required"`
+	Type           string        `json:"type" binding:"required"`
+	Capacity       float64       `json:"capacity" binding:"required,gt=0"`
+	VolumeCapacity float64       `json:"volume_capacity" binding:"min=0"`
+	Refrigerated   bool          `json:"refrigerated"`
+	Status         VehicleStatus `json:"status" binding:"omitempty,oneof=available in_service maintenance retired"`
+	LastLocation   string        `json:"last_location"`
+}
+
+// UpdateVehicleStatusRequest 車両ステータス更新リクエスト
+// LastLocationを省略した場合は最終位置を変更しない
+type UpdateVehicleStatusRequest struct {
+	Status       VehicleStatus `json:"status" binding:"required,oneof=available in_service maintenance retired"`
+	LastLocation string        `json:"last_location"`
+}
+
+// VehicleMaintenance 車両の整備予定
+type VehicleMaintenance struct {
+	ID        int64     `json:"id"`
+	VehicleID int64     `json:"vehicle_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateMaintenanceRequest 整備予定登録リクエスト
+type CreateMaintenanceRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason" binding:"required"`
+}
+
+// AssignVehicleRequest 車両への配送割当リクエスト
+// RequiresRefrigerationを指定した場合は冷蔵・冷凍設備のある車両にのみ割り当てる
+type AssignVehicleRequest struct {
+	DeliveryID            int64     `json:"delivery_id" binding:"required"`
+	StartTime             time.Time `json:"start_time" binding:"required"`
+	EndTime               time.Time `json:"end_time" binding:"required"`
+	RequiresRefrigeration bool      `json:"requires_refrigeration"`
+}
+
+// VehicleAvailabilityQuery 稼働可能な車両の検索条件
+// WeightGrams・VolumeMLを指定した場合は、期間中の積載量に空きのある車両だけを返す
+type VehicleAvailabilityQuery struct {
+	StartTime    time.Time
+	EndTime      time.Time
+	WeightGrams  int
+	VolumeML     int
+	Refrigerated bool
+}
+
+// VehicleAvailability 期間中の車両の空き状況
+type VehicleAvailability struct {
+	Vehicle           *Vehicle `json:"vehicle"`
+	LoadedWeightGrams int      `json:"loaded_weight_g"`
+	LoadedVolumeML    int      `json:"loaded_volume_ml"`
+	RemainingGrams    int      `json:"remaining_weight_g"`
+	RemainingVolumeML *int     `json:"remaining_volume_ml,omitempty"`
+}
+
+// VehicleScheduleEntry 車両の配送履歴の1件（配送スケジュールと配送の概要）
+type VehicleScheduleEntry struct {
+	*DeliverySchedule
+	OrderID        int64          `json:"order_id"`
+	DeliveryStatus DeliveryStatus `json:"delivery_status"`
+	ToAddress      string         `json:"to_address"`
+}
+
+// VehicleHistory 車両の配送履歴と整備履歴
+type VehicleHistory struct {
+	Vehicle     *Vehicle                `json:"vehicle"`
+	Schedules   []*VehicleScheduleEntry `json:"schedules"`
+	Maintenance []*VehicleMaintenance   `json:"maintenance"`
+}
+
+// VehicleUnavailableError 車両を割り当てられない場合のエラー（整備中・廃車・設備不足など）
+type VehicleUnavailableError struct {
+	VehicleID     int64
+	VehicleNumber string
+	Reason        string
+}
+
+func (e *VehicleUnavailableError) Error() string {
+	return fmt.Sprintf("車両「%s」は割り当てられません: %s", e.VehicleNumber, e.Reason)
+}
+
+// VehicleCapacityError 車両の積載量超過エラー
+type VehicleCapacityError struct {
+	VehicleID     int64
+	VehicleNumber string
+	Resource      string
+	Remaining     int
+	Requested     int
+}
+
+func (e *VehicleCapacityError) Error() string {
+	return fmt.Sprintf("車両「%s」の%sの空き(%d)を超える配送は割り当てられません: 要求 %d", e.VehicleNumber, e.Resource, e.Remaining, e.Requested)
+}
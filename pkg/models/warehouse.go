@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * 倉庫モデル
+ * 倉庫と保管容量の情報を管理する
+ */
+
+// WarehouseStatus 倉庫ステータス
+type WarehouseStatus string
+
+const (
+	// WarehouseStatusActive 稼働中
+	WarehouseStatusActive WarehouseStatus = "active"
+	// WarehouseStatusInactive 停止中
+	WarehouseStatusInactive WarehouseStatus = "inactive"
+)
+
+// Warehouse 倉庫情報
+// Nameは在庫のロケーションとして使用される
+// Latitude・Longitudeは倉庫間の距離の算出に使用する（未設定の場合はnil）
+// AddressStatusは住所の確認状態で、要確認の場合はAddressNoteに理由を記録する
+type Warehouse struct {
+	ID            int64           `json:"id"`
+	Name          string          `json:"name"`
+	Address       string          `json:"address"`
+	Capacity      int             `json:"capacity"`
+	Status        WarehouseStatus `json:"status"`
+	Latitude      *float64        `json:"latitude,omitempty"`
+	Longitude     *float64        `json:"longitude,omitempty"`
+	AddressStatus AddressStatus   `json:"address_status"`
+	AddressNote   string          `json:"address_note,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// HasCoordinates 倉庫の緯度経度が設定されているかどうかを判定する
+func (w *Warehouse) HasCoordinates() bool {
+	return w.Latitude != nil && w.Longitude != nil
+}
+
+// CreateWarehouseRequest 倉庫作成リクエスト
+// Latitude・Longitudeを省略した場合は住所から位置を求める
+type CreateWarehouseRequest struct {
+	Name      string          `json:"name" binding:"required"`
+	Address   string          `json:"address" binding:"required"`
+	Capacity  int             `json:"capacity" binding:"required,min=1"`
+	Status    WarehouseStatus `json:"status" binding:"omitempty,oneof=active inactive"`
+	Latitude  *float64        `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64        `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+// UpdateWarehouseRequest 倉庫更新リクエスト
+type UpdateWarehouseRequest struct {
+	Name      string          `json:"name" binding:"required"`
+	Address   string          `json:"address" binding:"required"`
+	Capacity  int             `json:"capacity" binding:"required,min=1"`
+	Status    WarehouseStatus `json:"status" binding:"required,oneof=active inactive"`
+	Latitude  *float64        `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64        `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+// WarehouseUtilization 倉庫使用率
+type WarehouseUtilization struct {
+	WarehouseID       int64   `json:"warehouse_id"`
+	Name              string  `json:"name"`
+	Capacity          int     `json:"capacity"`
+	UsedQuantity      int     `json:"used_quantity"`
+	RemainingCapacity int     `json:"remaining_capacity"`
+	UtilizationRate   float64 `json:"utilization_rate"`
+}
+
+// NewWarehouseUtilization 倉庫と保管数量から使用率を算出する
+func NewWarehouseUtilization(warehouse *Warehouse, used int) *WarehouseUtilization {
+	utilization := &WarehouseUtilization{
+		WarehouseID:       warehouse.ID,
+		Name:              warehouse.Name,
+		Capacity:          warehouse.Capacity,
+		UsedQuantity:      used,
+		RemainingCapacity: warehouse.Capacity - used,
+	}
+	if warehouse.Capacity > 0 {
+		utilization.UtilizationRate = float64(used) / float64(warehouse.Capacity)
+	}
+	return utilization
+}
+
+// WarehouseCapacityError 倉庫の保管容量超過エラー
+type WarehouseCapacityError struct {
+	WarehouseID int64
+	Name        string
+	Remaining   int
+	Requested   int
+}
+
+func (e *WarehouseCapacityError) Error() string {
+	return fmt.Sprintf("倉庫「%s」の空き容量(%d)を超える入庫はできません: 要求数量 %d", e.Name, e.Remaining, e.Requested)
+}
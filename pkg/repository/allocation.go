@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 在庫割当リポジトリ
+ * データベースとの在庫割当方式・割当結果関連の操作を管理する
+ */
+
+// AllocationRepository 在庫割当リポジトリインターフェース
+type AllocationRepository interface {
+	// 割当方式
+	GetPolicyForProduct(ctx context.Context, productID int64) (*models.AllocationPolicy, error)
+	ListPolicies(ctx context.Context) ([]*models.AllocationPolicy, error)
+	SetPolicy(ctx context.Context, policy *models.AllocationPolicy) error
+	DeletePolicy(ctx context.Context, category string) error
+
+	// 割当結果
+	CreateDeliveryAllocation(ctx context.Context, allocation *models.DeliveryAllocation) error
+	ListDeliveryAllocations(ctx context.Context, deliveryID int64) ([]*models.DeliveryAllocation, error)
+	UpdateDeliveryAllocationQuantity(ctx context.Context, id int64, quantity int) error
+	DeleteDeliveryAllocation(ctx context.Context, id int64) error
+}
+
+// SQLAllocationRepository SQL在庫割当リポジトリ
+type SQLAllocationRepository struct {
+	db DB
+}
+
+// NewSQLAllocationRepository SQL在庫割当リポジトリを作成する
+func NewSQLAllocationRepository(db DB) AllocationRepository {
+	return &SQLAllocationRepository{db: db}
+}
+
+// GetPolicyForProduct 商品のカテゴリに設定された割当方式を取得する
+// カテゴリに割当方式が設定されていない場合はErrNotFoundを返す
+func (r *SQLAllocationRepository) GetPolicyForProduct(ctx context.Context, productID int64) (*models.AllocationPolicy, error) {
+	query := `
+		SELECT ap.category, ap.strategy, ap.updated_at
+		FROM allocation_policies ap
+		JOIN products p ON p.category = ap.category
+		WHERE p.id = $1`
+
+	policy := &models.AllocationPolicy{}
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+		&policy.Category,
+		&policy.Strategy,
+		&policy.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("割当方式取得エラー: %v", err)
+	}
+
+	return policy, nil
+}
+
+// ListPolicies 割当方式一覧を取得する
+func (r *SQLAllocationRepository) ListPolicies(ctx context.Context) ([]*models.AllocationPolicy, error) {
+	query := `
+		SELECT category, strategy, updated_at
+		FROM allocation_policies
+		ORDER BY category`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("割当方式一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.AllocationPolicy
+	for rows.Next() {
+		policy := &models.AllocationPolicy{}
+		if err := rows.Scan(&policy.Category, &policy.Strategy, &policy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("割当方式データ読み取りエラー: %v", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("割当方式一覧読み取りエラー: %v", err)
+	}
+
+	return policies, nil
+}
+
+// SetPolicy カテゴリの割当方式を設定する（未設定の場合は作成する）
+func (r *SQLAllocationRepository) SetPolicy(ctx context.Context, policy *models.AllocationPolicy) error {
+	query := `
+		INSERT INTO allocation_policies (category, strategy, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (category) DO UPDATE
+		SET strategy = EXCLUDED.strategy, updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, query, policy.Category, policy.Strategy, now); err != nil {
+		return fmt.Errorf("割当方式設定エラー: %v", err)
+	}
+
+	policy.UpdatedAt = now
+	return nil
+}
+
+// DeletePolicy カテゴリの割当方式を削除する
+func (r *SQLAllocationRepository) DeletePolicy(ctx context.Context, category string) error {
+	query := `DELETE FROM allocation_policies WHERE category = $1`
+
+	result, err := r.db.ExecContext(ctx, query, category)
+	if err != nil {
+		return fmt.Errorf("割当方式削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateDeliveryAllocation 配送明細の在庫割当結果を記録する
+func (r *SQLAllocationRepository) CreateDeliveryAllocation(ctx context.Context, allocation *models.DeliveryAllocation) error {
+	query := `
+		INSERT INTO delivery_allocations (
+			delivery_id, delivery_item_id, product_id, warehouse_id,
+			location, lot_id, quantity, strategy, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		allocation.DeliveryID,
+		allocation.DeliveryItemID,
+		allocation.ProductID,
+		allocation.WarehouseID,
+		allocation.Location,
+		allocation.LotID,
+		allocation.Quantity,
+		allocation.Strategy,
+		now,
+	).Scan(&allocation.ID)
+	if err != nil {
+		return fmt.Errorf("在庫割当記録エラー: %v", err)
+	}
+
+	allocation.CreatedAt = now
+	return nil
+}
+
+// ListDeliveryAllocations 配送の在庫割当結果を取得する
+func (r *SQLAllocationRepository) ListDeliveryAllocations(ctx context.Context, deliveryID int64) ([]*models.DeliveryAllocation, error) {
+	query := `
+		SELECT id, delivery_id, delivery_item_id, product_id, warehouse_id,
+			location, lot_id, quantity, strategy, created_at
+		FROM delivery_allocations
+		WHERE delivery_id = $1
+		ORDER BY delivery_item_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("在庫割当取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var allocations []*models.DeliveryAllocation
+	for rows.Next() {
+		allocation := &models.DeliveryAllocation{}
+		var lotID sql.NullInt64
+		err := rows.Scan(
+			&allocation.ID,
+			&allocation.DeliveryID,
+			&allocation.DeliveryItemID,
+			&allocation.ProductID,
+			&allocation.WarehouseID,
+			&allocation.Location,
+			&lotID,
+			&allocation.Quantity,
+			&allocation.Strategy,
+			&allocation.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("在庫割当データ読み取りエラー: %v", err)
+		}
+		if lotID.Valid {
+			id := lotID.Int64
+			allocation.LotID = &id
+		}
+		allocations = append(allocations, allocation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫割当読み取りエラー: %v", err)
+	}
+
+	return allocations, nil
+}
+
+// UpdateDeliveryAllocationQuantity 配送明細の在庫割当結果の数量を更新する
+func (r *SQLAllocationRepository) UpdateDeliveryAllocationQuantity(ctx context.Context, id int64, quantity int) error {
+	query := `UPDATE delivery_allocations SET quantity = $1 WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, quantity, id)
+	if err != nil {
+		return fmt.Errorf("在庫割当数量更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteDeliveryAllocation 配送明細の在庫割当結果を削除する
+func (r *SQLAllocationRepository) DeleteDeliveryAllocation(ctx context.Context, id int64) error {
+	query := `DELETE FROM delivery_allocations WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("在庫割当削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"database/sql"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 楽観的排他制御
+ * バージョンを条件とした更新の結果を判定する
+ */
+
+// scanVersionedUpdate バージョンを条件とした更新の結果を読み取り、更新後のバージョンを返す
+// 更新文は更新前のバージョンと更新後のバージョン（更新されなかった場合はNULL）の1行を返す
+// 対象が存在しない場合はErrNotFound、バージョンが一致しない場合は*models.VersionConflictErrorを返す
+func scanVersionedUpdate(scanner rowScanner, resource string, id int64, expected int) (int, error) {
+	var current int
+	var updated sql.NullInt64
+	err := scanner.Scan(&current, &updated)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !updated.Valid {
+		return 0, &models.VersionConflictError{
+			Resource:        resource,
+			ID:              id,
+			ExpectedVersion: expected,
+			CurrentVersion:  current,
+		}
+	}
+
+	return int(updated.Int64), nil
+}
@@ -33,3 +33,19 @@ func (db *SQLDatabase) GetContext(ctx context.Context, dest interface{}, query s
 	row := db.QueryRowContext(ctx, query, args...)
 	return row.Scan(dest)
 }
+
+// SQLTx トランザクションをDBインターフェースとして扱うラッパー
+type SQLTx struct {
+	*sql.Tx
+}
+
+// NewSQLTx トランザクションをラップする
+func NewSQLTx(tx *sql.Tx) DB {
+	return &SQLTx{tx}
+}
+
+// GetContext 単一の行を取得する
+func (tx *SQLTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	row := tx.QueryRowContext(ctx, query, args...)
+	return row.Scan(dest)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -17,12 +18,33 @@ import (
 type DeliveryRepository interface {
 	CreateDelivery(ctx context.Context, delivery *models.Delivery) error
 	GetDelivery(ctx context.Context, id int64) (*models.Delivery, error)
-	ListDeliveries(ctx context.Context) ([]*models.Delivery, error)
+	ListDeliveries(ctx context.Context, spec *QuerySpec) ([]*models.Delivery, *PageInfo, error)
 	UpdateDelivery(ctx context.Context, delivery *models.Delivery) error
 	CreateDeliveryItem(ctx context.Context, item *models.DeliveryItem) error
+	GetDeliveryItem(ctx context.Context, id int64) (*models.DeliveryItem, error)
 	ListDeliveryItems(ctx context.Context, deliveryID int64) ([]*models.DeliveryItem, error)
+	UpdateDeliveryItem(ctx context.Context, item *models.DeliveryItem) error
 	CreateDeliveryTracking(ctx context.Context, tracking *models.DeliveryTracking) error
 	ListDeliveryTrackings(ctx context.Context, deliveryID int64) ([]*models.DeliveryTracking, error)
+	CreateStatusHistory(ctx context.Context, history *models.DeliveryStatusHistory) error
+	ListStatusHistory(ctx context.Context, deliveryID int64) ([]*models.DeliveryStatusHistory, error)
+}
+
+// deliveryQuery 配送一覧の絞り込み・並べ替えに使用できる項目
+var deliveryQuery = &queryTable{
+	name: "deliveries",
+	fields: map[string]queryField{
+		"id":                {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"order_id":          {column: "order_id", typ: fieldInt, filterable: true, sortable: true},
+		"status":            {column: "status", typ: fieldString, filterable: true, sortable: true},
+		"from_warehouse_id": {column: "from_warehouse_id", typ: fieldInt, filterable: true, sortable: true},
+		"address_status":    {column: "address_status", typ: fieldString, filterable: true},
+		"estimated_time":    {column: "estimated_time", typ: fieldTime, filterable: true, sortable: true},
+		"actual_time":       {column: "actual_time", typ: fieldTime, filterable: true},
+		"created_at":        {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+		"updated_at":        {column: "updated_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "id"}},
 }
 
 // SQLDeliveryRepository SQL配送管理リポジトリ
@@ -41,8 +63,9 @@ func (r *SQLDeliveryRepository) CreateDelivery(ctx context.Context, delivery *mo
 		INSERT INTO deliveries (
 			order_id, status, from_warehouse_id,
 			to_address, estimated_time, actual_time,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+			created_at, updated_at, to_latitude, to_longitude,
+			address_status, address_note
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10, $11)
 		RETURNING id`
 
 	now := time.Now()
@@ -54,12 +77,18 @@ func (r *SQLDeliveryRepository) CreateDelivery(ctx context.Context, delivery *mo
 		delivery.EstimatedTime,
 		delivery.ActualTime,
 		now,
+		delivery.ToLatitude,
+		delivery.ToLongitude,
+		addressStatusOrDefault(delivery.AddressStatus),
+		delivery.AddressNote,
 	).Scan(&delivery.ID)
 
 	if err != nil {
 		return fmt.Errorf("配送作成エラー: %v", err)
 	}
 
+	delivery.Version = 1
+	delivery.AddressStatus = addressStatusOrDefault(delivery.AddressStatus)
 	delivery.CreatedAt = now
 	delivery.UpdatedAt = now
 	return nil
@@ -71,7 +100,8 @@ func (r *SQLDeliveryRepository) GetDelivery(ctx context.Context, id int64) (*mod
 	query := `
 		SELECT id, order_id, status, from_warehouse_id,
 			to_address, estimated_time, actual_time,
-			created_at, updated_at
+			created_at, updated_at, version, to_latitude, to_longitude,
+			address_status, address_note
 		FROM deliveries
 		WHERE id = $1`
 
@@ -85,6 +115,11 @@ func (r *SQLDeliveryRepository) GetDelivery(ctx context.Context, id int64) (*mod
 		&delivery.ActualTime,
 		&delivery.CreatedAt,
 		&delivery.UpdatedAt,
+		&delivery.Version,
+		&delivery.ToLatitude,
+		&delivery.ToLongitude,
+		&delivery.AddressStatus,
+		&delivery.AddressNote,
 	)
 
 	if err != nil {
@@ -95,24 +130,30 @@ func (r *SQLDeliveryRepository) GetDelivery(ctx context.Context, id int64) (*mod
 }
 
 // ListDeliveries 配送一覧を取得する
-func (r *SQLDeliveryRepository) ListDeliveries(ctx context.Context) ([]*models.Delivery, error) {
+// specがnilの場合は全件をID順に取得する
+func (r *SQLDeliveryRepository) ListDeliveries(ctx context.Context, spec *QuerySpec) ([]*models.Delivery, *PageInfo, error) {
+	q, err := deliveryQuery.build(spec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
 		SELECT id, order_id, status, from_warehouse_id,
 			to_address, estimated_time, actual_time,
-			created_at, updated_at
-		FROM deliveries
-		ORDER BY id`
+			created_at, updated_at, version, to_latitude, to_longitude,
+			address_status, address_note` + q.cursorColumns() + `
+		FROM deliveries` + q.clauses()
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("配送一覧取得エラー: %v", err)
+		return nil, nil, fmt.Errorf("配送一覧取得エラー: %v", err)
 	}
 	defer rows.Close()
 
 	var deliveries []*models.Delivery
 	for rows.Next() {
 		delivery := &models.Delivery{}
-		err := rows.Scan(
+		err := q.scanner(rows).Scan(
 			&delivery.ID,
 			&delivery.OrderID,
 			&delivery.Status,
@@ -122,66 +163,140 @@ func (r *SQLDeliveryRepository) ListDeliveries(ctx context.Context) ([]*models.D
 			&delivery.ActualTime,
 			&delivery.CreatedAt,
 			&delivery.UpdatedAt,
+			&delivery.Version,
+			&delivery.ToLatitude,
+			&delivery.ToLongitude,
+			&delivery.AddressStatus,
+			&delivery.AddressNote,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("配送データ読み取りエラー: %v", err)
+			return nil, nil, fmt.Errorf("配送データ読み取りエラー: %v", err)
 		}
 		deliveries = append(deliveries, delivery)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("配送一覧読み取りエラー: %v", err)
+		return nil, nil, fmt.Errorf("配送一覧読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(deliveries))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return deliveries, nil
+	return deliveries[:n], page, nil
 }
 
 // UpdateDelivery 配送を更新する
+// 読み取り時のバージョン（delivery.Version）と一致する場合のみ更新し、バージョンを1つ進める
 func (r *SQLDeliveryRepository) UpdateDelivery(ctx context.Context, delivery *models.Delivery) error {
 	query := `
-		UPDATE deliveries
-		SET order_id = $1, status = $2, from_warehouse_id = $3,
-			to_address = $4, estimated_time = $5, actual_time = $6,
-			updated_at = $7
-		WHERE id = $8`
+		WITH previous AS (
+			SELECT id, version FROM deliveries WHERE id = $8 FOR UPDATE
+		), updated AS (
+			UPDATE deliveries d
+			SET order_id = $1, status = $2, from_warehouse_id = $3,
+				to_address = $4, estimated_time = $5, actual_time = $6,
+				updated_at = $7, to_latitude = $10, to_longitude = $11,
+				address_status = $12, address_note = $13,
+				version = d.version + 1
+			FROM previous p
+			WHERE d.id = p.id AND p.version = $9
+			RETURNING d.id, d.version
+		)
+		SELECT p.version, u.version FROM previous p LEFT JOIN updated u ON u.id = p.id`
 
-	result, err := r.db.ExecContext(ctx, query,
+	now := time.Now()
+	row := r.db.QueryRowContext(ctx, query,
 		delivery.OrderID,
 		delivery.Status,
 		delivery.FromWarehouseID,
 		delivery.ToAddress,
 		delivery.EstimatedTime,
 		delivery.ActualTime,
-		time.Now(),
+		now,
 		delivery.ID,
+		delivery.Version,
+		delivery.ToLatitude,
+		delivery.ToLongitude,
+		addressStatusOrDefault(delivery.AddressStatus),
+		delivery.AddressNote,
 	)
+
+	version, err := scanVersionedUpdate(row, "配送", delivery.ID, delivery.Version)
+	if err == ErrNotFound {
+		return ErrNotFound
+	}
+	if _, ok := err.(*models.VersionConflictError); ok {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("配送更新エラー: %v", err)
 	}
 
-	rows, err := result.RowsAffected()
+	delivery.Version = version
+	delivery.UpdatedAt = now
+	return nil
+}
+
+// scanDeliveryItem 配送商品行を読み取る
+func scanDeliveryItem(scanner rowScanner) (*models.DeliveryItem, error) {
+	item := &models.DeliveryItem{}
+	var lotID sql.NullInt64
+
+	err := scanner.Scan(
+		&item.ID,
+		&item.DeliveryID,
+		&item.ProductID,
+		&lotID,
+		&item.Quantity,
+		&item.CancelledQuantity,
+		&item.ReturnedQuantity,
+		&item.Status,
+		&item.Unit,
+		&item.UnitQuantity,
+	)
 	if err != nil {
-		return fmt.Errorf("結果取得エラー: %v", err)
+		return nil, err
 	}
-	if rows == 0 {
-		return ErrNotFound
+
+	if lotID.Valid {
+		id := lotID.Int64
+		item.LotID = &id
 	}
 
-	return nil
+	return item, nil
 }
 
 // CreateDeliveryItem 配送商品を作成する
+// 荷姿を指定していない場合は基本単位の数量として記録する
 func (r *SQLDeliveryRepository) CreateDeliveryItem(ctx context.Context, item *models.DeliveryItem) error {
 	query := `
 		INSERT INTO delivery_items (
-			delivery_id, product_id, quantity
-		) VALUES ($1, $2, $3)
+			delivery_id, product_id, lot_id, quantity,
+			cancelled_quantity, returned_quantity, status,
+			unit, unit_quantity
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
 
+	if item.Status == "" {
+		item.Status = models.DeliveryItemStatusActive
+	}
+	if models.IsBaseUnit(item.Unit) {
+		item.Unit = models.BaseUnit
+		item.UnitQuantity = item.Quantity
+	}
+
 	err := r.db.QueryRowContext(ctx, query,
 		item.DeliveryID,
 		item.ProductID,
+		item.LotID,
 		item.Quantity,
+		item.CancelledQuantity,
+		item.ReturnedQuantity,
+		item.Status,
+		item.Unit,
+		item.UnitQuantity,
 	).Scan(&item.ID)
 
 	if err != nil {
@@ -191,10 +306,32 @@ func (r *SQLDeliveryRepository) CreateDeliveryItem(ctx context.Context, item *mo
 	return nil
 }
 
+// GetDeliveryItem 配送商品を取得する
+func (r *SQLDeliveryRepository) GetDeliveryItem(ctx context.Context, id int64) (*models.DeliveryItem, error) {
+	query := `
+		SELECT id, delivery_id, product_id, lot_id, quantity,
+			cancelled_quantity, returned_quantity, status,
+			unit, unit_quantity
+		FROM delivery_items
+		WHERE id = $1`
+
+	item, err := scanDeliveryItem(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送商品取得エラー: %v", err)
+	}
+
+	return item, nil
+}
+
 // ListDeliveryItems 配送商品一覧を取得する
 func (r *SQLDeliveryRepository) ListDeliveryItems(ctx context.Context, deliveryID int64) ([]*models.DeliveryItem, error) {
 	query := `
-		SELECT id, delivery_id, product_id, quantity
+		SELECT id, delivery_id, product_id, lot_id, quantity,
+			cancelled_quantity, returned_quantity, status,
+			unit, unit_quantity
 		FROM delivery_items
 		WHERE delivery_id = $1
 		ORDER BY id`
@@ -207,13 +344,7 @@ func (r *SQLDeliveryRepository) ListDeliveryItems(ctx context.Context, deliveryI
 
 	var items []*models.DeliveryItem
 	for rows.Next() {
-		item := &models.DeliveryItem{}
-		err := rows.Scan(
-			&item.ID,
-			&item.DeliveryID,
-			&item.ProductID,
-			&item.Quantity,
-		)
+		item, err := scanDeliveryItem(rows)
 		if err != nil {
 			return nil, fmt.Errorf("配送商品データ読み取りエラー: %v", err)
 		}
@@ -227,6 +358,34 @@ func (r *SQLDeliveryRepository) ListDeliveryItems(ctx context.Context, deliveryI
 	return items, nil
 }
 
+// UpdateDeliveryItem 配送商品のキャンセル・返品数量とステータスを更新する
+func (r *SQLDeliveryRepository) UpdateDeliveryItem(ctx context.Context, item *models.DeliveryItem) error {
+	query := `
+		UPDATE delivery_items
+		SET cancelled_quantity = $1, returned_quantity = $2, status = $3
+		WHERE id = $4`
+
+	result, err := r.db.ExecContext(ctx, query,
+		item.CancelledQuantity,
+		item.ReturnedQuantity,
+		item.Status,
+		item.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("配送商品更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 // CreateDeliveryTracking 配送追跡を作成する
 func (r *SQLDeliveryRepository) CreateDeliveryTracking(ctx context.Context, tracking *models.DeliveryTracking) error {
 	query := `
@@ -290,3 +449,77 @@ func (r *SQLDeliveryRepository) ListDeliveryTrackings(ctx context.Context, deliv
 
 	return trackings, nil
 }
+
+// CreateStatusHistory 配送ステータス変更履歴を作成する
+func (r *SQLDeliveryRepository) CreateStatusHistory(ctx context.Context, history *models.DeliveryStatusHistory) error {
+	query := `
+		INSERT INTO delivery_status_history (
+			delivery_id, from_status, to_status,
+			changed_by, reason, changed_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		history.DeliveryID,
+		history.FromStatus,
+		history.ToStatus,
+		history.ChangedBy,
+		history.Reason,
+		now,
+	).Scan(&history.ID)
+
+	if err != nil {
+		return fmt.Errorf("配送ステータス履歴作成エラー: %v", err)
+	}
+
+	history.ChangedAt = now
+	return nil
+}
+
+// ListStatusHistory 配送ステータス変更履歴を取得する
+func (r *SQLDeliveryRepository) ListStatusHistory(ctx context.Context, deliveryID int64) ([]*models.DeliveryStatusHistory, error) {
+	query := `
+		SELECT id, delivery_id, from_status, to_status,
+			changed_by, reason, changed_at
+		FROM delivery_status_history
+		WHERE delivery_id = $1
+		ORDER BY changed_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("配送ステータス履歴取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var histories []*models.DeliveryStatusHistory
+	for rows.Next() {
+		history := &models.DeliveryStatusHistory{}
+		var changedBy sql.NullInt64
+		var reason sql.NullString
+		err := rows.Scan(
+			&history.ID,
+			&history.DeliveryID,
+			&history.FromStatus,
+			&history.ToStatus,
+			&changedBy,
+			&reason,
+			&history.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("配送ステータス履歴データ読み取りエラー: %v", err)
+		}
+		if changedBy.Valid {
+			id := changedBy.Int64
+			history.ChangedBy = &id
+		}
+		history.Reason = reason.String
+		histories = append(histories, history)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("配送ステータス履歴読み取りエラー: %v", err)
+	}
+
+	return histories, nil
+}
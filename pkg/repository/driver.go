@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 運転手リポジトリ
+ * データベースとの運転手関連の操作を管理する
+ */
+
+// DriverRepository 運転手リポジトリインターフェース
+type DriverRepository interface {
+	// CreateDriver 運転手を登録する
+	// 指定したユーザーが存在しないかロールがdriverでない場合はErrNotFoundを返す
+	CreateDriver(ctx context.Context, driver *models.Driver) error
+	GetDriver(ctx context.Context, id int64) (*models.Driver, error)
+	// LockDriver 運転手を取得し、トランザクションの終了まで行をロックする（運転手への割当を直列化する）
+	LockDriver(ctx context.Context, id int64) (*models.Driver, error)
+	ListDrivers(ctx context.Context, spec *QuerySpec) ([]*models.Driver, *PageInfo, error)
+	UpdateDriver(ctx context.Context, driver *models.Driver) error
+}
+
+// driverSource 運転手とユーザー情報（氏名・メールアドレス）を結合した行
+// 一覧取得条件の項目名とidの列名が衝突しないよう副問い合わせにする
+const driverSource = `(
+			SELECT d.*, u.name, u.email
+			FROM drivers d
+			JOIN users u ON u.id = d.user_id
+		) drivers`
+
+// driverQuery 運転手一覧の絞り込み・並べ替えに使用できる項目
+var driverQuery = &queryTable{
+	name: driverSource,
+	fields: map[string]queryField{
+		"id":             {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"user_id":        {column: "user_id", typ: fieldInt, filterable: true},
+		"name":           {column: "name", typ: fieldString, filterable: true, sortable: true},
+		"license_number": {column: "license_number", typ: fieldString, filterable: true},
+		"license_expiry": {column: "license_expiry", typ: fieldTime, filterable: true, sortable: true},
+		"active":         {column: "active", typ: fieldString, filterable: true},
+		"created_at":     {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "id"}},
+}
+
+// SQLDriverRepository SQL運転手リポジトリ
+type SQLDriverRepository struct {
+	db DB
+}
+
+// NewSQLDriverRepository SQL運転手リポジトリを作成する
+func NewSQLDriverRepository(db DB) DriverRepository {
+	return &SQLDriverRepository{db: db}
+}
+
+const driverColumns = `id, user_id, name, email, license_number, license_expiry, COALESCE(phone, ''),
+			to_char(shift_start, 'HH24:MI'), to_char(shift_end, 'HH24:MI'), active, created_at, updated_at`
+
+// scanDriver 運転手行を読み取る
+func scanDriver(scanner rowScanner) (*models.Driver, error) {
+	driver := &models.Driver{}
+	var licenseExpiry sql.NullTime
+
+	err := scanner.Scan(
+		&driver.ID,
+		&driver.UserID,
+		&driver.Name,
+		&driver.Email,
+		&driver.LicenseNumber,
+		&licenseExpiry,
+		&driver.Phone,
+		&driver.ShiftStart,
+		&driver.ShiftEnd,
+		&driver.Active,
+		&driver.CreatedAt,
+		&driver.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if licenseExpiry.Valid {
+		driver.LicenseExpiry = &licenseExpiry.Time
+	}
+
+	return driver, nil
+}
+
+// CreateDriver 運転手を登録する
+func (r *SQLDriverRepository) CreateDriver(ctx context.Context, driver *models.Driver) error {
+	query := `
+		INSERT INTO drivers (
+			user_id, license_number, license_expiry, phone,
+			shift_start, shift_end, active, created_at, updated_at
+		)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8, $8
+		FROM users
+		WHERE id = $1 AND role = $9
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		driver.UserID,
+		driver.LicenseNumber,
+		driver.LicenseExpiry,
+		driver.Phone,
+		driver.ShiftStart,
+		driver.ShiftEnd,
+		driver.Active,
+		now,
+		models.RoleDriver,
+	).Scan(&driver.ID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("運転手登録エラー: %v", err)
+	}
+
+	driver.CreatedAt = now
+	driver.UpdatedAt = now
+	return nil
+}
+
+// GetDriver 運転手を取得する
+func (r *SQLDriverRepository) GetDriver(ctx context.Context, id int64) (*models.Driver, error) {
+	return r.getDriver(ctx, id, "")
+}
+
+// LockDriver 運転手を取得し、トランザクションの終了まで行をロックする
+func (r *SQLDriverRepository) LockDriver(ctx context.Context, id int64) (*models.Driver, error) {
+	return r.getDriver(ctx, id, " FOR UPDATE")
+}
+
+func (r *SQLDriverRepository) getDriver(ctx context.Context, id int64, lock string) (*models.Driver, error) {
+	query := `
+		SELECT ` + driverColumns + `
+		FROM ` + driverSource + `
+		WHERE id = $1` + lock
+
+	driver, err := scanDriver(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("運転手取得エラー: %v", err)
+	}
+
+	return driver, nil
+}
+
+// ListDrivers 運転手一覧を取得する
+func (r *SQLDriverRepository) ListDrivers(ctx context.Context, spec *QuerySpec) ([]*models.Driver, *PageInfo, error) {
+	q, err := driverQuery.build(spec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT ` + driverColumns + q.cursorColumns() + `
+		FROM ` + driverSource + q.clauses()
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("運転手一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var drivers []*models.Driver
+	for rows.Next() {
+		driver, err := scanDriver(q.scanner(rows))
+		if err != nil {
+			return nil, nil, fmt.Errorf("運転手データ読み取りエラー: %v", err)
+		}
+		drivers = append(drivers, driver)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("運転手一覧読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(drivers))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return drivers[:n], page, nil
+}
+
+// UpdateDriver 運転手を更新する
+func (r *SQLDriverRepository) UpdateDriver(ctx context.Context, driver *models.Driver) error {
+	query := `
+		UPDATE drivers
+		SET license_number = $1, license_expiry = $2, phone = $3,
+			shift_start = $4, shift_end = $5, active = $6, updated_at = $7
+		WHERE id = $8`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		driver.LicenseNumber,
+		driver.LicenseExpiry,
+		driver.Phone,
+		driver.ShiftStart,
+		driver.ShiftEnd,
+		driver.Active,
+		now,
+		driver.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("運転手更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	driver.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 到着予定日時リポジトリ
+ * データベースとの配送の到着予定日時の予測関連の操作を管理する
+ */
+
+// ETARepository 到着予定日時リポジトリインターフェース
+type ETARepository interface {
+	// ListActiveTrackings 配送中・配送予定の配送の最新の配送追跡情報を取得する
+	ListActiveTrackings(ctx context.Context) ([]*models.ETATracking, error)
+	// GetDeliveryTracking 配送の最新の配送追跡情報を取得する
+	GetDeliveryTracking(ctx context.Context, deliveryID int64) (*models.ETATracking, error)
+	// GetLatestPosition 配送追跡の緯度経度のある最新の追跡イベントを取得する
+	GetLatestPosition(ctx context.Context, trackingID string) (*models.TrackingEvent, error)
+	// UpdateEstimatedTime 配送追跡の到着予定日時を更新する（notifiedを指定した場合は通知済みの到着予定日時も更新する）
+	UpdateEstimatedTime(ctx context.Context, trackingID string, estimated time.Time, notified *time.Time) error
+	// GetLegDurationStats 車両の指定日時以降の配送ルートの区間の所要時間の実績を集計する
+	GetLegDurationStats(ctx context.Context, vehicleID int64, since time.Time) (*models.LegDurationStats, error)
+}
+
+// SQLETARepository SQL到着予定日時リポジトリ
+type SQLETARepository struct {
+	db DB
+}
+
+// NewSQLETARepository SQL到着予定日時リポジトリを作成する
+func NewSQLETARepository(db DB) ETARepository {
+	return &SQLETARepository{db: db}
+}
+
+// scanETATracking 到着予定日時の予測対象の配送追跡情報の行を読み取る
+func scanETATracking(scanner rowScanner) (*models.ETATracking, error) {
+	tracking := &models.ETATracking{}
+	var estimatedTime, notifiedTime sql.NullTime
+	err := scanner.Scan(
+		&tracking.TrackingID,
+		&tracking.DeliveryID,
+		&estimatedTime,
+		&notifiedTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if estimatedTime.Valid {
+		tracking.EstimatedTime = &estimatedTime.Time
+	}
+	if notifiedTime.Valid {
+		tracking.NotifiedEstimatedTime = &notifiedTime.Time
+	}
+	return tracking, nil
+}
+
+// ListActiveTrackings 配送中・配送予定の配送の最新の配送追跡情報を取得する
+func (r *SQLETARepository) ListActiveTrackings(ctx context.Context) ([]*models.ETATracking, error) {
+	query := `
+		SELECT DISTINCT ON (t.delivery_id) t.id, t.delivery_id, t.estimated_time, t.notified_estimated_time
+		FROM tracking_info t
+		JOIN deliveries d ON d.id = t.delivery_id
+		WHERE d.status IN ($1, $2) AND t.status <> $3
+		ORDER BY t.delivery_id, t.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		models.DeliveryStatusScheduled,
+		models.DeliveryStatusInTransit,
+		models.TrackingStatusDelivered,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("配送追跡情報一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var trackings []*models.ETATracking
+	for rows.Next() {
+		tracking, err := scanETATracking(rows)
+		if err != nil {
+			return nil, fmt.Errorf("配送追跡情報データ読み取りエラー: %v", err)
+		}
+		trackings = append(trackings, tracking)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("配送追跡情報読み取りエラー: %v", err)
+	}
+
+	return trackings, nil
+}
+
+// GetDeliveryTracking 配送の最新の配送追跡情報を取得する
+func (r *SQLETARepository) GetDeliveryTracking(ctx context.Context, deliveryID int64) (*models.ETATracking, error) {
+	query := `
+		SELECT id, delivery_id, estimated_time, notified_estimated_time
+		FROM tracking_info
+		WHERE delivery_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	tracking, err := scanETATracking(r.db.QueryRowContext(ctx, query, deliveryID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送追跡情報取得エラー: %v", err)
+	}
+
+	return tracking, nil
+}
+
+// GetLatestPosition 配送追跡の緯度経度のある最新の追跡イベントを取得する
+// 緯度経度がともに0のイベントは位置が不明として除く
+func (r *SQLETARepository) GetLatestPosition(ctx context.Context, trackingID string) (*models.TrackingEvent, error) {
+	query := `
+		SELECT id, tracking_id, status, location, description,
+			latitude, longitude, created_at
+		FROM tracking_events
+		WHERE tracking_id = $1 AND (latitude <> 0 OR longitude <> 0)
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+
+	event := &models.TrackingEvent{}
+	err := r.db.QueryRowContext(ctx, query, trackingID).Scan(
+		&event.ID,
+		&event.TrackingID,
+		&event.Status,
+		&event.Location,
+		&event.Description,
+		&event.Latitude,
+		&event.Longitude,
+		&event.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("追跡イベント取得エラー: %v", err)
+	}
+
+	return event, nil
+}
+
+// UpdateEstimatedTime 配送追跡の到着予定日時を更新する
+func (r *SQLETARepository) UpdateEstimatedTime(ctx context.Context, trackingID string, estimated time.Time, notified *time.Time) error {
+	query := `
+		UPDATE tracking_info
+		SET estimated_time = $1,
+			notified_estimated_time = COALESCE($2, notified_estimated_time),
+			updated_at = $3
+		WHERE id = $4`
+
+	var notifiedTime sql.NullTime
+	if notified != nil {
+		notifiedTime = sql.NullTime{Time: *notified, Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, estimated, notifiedTime, time.Now(), trackingID)
+	if err != nil {
+		return fmt.Errorf("到着予定日時更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetLegDurationStats 車両の指定日時以降の配送ルートの区間の所要時間の実績を集計する
+// 同じ日の配送ルートで続けて配送完了した訪問先の組を1区間とし、計画の到着予定日時の間隔と
+// 実際の配送完了日時の間隔（いずれも荷下ろしの時間を含む）を合計する
+func (r *SQLETARepository) GetLegDurationStats(ctx context.Context, vehicleID int64, since time.Time) (*models.LegDurationStats, error) {
+	query := `
+		WITH legs AS (
+			SELECT r.arrival_time, d.actual_time,
+				LAG(r.arrival_time) OVER w AS previous_arrival,
+				LAG(d.actual_time) OVER w AS previous_actual
+			FROM routes r
+			JOIN deliveries d ON d.id = r.delivery_id
+			WHERE r.vehicle_id = $1 AND r.route_date >= $2 AND r.status = $3
+				AND r.arrival_time IS NOT NULL
+				AND d.status = $4 AND d.actual_time IS NOT NULL
+			WINDOW w AS (PARTITION BY r.route_date ORDER BY r.sequence)
+		)
+		SELECT COUNT(*),
+			COALESCE(SUM(EXTRACT(EPOCH FROM arrival_time - previous_arrival)) / 60, 0),
+			COALESCE(SUM(EXTRACT(EPOCH FROM actual_time - previous_actual)) / 60, 0)
+		FROM legs
+		WHERE previous_arrival IS NOT NULL AND previous_actual IS NOT NULL
+			AND arrival_time > previous_arrival AND actual_time > previous_actual`
+
+	stats := &models.LegDurationStats{}
+	err := r.db.QueryRowContext(ctx, query,
+		vehicleID,
+		since.Format("2006-01-02"),
+		models.RouteStatusPlanned,
+		models.DeliveryStatusDelivered,
+	).Scan(&stats.Legs, &stats.PlannedMinutes, &stats.ActualMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("区間所要時間集計エラー: %v", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 賞味期限監視リポジトリ
+ * データベースとの賞味期限監視・通知関連の操作を管理する
+ */
+
+// ExpiryRepository 賞味期限監視リポジトリインターフェース
+type ExpiryRepository interface {
+	// 通知日数
+	ListExpiryPolicies(ctx context.Context) ([]*models.ExpiryAlertPolicy, error)
+	SetExpiryPolicy(ctx context.Context, policy *models.ExpiryAlertPolicy) error
+	DeleteExpiryPolicy(ctx context.Context, category string) error
+
+	// ListExpiringStock 賞味期限が指定日時より前の販売可能なロット在庫を取得する
+	ListExpiringStock(ctx context.Context, before time.Time) ([]*models.ExpiringStock, error)
+	// MarkExpired 在庫行を賞味期限切れにする
+	MarkExpired(ctx context.Context, inventoryID int64) error
+	// RecordExpiryAlert 在庫行の賞味期限通知を記録する
+	// 同じ在庫行・種類の通知が記録済みの場合はfalseを返す
+	RecordExpiryAlert(ctx context.Context, inventoryID int64, kind models.ExpiryAlertKind) (bool, error)
+	// ListAlertRecipientIDs 賞味期限通知の送信先（有効なマネージャー・管理者）のユーザーIDを取得する
+	ListAlertRecipientIDs(ctx context.Context) ([]int64, error)
+}
+
+// SQLExpiryRepository SQL賞味期限監視リポジトリ
+type SQLExpiryRepository struct {
+	db DB
+}
+
+// NewSQLExpiryRepository SQL賞味期限監視リポジトリを作成する
+func NewSQLExpiryRepository(db DB) ExpiryRepository {
+	return &SQLExpiryRepository{db: db}
+}
+
+// ListExpiryPolicies 通知日数一覧を取得する
+func (r *SQLExpiryRepository) ListExpiryPolicies(ctx context.Context) ([]*models.ExpiryAlertPolicy, error) {
+	query := `
+		SELECT category, lead_days, updated_at
+		FROM expiry_alert_policies
+		ORDER BY category`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("通知日数一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.ExpiryAlertPolicy
+	for rows.Next() {
+		policy := &models.ExpiryAlertPolicy{}
+		if err := rows.Scan(&policy.Category, &policy.LeadDays, &policy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("通知日数データ読み取りエラー: %v", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("通知日数一覧読み取りエラー: %v", err)
+	}
+
+	return policies, nil
+}
+
+// SetExpiryPolicy カテゴリの通知日数を設定する（未設定の場合は作成する）
+func (r *SQLExpiryRepository) SetExpiryPolicy(ctx context.Context, policy *models.ExpiryAlertPolicy) error {
+	query := `
+		INSERT INTO expiry_alert_policies (category, lead_days, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (category) DO UPDATE
+		SET lead_days = EXCLUDED.lead_days, updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, query, policy.Category, policy.LeadDays, now); err != nil {
+		return fmt.Errorf("通知日数設定エラー: %v", err)
+	}
+
+	policy.UpdatedAt = now
+	return nil
+}
+
+// DeleteExpiryPolicy カテゴリの通知日数を削除する
+func (r *SQLExpiryRepository) DeleteExpiryPolicy(ctx context.Context, category string) error {
+	query := `DELETE FROM expiry_alert_policies WHERE category = $1`
+
+	result, err := r.db.ExecContext(ctx, query, category)
+	if err != nil {
+		return fmt.Errorf("通知日数削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListExpiringStock 賞味期限が指定日時より前の販売可能なロット在庫を賞味期限の近い順に取得する
+func (r *SQLExpiryRepository) ListExpiringStock(ctx context.Context, before time.Time) ([]*models.ExpiringStock, error) {
+	query := `
+		SELECT i.id, i.product_id, p.name, COALESCE(p.category, ''), i.location,
+			i.bin_id, l.id, l.lot_number, l.best_before_date, i.quantity, i.status
+		FROM inventory i
+		JOIN lots l ON l.id = i.lot_id
+		JOIN products p ON p.id = i.product_id
+		WHERE i.quantity > 0
+			AND i.status NOT IN ($1, $2)
+			AND l.best_before_date < $3
+		ORDER BY l.best_before_date, i.location, i.id`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		models.InventoryStatusQuarantined,
+		models.InventoryStatusExpired,
+		before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("賞味期限接近在庫取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var stocks []*models.ExpiringStock
+	for rows.Next() {
+		stock := &models.ExpiringStock{}
+		var binID sql.NullInt64
+		err := rows.Scan(
+			&stock.InventoryID,
+			&stock.ProductID,
+			&stock.ProductName,
+			&stock.Category,
+			&stock.Location,
+			&binID,
+			&stock.LotID,
+			&stock.LotNumber,
+			&stock.BestBeforeDate,
+			&stock.Quantity,
+			&stock.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("賞味期限接近在庫データ読み取りエラー: %v", err)
+		}
+		if binID.Valid {
+			id := binID.Int64
+			stock.BinID = &id
+		}
+		stocks = append(stocks, stock)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("賞味期限接近在庫読み取りエラー: %v", err)
+	}
+
+	return stocks, nil
+}
+
+// MarkExpired 在庫行を賞味期限切れにする
+func (r *SQLExpiryRepository) MarkExpired(ctx context.Context, inventoryID int64) error {
+	query := `
+		UPDATE inventory
+		SET status = $1, updated_at = $2, version = version + 1
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, models.InventoryStatusExpired, time.Now(), inventoryID)
+	if err != nil {
+		return fmt.Errorf("賞味期限切れ更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordExpiryAlert 在庫行の賞味期限通知を記録する
+func (r *SQLExpiryRepository) RecordExpiryAlert(ctx context.Context, inventoryID int64, kind models.ExpiryAlertKind) (bool, error) {
+	query := `
+		INSERT INTO expiry_alerts (inventory_id, kind, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (inventory_id, kind) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, inventoryID, kind, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("賞味期限通知記録エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("結果取得エラー: %v", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ListAlertRecipientIDs 賞味期限通知の送信先のユーザーIDを取得する
+func (r *SQLExpiryRepository) ListAlertRecipientIDs(ctx context.Context) ([]int64, error) {
+	return listAlertRecipientIDs(ctx, r.db)
+}
@@ -16,36 +16,170 @@ import (
 
 // SQLInventoryRepository SQL在庫管理リポジトリ
 type SQLInventoryRepository struct {
-	db *sql.DB
+	db DB
 }
 
 // NewInventoryRepository 在庫管理リポジトリを作成する
 func NewInventoryRepository(db *sql.DB) InventoryRepository {
+	return &SQLInventoryRepository{db: NewSQLDatabase(db)}
+}
+
+// newTxInventoryRepository トランザクション用の在庫管理リポジトリを作成する
+func newTxInventoryRepository(db DB) InventoryRepository {
 	return &SQLInventoryRepository{db: db}
 }
 
+const inventoryColumns = `id, product_id, quantity, location, warehouse_id,
+			bin_id, lot_id, status, created_at, updated_at, version`
+
+const movementColumns = `id, product_id, from_location, to_location,
+			quantity, movement_type, movement_date,
+			reference_number, created_at, from_bin_id, to_bin_id, lot_id, reason_code,
+			unit, unit_quantity`
+
+// inventoryQuery 在庫一覧の絞り込み・並べ替えに使用できる項目
+var inventoryQuery = &queryTable{
+	name: "inventory",
+	fields: map[string]queryField{
+		"id":           {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"product_id":   {column: "product_id", typ: fieldInt, filterable: true, sortable: true},
+		"location":     {column: "location", typ: fieldString, filterable: true, sortable: true},
+		"warehouse_id": {column: "warehouse_id", typ: fieldInt, filterable: true},
+		"bin_id":       {column: "bin_id", typ: fieldInt, filterable: true},
+		"lot_id":       {column: "lot_id", typ: fieldInt, filterable: true},
+		"status":       {column: "status", typ: fieldString, filterable: true, sortable: true},
+		"quantity":     {column: "quantity", typ: fieldInt, filterable: true, sortable: true},
+		"created_at":   {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+		"updated_at":   {column: "updated_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "id"}},
+}
+
+// scanInventory 在庫行を読み取る
+func scanInventory(scanner rowScanner) (*models.Inventory, error) {
+	inventory := &models.Inventory{}
+	var warehouseID sql.NullInt64
+	var binID sql.NullInt64
+	var lotID sql.NullInt64
+
+	err := scanner.Scan(
+		&inventory.ID,
+		&inventory.ProductID,
+		&inventory.Quantity,
+		&inventory.Location,
+		&warehouseID,
+		&binID,
+		&lotID,
+		&inventory.Status,
+		&inventory.CreatedAt,
+		&inventory.UpdatedAt,
+		&inventory.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if warehouseID.Valid {
+		id := warehouseID.Int64
+		inventory.WarehouseID = &id
+	}
+	if binID.Valid {
+		id := binID.Int64
+		inventory.BinID = &id
+	}
+	if lotID.Valid {
+		id := lotID.Int64
+		inventory.LotID = &id
+	}
+
+	return inventory, nil
+}
+
+// scanMovement 在庫移動行を読み取る
+func scanMovement(scanner rowScanner) (*models.InventoryMovement, error) {
+	movement := &models.InventoryMovement{}
+	var fromBinID, toBinID, lotID sql.NullInt64
+	var reasonCode sql.NullString
+
+	err := scanner.Scan(
+		&movement.ID,
+		&movement.ProductID,
+		&movement.FromLocation,
+		&movement.ToLocation,
+		&movement.Quantity,
+		&movement.MovementType,
+		&movement.MovementDate,
+		&movement.ReferenceNumber,
+		&movement.CreatedAt,
+		&fromBinID,
+		&toBinID,
+		&lotID,
+		&reasonCode,
+		&movement.Unit,
+		&movement.UnitQuantity,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromBinID.Valid {
+		id := fromBinID.Int64
+		movement.FromBinID = &id
+	}
+	if toBinID.Valid {
+		id := toBinID.Int64
+		movement.ToBinID = &id
+	}
+	if lotID.Valid {
+		id := lotID.Int64
+		movement.LotID = &id
+	}
+	movement.ReasonCode = reasonCode.String
+
+	return movement, nil
+}
+
 // CreateInventory 在庫を作成する
+// ロケーション名が倉庫名と一致する場合は倉庫に紐付ける
+// 初期数量は在庫元帳に記帳する
 func (r *SQLInventoryRepository) CreateInventory(ctx context.Context, inventory *models.Inventory) error {
 	query := `
-		INSERT INTO inventory (
-			product_id, quantity, location, status,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $5)
-		RETURNING id`
+		WITH created AS (
+			INSERT INTO inventory (
+				product_id, quantity, location, warehouse_id, bin_id,
+				lot_id, status, created_at, updated_at
+			) VALUES ($1, $2, $3, (SELECT id FROM warehouses WHERE name = $3), $4, $5, $6, $7, $7)
+			RETURNING id, warehouse_id, product_id, location, bin_id, lot_id, quantity
+		), changed AS (
+			SELECT id, product_id, location, bin_id, lot_id, quantity AS delta, quantity AS balance
+			FROM created
+		), ` + ledgerInsert(8) + `
+		SELECT id, warehouse_id FROM created`
 
 	now := time.Now()
-	err := r.db.QueryRowContext(ctx, query,
+	args := append([]interface{}{
 		inventory.ProductID,
 		inventory.Quantity,
 		inventory.Location,
+		inventory.BinID,
+		inventory.LotID,
 		inventory.Status,
 		now,
-	).Scan(&inventory.ID)
+	}, ledgerArgs(ctx, models.LedgerEntryTypeCreate, now)...)
+
+	var warehouseID sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&inventory.ID, &warehouseID)
 
 	if err != nil {
 		return fmt.Errorf("在庫作成エラー: %v", err)
 	}
 
+	if warehouseID.Valid {
+		id := warehouseID.Int64
+		inventory.WarehouseID = &id
+	}
+
+	inventory.Version = 1
 	inventory.CreatedAt = now
 	inventory.UpdatedAt = now
 	return nil
@@ -53,22 +187,12 @@ func (r *SQLInventoryRepository) CreateInventory(ctx context.Context, inventory
 
 // GetInventory 在庫を取得する
 func (r *SQLInventoryRepository) GetInventory(ctx context.Context, id int64) (*models.Inventory, error) {
-	inventory := &models.Inventory{}
 	query := `
-		SELECT id, product_id, quantity, location, status,
-			created_at, updated_at
+		SELECT ` + inventoryColumns + `
 		FROM inventory
 		WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&inventory.ID,
-		&inventory.ProductID,
-		&inventory.Quantity,
-		&inventory.Location,
-		&inventory.Status,
-		&inventory.CreatedAt,
-		&inventory.UpdatedAt,
-	)
+	inventory, err := scanInventory(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("在庫が見つかりません")
@@ -81,88 +205,114 @@ func (r *SQLInventoryRepository) GetInventory(ctx context.Context, id int64) (*m
 }
 
 // ListInventories 在庫一覧を取得する
-func (r *SQLInventoryRepository) ListInventories(ctx context.Context) ([]*models.Inventory, error) {
+// specがnilの場合は全件をID順に取得する
+func (r *SQLInventoryRepository) ListInventories(ctx context.Context, spec *QuerySpec) ([]*models.Inventory, *PageInfo, error) {
+	q, err := inventoryQuery.build(spec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
-		SELECT id, product_id, quantity, location, status,
-			created_at, updated_at
-		FROM inventory
-		ORDER BY id`
+		SELECT ` + inventoryColumns + q.cursorColumns() + `
+		FROM inventory` + q.clauses()
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("在庫一覧取得エラー: %v", err)
+		return nil, nil, fmt.Errorf("在庫一覧取得エラー: %v", err)
 	}
 	defer rows.Close()
 
 	var inventories []*models.Inventory
 	for rows.Next() {
-		inventory := &models.Inventory{}
-		err := rows.Scan(
-			&inventory.ID,
-			&inventory.ProductID,
-			&inventory.Quantity,
-			&inventory.Location,
-			&inventory.Status,
-			&inventory.CreatedAt,
-			&inventory.UpdatedAt,
-		)
+		inventory, err := scanInventory(q.scanner(rows))
 		if err != nil {
-			return nil, fmt.Errorf("在庫データ読み取りエラー: %v", err)
+			return nil, nil, fmt.Errorf("在庫データ読み取りエラー: %v", err)
 		}
 		inventories = append(inventories, inventory)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("在庫一覧読み取りエラー: %v", err)
+		return nil, nil, fmt.Errorf("在庫一覧読み取りエラー: %v", err)
 	}
 
-	return inventories, nil
+	page, n, err := q.page(ctx, r.db, len(inventories))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return inventories[:n], page, nil
 }
 
 // UpdateInventory 在庫を更新する
+// 読み取り時のバージョン（inventory.Version）と一致する場合のみ更新し、バージョンを1つ進める
+// 数量が変わる場合は増減を在庫元帳に記帳する
 func (r *SQLInventoryRepository) UpdateInventory(ctx context.Context, inventory *models.Inventory) error {
 	query := `
-		UPDATE inventory
-		SET product_id = $1, quantity = $2, location = $3,
-			status = $4, updated_at = $5
-		WHERE id = $6`
+		WITH previous AS (
+			SELECT id, quantity, version FROM inventory WHERE id = $8 FOR UPDATE
+		), updated AS (
+			UPDATE inventory i
+			SET product_id = $1, quantity = $2, location = $3,
+				warehouse_id = (SELECT id FROM warehouses WHERE name = $3),
+				bin_id = $4, lot_id = $5, status = $6, updated_at = $7,
+				version = i.version + 1
+			FROM previous p
+			WHERE i.id = p.id AND p.version = $9
+			RETURNING i.id, i.product_id, i.location, i.bin_id, i.lot_id,
+				i.quantity - p.quantity AS delta, i.quantity AS balance, i.version
+		), changed AS (
+			SELECT * FROM updated
+		), ` + ledgerInsert(10) + `
+		SELECT p.version, u.version FROM previous p LEFT JOIN updated u ON u.id = p.id`
 
-	result, err := r.db.ExecContext(ctx, query,
+	now := time.Now()
+	args := append([]interface{}{
 		inventory.ProductID,
 		inventory.Quantity,
 		inventory.Location,
+		inventory.BinID,
+		inventory.LotID,
 		inventory.Status,
-		time.Now(),
+		now,
 		inventory.ID,
-	)
-	if err != nil {
-		return fmt.Errorf("在庫更新エラー: %v", err)
-	}
+		inventory.Version,
+	}, ledgerArgs(ctx, models.LedgerEntryTypeChange, now)...)
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("結果取得エラー: %v", err)
-	}
-	if rows == 0 {
+	version, err := scanVersionedUpdate(r.db.QueryRowContext(ctx, query, args...), "在庫", inventory.ID, inventory.Version)
+	if err == ErrNotFound {
 		return fmt.Errorf("在庫が見つかりません")
 	}
+	if _, ok := err.(*models.VersionConflictError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("在庫更新エラー: %v", err)
+	}
 
+	inventory.Version = version
+	inventory.UpdatedAt = now
 	return nil
 }
 
 // DeleteInventory 在庫を削除する
+// 残っていた数量は減少として在庫元帳に記帳する
 func (r *SQLInventoryRepository) DeleteInventory(ctx context.Context, id int64) error {
-	query := `DELETE FROM inventory WHERE id = $1`
-
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
+	query := `
+		WITH deleted AS (
+			DELETE FROM inventory WHERE id = $1
+			RETURNING id, product_id, location, bin_id, lot_id, quantity
+		), changed AS (
+			SELECT id, product_id, location, bin_id, lot_id, -quantity AS delta, 0 AS balance
+			FROM deleted
+		), ` + ledgerInsert(2) + `
+		SELECT COUNT(*) FROM deleted`
+
+	args := append([]interface{}{id}, ledgerArgs(ctx, models.LedgerEntryTypeDelete, time.Now())...)
+
+	var rows int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&rows); err != nil {
 		return fmt.Errorf("在庫削除エラー: %v", err)
 	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("結果取得エラー: %v", err)
-	}
 	if rows == 0 {
 		return fmt.Errorf("在庫が見つかりません")
 	}
@@ -172,22 +322,12 @@ func (r *SQLInventoryRepository) DeleteInventory(ctx context.Context, id int64)
 
 // GetInventoryByProduct 商品IDから在庫を取得する
 func (r *SQLInventoryRepository) GetInventoryByProduct(ctx context.Context, productID int64) (*models.Inventory, error) {
-	inventory := &models.Inventory{}
 	query := `
-		SELECT id, product_id, quantity, location, status,
-			created_at, updated_at
+		SELECT ` + inventoryColumns + `
 		FROM inventory
 		WHERE product_id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(
-		&inventory.ID,
-		&inventory.ProductID,
-		&inventory.Quantity,
-		&inventory.Location,
-		&inventory.Status,
-		&inventory.CreatedAt,
-		&inventory.UpdatedAt,
-	)
+	inventory, err := scanInventory(r.db.QueryRowContext(ctx, query, productID))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("在庫が見つかりません")
@@ -199,29 +339,38 @@ func (r *SQLInventoryRepository) GetInventoryByProduct(ctx context.Context, prod
 	return inventory, nil
 }
 
-// UpdateQuantity 在庫数を更新する
-func (r *SQLInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int) error {
+// UpdateQuantity 在庫数を更新し、増減を在庫元帳に記帳する
+// 読み取り時のバージョン（version）と一致する場合のみ更新し、バージョンを1つ進める
+// 在庫移動に伴う変更の場合はWithLedgerMovementで在庫移動IDを設定したコンテキストを渡す
+func (r *SQLInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int, version int) error {
 	query := `
-		UPDATE inventory
-		SET quantity = $1, updated_at = $2
-		WHERE id = $3`
-
-	result, err := r.db.ExecContext(ctx, query,
-		quantity,
-		time.Now(),
-		id,
-	)
-	if err != nil {
-		return fmt.Errorf("在庫数更新エラー: %v", err)
-	}
+		WITH previous AS (
+			SELECT id, quantity, version FROM inventory WHERE id = $3 FOR UPDATE
+		), updated AS (
+			UPDATE inventory i
+			SET quantity = $1, updated_at = $2, version = i.version + 1
+			FROM previous p
+			WHERE i.id = p.id AND p.version = $4
+			RETURNING i.id, i.product_id, i.location, i.bin_id, i.lot_id,
+				i.quantity - p.quantity AS delta, i.quantity AS balance, i.version
+		), changed AS (
+			SELECT * FROM updated
+		), ` + ledgerInsert(5) + `
+		SELECT p.version, u.version FROM previous p LEFT JOIN updated u ON u.id = p.id`
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("結果取得エラー: %v", err)
-	}
-	if rows == 0 {
+	now := time.Now()
+	args := append([]interface{}{quantity, now, id, version}, ledgerArgs(ctx, models.LedgerEntryTypeChange, now)...)
+
+	_, err := scanVersionedUpdate(r.db.QueryRowContext(ctx, query, args...), "在庫", id, version)
+	if err == ErrNotFound {
 		return fmt.Errorf("在庫が見つかりません")
 	}
+	if _, ok := err.(*models.VersionConflictError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("在庫数更新エラー: %v", err)
+	}
 
 	return nil
 }
@@ -229,8 +378,7 @@ func (r *SQLInventoryRepository) UpdateQuantity(ctx context.Context, id int64, q
 // GetInventoryByLocation 場所から在庫を取得する
 func (r *SQLInventoryRepository) GetInventoryByLocation(ctx context.Context, location string) ([]*models.Inventory, error) {
 	query := `
-		SELECT id, product_id, quantity, location, status,
-			created_at, updated_at
+		SELECT ` + inventoryColumns + `
 		FROM inventory
 		WHERE location = $1
 		ORDER BY id`
@@ -243,16 +391,7 @@ func (r *SQLInventoryRepository) GetInventoryByLocation(ctx context.Context, loc
 
 	var inventories []*models.Inventory
 	for rows.Next() {
-		inventory := &models.Inventory{}
-		err := rows.Scan(
-			&inventory.ID,
-			&inventory.ProductID,
-			&inventory.Quantity,
-			&inventory.Location,
-			&inventory.Status,
-			&inventory.CreatedAt,
-			&inventory.UpdatedAt,
-		)
+		inventory, err := scanInventory(rows)
 		if err != nil {
 			return nil, fmt.Errorf("在庫データ読み取りエラー: %v", err)
 		}
@@ -266,16 +405,86 @@ func (r *SQLInventoryRepository) GetInventoryByLocation(ctx context.Context, loc
 	return inventories, nil
 }
 
+// GetInventoryByProductLocation 商品・ロケーションの在庫行を取得する
+func (r *SQLInventoryRepository) GetInventoryByProductLocation(ctx context.Context, productID int64, location string) ([]*models.Inventory, error) {
+	query := `
+		SELECT ` + inventoryColumns + `
+		FROM inventory
+		WHERE product_id = $1 AND location = $2
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, location)
+	if err != nil {
+		return nil, fmt.Errorf("在庫一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var inventories []*models.Inventory
+	for rows.Next() {
+		inventory, err := scanInventory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫データ読み取りエラー: %v", err)
+		}
+		inventories = append(inventories, inventory)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫一覧読み取りエラー: %v", err)
+	}
+
+	return inventories, nil
+}
+
+// GetInventoryByStockKey 商品・ロケーション・ビン・ロット・隔離区分が一致する在庫行を取得する
+// 隔離中でない在庫行として賞味期限切れの在庫行は対象としない（idx_inventory_stock_keyと同じキー）
+func (r *SQLInventoryRepository) GetInventoryByStockKey(ctx context.Context, productID int64, location string, binID, lotID *int64, quarantined bool) (*models.Inventory, error) {
+	query := `
+		SELECT ` + inventoryColumns + `
+		FROM inventory
+		WHERE product_id = $1 AND location = $2
+			AND COALESCE(bin_id, 0) = COALESCE($3, 0)
+			AND COALESCE(lot_id, 0) = COALESCE($4, 0)
+			AND status <> $5
+			AND (status = $6) = $7
+		ORDER BY id
+		LIMIT 1`
+
+	inventory, err := scanInventory(r.db.QueryRowContext(ctx, query,
+		productID,
+		location,
+		binID,
+		lotID,
+		models.InventoryStatusExpired,
+		models.InventoryStatusQuarantined,
+		quarantined,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫取得エラー: %v", err)
+	}
+
+	return inventory, nil
+}
+
 // CreateMovement 在庫移動を作成する
+// 荷姿を指定していない場合は基本単位の数量として記録する
 func (r *SQLInventoryRepository) CreateMovement(ctx context.Context, movement *models.InventoryMovement) error {
 	query := `
 		INSERT INTO inventory_movements (
 			product_id, from_location, to_location,
 			quantity, movement_type, movement_date,
-			reference_number, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			reference_number, created_at, from_bin_id, to_bin_id, lot_id,
+			reason_code, unit, unit_quantity
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13, $14)
 		RETURNING id`
 
+	if models.IsBaseUnit(movement.Unit) {
+		movement.Unit = models.BaseUnit
+		movement.UnitQuantity = movement.Quantity
+	}
+
 	now := time.Now()
 	err := r.db.QueryRowContext(ctx, query,
 		movement.ProductID,
@@ -286,6 +495,12 @@ func (r *SQLInventoryRepository) CreateMovement(ctx context.Context, movement *m
 		movement.MovementDate,
 		movement.ReferenceNumber,
 		now,
+		movement.FromBinID,
+		movement.ToBinID,
+		movement.LotID,
+		movement.ReasonCode,
+		movement.Unit,
+		movement.UnitQuantity,
 	).Scan(&movement.ID)
 
 	if err != nil {
@@ -299,9 +514,7 @@ func (r *SQLInventoryRepository) CreateMovement(ctx context.Context, movement *m
 // ListMovements 在庫移動履歴を取得する
 func (r *SQLInventoryRepository) ListMovements(ctx context.Context, productID int64) ([]*models.InventoryMovement, error) {
 	query := `
-		SELECT id, product_id, from_location, to_location,
-			quantity, movement_type, movement_date,
-			reference_number, created_at
+		SELECT ` + movementColumns + `
 		FROM inventory_movements
 		WHERE product_id = $1
 		ORDER BY movement_date DESC`
@@ -314,18 +527,7 @@ func (r *SQLInventoryRepository) ListMovements(ctx context.Context, productID in
 
 	var movements []*models.InventoryMovement
 	for rows.Next() {
-		movement := &models.InventoryMovement{}
-		err := rows.Scan(
-			&movement.ID,
-			&movement.ProductID,
-			&movement.FromLocation,
-			&movement.ToLocation,
-			&movement.Quantity,
-			&movement.MovementType,
-			&movement.MovementDate,
-			&movement.ReferenceNumber,
-			&movement.CreatedAt,
-		)
+		movement, err := scanMovement(rows)
 		if err != nil {
 			return nil, fmt.Errorf("在庫移動データ読み取りエラー: %v", err)
 		}
@@ -16,14 +16,19 @@ type InventoryRepository interface {
 	// 基本的なCRUD操作
 	CreateInventory(ctx context.Context, inventory *models.Inventory) error
 	GetInventory(ctx context.Context, id int64) (*models.Inventory, error)
-	ListInventories(ctx context.Context) ([]*models.Inventory, error)
+	ListInventories(ctx context.Context, spec *QuerySpec) ([]*models.Inventory, *PageInfo, error)
 	UpdateInventory(ctx context.Context, inventory *models.Inventory) error
 	DeleteInventory(ctx context.Context, id int64) error
 
 	// 在庫特有の操作
 	GetInventoryByProduct(ctx context.Context, productID int64) (*models.Inventory, error)
-	UpdateQuantity(ctx context.Context, id int64, quantity int) error
+	UpdateQuantity(ctx context.Context, id int64, quantity int, version int) error
 	GetInventoryByLocation(ctx context.Context, location string) ([]*models.Inventory, error)
+	// GetInventoryByProductLocation 商品・ロケーションの在庫行を取得する
+	GetInventoryByProductLocation(ctx context.Context, productID int64, location string) ([]*models.Inventory, error)
+	// GetInventoryByStockKey 商品・ロケーション・ビン・ロット・隔離区分が一致する在庫行を取得する
+	// binID・lotIDがnilの場合はビン未割当・ロット管理していない在庫行を対象とし、該当する在庫行がない場合はErrNotFoundを返す
+	GetInventoryByStockKey(ctx context.Context, productID int64, location string, binID, lotID *int64, quarantined bool) (*models.Inventory, error)
 	CreateMovement(ctx context.Context, movement *models.InventoryMovement) error
 	ListMovements(ctx context.Context, productID int64) ([]*models.InventoryMovement, error)
 }
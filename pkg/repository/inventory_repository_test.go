@@ -42,8 +42,9 @@ func TestSQLInventoryRepository_CreateInventory(t *testing.T) {
 			},
 			mockSetup: func() {
 				mock.ExpectQuery(`INSERT INTO inventory`).
-					WithArgs(1, 100, "東京倉庫", models.InventoryStatusAvailable, sqlmock.AnyArg()).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					WithArgs(1, 100, "東京倉庫", nil, nil, models.InventoryStatusAvailable, sqlmock.AnyArg(),
+						models.LedgerEntryTypeCreate, nil, 0, "", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "warehouse_id"}).AddRow(1, 1))
 			},
 			expectedError: false,
 			expectedID:    1,
@@ -58,7 +59,8 @@ func TestSQLInventoryRepository_CreateInventory(t *testing.T) {
 			},
 			mockSetup: func() {
 				mock.ExpectQuery(`INSERT INTO inventory`).
-					WithArgs(1, 100, "東京倉庫", models.InventoryStatusAvailable, sqlmock.AnyArg()).
+					WithArgs(1, 100, "東京倉庫", nil, nil, models.InventoryStatusAvailable, sqlmock.AnyArg(),
+						models.LedgerEntryTypeCreate, nil, 0, "", sqlmock.AnyArg()).
 					WillReturnError(sql.ErrConnDone)
 			},
 			expectedError: true,
@@ -105,9 +107,9 @@ func TestSQLInventoryRepository_GetInventory(t *testing.T) {
 			name: "正常な在庫取得",
 			id:   1,
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"}).
-					AddRow(1, 1, 100, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE id = \$1`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"}).
+					AddRow(1, 1, 100, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1)
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE id = \$1`).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -124,7 +126,7 @@ func TestSQLInventoryRepository_GetInventory(t *testing.T) {
 			name: "在庫が見つからない",
 			id:   999,
 			mockSetup: func() {
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE id = \$1`).
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE id = \$1`).
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -168,18 +170,19 @@ func TestSQLInventoryRepository_UpdateQuantity(t *testing.T) {
 	tests := []struct {
 		name          string
 		id            int64
-		quantity      int
-		mockSetup     func()
-		expectedError bool
+		quantity         int
+		mockSetup        func()
+		expectedError    bool
+		expectedConflict bool
 	}{
 		{
 			name:     "正常な在庫数更新",
 			id:       1,
 			quantity:  150,
 			mockSetup: func() {
-				mock.ExpectExec(`UPDATE inventory SET quantity = \$1, updated_at = \$2 WHERE id = \$3`).
-					WithArgs(150, sqlmock.AnyArg(), 1).
-					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`UPDATE inventory i\s+SET quantity = \$1, updated_at = \$2, version = i.version \+ 1 FROM previous p WHERE i.id = p.id AND p.version = \$4`).
+					WithArgs(150, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(1, 2))
 			},
 			expectedError: false,
 		},
@@ -188,12 +191,24 @@ func TestSQLInventoryRepository_UpdateQuantity(t *testing.T) {
 			id:       999,
 			quantity:  150,
 			mockSetup: func() {
-				mock.ExpectExec(`UPDATE inventory SET quantity = \$1, updated_at = \$2 WHERE id = \$3`).
-					WithArgs(150, sqlmock.AnyArg(), 999).
-					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(`UPDATE inventory i\s+SET quantity = \$1, updated_at = \$2, version = i.version \+ 1 FROM previous p WHERE i.id = p.id AND p.version = \$4`).
+					WithArgs(150, sqlmock.AnyArg(), 999, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "version"}))
 			},
 			expectedError: true,
 		},
+		{
+			name:     "他の操作で更新済み",
+			id:       1,
+			quantity: 150,
+			mockSetup: func() {
+				mock.ExpectQuery(`UPDATE inventory i\s+SET quantity = \$1, updated_at = \$2, version = i.version \+ 1 FROM previous p WHERE i.id = p.id AND p.version = \$4`).
+					WithArgs(150, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(3, nil))
+			},
+			expectedError:    true,
+			expectedConflict: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,9 +216,14 @@ func TestSQLInventoryRepository_UpdateQuantity(t *testing.T) {
 			mock.ExpectationsWereMet()
 			tt.mockSetup()
 
-			err := repo.UpdateQuantity(context.Background(), tt.id, tt.quantity)
+			err := repo.UpdateQuantity(context.Background(), tt.id, tt.quantity, 1)
 
-			if tt.expectedError {
+			if tt.expectedConflict {
+				var conflictErr *models.VersionConflictError
+				if assert.ErrorAs(t, err, &conflictErr) {
+					assert.Equal(t, 3, conflictErr.CurrentVersion)
+				}
+			} else if tt.expectedError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
@@ -232,9 +252,9 @@ func TestSQLInventoryRepository_GetInventoryByProduct(t *testing.T) {
 			name:      "正常な商品在庫取得",
 			productID: 1,
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"}).
-					AddRow(1, 1, 100, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE product_id = \$1`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"}).
+					AddRow(1, 1, 100, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1)
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE product_id = \$1`).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -251,7 +271,7 @@ func TestSQLInventoryRepository_GetInventoryByProduct(t *testing.T) {
 			name:      "商品在庫が見つからない",
 			productID: 999,
 			mockSetup: func() {
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE product_id = \$1`).
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE product_id = \$1`).
 					WithArgs(999).
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -312,7 +332,7 @@ func TestSQLInventoryRepository_CreateMovement(t *testing.T) {
 			},
 			mockSetup: func() {
 				mock.ExpectQuery(`INSERT INTO inventory_movements`).
-					WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg()).
+					WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg(), nil, nil, nil, "", "g", 50).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 			},
 			expectedError: false,
@@ -331,7 +351,7 @@ func TestSQLInventoryRepository_CreateMovement(t *testing.T) {
 			},
 			mockSetup: func() {
 				mock.ExpectQuery(`INSERT INTO inventory_movements`).
-					WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg()).
+					WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg(), nil, nil, nil, "", "g", 50).
 					WillReturnError(sql.ErrConnDone)
 			},
 			expectedError: true,
@@ -377,10 +397,10 @@ func TestSQLInventoryRepository_ListMovements(t *testing.T) {
 			name:      "正常な移動履歴取得",
 			productID: 1,
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "from_location", "to_location", "quantity", "movement_type", "movement_date", "reference_number", "created_at"}).
-					AddRow(1, 1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, time.Now(), "TRF-001", time.Now()).
-					AddRow(2, 1, "大阪倉庫", "名古屋倉庫", 30, models.MovementTypeTransfer, time.Now(), "TRF-002", time.Now())
-				mock.ExpectQuery(`SELECT id, product_id, from_location, to_location, quantity, movement_type, movement_date, reference_number, created_at FROM inventory_movements WHERE product_id = \$1 ORDER BY movement_date DESC`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "from_location", "to_location", "quantity", "movement_type", "movement_date", "reference_number", "created_at", "from_bin_id", "to_bin_id", "lot_id", "reason_code", "unit", "unit_quantity"}).
+					AddRow(1, 1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, time.Now(), "TRF-001", time.Now(), nil, nil, nil, nil, "g", 50).
+					AddRow(2, 1, "大阪倉庫", "名古屋倉庫", 30, models.MovementTypeTransfer, time.Now(), "TRF-002", time.Now(), nil, nil, nil, nil, "g", 30)
+				mock.ExpectQuery(`SELECT id, product_id, from_location, to_location, quantity, movement_type, movement_date, reference_number, created_at, from_bin_id, to_bin_id, lot_id, reason_code, unit, unit_quantity FROM inventory_movements WHERE product_id = \$1 ORDER BY movement_date DESC`).
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -391,8 +411,8 @@ func TestSQLInventoryRepository_ListMovements(t *testing.T) {
 			name:      "移動履歴が存在しない",
 			productID: 999,
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "from_location", "to_location", "quantity", "movement_type", "movement_date", "reference_number", "created_at"})
-				mock.ExpectQuery(`SELECT id, product_id, from_location, to_location, quantity, movement_type, movement_date, reference_number, created_at FROM inventory_movements WHERE product_id = \$1 ORDER BY movement_date DESC`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "from_location", "to_location", "quantity", "movement_type", "movement_date", "reference_number", "created_at", "from_bin_id", "to_bin_id", "lot_id", "reason_code", "unit", "unit_quantity"})
+				mock.ExpectQuery(`SELECT id, product_id, from_location, to_location, quantity, movement_type, movement_date, reference_number, created_at, from_bin_id, to_bin_id, lot_id, reason_code, unit, unit_quantity FROM inventory_movements WHERE product_id = \$1 ORDER BY movement_date DESC`).
 					WithArgs(999).
 					WillReturnRows(rows)
 			},
@@ -439,10 +459,10 @@ func TestSQLInventoryRepository_GetInventoryByLocation(t *testing.T) {
 			name:     "正常なロケーション別在庫取得",
 			location: "東京倉庫",
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"}).
-					AddRow(1, 1, 100, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now()).
-					AddRow(2, 2, 50, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE location = \$1 ORDER BY id`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"}).
+					AddRow(1, 1, 100, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1).
+					AddRow(2, 2, 50, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1)
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE location = \$1 ORDER BY id`).
 					WithArgs("東京倉庫").
 					WillReturnRows(rows)
 			},
@@ -453,8 +473,8 @@ func TestSQLInventoryRepository_GetInventoryByLocation(t *testing.T) {
 			name:     "ロケーションに在庫が存在しない",
 			location: "存在しない倉庫",
 			mockSetup: func() {
-				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"})
-				mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE location = \$1 ORDER BY id`).
+				rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"})
+				mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE location = \$1 ORDER BY id`).
 					WithArgs("存在しない倉庫").
 					WillReturnRows(rows)
 			},
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 在庫元帳リポジトリ
+ * 在庫数の増減の記帳（在庫リポジトリが在庫の変更と同一のSQL文で行う）と元帳の照会・照合を管理する
+ */
+
+// LedgerRepository 在庫元帳リポジトリインターフェース
+type LedgerRepository interface {
+	// ListLedgerEntries 在庫行の記帳を古い順に取得する
+	ListLedgerEntries(ctx context.Context, inventoryID int64) ([]*models.LedgerEntry, error)
+	// ListLedgerBalances すべての在庫行の数量と元帳から再計算した残高を取得する
+	ListLedgerBalances(ctx context.Context) ([]*models.LedgerBalance, error)
+	// RestoreQuantity 在庫行の数量を元帳残高に戻す（元帳には記帳しない。照合による修正専用）
+	// 照合時のバージョン（version）と一致する場合のみ戻す
+	RestoreQuantity(ctx context.Context, inventoryID int64, version int) error
+}
+
+// SQLLedgerRepository SQL在庫元帳リポジトリ
+type SQLLedgerRepository struct {
+	db DB
+}
+
+// NewSQLLedgerRepository SQL在庫元帳リポジトリを作成する
+func NewSQLLedgerRepository(db DB) LedgerRepository {
+	return &SQLLedgerRepository{db: db}
+}
+
+type ledgerMovementKey struct{}
+
+// WithLedgerMovement 在庫の変更を在庫移動に紐付けて記帳するコンテキストを返す
+func WithLedgerMovement(ctx context.Context, movementID int64) context.Context {
+	return context.WithValue(ctx, ledgerMovementKey{}, movementID)
+}
+
+// ledgerSource コンテキストから記帳に付加する在庫移動・操作ユーザー・リクエストIDを取得する
+func ledgerSource(ctx context.Context) (movementID *int64, actorID int64, requestID string) {
+	if id, ok := ctx.Value(ledgerMovementKey{}).(int64); ok {
+		movementID = &id
+	}
+	return movementID, logger.ActorIDFromContext(ctx), logger.RequestIDFromContext(ctx)
+}
+
+// ledgerInsert 在庫の変更を返すCTE（changed）の行を記帳するCTE
+// changedはid, product_id, location, bin_id, lot_id, delta, balanceを返し、増減のない行は記帳しない
+// パラメータは記帳区分・在庫移動ID・操作ユーザーID・リクエストID・記帳日時の順で、先頭の番号をfirstで指定する
+func ledgerInsert(first int) string {
+	return fmt.Sprintf(`entry AS (
+			INSERT INTO inventory_ledger (
+				inventory_id, product_id, location, bin_id, lot_id, quantity, balance,
+				entry_type, movement_id, actor_id, request_id, created_at
+			)
+			SELECT id, product_id, location, bin_id, lot_id, delta, balance,
+				$%d, $%d, NULLIF($%d, 0), NULLIF($%d, ''), $%d
+			FROM changed
+			WHERE delta <> 0
+		)`, first, first+1, first+2, first+3, first+4)
+}
+
+// ledgerArgs 記帳のパラメータを返す
+func ledgerArgs(ctx context.Context, entryType models.LedgerEntryType, at time.Time) []interface{} {
+	movementID, actorID, requestID := ledgerSource(ctx)
+	return []interface{}{entryType, movementID, actorID, requestID, at}
+}
+
+// ListLedgerEntries 在庫行の記帳を古い順に取得する
+func (r *SQLLedgerRepository) ListLedgerEntries(ctx context.Context, inventoryID int64) ([]*models.LedgerEntry, error) {
+	query := `
+		SELECT id, inventory_id, product_id, location, bin_id, lot_id, quantity, balance,
+			entry_type, movement_id, COALESCE(actor_id, 0), COALESCE(request_id, ''), created_at
+		FROM inventory_ledger
+		WHERE inventory_id = $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, inventoryID)
+	if err != nil {
+		return nil, fmt.Errorf("在庫元帳取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.LedgerEntry
+	for rows.Next() {
+		entry := &models.LedgerEntry{}
+		var binID, lotID, movementID sql.NullInt64
+		err := rows.Scan(
+			&entry.ID,
+			&entry.InventoryID,
+			&entry.ProductID,
+			&entry.Location,
+			&binID,
+			&lotID,
+			&entry.Quantity,
+			&entry.Balance,
+			&entry.EntryType,
+			&movementID,
+			&entry.ActorID,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("在庫元帳データ読み取りエラー: %v", err)
+		}
+		if binID.Valid {
+			id := binID.Int64
+			entry.BinID = &id
+		}
+		if lotID.Valid {
+			id := lotID.Int64
+			entry.LotID = &id
+		}
+		if movementID.Valid {
+			id := movementID.Int64
+			entry.MovementID = &id
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫元帳読み取りエラー: %v", err)
+	}
+
+	return entries, nil
+}
+
+// ListLedgerBalances すべての在庫行の数量と元帳から再計算した残高を取得する
+func (r *SQLLedgerRepository) ListLedgerBalances(ctx context.Context) ([]*models.LedgerBalance, error) {
+	query := `
+		SELECT i.id, i.product_id, i.location, i.quantity, i.version, COALESCE(SUM(l.quantity), 0)
+		FROM inventory i
+		LEFT JOIN inventory_ledger l ON l.inventory_id = i.id
+		GROUP BY i.id, i.product_id, i.location, i.quantity, i.version
+		ORDER BY i.id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("元帳残高取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var balances []*models.LedgerBalance
+	for rows.Next() {
+		balance := &models.LedgerBalance{}
+		err := rows.Scan(
+			&balance.InventoryID,
+			&balance.ProductID,
+			&balance.Location,
+			&balance.Quantity,
+			&balance.Version,
+			&balance.LedgerBalance,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("元帳残高データ読み取りエラー: %v", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("元帳残高読み取りエラー: %v", err)
+	}
+
+	return balances, nil
+}
+
+// RestoreQuantity 在庫行の数量を元帳残高に戻す
+// 元帳残高は更新と同一のSQL文で再計算する。在庫の変更は必ずバージョンを進めて同じSQL文で記帳するため、
+// 照合時のバージョンと一致していれば照合後に記帳された増減はない
+// 対象が存在しない場合はErrNotFound、バージョンが一致しない場合は*models.VersionConflictErrorを返す
+func (r *SQLLedgerRepository) RestoreQuantity(ctx context.Context, inventoryID int64, version int) error {
+	query := `
+		WITH previous AS (
+			SELECT id, version FROM inventory WHERE id = $1 FOR UPDATE
+		), updated AS (
+			UPDATE inventory i
+			SET quantity = (
+					SELECT COALESCE(SUM(l.quantity), 0) FROM inventory_ledger l WHERE l.inventory_id = i.id
+				),
+				updated_at = $2, version = i.version + 1
+			FROM previous p
+			WHERE i.id = p.id AND p.version = $3
+			RETURNING i.id, i.version
+		)
+		SELECT p.version, u.version FROM previous p LEFT JOIN updated u ON u.id = p.id`
+
+	_, err := scanVersionedUpdate(r.db.QueryRowContext(ctx, query, inventoryID, time.Now(), version), "在庫", inventoryID, version)
+	if err == ErrNotFound {
+		return err
+	}
+	if _, ok := err.(*models.VersionConflictError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("在庫数復元エラー: %v", err)
+	}
+
+	return nil
+}
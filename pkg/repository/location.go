@@ -0,0 +1,437 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * ロケーション階層リポジトリ
+ * 倉庫内のゾーン・通路・ビンに関する操作を管理する
+ */
+
+// LocationRepository ロケーション階層リポジトリインターフェース
+type LocationRepository interface {
+	// ゾーン
+	CreateZone(ctx context.Context, zone *models.Zone) error
+	GetZone(ctx context.Context, id int64) (*models.Zone, error)
+	ListZones(ctx context.Context, warehouseID int64) ([]*models.Zone, error)
+	SetZoneCategories(ctx context.Context, zoneID int64, categories []string) error
+
+	// 通路
+	CreateAisle(ctx context.Context, aisle *models.Aisle) error
+	ListAisles(ctx context.Context, zoneID int64) ([]*models.Aisle, error)
+
+	// ビン
+	CreateBin(ctx context.Context, bin *models.Bin) error
+	ListBins(ctx context.Context, aisleID int64) ([]*models.Bin, error)
+	GetBinLocation(ctx context.Context, binID int64) (*models.BinLocation, error)
+
+	// GetProductCategory 保管ルールの判定に使用する商品カテゴリを取得する
+	GetProductCategory(ctx context.Context, productID int64) (string, error)
+	// ListLocationStock ロケーション階層で在庫を集計する
+	ListLocationStock(ctx context.Context, query *models.LocationStockQuery) ([]*models.LocationStock, error)
+}
+
+// SQLLocationRepository SQLロケーション階層リポジトリ
+type SQLLocationRepository struct {
+	db DB
+}
+
+// NewSQLLocationRepository SQLロケーション階層リポジトリを作成する
+func NewSQLLocationRepository(db DB) LocationRepository {
+	return &SQLLocationRepository{db: db}
+}
+
+// CreateZone ゾーンを作成する
+func (r *SQLLocationRepository) CreateZone(ctx context.Context, zone *models.Zone) error {
+	query := `
+		INSERT INTO zones (
+			warehouse_id, code, name, zone_type,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		zone.WarehouseID,
+		zone.Code,
+		zone.Name,
+		zone.ZoneType,
+		now,
+	).Scan(&zone.ID)
+	if err != nil {
+		return fmt.Errorf("ゾーン作成エラー: %v", err)
+	}
+
+	if err := r.SetZoneCategories(ctx, zone.ID, zone.AllowedCategories); err != nil {
+		return err
+	}
+
+	zone.CreatedAt = now
+	zone.UpdatedAt = now
+	return nil
+}
+
+// GetZone ゾーンを取得する
+func (r *SQLLocationRepository) GetZone(ctx context.Context, id int64) (*models.Zone, error) {
+	zone := &models.Zone{}
+	query := `
+		SELECT id, warehouse_id, code, name, zone_type,
+			created_at, updated_at
+		FROM zones
+		WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&zone.ID,
+		&zone.WarehouseID,
+		&zone.Code,
+		&zone.Name,
+		&zone.ZoneType,
+		&zone.CreatedAt,
+		&zone.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン取得エラー: %v", err)
+	}
+
+	if zone.AllowedCategories, err = r.listZoneCategories(ctx, zone.ID); err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+// ListZones 倉庫のゾーン一覧を取得する
+func (r *SQLLocationRepository) ListZones(ctx context.Context, warehouseID int64) ([]*models.Zone, error) {
+	query := `
+		SELECT id, warehouse_id, code, name, zone_type,
+			created_at, updated_at
+		FROM zones
+		WHERE warehouse_id = $1
+		ORDER BY code`
+
+	rows, err := r.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var zones []*models.Zone
+	for rows.Next() {
+		zone := &models.Zone{}
+		err := rows.Scan(
+			&zone.ID,
+			&zone.WarehouseID,
+			&zone.Code,
+			&zone.Name,
+			&zone.ZoneType,
+			&zone.CreatedAt,
+			&zone.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ゾーンデータ読み取りエラー: %v", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ゾーン一覧読み取りエラー: %v", err)
+	}
+
+	for _, zone := range zones {
+		if zone.AllowedCategories, err = r.listZoneCategories(ctx, zone.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return zones, nil
+}
+
+// listZoneCategories ゾーンに保管できる商品カテゴリを取得する
+func (r *SQLLocationRepository) listZoneCategories(ctx context.Context, zoneID int64) ([]string, error) {
+	query := `
+		SELECT category
+		FROM zone_allowed_categories
+		WHERE zone_id = $1
+		ORDER BY category`
+
+	rows, err := r.db.QueryContext(ctx, query, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン保管カテゴリ取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	categories := []string{}
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("ゾーン保管カテゴリ読み取りエラー: %v", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ゾーン保管カテゴリ読み取りエラー: %v", err)
+	}
+
+	return categories, nil
+}
+
+// SetZoneCategories ゾーンに保管できる商品カテゴリを置き換える
+// 空の場合はすべてのカテゴリを保管できる
+func (r *SQLLocationRepository) SetZoneCategories(ctx context.Context, zoneID int64, categories []string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM zone_allowed_categories WHERE zone_id = $1`, zoneID); err != nil {
+		return fmt.Errorf("ゾーン保管カテゴリ削除エラー: %v", err)
+	}
+
+	query := `
+		INSERT INTO zone_allowed_categories (zone_id, category)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	for _, category := range categories {
+		if _, err := r.db.ExecContext(ctx, query, zoneID, category); err != nil {
+			return fmt.Errorf("ゾーン保管カテゴリ作成エラー: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateAisle 通路を作成する
+func (r *SQLLocationRepository) CreateAisle(ctx context.Context, aisle *models.Aisle) error {
+	query := `
+		INSERT INTO aisles (zone_id, code, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING id`
+
+	now := time.Now()
+	if err := r.db.QueryRowContext(ctx, query, aisle.ZoneID, aisle.Code, now).Scan(&aisle.ID); err != nil {
+		return fmt.Errorf("通路作成エラー: %v", err)
+	}
+
+	aisle.CreatedAt = now
+	aisle.UpdatedAt = now
+	return nil
+}
+
+// ListAisles ゾーンの通路一覧を取得する
+func (r *SQLLocationRepository) ListAisles(ctx context.Context, zoneID int64) ([]*models.Aisle, error) {
+	query := `
+		SELECT id, zone_id, code, created_at, updated_at
+		FROM aisles
+		WHERE zone_id = $1
+		ORDER BY code`
+
+	rows, err := r.db.QueryContext(ctx, query, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("通路一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var aisles []*models.Aisle
+	for rows.Next() {
+		aisle := &models.Aisle{}
+		if err := rows.Scan(&aisle.ID, &aisle.ZoneID, &aisle.Code, &aisle.CreatedAt, &aisle.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("通路データ読み取りエラー: %v", err)
+		}
+		aisles = append(aisles, aisle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("通路一覧読み取りエラー: %v", err)
+	}
+
+	return aisles, nil
+}
+
+// CreateBin ビンを作成する
+func (r *SQLLocationRepository) CreateBin(ctx context.Context, bin *models.Bin) error {
+	query := `
+		INSERT INTO bins (aisle_id, code, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING id`
+
+	now := time.Now()
+	if err := r.db.QueryRowContext(ctx, query, bin.AisleID, bin.Code, now).Scan(&bin.ID); err != nil {
+		return fmt.Errorf("ビン作成エラー: %v", err)
+	}
+
+	bin.CreatedAt = now
+	bin.UpdatedAt = now
+	return nil
+}
+
+// ListBins 通路のビン一覧を取得する
+func (r *SQLLocationRepository) ListBins(ctx context.Context, aisleID int64) ([]*models.Bin, error) {
+	query := `
+		SELECT id, aisle_id, code, created_at, updated_at
+		FROM bins
+		WHERE aisle_id = $1
+		ORDER BY code`
+
+	rows, err := r.db.QueryContext(ctx, query, aisleID)
+	if err != nil {
+		return nil, fmt.Errorf("ビン一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var bins []*models.Bin
+	for rows.Next() {
+		bin := &models.Bin{}
+		if err := rows.Scan(&bin.ID, &bin.AisleID, &bin.Code, &bin.CreatedAt, &bin.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ビンデータ読み取りエラー: %v", err)
+		}
+		bins = append(bins, bin)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ビン一覧読み取りエラー: %v", err)
+	}
+
+	return bins, nil
+}
+
+// GetBinLocation ビンの階層上の位置を取得する
+func (r *SQLLocationRepository) GetBinLocation(ctx context.Context, binID int64) (*models.BinLocation, error) {
+	location := &models.BinLocation{}
+	query := `
+		SELECT b.id, b.code, a.id, a.code, z.id, z.code, z.zone_type,
+			w.id, w.name
+		FROM bins b
+		JOIN aisles a ON a.id = b.aisle_id
+		JOIN zones z ON z.id = a.zone_id
+		JOIN warehouses w ON w.id = z.warehouse_id
+		WHERE b.id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, binID).Scan(
+		&location.BinID,
+		&location.BinCode,
+		&location.AisleID,
+		&location.AisleCode,
+		&location.ZoneID,
+		&location.ZoneCode,
+		&location.ZoneType,
+		&location.WarehouseID,
+		&location.WarehouseName,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ビン位置取得エラー: %v", err)
+	}
+
+	return location, nil
+}
+
+// GetProductCategory 商品カテゴリを取得する
+func (r *SQLLocationRepository) GetProductCategory(ctx context.Context, productID int64) (string, error) {
+	var category sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT category FROM products WHERE id = $1`, productID).Scan(&category)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("商品カテゴリ取得エラー: %v", err)
+	}
+
+	return category.String, nil
+}
+
+// ListLocationStock ロケーション階層で在庫を集計する
+// 倉庫・ゾーン・商品ごとに数量を合計し、ビン未割当の在庫はゾーンなしとして集計する
+func (r *SQLLocationRepository) ListLocationStock(ctx context.Context, q *models.LocationStockQuery) ([]*models.LocationStock, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if q.WarehouseID != 0 {
+		addCondition("w.id = $%d", q.WarehouseID)
+	}
+	if q.ZoneID != 0 {
+		addCondition("z.id = $%d", q.ZoneID)
+	}
+	if q.ZoneType != "" {
+		addCondition("z.zone_type = $%d", q.ZoneType)
+	}
+	if q.AisleID != 0 {
+		addCondition("a.id = $%d", q.AisleID)
+	}
+	if q.BinID != 0 {
+		addCondition("b.id = $%d", q.BinID)
+	}
+	if q.ProductID != 0 {
+		addCondition("p.id = $%d", q.ProductID)
+	}
+	if q.Category != "" {
+		addCondition("p.category = $%d", q.Category)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT w.id, w.name, z.id, COALESCE(z.code, ''), COALESCE(z.zone_type, ''),
+			p.id, p.name, COALESCE(p.category, ''), SUM(i.quantity)
+		FROM inventory i
+		JOIN warehouses w ON w.id = i.warehouse_id
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN bins b ON b.id = i.bin_id
+		LEFT JOIN aisles a ON a.id = b.aisle_id
+		LEFT JOIN zones z ON z.id = a.zone_id
+		%s
+		GROUP BY w.id, w.name, z.id, z.code, z.zone_type, p.id, p.name, p.category
+		ORDER BY w.id, z.code, p.id`, where)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ロケーション在庫集計エラー: %v", err)
+	}
+	defer rows.Close()
+
+	stocks := []*models.LocationStock{}
+	for rows.Next() {
+		stock := &models.LocationStock{}
+		var zoneID sql.NullInt64
+		err := rows.Scan(
+			&stock.WarehouseID,
+			&stock.WarehouseName,
+			&zoneID,
+			&stock.ZoneCode,
+			&stock.ZoneType,
+			&stock.ProductID,
+			&stock.ProductName,
+			&stock.Category,
+			&stock.Quantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ロケーション在庫読み取りエラー: %v", err)
+		}
+		if zoneID.Valid {
+			id := zoneID.Int64
+			stock.ZoneID = &id
+		}
+		stocks = append(stocks, stock)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロケーション在庫読み取りエラー: %v", err)
+	}
+
+	return stocks, nil
+}
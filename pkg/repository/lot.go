@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * ロットリポジトリ
+ * データベースとの収穫ロット・出荷ロット関連の操作を管理する
+ */
+
+// LotRepository ロットリポジトリインターフェース
+type LotRepository interface {
+	CreateLot(ctx context.Context, lot *models.Lot) error
+	GetLot(ctx context.Context, id int64) (*models.Lot, error)
+	GetLotByNumber(ctx context.Context, lotNumber string) (*models.Lot, error)
+	ListLots(ctx context.Context, productID int64) ([]*models.Lot, error)
+
+	// CreateDeliveryItemLot 配送明細の出荷ロットを記録する
+	CreateDeliveryItemLot(ctx context.Context, itemLot *models.DeliveryItemLot) error
+
+	// トレーサビリティ
+	ListLotStock(ctx context.Context, lotID int64) ([]*models.Inventory, error)
+	ListLotMovements(ctx context.Context, lotID int64) ([]*models.InventoryMovement, error)
+	ListLotDeliveries(ctx context.Context, lotID int64) ([]*models.LotDelivery, error)
+}
+
+// SQLLotRepository SQLロットリポジトリ
+type SQLLotRepository struct {
+	db DB
+}
+
+// NewSQLLotRepository SQLロットリポジトリを作成する
+func NewSQLLotRepository(db DB) LotRepository {
+	return &SQLLotRepository{db: db}
+}
+
+const lotColumns = `id, lot_number, product_id, harvest_flush, harvest_date,
+			origin_estate, grade, best_before_date, created_at, updated_at`
+
+// scanLot ロット行を読み取る
+func scanLot(scanner rowScanner) (*models.Lot, error) {
+	lot := &models.Lot{}
+	err := scanner.Scan(
+		&lot.ID,
+		&lot.LotNumber,
+		&lot.ProductID,
+		&lot.HarvestFlush,
+		&lot.HarvestDate,
+		&lot.OriginEstate,
+		&lot.Grade,
+		&lot.BestBeforeDate,
+		&lot.CreatedAt,
+		&lot.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// CreateLot ロットを作成する
+func (r *SQLLotRepository) CreateLot(ctx context.Context, lot *models.Lot) error {
+	query := `
+		INSERT INTO lots (
+			lot_number, product_id, harvest_flush, harvest_date,
+			origin_estate, grade, best_before_date,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		lot.LotNumber,
+		lot.ProductID,
+		lot.HarvestFlush,
+		lot.HarvestDate,
+		lot.OriginEstate,
+		lot.Grade,
+		lot.BestBeforeDate,
+		now,
+	).Scan(&lot.ID)
+	if err != nil {
+		return fmt.Errorf("ロット作成エラー: %v", err)
+	}
+
+	lot.CreatedAt = now
+	lot.UpdatedAt = now
+	return nil
+}
+
+// GetLot ロットを取得する
+func (r *SQLLotRepository) GetLot(ctx context.Context, id int64) (*models.Lot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM lots
+		WHERE id = $1`
+
+	lot, err := scanLot(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロット取得エラー: %v", err)
+	}
+
+	return lot, nil
+}
+
+// GetLotByNumber ロット番号からロットを取得する
+func (r *SQLLotRepository) GetLotByNumber(ctx context.Context, lotNumber string) (*models.Lot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM lots
+		WHERE lot_number = $1`
+
+	lot, err := scanLot(r.db.QueryRowContext(ctx, query, lotNumber))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロット取得エラー: %v", err)
+	}
+
+	return lot, nil
+}
+
+// ListLots ロット一覧を取得する
+// productIDが0の場合はすべての商品のロットを取得する
+func (r *SQLLotRepository) ListLots(ctx context.Context, productID int64) ([]*models.Lot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM lots
+		WHERE $1 = 0 OR product_id = $1
+		ORDER BY best_before_date, id`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var lots []*models.Lot
+	for rows.Next() {
+		lot, err := scanLot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ロットデータ読み取りエラー: %v", err)
+		}
+		lots = append(lots, lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロット一覧読み取りエラー: %v", err)
+	}
+
+	return lots, nil
+}
+
+// CreateDeliveryItemLot 配送明細の出荷ロットを記録する
+func (r *SQLLotRepository) CreateDeliveryItemLot(ctx context.Context, itemLot *models.DeliveryItemLot) error {
+	query := `
+		INSERT INTO delivery_item_lots (
+			delivery_item_id, lot_id, quantity, created_at
+		) VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		itemLot.DeliveryItemID,
+		itemLot.LotID,
+		itemLot.Quantity,
+		now,
+	).Scan(&itemLot.ID)
+	if err != nil {
+		return fmt.Errorf("出荷ロット作成エラー: %v", err)
+	}
+
+	itemLot.CreatedAt = now
+	return nil
+}
+
+// ListLotStock ロットの在庫を保管場所ごとに取得する
+func (r *SQLLotRepository) ListLotStock(ctx context.Context, lotID int64) ([]*models.Inventory, error) {
+	query := `
+		SELECT ` + inventoryColumns + `
+		FROM inventory
+		WHERE lot_id = $1 AND quantity > 0
+		ORDER BY location, id`
+
+	rows, err := r.db.QueryContext(ctx, query, lotID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット在庫取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var inventories []*models.Inventory
+	for rows.Next() {
+		inventory, err := scanInventory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫データ読み取りエラー: %v", err)
+		}
+		inventories = append(inventories, inventory)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロット在庫読み取りエラー: %v", err)
+	}
+
+	return inventories, nil
+}
+
+// ListLotMovements ロットの在庫移動履歴を古い順に取得する
+func (r *SQLLotRepository) ListLotMovements(ctx context.Context, lotID int64) ([]*models.InventoryMovement, error) {
+	query := `
+		SELECT ` + movementColumns + `
+		FROM inventory_movements
+		WHERE lot_id = $1
+		ORDER BY movement_date, id`
+
+	rows, err := r.db.QueryContext(ctx, query, lotID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット移動履歴取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var movements []*models.InventoryMovement
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫移動データ読み取りエラー: %v", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロット移動履歴読み取りエラー: %v", err)
+	}
+
+	return movements, nil
+}
+
+// ListLotDeliveries ロットを出荷した配送を取得する
+func (r *SQLLotRepository) ListLotDeliveries(ctx context.Context, lotID int64) ([]*models.LotDelivery, error) {
+	query := `
+		SELECT d.id, di.id, d.order_id, d.status, d.to_address,
+			dil.quantity, dil.created_at
+		FROM delivery_item_lots dil
+		JOIN delivery_items di ON di.id = dil.delivery_item_id
+		JOIN deliveries d ON d.id = di.delivery_id
+		WHERE dil.lot_id = $1
+		ORDER BY dil.created_at, dil.id`
+
+	rows, err := r.db.QueryContext(ctx, query, lotID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット出荷先取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.LotDelivery
+	for rows.Next() {
+		delivery := &models.LotDelivery{}
+		err := rows.Scan(
+			&delivery.DeliveryID,
+			&delivery.DeliveryItemID,
+			&delivery.OrderID,
+			&delivery.Status,
+			&delivery.ToAddress,
+			&delivery.Quantity,
+			&delivery.ShippedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ロット出荷先データ読み取りエラー: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロット出荷先読み取りエラー: %v", err)
+	}
+
+	return deliveries, nil
+}
@@ -23,7 +23,7 @@ type NotificationRepository interface {
 	GetNotification(ctx context.Context, id int64) (*models.Notification, error)
 
 	// ListNotifications ユーザーの通知一覧を取得する
-	ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error)
+	ListNotifications(ctx context.Context, userID int64, spec *QuerySpec) ([]*models.Notification, *PageInfo, error)
 
 	// UpdateNotificationStatus 通知ステータスを更新する
 	UpdateNotificationStatus(ctx context.Context, id int64, status models.NotificationStatus) error
@@ -32,6 +32,19 @@ type NotificationRepository interface {
 	DeleteNotification(ctx context.Context, id int64) error
 }
 
+// notificationQuery 通知一覧の絞り込み・並べ替えに使用できる項目
+var notificationQuery = &queryTable{
+	name: "notifications",
+	fields: map[string]queryField{
+		"id":         {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"type":       {column: "type", typ: fieldString, filterable: true, sortable: true},
+		"status":     {column: "status", typ: fieldString, filterable: true, sortable: true},
+		"created_at": {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+		"updated_at": {column: "updated_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "created_at", Descending: true}},
+}
+
 // SQLNotificationRepository SQL通知リポジトリ
 type SQLNotificationRepository struct {
 	db DB
@@ -123,17 +136,21 @@ func (r *SQLNotificationRepository) GetNotification(ctx context.Context, id int6
 }
 
 // ListNotifications 通知一覧を取得する
-func (r *SQLNotificationRepository) ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error) {
+// specがnilの場合は全件を新しい順に取得する
+func (r *SQLNotificationRepository) ListNotifications(ctx context.Context, userID int64, spec *QuerySpec) ([]*models.Notification, *PageInfo, error) {
+	q, err := notificationQuery.build(spec, []string{"user_id = $1"}, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
 		SELECT id, type, status, title, message,
-			data, user_id, created_at, updated_at
-		FROM notifications
-		WHERE user_id = $1
-		ORDER BY created_at DESC`
+			data, user_id, created_at, updated_at` + q.cursorColumns() + `
+		FROM notifications` + q.clauses()
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("通知一覧取得エラー: %v", err)
+		return nil, nil, fmt.Errorf("通知一覧取得エラー: %v", err)
 	}
 	defer rows.Close()
 
@@ -141,7 +158,7 @@ func (r *SQLNotificationRepository) ListNotifications(ctx context.Context, userI
 	for rows.Next() {
 		notification := &models.Notification{}
 		var jsonData []byte
-		err := rows.Scan(
+		err := q.scanner(rows).Scan(
 			&notification.ID,
 			&notification.Type,
 			&notification.Status,
@@ -153,13 +170,13 @@ func (r *SQLNotificationRepository) ListNotifications(ctx context.Context, userI
 			&notification.UpdatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("通知データ読み取りエラー: %v", err)
+			return nil, nil, fmt.Errorf("通知データ読み取りエラー: %v", err)
 		}
 
 		// JSONデータをmapに変換
 		if len(jsonData) > 0 {
 			if err := json.Unmarshal(jsonData, &notification.Data); err != nil {
-				return nil, fmt.Errorf("データのJSON変換エラー: %v", err)
+				return nil, nil, fmt.Errorf("データのJSON変換エラー: %v", err)
 			}
 		} else {
 			notification.Data = make(map[string]interface{})
@@ -169,10 +186,15 @@ func (r *SQLNotificationRepository) ListNotifications(ctx context.Context, userI
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("通知一覧読み取りエラー: %v", err)
+		return nil, nil, fmt.Errorf("通知一覧読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(notifications))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return notifications, nil
+	return notifications[:n], page, nil
 }
 
 // UpdateNotificationStatus 通知ステータスを更新する
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"tea-logistics/pkg/models"
+
+	"github.com/lib/pq"
 )
 
 /*
@@ -16,27 +18,74 @@ import (
 
 // SQLProductRepository SQL商品リポジトリ
 type SQLProductRepository struct {
-	db *sql.DB
+	db DB
 }
 
 // NewProductRepository 商品リポジトリを作成する
 func NewProductRepository(db *sql.DB) ProductRepository {
+	return &SQLProductRepository{db: NewSQLDatabase(db)}
+}
+
+// newTxProductRepository トランザクション用の商品リポジトリを作成する
+func newTxProductRepository(db DB) ProductRepository {
 	return &SQLProductRepository{db: db}
 }
 
+const productColumns = `id, name, description, sku, COALESCE(category, ''), price, status,
+			created_at, updated_at, version`
+
+// productQuery 商品一覧の絞り込み・並べ替えに使用できる項目
+var productQuery = &queryTable{
+	name: "products",
+	fields: map[string]queryField{
+		"id":         {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"name":       {column: "name", typ: fieldString, filterable: true, sortable: true},
+		"sku":        {column: "sku", typ: fieldString, filterable: true, sortable: true},
+		"category":   {column: "COALESCE(category, '')", typ: fieldString, filterable: true, sortable: true},
+		"status":     {column: "status", typ: fieldString, filterable: true, sortable: true},
+		"price":      {column: "price", typ: fieldFloat, filterable: true, sortable: true},
+		"created_at": {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+		"updated_at": {column: "updated_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "id"}},
+}
+
+// scanProduct 商品行を読み取る
+func scanProduct(scanner rowScanner) (*models.Product, error) {
+	product := &models.Product{}
+	err := scanner.Scan(
+		&product.ID,
+		&product.Name,
+		&product.Description,
+		&product.SKU,
+		&product.Category,
+		&product.Price,
+		&product.Status,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+		&product.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
 // CreateProduct 商品を作成する
 func (r *SQLProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
 	query := `
 		INSERT INTO products (
-			name, description, price, status,
+			name, description, sku, category, price, status,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $5)
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $7)
 		RETURNING id`
 
 	now := time.Now()
 	err := r.db.QueryRowContext(ctx, query,
 		product.Name,
 		product.Description,
+		product.SKU,
+		product.Category,
 		product.Price,
 		product.Status,
 		now,
@@ -46,6 +95,7 @@ func (r *SQLProductRepository) CreateProduct(ctx context.Context, product *model
 		return fmt.Errorf("商品作成エラー: %v", err)
 	}
 
+	product.Version = 1
 	product.CreatedAt = now
 	product.UpdatedAt = now
 	return nil
@@ -53,22 +103,12 @@ func (r *SQLProductRepository) CreateProduct(ctx context.Context, product *model
 
 // GetProduct 商品を取得する
 func (r *SQLProductRepository) GetProduct(ctx context.Context, id int64) (*models.Product, error) {
-	product := &models.Product{}
 	query := `
-		SELECT id, name, description, price, status,
-			created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Price,
-		&product.Status,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("商品が見つかりません")
@@ -81,80 +121,98 @@ func (r *SQLProductRepository) GetProduct(ctx context.Context, id int64) (*model
 }
 
 // ListProducts 商品一覧を取得する
-func (r *SQLProductRepository) ListProducts(ctx context.Context) ([]*models.Product, error) {
+// specがnilの場合は全件をID順に取得する
+func (r *SQLProductRepository) ListProducts(ctx context.Context, spec *QuerySpec) ([]*models.Product, *PageInfo, error) {
+	q, err := productQuery.build(spec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
-		SELECT id, name, description, price, status,
-			created_at, updated_at
-		FROM products
-		ORDER BY id`
+		SELECT ` + productColumns + q.cursorColumns() + `
+		FROM products` + q.clauses()
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("商品一覧取得エラー: %v", err)
+		return nil, nil, fmt.Errorf("商品一覧取得エラー: %v", err)
 	}
 	defer rows.Close()
 
 	var products []*models.Product
 	for rows.Next() {
-		product := &models.Product{}
-		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&product.Description,
-			&product.Price,
-			&product.Status,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+		product, err := scanProduct(q.scanner(rows))
 		if err != nil {
-			return nil, fmt.Errorf("商品データ読み取りエラー: %v", err)
+			return nil, nil, fmt.Errorf("商品データ読み取りエラー: %v", err)
 		}
 		products = append(products, product)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("商品一覧読み取りエラー: %v", err)
+		return nil, nil, fmt.Errorf("商品一覧読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(products))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return products, nil
+	return products[:n], page, nil
 }
 
 // UpdateProduct 商品を更新する
+// 読み取り時のバージョン（product.Version）と一致する場合のみ更新し、バージョンを1つ進める
 func (r *SQLProductRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
 	query := `
-		UPDATE products
-		SET name = $1, description = $2, price = $3,
-			status = $4, updated_at = $5
-		WHERE id = $6`
+		WITH previous AS (
+			SELECT id, version FROM products WHERE id = $8 FOR UPDATE
+		), updated AS (
+			UPDATE products pr
+			SET name = $1, description = $2, sku = $3, category = NULLIF($4, ''),
+				price = $5, status = $6, updated_at = $7, version = pr.version + 1
+			FROM previous p
+			WHERE pr.id = p.id AND p.version = $9
+			RETURNING pr.id, pr.version
+		)
+		SELECT p.version, u.version FROM previous p LEFT JOIN updated u ON u.id = p.id`
 
-	result, err := r.db.ExecContext(ctx, query,
+	now := time.Now()
+	row := r.db.QueryRowContext(ctx, query,
 		product.Name,
 		product.Description,
+		product.SKU,
+		product.Category,
 		product.Price,
 		product.Status,
-		time.Now(),
+		now,
 		product.ID,
+		product.Version,
 	)
-	if err != nil {
-		return fmt.Errorf("商品更新エラー: %v", err)
-	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("結果取得エラー: %v", err)
-	}
-	if rows == 0 {
+	version, err := scanVersionedUpdate(row, "商品", product.ID, product.Version)
+	if err == ErrNotFound {
 		return fmt.Errorf("商品が見つかりません")
 	}
+	if _, ok := err.(*models.VersionConflictError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("商品更新エラー: %v", err)
+	}
 
+	product.Version = version
+	product.UpdatedAt = now
 	return nil
 }
 
 // DeleteProduct 商品を削除する
+// 他のテーブルから参照されている場合は*models.ProductInUseErrorを返す
 func (r *SQLProductRepository) DeleteProduct(ctx context.Context, id int64) error {
 	query := `DELETE FROM products WHERE id = $1`
 
 	result, err := r.db.ExecContext(ctx, query, id)
+	if isForeignKeyViolation(err) {
+		return &models.ProductInUseError{ProductID: id}
+	}
 	if err != nil {
 		return fmt.Errorf("商品削除エラー: %v", err)
 	}
@@ -169,3 +227,9 @@ func (r *SQLProductRepository) DeleteProduct(ctx context.Context, id int64) erro
 
 	return nil
 }
+
+// isForeignKeyViolation 外部キー制約違反のエラーかどうかを判定する
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
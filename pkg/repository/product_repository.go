@@ -15,7 +15,8 @@ import (
 type ProductRepository interface {
 	CreateProduct(ctx context.Context, product *models.Product) error
 	GetProduct(ctx context.Context, id int64) (*models.Product, error)
-	ListProducts(ctx context.Context) ([]*models.Product, error)
+	ListProducts(ctx context.Context, spec *QuerySpec) ([]*models.Product, *PageInfo, error)
+	SearchProducts(ctx context.Context, terms []string, spec *QuerySpec) (*models.ProductSearchResult, *PageInfo, error)
 	UpdateProduct(ctx context.Context, product *models.Product) error
 	DeleteProduct(ctx context.Context, id int64) error
 }
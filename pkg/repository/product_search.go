@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 商品検索リポジトリ
+ * pg_trgmのトライグラムインデックスを使った商品の部分一致検索と順位付けを実装する
+ * 形態素解析を行わず部分一致で検索するため、漢字・かなの語も分かち書きなしで検索できる
+ * 項目と検索語はどちらもNFKC正規化・小文字化した文字列（product_search_text）で比較する
+ * 漢字・かなのトライグラムはデータベースのLC_CTYPEがUTF-8（ja_JP.UTF-8・C.UTF-8など）の場合だけ作成される
+ */
+
+// 商品検索の項目と順位付けの重み（一致した項目の重みと類似度の合計で順位を付ける）
+// 項目はトライグラム式インデックスと同じproduct_search_textの式で比較する
+var productSearchFields = []struct {
+	column string
+	weight int
+}{
+	{"product_search_text(name)", 4},
+	{"product_search_text(sku)", 3},
+	{"product_search_text(category)", 2},
+	{"product_search_text(description)", 1},
+}
+
+// searchScanner 商品行の末尾の検索スコアを合わせて読み取る
+type searchScanner struct {
+	rowScanner
+	score *float64
+}
+
+func (s searchScanner) Scan(dest ...interface{}) error {
+	return s.rowScanner.Scan(append(dest, s.score)...)
+}
+
+// SearchProducts 正規化済みの検索語のすべてを名前・SKU・カテゴリ・説明のいずれかに含む商品を関連度の高い順に取得する
+// specでは絞り込み（カテゴリ・ステータスなど）とオフセット方式のページングを指定でき、並び順・カーソルは指定できない
+func (r *SQLProductRepository) SearchProducts(ctx context.Context, terms []string, spec *QuerySpec) (*models.ProductSearchResult, *PageInfo, error) {
+	if len(terms) == 0 {
+		return nil, nil, &InvalidQueryError{Field: "q", Message: "検索語を入力してください"}
+	}
+	if spec != nil && (spec.Cursor != "" || len(spec.Sort) > 0) {
+		return nil, nil, &InvalidQueryError{Message: "検索結果は関連度順のため、sortとcursorは指定できません"}
+	}
+
+	// 検索語ごとにいずれかの項目に含むことを条件とし、一致した項目の重みと類似度をスコアに加える
+	var conditions, scores []string
+	var args []interface{}
+	for _, term := range terms {
+		args = append(args, escapeLike(strings.ToLower(term)))
+		param := fmt.Sprintf("$%d", len(args))
+		pattern := "'%' || " + param + " || '%'"
+
+		var matches []string
+		for _, field := range productSearchFields {
+			matches = append(matches, fmt.Sprintf("%s LIKE %s", field.column, pattern))
+			scores = append(scores, fmt.Sprintf("CASE WHEN %s LIKE %s THEN %d ELSE 0 END", field.column, pattern, field.weight))
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		scores = append(scores,
+			fmt.Sprintf("4 * similarity(product_search_text(name), %s)", param),
+			fmt.Sprintf("3 * similarity(product_search_text(sku), %s)", param),
+		)
+	}
+
+	q, err := productQuery.build(spec, conditions, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT ` + productColumns + `,
+			(` + strings.Join(scores, "\n\t\t\t\t+ ") + `)::float8 AS score
+		FROM products` + q.where + `
+		ORDER BY score DESC, id ASC` + q.limit
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("商品検索エラー: %v", err)
+	}
+	defer rows.Close()
+
+	result := &models.ProductSearchResult{Terms: terms}
+	for rows.Next() {
+		hit := &models.ProductSearchHit{}
+		hit.Product, err = scanProduct(searchScanner{rowScanner: rows, score: &hit.Score})
+		if err != nil {
+			return nil, nil, fmt.Errorf("商品データ読み取りエラー: %v", err)
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("商品検索結果読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(result.Hits))
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Hits = result.Hits[:n]
+
+	// カテゴリ別・ステータス別の件数は検索語の条件だけで集計する
+	where := whereClause(conditions)
+	if result.Facets.Categories, err = r.searchFacets(ctx, "COALESCE(category, '')", where, args); err != nil {
+		return nil, nil, err
+	}
+	if result.Facets.Statuses, err = r.searchFacets(ctx, "status", where, args); err != nil {
+		return nil, nil, err
+	}
+
+	return result, page, nil
+}
+
+// searchFacets 検索条件に一致する商品の項目の値ごとの件数を件数の多い順に集計する
+func (r *SQLProductRepository) searchFacets(ctx context.Context, column, where string, args []interface{}) ([]models.SearchFacet, error) {
+	query := `
+		SELECT ` + column + `, COUNT(*)
+		FROM products` + where + `
+		GROUP BY 1
+		ORDER BY 2 DESC, 1`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("検索結果の件数集計エラー: %v", err)
+	}
+	defer rows.Close()
+
+	facets := []models.SearchFacet{}
+	for rows.Next() {
+		var facet models.SearchFacet
+		if err := rows.Scan(&facet.Value, &facet.Count); err != nil {
+			return nil, fmt.Errorf("検索結果の件数読み取りエラー: %v", err)
+		}
+		facets = append(facets, facet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("検索結果の件数読み取りエラー: %v", err)
+	}
+
+	return facets, nil
+}
+
+// escapeLike LIKEの特殊文字（%・_・\）をエスケープする
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 商品検索のSQLモックテスト
+ * 検索語ごとの部分一致条件・関連度順の並び・カテゴリ別/ステータス別の件数を検証する
+ */
+
+func TestSQLProductRepository_SearchProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewProductRepository(db)
+	now := time.Now()
+	match := func(param string) string {
+		pattern := "'%' || " + param + " || '%'"
+		return "(product_search_text(name) LIKE " + pattern + " OR product_search_text(sku) LIKE " + pattern +
+			" OR product_search_text(category) LIKE " + pattern + " OR product_search_text(description) LIKE " + pattern + ")"
+	}
+
+	// 検索語はすべて含むこと（AND）、カテゴリの絞り込みは検索結果にだけ適用する
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM products
+		WHERE `+match("$1")+`
+			AND `+match("$2")+`
+			AND COALESCE(category, '') = $3
+		ORDER BY score DESC, id ASC
+		LIMIT $4`)).
+		WithArgs("抹茶", `100\%`, "抹茶", 21).
+		WillReturnRows(sqlmock.NewRows(append(productListColumns, "score")).
+			AddRow(2, "抹茶100%", "", "MAT-100", "抹茶", 2000.0, "active", now, now, 1, 14.5))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(category, ''), COUNT(*)
+		FROM products
+		WHERE `+match("$1")+`
+			AND `+match("$2")+`
+		GROUP BY 1`)).
+		WithArgs("抹茶", `100\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"category", "count"}).AddRow("抹茶", 1).AddRow("菓子", 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT status, COUNT(*)`)).
+		WithArgs("抹茶", `100\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow("active", 2))
+
+	result, page, err := repo.SearchProducts(context.Background(), []string{"抹茶", "100%"}, &QuerySpec{
+		Filters: []Filter{{Field: "category", Operator: FilterEqual, Value: "抹茶"}},
+		Limit:   20,
+	})
+
+	require.NoError(t, err)
+	if assert.Len(t, result.Hits, 1) {
+		assert.Equal(t, int64(2), result.Hits[0].Product.ID)
+		assert.Equal(t, 14.5, result.Hits[0].Score)
+	}
+	assert.Equal(t, []models.SearchFacet{{Value: "抹茶", Count: 1}, {Value: "菓子", Count: 1}}, result.Facets.Categories)
+	assert.Equal(t, []models.SearchFacet{{Value: "active", Count: 2}}, result.Facets.Statuses)
+	assert.Equal(t, int64(1), *page.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProductRepository_SearchProducts_RejectsSortAndCursor(t *testing.T) {
+	repo := &SQLProductRepository{}
+
+	_, _, err := repo.SearchProducts(context.Background(), []string{"煎茶"}, &QuerySpec{Sort: []SortKey{{Field: "name"}}})
+
+	var invalid *InvalidQueryError
+	assert.ErrorAs(t, err, &invalid)
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 荷姿リポジトリ
+ * データベースとの商品の荷姿（包装単位）関連の操作を管理する
+ */
+
+// ProductVariantRepository 荷姿リポジトリインターフェース
+type ProductVariantRepository interface {
+	CreateVariant(ctx context.Context, variant *models.ProductVariant) error
+	GetVariant(ctx context.Context, id int64) (*models.ProductVariant, error)
+	GetVariantByUnit(ctx context.Context, productID int64, unit string) (*models.ProductVariant, error)
+	ListVariants(ctx context.Context, productID int64) ([]*models.ProductVariant, error)
+	UpdateVariant(ctx context.Context, variant *models.ProductVariant) error
+	DeleteVariant(ctx context.Context, id int64) error
+}
+
+// SQLProductVariantRepository SQL荷姿リポジトリ
+type SQLProductVariantRepository struct {
+	db DB
+}
+
+// NewSQLProductVariantRepository SQL荷姿リポジトリを作成する
+func NewSQLProductVariantRepository(db DB) ProductVariantRepository {
+	return &SQLProductVariantRepository{db: db}
+}
+
+const variantColumns = `id, product_id, unit, name, sku, conversion_factor,
+			price, weight_g, volume_ml, created_at, updated_at`
+
+// scanVariant 荷姿行を読み取る
+func scanVariant(scanner rowScanner) (*models.ProductVariant, error) {
+	variant := &models.ProductVariant{}
+	err := scanner.Scan(
+		&variant.ID,
+		&variant.ProductID,
+		&variant.Unit,
+		&variant.Name,
+		&variant.SKU,
+		&variant.ConversionFactor,
+		&variant.Price,
+		&variant.WeightGrams,
+		&variant.VolumeML,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// CreateVariant 荷姿を作成する
+func (r *SQLProductVariantRepository) CreateVariant(ctx context.Context, variant *models.ProductVariant) error {
+	query := `
+		INSERT INTO product_variants (
+			product_id, unit, name, sku, conversion_factor,
+			price, weight_g, volume_ml, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		variant.ProductID,
+		variant.Unit,
+		variant.Name,
+		variant.SKU,
+		variant.ConversionFactor,
+		variant.Price,
+		variant.WeightGrams,
+		variant.VolumeML,
+		now,
+	).Scan(&variant.ID)
+	if err != nil {
+		return fmt.Errorf("荷姿作成エラー: %v", err)
+	}
+
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	return nil
+}
+
+// GetVariant 荷姿を取得する
+func (r *SQLProductVariantRepository) GetVariant(ctx context.Context, id int64) (*models.ProductVariant, error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants
+		WHERE id = $1`
+
+	variant, err := scanVariant(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("荷姿取得エラー: %v", err)
+	}
+
+	return variant, nil
+}
+
+// GetVariantByUnit 商品と荷姿コードから荷姿を取得する
+func (r *SQLProductVariantRepository) GetVariantByUnit(ctx context.Context, productID int64, unit string) (*models.ProductVariant, error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants
+		WHERE product_id = $1 AND unit = $2`
+
+	variant, err := scanVariant(r.db.QueryRowContext(ctx, query, productID, unit))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("荷姿取得エラー: %v", err)
+	}
+
+	return variant, nil
+}
+
+// ListVariants 商品の荷姿一覧を換算係数の小さい順に取得する
+func (r *SQLProductVariantRepository) ListVariants(ctx context.Context, productID int64) ([]*models.ProductVariant, error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants
+		WHERE product_id = $1
+		ORDER BY conversion_factor, id`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("荷姿一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var variants []*models.ProductVariant
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("荷姿データ読み取りエラー: %v", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("荷姿一覧読み取りエラー: %v", err)
+	}
+
+	return variants, nil
+}
+
+// UpdateVariant 荷姿を更新する
+func (r *SQLProductVariantRepository) UpdateVariant(ctx context.Context, variant *models.ProductVariant) error {
+	query := `
+		UPDATE product_variants
+		SET unit = $1, name = $2, sku = $3, conversion_factor = $4,
+			price = $5, weight_g = $6, volume_ml = $7, updated_at = $8
+		WHERE id = $9`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		variant.Unit,
+		variant.Name,
+		variant.SKU,
+		variant.ConversionFactor,
+		variant.Price,
+		variant.WeightGrams,
+		variant.VolumeML,
+		now,
+		variant.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("荷姿更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	variant.UpdatedAt = now
+	return nil
+}
+
+// DeleteVariant 荷姿を削除する
+func (r *SQLProductVariantRepository) DeleteVariant(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM product_variants WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("荷姿削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,487 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 発注リポジトリ
+ * データベースとの仕入先・発注書・入荷実績関連の操作を管理する
+ */
+
+// PurchaseOrderRepository 発注リポジトリインターフェース
+type PurchaseOrderRepository interface {
+	// 仕入先
+	CreateSupplier(ctx context.Context, supplier *models.Supplier) error
+	GetSupplier(ctx context.Context, id int64) (*models.Supplier, error)
+	ListSuppliers(ctx context.Context) ([]*models.Supplier, error)
+	UpdateSupplier(ctx context.Context, supplier *models.Supplier) error
+
+	// 発注書
+	// CreatePurchaseOrder 発注書を作成する（PONumberが空の場合は採番する）
+	CreatePurchaseOrder(ctx context.Context, order *models.PurchaseOrder) error
+	GetPurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error)
+	// ListPurchaseOrders 発注書一覧を取得する（statusが空・supplierIDが0の場合は絞り込まない）
+	ListPurchaseOrders(ctx context.Context, status models.PurchaseOrderStatus, supplierID int64) ([]*models.PurchaseOrder, error)
+	UpdatePurchaseOrderStatus(ctx context.Context, order *models.PurchaseOrder) error
+
+	// 発注明細
+	CreatePurchaseOrderLine(ctx context.Context, line *models.PurchaseOrderLine) error
+	ListPurchaseOrderLines(ctx context.Context, orderID int64) ([]*models.PurchaseOrderLine, error)
+	UpdateReceivedQuantity(ctx context.Context, line *models.PurchaseOrderLine) error
+
+	// 入荷実績
+	CreateReceipt(ctx context.Context, receipt *models.PurchaseOrderReceiptLine) error
+	ListReceipts(ctx context.Context, orderID int64) ([]*models.PurchaseOrderReceiptLine, error)
+}
+
+// SQLPurchaseOrderRepository SQL発注リポジトリ
+type SQLPurchaseOrderRepository struct {
+	db DB
+}
+
+// NewSQLPurchaseOrderRepository SQL発注リポジトリを作成する
+func NewSQLPurchaseOrderRepository(db DB) PurchaseOrderRepository {
+	return &SQLPurchaseOrderRepository{db: db}
+}
+
+const supplierColumns = `id, code, name, contact_name, email, phone, address, created_at, updated_at`
+
+const purchaseOrderColumns = `id, po_number, supplier_id, location, status, expected_arrival,
+			notes, COALESCE(created_by, 0), closed_at, created_at, updated_at`
+
+const receiptColumns = `id, purchase_order_id, line_id, product_id, lot_id,
+			expected_quantity, received_quantity, COALESCE(received_by, 0), received_at`
+
+// scanSupplier 仕入先の行を読み取る
+func scanSupplier(scanner rowScanner) (*models.Supplier, error) {
+	supplier := &models.Supplier{}
+	err := scanner.Scan(
+		&supplier.ID,
+		&supplier.Code,
+		&supplier.Name,
+		&supplier.ContactName,
+		&supplier.Email,
+		&supplier.Phone,
+		&supplier.Address,
+		&supplier.CreatedAt,
+		&supplier.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+// scanPurchaseOrder 発注書の行を読み取る
+func scanPurchaseOrder(scanner rowScanner) (*models.PurchaseOrder, error) {
+	order := &models.PurchaseOrder{}
+	var closedAt sql.NullTime
+	err := scanner.Scan(
+		&order.ID,
+		&order.PONumber,
+		&order.SupplierID,
+		&order.Location,
+		&order.Status,
+		&order.ExpectedArrival,
+		&order.Notes,
+		&order.CreatedBy,
+		&closedAt,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		t := closedAt.Time
+		order.ClosedAt = &t
+	}
+	return order, nil
+}
+
+// scanReceipt 入荷実績の行を読み取る
+func scanReceipt(scanner rowScanner) (*models.PurchaseOrderReceiptLine, error) {
+	receipt := &models.PurchaseOrderReceiptLine{}
+	var lotID sql.NullInt64
+	err := scanner.Scan(
+		&receipt.ID,
+		&receipt.PurchaseOrderID,
+		&receipt.LineID,
+		&receipt.ProductID,
+		&lotID,
+		&receipt.ExpectedQuantity,
+		&receipt.ReceivedQuantity,
+		&receipt.ReceivedBy,
+		&receipt.ReceivedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lotID.Valid {
+		id := lotID.Int64
+		receipt.LotID = &id
+	}
+	receipt.Variance = receipt.ReceivedQuantity - receipt.ExpectedQuantity
+	return receipt, nil
+}
+
+// CreateSupplier 仕入先を作成する
+func (r *SQLPurchaseOrderRepository) CreateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	query := `
+		INSERT INTO suppliers (
+			code, name, contact_name, email, phone, address,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		supplier.Code,
+		supplier.Name,
+		supplier.ContactName,
+		supplier.Email,
+		supplier.Phone,
+		supplier.Address,
+		now,
+	).Scan(&supplier.ID)
+	if err != nil {
+		return fmt.Errorf("仕入先作成エラー: %v", err)
+	}
+
+	supplier.CreatedAt = now
+	supplier.UpdatedAt = now
+	return nil
+}
+
+// GetSupplier 仕入先を取得する
+func (r *SQLPurchaseOrderRepository) GetSupplier(ctx context.Context, id int64) (*models.Supplier, error) {
+	query := `
+		SELECT ` + supplierColumns + `
+		FROM suppliers
+		WHERE id = $1`
+
+	supplier, err := scanSupplier(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("仕入先取得エラー: %v", err)
+	}
+
+	return supplier, nil
+}
+
+// ListSuppliers 仕入先一覧を取得する
+func (r *SQLPurchaseOrderRepository) ListSuppliers(ctx context.Context) ([]*models.Supplier, error) {
+	query := `
+		SELECT ` + supplierColumns + `
+		FROM suppliers
+		ORDER BY code`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("仕入先一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var suppliers []*models.Supplier
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("仕入先データ読み取りエラー: %v", err)
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("仕入先一覧読み取りエラー: %v", err)
+	}
+
+	return suppliers, nil
+}
+
+// UpdateSupplier 仕入先を更新する
+func (r *SQLPurchaseOrderRepository) UpdateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	query := `
+		UPDATE suppliers
+		SET name = $1, contact_name = $2, email = $3, phone = $4, address = $5,
+			updated_at = $6
+		WHERE id = $7`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		supplier.Name,
+		supplier.ContactName,
+		supplier.Email,
+		supplier.Phone,
+		supplier.Address,
+		now,
+		supplier.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("仕入先更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	supplier.UpdatedAt = now
+	return nil
+}
+
+// CreatePurchaseOrder 発注書を作成する
+// 発注番号が空の場合はシーケンスから「PO-000001」形式で採番する
+func (r *SQLPurchaseOrderRepository) CreatePurchaseOrder(ctx context.Context, order *models.PurchaseOrder) error {
+	query := `
+		INSERT INTO purchase_orders (
+			po_number, supplier_id, location, status, expected_arrival,
+			notes, created_by, created_at, updated_at
+		) VALUES (
+			COALESCE(NULLIF($1, ''), 'PO-' || lpad(nextval('purchase_order_number_seq')::text, 6, '0')),
+			$2, $3, $4, $5, $6, NULLIF($7, 0), $8, $8
+		)
+		RETURNING id, po_number`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		order.PONumber,
+		order.SupplierID,
+		order.Location,
+		order.Status,
+		order.ExpectedArrival,
+		order.Notes,
+		order.CreatedBy,
+		now,
+	).Scan(&order.ID, &order.PONumber)
+	if err != nil {
+		return fmt.Errorf("発注書作成エラー: %v", err)
+	}
+
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	return nil
+}
+
+// GetPurchaseOrder 発注書を取得する
+func (r *SQLPurchaseOrderRepository) GetPurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders
+		WHERE id = $1`
+
+	order, err := scanPurchaseOrder(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("発注書取得エラー: %v", err)
+	}
+
+	return order, nil
+}
+
+// ListPurchaseOrders 発注書一覧を入荷予定日の近い順に取得する
+func (r *SQLPurchaseOrderRepository) ListPurchaseOrders(ctx context.Context, status models.PurchaseOrderStatus, supplierID int64) ([]*models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders
+		WHERE ($1 = '' OR status = $1)
+			AND ($2 = 0 OR supplier_id = $2)
+		ORDER BY expected_arrival, id`
+
+	rows, err := r.db.QueryContext(ctx, query, status, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("発注書一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.PurchaseOrder
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("発注書データ読み取りエラー: %v", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("発注書一覧読み取りエラー: %v", err)
+	}
+
+	return orders, nil
+}
+
+// UpdatePurchaseOrderStatus 発注書のステータスと完了日時を更新する
+func (r *SQLPurchaseOrderRepository) UpdatePurchaseOrderStatus(ctx context.Context, order *models.PurchaseOrder) error {
+	query := `
+		UPDATE purchase_orders
+		SET status = $1, closed_at = $2, updated_at = $3
+		WHERE id = $4`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, order.Status, order.ClosedAt, now, order.ID)
+	if err != nil {
+		return fmt.Errorf("発注書更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	order.UpdatedAt = now
+	return nil
+}
+
+// CreatePurchaseOrderLine 発注明細を作成する
+func (r *SQLPurchaseOrderRepository) CreatePurchaseOrderLine(ctx context.Context, line *models.PurchaseOrderLine) error {
+	query := `
+		INSERT INTO purchase_order_lines (
+			purchase_order_id, product_id, ordered_quantity, received_quantity
+		) VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.PurchaseOrderID,
+		line.ProductID,
+		line.OrderedQuantity,
+		line.ReceivedQuantity,
+	).Scan(&line.ID)
+	if err != nil {
+		return fmt.Errorf("発注明細作成エラー: %v", err)
+	}
+
+	return nil
+}
+
+// ListPurchaseOrderLines 発注明細一覧を取得する
+func (r *SQLPurchaseOrderRepository) ListPurchaseOrderLines(ctx context.Context, orderID int64) ([]*models.PurchaseOrderLine, error) {
+	query := `
+		SELECT id, purchase_order_id, product_id, ordered_quantity, received_quantity
+		FROM purchase_order_lines
+		WHERE purchase_order_id = $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("発注明細一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var lines []*models.PurchaseOrderLine
+	for rows.Next() {
+		line := &models.PurchaseOrderLine{}
+		err := rows.Scan(
+			&line.ID,
+			&line.PurchaseOrderID,
+			&line.ProductID,
+			&line.OrderedQuantity,
+			&line.ReceivedQuantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("発注明細データ読み取りエラー: %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("発注明細一覧読み取りエラー: %v", err)
+	}
+
+	return lines, nil
+}
+
+// UpdateReceivedQuantity 発注明細の入荷済み数量を更新する
+func (r *SQLPurchaseOrderRepository) UpdateReceivedQuantity(ctx context.Context, line *models.PurchaseOrderLine) error {
+	query := `
+		UPDATE purchase_order_lines
+		SET received_quantity = $1
+		WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, line.ReceivedQuantity, line.ID)
+	if err != nil {
+		return fmt.Errorf("発注明細更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateReceipt 入荷実績を記録する
+func (r *SQLPurchaseOrderRepository) CreateReceipt(ctx context.Context, receipt *models.PurchaseOrderReceiptLine) error {
+	query := `
+		INSERT INTO purchase_order_receipts (
+			purchase_order_id, line_id, product_id, lot_id,
+			expected_quantity, received_quantity, received_by, received_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		receipt.PurchaseOrderID,
+		receipt.LineID,
+		receipt.ProductID,
+		receipt.LotID,
+		receipt.ExpectedQuantity,
+		receipt.ReceivedQuantity,
+		receipt.ReceivedBy,
+		now,
+	).Scan(&receipt.ID)
+	if err != nil {
+		return fmt.Errorf("入荷実績作成エラー: %v", err)
+	}
+
+	receipt.ReceivedAt = now
+	return nil
+}
+
+// ListReceipts 発注書の入荷実績を入荷順に取得する
+func (r *SQLPurchaseOrderRepository) ListReceipts(ctx context.Context, orderID int64) ([]*models.PurchaseOrderReceiptLine, error) {
+	query := `
+		SELECT ` + receiptColumns + `
+		FROM purchase_order_receipts
+		WHERE purchase_order_id = $1
+		ORDER BY received_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("入荷実績取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var receipts []*models.PurchaseOrderReceiptLine
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("入荷実績データ読み取りエラー: %v", err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("入荷実績読み取りエラー: %v", err)
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,455 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * 一覧取得条件
+ * 一覧取得の絞り込み・並び順・ページング（オフセット方式・カーソル方式）の共通の指定と、
+ * 許可した項目だけをSQLの条件・並び順に変換する処理を提供する
+ */
+
+// FilterOperator 絞り込みの比較方法
+type FilterOperator string
+
+const (
+	// FilterEqual 値と一致する
+	FilterEqual FilterOperator = "eq"
+	// FilterFrom 値以上（日時の場合は指定日時以降）
+	FilterFrom FilterOperator = "from"
+	// FilterTo 値以下（日付のみ指定した場合はその日の終わりまで）
+	FilterTo FilterOperator = "to"
+)
+
+// Filter 項目の絞り込み条件
+// Valueは文字列で受け取り、項目の型に合わせて変換する
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Value    string
+}
+
+// SortKey 並び順の項目
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// QuerySpec 一覧取得条件
+// Limitが0の場合は件数を制限しない。CursorとOffsetは同時に指定できない
+type QuerySpec struct {
+	Filters []Filter
+	Sort    []SortKey
+	Limit   int
+	Offset  int
+	Cursor  string
+}
+
+// PageInfo 一覧取得結果のページ情報
+// Totalは絞り込み条件に一致する全件数で、カーソル方式の場合は返さない
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      *int64 `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// InvalidQueryError 一覧取得条件の誤り
+type InvalidQueryError struct {
+	Field   string
+	Message string
+}
+
+func (e *InvalidQueryError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// fieldType 項目の型
+type fieldType int
+
+const (
+	fieldString fieldType = iota
+	fieldInt
+	fieldFloat
+	fieldTime
+)
+
+// queryField 一覧取得条件に使用できる項目
+type queryField struct {
+	column     string
+	typ        fieldType
+	filterable bool
+	sortable   bool
+}
+
+// queryTable 一覧取得条件を変換する対象のテーブル
+// 絞り込み・並び順に使用できるのはfieldsに定義した項目だけで、defaultSortの後にidで並べる
+type queryTable struct {
+	name        string
+	fields      map[string]queryField
+	defaultSort []SortKey
+}
+
+// builtQuery 一覧取得条件を変換したSQLの断片
+type builtQuery struct {
+	table *queryTable
+	spec  QuerySpec
+	// 絞り込み条件（カーソル条件を含まない）とその引数（件数の集計に使用する）
+	filterWhere string
+	filterArgs  []interface{}
+	// カーソル条件を含む条件・並び順・件数制限とその引数
+	where   string
+	orderBy string
+	limit   string
+	args    []interface{}
+	// 並び順の項目（最後はid）と、cursorScannerで読み取った各行の項目の値（次のページのカーソルに使用する）
+	sortFields   []queryField
+	cursorValues [][]interface{}
+}
+
+// build 一覧取得条件をSQLの条件・並び順・件数制限に変換する
+// conditionsとargsは呼び出し元が固定で付ける条件（利用者IDなど）で、プレースホルダは$1から順に使用すること
+func (t *queryTable) build(spec *QuerySpec, conditions []string, args ...interface{}) (*builtQuery, error) {
+	q := &builtQuery{table: t}
+	if spec != nil {
+		q.spec = *spec
+	}
+	if q.spec.Limit < 0 || q.spec.Offset < 0 {
+		return nil, &InvalidQueryError{Message: "limitとoffsetに負の値は指定できません"}
+	}
+	if q.spec.Cursor != "" && q.spec.Offset > 0 {
+		return nil, &InvalidQueryError{Message: "cursorとoffsetは同時に指定できません"}
+	}
+
+	placeholder := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, filter := range q.spec.Filters {
+		field, ok := t.fields[filter.Field]
+		if !ok || !field.filterable {
+			return nil, &InvalidQueryError{Field: filter.Field, Message: "この項目では絞り込みできません"}
+		}
+		value, err := field.parse(filter.Value)
+		if err != nil {
+			return nil, &InvalidQueryError{Field: filter.Field, Message: err.Error()}
+		}
+
+		switch filter.Operator {
+		case FilterEqual:
+			conditions = append(conditions, fmt.Sprintf("%s = %s", field.column, placeholder(value)))
+		case FilterFrom:
+			conditions = append(conditions, fmt.Sprintf("%s >= %s", field.column, placeholder(value)))
+		case FilterTo:
+			// 日付のみの指定はその日の終わりまでを含める
+			if field.typ == fieldTime && isDateOnly(filter.Value) {
+				end := value.(time.Time).AddDate(0, 0, 1)
+				conditions = append(conditions, fmt.Sprintf("%s < %s", field.column, placeholder(end)))
+			} else {
+				conditions = append(conditions, fmt.Sprintf("%s <= %s", field.column, placeholder(value)))
+			}
+		default:
+			return nil, &InvalidQueryError{Field: filter.Field, Message: "無効な比較方法です"}
+		}
+	}
+	q.filterWhere = whereClause(conditions)
+	q.filterArgs = append([]interface{}(nil), args...)
+
+	// 並び順: 指定した項目（省略時は既定の並び順）の後にidで並べ、同じ値の行の順序を一意にする
+	keys := q.spec.Sort
+	if len(keys) == 0 {
+		keys = t.defaultSort
+	}
+	var order []string
+	var sortFields []queryField
+	var descending []bool
+	hasID := false
+	for _, key := range keys {
+		field, ok := t.fields[key.Field]
+		if !ok || !field.sortable {
+			return nil, &InvalidQueryError{Field: key.Field, Message: "この項目では並べ替えできません"}
+		}
+		direction := "ASC"
+		if key.Descending {
+			direction = "DESC"
+		}
+		order = append(order, field.column+" "+direction)
+		sortFields = append(sortFields, field)
+		descending = append(descending, key.Descending)
+		if key.Field == "id" {
+			hasID = true
+			break
+		}
+	}
+	if !hasID {
+		order = append(order, "id ASC")
+		sortFields = append(sortFields, queryField{column: "id", typ: fieldInt})
+		descending = append(descending, false)
+	}
+	q.orderBy = "\n\t\tORDER BY " + strings.Join(order, ", ")
+
+	q.sortFields = sortFields
+
+	// カーソル方式: 前のページの最後の行の並び順の項目の値より後に並ぶ行を取得する
+	// 値はカーソルに含めるため、前のページの最後の行がその後に更新・削除されても続きから取得できる
+	// NULLは昇順では最後、降順では最初に並ぶ（PostgreSQLの既定）ものとして比較する
+	if q.spec.Cursor != "" {
+		values, err := decodeCursor(q.spec.Cursor, sortFields)
+		if err != nil {
+			return nil, err
+		}
+		params := make([]string, len(values))
+		for i, value := range values {
+			if value != nil {
+				params[i] = placeholder(value)
+			}
+		}
+		var alternatives []string
+		for i, field := range sortFields {
+			var terms []string
+			for j := 0; j < i; j++ {
+				if values[j] == nil {
+					terms = append(terms, sortFields[j].column+" IS NULL")
+				} else {
+					terms = append(terms, fmt.Sprintf("%s = %s", sortFields[j].column, params[j]))
+				}
+			}
+			switch {
+			case field.column == "id":
+				// idはNULLにならないため、そのまま比較する
+				operator := ">"
+				if descending[i] {
+					operator = "<"
+				}
+				terms = append(terms, fmt.Sprintf("id %s %s", operator, params[i]))
+			case !descending[i] && values[i] == nil:
+				// 昇順でNULLより後に並ぶ値はない
+				continue
+			case !descending[i]:
+				terms = append(terms, fmt.Sprintf("(%s > %s OR %s IS NULL)", field.column, params[i], field.column))
+			case values[i] == nil:
+				terms = append(terms, field.column+" IS NOT NULL")
+			default:
+				terms = append(terms, fmt.Sprintf("%s < %s", field.column, params[i]))
+			}
+			alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+	q.where = whereClause(conditions)
+
+	// 次のページの有無を判定するため、指定件数より1件多く取得する
+	if q.spec.Limit > 0 {
+		q.limit = fmt.Sprintf("\n\t\tLIMIT %s", placeholder(q.spec.Limit+1))
+	}
+	if q.spec.Offset > 0 {
+		q.limit += fmt.Sprintf(" OFFSET %s", placeholder(q.spec.Offset))
+	}
+	q.args = args
+
+	return q, nil
+}
+
+// clauses 条件・並び順・件数制限をSELECT文の末尾に付ける形で返す
+func (q *builtQuery) clauses() string {
+	return q.where + q.orderBy + q.limit
+}
+
+// cursorColumns 並び順の項目をSELECT句の列の末尾に付ける形で返す
+// 行はscannerで読み取り、次のページのカーソルを作成できるようにする
+func (q *builtQuery) cursorColumns() string {
+	columns := make([]string, len(q.sortFields))
+	for i, field := range q.sortFields {
+		columns[i] = field.column
+	}
+	return ",\n\t\t\t" + strings.Join(columns, ", ")
+}
+
+// scanner 行の列に続けてcursorColumnsの並び順の項目の値を読み取るscannerを返す
+func (q *builtQuery) scanner(rows rowScanner) rowScanner {
+	return cursorScanner{rowScanner: rows, q: q}
+}
+
+// cursorScanner 行を読み取り、並び順の項目の値を記録する
+type cursorScanner struct {
+	rowScanner
+	q *builtQuery
+}
+
+func (s cursorScanner) Scan(dest ...interface{}) error {
+	values := make([]interface{}, len(s.q.sortFields))
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	if err := s.rowScanner.Scan(dest...); err != nil {
+		return err
+	}
+	s.q.cursorValues = append(s.q.cursorValues, values)
+	return nil
+}
+
+// page 取得した行数からページ情報を作成し、返却する行数を返す
+// 次のページのカーソルは、scannerで読み取った返却する最後の行の並び順の項目の値から作成する
+func (q *builtQuery) page(ctx context.Context, db DB, fetched int) (*PageInfo, int, error) {
+	info := &PageInfo{Limit: q.spec.Limit, Offset: q.spec.Offset}
+	n := fetched
+	if q.spec.Limit > 0 && fetched > q.spec.Limit {
+		n = q.spec.Limit
+		info.HasMore = true
+		if n <= len(q.cursorValues) {
+			cursor, err := encodeCursor(q.cursorValues[n-1])
+			if err != nil {
+				return nil, 0, err
+			}
+			info.NextCursor = cursor
+		}
+	}
+
+	// オフセット方式の場合は全件数を返す（最後のページまで取得できた場合は集計せずに求める）
+	if q.spec.Cursor == "" {
+		total := int64(q.spec.Offset + n)
+		if info.HasMore || (q.spec.Offset > 0 && n == 0) {
+			query := "SELECT COUNT(*) FROM " + q.table.name + q.filterWhere
+			if err := db.QueryRowContext(ctx, query, q.filterArgs...).Scan(&total); err != nil {
+				return nil, 0, fmt.Errorf("件数集計エラー: %v", err)
+			}
+		}
+		info.Total = &total
+	}
+
+	return info, n, nil
+}
+
+// parse 文字列の値を項目の型に変換する
+func (f queryField) parse(value string) (interface{}, error) {
+	switch f.typ {
+	case fieldInt:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("整数で指定してください")
+		}
+		return v, nil
+	case fieldFloat:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("数値で指定してください")
+		}
+		return v, nil
+	case fieldTime:
+		if isDateOnly(value) {
+			v, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("日付はYYYY-MM-DD形式で指定してください")
+			}
+			return v, nil
+		}
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("日時はYYYY-MM-DDまたはRFC3339形式で指定してください")
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// isDateOnly 日付のみ（YYYY-MM-DD）の指定かどうかを判定する
+func isDateOnly(value string) bool {
+	return len(value) == len("2006-01-02")
+}
+
+// whereClause 条件をANDで結合したWHERE句を返す
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, "\n\t\t\tAND ")
+}
+
+// encodeCursor 行の並び順の項目の値からカーソルを作成する
+func encodeCursor(values []interface{}) (string, error) {
+	encoded := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case []byte:
+			// 文字列・NUMERICの値はドライバーからバイト列で返る場合がある
+			encoded[i] = string(v)
+		case time.Time:
+			encoded[i] = v.Format(time.RFC3339Nano)
+		default:
+			encoded[i] = v
+		}
+	}
+	raw, err := json.Marshal(encoded)
+	if err != nil {
+		return "", fmt.Errorf("カーソル作成エラー: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor カーソルから並び順の項目の値を取り出す
+// 並び順の項目と数・型が合わないカーソルは無効とする
+func decodeCursor(cursor string, fields []queryField) ([]interface{}, error) {
+	invalid := &InvalidQueryError{Field: "cursor", Message: "無効なカーソルです"}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var encoded []interface{}
+	if err := decoder.Decode(&encoded); err != nil || len(encoded) != len(fields) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		var text string
+		switch v := encoded[i].(type) {
+		case nil:
+			// idはNULLにならない
+			if field.column == "id" {
+				return nil, invalid
+			}
+			continue
+		case json.Number:
+			if field.typ != fieldInt && field.typ != fieldFloat {
+				return nil, invalid
+			}
+			text = v.String()
+		case string:
+			text = v
+		default:
+			return nil, invalid
+		}
+
+		switch field.typ {
+		case fieldInt:
+			values[i], err = strconv.ParseInt(text, 10, 64)
+		case fieldFloat:
+			values[i], err = strconv.ParseFloat(text, 64)
+		case fieldTime:
+			values[i], err = time.Parse(time.RFC3339Nano, text)
+		default:
+			values[i] = text
+		}
+		if err != nil {
+			return nil, invalid
+		}
+	}
+
+	return values, nil
+}
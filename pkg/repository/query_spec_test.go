@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 一覧取得条件のSQLモックテスト
+ * 絞り込み・並び順・ページングのSQLへの変換とページ情報を検証する
+ */
+
+var productListColumns = []string{"id", "name", "description", "sku", "category", "price", "status", "created_at", "updated_at", "version"}
+
+func TestSQLProductRepository_ListProducts_OffsetPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewProductRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM products
+		WHERE COALESCE(category, '') = $1
+			AND created_at >= $2
+		ORDER BY price DESC, id ASC
+		LIMIT $3 OFFSET $4`)).
+		WithArgs("煎茶", sqlmock.AnyArg(), 3, 2).
+		WillReturnRows(sqlmock.NewRows(append(productListColumns, "price", "id")).
+			AddRow(3, "煎茶A", "", "SEN-003", "煎茶", 1500.0, "active", now, now, 1, 1500.0, 3).
+			AddRow(4, "煎茶B", "", "SEN-004", "煎茶", 1200.0, "active", now, now, 1, 1200.0, 4).
+			AddRow(5, "煎茶C", "", "SEN-005", "煎茶", 1000.0, "active", now, now, 1, 1000.0, 5))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM products
+		WHERE COALESCE(category, '') = $1
+			AND created_at >= $2`)).
+		WithArgs("煎茶", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	products, page, err := repo.ListProducts(context.Background(), &QuerySpec{
+		Filters: []Filter{
+			{Field: "category", Operator: FilterEqual, Value: "煎茶"},
+			{Field: "created_at", Operator: FilterFrom, Value: "2026-01-01"},
+		},
+		Sort:   []SortKey{{Field: "price", Descending: true}},
+		Limit:  2,
+		Offset: 2,
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.True(t, page.HasMore)
+	// 次のページのカーソルは返却する最後の行の並び順の項目の値を含む
+	assert.Equal(t, mustEncodeCursor(t, 1200.0, int64(4)), page.NextCursor)
+	if assert.NotNil(t, page.Total) {
+		assert.Equal(t, int64(7), *page.Total)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLNotificationRepository_ListNotifications_Cursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSQLNotificationRepository(NewSQLDatabase(db))
+	cursorTime := time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC)
+
+	// 既定の並び順（新しい順）でカーソルの値より後に並ぶ行を取得し、全件数は集計しない
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM notifications
+		WHERE user_id = $1
+			AND status = $2
+			AND ((created_at < $3) OR (created_at = $3 AND id > $4))
+		ORDER BY created_at DESC, id ASC
+		LIMIT $5`)).
+		WithArgs(int64(1), "unread", cursorTime, int64(20), 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "status", "title", "message", "data", "user_id", "created_at", "updated_at", "created_at", "id"}).
+			AddRow(19, "delivery_status", "unread", "配送状況", "", nil, 1, cursorTime, cursorTime, cursorTime, 19))
+
+	notifications, page, err := repo.ListNotifications(context.Background(), 1, &QuerySpec{
+		Filters: []Filter{{Field: "status", Operator: FilterEqual, Value: "unread"}},
+		Limit:   10,
+		Cursor:  mustEncodeCursor(t, cursorTime, int64(20)),
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, notifications, 1)
+	assert.False(t, page.HasMore)
+	assert.Nil(t, page.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryTable_CursorComparesNullSortValues(t *testing.T) {
+	// 昇順（NULLは最後）でカーソルの行の値がNULLの場合は、NULLの行の中でidが後の行だけを取得する
+	q, err := driverQuery.build(&QuerySpec{
+		Sort:   []SortKey{{Field: "license_expiry"}},
+		Cursor: mustEncodeCursor(t, nil, int64(7)),
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, q.where, "((license_expiry IS NULL AND id > $1))")
+	assert.Equal(t, []interface{}{int64(7)}, q.args)
+
+	// 降順（NULLは最初）でカーソルの行の値がNULLの場合は、値のある行がすべて後に並ぶ
+	q, err = driverQuery.build(&QuerySpec{
+		Sort:   []SortKey{{Field: "license_expiry", Descending: true}},
+		Cursor: mustEncodeCursor(t, nil, int64(7)),
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, q.where, "((license_expiry IS NOT NULL) OR (license_expiry IS NULL AND id > $1))")
+
+	// 昇順でカーソルの行の値がNULLでない場合は、NULLの行も後に並ぶ
+	q, err = driverQuery.build(&QuerySpec{
+		Sort:   []SortKey{{Field: "license_expiry"}},
+		Cursor: mustEncodeCursor(t, "2026-03-01T09:30:00Z", int64(7)),
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, q.where, "(((license_expiry > $1 OR license_expiry IS NULL)) OR (license_expiry = $1 AND id > $2))")
+}
+
+func TestQueryTable_RejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *QuerySpec
+	}{
+		{"絞り込みできない項目", &QuerySpec{Filters: []Filter{{Field: "password", Operator: FilterEqual, Value: "x"}}}},
+		{"並べ替えできない項目", &QuerySpec{Sort: []SortKey{{Field: "name; DROP TABLE products"}}}},
+		{"型の合わない値", &QuerySpec{Filters: []Filter{{Field: "price", Operator: FilterFrom, Value: "高い"}}}},
+		{"無効なカーソル", &QuerySpec{Cursor: "!!"}},
+		{"並び順と合わないカーソル", &QuerySpec{Sort: []SortKey{{Field: "price"}}, Cursor: mustEncodeCursor(t, int64(1))}},
+		{"型の合わないカーソル", &QuerySpec{Sort: []SortKey{{Field: "created_at"}}, Cursor: mustEncodeCursor(t, "昨日", int64(1))}},
+		{"カーソルとオフセットの同時指定", &QuerySpec{Cursor: mustEncodeCursor(t, int64(1)), Offset: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := productQuery.build(tt.spec, nil)
+
+			var invalid *InvalidQueryError
+			assert.True(t, errors.As(err, &invalid), "got %v", err)
+		})
+	}
+}
+
+func TestQueryTable_DateOnlyUpperBoundIncludesWholeDay(t *testing.T) {
+	q, err := deliveryQuery.build(&QuerySpec{
+		Filters: []Filter{{Field: "estimated_time", Operator: FilterTo, Value: "2026-03-31"}},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, q.where, "estimated_time < $1")
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local), q.args[0])
+	assert.Empty(t, q.limit)
+}
+
+// mustEncodeCursor 並び順の項目の値からカーソルを作成する
+func mustEncodeCursor(t *testing.T, values ...interface{}) string {
+	cursor, err := encodeCursor(values)
+	require.NoError(t, err)
+	return cursor
+}
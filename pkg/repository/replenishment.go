@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 在庫補充リポジトリ
+ * データベースとの発注点設定・在庫補充提案関連の操作を管理する
+ */
+
+// ReplenishmentRepository 在庫補充リポジトリインターフェース
+type ReplenishmentRepository interface {
+	// 発注点設定
+	ListReorderSettings(ctx context.Context) ([]*models.ReorderSetting, error)
+	GetReorderSetting(ctx context.Context, productID int64, location string) (*models.ReorderSetting, error)
+	SetReorderSetting(ctx context.Context, setting *models.ReorderSetting) error
+	DeleteReorderSetting(ctx context.Context, id int64) error
+
+	// 在庫補充提案
+	CreateProposal(ctx context.Context, proposal *models.ReplenishmentProposal) error
+	GetProposal(ctx context.Context, id int64) (*models.ReplenishmentProposal, error)
+	// ListProposals 在庫補充提案一覧を取得する（statusが空の場合はすべて）
+	ListProposals(ctx context.Context, status models.ReplenishmentProposalStatus) ([]*models.ReplenishmentProposal, error)
+	// GetOpenProposal 商品・ロケーションの未対応の在庫補充提案を取得する
+	GetOpenProposal(ctx context.Context, productID int64, location string) (*models.ReplenishmentProposal, error)
+	UpdateProposalStatus(ctx context.Context, id int64, status models.ReplenishmentProposalStatus) error
+
+	// ListAlertRecipientIDs 在庫補充提案の通知先（有効なマネージャー・管理者）のユーザーIDを取得する
+	ListAlertRecipientIDs(ctx context.Context) ([]int64, error)
+}
+
+// SQLReplenishmentRepository SQL在庫補充リポジトリ
+type SQLReplenishmentRepository struct {
+	db DB
+}
+
+// NewSQLReplenishmentRepository SQL在庫補充リポジトリを作成する
+func NewSQLReplenishmentRepository(db DB) ReplenishmentRepository {
+	return &SQLReplenishmentRepository{db: db}
+}
+
+const reorderSettingColumns = `id, product_id, location, reorder_point, safety_stock,
+			reorder_quantity, created_at, updated_at`
+
+const proposalColumns = `id, product_id, location, type, source_location, quantity,
+			available_to_promise, reorder_point, safety_stock, status, created_at, updated_at`
+
+// scanReorderSetting 発注点設定の行を読み取る
+func scanReorderSetting(scanner rowScanner) (*models.ReorderSetting, error) {
+	setting := &models.ReorderSetting{}
+	err := scanner.Scan(
+		&setting.ID,
+		&setting.ProductID,
+		&setting.Location,
+		&setting.ReorderPoint,
+		&setting.SafetyStock,
+		&setting.ReorderQuantity,
+		&setting.CreatedAt,
+		&setting.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// scanProposal 在庫補充提案の行を読み取る
+func scanProposal(scanner rowScanner) (*models.ReplenishmentProposal, error) {
+	proposal := &models.ReplenishmentProposal{}
+	var sourceLocation sql.NullString
+	err := scanner.Scan(
+		&proposal.ID,
+		&proposal.ProductID,
+		&proposal.Location,
+		&proposal.Type,
+		&sourceLocation,
+		&proposal.Quantity,
+		&proposal.AvailableToPromise,
+		&proposal.ReorderPoint,
+		&proposal.SafetyStock,
+		&proposal.Status,
+		&proposal.CreatedAt,
+		&proposal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	proposal.SourceLocation = sourceLocation.String
+	return proposal, nil
+}
+
+// ListReorderSettings 発注点設定一覧を取得する
+func (r *SQLReplenishmentRepository) ListReorderSettings(ctx context.Context) ([]*models.ReorderSetting, error) {
+	query := `
+		SELECT ` + reorderSettingColumns + `
+		FROM reorder_settings
+		ORDER BY product_id, location`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("発注点設定一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var settings []*models.ReorderSetting
+	for rows.Next() {
+		setting, err := scanReorderSetting(rows)
+		if err != nil {
+			return nil, fmt.Errorf("発注点設定データ読み取りエラー: %v", err)
+		}
+		settings = append(settings, setting)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("発注点設定一覧読み取りエラー: %v", err)
+	}
+
+	return settings, nil
+}
+
+// GetReorderSetting 商品・ロケーションの発注点設定を取得する
+func (r *SQLReplenishmentRepository) GetReorderSetting(ctx context.Context, productID int64, location string) (*models.ReorderSetting, error) {
+	query := `
+		SELECT ` + reorderSettingColumns + `
+		FROM reorder_settings
+		WHERE product_id = $1 AND location = $2`
+
+	setting, err := scanReorderSetting(r.db.QueryRowContext(ctx, query, productID, location))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("発注点設定取得エラー: %v", err)
+	}
+
+	return setting, nil
+}
+
+// SetReorderSetting 商品・ロケーションの発注点を設定する（未設定の場合は作成する）
+func (r *SQLReplenishmentRepository) SetReorderSetting(ctx context.Context, setting *models.ReorderSetting) error {
+	query := `
+		INSERT INTO reorder_settings (
+			product_id, location, reorder_point, safety_stock, reorder_quantity,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (product_id, location) DO UPDATE
+		SET reorder_point = EXCLUDED.reorder_point,
+			safety_stock = EXCLUDED.safety_stock,
+			reorder_quantity = EXCLUDED.reorder_quantity,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		setting.ProductID,
+		setting.Location,
+		setting.ReorderPoint,
+		setting.SafetyStock,
+		setting.ReorderQuantity,
+		now,
+	).Scan(&setting.ID, &setting.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("発注点設定エラー: %v", err)
+	}
+
+	setting.UpdatedAt = now
+	return nil
+}
+
+// DeleteReorderSetting 発注点設定を削除する
+func (r *SQLReplenishmentRepository) DeleteReorderSetting(ctx context.Context, id int64) error {
+	query := `DELETE FROM reorder_settings WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("発注点設定削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateProposal 在庫補充提案を作成する
+func (r *SQLReplenishmentRepository) CreateProposal(ctx context.Context, proposal *models.ReplenishmentProposal) error {
+	query := `
+		INSERT INTO replenishment_proposals (
+			product_id, location, type, source_location, quantity,
+			available_to_promise, reorder_point, safety_stock, status,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $10)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		proposal.ProductID,
+		proposal.Location,
+		proposal.Type,
+		proposal.SourceLocation,
+		proposal.Quantity,
+		proposal.AvailableToPromise,
+		proposal.ReorderPoint,
+		proposal.SafetyStock,
+		proposal.Status,
+		now,
+	).Scan(&proposal.ID)
+	if err != nil {
+		return fmt.Errorf("在庫補充提案作成エラー: %v", err)
+	}
+
+	proposal.CreatedAt = now
+	proposal.UpdatedAt = now
+	return nil
+}
+
+// GetProposal 在庫補充提案を取得する
+func (r *SQLReplenishmentRepository) GetProposal(ctx context.Context, id int64) (*models.ReplenishmentProposal, error) {
+	query := `
+		SELECT ` + proposalColumns + `
+		FROM replenishment_proposals
+		WHERE id = $1`
+
+	proposal, err := scanProposal(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫補充提案取得エラー: %v", err)
+	}
+
+	return proposal, nil
+}
+
+// ListProposals 在庫補充提案一覧を新しい順に取得する
+func (r *SQLReplenishmentRepository) ListProposals(ctx context.Context, status models.ReplenishmentProposalStatus) ([]*models.ReplenishmentProposal, error) {
+	query := `
+		SELECT ` + proposalColumns + `
+		FROM replenishment_proposals
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("在庫補充提案一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var proposals []*models.ReplenishmentProposal
+	for rows.Next() {
+		proposal, err := scanProposal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫補充提案データ読み取りエラー: %v", err)
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫補充提案一覧読み取りエラー: %v", err)
+	}
+
+	return proposals, nil
+}
+
+// GetOpenProposal 商品・ロケーションの未対応の在庫補充提案を取得する
+func (r *SQLReplenishmentRepository) GetOpenProposal(ctx context.Context, productID int64, location string) (*models.ReplenishmentProposal, error) {
+	query := `
+		SELECT ` + proposalColumns + `
+		FROM replenishment_proposals
+		WHERE product_id = $1 AND location = $2 AND status = $3
+		ORDER BY id DESC
+		LIMIT 1`
+
+	proposal, err := scanProposal(r.db.QueryRowContext(ctx, query, productID, location, models.ReplenishmentProposalOpen))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫補充提案取得エラー: %v", err)
+	}
+
+	return proposal, nil
+}
+
+// UpdateProposalStatus 在庫補充提案のステータスを更新する
+func (r *SQLReplenishmentRepository) UpdateProposalStatus(ctx context.Context, id int64, status models.ReplenishmentProposalStatus) error {
+	query := `
+		UPDATE replenishment_proposals
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("在庫補充提案更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListAlertRecipientIDs 在庫補充提案の通知先のユーザーIDを取得する
+func (r *SQLReplenishmentRepository) ListAlertRecipientIDs(ctx context.Context) ([]int64, error) {
+	return listAlertRecipientIDs(ctx, r.db)
+}
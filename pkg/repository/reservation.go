@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 在庫引当リポジトリ
+ * データベースとの在庫引当関連の操作を管理する
+ */
+
+// ReservationRepository 在庫引当リポジトリインターフェース
+type ReservationRepository interface {
+	CreateReservation(ctx context.Context, reservation *models.Reservation) error
+	GetReservation(ctx context.Context, id int64) (*models.Reservation, error)
+	ListReservationsByDelivery(ctx context.Context, deliveryID int64) ([]*models.Reservation, error)
+	UpdateReservationStatus(ctx context.Context, id int64, status models.ReservationStatus) error
+	UpdateReservationExpiry(ctx context.Context, id int64, expiresAt *time.Time) error
+	// ExpireReservation 有効期限を過ぎた引当中の在庫引当を期限切れにする
+	ExpireReservation(ctx context.Context, id int64, now time.Time) error
+	UpdateReservationQuantity(ctx context.Context, id int64, quantity int) error
+	SumActiveReserved(ctx context.Context, productID int64, location string) (int, error)
+	SumActiveReservedByLot(ctx context.Context, lotID int64, location string) (int, error)
+	ListExpiredReservations(ctx context.Context, now time.Time) ([]*models.Reservation, error)
+	// LockStock 商品・ロケーション単位の在庫引当をトランザクションの終了まで直列化する
+	LockStock(ctx context.Context, productID int64, location string) error
+}
+
+// SQLReservationRepository SQL在庫引当リポジトリ
+type SQLReservationRepository struct {
+	db DB
+}
+
+// NewSQLReservationRepository SQL在庫引当リポジトリを作成する
+func NewSQLReservationRepository(db DB) ReservationRepository {
+	return &SQLReservationRepository{db: db}
+}
+
+const reservationColumns = `id, product_id, location, lot_id, quantity, delivery_id,
+			delivery_item_id, reference_number, status, expires_at,
+			created_at, updated_at`
+
+// rowScanner sql.Rowとsql.Rowsに共通する読み取りインターフェース
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReservation 引当行を読み取る
+func scanReservation(scanner rowScanner) (*models.Reservation, error) {
+	reservation := &models.Reservation{}
+	var lotID sql.NullInt64
+	var deliveryID sql.NullInt64
+	var deliveryItemID sql.NullInt64
+	var referenceNumber sql.NullString
+	var expiresAt sql.NullTime
+
+	err := scanner.Scan(
+		&reservation.ID,
+		&reservation.ProductID,
+		&reservation.Location,
+		&lotID,
+		&reservation.Quantity,
+		&deliveryID,
+		&deliveryItemID,
+		&referenceNumber,
+		&reservation.Status,
+		&expiresAt,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lotID.Valid {
+		id := lotID.Int64
+		reservation.LotID = &id
+	}
+	if deliveryID.Valid {
+		id := deliveryID.Int64
+		reservation.DeliveryID = &id
+	}
+	if deliveryItemID.Valid {
+		id := deliveryItemID.Int64
+		reservation.DeliveryItemID = &id
+	}
+	reservation.ReferenceNumber = referenceNumber.String
+	if expiresAt.Valid {
+		t := expiresAt.Time
+		reservation.ExpiresAt = &t
+	}
+
+	return reservation, nil
+}
+
+// CreateReservation 在庫引当を作成する
+func (r *SQLReservationRepository) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	query := `
+		INSERT INTO stock_reservations (
+			product_id, location, lot_id, quantity, delivery_id,
+			delivery_item_id, reference_number, status, expires_at,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		reservation.ProductID,
+		reservation.Location,
+		reservation.LotID,
+		reservation.Quantity,
+		reservation.DeliveryID,
+		reservation.DeliveryItemID,
+		reservation.ReferenceNumber,
+		reservation.Status,
+		reservation.ExpiresAt,
+		now,
+	).Scan(&reservation.ID)
+
+	if err != nil {
+		return fmt.Errorf("在庫引当作成エラー: %v", err)
+	}
+
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+	return nil
+}
+
+// GetReservation 在庫引当を取得する
+func (r *SQLReservationRepository) GetReservation(ctx context.Context, id int64) (*models.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM stock_reservations
+		WHERE id = $1`
+
+	reservation, err := scanReservation(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫引当取得エラー: %v", err)
+	}
+
+	return reservation, nil
+}
+
+// ListReservationsByDelivery 配送に紐づく在庫引当一覧を取得する
+func (r *SQLReservationRepository) ListReservationsByDelivery(ctx context.Context, deliveryID int64) ([]*models.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM stock_reservations
+		WHERE delivery_id = $1
+		ORDER BY id`
+
+	return r.listReservations(ctx, query, deliveryID)
+}
+
+// ListExpiredReservations 期限切れとなった引当中の在庫引当一覧を取得する
+func (r *SQLReservationRepository) ListExpiredReservations(ctx context.Context, now time.Time) ([]*models.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM stock_reservations
+		WHERE status = $1 AND expires_at IS NOT NULL AND expires_at <= $2
+		ORDER BY expires_at`
+
+	return r.listReservations(ctx, query, models.ReservationStatusActive, now)
+}
+
+// listReservations 在庫引当一覧を読み取る
+func (r *SQLReservationRepository) listReservations(ctx context.Context, query string, args ...interface{}) ([]*models.Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("在庫引当一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var reservations []*models.Reservation
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫引当データ読み取りエラー: %v", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫引当一覧読み取りエラー: %v", err)
+	}
+
+	return reservations, nil
+}
+
+// UpdateReservationStatus 在庫引当ステータスを更新する
+// 解放・確定・期限切れはいずれも終端状態のため、引当中の在庫引当のみを更新対象とする
+func (r *SQLReservationRepository) UpdateReservationStatus(ctx context.Context, id int64, status models.ReservationStatus) error {
+	query := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	return r.execUpdate(ctx, "在庫引当ステータス更新エラー", query, status, time.Now(), id, models.ReservationStatusActive)
+}
+
+// UpdateReservationExpiry 引当中の在庫引当の有効期限を更新する
+// expiresAtにnilを指定した場合は無期限となる
+func (r *SQLReservationRepository) UpdateReservationExpiry(ctx context.Context, id int64, expiresAt *time.Time) error {
+	query := `
+		UPDATE stock_reservations
+		SET expires_at = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	return r.execUpdate(ctx, "在庫引当期限更新エラー", query, expiresAt, time.Now(), id, models.ReservationStatusActive)
+}
+
+// ExpireReservation 有効期限を過ぎた引当中の在庫引当を期限切れにする
+// 一覧の取得後に解放・確定された引当や、有効期限が延長・解除された引当は更新しない（ErrNotFoundを返す）
+func (r *SQLReservationRepository) ExpireReservation(ctx context.Context, id int64, now time.Time) error {
+	query := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4 AND expires_at IS NOT NULL AND expires_at <= $5`
+
+	return r.execUpdate(ctx, "在庫引当期限切れ更新エラー", query,
+		models.ReservationStatusExpired, time.Now(), id, models.ReservationStatusActive, now)
+}
+
+// UpdateReservationQuantity 引当中の在庫引当の数量を更新する
+func (r *SQLReservationRepository) UpdateReservationQuantity(ctx context.Context, id int64, quantity int) error {
+	query := `
+		UPDATE stock_reservations
+		SET quantity = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	return r.execUpdate(ctx, "在庫引当数量更新エラー", query, quantity, time.Now(), id, models.ReservationStatusActive)
+}
+
+// execUpdate 更新クエリを実行し、対象行の存在を確認する
+func (r *SQLReservationRepository) execUpdate(ctx context.Context, errMsg, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %v", errMsg, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SumActiveReserved 商品・ロケーション単位の引当中数量を集計する
+func (r *SQLReservationRepository) SumActiveReserved(ctx context.Context, productID int64, location string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM stock_reservations
+		WHERE product_id = $1 AND location = $2 AND status = $3`
+
+	var reserved int
+	if err := r.db.QueryRowContext(ctx, query, productID, location, models.ReservationStatusActive).Scan(&reserved); err != nil {
+		return 0, fmt.Errorf("引当数量集計エラー: %v", err)
+	}
+
+	return reserved, nil
+}
+
+// SumActiveReservedByLot ロット・ロケーション単位の引当中数量を集計する
+func (r *SQLReservationRepository) SumActiveReservedByLot(ctx context.Context, lotID int64, location string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM stock_reservations
+		WHERE lot_id = $1 AND location = $2 AND status = $3`
+
+	var reserved int
+	if err := r.db.QueryRowContext(ctx, query, lotID, location, models.ReservationStatusActive).Scan(&reserved); err != nil {
+		return 0, fmt.Errorf("ロット引当数量集計エラー: %v", err)
+	}
+
+	return reserved, nil
+}
+
+// LockStock 商品・ロケーション単位のアドバイザリロックを取得する
+// 商品IDとロケーションを1つの64ビットのキーにハッシュするため、商品IDの範囲に制限はない
+// ロックはトランザクションの終了時に解放されるため、トランザクション内で呼び出すこと
+// 複数のロックを取得する場合は、デッドロックしないよう呼び出し元で一定の順序に並べること
+func (r *SQLReservationRepository) LockStock(ctx context.Context, productID int64, location string) error {
+	key := fmt.Sprintf("stock:%d:%s", productID, location)
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, key); err != nil {
+		return fmt.Errorf("在庫ロックエラー: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 配送ルートリポジトリ
+ * データベースとの車両の1日の配送ルート関連の操作を管理する
+ */
+
+// RouteRepository 配送ルートリポジトリインターフェース
+type RouteRepository interface {
+	// ReplaceVehicleRoute 車両の指定した日の配送ルートを置き換える
+	ReplaceVehicleRoute(ctx context.Context, vehicleID int64, date string, routes []*models.Route) error
+	// ListVehicleRoute 車両の指定した日の配送ルートを訪問順に取得する
+	ListVehicleRoute(ctx context.Context, vehicleID int64, date string) ([]*models.Route, error)
+	// GetDeliveryRoute 配送の訪問先を取得する（複数の日の配送ルートにある場合は最も新しい日のもの）
+	GetDeliveryRoute(ctx context.Context, deliveryID int64) (*models.Route, error)
+}
+
+// SQLRouteRepository SQL配送ルートリポジトリ
+type SQLRouteRepository struct {
+	db DB
+}
+
+// NewSQLRouteRepository SQL配送ルートリポジトリを作成する
+func NewSQLRouteRepository(db DB) RouteRepository {
+	return &SQLRouteRepository{db: db}
+}
+
+// ReplaceVehicleRoute 車両の指定した日の配送ルートを削除し、訪問先を登録し直す
+// 削除と登録の間に他の処理から参照されないよう、トランザクション内で呼び出すこと
+func (r *SQLRouteRepository) ReplaceVehicleRoute(ctx context.Context, vehicleID int64, date string, routes []*models.Route) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM routes WHERE vehicle_id = $1 AND route_date = $2`, vehicleID, date); err != nil {
+		return fmt.Errorf("配送ルート削除エラー: %v", err)
+	}
+
+	query := `
+		INSERT INTO routes (
+			delivery_id, vehicle_id, route_date, sequence, location, latitude, longitude,
+			arrival_time, distance, duration, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+		RETURNING id`
+
+	now := time.Now()
+	for _, route := range routes {
+		var arrivalTime sql.NullTime
+		if !route.ArrivalTime.IsZero() {
+			arrivalTime = sql.NullTime{Time: route.ArrivalTime, Valid: true}
+		}
+
+		err := r.db.QueryRowContext(ctx, query,
+			route.DeliveryID,
+			vehicleID,
+			date,
+			route.Sequence,
+			route.Location,
+			route.Latitude,
+			route.Longitude,
+			arrivalTime,
+			route.Distance,
+			route.Duration,
+			route.Status,
+			now,
+		).Scan(&route.ID)
+		if err != nil {
+			return fmt.Errorf("配送ルート作成エラー: %v", err)
+		}
+
+		route.VehicleID = vehicleID
+		route.RouteDate = date
+		route.CreatedAt = now
+		route.UpdatedAt = now
+	}
+
+	return nil
+}
+
+const routeColumns = `id, delivery_id, vehicle_id, to_char(route_date, 'YYYY-MM-DD'), sequence, location,
+			latitude, longitude, arrival_time, COALESCE(distance, 0), COALESCE(duration, 0),
+			status, created_at, updated_at`
+
+// scanRoute 配送ルートの訪問先の行を読み取る
+func scanRoute(scanner rowScanner) (*models.Route, error) {
+	route := &models.Route{}
+	var arrivalTime sql.NullTime
+	err := scanner.Scan(
+		&route.ID,
+		&route.DeliveryID,
+		&route.VehicleID,
+		&route.RouteDate,
+		&route.Sequence,
+		&route.Location,
+		&route.Latitude,
+		&route.Longitude,
+		&arrivalTime,
+		&route.Distance,
+		&route.Duration,
+		&route.Status,
+		&route.CreatedAt,
+		&route.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if arrivalTime.Valid {
+		route.ArrivalTime = arrivalTime.Time
+	}
+	return route, nil
+}
+
+// ListVehicleRoute 車両の指定した日の配送ルートを訪問順に取得する
+func (r *SQLRouteRepository) ListVehicleRoute(ctx context.Context, vehicleID int64, date string) ([]*models.Route, error) {
+	query := `
+		SELECT ` + routeColumns + `
+		FROM routes
+		WHERE vehicle_id = $1 AND route_date = $2
+		ORDER BY sequence, id`
+
+	rows, err := r.db.QueryContext(ctx, query, vehicleID, date)
+	if err != nil {
+		return nil, fmt.Errorf("配送ルート取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var routes []*models.Route
+	for rows.Next() {
+		route, err := scanRoute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("配送ルートデータ読み取りエラー: %v", err)
+		}
+		routes = append(routes, route)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("配送ルート読み取りエラー: %v", err)
+	}
+
+	return routes, nil
+}
+
+// GetDeliveryRoute 配送の訪問先を取得する
+func (r *SQLRouteRepository) GetDeliveryRoute(ctx context.Context, deliveryID int64) (*models.Route, error) {
+	query := `
+		SELECT ` + routeColumns + `
+		FROM routes
+		WHERE delivery_id = $1
+		ORDER BY route_date DESC, id DESC
+		LIMIT 1`
+
+	route, err := scanRoute(r.db.QueryRowContext(ctx, query, deliveryID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送ルート取得エラー: %v", err)
+	}
+
+	return route, nil
+}
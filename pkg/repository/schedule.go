@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 配送スケジュールリポジトリ
+ * データベースとの配送スケジュール（配送への車両・運転手の割当枠）関連の操作を管理する
+ */
+
+// ScheduleRepository 配送スケジュールリポジトリインターフェース
+type ScheduleRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.DeliverySchedule) error
+	GetSchedule(ctx context.Context, id int64) (*models.DeliverySchedule, error)
+	UpdateScheduleStatus(ctx context.Context, id int64, status models.ScheduleStatus) error
+	// CancelDeliverySchedules 配送の予定中の配送スケジュールをすべて取消済みにし、取り消した配送スケジュールを返す
+	CancelDeliverySchedules(ctx context.Context, deliveryID int64) ([]*models.DeliverySchedule, error)
+	// ListVehicleSchedules 期間と重なる車両の配送スケジュールを配送の概要とともに開始日時の順に取得する
+	// fromまたはtoがゼロ値の場合は、その側の期間を限定しない
+	ListVehicleSchedules(ctx context.Context, vehicleID int64, from, to time.Time) ([]*models.VehicleScheduleEntry, error)
+	// ListSchedulesInPeriod 期間と重なるすべての車両の予定中の配送スケジュールを取得する
+	ListSchedulesInPeriod(ctx context.Context, start, end time.Time) ([]*models.DeliverySchedule, error)
+	// ListDriverSchedules 期間と重なる運転手の配送スケジュールを開始日時の順に取得する（取消済みを含む）
+	ListDriverSchedules(ctx context.Context, driverID int64, start, end time.Time) ([]*models.DeliverySchedule, error)
+}
+
+// SQLScheduleRepository SQL配送スケジュールリポジトリ
+type SQLScheduleRepository struct {
+	db DB
+}
+
+// NewSQLScheduleRepository SQL配送スケジュールリポジトリを作成する
+func NewSQLScheduleRepository(db DB) ScheduleRepository {
+	return &SQLScheduleRepository{db: db}
+}
+
+const scheduleColumns = `s.id, s.delivery_id, s.driver_id, s.vehicle_id, s.start_time, s.end_time,
+			s.status, s.weight_g, s.volume_ml, s.created_at, s.updated_at`
+
+// scanSchedule 配送スケジュール行を読み取る
+// extraには配送スケジュールの列の後に続く列の読み取り先を指定する
+func scanSchedule(scanner rowScanner, extra ...interface{}) (*models.DeliverySchedule, error) {
+	schedule := &models.DeliverySchedule{}
+	var driverID sql.NullInt64
+
+	err := scanner.Scan(append([]interface{}{
+		&schedule.ID,
+		&schedule.DeliveryID,
+		&driverID,
+		&schedule.VehicleID,
+		&schedule.StartTime,
+		&schedule.EndTime,
+		&schedule.Status,
+		&schedule.WeightGrams,
+		&schedule.VolumeML,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	}, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if driverID.Valid {
+		id := driverID.Int64
+		schedule.DriverID = &id
+	}
+
+	return schedule, nil
+}
+
+// CreateSchedule 配送スケジュールを作成する
+func (r *SQLScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.DeliverySchedule) error {
+	query := `
+		INSERT INTO delivery_schedules (
+			delivery_id, driver_id, vehicle_id, start_time, end_time,
+			status, weight_g, volume_ml, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id`
+
+	if schedule.Status == "" {
+		schedule.Status = models.ScheduleStatusScheduled
+	}
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		schedule.DeliveryID,
+		schedule.DriverID,
+		schedule.VehicleID,
+		schedule.StartTime,
+		schedule.EndTime,
+		schedule.Status,
+		schedule.WeightGrams,
+		schedule.VolumeML,
+		now,
+	).Scan(&schedule.ID)
+	if err != nil {
+		return fmt.Errorf("配送スケジュール作成エラー: %v", err)
+	}
+
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	return nil
+}
+
+// GetSchedule 配送スケジュールを取得する
+func (r *SQLScheduleRepository) GetSchedule(ctx context.Context, id int64) (*models.DeliverySchedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM delivery_schedules s
+		WHERE s.id = $1`
+
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+
+	return schedule, nil
+}
+
+// UpdateScheduleStatus 配送スケジュールのステータスを更新する
+func (r *SQLScheduleRepository) UpdateScheduleStatus(ctx context.Context, id int64, status models.ScheduleStatus) error {
+	query := `
+		UPDATE delivery_schedules
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("配送スケジュール更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CancelDeliverySchedules 配送の予定中の配送スケジュールをすべて取消済みにする
+func (r *SQLScheduleRepository) CancelDeliverySchedules(ctx context.Context, deliveryID int64) ([]*models.DeliverySchedule, error) {
+	query := `
+		UPDATE delivery_schedules s
+		SET status = $1, updated_at = $2
+		WHERE s.delivery_id = $3 AND s.status = $4
+		RETURNING ` + scheduleColumns
+
+	return r.querySchedules(ctx, query, models.ScheduleStatusCancelled, time.Now(), deliveryID, models.ScheduleStatusScheduled)
+}
+
+// ListVehicleSchedules 期間と重なる車両の配送スケジュールを配送の概要とともに取得する
+func (r *SQLScheduleRepository) ListVehicleSchedules(ctx context.Context, vehicleID int64, from, to time.Time) ([]*models.VehicleScheduleEntry, error) {
+	conditions := []string{"s.vehicle_id = $1"}
+	args := []interface{}{vehicleID}
+	if !from.IsZero() {
+		args = append(args, from)
+		conditions = append(conditions, fmt.Sprintf("s.end_time > $%d", len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		conditions = append(conditions, fmt.Sprintf("s.start_time < $%d", len(args)))
+	}
+
+	query := `
+		SELECT ` + scheduleColumns + `,
+			d.order_id, d.status, d.to_address
+		FROM delivery_schedules s
+		JOIN deliveries d ON d.id = s.delivery_id` + whereClause(conditions) + `
+		ORDER BY s.start_time, s.id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("車両の配送スケジュール取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.VehicleScheduleEntry
+	for rows.Next() {
+		entry := &models.VehicleScheduleEntry{}
+		entry.DeliverySchedule, err = scanSchedule(rows, &entry.OrderID, &entry.DeliveryStatus, &entry.ToAddress)
+		if err != nil {
+			return nil, fmt.Errorf("配送スケジュールデータ読み取りエラー: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("車両の配送スケジュール読み取りエラー: %v", err)
+	}
+
+	return entries, nil
+}
+
+// ListSchedulesInPeriod 期間と重なるすべての車両の予定中の配送スケジュールを取得する
+func (r *SQLScheduleRepository) ListSchedulesInPeriod(ctx context.Context, start, end time.Time) ([]*models.DeliverySchedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM delivery_schedules s
+		WHERE s.status = $1 AND s.start_time < $3 AND s.end_time > $2
+		ORDER BY s.vehicle_id, s.start_time, s.id`
+
+	return r.querySchedules(ctx, query, models.ScheduleStatusScheduled, start, end)
+}
+
+// ListDriverSchedules 期間と重なる運転手の配送スケジュールを開始日時の順に取得する
+func (r *SQLScheduleRepository) ListDriverSchedules(ctx context.Context, driverID int64, start, end time.Time) ([]*models.DeliverySchedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM delivery_schedules s
+		WHERE s.driver_id = $1 AND s.start_time < $3 AND s.end_time > $2
+		ORDER BY s.start_time, s.id`
+
+	return r.querySchedules(ctx, query, driverID, start, end)
+}
+
+func (r *SQLScheduleRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]*models.DeliverySchedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var schedules []*models.DeliverySchedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("配送スケジュールデータ読み取りエラー: %v", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("配送スケジュール読み取りエラー: %v", err)
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 棚卸リポジトリ
+ * データベースとの棚卸・棚卸明細関連の操作を管理する
+ */
+
+// StockCountRepository 棚卸リポジトリインターフェース
+type StockCountRepository interface {
+	// 棚卸
+	CreateStockCount(ctx context.Context, count *models.StockCount) error
+	GetStockCount(ctx context.Context, id int64) (*models.StockCount, error)
+	// ListStockCounts 棚卸一覧を取得する（statusが空の場合はすべて）
+	ListStockCounts(ctx context.Context, status models.StockCountStatus) ([]*models.StockCount, error)
+	// GetActiveStockCount ロケーションを凍結している進行中の棚卸を取得する
+	GetActiveStockCount(ctx context.Context, location string) (*models.StockCount, error)
+	UpdateStockCount(ctx context.Context, count *models.StockCount) error
+
+	// 棚卸明細
+	CreateStockCountLine(ctx context.Context, line *models.StockCountLine) error
+	ListStockCountLines(ctx context.Context, countID int64) ([]*models.StockCountLine, error)
+	UpdateStockCountLine(ctx context.Context, line *models.StockCountLine) error
+}
+
+// SQLStockCountRepository SQL棚卸リポジトリ
+type SQLStockCountRepository struct {
+	db DB
+}
+
+// NewSQLStockCountRepository SQL棚卸リポジトリを作成する
+func NewSQLStockCountRepository(db DB) StockCountRepository {
+	return &SQLStockCountRepository{db: db}
+}
+
+const stockCountColumns = `id, location, status, notes, COALESCE(created_by, 0),
+			COALESCE(reviewed_by, 0), reviewed_at, review_note, created_at, updated_at`
+
+const stockCountLineColumns = `id, stock_count_id, inventory_id, product_id, bin_id, lot_id,
+			status, expected_quantity, counted_quantity, reason_code`
+
+// scanStockCount 棚卸の行を読み取る
+func scanStockCount(scanner rowScanner) (*models.StockCount, error) {
+	count := &models.StockCount{}
+	var reviewedAt sql.NullTime
+	err := scanner.Scan(
+		&count.ID,
+		&count.Location,
+		&count.Status,
+		&count.Notes,
+		&count.CreatedBy,
+		&count.ReviewedBy,
+		&reviewedAt,
+		&count.ReviewNote,
+		&count.CreatedAt,
+		&count.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		t := reviewedAt.Time
+		count.ReviewedAt = &t
+	}
+	return count, nil
+}
+
+// scanStockCountLine 棚卸明細の行を読み取る
+func scanStockCountLine(scanner rowScanner) (*models.StockCountLine, error) {
+	line := &models.StockCountLine{}
+	var inventoryID, binID, lotID, counted sql.NullInt64
+	var reasonCode sql.NullString
+	err := scanner.Scan(
+		&line.ID,
+		&line.StockCountID,
+		&inventoryID,
+		&line.ProductID,
+		&binID,
+		&lotID,
+		&line.Status,
+		&line.ExpectedQuantity,
+		&counted,
+		&reasonCode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if inventoryID.Valid {
+		id := inventoryID.Int64
+		line.InventoryID = &id
+	}
+	if binID.Valid {
+		id := binID.Int64
+		line.BinID = &id
+	}
+	if lotID.Valid {
+		id := lotID.Int64
+		line.LotID = &id
+	}
+	if counted.Valid {
+		quantity := int(counted.Int64)
+		line.CountedQuantity = &quantity
+	}
+	line.ReasonCode = models.AdjustmentReason(reasonCode.String)
+	return line, nil
+}
+
+// CreateStockCount 棚卸を作成する
+func (r *SQLStockCountRepository) CreateStockCount(ctx context.Context, count *models.StockCount) error {
+	query := `
+		INSERT INTO stock_counts (
+			location, status, notes, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, 0), $5, $5)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		count.Location,
+		count.Status,
+		count.Notes,
+		count.CreatedBy,
+		now,
+	).Scan(&count.ID)
+	if err != nil {
+		return fmt.Errorf("棚卸作成エラー: %v", err)
+	}
+
+	count.CreatedAt = now
+	count.UpdatedAt = now
+	return nil
+}
+
+// GetStockCount 棚卸を取得する
+func (r *SQLStockCountRepository) GetStockCount(ctx context.Context, id int64) (*models.StockCount, error) {
+	query := `
+		SELECT ` + stockCountColumns + `
+		FROM stock_counts
+		WHERE id = $1`
+
+	count, err := scanStockCount(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("棚卸取得エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// ListStockCounts 棚卸一覧を新しい順に取得する
+func (r *SQLStockCountRepository) ListStockCounts(ctx context.Context, status models.StockCountStatus) ([]*models.StockCount, error) {
+	query := `
+		SELECT ` + stockCountColumns + `
+		FROM stock_counts
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("棚卸一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []*models.StockCount
+	for rows.Next() {
+		count, err := scanStockCount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("棚卸データ読み取りエラー: %v", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("棚卸一覧読み取りエラー: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetActiveStockCount ロケーションを凍結している進行中の棚卸を取得する
+func (r *SQLStockCountRepository) GetActiveStockCount(ctx context.Context, location string) (*models.StockCount, error) {
+	query := `
+		SELECT ` + stockCountColumns + `
+		FROM stock_counts
+		WHERE location = $1 AND status IN ($2, $3)
+		LIMIT 1`
+
+	count, err := scanStockCount(r.db.QueryRowContext(ctx, query,
+		location,
+		models.StockCountStatusOpen,
+		models.StockCountStatusSubmitted,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("進行中の棚卸取得エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// UpdateStockCount 棚卸のステータスと承認情報を更新する
+func (r *SQLStockCountRepository) UpdateStockCount(ctx context.Context, count *models.StockCount) error {
+	query := `
+		UPDATE stock_counts
+		SET status = $1, reviewed_by = NULLIF($2, 0), reviewed_at = $3,
+			review_note = $4, updated_at = $5
+		WHERE id = $6`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		count.Status,
+		count.ReviewedBy,
+		count.ReviewedAt,
+		count.ReviewNote,
+		now,
+		count.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("棚卸更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	count.UpdatedAt = now
+	return nil
+}
+
+// CreateStockCountLine 棚卸明細を作成する
+func (r *SQLStockCountRepository) CreateStockCountLine(ctx context.Context, line *models.StockCountLine) error {
+	query := `
+		INSERT INTO stock_count_lines (
+			stock_count_id, inventory_id, product_id, bin_id, lot_id,
+			status, expected_quantity, counted_quantity, reason_code
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.StockCountID,
+		line.InventoryID,
+		line.ProductID,
+		line.BinID,
+		line.LotID,
+		line.Status,
+		line.ExpectedQuantity,
+		line.CountedQuantity,
+		line.ReasonCode,
+	).Scan(&line.ID)
+	if err != nil {
+		return fmt.Errorf("棚卸明細作成エラー: %v", err)
+	}
+
+	return nil
+}
+
+// ListStockCountLines 棚卸明細一覧を取得する
+func (r *SQLStockCountRepository) ListStockCountLines(ctx context.Context, countID int64) ([]*models.StockCountLine, error) {
+	query := `
+		SELECT ` + stockCountLineColumns + `
+		FROM stock_count_lines
+		WHERE stock_count_id = $1
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, countID)
+	if err != nil {
+		return nil, fmt.Errorf("棚卸明細一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var lines []*models.StockCountLine
+	for rows.Next() {
+		line, err := scanStockCountLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("棚卸明細データ読み取りエラー: %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("棚卸明細一覧読み取りエラー: %v", err)
+	}
+
+	return lines, nil
+}
+
+// UpdateStockCountLine 棚卸明細の実数と調整理由を更新する
+func (r *SQLStockCountRepository) UpdateStockCountLine(ctx context.Context, line *models.StockCountLine) error {
+	query := `
+		UPDATE stock_count_lines
+		SET counted_quantity = $1, reason_code = NULLIF($2, '')
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, line.CountedQuantity, line.ReasonCode, line.ID)
+	if err != nil {
+		return fmt.Errorf("棚卸明細更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
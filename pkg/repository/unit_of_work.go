@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/*
+ * ユニットオブワーク
+ * 複数のリポジトリ操作を単一のトランザクションで実行する
+ */
+
+// TxRepositories トランザクション内で利用するリポジトリ群
+type TxRepositories struct {
+	Deliveries     DeliveryRepository
+	Inventory      InventoryRepository
+	Reservations   ReservationRepository
+	Warehouses     WarehouseRepository
+	Locations      LocationRepository
+	Lots           LotRepository
+	Allocations    AllocationRepository
+	Replenishment  ReplenishmentRepository
+	PurchaseOrders PurchaseOrderRepository
+	StockCounts    StockCountRepository
+	Valuation      ValuationRepository
+	Products       ProductRepository
+	Variants       ProductVariantRepository
+	Vehicles       VehicleRepository
+	Schedules      ScheduleRepository
+	Drivers        DriverRepository
+	Routes         RouteRepository
+}
+
+// UnitOfWork ユニットオブワークインターフェース
+type UnitOfWork interface {
+	// WithinTx fnをトランザクション内で実行する
+	// fnがエラーを返した場合はロールバックし、成功した場合はコミットする
+	WithinTx(ctx context.Context, fn func(ctx context.Context, repos *TxRepositories) error) error
+}
+
+// SQLUnitOfWork SQLユニットオブワーク
+type SQLUnitOfWork struct {
+	db *sql.DB
+}
+
+// NewSQLUnitOfWork SQLユニットオブワークを作成する
+func NewSQLUnitOfWork(db *sql.DB) UnitOfWork {
+	return &SQLUnitOfWork{db: db}
+}
+
+// WithinTx fnをトランザクション内で実行する
+func (u *SQLUnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context, repos *TxRepositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("トランザクション開始エラー: %v", err)
+	}
+
+	// パニック時も必ずロールバックする
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	txDB := NewSQLTx(tx)
+	repos := &TxRepositories{
+		Deliveries:     NewSQLDeliveryRepository(txDB),
+		Inventory:      newTxInventoryRepository(txDB),
+		Reservations:   NewSQLReservationRepository(txDB),
+		Warehouses:     NewSQLWarehouseRepository(txDB),
+		Locations:      NewSQLLocationRepository(txDB),
+		Lots:           NewSQLLotRepository(txDB),
+		Allocations:    NewSQLAllocationRepository(txDB),
+		Replenishment:  NewSQLReplenishmentRepository(txDB),
+		PurchaseOrders: NewSQLPurchaseOrderRepository(txDB),
+		StockCounts:    NewSQLStockCountRepository(txDB),
+		Valuation:      NewSQLValuationRepository(txDB),
+		Products:       newTxProductRepository(txDB),
+		Variants:       NewSQLProductVariantRepository(txDB),
+		Vehicles:       NewSQLVehicleRepository(txDB),
+		Schedules:      NewSQLScheduleRepository(txDB),
+		Drivers:        NewSQLDriverRepository(txDB),
+		Routes:         NewSQLRouteRepository(txDB),
+	}
+
+	if err := fn(ctx, repos); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%v, ロールバックエラー: %v", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("トランザクションコミットエラー: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * ユニットオブワークのSQLモックテスト
+ * トランザクションのコミット・ロールバックを検証する
+ */
+
+func TestSQLUnitOfWork_WithinTx(t *testing.T) {
+	t.Run("成功時はコミットする", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		uow := NewSQLUnitOfWork(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE inventory`).
+			WithArgs(50, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(1, 2))
+		mock.ExpectQuery(`INSERT INTO deliveries`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		err = uow.WithinTx(context.Background(), func(ctx context.Context, repos *TxRepositories) error {
+			if err := repos.Inventory.UpdateQuantity(ctx, 1, 50, 1); err != nil {
+				return err
+			}
+			return repos.Deliveries.CreateDelivery(ctx, &models.Delivery{
+				OrderID:         1,
+				Status:          "pending",
+				FromWarehouseID: 1,
+				ToAddress:       "東京都渋谷区",
+				EstimatedTime:   time.Now(),
+			})
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("失敗時はロールバックする", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		uow := NewSQLUnitOfWork(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE inventory`).
+			WithArgs(50, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(1, 2))
+		mock.ExpectQuery(`INSERT INTO delivery_items`).
+			WillReturnError(errors.New("constraint violation"))
+		mock.ExpectRollback()
+
+		err = uow.WithinTx(context.Background(), func(ctx context.Context, repos *TxRepositories) error {
+			if err := repos.Inventory.UpdateQuantity(ctx, 1, 50, 1); err != nil {
+				return err
+			}
+			return repos.Deliveries.CreateDeliveryItem(ctx, &models.DeliveryItem{
+				DeliveryID: 1,
+				ProductID:  1,
+				Quantity:   10,
+			})
+		})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
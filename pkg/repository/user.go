@@ -168,3 +168,33 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, userID int64, hashe
 
 	return nil
 }
+
+// listAlertRecipientIDs 在庫アラートの送信先（有効なマネージャー・管理者）のユーザーIDを取得する
+func listAlertRecipientIDs(ctx context.Context, db DB) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM users
+		WHERE role IN ($1, $2) AND status = $3
+		ORDER BY id`
+
+	rows, err := db.QueryContext(ctx, query, models.RoleManager, models.RoleAdmin, models.UserStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("通知先ユーザー取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("通知先ユーザーデータ読み取りエラー: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("通知先ユーザー読み取りエラー: %v", err)
+	}
+
+	return userIDs, nil
+}
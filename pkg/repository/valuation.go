@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 在庫評価リポジトリ
+ * データベースとの在庫数量・在庫移動台帳・商品単価履歴・日次スナップショット関連の操作を管理する
+ */
+
+// ValuationRepository 在庫評価リポジトリインターフェース
+type ValuationRepository interface {
+	// ListStockLevels 現在の在庫数量を商品・ロケーションごとに集計して取得する
+	ListStockLevels(ctx context.Context) ([]*models.StockLevel, error)
+	// ListStockLocations 在庫を保管するロケーション（倉庫名と在庫のあるロケーション）を取得する
+	ListStockLocations(ctx context.Context) ([]string, error)
+	// ListMovementsSince 指定日時以降の在庫移動を取得する
+	ListMovementsSince(ctx context.Context, since time.Time) ([]*models.InventoryMovement, error)
+	// ListCostLayers 指定日時より前の入庫を入庫日時点の単価とともに古い順に取得する
+	ListCostLayers(ctx context.Context, before time.Time) ([]*models.CostLayer, error)
+	// ListProductCosts 指定日時より前に有効だった単価で商品一覧を取得する
+	ListProductCosts(ctx context.Context, before time.Time) ([]*models.ProductCost, error)
+
+	// 日次スナップショット
+	CreateSnapshot(ctx context.Context, snapshot *models.InventorySnapshot) error
+	CreateSnapshotLine(ctx context.Context, snapshotDate time.Time, level *models.StockLevel) error
+	GetSnapshot(ctx context.Context, snapshotDate time.Time) (*models.InventorySnapshot, error)
+	ListSnapshotLines(ctx context.Context, snapshotDate time.Time) ([]*models.StockLevel, error)
+}
+
+// SQLValuationRepository SQL在庫評価リポジトリ
+type SQLValuationRepository struct {
+	db DB
+}
+
+// NewSQLValuationRepository SQL在庫評価リポジトリを作成する
+func NewSQLValuationRepository(db DB) ValuationRepository {
+	return &SQLValuationRepository{db: db}
+}
+
+// snapshotDateOf スナップショット日付として保存する日付文字列を返す
+func snapshotDateOf(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// ListStockLevels 現在の在庫数量を商品・ロケーションごとに集計して取得する
+func (r *SQLValuationRepository) ListStockLevels(ctx context.Context) ([]*models.StockLevel, error) {
+	query := `
+		SELECT product_id, location, SUM(quantity)
+		FROM inventory
+		GROUP BY product_id, location
+		ORDER BY product_id, location`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("在庫数量集計エラー: %v", err)
+	}
+	defer rows.Close()
+
+	return scanStockLevels(rows)
+}
+
+// scanStockLevels 在庫数量の行をすべて読み取る
+func scanStockLevels(rows *sql.Rows) ([]*models.StockLevel, error) {
+	var levels []*models.StockLevel
+	for rows.Next() {
+		level := &models.StockLevel{}
+		if err := rows.Scan(&level.ProductID, &level.Location, &level.Quantity); err != nil {
+			return nil, fmt.Errorf("在庫数量データ読み取りエラー: %v", err)
+		}
+		levels = append(levels, level)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫数量読み取りエラー: %v", err)
+	}
+
+	return levels, nil
+}
+
+// ListStockLocations 在庫を保管するロケーションを取得する
+func (r *SQLValuationRepository) ListStockLocations(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT name FROM warehouses
+		UNION
+		SELECT DISTINCT location FROM inventory`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ロケーション一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var locations []string
+	for rows.Next() {
+		var location string
+		if err := rows.Scan(&location); err != nil {
+			return nil, fmt.Errorf("ロケーションデータ読み取りエラー: %v", err)
+		}
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ロケーション一覧読み取りエラー: %v", err)
+	}
+
+	return locations, nil
+}
+
+// ListMovementsSince 指定日時以降の在庫移動を新しい順に取得する
+func (r *SQLValuationRepository) ListMovementsSince(ctx context.Context, since time.Time) ([]*models.InventoryMovement, error) {
+	query := `
+		SELECT ` + movementColumns + `
+		FROM inventory_movements
+		WHERE movement_date >= $1
+		ORDER BY movement_date DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("在庫移動履歴取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var movements []*models.InventoryMovement
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("在庫移動データ読み取りエラー: %v", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("在庫移動履歴読み取りエラー: %v", err)
+	}
+
+	return movements, nil
+}
+
+// ListCostLayers 指定日時より前の入庫を入庫日時点の単価とともに古い順に取得する
+// 単価の履歴がない場合は現在の商品単価を使用する
+func (r *SQLValuationRepository) ListCostLayers(ctx context.Context, before time.Time) ([]*models.CostLayer, error) {
+	query := `
+		SELECT m.product_id, m.movement_date, m.quantity,
+			COALESCE((
+				SELECT h.price FROM product_price_history h
+				WHERE h.product_id = m.product_id AND h.effective_from <= m.movement_date
+				ORDER BY h.effective_from DESC
+				LIMIT 1
+			), p.price)
+		FROM inventory_movements m
+		JOIN products p ON p.id = m.product_id
+		WHERE m.movement_type = $1 AND m.movement_date < $2
+		ORDER BY m.product_id, m.movement_date, m.id`
+
+	rows, err := r.db.QueryContext(ctx, query, models.MovementTypeInbound, before)
+	if err != nil {
+		return nil, fmt.Errorf("入庫原価取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var layers []*models.CostLayer
+	for rows.Next() {
+		layer := &models.CostLayer{}
+		if err := rows.Scan(&layer.ProductID, &layer.MovementDate, &layer.Quantity, &layer.UnitCost); err != nil {
+			return nil, fmt.Errorf("入庫原価データ読み取りエラー: %v", err)
+		}
+		layers = append(layers, layer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("入庫原価読み取りエラー: %v", err)
+	}
+
+	return layers, nil
+}
+
+// ListProductCosts 指定日時より前に有効だった単価で商品一覧を取得する
+// 単価の履歴がない場合は現在の商品単価を使用する
+func (r *SQLValuationRepository) ListProductCosts(ctx context.Context, before time.Time) ([]*models.ProductCost, error) {
+	query := `
+		SELECT p.id, p.name, p.sku, COALESCE(p.category, ''),
+			COALESCE((
+				SELECT h.price FROM product_price_history h
+				WHERE h.product_id = p.id AND h.effective_from < $1
+				ORDER BY h.effective_from DESC
+				LIMIT 1
+			), p.price)
+		FROM products p
+		ORDER BY p.id`
+
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("商品単価取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var costs []*models.ProductCost
+	for rows.Next() {
+		cost := &models.ProductCost{}
+		if err := rows.Scan(&cost.ProductID, &cost.Name, &cost.SKU, &cost.Category, &cost.Price); err != nil {
+			return nil, fmt.Errorf("商品単価データ読み取りエラー: %v", err)
+		}
+		costs = append(costs, cost)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("商品単価読み取りエラー: %v", err)
+	}
+
+	return costs, nil
+}
+
+// CreateSnapshot 日次スナップショットを作成する
+func (r *SQLValuationRepository) CreateSnapshot(ctx context.Context, snapshot *models.InventorySnapshot) error {
+	query := `
+		INSERT INTO inventory_snapshots (snapshot_date, line_count, created_at)
+		VALUES ($1, $2, $3)`
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, query, snapshotDateOf(snapshot.SnapshotDate), snapshot.LineCount, now)
+	if err != nil {
+		return fmt.Errorf("在庫スナップショット作成エラー: %v", err)
+	}
+
+	snapshot.CreatedAt = now
+	return nil
+}
+
+// CreateSnapshotLine 日次スナップショットの明細を作成する
+func (r *SQLValuationRepository) CreateSnapshotLine(ctx context.Context, snapshotDate time.Time, level *models.StockLevel) error {
+	query := `
+		INSERT INTO inventory_snapshot_lines (snapshot_date, product_id, location, quantity)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, snapshotDateOf(snapshotDate), level.ProductID, level.Location, level.Quantity)
+	if err != nil {
+		return fmt.Errorf("在庫スナップショット明細作成エラー: %v", err)
+	}
+
+	return nil
+}
+
+// GetSnapshot 日次スナップショットを取得する
+func (r *SQLValuationRepository) GetSnapshot(ctx context.Context, snapshotDate time.Time) (*models.InventorySnapshot, error) {
+	query := `
+		SELECT snapshot_date, line_count, created_at
+		FROM inventory_snapshots
+		WHERE snapshot_date = $1`
+
+	snapshot := &models.InventorySnapshot{}
+	err := r.db.QueryRowContext(ctx, query, snapshotDateOf(snapshotDate)).Scan(
+		&snapshot.SnapshotDate,
+		&snapshot.LineCount,
+		&snapshot.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫スナップショット取得エラー: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListSnapshotLines 日次スナップショットの明細を取得する
+func (r *SQLValuationRepository) ListSnapshotLines(ctx context.Context, snapshotDate time.Time) ([]*models.StockLevel, error) {
+	query := `
+		SELECT product_id, location, quantity
+		FROM inventory_snapshot_lines
+		WHERE snapshot_date = $1
+		ORDER BY product_id, location`
+
+	rows, err := r.db.QueryContext(ctx, query, snapshotDateOf(snapshotDate))
+	if err != nil {
+		return nil, fmt.Errorf("在庫スナップショット明細取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	return scanStockLevels(rows)
+}
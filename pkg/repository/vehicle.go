@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 車両リポジトリ
+ * データベースとの配送車両・整備予定関連の操作を管理する
+ */
+
+// VehicleRepository 車両リポジトリインターフェース
+type VehicleRepository interface {
+	CreateVehicle(ctx context.Context, vehicle *models.Vehicle) error
+	GetVehicle(ctx context.Context, id int64) (*models.Vehicle, error)
+	// LockVehicle 車両を取得し、トランザクションの終了まで行をロックする（配送の割当を直列化する）
+	LockVehicle(ctx context.Context, id int64) (*models.Vehicle, error)
+	ListVehicles(ctx context.Context, spec *QuerySpec) ([]*models.Vehicle, *PageInfo, error)
+	UpdateVehicle(ctx context.Context, vehicle *models.Vehicle) error
+
+	// 整備予定
+	CreateMaintenance(ctx context.Context, maintenance *models.VehicleMaintenance) error
+	ListMaintenance(ctx context.Context, vehicleID int64) ([]*models.VehicleMaintenance, error)
+	// ListMaintenanceInPeriod 期間と重なるすべての車両の整備予定を取得する
+	ListMaintenanceInPeriod(ctx context.Context, start, end time.Time) ([]*models.VehicleMaintenance, error)
+}
+
+// vehicleQuery 車両一覧の絞り込み・並べ替えに使用できる項目
+var vehicleQuery = &queryTable{
+	name: "vehicles",
+	fields: map[string]queryField{
+		"id":             {column: "id", typ: fieldInt, filterable: true, sortable: true},
+		"vehicle_number": {column: "vehicle_number", typ: fieldString, filterable: true, sortable: true},
+		"type":           {column: "type", typ: fieldString, filterable: true, sortable: true},
+		"capacity":       {column: "capacity", typ: fieldFloat, filterable: true, sortable: true},
+		"refrigerated":   {column: "refrigerated", typ: fieldString, filterable: true},
+		"status":         {column: "status", typ: fieldString, filterable: true, sortable: true},
+		"created_at":     {column: "created_at", typ: fieldTime, filterable: true, sortable: true},
+	},
+	defaultSort: []SortKey{{Field: "id"}},
+}
+
+// SQLVehicleRepository SQL車両リポジトリ
+type SQLVehicleRepository struct {
+	db DB
+}
+
+// NewSQLVehicleRepository SQL車両リポジトリを作成する
+func NewSQLVehicleRepository(db DB) VehicleRepository {
+	return &SQLVehicleRepository{db: db}
+}
+
+const vehicleColumns = `id, vehicle_number, type, capacity, volume_capacity,
+			refrigerated, status, COALESCE(last_location, ''), created_at, updated_at`
+
+// scanVehicle 車両行を読み取る
+func scanVehicle(scanner rowScanner) (*models.Vehicle, error) {
+	vehicle := &models.Vehicle{}
+	err := scanner.Scan(
+		&vehicle.ID,
+		&vehicle.VehicleNumber,
+		&vehicle.Type,
+		&vehicle.Capacity,
+		&vehicle.VolumeCapacity,
+		&vehicle.Refrigerated,
+		&vehicle.Status,
+		&vehicle.LastLocation,
+		&vehicle.CreatedAt,
+		&vehicle.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+// CreateVehicle 車両を登録する
+func (r *SQLVehicleRepository) CreateVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	query := `
+		INSERT INTO vehicles (
+			vehicle_number, type, capacity, volume_capacity,
+			refrigerated, status, last_location, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		vehicle.VehicleNumber,
+		vehicle.Type,
+		vehicle.Capacity,
+		vehicle.VolumeCapacity,
+		vehicle.Refrigerated,
+		vehicle.Status,
+		vehicle.LastLocation,
+		now,
+	).Scan(&vehicle.ID)
+	if err != nil {
+		return fmt.Errorf("車両登録エラー: %v", err)
+	}
+
+	vehicle.CreatedAt = now
+	vehicle.UpdatedAt = now
+	return nil
+}
+
+// GetVehicle 車両を取得する
+func (r *SQLVehicleRepository) GetVehicle(ctx context.Context, id int64) (*models.Vehicle, error) {
+	return r.getVehicle(ctx, id, "")
+}
+
+// LockVehicle 車両を取得し、トランザクションの終了まで行をロックする
+func (r *SQLVehicleRepository) LockVehicle(ctx context.Context, id int64) (*models.Vehicle, error) {
+	return r.getVehicle(ctx, id, " FOR UPDATE")
+}
+
+func (r *SQLVehicleRepository) getVehicle(ctx context.Context, id int64, lock string) (*models.Vehicle, error) {
+	query := `
+		SELECT ` + vehicleColumns + `
+		FROM vehicles
+		WHERE id = $1` + lock
+
+	vehicle, err := scanVehicle(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("車両取得エラー: %v", err)
+	}
+
+	return vehicle, nil
+}
+
+// ListVehicles 車両一覧を取得する
+func (r *SQLVehicleRepository) ListVehicles(ctx context.Context, spec *QuerySpec) ([]*models.Vehicle, *PageInfo, error) {
+	q, err := vehicleQuery.build(spec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT ` + vehicleColumns + q.cursorColumns() + `
+		FROM vehicles` + q.clauses()
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("車両一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var vehicles []*models.Vehicle
+	for rows.Next() {
+		vehicle, err := scanVehicle(q.scanner(rows))
+		if err != nil {
+			return nil, nil, fmt.Errorf("車両データ読み取りエラー: %v", err)
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("車両一覧読み取りエラー: %v", err)
+	}
+
+	page, n, err := q.page(ctx, r.db, len(vehicles))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return vehicles[:n], page, nil
+}
+
+// UpdateVehicle 車両を更新する
+func (r *SQLVehicleRepository) UpdateVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	query := `
+		UPDATE vehicles
+		SET vehicle_number = $1, type = $2, capacity = $3, volume_capacity = $4,
+			refrigerated = $5, status = $6, last_location = $7, updated_at = $8
+		WHERE id = $9`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		vehicle.VehicleNumber,
+		vehicle.Type,
+		vehicle.Capacity,
+		vehicle.VolumeCapacity,
+		vehicle.Refrigerated,
+		vehicle.Status,
+		vehicle.LastLocation,
+		now,
+		vehicle.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("車両更新エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	vehicle.UpdatedAt = now
+	return nil
+}
+
+const maintenanceColumns = `id, vehicle_id, start_time, end_time, reason, created_at`
+
+// scanMaintenance 整備予定行を読み取る
+func scanMaintenance(scanner rowScanner) (*models.VehicleMaintenance, error) {
+	maintenance := &models.VehicleMaintenance{}
+	err := scanner.Scan(
+		&maintenance.ID,
+		&maintenance.VehicleID,
+		&maintenance.StartTime,
+		&maintenance.EndTime,
+		&maintenance.Reason,
+		&maintenance.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return maintenance, nil
+}
+
+// CreateMaintenance 整備予定を登録する
+func (r *SQLVehicleRepository) CreateMaintenance(ctx context.Context, maintenance *models.VehicleMaintenance) error {
+	query := `
+		INSERT INTO vehicle_maintenance (vehicle_id, start_time, end_time, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		maintenance.VehicleID,
+		maintenance.StartTime,
+		maintenance.EndTime,
+		maintenance.Reason,
+		now,
+	).Scan(&maintenance.ID)
+	if err != nil {
+		return fmt.Errorf("整備予定登録エラー: %v", err)
+	}
+
+	maintenance.CreatedAt = now
+	return nil
+}
+
+// ListMaintenance 車両の整備予定を開始日時の順に取得する
+func (r *SQLVehicleRepository) ListMaintenance(ctx context.Context, vehicleID int64) ([]*models.VehicleMaintenance, error) {
+	query := `
+		SELECT ` + maintenanceColumns + `
+		FROM vehicle_maintenance
+		WHERE vehicle_id = $1
+		ORDER BY start_time, id`
+
+	return r.queryMaintenance(ctx, query, vehicleID)
+}
+
+// ListMaintenanceInPeriod 期間と重なるすべての車両の整備予定を取得する
+func (r *SQLVehicleRepository) ListMaintenanceInPeriod(ctx context.Context, start, end time.Time) ([]*models.VehicleMaintenance, error) {
+	query := `
+		SELECT ` + maintenanceColumns + `
+		FROM vehicle_maintenance
+		WHERE start_time < $2 AND end_time > $1
+		ORDER BY vehicle_id, start_time, id`
+
+	return r.queryMaintenance(ctx, query, start, end)
+}
+
+func (r *SQLVehicleRepository) queryMaintenance(ctx context.Context, query string, args ...interface{}) ([]*models.VehicleMaintenance, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("整備予定取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var list []*models.VehicleMaintenance
+	for rows.Next() {
+		maintenance, err := scanMaintenance(rows)
+		if err != nil {
+			return nil, fmt.Errorf("整備予定データ読み取りエラー: %v", err)
+		}
+		list = append(list, maintenance)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("整備予定読み取りエラー: %v", err)
+	}
+
+	return list, nil
+}
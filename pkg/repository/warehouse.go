@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 倉庫リポジトリ
+ * データベースとの倉庫関連の操作を管理する
+ */
+
+// WarehouseRepository 倉庫リポジトリインターフェース
+type WarehouseRepository interface {
+	CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error
+	GetWarehouse(ctx context.Context, id int64) (*models.Warehouse, error)
+	GetWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error)
+	// LockWarehouseByName 倉庫名から倉庫を取得し、トランザクションの終了まで倉庫の行をロックする
+	LockWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error)
+	ListWarehouses(ctx context.Context) ([]*models.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error
+	DeleteWarehouse(ctx context.Context, id int64) error
+
+	// GetStoredQuantity 倉庫に保管されている在庫数量の合計を取得する
+	GetStoredQuantity(ctx context.Context, id int64) (int, error)
+	// ListStoredQuantities 倉庫ごとの在庫数量の合計を取得する
+	ListStoredQuantities(ctx context.Context) (map[int64]int, error)
+}
+
+// SQLWarehouseRepository SQL倉庫リポジトリ
+type SQLWarehouseRepository struct {
+	db DB
+}
+
+// NewSQLWarehouseRepository SQL倉庫リポジトリを作成する
+func NewSQLWarehouseRepository(db DB) WarehouseRepository {
+	return &SQLWarehouseRepository{db: db}
+}
+
+// scanWarehouse 倉庫行を読み取る
+func scanWarehouse(scanner rowScanner) (*models.Warehouse, error) {
+	warehouse := &models.Warehouse{}
+	err := scanner.Scan(
+		&warehouse.ID,
+		&warehouse.Name,
+		&warehouse.Address,
+		&warehouse.Capacity,
+		&warehouse.Status,
+		&warehouse.Latitude,
+		&warehouse.Longitude,
+		&warehouse.AddressStatus,
+		&warehouse.AddressNote,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// CreateWarehouse 倉庫を作成する
+func (r *SQLWarehouseRepository) CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (
+			name, address, capacity, status,
+			latitude, longitude, address_status, address_note,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id`
+
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		warehouse.Name,
+		warehouse.Address,
+		warehouse.Capacity,
+		warehouse.Status,
+		warehouse.Latitude,
+		warehouse.Longitude,
+		addressStatusOrDefault(warehouse.AddressStatus),
+		warehouse.AddressNote,
+		now,
+	).Scan(&warehouse.ID)
+	if err != nil {
+		return fmt.Errorf("倉庫作成エラー: %v", err)
+	}
+
+	warehouse.AddressStatus = addressStatusOrDefault(warehouse.AddressStatus)
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+// GetWarehouse 倉庫を取得する
+func (r *SQLWarehouseRepository) GetWarehouse(ctx context.Context, id int64) (*models.Warehouse, error) {
+	query := `
+		SELECT id, name, address, capacity, status,
+			latitude, longitude, address_status, address_note,
+			created_at, updated_at
+		FROM warehouses
+		WHERE id = $1`
+
+	warehouse, err := scanWarehouse(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("倉庫取得エラー: %v", err)
+	}
+
+	return warehouse, nil
+}
+
+// GetWarehouseByName 倉庫名から倉庫を取得する
+func (r *SQLWarehouseRepository) GetWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error) {
+	query := `
+		SELECT id, name, address, capacity, status,
+			latitude, longitude, address_status, address_note,
+			created_at, updated_at
+		FROM warehouses
+		WHERE name = $1`
+
+	warehouse, err := scanWarehouse(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("倉庫取得エラー: %v", err)
+	}
+
+	return warehouse, nil
+}
+
+// LockWarehouseByName 倉庫名から倉庫を取得し、倉庫の行をロックする
+// 空き容量の確認から入庫までを直列化するため、トランザクション内で呼び出すこと
+func (r *SQLWarehouseRepository) LockWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error) {
+	query := `
+		SELECT id, name, address, capacity, status,
+			latitude, longitude, address_status, address_note,
+			created_at, updated_at
+		FROM warehouses
+		WHERE name = $1
+		FOR UPDATE`
+
+	warehouse, err := scanWarehouse(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("倉庫ロックエラー: %v", err)
+	}
+
+	return warehouse, nil
+}
+
+// ListWarehouses 倉庫一覧を取得する
+func (r *SQLWarehouseRepository) ListWarehouses(ctx context.Context) ([]*models.Warehouse, error) {
+	query := `
+		SELECT id, name, address, capacity, status,
+			latitude, longitude, address_status, address_note,
+			created_at, updated_at
+		FROM warehouses
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	var warehouses []*models.Warehouse
+	for rows.Next() {
+		warehouse, err := scanWarehouse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("倉庫データ読み取りエラー: %v", err)
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("倉庫一覧読み取りエラー: %v", err)
+	}
+
+	return warehouses, nil
+}
+
+// UpdateWarehouse 倉庫を更新する
+// 倉庫名を変更した場合は、倉庫名をロケーションとして参照している在庫・引当なども同じトランザクション内で新しい倉庫名に合わせる
+func (r *SQLWarehouseRepository) UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	query := `
+		WITH previous AS (
+			SELECT id, name FROM warehouses WHERE id = $8 FOR UPDATE
+		), updated AS (
+			UPDATE warehouses w
+			SET name = $1, address = $2, capacity = $3,
+				status = $4, latitude = $5, longitude = $6, updated_at = $7,
+				address_status = $9, address_note = $10
+			FROM previous p
+			WHERE w.id = p.id
+			RETURNING w.id
+		)
+		SELECT p.name FROM previous p JOIN updated u ON u.id = p.id`
+
+	now := time.Now()
+	var previousName string
+	err := r.db.QueryRowContext(ctx, query,
+		warehouse.Name,
+		warehouse.Address,
+		warehouse.Capacity,
+		warehouse.Status,
+		warehouse.Latitude,
+		warehouse.Longitude,
+		now,
+		warehouse.ID,
+		addressStatusOrDefault(warehouse.AddressStatus),
+		warehouse.AddressNote,
+	).Scan(&previousName)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("倉庫更新エラー: %v", err)
+	}
+
+	if previousName != warehouse.Name {
+		if err := r.renameLocation(ctx, warehouse.ID, previousName, warehouse.Name, now); err != nil {
+			return err
+		}
+	}
+
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+// renameLocation 倉庫名の変更に合わせて、倉庫名をロケーションとして参照している行を新しい倉庫名に書き換える
+// 在庫・引当中の在庫引当・発注点設定・未処理の補充提案・進行中の棚卸は倉庫名で在庫を参照するため書き換え、
+// 在庫移動・在庫元帳・確定済みの引当などの履歴は記録時点の倉庫名のまま残す
+func (r *SQLWarehouseRepository) renameLocation(ctx context.Context, warehouseID int64, from, to string, now time.Time) error {
+	renames := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"在庫", `
+			UPDATE inventory
+			SET location = $1, updated_at = $2, version = version + 1
+			WHERE (warehouse_id = $3 OR location = $4) AND location <> $1`,
+			[]interface{}{to, now, warehouseID, from}},
+		{"在庫引当", `
+			UPDATE stock_reservations
+			SET location = $1, updated_at = $2
+			WHERE location = $3 AND status = $4`,
+			[]interface{}{to, now, from, models.ReservationStatusActive}},
+		{"発注点設定", `
+			UPDATE reorder_settings
+			SET location = $1, updated_at = $2
+			WHERE location = $3`,
+			[]interface{}{to, now, from}},
+		{"補充提案", `
+			UPDATE replenishment_proposals
+			SET location = CASE WHEN location = $3 THEN $1 ELSE location END,
+				source_location = CASE WHEN source_location = $3 THEN $1 ELSE source_location END,
+				updated_at = $2
+			WHERE (location = $3 OR source_location = $3) AND status = $4`,
+			[]interface{}{to, now, from, models.ReplenishmentProposalOpen}},
+		{"棚卸", `
+			UPDATE stock_counts
+			SET location = $1, updated_at = $2
+			WHERE location = $3 AND status IN ($4, $5)`,
+			[]interface{}{to, now, from, models.StockCountStatusOpen, models.StockCountStatusSubmitted}},
+	}
+
+	for _, rename := range renames {
+		if _, err := r.db.ExecContext(ctx, rename.query, rename.args...); err != nil {
+			return fmt.Errorf("%sロケーション更新エラー: %v", rename.name, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteWarehouse 倉庫を削除する
+func (r *SQLWarehouseRepository) DeleteWarehouse(ctx context.Context, id int64) error {
+	query := `DELETE FROM warehouses WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("倉庫削除エラー: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("結果取得エラー: %v", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetStoredQuantity 倉庫に保管されている在庫数量の合計を取得する
+func (r *SQLWarehouseRepository) GetStoredQuantity(ctx context.Context, id int64) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM inventory
+		WHERE warehouse_id = $1`
+
+	var quantity int
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&quantity); err != nil {
+		return 0, fmt.Errorf("倉庫在庫数量取得エラー: %v", err)
+	}
+
+	return quantity, nil
+}
+
+// ListStoredQuantities 倉庫ごとの在庫数量の合計を取得する
+func (r *SQLWarehouseRepository) ListStoredQuantities(ctx context.Context) (map[int64]int, error) {
+	query := `
+		SELECT warehouse_id, COALESCE(SUM(quantity), 0)
+		FROM inventory
+		WHERE warehouse_id IS NOT NULL
+		GROUP BY warehouse_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫在庫数量取得エラー: %v", err)
+	}
+	defer rows.Close()
+
+	quantities := make(map[int64]int)
+	for rows.Next() {
+		var warehouseID int64
+		var quantity int
+		if err := rows.Scan(&warehouseID, &quantity); err != nil {
+			return nil, fmt.Errorf("倉庫在庫数量読み取りエラー: %v", err)
+		}
+		quantities[warehouseID] = quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("倉庫在庫数量読み取りエラー: %v", err)
+	}
+
+	return quantities, nil
+}
+
+// addressStatusOrDefault 住所の確認状態が未設定の場合は未確認とする
+func addressStatusOrDefault(status models.AddressStatus) models.AddressStatus {
+	if status == "" {
+		return models.AddressStatusUnverified
+	}
+	return status
+}
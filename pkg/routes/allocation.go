@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫割当ルーティング
+ * 商品カテゴリごとの在庫割当方式のエンドポイントを定義する
+ */
+
+// SetupAllocationRoutes 在庫割当ルーティングを設定する
+func SetupAllocationRoutes(router *gin.Engine, handler *handlers.AllocationHandler) {
+	// 認証が必要なルートグループ
+	policy := router.Group("/api/v1/allocation-policies")
+	policy.Use(middleware.AuthMiddleware())
+	{
+		// 割当方式一覧の取得（閲覧者以上）
+		policy.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListPolicies)
+
+		// 割当方式の設定（マネージャー以上）
+		policy.PUT("/:category", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.SetPolicy)
+
+		// 割当方式の削除（マネージャー以上）
+		policy.DELETE("/:category", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.DeletePolicy)
+	}
+}
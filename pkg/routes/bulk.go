@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 一括取込・出力ルーティング
+ * 商品・在庫数・保管場所のCSV/Excelファイルの取込・出力のエンドポイントを定義する
+ */
+
+// SetupBulkRoutes 一括取込・出力ルーティングを設定する
+func SetupBulkRoutes(router *gin.Engine, handler *handlers.BulkHandler) {
+	// 認証が必要なルートグループ
+	api := router.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware())
+
+	exportRoles := middleware.RoleAuth(
+		models.RoleViewer,
+		models.RoleOperator,
+		models.RoleManager,
+		models.RoleAdmin,
+	)
+	importRoles := middleware.RoleAuth(
+		models.RoleManager,
+		models.RoleAdmin,
+	)
+	{
+		// 商品の一括取込・出力
+		api.POST("/products/import", importRoles, handler.ImportProducts)
+		api.GET("/products/export", exportRoles, handler.ExportProducts)
+
+		// 在庫数の一括取込・出力
+		api.POST("/inventory/import", importRoles, handler.ImportInventory)
+		api.GET("/inventory/export", exportRoles, handler.ExportInventory)
+
+		// 保管場所（ゾーン・通路・ビン）の一括取込・出力
+		api.POST("/locations/import", importRoles, handler.ImportLocations)
+		api.GET("/locations/export", exportRoles, handler.ExportLocations)
+	}
+}
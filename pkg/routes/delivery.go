@@ -31,9 +31,30 @@ func SetupDeliveryRoutes(router *gin.Engine, handler *handlers.DeliveryHandler)
 	// 配送ステータス更新 (管理者、マネージャー、オペレーター)
 	deliveries.PUT("/:id/status", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.UpdateDeliveryStatus)
 
+	// 配送先住所の修正 (管理者、マネージャー、オペレーター)
+	deliveries.PUT("/:id/address", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.UpdateDeliveryAddress)
+
+	// 配送ステータス履歴取得 (全ロール)
+	deliveries.GET("/:id/history", handler.ListStatusHistory)
+
+	// 配送の在庫割当結果取得 (全ロール)
+	deliveries.GET("/:id/allocations", handler.ListDeliveryAllocations)
+
+	// 配送の出荷重量・容積取得 (全ロール)
+	deliveries.GET("/:id/shipment", handler.GetShipment)
+
 	// 配送完了 (管理者、マネージャー、オペレーター)
 	deliveries.POST("/:id/complete", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.CompleteDelivery)
 
+	// 配送明細キャンセル (管理者、マネージャー)
+	deliveries.POST("/:id/items/:item_id/cancel", middleware.RoleAuth(models.RoleAdmin, models.RoleManager), handler.CancelDeliveryItem)
+
+	// 配送キャンセル (管理者、マネージャー)
+	deliveries.POST("/:id/cancel", middleware.RoleAuth(models.RoleAdmin, models.RoleManager), handler.CancelDelivery)
+
+	// 返品登録 (管理者、マネージャー、オペレーター)
+	deliveries.POST("/:id/returns", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.ReturnDelivery)
+
 	// 配送追跡作成 (管理者、マネージャー、オペレーター)
 	deliveries.POST("/:id/tracking", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.CreateDeliveryTracking)
 
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 運転手ルーティング
+ * 運転手と配送予定表のエンドポイントを定義する
+ */
+
+// SetupDriverRoutes 運転手ルーティングを設定する
+func SetupDriverRoutes(router *gin.Engine, handler *handlers.DriverHandler) {
+	// 認証が必要なルートグループ
+	driver := router.Group("/api/v1/drivers")
+	driver.Use(middleware.AuthMiddleware())
+	{
+		// 運転手一覧の取得（閲覧者以上）
+		driver.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListDrivers)
+
+		// 運転手詳細の取得（閲覧者以上）
+		driver.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetDriver)
+
+		// 配送予定表の取得（閲覧者以上と運転手本人）
+		driver.GET("/:id/manifest", middleware.RoleAuth(
+			models.RoleDriver,
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetManifest)
+
+		// 運転手の登録（マネージャー以上）
+		driver.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateDriver)
+
+		// 運転手の更新（マネージャー以上）
+		driver.PUT("/:id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateDriver)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 到着予定日時ルーティング
+ * 配送の到着予定日時の予測のエンドポイントを定義する
+ */
+
+// SetupETARoutes 到着予定日時ルーティングを設定する
+func SetupETARoutes(router *gin.Engine, handler *handlers.ETAHandler) {
+	// 認証が必要なルート
+	deliveries := router.Group("/api/deliveries")
+	deliveries.Use(middleware.AuthMiddleware())
+
+	// 到着予定日時の再予測 (管理者、マネージャー、オペレーター)
+	deliveries.POST("/:id/eta", middleware.RoleAuth(models.RoleAdmin, models.RoleManager, models.RoleOperator), handler.UpdateDeliveryETA)
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 賞味期限監視ルーティング
+ * 賞味期限の近い在庫と通知日数のエンドポイントを定義する
+ */
+
+// SetupExpiryRoutes 賞味期限監視ルーティングを設定する
+func SetupExpiryRoutes(router *gin.Engine, handler *handlers.ExpiryHandler) {
+	// 認証が必要なルートグループ
+	expiry := router.Group("/api/v1/expiry")
+	expiry.Use(middleware.AuthMiddleware())
+	{
+		// 賞味期限の近い在庫一覧の取得（閲覧者以上）
+		expiry.GET("/stock", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListExpiringStock)
+
+		// 賞味期限の確認の即時実行（マネージャー以上）
+		expiry.POST("/scan", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ScanExpiry)
+
+		// 通知日数一覧の取得（閲覧者以上）
+		expiry.GET("/policies", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListPolicies)
+
+		// 通知日数の設定（マネージャー以上）
+		expiry.PUT("/policies/:category", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.SetPolicy)
+
+		// 通知日数の削除（マネージャー以上）
+		expiry.DELETE("/policies/:category", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.DeletePolicy)
+	}
+}
@@ -27,6 +27,14 @@ func SetupInventoryRoutes(router *gin.Engine, handler *handlers.InventoryHandler
 			models.RoleAdmin,
 		), handler.GetInventory)
 
+		// 在庫一覧の取得（閲覧者以上）
+		inventory.GET("/items", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListInventories)
+
 		// 在庫の更新（オペレーター以上）
 		inventory.PUT("", middleware.RoleAuth(
 			models.RoleOperator,
@@ -55,5 +63,26 @@ func SetupInventoryRoutes(router *gin.Engine, handler *handlers.InventoryHandler
 			models.RoleManager,
 			models.RoleAdmin,
 		), handler.CheckAvailability)
+
+		// 在庫の引当（オペレーター以上）
+		inventory.POST("/reservations", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateReservation)
+
+		// 在庫引当の解放（オペレーター以上）
+		inventory.POST("/reservations/:id/release", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ReleaseReservation)
+
+		// 在庫引当の確定（オペレーター以上）
+		inventory.POST("/reservations/:id/commit", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CommitReservation)
 	}
 }
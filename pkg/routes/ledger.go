@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫元帳ルーティング
+ * 在庫元帳の照会と在庫数の照合のエンドポイントを定義する
+ */
+
+// SetupLedgerRoutes 在庫元帳ルーティングを設定する
+func SetupLedgerRoutes(router *gin.Engine, handler *handlers.LedgerHandler) {
+	// 認証が必要なルートグループ
+	inventory := router.Group("/api/v1/inventory")
+	inventory.Use(middleware.AuthMiddleware())
+	{
+		// 在庫行の記帳一覧の取得（閲覧者以上）
+		inventory.GET("/:id/ledger", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListLedgerEntries)
+
+		// 在庫数と元帳残高の照合（マネージャー以上）
+		inventory.POST("/reconcile", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.Reconcile)
+	}
+}
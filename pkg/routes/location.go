@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * ロケーションルーティング
+ * ゾーン・通路・ビンとロケーション在庫集計のエンドポイントを定義する
+ */
+
+// SetupLocationRoutes ロケーションルーティングを設定する
+func SetupLocationRoutes(router *gin.Engine, handler *handlers.LocationHandler) {
+	// 認証が必要なルートグループ
+	location := router.Group("/api/v1/locations")
+	location.Use(middleware.AuthMiddleware())
+
+	readRoles := middleware.RoleAuth(
+		models.RoleViewer,
+		models.RoleOperator,
+		models.RoleManager,
+		models.RoleAdmin,
+	)
+	writeRoles := middleware.RoleAuth(
+		models.RoleManager,
+		models.RoleAdmin,
+	)
+	{
+		// ロケーション階層での在庫集計（閲覧者以上）
+		location.GET("/stock", readRoles, handler.GetLocationStock)
+
+		// ゾーン（閲覧はすべてのロール、作成・更新はマネージャー以上）
+		location.GET("/zones", readRoles, handler.ListZones)
+		location.GET("/zones/:id", readRoles, handler.GetZone)
+		location.POST("/zones", writeRoles, handler.CreateZone)
+		location.PUT("/zones/:id/categories", writeRoles, handler.UpdateZoneCategories)
+
+		// 通路
+		location.GET("/aisles", readRoles, handler.ListAisles)
+		location.POST("/aisles", writeRoles, handler.CreateAisle)
+
+		// ビン
+		location.GET("/bins", readRoles, handler.ListBins)
+		location.GET("/bins/:id", readRoles, handler.GetBinLocation)
+		location.POST("/bins", writeRoles, handler.CreateBin)
+	}
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * ロットルーティング
+ * 収穫ロットとトレーサビリティのエンドポイントを定義する
+ */
+
+// SetupLotRoutes ロットルーティングを設定する
+func SetupLotRoutes(router *gin.Engine, handler *handlers.LotHandler) {
+	// 認証が必要なルートグループ
+	lot := router.Group("/api/v1/lots")
+	lot.Use(middleware.AuthMiddleware())
+	{
+		// ロット一覧の取得（閲覧者以上）
+		lot.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListLots)
+
+		// ロット詳細の取得（閲覧者以上）
+		lot.GET("/:lot", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetLot)
+
+		// ロットのトレーサビリティ（閲覧者以上）
+		lot.GET("/:lot/trace", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.TraceLot)
+
+		// ロットの登録（オペレーター以上）
+		lot.POST("", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateLot)
+	}
+}
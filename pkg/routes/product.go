@@ -27,6 +27,14 @@ func SetupProductRoutes(router *gin.Engine, handler *handlers.ProductHandler) {
 			models.RoleAdmin,
 		), handler.ListProducts)
 
+		// 商品の検索（閲覧者以上）
+		product.GET("/search", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.SearchProducts)
+
 		// 商品詳細の取得（閲覧者以上）
 		product.GET("/:id", middleware.RoleAuth(
 			models.RoleViewer,
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 荷姿ルーティング
+ * 商品の荷姿と単位換算のエンドポイントを定義する
+ */
+
+// SetupProductVariantRoutes 荷姿ルーティングを設定する
+func SetupProductVariantRoutes(router *gin.Engine, handler *handlers.ProductVariantHandler) {
+	// 認証が必要なルートグループ
+	product := router.Group("/api/v1/products/:id")
+	product.Use(middleware.AuthMiddleware())
+	{
+		// 荷姿一覧の取得（閲覧者以上）
+		product.GET("/variants", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListVariants)
+
+		// 単位換算（閲覧者以上）
+		product.GET("/convert", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ConvertQuantity)
+
+		// 荷姿の作成（マネージャー以上）
+		product.POST("/variants", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateVariant)
+
+		// 荷姿の更新（マネージャー以上）
+		product.PUT("/variants/:variant_id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateVariant)
+
+		// 荷姿の削除（マネージャー以上）
+		product.DELETE("/variants/:variant_id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.DeleteVariant)
+	}
+}
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 発注・入荷ルーティング
+ * 仕入先・発注書と入荷のエンドポイントを定義する
+ */
+
+// SetupPurchaseOrderRoutes 発注・入荷ルーティングを設定する
+func SetupPurchaseOrderRoutes(router *gin.Engine, handler *handlers.PurchaseOrderHandler) {
+	// 認証が必要なルートグループ
+	supplier := router.Group("/api/v1/suppliers")
+	supplier.Use(middleware.AuthMiddleware())
+	{
+		// 仕入先一覧の取得（閲覧者以上）
+		supplier.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListSuppliers)
+
+		// 仕入先の取得（閲覧者以上）
+		supplier.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetSupplier)
+
+		// 仕入先の登録（マネージャー以上）
+		supplier.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateSupplier)
+
+		// 仕入先の更新（マネージャー以上）
+		supplier.PUT("/:id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateSupplier)
+	}
+
+	order := router.Group("/api/v1/purchase-orders")
+	order.Use(middleware.AuthMiddleware())
+	{
+		// 発注書一覧の取得（閲覧者以上）
+		order.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListPurchaseOrders)
+
+		// 発注書の取得（閲覧者以上）
+		order.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetPurchaseOrder)
+
+		// 発注書の作成（マネージャー以上）
+		order.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreatePurchaseOrder)
+
+		// 入荷（オペレーター以上）
+		order.POST("/:id/receive", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ReceivePurchaseOrder)
+
+		// 発注のキャンセル（マネージャー以上）
+		order.POST("/:id/cancel", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CancelPurchaseOrder)
+
+		// 一部入荷の発注の完了（マネージャー以上）
+		order.POST("/:id/close", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ClosePurchaseOrder)
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫補充ルーティング
+ * 発注点設定と在庫補充提案のエンドポイントを定義する
+ */
+
+// SetupReplenishmentRoutes 在庫補充ルーティングを設定する
+func SetupReplenishmentRoutes(router *gin.Engine, handler *handlers.ReplenishmentHandler) {
+	// 認証が必要なルートグループ
+	replenishment := router.Group("/api/v1/replenishment")
+	replenishment.Use(middleware.AuthMiddleware())
+	{
+		// 発注点設定一覧の取得（閲覧者以上）
+		replenishment.GET("/settings", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListSettings)
+
+		// 発注点の設定（マネージャー以上）
+		replenishment.PUT("/settings", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.SetSetting)
+
+		// 発注点設定の削除（マネージャー以上）
+		replenishment.DELETE("/settings/:id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.DeleteSetting)
+
+		// 在庫補充提案一覧の取得（閲覧者以上）
+		replenishment.GET("/proposals", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListProposals)
+
+		// 在庫補充提案の採用・見送り（マネージャー以上）
+		replenishment.PUT("/proposals/:id/status", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateProposalStatus)
+
+		// 発注点による在庫評価の即時実行（マネージャー以上）
+		replenishment.POST("/evaluate", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.Evaluate)
+	}
+}
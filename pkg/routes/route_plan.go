@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 配送ルートルーティング
+ * 車両の1日の配送ルートのエンドポイントを定義する
+ */
+
+// SetupRoutePlanRoutes 配送ルートルーティングを設定する
+func SetupRoutePlanRoutes(router *gin.Engine, handler *handlers.RouteHandler) {
+	// 認証が必要なルートグループ
+	route := router.Group("/api/v1/vehicles")
+	route.Use(middleware.AuthMiddleware())
+	{
+		// 配送ルートの取得（閲覧者以上）
+		route.GET("/:id/route", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetVehicleRoute)
+
+		// 配送ルートの再計算（マネージャー以上）
+		route.POST("/:id/route", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.PlanVehicleRoute)
+	}
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 配送スケジュールルーティング
+ * 配送への運転手・車両の割当のエンドポイントを定義する
+ */
+
+// SetupScheduleRoutes 配送スケジュールルーティングを設定する
+func SetupScheduleRoutes(router *gin.Engine, handler *handlers.ScheduleHandler) {
+	// 認証が必要なルートグループ
+	schedule := router.Group("/api/v1/schedules")
+	schedule.Use(middleware.AuthMiddleware())
+	{
+		// 配送スケジュールの取得（閲覧者以上）
+		schedule.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetSchedule)
+
+		// 配送への運転手・車両の割当（マネージャー以上）
+		schedule.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ScheduleDelivery)
+	}
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 棚卸ルーティング
+ * 棚卸の開始・実数入力・承認のエンドポイントを定義する
+ */
+
+// SetupStockCountRoutes 棚卸ルーティングを設定する
+func SetupStockCountRoutes(router *gin.Engine, handler *handlers.StockCountHandler) {
+	// 認証が必要なルートグループ
+	count := router.Group("/api/v1/stock-counts")
+	count.Use(middleware.AuthMiddleware())
+	{
+		// 棚卸一覧の取得（閲覧者以上）
+		count.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListStockCounts)
+
+		// 棚卸の取得（閲覧者以上）
+		count.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetStockCount)
+
+		// 棚卸の開始（オペレーター以上）
+		count.POST("", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.StartStockCount)
+
+		// 実数の入力（オペレーター以上）
+		count.POST("/:id/counts", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.RecordCounts)
+
+		// 棚卸の提出（オペレーター以上）
+		count.POST("/:id/submit", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.SubmitStockCount)
+
+		// 棚卸の承認（マネージャー以上）
+		count.POST("/:id/approve", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ApproveStockCount)
+
+		// 棚卸の却下（マネージャー以上）
+		count.POST("/:id/reject", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.RejectStockCount)
+
+		// 棚卸の中止（マネージャー以上）
+		count.POST("/:id/cancel", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CancelStockCount)
+	}
+}
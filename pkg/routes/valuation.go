@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 在庫評価ルーティング
+ * 評価日時点の在庫照会・在庫評価・日次スナップショットのエンドポイントを定義する
+ */
+
+// SetupValuationRoutes 在庫評価ルーティングを設定する
+func SetupValuationRoutes(router *gin.Engine, handler *handlers.ValuationHandler) {
+	// 認証が必要なルートグループ
+	inventory := router.Group("/api/v1/inventory")
+	inventory.Use(middleware.AuthMiddleware())
+	{
+		// 評価日時点の在庫の取得（閲覧者以上）
+		inventory.GET("/as-of", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetStockAsOf)
+
+		// 在庫評価の取得・CSV出力（マネージャー以上）
+		inventory.GET("/valuation", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetValuation)
+
+		// 日次スナップショットの作成（マネージャー以上）
+		inventory.POST("/snapshots", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.TakeSnapshot)
+	}
+}
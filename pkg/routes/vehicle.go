@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 車両ルーティング
+ * 配送車両・整備予定・配送の割当のエンドポイントを定義する
+ */
+
+// SetupVehicleRoutes 車両ルーティングを設定する
+func SetupVehicleRoutes(router *gin.Engine, handler *handlers.VehicleHandler) {
+	// 認証が必要なルートグループ
+	vehicle := router.Group("/api/v1/vehicles")
+	vehicle.Use(middleware.AuthMiddleware())
+	{
+		// 車両一覧の取得（閲覧者以上）
+		vehicle.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListVehicles)
+
+		// 稼働可能な車両の取得（閲覧者以上）
+		vehicle.GET("/available", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListAvailableVehicles)
+
+		// 車両詳細の取得（閲覧者以上）
+		vehicle.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetVehicle)
+
+		// 車両の配送履歴の取得（閲覧者以上）
+		vehicle.GET("/:id/history", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetVehicleHistory)
+
+		// 整備予定の取得（閲覧者以上）
+		vehicle.GET("/:id/maintenance", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListMaintenance)
+
+		// 車両の登録（マネージャー以上）
+		vehicle.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateVehicle)
+
+		// 車両の更新（マネージャー以上）
+		vehicle.PUT("/:id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateVehicle)
+
+		// 車両ステータスの更新（オペレーター以上）
+		vehicle.PUT("/:id/status", middleware.RoleAuth(
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateVehicleStatus)
+
+		// 整備予定の登録（マネージャー以上）
+		vehicle.POST("/:id/maintenance", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateMaintenance)
+
+		// 配送の割当（マネージャー以上）
+		vehicle.POST("/:id/assignments", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.AssignDelivery)
+
+		// 配送の割当の取消（マネージャー以上）
+		vehicle.DELETE("/:id/assignments/:schedule_id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CancelAssignment)
+	}
+}
@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"tea-logistics/pkg/handlers"
+	"tea-logistics/pkg/middleware"
+	"tea-logistics/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+ * 倉庫ルーティング
+ * 倉庫関連のエンドポイントを定義する
+ */
+
+// SetupWarehouseRoutes 倉庫ルーティングを設定する
+func SetupWarehouseRoutes(router *gin.Engine, handler *handlers.WarehouseHandler) {
+	// 認証が必要なルートグループ
+	warehouse := router.Group("/api/v1/warehouses")
+	warehouse.Use(middleware.AuthMiddleware())
+	{
+		// 倉庫一覧の取得（閲覧者以上）
+		warehouse.GET("", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListWarehouses)
+
+		// 全倉庫の使用率レポート（閲覧者以上）
+		warehouse.GET("/utilization", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.ListUtilization)
+
+		// 倉庫詳細の取得（閲覧者以上）
+		warehouse.GET("/:id", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetWarehouse)
+
+		// 倉庫の使用率（閲覧者以上）
+		warehouse.GET("/:id/utilization", middleware.RoleAuth(
+			models.RoleViewer,
+			models.RoleOperator,
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.GetUtilization)
+
+		// 倉庫の作成（マネージャー以上）
+		warehouse.POST("", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.CreateWarehouse)
+
+		// 倉庫の更新（マネージャー以上）
+		warehouse.PUT("/:id", middleware.RoleAuth(
+			models.RoleManager,
+			models.RoleAdmin,
+		), handler.UpdateWarehouse)
+
+		// 倉庫の削除（管理者のみ）
+		warehouse.DELETE("/:id", middleware.RoleAuth(
+			models.RoleAdmin,
+		), handler.DeleteWarehouse)
+	}
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 在庫割当サービス
+ * 配送明細へ割り当てる在庫（倉庫・ロット）の選定方式と、商品カテゴリごとの割当方式の設定を実装する
+ */
+
+// DefaultAllocationStrategy 割当方式が設定されていないカテゴリの既定の割当方式
+const DefaultAllocationStrategy = models.AllocationStrategyFEFO
+
+// ErrAllocationPolicyNotFound 割当方式が設定されていない場合のエラー
+var ErrAllocationPolicyNotFound = errors.New("割当方式が設定されていません")
+
+// AllocationCandidate 在庫割当の候補
+// 倉庫内の同一ロット（またはロット管理していない在庫）を1件の候補とする
+type AllocationCandidate struct {
+	WarehouseID int64
+	Location    string
+	Lot         *models.Lot
+	// Available 引当可能数（在庫数 - 引当中数量）
+	Available int
+	// ReceivedAt 候補の在庫行のうち最も古い入庫日時
+	ReceivedAt time.Time
+	// DistanceKm 出荷元倉庫からの距離（位置情報がない場合は+Inf）
+	DistanceKm float64
+
+	// stock 候補の倉庫の販売可能在庫（引当時に倉庫全体の引当可能数を確認するため、ロットで絞り込まない）
+	stock *locationStock
+}
+
+// LotID 候補のロットIDを返す（ロット管理していない在庫の場合はnil）
+func (c *AllocationCandidate) LotID() *int64 {
+	return lotIDOf(c.Lot)
+}
+
+// AllocationStrategy 在庫割当方式
+// 割当候補を割当順に並べ替え、先頭から順に必要数量を割り当てる
+type AllocationStrategy interface {
+	// Type 割当方式の種類を返す
+	Type() models.AllocationStrategyType
+	// CrossWarehouse 出荷元倉庫以外の倉庫の在庫も割当候補とするかどうかを返す
+	CrossWarehouse() bool
+	// Rank 割当候補を割当順に並べ替える
+	Rank(candidates []*AllocationCandidate)
+}
+
+// NewAllocationStrategy 割当方式の種類に対応する割当方式を作成する
+func NewAllocationStrategy(strategyType models.AllocationStrategyType) (AllocationStrategy, error) {
+	switch strategyType {
+	case models.AllocationStrategyFIFO:
+		return fifoStrategy{}, nil
+	case models.AllocationStrategyFEFO:
+		return fefoStrategy{}, nil
+	case models.AllocationStrategyNearestWarehouse:
+		return nearestWarehouseStrategy{}, nil
+	}
+	return nil, fmt.Errorf("無効な割当方式です: %s", strategyType)
+}
+
+// fifoStrategy 先入れ先出し（入庫の古い在庫から割り当てる）
+type fifoStrategy struct{}
+
+func (fifoStrategy) Type() models.AllocationStrategyType { return models.AllocationStrategyFIFO }
+
+func (fifoStrategy) CrossWarehouse() bool { return false }
+
+func (fifoStrategy) Rank(candidates []*AllocationCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return receivedBefore(candidates[i], candidates[j])
+	})
+}
+
+// fefoStrategy 先に期限の来るものから出荷（賞味期限の近いロットから割り当てる）
+// ロット管理していない在庫は賞味期限が不明なため最後に割り当てる
+type fefoStrategy struct{}
+
+func (fefoStrategy) Type() models.AllocationStrategyType { return models.AllocationStrategyFEFO }
+
+func (fefoStrategy) CrossWarehouse() bool { return false }
+
+func (fefoStrategy) Rank(candidates []*AllocationCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return expiresBefore(candidates[i], candidates[j])
+	})
+}
+
+// nearestWarehouseStrategy 出荷元倉庫から近い倉庫の在庫から割り当てる
+// 出荷元倉庫の在庫が不足する場合は、稼働中の他倉庫の在庫を近い順に割り当てる
+// 同じ倉庫内では賞味期限の近いロットから割り当てる
+type nearestWarehouseStrategy struct{}
+
+func (nearestWarehouseStrategy) Type() models.AllocationStrategyType {
+	return models.AllocationStrategyNearestWarehouse
+}
+
+func (nearestWarehouseStrategy) CrossWarehouse() bool { return true }
+
+func (nearestWarehouseStrategy) Rank(candidates []*AllocationCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.DistanceKm != b.DistanceKm {
+			return a.DistanceKm < b.DistanceKm
+		}
+		return expiresBefore(a, b)
+	})
+}
+
+// receivedBefore 候補aの入庫日時が候補bより古いかどうかを判定する
+func receivedBefore(a, b *AllocationCandidate) bool {
+	return a.ReceivedAt.Before(b.ReceivedAt)
+}
+
+// expiresBefore 候補aの賞味期限が候補bより早いかどうかを判定する（同じ場合は入庫日時で比較する）
+func expiresBefore(a, b *AllocationCandidate) bool {
+	switch {
+	case a.Lot == nil && b.Lot == nil:
+		return receivedBefore(a, b)
+	case a.Lot == nil:
+		return false
+	case b.Lot == nil:
+		return true
+	}
+	if !a.Lot.BestBeforeDate.Equal(b.Lot.BestBeforeDate) {
+		return a.Lot.BestBeforeDate.Before(b.Lot.BestBeforeDate)
+	}
+	return receivedBefore(a, b)
+}
+
+// allocationPick 割当候補からの割当数量
+type allocationPick struct {
+	Candidate *AllocationCandidate
+	Quantity  int
+}
+
+// allocationStrategyFor トランザクション内で商品のカテゴリに設定された割当方式を取得する
+// 割当方式が設定されていない場合は既定の割当方式を返す
+func allocationStrategyFor(ctx context.Context, tx *repository.TxRepositories, productID int64) (AllocationStrategy, error) {
+	policy, err := tx.Allocations.GetPolicyForProduct(ctx, productID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return NewAllocationStrategy(DefaultAllocationStrategy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("割当方式取得エラー: %v", err)
+	}
+
+	return NewAllocationStrategy(policy.Strategy)
+}
+
+// allocationWarehouses トランザクション内で割当方式の割当候補とする倉庫を取得する
+// 出荷元倉庫を先頭とし、倉庫をまたいで割り当てる割当方式の場合は稼働中の他倉庫を続ける
+func allocationWarehouses(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	strategy AllocationStrategy,
+	origin *models.Warehouse,
+) ([]*models.Warehouse, error) {
+	warehouses := []*models.Warehouse{origin}
+	if !strategy.CrossWarehouse() {
+		return warehouses, nil
+	}
+
+	all, err := tx.Warehouses.ListWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+	for _, w := range all {
+		if w.ID != origin.ID && w.Status == models.WarehouseStatusActive {
+			warehouses = append(warehouses, w)
+		}
+	}
+	return warehouses, nil
+}
+
+// allocateStock トランザクション内で割当方式に従って商品の在庫を割り当てる
+// warehousesはallocationWarehousesで取得した割当候補の倉庫で、各倉庫の在庫はlockStocksでロックしておくこと
+// lotを指定した場合は指定ロットの在庫のみを割当候補とする
+// 割当候補の引当可能数の合計が不足している場合はエラーを返す
+func allocateStock(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	strategy AllocationStrategy,
+	origin *models.Warehouse,
+	warehouses []*models.Warehouse,
+	productID int64,
+	lot *models.Lot,
+	quantity int,
+) ([]allocationPick, error) {
+	candidates, err := loadAllocationCandidates(ctx, tx, origin, warehouses, productID, lot)
+	if err != nil {
+		return nil, err
+	}
+	strategy.Rank(candidates)
+
+	var picks []allocationPick
+	remaining := quantity
+	for _, candidate := range candidates {
+		if remaining == 0 {
+			break
+		}
+		take := candidate.Available
+		if take > remaining {
+			take = remaining
+		}
+		picks = append(picks, allocationPick{Candidate: candidate, Quantity: take})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		logger.Warn("割当可能数不足", map[string]interface{}{
+			"product_id": productID,
+			"strategy":   strategy.Type(),
+			"required":   quantity,
+			"shortage":   remaining,
+		})
+		return nil, &models.InsufficientStockError{
+			ProductID: productID,
+			LotID:     lotIDOf(lot),
+			Required:  quantity,
+			Available: quantity - remaining,
+		}
+	}
+
+	return picks, nil
+}
+
+// loadAllocationCandidates トランザクション内で商品の割当候補を取得する
+// 賞味期限を過ぎたロットと引当可能数のない候補は含めない
+func loadAllocationCandidates(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	origin *models.Warehouse,
+	warehouses []*models.Warehouse,
+	productID int64,
+	lot *models.Lot,
+) ([]*AllocationCandidate, error) {
+	lots := make(map[int64]*models.Lot)
+	if lot != nil {
+		lots[lot.ID] = lot
+	}
+
+	now := time.Now()
+	var candidates []*AllocationCandidate
+	for _, warehouse := range warehouses {
+		stock, err := loadLocationStock(ctx, tx.Inventory, productID, warehouse.Name)
+		if errors.Is(err, errStockNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found, err := warehouseCandidates(ctx, tx, stock, lots, lot)
+		if err != nil {
+			return nil, err
+		}
+
+		distance := warehouseDistance(origin, warehouse)
+		for _, candidate := range found {
+			if candidate.Lot != nil && candidate.Lot.IsExpired(now) {
+				continue
+			}
+			if candidate.Available <= 0 {
+				continue
+			}
+			candidate.WarehouseID = warehouse.ID
+			candidate.DistanceKm = distance
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+// warehouseCandidates 倉庫内の在庫をロットごとの割当候補にまとめ、引当可能数を算出する
+// ロット管理していない在庫の引当中数量は、倉庫全体の引当中数量からロット指定の引当中数量を除いたものとする
+func warehouseCandidates(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	stock *locationStock,
+	lots map[int64]*models.Lot,
+	only *models.Lot,
+) ([]*AllocationCandidate, error) {
+	var candidates []*AllocationCandidate
+	byLot := make(map[int64]*AllocationCandidate)
+	var unlotted *AllocationCandidate
+	for _, row := range stock.Rows {
+		var candidate *AllocationCandidate
+		if row.LotID == nil {
+			candidate = unlotted
+		} else {
+			candidate = byLot[*row.LotID]
+		}
+
+		if candidate == nil {
+			candidate = &AllocationCandidate{
+				Location:   stock.Location,
+				ReceivedAt: row.CreatedAt,
+				stock:      stock,
+			}
+			if row.LotID == nil {
+				unlotted = candidate
+			} else {
+				byLot[*row.LotID] = candidate
+				lot, err := lotByID(ctx, tx, lots, *row.LotID)
+				if err != nil {
+					return nil, err
+				}
+				candidate.Lot = lot
+			}
+			candidates = append(candidates, candidate)
+		}
+		if row.CreatedAt.Before(candidate.ReceivedAt) {
+			candidate.ReceivedAt = row.CreatedAt
+		}
+	}
+
+	reserved, err := tx.Reservations.SumActiveReserved(ctx, stock.ProductID, stock.Location)
+	if err != nil {
+		return nil, fmt.Errorf("引当数量取得エラー: %v", err)
+	}
+
+	lotReservedTotal := 0
+	for _, candidate := range candidates {
+		if candidate.Lot == nil {
+			continue
+		}
+		lotReserved, err := tx.Reservations.SumActiveReservedByLot(ctx, candidate.Lot.ID, stock.Location)
+		if err != nil {
+			return nil, fmt.Errorf("ロット引当数量取得エラー: %v", err)
+		}
+		lotReservedTotal += lotReserved
+		candidate.Available = stock.ForLot(&candidate.Lot.ID).OnHand() - lotReserved
+	}
+	if unlotted != nil {
+		unlotted.Available = stock.ForLot(nil).OnHand() - (reserved - lotReservedTotal)
+	}
+
+	// 倉庫全体の引当可能数を超えて割り当てないようにする
+	available := stock.OnHand() - reserved
+	var result []*AllocationCandidate
+	for _, candidate := range candidates {
+		if only != nil && !sameID(candidate.LotID(), &only.ID) {
+			continue
+		}
+		if candidate.Available > available {
+			candidate.Available = available
+		}
+		result = append(result, candidate)
+	}
+
+	return result, nil
+}
+
+// lotByID トランザクション内でロットを取得する（取得済みのロットは再取得しない）
+func lotByID(ctx context.Context, tx *repository.TxRepositories, lots map[int64]*models.Lot, lotID int64) (*models.Lot, error) {
+	if lot, ok := lots[lotID]; ok {
+		return lot, nil
+	}
+
+	lot, err := tx.Lots.GetLot(ctx, lotID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット取得エラー: %v", err)
+	}
+	lots[lotID] = lot
+	return lot, nil
+}
+
+// warehouseDistance 出荷元倉庫から倉庫までの距離を返す
+// 出荷元倉庫自身は0、いずれかの位置情報がない場合は+Infとする
+func warehouseDistance(origin, warehouse *models.Warehouse) float64 {
+	if origin.ID == warehouse.ID {
+		return 0
+	}
+	if !origin.HasCoordinates() || !warehouse.HasCoordinates() {
+		return math.Inf(1)
+	}
+	return geo.DistanceKm(
+		geo.Point{Latitude: *origin.Latitude, Longitude: *origin.Longitude},
+		geo.Point{Latitude: *warehouse.Latitude, Longitude: *warehouse.Longitude},
+	)
+}
+
+// AllocationService 在庫割当方式サービス
+type AllocationService struct {
+	repo repository.AllocationRepository
+}
+
+// NewAllocationService 在庫割当方式サービスを作成する
+func NewAllocationService(repo repository.AllocationRepository) *AllocationService {
+	return &AllocationService{repo: repo}
+}
+
+// ListPolicies 商品カテゴリごとの割当方式一覧を取得する
+func (s *AllocationService) ListPolicies(ctx context.Context) ([]*models.AllocationPolicy, error) {
+	policies, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("割当方式一覧取得エラー: %v", err)
+	}
+
+	return policies, nil
+}
+
+// SetPolicy 商品カテゴリの割当方式を設定する
+func (s *AllocationService) SetPolicy(ctx context.Context, category string, req *models.SetAllocationPolicyRequest) (*models.AllocationPolicy, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, fmt.Errorf("商品カテゴリを指定してください")
+	}
+	if !req.Strategy.IsValid() {
+		return nil, fmt.Errorf("無効な割当方式です: %s", req.Strategy)
+	}
+
+	policy := &models.AllocationPolicy{
+		Category: category,
+		Strategy: req.Strategy,
+	}
+	if err := s.repo.SetPolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("割当方式設定エラー: %v", err)
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy 商品カテゴリの割当方式を削除する（既定の割当方式に戻す）
+func (s *AllocationService) DeletePolicy(ctx context.Context, category string) error {
+	err := s.repo.DeletePolicy(ctx, category)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAllocationPolicyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("割当方式削除エラー: %v", err)
+	}
+
+	return nil
+}
+
+// reduceDeliveryAllocations トランザクション内で配送明細の在庫割当結果をキャンセル数量分減らす
+// 在庫引当と同じく後から割り当てた割当結果から順に減らし、数量が0になった割当結果は削除する
+func reduceDeliveryAllocations(ctx context.Context, tx *repository.TxRepositories, deliveryID, itemID int64, quantity int) error {
+	allocations, err := tx.Allocations.ListDeliveryAllocations(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("在庫割当取得エラー: %v", err)
+	}
+
+	for j := len(allocations) - 1; j >= 0 && quantity > 0; j-- {
+		allocation := allocations[j]
+		if allocation.DeliveryItemID != itemID {
+			continue
+		}
+		if quantity >= allocation.Quantity {
+			if err := tx.Allocations.DeleteDeliveryAllocation(ctx, allocation.ID); err != nil {
+				return fmt.Errorf("在庫割当削除エラー: %v", err)
+			}
+			quantity -= allocation.Quantity
+			continue
+		}
+		if err := tx.Allocations.UpdateDeliveryAllocationQuantity(ctx, allocation.ID, allocation.Quantity-quantity); err != nil {
+			return fmt.Errorf("在庫割当数量更新エラー: %v", err)
+		}
+		allocation.Quantity -= quantity
+		quantity = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 在庫割当サービステスト
+ */
+
+func TestCreateDelivery_FEFOSplitsAcrossLots(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLotRepo := new(mocks.MockLotRepository)
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Lots:         mockLotRepo,
+		Allocations:  mockAllocationRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+	service := NewDeliveryService(mockRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
+
+	ctx := context.Background()
+	now := time.Now()
+	earlyLotID, lateLotID := int64(3), int64(4)
+	earlyLot := &models.Lot{ID: earlyLotID, LotNumber: "SZ-2026-01", ProductID: 1, BestBeforeDate: now.AddDate(0, 2, 0)}
+	lateLot := &models.Lot{ID: lateLotID, LotNumber: "SZ-2026-02", ProductID: 1, BestBeforeDate: now.AddDate(1, 0, 0)}
+	// 入庫順では賞味期限の遅いロットが先だが、賞味期限の近いロットから割り当てる
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 50, Location: "東京倉庫", LotID: &lateLotID, Status: models.InventoryStatusAvailable, CreatedAt: now.AddDate(0, -2, 0)},
+		{ID: 2, ProductID: 1, Quantity: 20, Location: "東京倉庫", LotID: &earlyLotID, Status: models.InventoryStatusAvailable, CreatedAt: now.AddDate(0, -1, 0)},
+	}
+
+	mockWarehouseRepo.On("GetWarehouse", ctx, int64(1)).Return(&models.Warehouse{ID: 1, Name: "東京倉庫"}, nil)
+	mockAllocationRepo.On("GetPolicyForProduct", ctx, int64(1)).Return(&models.AllocationPolicy{Category: "煎茶", Strategy: models.AllocationStrategyFEFO}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories, nil)
+	mockLotRepo.On("GetLot", ctx, lateLotID).Return(lateLot, nil)
+	mockLotRepo.On("GetLot", ctx, earlyLotID).Return(earlyLot, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	mockReservationRepo.On("SumActiveReservedByLot", ctx, mock.Anything, "東京倉庫").Return(0, nil)
+	mockRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	mockReservationRepo.On("CreateReservation", ctx, mock.MatchedBy(func(r *models.Reservation) bool {
+		return sameID(r.LotID, &earlyLotID) && r.Quantity == 20
+	})).Return(nil).Once()
+	mockReservationRepo.On("CreateReservation", ctx, mock.MatchedBy(func(r *models.Reservation) bool {
+		return sameID(r.LotID, &lateLotID) && r.Quantity == 10
+	})).Return(nil).Once()
+	mockAllocationRepo.On("CreateDeliveryAllocation", ctx, mock.AnythingOfType("*models.DeliveryAllocation")).Return(nil).Twice()
+	mockNotifyService.On("NotifyDeliveryStatusChange", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+
+	delivery, err := service.CreateDelivery(ctx, &models.CreateDeliveryRequest{
+		OrderID:         1,
+		Items:           []models.CreateDeliveryItemRequest{{ProductID: 1, Quantity: 30}},
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   now.Add(24 * time.Hour),
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, delivery.Allocations, 2) {
+		assert.Equal(t, &earlyLotID, delivery.Allocations[0].LotID)
+		assert.Equal(t, 20, delivery.Allocations[0].Quantity)
+		assert.Equal(t, &lateLotID, delivery.Allocations[1].LotID)
+		assert.Equal(t, 10, delivery.Allocations[1].Quantity)
+		assert.Equal(t, models.AllocationStrategyFEFO, delivery.Allocations[1].Strategy)
+	}
+	mockReservationRepo.AssertExpectations(t)
+	mockAllocationRepo.AssertExpectations(t)
+}
+
+func TestAllocateStock_NearestWarehouseSpillsOver(t *testing.T) {
+	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	tx := &repository.TxRepositories{
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+	}
+
+	ctx := context.Background()
+	coords := func(lat, lng float64) (*float64, *float64) { return &lat, &lng }
+	tokyoLat, tokyoLng := coords(35.68, 139.76)
+	yokohamaLat, yokohamaLng := coords(35.44, 139.64)
+	shizuokaLat, shizuokaLng := coords(34.97, 138.38)
+	tokyo := &models.Warehouse{ID: 1, Name: "東京倉庫", Status: models.WarehouseStatusActive, Latitude: tokyoLat, Longitude: tokyoLng}
+	shizuoka := &models.Warehouse{ID: 2, Name: "静岡倉庫", Status: models.WarehouseStatusActive, Latitude: shizuokaLat, Longitude: shizuokaLng}
+	yokohama := &models.Warehouse{ID: 3, Name: "横浜倉庫", Status: models.WarehouseStatusActive, Latitude: yokohamaLat, Longitude: yokohamaLng}
+	closed := &models.Warehouse{ID: 4, Name: "閉鎖倉庫", Status: models.WarehouseStatusInactive}
+
+	mockWarehouseRepo.On("ListWarehouses", ctx).Return([]*models.Warehouse{tokyo, shizuoka, yokohama, closed}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 10, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "静岡倉庫").Return([]*models.Inventory{
+		{ID: 2, ProductID: 1, Quantity: 100, Location: "静岡倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "横浜倉庫").Return([]*models.Inventory{
+		{ID: 3, ProductID: 1, Quantity: 15, Location: "横浜倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), mock.Anything).Return(0, nil)
+
+	strategy, err := NewAllocationStrategy(models.AllocationStrategyNearestWarehouse)
+	assert.NoError(t, err)
+
+	warehouses, err := allocationWarehouses(ctx, tx, strategy, tokyo)
+	assert.NoError(t, err)
+	picks, err := allocateStock(ctx, tx, strategy, tokyo, warehouses, 1, nil, 40)
+
+	assert.NoError(t, err)
+	if assert.Len(t, picks, 3) {
+		assert.Equal(t, "東京倉庫", picks[0].Candidate.Location)
+		assert.Equal(t, 10, picks[0].Quantity)
+		assert.Equal(t, "横浜倉庫", picks[1].Candidate.Location)
+		assert.Equal(t, 15, picks[1].Quantity)
+		assert.Equal(t, "静岡倉庫", picks[2].Candidate.Location)
+		assert.Equal(t, 15, picks[2].Quantity)
+	}
+	mockInventoryRepo.AssertNotCalled(t, "GetInventoryByProductLocation", ctx, int64(1), "閉鎖倉庫")
+}
+
+func TestAllocateStock_FIFOSkipsExpiredLots(t *testing.T) {
+	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockLotRepo := new(mocks.MockLotRepository)
+	tx := &repository.TxRepositories{
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Lots:         mockLotRepo,
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	expiredLotID := int64(5)
+	expiredLot := &models.Lot{ID: expiredLotID, ProductID: 1, BestBeforeDate: now.AddDate(0, 0, -1)}
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 30, Location: "東京倉庫", Status: models.InventoryStatusAvailable, CreatedAt: now.AddDate(0, 0, -3)},
+		{ID: 2, ProductID: 1, Quantity: 40, Location: "東京倉庫", LotID: &expiredLotID, Status: models.InventoryStatusAvailable, CreatedAt: now.AddDate(0, -6, 0)},
+	}
+
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories, nil)
+	mockLotRepo.On("GetLot", ctx, expiredLotID).Return(expiredLot, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	mockReservationRepo.On("SumActiveReservedByLot", ctx, expiredLotID, "東京倉庫").Return(0, nil)
+
+	strategy, err := NewAllocationStrategy(models.AllocationStrategyFIFO)
+	assert.NoError(t, err)
+
+	// 入庫の古いロットは賞味期限切れのため割り当てず、ロット管理していない在庫だけでは不足する
+	tokyo := &models.Warehouse{ID: 1, Name: "東京倉庫"}
+	picks, err := allocateStock(ctx, tx, strategy, tokyo, []*models.Warehouse{tokyo}, 1, nil, 35)
+
+	assert.Error(t, err)
+	assert.Nil(t, picks)
+}
+
+func TestAllocationStrategyFor_DefaultsWhenPolicyMissing(t *testing.T) {
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	tx := &repository.TxRepositories{Allocations: mockAllocationRepo}
+
+	ctx := context.Background()
+	mockAllocationRepo.On("GetPolicyForProduct", ctx, int64(1)).Return(nil, repository.ErrNotFound)
+	mockAllocationRepo.On("GetPolicyForProduct", ctx, int64(2)).Return(&models.AllocationPolicy{Category: "抹茶", Strategy: models.AllocationStrategyFIFO}, nil)
+
+	strategy, err := allocationStrategyFor(ctx, tx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAllocationStrategy, strategy.Type())
+
+	strategy, err = allocationStrategyFor(ctx, tx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AllocationStrategyFIFO, strategy.Type())
+}
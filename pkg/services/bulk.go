@@ -0,0 +1,974 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/tabular"
+)
+
+/*
+ * 一括取込・出力サービス
+ * 商品・在庫数・保管場所（ゾーン・通路・ビン）のCSV/Excelファイルによる一括取込と出力を実装する
+ * 取込は全行を検証してから単一トランザクションで登録し、1行でもエラーがあれば何も登録しない
+ */
+
+// maxImportRows 1ファイルで取込できる明細行数の上限
+const maxImportRows = 10000
+
+// 出力ファイルの列（取込ファイルの列名と同じにし、出力したファイルをそのまま取込に使えるようにする）
+var (
+	productExportColumns   = []string{"id", "sku", "name", "description", "category", "price", "status"}
+	inventoryExportColumns = []string{"id", "sku", "product_id", "location", "bin_id", "lot_id", "status", "quantity"}
+	locationExportColumns  = []string{"warehouse", "zone_code", "zone_name", "zone_type", "aisle_code", "bin_code"}
+)
+
+// BulkService 一括取込・出力サービス
+type BulkService struct {
+	productService   *ProductService
+	inventoryService *InventoryService
+	warehouseRepo    repository.WarehouseRepository
+	locationRepo     repository.LocationRepository
+	uow              repository.UnitOfWork
+}
+
+// NewBulkService 一括取込・出力サービスを作成する
+func NewBulkService(
+	productService *ProductService,
+	inventoryService *InventoryService,
+	warehouseRepo repository.WarehouseRepository,
+	locationRepo repository.LocationRepository,
+	uow repository.UnitOfWork,
+) *BulkService {
+	return &BulkService{
+		productService:   productService,
+		inventoryService: inventoryService,
+		warehouseRepo:    warehouseRepo,
+		locationRepo:     locationRepo,
+		uow:              uow,
+	}
+}
+
+// importSheet 取込ファイルの見出し行と明細行
+type importSheet struct {
+	header tabular.Header
+	rows   [][]string
+}
+
+// newImportSheet 取込ファイルの見出し行に必須列があるかを確認する
+// 不足している場合は結果にエラーを追加してnilを返す
+func newImportSheet(rows [][]string, result *models.ImportResult, required ...string) *importSheet {
+	if len(rows) == 0 {
+		result.AddError(1, "", "見出し行がありません")
+		return nil
+	}
+
+	header := tabular.NewHeader(rows[0])
+	for _, column := range header.Missing(required...) {
+		result.AddError(1, column, "必須の列がありません")
+	}
+	if result.HasErrors() {
+		return nil
+	}
+
+	if len(rows)-1 > maxImportRows {
+		result.AddError(1, "", fmt.Sprintf("取込できる明細行は%d行までです", maxImportRows))
+		return nil
+	}
+
+	return &importSheet{header: header, rows: rows[1:]}
+}
+
+// each 空行を除いた明細行をファイル上の行番号（見出し行を1行目とする）とともに処理する
+func (s *importSheet) each(result *models.ImportResult, fn func(line int, row []string) error) error {
+	for i, row := range s.rows {
+		if tabular.IsBlank(row) {
+			continue
+		}
+		result.TotalRows++
+		if err := fn(i+2, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportProducts 商品を一括登録する
+// 列: sku・name・price（必須）、description・category・status（省略時はactive）
+// SKUがファイル内で重複している場合や登録済みの場合はエラーとする
+func (s *BulkService) ImportProducts(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{DryRun: dryRun}
+	sheet := newImportSheet(rows, result, "sku", "name", "price")
+	if sheet == nil {
+		return result, nil
+	}
+
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		existing, _, err := tx.Products.ListProducts(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("商品一覧取得エラー: %v", err)
+		}
+		// SKUと最初に現れた行番号（登録済みの商品は0）
+		skuLines := make(map[string]int, len(existing))
+		for _, product := range existing {
+			skuLines[product.SKU] = 0
+		}
+
+		var products []*models.Product
+		err = sheet.each(result, func(line int, row []string) error {
+			product, valid := parseProductRow(sheet.header, row, line, result)
+			if product.SKU != "" {
+				if first, ok := skuLines[product.SKU]; ok {
+					if first == 0 {
+						result.AddError(line, "sku", fmt.Sprintf("SKU %sは既に登録されています", product.SKU))
+					} else {
+						result.AddError(line, "sku", fmt.Sprintf("SKU %sが%d行目と重複しています", product.SKU, first))
+					}
+					valid = false
+				} else {
+					skuLines[product.SKU] = line
+				}
+			}
+			if valid {
+				products = append(products, product)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if result.HasErrors() || dryRun {
+			return nil
+		}
+
+		for _, product := range products {
+			if err := tx.Products.CreateProduct(ctx, product); err != nil {
+				return fmt.Errorf("商品作成エラー: %v", err)
+			}
+		}
+		result.Created = len(products)
+		result.Committed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseProductRow 商品の取込行を解釈する
+// 誤りがある場合は結果にエラーを追加し、falseを返す
+func parseProductRow(header tabular.Header, row []string, line int, result *models.ImportResult) (*models.Product, bool) {
+	valid := true
+	fail := func(column, message string) {
+		result.AddError(line, column, message)
+		valid = false
+	}
+
+	product := &models.Product{
+		SKU:         header.Value(row, "sku"),
+		Name:        header.Value(row, "name"),
+		Description: header.Value(row, "description"),
+		Category:    header.Value(row, "category"),
+		Status:      models.ProductStatus(header.Value(row, "status")),
+	}
+
+	if product.SKU == "" {
+		fail("sku", "SKUを入力してください")
+	}
+	if product.Name == "" {
+		fail("name", "商品名を入力してください")
+	}
+
+	price, err := strconv.ParseFloat(header.Value(row, "price"), 64)
+	if err != nil || price <= 0 {
+		fail("price", "価格は0より大きい数値で入力してください")
+	}
+	product.Price = price
+
+	switch product.Status {
+	case "":
+		product.Status = models.ProductStatusActive
+	case models.ProductStatusActive, models.ProductStatusInactive, models.ProductStatusDiscontinued:
+	default:
+		fail("status", "ステータスはactive・inactive・discontinuedのいずれかを入力してください")
+	}
+
+	return product, valid
+}
+
+// inventoryLevel 取込む在庫数と取込先の在庫行
+type inventoryLevel struct {
+	line      int
+	productID int64
+	location  string
+	binID     *int64
+	lotID     *int64
+	status    models.InventoryStatus
+	quantity  int
+	// current 取込先の在庫行（まだない場合はnil）
+	current *models.Inventory
+}
+
+// inventoryLevelKey ファイル内の重複を判定する在庫行のキー（idx_inventory_stock_keyと同じ）
+type inventoryLevelKey struct {
+	productID   int64
+	location    string
+	binID       int64
+	lotID       int64
+	quarantined bool
+}
+
+// key 在庫行のキーを返す（ビン未割当・ロット管理外は0とする）
+func (l *inventoryLevel) key() inventoryLevelKey {
+	key := inventoryLevelKey{productID: l.productID, location: l.location, quarantined: l.quarantined()}
+	if l.binID != nil {
+		key.binID = *l.binID
+	}
+	if l.lotID != nil {
+		key.lotID = *l.lotID
+	}
+	return key
+}
+
+// quarantined 隔離在庫の在庫行かどうかを判定する
+func (l *inventoryLevel) quarantined() bool {
+	return l.status == models.InventoryStatusQuarantined
+}
+
+// increase 取込による在庫数の増加分を返す（減少する場合は負の値）
+func (l *inventoryLevel) increase() int {
+	if l.current == nil {
+		return l.quantity
+	}
+	return l.quantity - l.current.Quantity
+}
+
+// parseOptionalID 省略可能なID列の値を読み取る（空の場合はnil）
+func parseOptionalID(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("invalid id: %s", value)
+	}
+	return &id, nil
+}
+
+// ImportInventory 在庫数を一括登録する
+// 列: sku・location・quantity（必須）、bin_id・lot_id・status（省略時はビン未割当・ロット管理外のavailable）
+// 商品・ロケーション・ビン・ロット・隔離区分が一致する在庫行を指定数量にし、在庫行がない場合は作成する
+// 未登録の商品・ビン・ロット、負の数量、棚卸中のロケーション、ファイル内で重複する在庫行はエラーとする
+// 取込後の販売可能在庫が引当中の数量を下回る場合や、増加分が倉庫の空き容量を超える場合もエラーとする
+func (s *BulkService) ImportInventory(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{DryRun: dryRun}
+	sheet := newImportSheet(rows, result, "sku", "location", "quantity")
+	if sheet == nil {
+		return result, nil
+	}
+
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		products, _, err := tx.Products.ListProducts(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("商品一覧取得エラー: %v", err)
+		}
+		productIDs := make(map[string]int64, len(products))
+		for _, product := range products {
+			productIDs[product.SKU] = product.ID
+		}
+
+		// ロケーションごとの棚卸による凍結状況
+		frozen := make(map[string]error)
+		// 在庫行のキーと最初に現れた行番号
+		lines := make(map[inventoryLevelKey]int)
+		// 確認済みのビン・ロット
+		bins := make(map[int64]*models.BinLocation)
+		lots := make(map[int64]*models.Lot)
+
+		var levels []*inventoryLevel
+		err = sheet.each(result, func(line int, row []string) error {
+			valid := true
+			fail := func(column, message string) {
+				result.AddError(line, column, message)
+				valid = false
+			}
+
+			sku := sheet.header.Value(row, "sku")
+			location := sheet.header.Value(row, "location")
+
+			productID, ok := productIDs[sku]
+			if sku == "" {
+				fail("sku", "SKUを入力してください")
+			} else if !ok {
+				fail("sku", fmt.Sprintf("SKU %sの商品は登録されていません", sku))
+			}
+
+			if location == "" {
+				fail("location", "ロケーションを入力してください")
+			} else {
+				frozenErr, checked := frozen[location]
+				if !checked {
+					frozenErr = checkLocationNotFrozen(ctx, tx, location)
+					var locationFrozen *models.LocationFrozenError
+					if frozenErr != nil && !errors.As(frozenErr, &locationFrozen) {
+						return frozenErr
+					}
+					frozen[location] = frozenErr
+				}
+				if frozenErr != nil {
+					fail("location", frozenErr.Error())
+				}
+			}
+
+			quantity, err := strconv.Atoi(sheet.header.Value(row, "quantity"))
+			if err != nil {
+				fail("quantity", "数量は整数で入力してください")
+			} else if quantity < 0 {
+				fail("quantity", "数量に負の値は指定できません")
+			}
+
+			binID, err := parseOptionalID(sheet.header.Value(row, "bin_id"))
+			if err != nil {
+				fail("bin_id", "ビンIDは正の整数で入力してください")
+			} else if binID != nil && location != "" {
+				bin, checked := bins[*binID]
+				if !checked {
+					bin, err = tx.Locations.GetBinLocation(ctx, *binID)
+					if err != nil && !errors.Is(err, repository.ErrNotFound) {
+						return fmt.Errorf("ビン取得エラー: %v", err)
+					}
+					bins[*binID] = bin
+				}
+				if bin == nil {
+					fail("bin_id", fmt.Sprintf("ビンID %dは登録されていません", *binID))
+				} else if bin.WarehouseName != location {
+					fail("bin_id", fmt.Sprintf("ビンID %dはロケーション%sのビンではありません", *binID, location))
+				}
+			}
+
+			lotID, err := parseOptionalID(sheet.header.Value(row, "lot_id"))
+			if err != nil {
+				fail("lot_id", "ロットIDは正の整数で入力してください")
+			} else if lotID != nil && productID != 0 {
+				lot, checked := lots[*lotID]
+				if !checked {
+					lot, err = tx.Lots.GetLot(ctx, *lotID)
+					if err != nil && !errors.Is(err, repository.ErrNotFound) {
+						return fmt.Errorf("ロット取得エラー: %v", err)
+					}
+					lots[*lotID] = lot
+				}
+				if lot == nil {
+					fail("lot_id", fmt.Sprintf("ロットID %dは登録されていません", *lotID))
+				} else if lot.ProductID != productID {
+					fail("lot_id", fmt.Sprintf("ロットID %dはSKU %sの商品のロットではありません", *lotID, sku))
+				}
+			}
+
+			status := models.InventoryStatus(sheet.header.Value(row, "status"))
+			switch status {
+			case "":
+				status = models.InventoryStatusAvailable
+			case models.InventoryStatusAvailable, models.InventoryStatusQuarantined:
+			default:
+				fail("status", "ステータスはavailable・quarantinedのいずれかを入力してください")
+			}
+
+			level := &inventoryLevel{
+				line:      line,
+				productID: productID,
+				location:  location,
+				binID:     binID,
+				lotID:     lotID,
+				status:    status,
+				quantity:  quantity,
+			}
+			if productID != 0 && location != "" {
+				if first, ok := lines[level.key()]; ok {
+					fail("sku", fmt.Sprintf("%d行目と同じ在庫行です", first))
+				} else {
+					lines[level.key()] = line
+				}
+			}
+
+			if valid {
+				levels = append(levels, level)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if result.HasErrors() {
+			return nil
+		}
+
+		// 並行する入出庫・引当と同じ在庫を扱わないよう、ロックを取得してから取込先の在庫行を読む
+		keys := make([]stockKey, 0, len(levels))
+		for _, level := range levels {
+			keys = append(keys, stockKey{ProductID: level.productID, Location: level.location})
+		}
+		if err := lockStocks(ctx, tx, keys); err != nil {
+			return err
+		}
+		for _, level := range levels {
+			current, err := findStockRow(ctx, tx.Inventory, level.productID, level.location, level.binID, level.lotID, level.quarantined())
+			if err != nil && !errors.Is(err, errStockNotFound) {
+				return err
+			}
+			level.current = current
+		}
+
+		if err := checkImportReserved(ctx, tx, levels, result); err != nil {
+			return err
+		}
+		if err := checkImportCapacity(ctx, tx, levels, result); err != nil {
+			return err
+		}
+		if result.HasErrors() || dryRun {
+			return nil
+		}
+
+		created, updated := 0, 0
+		for _, level := range levels {
+			if inventory := level.current; inventory != nil {
+				if inventory.Quantity == level.quantity {
+					continue
+				}
+				if err := tx.Inventory.UpdateQuantity(ctx, inventory.ID, level.quantity, inventory.Version); err != nil {
+					return wrapUpdateError("在庫数更新エラー", err)
+				}
+				updated++
+				continue
+			}
+
+			inventory := &models.Inventory{
+				ProductID: level.productID,
+				Quantity:  level.quantity,
+				Location:  level.location,
+				BinID:     level.binID,
+				LotID:     level.lotID,
+				Status:    level.status,
+			}
+			if err := tx.Inventory.CreateInventory(ctx, inventory); err != nil {
+				return fmt.Errorf("在庫作成エラー: %v", err)
+			}
+			created++
+		}
+
+		result.Created = created
+		result.Updated = updated
+		result.Committed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// checkImportReserved 取込後の販売可能在庫が引当中の数量を下回らないか確認する
+// 商品・ロケーション全体と、ロットを指定した行はロット単位でも確認し、下回る場合は結果にエラーを追加する
+func checkImportReserved(ctx context.Context, tx *repository.TxRepositories, levels []*inventoryLevel, result *models.ImportResult) error {
+	var keys []stockKey
+	groups := make(map[stockKey][]*inventoryLevel)
+	for _, level := range levels {
+		key := stockKey{ProductID: level.productID, Location: level.location}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], level)
+	}
+
+	for _, key := range keys {
+		inventories, err := tx.Inventory.GetInventoryByProductLocation(ctx, key.ProductID, key.Location)
+		if err != nil {
+			return fmt.Errorf("在庫取得エラー: %v", err)
+		}
+
+		// 取込後の在庫行（既存の在庫行は取込む数量に置き換え、新しい在庫行を加える）
+		imported := make(map[int64]*inventoryLevel)
+		var after []*models.Inventory
+		for _, level := range groups[key] {
+			if level.current != nil {
+				imported[level.current.ID] = level
+				continue
+			}
+			after = append(after, &models.Inventory{LotID: level.lotID, Quantity: level.quantity, Status: level.status})
+		}
+		for _, inv := range inventories {
+			if level, ok := imported[inv.ID]; ok {
+				after = append(after, &models.Inventory{LotID: inv.LotID, Quantity: level.quantity, Status: inv.Status})
+				continue
+			}
+			after = append(after, inv)
+		}
+		stock := &locationStock{ProductID: key.ProductID, Location: key.Location}
+		for _, inv := range after {
+			if inv.Status.IsSellable() {
+				stock.Rows = append(stock.Rows, inv)
+			}
+		}
+
+		reserved, err := tx.Reservations.SumActiveReserved(ctx, key.ProductID, key.Location)
+		if err != nil {
+			return fmt.Errorf("引当数量取得エラー: %v", err)
+		}
+		if stock.OnHand() < reserved {
+			result.AddError(groups[key][0].line, "quantity", fmt.Sprintf(
+				"取込後の販売可能在庫(%d)が引当中の数量(%d)を下回ります", stock.OnHand(), reserved))
+			continue
+		}
+
+		checked := make(map[int64]bool)
+		for _, level := range groups[key] {
+			if level.lotID == nil || checked[*level.lotID] {
+				continue
+			}
+			checked[*level.lotID] = true
+
+			lotReserved, err := tx.Reservations.SumActiveReservedByLot(ctx, *level.lotID, key.Location)
+			if err != nil {
+				return fmt.Errorf("ロット引当数量取得エラー: %v", err)
+			}
+			if onHand := stock.ForLot(level.lotID).OnHand(); onHand < lotReserved {
+				result.AddError(level.line, "quantity", fmt.Sprintf(
+					"取込後のロットの販売可能在庫(%d)が引当中の数量(%d)を下回ります", onHand, lotReserved))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkImportCapacity 取込による在庫数の増加分が倉庫の空き容量を超えないか確認する
+// 倉庫として登録されていないロケーションは確認せず、超える場合は結果にエラーを追加する
+// 倉庫の行ロックは在庫のロックの後に、倉庫名の順で取得する
+func checkImportCapacity(ctx context.Context, tx *repository.TxRepositories, levels []*inventoryLevel, result *models.ImportResult) error {
+	var locations []string
+	increases := make(map[string]int)
+	firstLines := make(map[string]int)
+	for _, level := range levels {
+		if _, ok := firstLines[level.location]; !ok {
+			locations = append(locations, level.location)
+			firstLines[level.location] = level.line
+		}
+		increases[level.location] += level.increase()
+	}
+	sort.Strings(locations)
+
+	for _, location := range locations {
+		increase := increases[location]
+		if increase <= 0 {
+			continue
+		}
+
+		warehouse, err := tx.Warehouses.LockWarehouseByName(ctx, location)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("倉庫取得エラー: %v", err)
+		}
+		used, err := tx.Warehouses.GetStoredQuantity(ctx, warehouse.ID)
+		if err != nil {
+			return err
+		}
+
+		if remaining := warehouse.Capacity - used; increase > remaining {
+			capacityErr := &models.WarehouseCapacityError{
+				WarehouseID: warehouse.ID,
+				Name:        warehouse.Name,
+				Remaining:   remaining,
+				Requested:   increase,
+			}
+			result.AddError(firstLines[location], "quantity", capacityErr.Error())
+		}
+	}
+
+	return nil
+}
+
+// locationPlan 取込むビンとその上位のゾーン・通路
+type locationPlan struct {
+	warehouseID int64
+	zoneCode    string
+	zoneName    string
+	zoneType    models.ZoneType
+	aisleCode   string
+	binCode     string
+}
+
+// ImportLocations 保管場所（ゾーン・通路・ビン）を一括登録する
+// 列: warehouse（倉庫名）・zone_code・aisle_code・bin_code（必須）、zone_name・zone_type
+// 未登録のゾーン・通路は作成し、ゾーンを作成する場合はzone_nameとzone_typeを必須とする
+// 未登録の倉庫、登録済みのビン、ファイル内で重複するビンはエラーとする
+func (s *BulkService) ImportLocations(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{DryRun: dryRun}
+	sheet := newImportSheet(rows, result, "warehouse", "zone_code", "aisle_code", "bin_code")
+	if sheet == nil {
+		return result, nil
+	}
+
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		catalog := newLocationCatalog(tx)
+		// ファイル内で作成するゾーン（倉庫ID/ゾーンコード）の保管環境
+		plannedZones := make(map[string]models.ZoneType)
+		// ビン（倉庫ID/ゾーン/通路/ビン）と最初に現れた行番号
+		binLines := make(map[string]int)
+
+		var plans []locationPlan
+		err := sheet.each(result, func(line int, row []string) error {
+			valid := true
+			fail := func(column, message string) {
+				result.AddError(line, column, message)
+				valid = false
+			}
+
+			plan := locationPlan{
+				zoneCode:  sheet.header.Value(row, "zone_code"),
+				zoneName:  sheet.header.Value(row, "zone_name"),
+				zoneType:  models.ZoneType(sheet.header.Value(row, "zone_type")),
+				aisleCode: sheet.header.Value(row, "aisle_code"),
+				binCode:   sheet.header.Value(row, "bin_code"),
+			}
+			if plan.zoneType != "" && !plan.zoneType.IsValid() {
+				fail("zone_type", "保管環境はambient・chilled・humidity_controlledのいずれかを入力してください")
+			}
+			if plan.zoneCode == "" {
+				fail("zone_code", "ゾーンコードを入力してください")
+			}
+			if plan.aisleCode == "" {
+				fail("aisle_code", "通路コードを入力してください")
+			}
+			if plan.binCode == "" {
+				fail("bin_code", "ビンコードを入力してください")
+			}
+
+			warehouseName := sheet.header.Value(row, "warehouse")
+			if warehouseName == "" {
+				fail("warehouse", "倉庫名を入力してください")
+				return nil
+			}
+			warehouse, err := catalog.warehouse(ctx, warehouseName)
+			if err != nil {
+				return err
+			}
+			if warehouse == nil {
+				fail("warehouse", fmt.Sprintf("倉庫%sは登録されていません", warehouseName))
+				return nil
+			}
+			plan.warehouseID = warehouse.ID
+			if !valid {
+				return nil
+			}
+
+			// ゾーン: 登録済みの場合は保管環境が一致すること、未登録の場合は名称と保管環境を指定すること
+			zone, err := catalog.zone(ctx, warehouse.ID, plan.zoneCode)
+			if err != nil {
+				return err
+			}
+			zoneKey := fmt.Sprintf("%d/%s", warehouse.ID, plan.zoneCode)
+			switch {
+			case zone != nil:
+				if plan.zoneType != "" && plan.zoneType != zone.ZoneType {
+					fail("zone_type", fmt.Sprintf("ゾーン%sの保管環境は%sです", zone.Code, zone.ZoneType))
+				}
+			case plannedZones[zoneKey] != "":
+				if plan.zoneType != "" && plan.zoneType != plannedZones[zoneKey] {
+					fail("zone_type", fmt.Sprintf("ゾーン%sの保管環境が前の行と異なります", plan.zoneCode))
+				}
+			default:
+				if plan.zoneName == "" {
+					fail("zone_name", fmt.Sprintf("新しいゾーン%sの名称を入力してください", plan.zoneCode))
+				}
+				if plan.zoneType == "" {
+					fail("zone_type", fmt.Sprintf("新しいゾーン%sの保管環境を入力してください", plan.zoneCode))
+				}
+				if valid {
+					plannedZones[zoneKey] = plan.zoneType
+				}
+			}
+
+			// ビン: 登録済みでないこと、ファイル内で重複しないこと
+			if zone != nil {
+				exists, err := catalog.binExists(ctx, zone.ID, plan.aisleCode, plan.binCode)
+				if err != nil {
+					return err
+				}
+				if exists {
+					fail("bin_code", fmt.Sprintf("ビン%s/%s/%sは既に登録されています", plan.zoneCode, plan.aisleCode, plan.binCode))
+				}
+			}
+			binKey := fmt.Sprintf("%s/%s/%s", zoneKey, plan.aisleCode, plan.binCode)
+			if first, ok := binLines[binKey]; ok {
+				fail("bin_code", fmt.Sprintf("%d行目と同じビンです", first))
+			} else {
+				binLines[binKey] = line
+			}
+
+			if valid {
+				plans = append(plans, plan)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if result.HasErrors() || dryRun {
+			return nil
+		}
+
+		for _, plan := range plans {
+			if err := catalog.createBin(ctx, plan); err != nil {
+				return err
+			}
+		}
+		result.Created = len(plans)
+		result.Committed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// locationCatalog 取込中に参照・作成した倉庫・ゾーン・通路・ビンを保持する
+type locationCatalog struct {
+	tx         *repository.TxRepositories
+	warehouses map[string]*models.Warehouse
+	zones      map[int64]map[string]*models.Zone
+	aisles     map[int64]map[string]*models.Aisle
+	bins       map[int64]map[string]*models.Bin
+}
+
+// newLocationCatalog 保管場所の参照を作成する
+func newLocationCatalog(tx *repository.TxRepositories) *locationCatalog {
+	return &locationCatalog{
+		tx:         tx,
+		warehouses: make(map[string]*models.Warehouse),
+		zones:      make(map[int64]map[string]*models.Zone),
+		aisles:     make(map[int64]map[string]*models.Aisle),
+		bins:       make(map[int64]map[string]*models.Bin),
+	}
+}
+
+// warehouse 倉庫名から倉庫を取得する（未登録の場合はnil）
+func (c *locationCatalog) warehouse(ctx context.Context, name string) (*models.Warehouse, error) {
+	if warehouse, ok := c.warehouses[name]; ok {
+		return warehouse, nil
+	}
+	warehouse, err := c.tx.Warehouses.GetWarehouseByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		warehouse, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("倉庫取得エラー: %v", err)
+	}
+	c.warehouses[name] = warehouse
+	return warehouse, nil
+}
+
+// zone 倉庫のゾーンをコードで取得する（未登録の場合はnil）
+func (c *locationCatalog) zone(ctx context.Context, warehouseID int64, code string) (*models.Zone, error) {
+	zones, ok := c.zones[warehouseID]
+	if !ok {
+		list, err := c.tx.Locations.ListZones(ctx, warehouseID)
+		if err != nil {
+			return nil, fmt.Errorf("ゾーン一覧取得エラー: %v", err)
+		}
+		zones = make(map[string]*models.Zone, len(list))
+		for _, zone := range list {
+			zones[zone.Code] = zone
+		}
+		c.zones[warehouseID] = zones
+	}
+	return zones[code], nil
+}
+
+// aisle ゾーンの通路をコードで取得する（未登録の場合はnil）
+func (c *locationCatalog) aisle(ctx context.Context, zoneID int64, code string) (*models.Aisle, error) {
+	aisles, ok := c.aisles[zoneID]
+	if !ok {
+		list, err := c.tx.Locations.ListAisles(ctx, zoneID)
+		if err != nil {
+			return nil, fmt.Errorf("通路一覧取得エラー: %v", err)
+		}
+		aisles = make(map[string]*models.Aisle, len(list))
+		for _, aisle := range list {
+			aisles[aisle.Code] = aisle
+		}
+		c.aisles[zoneID] = aisles
+	}
+	return aisles[code], nil
+}
+
+// binExists ゾーンの通路にビンが登録済みかどうかを判定する
+func (c *locationCatalog) binExists(ctx context.Context, zoneID int64, aisleCode, binCode string) (bool, error) {
+	aisle, err := c.aisle(ctx, zoneID, aisleCode)
+	if err != nil || aisle == nil {
+		return false, err
+	}
+	bins, ok := c.bins[aisle.ID]
+	if !ok {
+		list, err := c.tx.Locations.ListBins(ctx, aisle.ID)
+		if err != nil {
+			return false, fmt.Errorf("ビン一覧取得エラー: %v", err)
+		}
+		bins = make(map[string]*models.Bin, len(list))
+		for _, bin := range list {
+			bins[bin.Code] = bin
+		}
+		c.bins[aisle.ID] = bins
+	}
+	_, exists := bins[binCode]
+	return exists, nil
+}
+
+// createBin ビンを作成する。上位のゾーン・通路が未登録の場合は先に作成する
+func (c *locationCatalog) createBin(ctx context.Context, plan locationPlan) error {
+	zone, err := c.zone(ctx, plan.warehouseID, plan.zoneCode)
+	if err != nil {
+		return err
+	}
+	if zone == nil {
+		zone = &models.Zone{
+			WarehouseID: plan.warehouseID,
+			Code:        plan.zoneCode,
+			Name:        plan.zoneName,
+			ZoneType:    plan.zoneType,
+		}
+		if err := c.tx.Locations.CreateZone(ctx, zone); err != nil {
+			return fmt.Errorf("ゾーン作成エラー: %v", err)
+		}
+		c.zones[plan.warehouseID][zone.Code] = zone
+	}
+
+	aisle, err := c.aisle(ctx, zone.ID, plan.aisleCode)
+	if err != nil {
+		return err
+	}
+	if aisle == nil {
+		aisle = &models.Aisle{ZoneID: zone.ID, Code: plan.aisleCode}
+		if err := c.tx.Locations.CreateAisle(ctx, aisle); err != nil {
+			return fmt.Errorf("通路作成エラー: %v", err)
+		}
+		c.aisles[zone.ID][aisle.Code] = aisle
+	}
+
+	bin := &models.Bin{AisleID: aisle.ID, Code: plan.binCode}
+	if err := c.tx.Locations.CreateBin(ctx, bin); err != nil {
+		return fmt.Errorf("ビン作成エラー: %v", err)
+	}
+	return nil
+}
+
+// ExportProducts 商品一覧を取込ファイルと同じ列で出力する
+func (s *BulkService) ExportProducts(ctx context.Context) ([][]string, error) {
+	products, _, err := s.productService.ListProducts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{productExportColumns}
+	for _, product := range products {
+		rows = append(rows, []string{
+			strconv.FormatInt(product.ID, 10),
+			product.SKU,
+			product.Name,
+			product.Description,
+			product.Category,
+			strconv.FormatFloat(product.Price, 'f', -1, 64),
+			string(product.Status),
+		})
+	}
+	return rows, nil
+}
+
+// ExportInventory 在庫一覧を商品のSKUを付けて出力する
+func (s *BulkService) ExportInventory(ctx context.Context) ([][]string, error) {
+	inventories, _, err := s.inventoryService.ListInventories(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	products, _, err := s.productService.ListProducts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	skus := make(map[int64]string, len(products))
+	for _, product := range products {
+		skus[product.ID] = product.SKU
+	}
+
+	rows := [][]string{inventoryExportColumns}
+	for _, inventory := range inventories {
+		rows = append(rows, []string{
+			strconv.FormatInt(inventory.ID, 10),
+			skus[inventory.ProductID],
+			strconv.FormatInt(inventory.ProductID, 10),
+			inventory.Location,
+			formatOptionalID(inventory.BinID),
+			formatOptionalID(inventory.LotID),
+			string(inventory.Status),
+			strconv.Itoa(inventory.Quantity),
+		})
+	}
+	return rows, nil
+}
+
+// ExportLocations 倉庫ごとのゾーン・通路・ビンを出力する
+func (s *BulkService) ExportLocations(ctx context.Context) ([][]string, error) {
+	warehouses, err := s.warehouseRepo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+
+	rows := [][]string{locationExportColumns}
+	for _, warehouse := range warehouses {
+		zones, err := s.locationRepo.ListZones(ctx, warehouse.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ゾーン一覧取得エラー: %v", err)
+		}
+		for _, zone := range zones {
+			aisles, err := s.locationRepo.ListAisles(ctx, zone.ID)
+			if err != nil {
+				return nil, fmt.Errorf("通路一覧取得エラー: %v", err)
+			}
+			for _, aisle := range aisles {
+				bins, err := s.locationRepo.ListBins(ctx, aisle.ID)
+				if err != nil {
+					return nil, fmt.Errorf("ビン一覧取得エラー: %v", err)
+				}
+				for _, bin := range bins {
+					rows = append(rows, []string{
+						warehouse.Name,
+						zone.Code,
+						zone.Name,
+						string(zone.ZoneType),
+						aisle.Code,
+						bin.Code,
+					})
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+// formatOptionalID 省略可能なIDを文字列にする（未設定の場合は空文字）
+func formatOptionalID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 一括取込・出力サービステスト
+ */
+
+// newTestBulkService テスト用の一括取込・出力サービスを作成する
+func newTestBulkService(repos *repository.TxRepositories) *BulkService {
+	return NewBulkService(nil, nil, repos.Warehouses, repos.Locations, mocks.NewMockUnitOfWork(repos))
+}
+
+func TestImportProducts_DryRunReportsRowErrors(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := newTestBulkService(&repository.TxRepositories{Products: mockProductRepo})
+
+	ctx := context.Background()
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{
+		{ID: 1, SKU: "SEN-001", Name: "煎茶"},
+	}, nil, nil)
+
+	result, err := service.ImportProducts(ctx, [][]string{
+		{"sku", "name", "price", "category"},
+		{"SEN-001", "煎茶（重複）", "1200", "煎茶"},
+		{"GYO-001", "玉露", "3000", "玉露"},
+		{"", "", "", ""},
+		{"GYO-001", "玉露（重複）", "3000", "玉露"},
+		{"MAT-001", "抹茶", "-10", "抹茶"},
+	}, true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.False(t, result.Committed)
+	assert.Equal(t, 4, result.TotalRows)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 2, Column: "sku", Message: "SKU SEN-001は既に登録されています"},
+		{Row: 5, Column: "sku", Message: "SKU GYO-001が3行目と重複しています"},
+		{Row: 6, Column: "price", Message: "価格は0より大きい数値で入力してください"},
+	}, result.Errors)
+	mockProductRepo.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+}
+
+func TestImportProducts_DoesNotCommitWhenAnyRowFails(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := newTestBulkService(&repository.TxRepositories{Products: mockProductRepo})
+
+	ctx := context.Background()
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{}, nil, nil)
+
+	result, err := service.ImportProducts(ctx, [][]string{
+		{"sku", "name", "price"},
+		{"SEN-001", "煎茶", "1200"},
+		{"GYO-001", "", "3000"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, 0, result.Created)
+	assert.Len(t, result.Errors, 1)
+	mockProductRepo.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+}
+
+func TestImportProducts_MissingRequiredColumn(t *testing.T) {
+	service := newTestBulkService(&repository.TxRepositories{Products: new(MockProductRepository)})
+
+	result, err := service.ImportProducts(context.Background(), [][]string{
+		{"sku", "name"},
+		{"SEN-001", "煎茶"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 1, Column: "price", Message: "必須の列がありません"},
+	}, result.Errors)
+}
+
+func TestImportInventory_DryRunReportsUnknownProductAndNegativeQuantity(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockInventoryRepo := new(MockInventoryRepository)
+	service := newTestBulkService(&repository.TxRepositories{
+		Products:    mockProductRepo,
+		Inventory:   mockInventoryRepo,
+		StockCounts: newDefaultStockCountRepo(),
+	})
+
+	ctx := context.Background()
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{
+		{ID: 1, SKU: "SEN-001"},
+	}, nil, nil)
+
+	result, err := service.ImportInventory(ctx, [][]string{
+		{"sku", "location", "quantity"},
+		{"SEN-001", "東京倉庫", "100"},
+		{"XXX-999", "東京倉庫", "10"},
+		{"SEN-001", "大阪倉庫", "-5"},
+		{"SEN-001", "東京倉庫", "20"},
+	}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.TotalRows)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 3, Column: "sku", Message: "SKU XXX-999の商品は登録されていません"},
+		{Row: 4, Column: "quantity", Message: "数量に負の値は指定できません"},
+		{Row: 5, Column: "sku", Message: "2行目と同じ在庫行です"},
+	}, result.Errors)
+	mockInventoryRepo.AssertNotCalled(t, "GetInventoryByStockKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportInventory_CommitsUpdatesAndCreates(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockInventoryRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestBulkService(&repository.TxRepositories{
+		Products:     mockProductRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+
+	ctx := context.Background()
+	current := &models.Inventory{ID: 10, ProductID: 1, Quantity: 40, Location: "東京倉庫", Status: models.InventoryStatusAvailable, Version: 3}
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{
+		{ID: 1, SKU: "SEN-001"},
+		{ID: 2, SKU: "GYO-001"},
+	}, nil, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(current, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(2), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(nil, repository.ErrNotFound)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{current}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(2), "東京倉庫").Return([]*models.Inventory{}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(30, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(2), "東京倉庫").Return(0, nil)
+	// 60個 + 25個の増加分が倉庫の空き容量に収まる
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "東京倉庫").Return(&models.Warehouse{ID: 1, Name: "東京倉庫", Capacity: 1000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(1)).Return(500, nil)
+	mockInventoryRepo.On("UpdateQuantity", ctx, int64(10), 100, 3).Return(nil)
+	mockInventoryRepo.On("CreateInventory", ctx, mock.MatchedBy(func(inventory *models.Inventory) bool {
+		return inventory.ProductID == 2 && inventory.Quantity == 25 && inventory.Location == "東京倉庫" &&
+			inventory.Status == models.InventoryStatusAvailable
+	})).Return(nil)
+
+	result, err := service.ImportInventory(ctx, [][]string{
+		{"SKU", "Location", "Quantity"},
+		{"SEN-001", "東京倉庫", "100"},
+		{"GYO-001", "東京倉庫", "25"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Committed)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Created)
+	assert.Empty(t, result.Errors)
+	mockReservationRepo.AssertCalled(t, "LockStock", ctx, int64(1), "東京倉庫")
+	mockReservationRepo.AssertCalled(t, "LockStock", ctx, int64(2), "東京倉庫")
+	mockInventoryRepo.AssertExpectations(t)
+	mockWarehouseRepo.AssertExpectations(t)
+}
+
+func TestImportInventory_KeysOnBinLotAndStatus(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockInventoryRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	mockLotRepo := new(mocks.MockLotRepository)
+	service := newTestBulkService(&repository.TxRepositories{
+		Products:     mockProductRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Locations:    mockLocationRepo,
+		Lots:         mockLotRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+
+	ctx := context.Background()
+	binID := int64(5)
+	lotID := int64(3)
+	// 同じ商品・ロケーションに、ビン未割当の在庫行とビン・ロット別の在庫行がある
+	unbinned := &models.Inventory{ID: 10, ProductID: 1, Quantity: 40, Location: "静岡倉庫", Status: models.InventoryStatusAvailable, Version: 1}
+	binned := &models.Inventory{ID: 11, ProductID: 1, Quantity: 20, Location: "静岡倉庫", BinID: &binID, LotID: &lotID, Status: models.InventoryStatusAvailable, Version: 2}
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{
+		{ID: 1, SKU: "SEN-001"},
+	}, nil, nil)
+	mockLocationRepo.On("GetBinLocation", ctx, binID).Return(&models.BinLocation{BinID: binID, WarehouseID: 1, WarehouseName: "静岡倉庫"}, nil)
+	mockLotRepo.On("GetLot", ctx, lotID).Return(&models.Lot{ID: lotID, ProductID: 1, LotNumber: "L-001"}, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(1), "静岡倉庫", &binID, &lotID, false).Return(binned, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(1), "静岡倉庫", &binID, &lotID, true).Return(nil, repository.ErrNotFound)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "静岡倉庫").Return([]*models.Inventory{unbinned, binned}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "静岡倉庫").Return(0, nil)
+	mockReservationRepo.On("SumActiveReservedByLot", ctx, lotID, "静岡倉庫").Return(10, nil)
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "静岡倉庫").Return(&models.Warehouse{ID: 1, Name: "静岡倉庫", Capacity: 1000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(1)).Return(60, nil)
+	// ビン・ロットの在庫行だけを更新し、ビン未割当の在庫行は変更しない
+	mockInventoryRepo.On("UpdateQuantity", ctx, int64(11), 15, 2).Return(nil)
+	// 隔離品は同じビン・ロットでも別の在庫行として作成する
+	mockInventoryRepo.On("CreateInventory", ctx, mock.MatchedBy(func(inventory *models.Inventory) bool {
+		return inventory.Quantity == 4 && inventory.Status == models.InventoryStatusQuarantined &&
+			inventory.BinID != nil && *inventory.BinID == binID && inventory.LotID != nil && *inventory.LotID == lotID
+	})).Return(nil)
+
+	result, err := service.ImportInventory(ctx, [][]string{
+		{"sku", "location", "bin_id", "lot_id", "status", "quantity"},
+		{"SEN-001", "静岡倉庫", "5", "3", "available", "15"},
+		{"SEN-001", "静岡倉庫", "5", "3", "quarantined", "4"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Created)
+	mockInventoryRepo.AssertExpectations(t)
+	mockInventoryRepo.AssertNotCalled(t, "UpdateQuantity", ctx, int64(10), mock.Anything, mock.Anything)
+}
+
+func TestImportInventory_RejectsQuantityBelowReservedAndOverCapacity(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockInventoryRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestBulkService(&repository.TxRepositories{
+		Products:     mockProductRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+
+	ctx := context.Background()
+	tokyo := &models.Inventory{ID: 10, ProductID: 1, Quantity: 40, Location: "東京倉庫", Status: models.InventoryStatusAvailable, Version: 3}
+	osaka := &models.Inventory{ID: 20, ProductID: 1, Quantity: 100, Location: "大阪倉庫", Status: models.InventoryStatusAvailable, Version: 1}
+	mockProductRepo.On("ListProducts", ctx, (*repository.QuerySpec)(nil)).Return([]*models.Product{
+		{ID: 1, SKU: "SEN-001"},
+	}, nil, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(tokyo, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", ctx, int64(1), "大阪倉庫", (*int64)(nil), (*int64)(nil), false).Return(osaka, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{tokyo}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "大阪倉庫").Return([]*models.Inventory{osaka}, nil)
+	// 東京倉庫は30個が引当中のため、20個にはできない
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(30, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "大阪倉庫").Return(0, nil)
+	// 大阪倉庫の空き容量は50個のため、80個の増加はできない
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(&models.Warehouse{ID: 2, Name: "大阪倉庫", Capacity: 1000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(2)).Return(950, nil)
+
+	result, err := service.ImportInventory(ctx, [][]string{
+		{"sku", "location", "quantity"},
+		{"SEN-001", "東京倉庫", "20"},
+		{"SEN-001", "大阪倉庫", "180"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 2, Column: "quantity", Message: "取込後の販売可能在庫(20)が引当中の数量(30)を下回ります"},
+		{Row: 3, Column: "quantity", Message: "倉庫「大阪倉庫」の空き容量(50)を超える入庫はできません: 要求数量 80"},
+	}, result.Errors)
+	mockWarehouseRepo.AssertNotCalled(t, "LockWarehouseByName", ctx, "東京倉庫")
+	mockInventoryRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportLocations_CreatesMissingZoneAndAisle(t *testing.T) {
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	service := newTestBulkService(&repository.TxRepositories{
+		Warehouses: mockWarehouseRepo,
+		Locations:  mockLocationRepo,
+	})
+
+	ctx := context.Background()
+	mockWarehouseRepo.On("GetWarehouseByName", ctx, "東京倉庫").Return(&models.Warehouse{ID: 1, Name: "東京倉庫"}, nil)
+	mockWarehouseRepo.On("GetWarehouseByName", ctx, "名古屋倉庫").Return(nil, repository.ErrNotFound)
+	mockLocationRepo.On("ListZones", ctx, int64(1)).Return([]*models.Zone{
+		{ID: 5, WarehouseID: 1, Code: "A", ZoneType: models.ZoneTypeAmbient},
+	}, nil)
+	mockLocationRepo.On("ListAisles", ctx, int64(5)).Return([]*models.Aisle{{ID: 7, ZoneID: 5, Code: "01"}}, nil)
+	mockLocationRepo.On("ListBins", ctx, int64(7)).Return([]*models.Bin{{ID: 9, AisleID: 7, Code: "001"}}, nil)
+
+	rows := [][]string{
+		{"warehouse", "zone_code", "zone_name", "zone_type", "aisle_code", "bin_code"},
+		{"東京倉庫", "A", "", "", "01", "002"},
+		{"東京倉庫", "C", "冷蔵", "chilled", "01", "001"},
+		{"東京倉庫", "C", "", "", "01", "002"},
+	}
+
+	// 検証: 登録済みのビン・未登録の倉庫・保管環境の不一致はエラー
+	result, err := service.ImportLocations(ctx, append(rows,
+		[]string{"東京倉庫", "A", "", "", "01", "001"},
+		[]string{"名古屋倉庫", "A", "", "", "01", "001"},
+		[]string{"東京倉庫", "C", "", "ambient", "02", "001"},
+	), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 5, Column: "bin_code", Message: "ビンA/01/001は既に登録されています"},
+		{Row: 6, Column: "warehouse", Message: "倉庫名古屋倉庫は登録されていません"},
+		{Row: 7, Column: "zone_type", Message: "ゾーンCの保管環境が前の行と異なります"},
+	}, result.Errors)
+
+	// 登録: 未登録のゾーン・通路は一度だけ作成する
+	mockLocationRepo.On("CreateBin", ctx, mock.AnythingOfType("*models.Bin")).Return(nil)
+	mockLocationRepo.On("CreateZone", ctx, mock.MatchedBy(func(zone *models.Zone) bool {
+		return zone.Code == "C" && zone.Name == "冷蔵" && zone.ZoneType == models.ZoneTypeChilled
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Zone).ID = 6
+	}).Return(nil).Once()
+	mockLocationRepo.On("ListAisles", ctx, int64(6)).Return([]*models.Aisle{}, nil)
+	mockLocationRepo.On("CreateAisle", ctx, mock.MatchedBy(func(aisle *models.Aisle) bool {
+		return aisle.ZoneID == 6 && aisle.Code == "01"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Aisle).ID = 8
+	}).Return(nil).Once()
+
+	result, err = service.ImportLocations(ctx, rows, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.True(t, result.Committed)
+	assert.Equal(t, 3, result.Created)
+	mockLocationRepo.AssertNumberOfCalls(t, "CreateBin", 3)
+	mockLocationRepo.AssertNumberOfCalls(t, "CreateZone", 1)
+	mockLocationRepo.AssertNumberOfCalls(t, "CreateAisle", 1)
+}
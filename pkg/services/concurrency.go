@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 楽観的排他制御
+ * 更新前提のバージョンの確認とバージョン競合エラーの受け渡しを行う
+ */
+
+// checkExpectedVersion If-Matchで指定されたバージョンが現在のバージョンと一致するかを確認する
+// expectedが0の場合は確認しない
+func checkExpectedVersion(resource string, id int64, expected, current int) error {
+	if expected == 0 || expected == current {
+		return nil
+	}
+	return &models.VersionConflictError{
+		Resource:        resource,
+		ID:              id,
+		ExpectedVersion: expected,
+		CurrentVersion:  current,
+	}
+}
+
+// wrapUpdateError 更新エラーにメッセージを付けて返す
+// バージョン競合はハンドラで409に変換できるようそのまま返す
+func wrapUpdateError(message string, err error) error {
+	var conflict *models.VersionConflictError
+	if errors.As(err, &conflict) {
+		return err
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 楽観的排他制御テスト
+ */
+
+func TestUpdateInventoryQuantity_StaleIfMatch(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
+
+	ctx := context.Background()
+	mockRepo.On("GetInventoryByStockKey", ctx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(
+		&models.Inventory{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable, Version: 4}, nil)
+
+	inventory, err := service.UpdateInventoryQuantity(ctx, 1, "東京倉庫", 150, 3)
+
+	var conflictErr *models.VersionConflictError
+	if assert.ErrorAs(t, err, &conflictErr) {
+		assert.Equal(t, int64(1), conflictErr.ID)
+		assert.Equal(t, 3, conflictErr.ExpectedVersion)
+		assert.Equal(t, 4, conflictErr.CurrentVersion)
+	}
+	assert.Nil(t, inventory)
+	mockRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateInventoryQuantity_ReturnsNewVersion(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
+
+	ctx := context.Background()
+	mockRepo.On("GetInventoryByStockKey", ctx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(
+		&models.Inventory{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable, Version: 4}, nil)
+	mockRepo.On("UpdateQuantity", ctx, int64(1), 150, 4).Return(nil)
+
+	inventory, err := service.UpdateInventoryQuantity(ctx, 1, "東京倉庫", 150, 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 150, inventory.Quantity)
+	assert.Equal(t, 5, inventory.Version)
+}
+
+func TestTransferInventory_PassesThroughVersionConflict(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, mockWarehouseRepo)
+
+	ctx := context.Background()
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable, Version: 2},
+	}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(nil, repository.ErrNotFound)
+	mockRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 11
+	}).Return(nil)
+	// 読み取り後に他の操作で移動元の在庫行が更新されていた
+	mockRepo.On("UpdateQuantity", repository.WithLedgerMovement(ctx, 11), int64(1), 70, 2).
+		Return(&models.VersionConflictError{Resource: "在庫", ID: 1, ExpectedVersion: 2, CurrentVersion: 3})
+
+	err := service.TransferInventory(ctx, 1, "東京倉庫", "大阪倉庫", 30)
+
+	var conflictErr *models.VersionConflictError
+	if assert.ErrorAs(t, err, &conflictErr) {
+		assert.Equal(t, 3, conflictErr.CurrentVersion)
+	}
+}
+
+func TestUpdateDeliveryStatus_StaleIfMatch(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, new(mocks.MockWarehouseRepository), mockNotifyService)
+
+	ctx := context.Background()
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(&models.Delivery{
+		ID:            1,
+		OrderID:       1,
+		Status:        models.DeliveryStatusScheduled,
+		EstimatedTime: time.Now().Add(24 * time.Hour),
+		Version:       6,
+	}, nil)
+
+	delivery, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{
+		Status:          models.DeliveryStatusInTransit,
+		ExpectedVersion: 5,
+	}, 7)
+
+	var conflictErr *models.VersionConflictError
+	if assert.ErrorAs(t, err, &conflictErr) {
+		assert.Equal(t, "配送", conflictErr.Resource)
+		assert.Equal(t, 6, conflictErr.CurrentVersion)
+	}
+	assert.Nil(t, delivery)
+	mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+	mockReservationRepo.AssertNotCalled(t, "ListReservationsByDelivery", mock.Anything, mock.Anything)
+}
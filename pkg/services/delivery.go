@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"tea-logistics/pkg/geo"
 	"tea-logistics/pkg/models"
 	"tea-logistics/pkg/repository"
 )
@@ -18,67 +20,187 @@ import (
 type DeliveryService struct {
 	repo          repository.DeliveryRepository
 	inventoryRepo repository.InventoryRepository
+	uow           repository.UnitOfWork
 	notifyService NotificationService
+	planner       *RoutePlanner
+	geocoder      geo.Geocoder
 }
 
 // NewDeliveryService 配送サービスを作成する
+// plannerがnilの場合は、配送のキャンセル時に配送ルートを計算し直さない
+// geocoderがnilの場合は、配送先住所の正規化・位置の特定を行わない
 func NewDeliveryService(
 	repo repository.DeliveryRepository,
 	inventoryRepo repository.InventoryRepository,
+	uow repository.UnitOfWork,
 	notifyService NotificationService,
+	planner *RoutePlanner,
+	geocoder geo.Geocoder,
 ) *DeliveryService {
 	return &DeliveryService{
 		repo:          repo,
 		inventoryRepo: inventoryRepo,
+		uow:           uow,
 		notifyService: notifyService,
+		planner:       planner,
+		geocoder:      geocoder,
 	}
 }
 
-// CreateDelivery 配送を作成する
-func (s *DeliveryService) CreateDelivery(ctx context.Context, req *models.CreateDeliveryRequest) (*models.Delivery, error) {
-	// 在庫の確認
-	inventory, err := s.inventoryRepo.GetInventory(ctx, req.ProductID)
+// lineAllocation 配送明細の在庫割当の条件（基本単位の数量・ロット・割当方式・割当候補の倉庫）
+type lineAllocation struct {
+	quantity   int
+	lot        *models.Lot
+	strategy   AllocationStrategy
+	warehouses []*models.Warehouse
+}
+
+// planLineAllocation トランザクション内で配送明細の在庫割当の条件を決める
+// ロットを指定した場合は指定ロットから引き当てる
+func planLineAllocation(ctx context.Context, tx *repository.TxRepositories, origin *models.Warehouse, line models.CreateDeliveryItemRequest) (*lineAllocation, error) {
+	quantity, _, err := toBaseQuantity(ctx, tx.Variants, line.ProductID, line.Unit, line.Quantity)
 	if err != nil {
-		return nil, fmt.Errorf("在庫確認エラー: %v", err)
+		return nil, err
 	}
 
-	if inventory.Quantity < req.Quantity {
-		return nil, fmt.Errorf("在庫が不足しています")
+	lot, err := resolveLot(ctx, tx, line.ProductID, line.LotNumber)
+	if err != nil {
+		return nil, err
 	}
-
-	// 在庫の更新
-	inventory.Quantity -= req.Quantity
-	if err := s.inventoryRepo.UpdateInventory(ctx, inventory); err != nil {
-		return nil, fmt.Errorf("在庫更新エラー: %v", err)
+	if lot != nil && lot.IsExpired(time.Now()) {
+		return nil, &models.InvalidLotError{LotNumber: lot.LotNumber, Reason: "は賞味期限を過ぎています"}
 	}
 
-	// 配送の作成
-	delivery := &models.Delivery{
-		OrderID:         req.OrderID,
-		Status:          "pending",
-		FromWarehouseID: req.FromWarehouseID,
-		ToAddress:       req.ToAddress,
-		EstimatedTime:   req.EstimatedTime,
+	strategy, err := allocationStrategyFor(ctx, tx, line.ProductID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
-		// 配送作成に失敗した場合、在庫を戻す
-		inventory.Quantity += req.Quantity
-		if updateErr := s.inventoryRepo.UpdateInventory(ctx, inventory); updateErr != nil {
-			return nil, fmt.Errorf("配送作成エラー: %v, 在庫復元エラー: %v", err, updateErr)
-		}
-		return nil, fmt.Errorf("配送作成エラー: %v", err)
+	warehouses, err := allocationWarehouses(ctx, tx, strategy, origin)
+	if err != nil {
+		return nil, err
 	}
 
-	// 配送商品の作成
-	item := &models.DeliveryItem{
-		DeliveryID: delivery.ID,
-		ProductID:  req.ProductID,
-		Quantity:   req.Quantity,
+	return &lineAllocation{quantity: quantity, lot: lot, strategy: strategy, warehouses: warehouses}, nil
+}
+
+// CreateDelivery 配送を作成する
+// 配送先住所は正規化して位置を求め、解釈できない住所は要確認として登録する
+func (s *DeliveryService) CreateDelivery(ctx context.Context, req *models.CreateDeliveryRequest) (*models.Delivery, error) {
+	if err := validateDeliveryItems(req.Items); err != nil {
+		return nil, err
 	}
+	destination := locateAddress(ctx, s.geocoder, req.ToAddress, req.ToLatitude, req.ToLongitude)
+
+	// 全明細の在庫引当と配送・配送商品の作成を単一トランザクションで実行する
+	var delivery *models.Delivery
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		// 出荷元倉庫の確認
+		warehouse, err := tx.Warehouses.GetWarehouse(ctx, req.FromWarehouseID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return &models.DeliveryValidationError{Message: fmt.Sprintf("出荷元倉庫（ID %d）が見つかりません", req.FromWarehouseID)}
+		}
+		if err != nil {
+			return fmt.Errorf("出荷元倉庫取得エラー: %v", err)
+		}
+
+		// 配送の作成
+		delivery = &models.Delivery{
+			OrderID:         req.OrderID,
+			Status:          models.DeliveryStatusPending,
+			FromWarehouseID: req.FromWarehouseID,
+			ToAddress:       destination.Address,
+			ToLatitude:      destination.Latitude,
+			ToLongitude:     destination.Longitude,
+			AddressStatus:   destination.Status,
+			AddressNote:     destination.Note,
+			EstimatedTime:   req.EstimatedTime,
+		}
+
+		if err := tx.Deliveries.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("配送作成エラー: %v", err)
+		}
+
+		// 明細ごとの割当方式と割当候補の倉庫を先に決め、全明細の在庫のロックを一定の順序で取得する
+		// 荷姿を指定した明細は、数量を基本単位に換算して割り当てる
+		plans := make([]*lineAllocation, len(req.Items))
+		var keys []stockKey
+		for i, line := range req.Items {
+			plan, err := planLineAllocation(ctx, tx, warehouse, line)
+			if err != nil {
+				return &models.DeliveryLineError{Line: i + 1, Err: err}
+			}
+			plans[i] = plan
+			for _, w := range plan.warehouses {
+				keys = append(keys, stockKey{ProductID: line.ProductID, Location: w.Name})
+			}
+		}
+		if err := lockStocks(ctx, tx, keys); err != nil {
+			return err
+		}
+
+		// 商品カテゴリの割当方式に従って在庫を割り当て、出荷まで引き当てる（在庫数は出荷確定時に減らす）
+		// 1つの明細を複数の倉庫・ロットに分割して割り当てた場合は、割当ごとに在庫引当を作成する
+		// 配送の引当は出荷・キャンセルまで保持するため、有効期限を設けない
+		for i, line := range req.Items {
+			plan := plans[i]
+			quantity, lot, strategy := plan.quantity, plan.lot, plan.strategy
+
+			picks, err := allocateStock(ctx, tx, strategy, warehouse, plan.warehouses, line.ProductID, lot, quantity)
+			if err != nil {
+				return &models.DeliveryLineError{Line: i + 1, Err: err}
+			}
+
+			// 配送商品の作成
+			item := &models.DeliveryItem{
+				DeliveryID:   delivery.ID,
+				ProductID:    line.ProductID,
+				LotID:        lotIDOf(lot),
+				Quantity:     quantity,
+				Unit:         unitOrBase(line.Unit),
+				UnitQuantity: line.Quantity,
+				Status:       models.DeliveryItemStatusActive,
+			}
+
+			if err := tx.Deliveries.CreateDeliveryItem(ctx, item); err != nil {
+				return fmt.Errorf("配送商品作成エラー: %v", err)
+			}
+
+			for _, pick := range picks {
+				reservation := &models.Reservation{
+					LotID:           pick.Candidate.LotID(),
+					Quantity:        pick.Quantity,
+					DeliveryID:      &delivery.ID,
+					DeliveryItemID:  &item.ID,
+					ReferenceNumber: deliveryReference(delivery),
+				}
+				if err := reserveStock(ctx, tx, pick.Candidate.stock, reservation); err != nil {
+					return &models.DeliveryLineError{Line: i + 1, Err: err}
+				}
+
+				allocation := &models.DeliveryAllocation{
+					DeliveryID:     delivery.ID,
+					DeliveryItemID: item.ID,
+					ProductID:      line.ProductID,
+					WarehouseID:    pick.Candidate.WarehouseID,
+					Location:       pick.Candidate.Location,
+					LotID:          pick.Candidate.LotID(),
+					Quantity:       pick.Quantity,
+					Strategy:       strategy.Type(),
+				}
+				if err := tx.Allocations.CreateDeliveryAllocation(ctx, allocation); err != nil {
+					return fmt.Errorf("在庫割当記録エラー: %v", err)
+				}
+				delivery.Allocations = append(delivery.Allocations, allocation)
+			}
+
+			delivery.Items = append(delivery.Items, item)
+		}
 
-	if err := s.repo.CreateDeliveryItem(ctx, item); err != nil {
-		return nil, fmt.Errorf("配送商品作成エラー: %v", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 配送作成の通知
@@ -92,6 +214,28 @@ func (s *DeliveryService) CreateDelivery(ctx context.Context, req *models.Create
 	return delivery, nil
 }
 
+// ListDeliveryAllocations 配送の在庫割当結果を取得する
+func (s *DeliveryService) ListDeliveryAllocations(ctx context.Context, deliveryID int64) ([]*models.DeliveryAllocation, error) {
+	if _, err := s.repo.GetDelivery(ctx, deliveryID); err != nil {
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
+	}
+
+	var allocations []*models.DeliveryAllocation
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		found, err := tx.Allocations.ListDeliveryAllocations(ctx, deliveryID)
+		if err != nil {
+			return fmt.Errorf("在庫割当取得エラー: %v", err)
+		}
+		allocations = found
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return allocations, nil
+}
+
 // GetDelivery 配送を取得する
 func (s *DeliveryService) GetDelivery(ctx context.Context, id int64) (*models.Delivery, error) {
 	delivery, err := s.repo.GetDelivery(ctx, id)
@@ -99,44 +243,165 @@ func (s *DeliveryService) GetDelivery(ctx context.Context, id int64) (*models.De
 		return nil, fmt.Errorf("配送取得エラー: %v", err)
 	}
 
+	items, err := s.repo.ListDeliveryItems(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("配送商品取得エラー: %v", err)
+	}
+	delivery.Items = items
+
 	return delivery, nil
 }
 
+// GetShipment 配送の出荷重量・容積を計算する
+// 荷姿を指定した明細は荷姿の重量（未登録の場合は正味重量）・容積で、基本単位の明細は正味重量で計算する
+func (s *DeliveryService) GetShipment(ctx context.Context, id int64) (*models.Shipment, error) {
+	if _, err := s.repo.GetDelivery(ctx, id); err != nil {
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
+	}
+
+	items, err := s.repo.ListDeliveryItems(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("配送商品取得エラー: %v", err)
+	}
+
+	shipment := &models.Shipment{DeliveryID: id, Lines: []*models.ShipmentLine{}}
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		for _, item := range items {
+			line, err := shipmentLine(ctx, tx.Variants, item)
+			if err != nil {
+				return err
+			}
+			shipment.WeightGrams += line.WeightGrams
+			shipment.VolumeML += line.VolumeML
+			shipment.Lines = append(shipment.Lines, line)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// shipmentLine 配送明細のキャンセル分を除いた出荷重量・容積を計算する
+// 配送作成後に荷姿が削除された場合は、正味重量と作成時の荷姿の数量の比率で計算する
+func shipmentLine(ctx context.Context, variants repository.ProductVariantRepository, item *models.DeliveryItem) (*models.ShipmentLine, error) {
+	remaining := item.RemainingQuantity()
+	line := &models.ShipmentLine{
+		DeliveryItemID: item.ID,
+		ProductID:      item.ProductID,
+		Unit:           unitOrBase(item.Unit),
+		UnitQuantity:   remaining,
+		Quantity:       remaining,
+		WeightGrams:    remaining,
+	}
+	if models.IsBaseUnit(item.Unit) {
+		return line, nil
+	}
+
+	variant, err := findVariant(ctx, variants, item.ProductID, item.Unit)
+	var unitErr *models.UnknownUnitError
+	if errors.As(err, &unitErr) {
+		if item.Quantity > 0 {
+			line.UnitQuantity = item.UnitQuantity * remaining / item.Quantity
+		}
+		return line, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line.UnitQuantity = variant.Packages(remaining)
+	if variant.WeightGrams > 0 {
+		line.WeightGrams = line.UnitQuantity * variant.WeightGrams
+	}
+	line.VolumeML = line.UnitQuantity * variant.VolumeML
+	return line, nil
+}
+
 // ListDeliveries 配送一覧を取得する
-func (s *DeliveryService) ListDeliveries(ctx context.Context) ([]*models.Delivery, error) {
-	deliveries, err := s.repo.ListDeliveries(ctx)
+func (s *DeliveryService) ListDeliveries(ctx context.Context, spec *repository.QuerySpec) ([]*models.Delivery, *repository.PageInfo, error) {
+	deliveries, page, err := s.repo.ListDeliveries(ctx, spec)
 	if err != nil {
-		return nil, fmt.Errorf("配送一覧取得エラー: %v", err)
+		return nil, nil, wrapListError("配送一覧取得エラー", err)
 	}
 
-	return deliveries, nil
+	return deliveries, page, nil
 }
 
-// UpdateDeliveryStatus 配送ステータスを更新する
-func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, status string) error {
+// UpdateDeliveryStatus 配送ステータスを更新し、更新後の配送を返す
+// 状態遷移ルールに反する場合は*models.StatusTransitionErrorを返す
+// req.ExpectedVersionを指定した場合は、配送のバージョンが一致しなければ*models.VersionConflictErrorを返す
+func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, req *models.UpdateDeliveryStatusRequest, changedBy int64) (*models.Delivery, error) {
 	delivery, err := s.repo.GetDelivery(ctx, id)
 	if err != nil {
-		return fmt.Errorf("配送取得エラー: %v", err)
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
 	}
-
-	delivery.Status = status
-	if status == "delivered" {
-		delivery.ActualTime = time.Now()
+	if err := checkExpectedVersion("配送", delivery.ID, req.ExpectedVersion, delivery.Version); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
-		return fmt.Errorf("配送更新エラー: %v", err)
+	// ステータス更新・履歴記録・引当の解放/保持/確定を単一トランザクションで実行する
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		return s.transitionStatus(ctx, tx, delivery, req.Status, changedBy, req.Reason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// ステータス更新の通知
 	if s.notifyService != nil {
-		if err := s.notifyService.NotifyDeliveryStatusChange(ctx, delivery); err != nil {
+		notify := s.notifyService.NotifyDeliveryStatusChange
+		if delivery.Status == models.DeliveryStatusDelivered {
+			notify = s.notifyService.NotifyDeliveryComplete
+		}
+		if err := notify(ctx, delivery); err != nil {
 			// 通知エラーはログに記録するだけで、ステータス更新自体は成功とする
 			fmt.Printf("通知エラー: %v\n", err)
 		}
 	}
 
-	return nil
+	return delivery, nil
+}
+
+// UpdateDeliveryAddress 配送先住所を修正し、位置を求め直す
+// 要確認の住所の修正に使用し、出荷前（pending・scheduled）の配送のみ修正できる
+// 位置が変わった配送を含む配送ルートは、配送ルートの再計算で反映する
+func (s *DeliveryService) UpdateDeliveryAddress(ctx context.Context, id int64, req *models.UpdateAddressRequest) (*models.Delivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
+	}
+	if err := checkExpectedVersion("配送", delivery.ID, req.ExpectedVersion, delivery.Version); err != nil {
+		return nil, err
+	}
+	if delivery.Status != models.DeliveryStatusPending && delivery.Status != models.DeliveryStatusScheduled {
+		return nil, fmt.Errorf("ステータスが「%s」の配送の住所は修正できません", delivery.Status)
+	}
+
+	destination := locateAddress(ctx, s.geocoder, req.ToAddress, req.ToLatitude, req.ToLongitude)
+	delivery.ToAddress = destination.Address
+	delivery.ToLatitude = destination.Latitude
+	delivery.ToLongitude = destination.Longitude
+	delivery.AddressStatus = destination.Status
+	delivery.AddressNote = destination.Note
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, wrapUpdateError("配送更新エラー", err)
+	}
+
+	return delivery, nil
+}
+
+// ListStatusHistory 配送ステータス変更履歴を取得する
+func (s *DeliveryService) ListStatusHistory(ctx context.Context, deliveryID int64) ([]*models.DeliveryStatusHistory, error) {
+	histories, err := s.repo.ListStatusHistory(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("配送ステータス履歴取得エラー: %v", err)
+	}
+
+	return histories, nil
 }
 
 // CreateDeliveryTracking 配送追跡を作成する
@@ -174,47 +439,282 @@ func (s *DeliveryService) ListDeliveryTrackings(ctx context.Context, deliveryID
 }
 
 // CompleteDelivery 配送を完了する
-func (s *DeliveryService) CompleteDelivery(ctx context.Context, id int64) error {
-	delivery, err := s.repo.GetDelivery(ctx, id)
+func (s *DeliveryService) CompleteDelivery(ctx context.Context, id int64, changedBy int64) error {
+	_, err := s.UpdateDeliveryStatus(ctx, id, &models.UpdateDeliveryStatusRequest{
+		Status: models.DeliveryStatusDelivered,
+	}, changedBy)
+	return err
+}
+
+// CancelDeliveryItem 配送明細の一部または全数量をキャンセルし、更新後の配送と明細を返す
+// req.Quantityに0を指定した場合は残数量をすべてキャンセルする
+// 配送のバージョンを進めることで、並行するステータス変更・明細のキャンセルと直列化する
+func (s *DeliveryService) CancelDeliveryItem(ctx context.Context, deliveryID, itemID int64, req *models.CancelDeliveryItemRequest) (*models.Delivery, *models.DeliveryItem, error) {
+	var delivery *models.Delivery
+	var item *models.DeliveryItem
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		found, err := tx.Deliveries.GetDelivery(ctx, deliveryID)
+		if err != nil {
+			return fmt.Errorf("配送取得エラー: %v", err)
+		}
+		delivery = found
+		if err := checkExpectedVersion("配送", delivery.ID, req.ExpectedVersion, delivery.Version); err != nil {
+			return err
+		}
+		switch delivery.Status {
+		case models.DeliveryStatusPending, models.DeliveryStatusScheduled:
+		default:
+			return &models.DeliveryItemCancelStatusError{DeliveryID: delivery.ID, Status: delivery.Status}
+		}
+
+		// 読み取り時のバージョンで配送を更新して行をロックし、読み取り後のステータス変更を検出する
+		if err := tx.Deliveries.UpdateDelivery(ctx, delivery); err != nil {
+			return wrapUpdateError("配送更新エラー", err)
+		}
+
+		foundItem, err := tx.Deliveries.GetDeliveryItem(ctx, itemID)
+		if err != nil {
+			return fmt.Errorf("配送商品取得エラー: %v", err)
+		}
+		if foundItem.DeliveryID != deliveryID {
+			return fmt.Errorf("配送商品取得エラー: %v", repository.ErrNotFound)
+		}
+		item = foundItem
+
+		remaining := item.RemainingQuantity()
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = remaining
+		}
+		if remaining == 0 || quantity > remaining {
+			return &models.CancelQuantityError{ItemID: item.ID, Quantity: quantity, Remaining: remaining}
+		}
+
+		// 明細に紐づく引当中の在庫を減らす
+		reservations, err := tx.Reservations.ListReservationsByDelivery(ctx, deliveryID)
+		if err != nil {
+			return fmt.Errorf("在庫引当取得エラー: %v", err)
+		}
+		// 複数の倉庫・ロットに分割して引き当てている場合は、後から割り当てた引当から順に減らす
+		toReduce := quantity
+		for j := len(reservations) - 1; j >= 0 && toReduce > 0; j-- {
+			reservation := reservations[j]
+			if reservation.DeliveryItemID == nil || *reservation.DeliveryItemID != itemID ||
+				reservation.Status != models.ReservationStatusActive {
+				continue
+			}
+			reduce := reservation.Quantity
+			if reduce > toReduce {
+				reduce = toReduce
+			}
+			if err := reduceReservation(ctx, tx, reservation, reduce); err != nil {
+				return err
+			}
+			toReduce -= reduce
+		}
+		// 在庫割当結果も同じ順に減らし、引当と一致させる
+		if err := reduceDeliveryAllocations(ctx, tx, deliveryID, itemID, quantity); err != nil {
+			return err
+		}
+
+		item.CancelledQuantity += quantity
+		if item.RemainingQuantity() == 0 {
+			item.Status = models.DeliveryItemStatusCancelled
+		}
+
+		if err := tx.Deliveries.UpdateDeliveryItem(ctx, item); err != nil {
+			return fmt.Errorf("配送商品更新エラー: %v", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("配送取得エラー: %v", err)
+		return nil, nil, err
 	}
 
-	if delivery.Status != "in_transit" {
-		return fmt.Errorf("配送中の配送のみ完了できます")
+	return delivery, item, nil
+}
+
+// transitionStatus トランザクション内で配送ステータスを遷移させ、変更履歴を記録する
+// 遷移先に応じて在庫引当の解放（キャンセル）・保持（出荷）・確定（配送完了）を行う
+// キャンセル時は予定中の配送スケジュールを取り消し、割り当てていた車両の配送ルートを計算し直す
+func (s *DeliveryService) transitionStatus(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	delivery *models.Delivery,
+	to models.DeliveryStatus,
+	changedBy int64,
+	reason string,
+) error {
+	from := delivery.Status
+	if err := from.ValidateTransition(to); err != nil {
+		return err
 	}
 
-	// 在庫の更新
-	items, err := s.repo.ListDeliveryItems(ctx, id)
+	switch to {
+	case models.DeliveryStatusCancelled:
+		if err := s.releaseDeliveryReservations(ctx, tx, delivery.ID); err != nil {
+			return err
+		}
+		if err := s.cancelDeliveryItems(ctx, tx, delivery.ID); err != nil {
+			return err
+		}
+		cancelled, err := tx.Schedules.CancelDeliverySchedules(ctx, delivery.ID)
+		if err != nil {
+			return fmt.Errorf("配送スケジュール取消エラー: %v", err)
+		}
+		if err := replanRoutes(ctx, tx, s.planner, cancelled...); err != nil {
+			return err
+		}
+	case models.DeliveryStatusInTransit:
+		if err := s.holdDeliveryReservations(ctx, tx, delivery.ID); err != nil {
+			return err
+		}
+	case models.DeliveryStatusDelivered:
+		// 引当中の在庫を確定し、在庫数を減らす
+		if err := s.commitDeliveryReservations(ctx, tx, delivery.ID); err != nil {
+			return err
+		}
+		delivery.ActualTime = time.Now()
+	case models.DeliveryStatusReturned:
+		// 配送中の返品は出荷済みのため、引当を確定して出荷元の在庫数を減らしておく
+		if from == models.DeliveryStatusInTransit {
+			if err := s.commitDeliveryReservations(ctx, tx, delivery.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	delivery.Status = to
+	if err := tx.Deliveries.UpdateDelivery(ctx, delivery); err != nil {
+		return wrapUpdateError("配送更新エラー", err)
+	}
+
+	history := &models.DeliveryStatusHistory{
+		DeliveryID: delivery.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+	}
+	if changedBy > 0 {
+		history.ChangedBy = &changedBy
+	}
+	if err := tx.Deliveries.CreateStatusHistory(ctx, history); err != nil {
+		return fmt.Errorf("配送ステータス履歴作成エラー: %v", err)
+	}
+
+	return nil
+}
+
+// cancelDeliveryItems 配送の有効な明細をすべてキャンセル済みにする
+func (s *DeliveryService) cancelDeliveryItems(ctx context.Context, tx *repository.TxRepositories, deliveryID int64) error {
+	items, err := tx.Deliveries.ListDeliveryItems(ctx, deliveryID)
 	if err != nil {
 		return fmt.Errorf("配送商品取得エラー: %v", err)
 	}
 
 	for _, item := range items {
-		inventory, err := s.inventoryRepo.GetInventory(ctx, item.ProductID)
-		if err != nil {
-			return fmt.Errorf("在庫取得エラー: %v", err)
+		if item.Status == models.DeliveryItemStatusCancelled {
+			continue
+		}
+		item.CancelledQuantity = item.Quantity
+		item.Status = models.DeliveryItemStatusCancelled
+		if err := tx.Deliveries.UpdateDeliveryItem(ctx, item); err != nil {
+			return fmt.Errorf("配送商品更新エラー: %v", err)
 		}
+	}
+
+	return nil
+}
 
-		inventory.Quantity -= item.Quantity
-		if err := s.inventoryRepo.UpdateInventory(ctx, inventory); err != nil {
-			return fmt.Errorf("在庫更新エラー: %v", err)
+// validateDeliveryItems 配送明細を検証する
+func validateDeliveryItems(items []models.CreateDeliveryItemRequest) error {
+	if len(items) == 0 {
+		return &models.DeliveryValidationError{Message: "配送明細を1件以上指定してください"}
+	}
+
+	// 同じ商品でも荷姿が異なる明細は別の明細として扱う
+	type lineKey struct {
+		productID int64
+		unit      string
+	}
+	seen := make(map[lineKey]bool, len(items))
+	for i, item := range items {
+		if item.Quantity <= 0 {
+			return &models.DeliveryValidationError{Line: i + 1, Message: "数量は1以上を指定してください"}
 		}
+		key := lineKey{item.ProductID, unitOrBase(item.Unit)}
+		if seen[key] {
+			return &models.DeliveryValidationError{Line: i + 1, Message: fmt.Sprintf("商品ID %d（%s）が重複しています", item.ProductID, key.unit)}
+		}
+		seen[key] = true
 	}
 
-	// 配送ステータスの更新
-	delivery.Status = "delivered"
-	delivery.ActualTime = time.Now()
+	return nil
+}
 
-	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
-		return fmt.Errorf("配送更新エラー: %v", err)
+// deliveryReference 配送の引当参照番号を生成する
+func deliveryReference(delivery *models.Delivery) string {
+	return fmt.Sprintf("DLV-%d", delivery.ID)
+}
+
+// releaseDeliveryReservations 配送に紐づく引当中の在庫を解放する
+func (s *DeliveryService) releaseDeliveryReservations(ctx context.Context, tx *repository.TxRepositories, deliveryID int64) error {
+	reservations, err := tx.Reservations.ListReservationsByDelivery(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("在庫引当取得エラー: %v", err)
 	}
 
-	// 配送完了の通知
-	if s.notifyService != nil {
-		if err := s.notifyService.NotifyDeliveryComplete(ctx, delivery); err != nil {
-			// 通知エラーはログに記録するだけで、配送完了自体は成功とする
-			fmt.Printf("通知エラー: %v\n", err)
+	for _, reservation := range reservations {
+		if reservation.Status != models.ReservationStatusActive {
+			continue
+		}
+		if err := releaseReservation(ctx, tx, reservation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// holdDeliveryReservations 出荷済みの配送の引当を無期限にする
+// 有効期限を設けていた以前の配送の引当が、出荷後に期限切れにならないようにする
+func (s *DeliveryService) holdDeliveryReservations(ctx context.Context, tx *repository.TxRepositories, deliveryID int64) error {
+	reservations, err := tx.Reservations.ListReservationsByDelivery(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("在庫引当取得エラー: %v", err)
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationStatusExpired {
+			return fmt.Errorf("在庫引当の有効期限が切れているため出荷できません")
+		}
+		if reservation.Status != models.ReservationStatusActive || reservation.ExpiresAt == nil {
+			continue
+		}
+		if err := tx.Reservations.UpdateReservationExpiry(ctx, reservation.ID, nil); err != nil {
+			return fmt.Errorf("在庫引当期限更新エラー: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// commitDeliveryReservations 配送に紐づく引当中の在庫を確定する
+func (s *DeliveryService) commitDeliveryReservations(ctx context.Context, tx *repository.TxRepositories, deliveryID int64) error {
+	reservations, err := tx.Reservations.ListReservationsByDelivery(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("在庫引当取得エラー: %v", err)
+	}
+
+	for _, reservation := range reservations {
+		switch reservation.Status {
+		case models.ReservationStatusActive:
+			if err := commitReservation(ctx, tx, reservation); err != nil {
+				return err
+			}
+		case models.ReservationStatusExpired:
+			return fmt.Errorf("在庫引当の有効期限が切れています")
 		}
 	}
 
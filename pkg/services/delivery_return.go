@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 配送キャンセル・返品サービス
+ * 出荷前のキャンセルと、出荷後の返品入庫（リバースロジスティクス）を実装する
+ */
+
+// returnLine 返品入庫する明細
+type returnLine struct {
+	item       *models.DeliveryItem
+	quantity   int
+	quarantine bool
+}
+
+// CancelDelivery 出荷前の配送をキャンセルし、在庫引当を解放する
+func (s *DeliveryService) CancelDelivery(ctx context.Context, id int64, req *models.CancelDeliveryRequest, changedBy int64) error {
+	_, err := s.UpdateDeliveryStatus(ctx, id, &models.UpdateDeliveryStatusRequest{
+		Status: models.DeliveryStatusCancelled,
+		Reason: req.Reason,
+	}, changedBy)
+	return err
+}
+
+// ReturnDelivery 配送の返品を処理する
+// 出荷前の配送は引当を解放してキャンセルとし、出荷後の配送は指定ロケーションへ返品入庫する
+// 隔離を指定した明細は隔離在庫として入庫し、販売可能在庫には含めない
+// 全明細の配送数量が返品済みになった時点で返品ステータスにし、一部返品の間はステータスを変えない
+func (s *DeliveryService) ReturnDelivery(ctx context.Context, id int64, req *models.ReturnDeliveryRequest, changedBy int64) (*models.DeliveryReturn, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
+	}
+
+	switch delivery.Status {
+	case models.DeliveryStatusPending, models.DeliveryStatusScheduled:
+		// 出荷前のため在庫は引き当てているだけであり、解放すれば元に戻る
+		if err := s.CancelDelivery(ctx, id, &models.CancelDeliveryRequest{Reason: req.Reason}, changedBy); err != nil {
+			return nil, err
+		}
+		return &models.DeliveryReturn{
+			DeliveryID: id,
+			Status:     models.DeliveryStatusCancelled,
+			Movements:  []*models.InventoryMovement{},
+		}, nil
+	case models.DeliveryStatusInTransit, models.DeliveryStatusDelivered:
+	default:
+		return nil, &models.StatusTransitionError{From: delivery.Status, To: models.DeliveryStatusReturned}
+	}
+
+	result := &models.DeliveryReturn{
+		DeliveryID: id,
+		Location:   req.Location,
+		Movements:  []*models.InventoryMovement{},
+	}
+
+	// 返品入庫・在庫移動記録・ステータス更新を単一トランザクションで実行する
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := checkLocationNotFrozen(ctx, tx, req.Location); err != nil {
+			return err
+		}
+
+		items, err := tx.Deliveries.ListDeliveryItems(ctx, id)
+		if err != nil {
+			return fmt.Errorf("配送商品取得エラー: %v", err)
+		}
+
+		lines, err := resolveReturnLines(items, req)
+		if err != nil {
+			return err
+		}
+
+		// 返品する商品の在庫のロックを一定の順序で取得してから入庫する
+		keys := make([]stockKey, 0, len(lines))
+		for _, line := range lines {
+			keys = append(keys, stockKey{ProductID: line.item.ProductID, Location: req.Location})
+		}
+		if err := lockStocks(ctx, tx, keys); err != nil {
+			return err
+		}
+
+		if fullyReturned(items, lines) {
+			if err := s.transitionStatus(ctx, tx, delivery, models.DeliveryStatusReturned, changedBy, req.Reason); err != nil {
+				return err
+			}
+		} else {
+			// 一部返品でも配送のバージョンを進め、同じ配送への返品を直列化する
+			if err := tx.Deliveries.UpdateDelivery(ctx, delivery); err != nil {
+				return wrapUpdateError("配送更新エラー", err)
+			}
+		}
+
+		for _, line := range lines {
+			movement, err := receiveReturnedStock(ctx, tx, delivery, line, req.Location)
+			if err != nil {
+				return err
+			}
+			result.Movements = append(result.Movements, movement)
+
+			line.item.ReturnedQuantity += line.quantity
+			if err := tx.Deliveries.UpdateDeliveryItem(ctx, line.item); err != nil {
+				return fmt.Errorf("配送商品更新エラー: %v", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Status = delivery.Status
+
+	logger.Info("返品入庫完了", map[string]interface{}{
+		"delivery_id":    id,
+		"location":       req.Location,
+		"movement_count": len(result.Movements),
+	})
+
+	// 返品の通知
+	if s.notifyService != nil {
+		if err := s.notifyService.NotifyDeliveryReturned(ctx, delivery, result); err != nil {
+			// 通知エラーはログに記録するだけで、返品自体は成功とする
+			fmt.Printf("通知エラー: %v\n", err)
+		}
+	}
+
+	return result, nil
+}
+
+// resolveReturnLines 返品リクエストを返品入庫する明細に変換する
+func resolveReturnLines(items []*models.DeliveryItem, req *models.ReturnDeliveryRequest) ([]returnLine, error) {
+	// 明細指定がない場合は全明細の配送数量を返品とする
+	if len(req.Items) == 0 {
+		var lines []returnLine
+		for _, item := range items {
+			if quantity := item.RemainingQuantity() - item.ReturnedQuantity; quantity > 0 {
+				lines = append(lines, returnLine{item: item, quantity: quantity, quarantine: req.Quarantine})
+			}
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("返品可能な明細がありません")
+		}
+		return lines, nil
+	}
+
+	itemsByID := make(map[int64]*models.DeliveryItem, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+	}
+
+	lines := make([]returnLine, 0, len(req.Items))
+	seen := make(map[int64]bool, len(req.Items))
+	for _, r := range req.Items {
+		item, ok := itemsByID[r.ItemID]
+		if !ok {
+			return nil, fmt.Errorf("明細ID %d はこの配送の明細ではありません", r.ItemID)
+		}
+		if seen[r.ItemID] {
+			return nil, fmt.Errorf("明細ID %d が重複しています", r.ItemID)
+		}
+		seen[r.ItemID] = true
+
+		if returnable := item.RemainingQuantity() - item.ReturnedQuantity; r.Quantity > returnable {
+			return nil, fmt.Errorf("明細ID %d の返品数量が返品可能数量(%d)を超えています", r.ItemID, returnable)
+		}
+		lines = append(lines, returnLine{item: item, quantity: r.Quantity, quarantine: r.Quarantine})
+	}
+
+	return lines, nil
+}
+
+// fullyReturned 今回の返品で全明細の配送数量（キャンセル分を除く）が返品済みになるかどうかを判定する
+func fullyReturned(items []*models.DeliveryItem, lines []returnLine) bool {
+	returning := make(map[int64]int, len(lines))
+	for _, line := range lines {
+		returning[line.item.ID] += line.quantity
+	}
+	for _, item := range items {
+		if item.RemainingQuantity()-item.ReturnedQuantity-returning[item.ID] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// receiveReturnedStock トランザクション内で返品をロケーション在庫に入庫し、返品の在庫移動を記録する
+func receiveReturnedStock(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	delivery *models.Delivery,
+	line returnLine,
+	location string,
+) (*models.InventoryMovement, error) {
+	productID := line.item.ProductID
+
+	status := models.InventoryStatusAvailable
+	if line.quarantine {
+		status = models.InventoryStatusQuarantined
+	}
+	movement := &models.InventoryMovement{
+		ProductID:       productID,
+		FromLocation:    delivery.ToAddress,
+		ToLocation:      location,
+		LotID:           line.item.LotID,
+		Quantity:        line.quantity,
+		MovementType:    models.MovementTypeReturn,
+		MovementDate:    time.Now(),
+		ReferenceNumber: deliveryReference(delivery),
+	}
+	if err := tx.Inventory.CreateMovement(ctx, movement); err != nil {
+		return nil, fmt.Errorf("返品在庫移動作成エラー: %v", err)
+	}
+
+	// 入庫を在庫元帳上で返品の在庫移動に紐付ける
+	ctx = repository.WithLedgerMovement(ctx, movement.ID)
+	if _, err := receiveStock(ctx, tx, productID, location, nil, line.item.LotID, status, line.quantity); err != nil {
+		return nil, wrapUpdateError("返品在庫入庫エラー", err)
+	}
+
+	return movement, nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 配送キャンセル・返品サービステスト
+ */
+
+func TestReturnDelivery_DeliveredWithQuarantine(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusDelivered, FromWarehouseID: 1, ToAddress: "東京都渋谷区"}
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, Status: models.DeliveryItemStatusActive},
+		{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 5, Status: models.DeliveryItemStatusActive},
+	}
+	available := &models.Inventory{ID: 10, ProductID: 1, Quantity: 50, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
+	// 一部返品のためステータスは配送完了のまま、バージョンだけを進める
+	mockRepo.On("UpdateDelivery", ctx, mock.MatchedBy(func(d *models.Delivery) bool {
+		return d.Status == models.DeliveryStatusDelivered
+	})).Return(nil)
+	// 返品の在庫移動を記録してから、入庫を在庫元帳上でこの移動に紐付ける
+	mockInventoryRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeReturn && m.FromLocation == "東京都渋谷区" && m.ToLocation == "東京倉庫"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 30
+	}).Return(nil).Twice()
+	returnCtx := repository.WithLedgerMovement(ctx, 30)
+	mockInventoryRepo.On("GetInventoryByStockKey", returnCtx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(available, nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", returnCtx, int64(2), "東京倉庫", (*int64)(nil), (*int64)(nil), true).Return(nil, repository.ErrNotFound)
+	// 良品は既存の販売可能在庫に戻す
+	mockInventoryRepo.On("UpdateQuantity", returnCtx, int64(10), 53, 0).Return(nil)
+	// 隔離品は販売可能在庫とは別の隔離在庫として作成する
+	mockInventoryRepo.On("CreateInventory", returnCtx, mock.MatchedBy(func(inv *models.Inventory) bool {
+		return inv.ProductID == 2 && inv.Quantity == 2 && inv.Status == models.InventoryStatusQuarantined
+	})).Return(nil)
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil).Twice()
+	mockNotifyService.On("NotifyDeliveryReturned", ctx, delivery, mock.AnythingOfType("*models.DeliveryReturn")).Return(nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{
+		Location: "東京倉庫",
+		Items: []models.ReturnDeliveryItemRequest{
+			{ItemID: 1, Quantity: 3},
+			{ItemID: 2, Quantity: 2, Quarantine: true},
+		},
+		Reason: "破損",
+	}, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusDelivered, result.Status)
+	assert.Len(t, result.Movements, 2)
+	assert.Equal(t, 3, items[0].ReturnedQuantity)
+	assert.Equal(t, 2, items[1].ReturnedQuantity)
+	// 配送完了時に引当は確定済みのため再度確定しない
+	mockReservationRepo.AssertNotCalled(t, "ListReservationsByDelivery", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateStatusHistory", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+	mockInventoryRepo.AssertExpectations(t)
+	mockNotifyService.AssertExpectations(t)
+}
+
+func TestReturnDelivery_RemainingQuantityCompletesReturn(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusDelivered, FromWarehouseID: 1, ToAddress: "東京都渋谷区"}
+	// 明細1は前回の返品で3個返品済み、明細2は2個キャンセル済み
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, ReturnedQuantity: 3, Status: models.DeliveryItemStatusActive},
+		{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 5, CancelledQuantity: 2, ReturnedQuantity: 3, Status: models.DeliveryItemStatusActive},
+	}
+	available := &models.Inventory{ID: 10, ProductID: 1, Quantity: 50, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
+	// 残りの7個を返品すると全明細が返品済みになるため、返品ステータスにする
+	mockRepo.On("UpdateDelivery", ctx, mock.MatchedBy(func(d *models.Delivery) bool {
+		return d.Status == models.DeliveryStatusReturned
+	})).Return(nil)
+	mockRepo.On("CreateStatusHistory", ctx, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
+	mockInventoryRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 33
+	}).Return(nil).Once()
+	returnCtx := repository.WithLedgerMovement(ctx, 33)
+	mockInventoryRepo.On("GetInventoryByStockKey", returnCtx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(available, nil)
+	mockInventoryRepo.On("UpdateQuantity", returnCtx, int64(10), 57, 0).Return(nil)
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil).Once()
+	mockNotifyService.On("NotifyDeliveryReturned", ctx, delivery, mock.AnythingOfType("*models.DeliveryReturn")).Return(nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{
+		Location: "東京倉庫",
+		Items:    []models.ReturnDeliveryItemRequest{{ItemID: 1, Quantity: 7}},
+	}, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusReturned, result.Status)
+	assert.Equal(t, 10, items[0].ReturnedQuantity)
+	mockRepo.AssertExpectations(t)
+	mockInventoryRepo.AssertExpectations(t)
+}
+
+func TestReturnDelivery_InTransitCommitsReservations(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusInTransit, FromWarehouseID: 1, ToAddress: "東京都渋谷区"}
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, Status: models.DeliveryItemStatusActive},
+	}
+	deliveryID := int64(1)
+	reservations := []*models.Reservation{
+		{ID: 1, ProductID: 1, Location: "東京倉庫", Quantity: 10, DeliveryID: &deliveryID, Status: models.ReservationStatusActive},
+	}
+	inventory := &models.Inventory{ID: 10, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	// 出荷分の引当を確定（出庫の在庫移動を記録）してから、明細指定なしのため全数量を返品入庫する
+	mockInventoryRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeOutbound
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 31
+	}).Return(nil).Once()
+	mockInventoryRepo.On("UpdateQuantity", repository.WithLedgerMovement(ctx, 31), int64(10), 90, 0).Return(nil).Once()
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(1), models.ReservationStatusCommitted).Return(nil)
+	mockRepo.On("UpdateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateStatusHistory", ctx, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
+	mockInventoryRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeReturn
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 32
+	}).Return(nil).Once()
+	returnCtx := repository.WithLedgerMovement(ctx, 32)
+	mockInventoryRepo.On("GetInventoryByStockKey", returnCtx, int64(1), "東京倉庫", (*int64)(nil), (*int64)(nil), false).Return(inventory, nil)
+	// 出庫で在庫行のバージョンが1つ進んでいるため、進んだバージョンを条件に更新する
+	mockInventoryRepo.On("UpdateQuantity", returnCtx, int64(10), 100, 1).Return(nil).Once()
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	mockNotifyService.On("NotifyDeliveryReturned", ctx, delivery, mock.AnythingOfType("*models.DeliveryReturn")).Return(nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{Location: "東京倉庫"}, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusReturned, result.Status)
+	assert.Equal(t, 10, items[0].ReturnedQuantity)
+	mockInventoryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestReturnDelivery_BeforeDispatchCancels(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusScheduled, FromWarehouseID: 1}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return([]*models.Reservation{}, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return([]*models.DeliveryItem{}, nil)
+	mockRepo.On("UpdateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateStatusHistory", ctx, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
+	mockNotifyService.On("NotifyDeliveryStatusChange", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{Location: "東京倉庫"}, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusCancelled, result.Status)
+	assert.Empty(t, result.Movements)
+	// 出荷前のため在庫の入庫は行わない
+	mockInventoryRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+	mockNotifyService.AssertNotCalled(t, "NotifyDeliveryReturned", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReturnDelivery_ExceedsReturnableQuantity(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusDelivered, FromWarehouseID: 1}
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, ReturnedQuantity: 8, Status: models.DeliveryItemStatusActive},
+	}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{
+		Location: "東京倉庫",
+		Items:    []models.ReturnDeliveryItemRequest{{ItemID: 1, Quantity: 3}},
+	}, 7)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestReturnDelivery_TerminalStatus(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusCancelled, FromWarehouseID: 1}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+
+	result, err := service.ReturnDelivery(ctx, 1, &models.ReturnDeliveryRequest{Location: "東京倉庫"}, 7)
+
+	var transitionErr *models.StatusTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.Nil(t, result)
+}
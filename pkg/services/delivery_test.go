@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
 	"tea-logistics/pkg/services/mocks"
 
 	"github.com/stretchr/testify/assert"
@@ -24,32 +25,72 @@ func setupTest() (*mocks.MockDeliveryRepository, *mocks.MockInventoryRepository,
 	return mockRepo, mockInventoryRepo, mockNotifyService
 }
 
+func newTestDeliveryService(
+	mockRepo *mocks.MockDeliveryRepository,
+	mockInventoryRepo *mocks.MockInventoryRepository,
+	mockReservationRepo *mocks.MockReservationRepository,
+	mockWarehouseRepo *mocks.MockWarehouseRepository,
+	mockNotifyService *mocks.MockNotificationService,
+) *DeliveryService {
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Allocations:  newDefaultAllocationRepo(),
+		StockCounts:  newDefaultStockCountRepo(),
+		Schedules:    newDefaultScheduleRepo(),
+	})
+	return NewDeliveryService(mockRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
+}
+
+// newDefaultScheduleRepo 配送に予定中の配送スケジュールがない（キャンセル時に取り消す配送スケジュールがない）モックを作成する
+func newDefaultScheduleRepo() *mocks.MockScheduleRepository {
+	mockScheduleRepo := new(mocks.MockScheduleRepository)
+	mockScheduleRepo.On("CancelDeliverySchedules", mock.Anything, mock.Anything).Return([]*models.DeliverySchedule{}, nil).Maybe()
+	return mockScheduleRepo
+}
+
+// newDefaultAllocationRepo 割当方式が未設定（既定の割当方式）で割当結果を記録するだけのモックを作成する
+func newDefaultAllocationRepo() *mocks.MockAllocationRepository {
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	mockAllocationRepo.On("GetPolicyForProduct", mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound).Maybe()
+	mockAllocationRepo.On("CreateDeliveryAllocation", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return mockAllocationRepo
+}
+
 func TestCreateDelivery(t *testing.T) {
 	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
-	service := NewDeliveryService(mockRepo, mockInventoryRepo, mockNotifyService)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
 
 	ctx := context.Background()
 	req := &models.CreateDeliveryRequest{
-		OrderID:         1,
-		ProductID:       1,
-		Quantity:        10,
+		OrderID: 1,
+		Items: []models.CreateDeliveryItemRequest{
+			{ProductID: 1, Quantity: 10},
+			{ProductID: 2, Quantity: 5},
+		},
 		FromWarehouseID: 1,
 		ToAddress:       "東京都渋谷区",
 		EstimatedTime:   time.Now().Add(24 * time.Hour),
 	}
 
-	inventory := &models.Inventory{
-		ID:        1,
-		ProductID: 1,
-		Quantity:  100,
-		Location:  "東京倉庫",
-		Status:    models.InventoryStatusAvailable,
+	warehouse := &models.Warehouse{ID: 1, Name: "東京倉庫"}
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+		{ID: 2, ProductID: 2, Quantity: 20, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
 	}
 
-	mockInventoryRepo.On("GetInventory", ctx, int64(1)).Return(inventory, nil)
-	mockInventoryRepo.On("UpdateInventory", ctx, mock.AnythingOfType("*models.Inventory")).Return(nil)
+	mockWarehouseRepo.On("GetWarehouse", ctx, int64(1)).Return(warehouse, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories[:1], nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(2), "東京倉庫").Return(inventories[1:], nil)
 	mockRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
-	mockRepo.On("CreateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	mockRepo.On("CreateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil).Twice()
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(80, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(2), "東京倉庫").Return(0, nil)
+	mockReservationRepo.On("CreateReservation", ctx, mock.AnythingOfType("*models.Reservation")).Return(nil).Twice()
 	mockNotifyService.On("NotifyDeliveryStatusChange", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
 	delivery, err := service.CreateDelivery(ctx, req)
@@ -60,15 +101,90 @@ func TestCreateDelivery(t *testing.T) {
 	assert.Equal(t, req.FromWarehouseID, delivery.FromWarehouseID)
 	assert.Equal(t, req.ToAddress, delivery.ToAddress)
 	assert.Equal(t, req.EstimatedTime, delivery.EstimatedTime)
+	assert.Len(t, delivery.Items, 2)
+	assert.Equal(t, int64(2), delivery.Items[1].ProductID)
+	assert.Equal(t, 5, delivery.Items[1].Quantity)
 
 	mockRepo.AssertExpectations(t)
 	mockInventoryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+	mockWarehouseRepo.AssertExpectations(t)
 	mockNotifyService.AssertExpectations(t)
 }
 
+func TestCreateDelivery_InsufficientAvailableToPromise(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	req := &models.CreateDeliveryRequest{
+		OrderID: 1,
+		Items: []models.CreateDeliveryItemRequest{
+			{ProductID: 1, Quantity: 30},
+		},
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	inventory := &models.Inventory{
+		ID:        1,
+		ProductID: 1,
+		Quantity:  100,
+		Location:  "東京倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+
+	mockWarehouseRepo.On("GetWarehouse", ctx, int64(1)).Return(&models.Warehouse{ID: 1, Name: "東京倉庫"}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	mockRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	// 100個のうち80個が引当済みのため、30個は引き当てられない
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(80, nil)
+
+	delivery, err := service.CreateDelivery(ctx, req)
+
+	var stockErr *models.InsufficientStockError
+	assert.ErrorAs(t, err, &stockErr)
+	assert.Equal(t, 20, stockErr.Available)
+	assert.Nil(t, delivery)
+	mockReservationRepo.AssertNotCalled(t, "CreateReservation", mock.Anything, mock.Anything)
+	mockNotifyService.AssertNotCalled(t, "NotifyDeliveryStatusChange", mock.Anything, mock.Anything)
+}
+
+func TestCreateDelivery_DuplicateProductLines(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	req := &models.CreateDeliveryRequest{
+		OrderID: 1,
+		Items: []models.CreateDeliveryItemRequest{
+			{ProductID: 1, Quantity: 10},
+			{ProductID: 1, Quantity: 5},
+		},
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	delivery, err := service.CreateDelivery(context.Background(), req)
+
+	var validationErr *models.DeliveryValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 2, validationErr.Line)
+	assert.Nil(t, delivery)
+	mockRepo.AssertNotCalled(t, "CreateDelivery", mock.Anything, mock.Anything)
+}
+
 func TestGetDelivery(t *testing.T) {
 	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
-	service := NewDeliveryService(mockRepo, mockInventoryRepo, mockNotifyService)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
 
 	ctx := context.Background()
 	expectedDelivery := &models.Delivery{
@@ -82,7 +198,13 @@ func TestGetDelivery(t *testing.T) {
 		UpdatedAt:       time.Now(),
 	}
 
+	items := []*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, Status: models.DeliveryItemStatusActive},
+		{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 5, Status: models.DeliveryItemStatusActive},
+	}
+
 	mockRepo.On("GetDelivery", ctx, int64(1)).Return(expectedDelivery, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
 
 	delivery, err := service.GetDelivery(ctx, 1)
 
@@ -94,38 +216,171 @@ func TestGetDelivery(t *testing.T) {
 	assert.Equal(t, expectedDelivery.FromWarehouseID, delivery.FromWarehouseID)
 	assert.Equal(t, expectedDelivery.ToAddress, delivery.ToAddress)
 	assert.Equal(t, expectedDelivery.EstimatedTime, delivery.EstimatedTime)
+	assert.Equal(t, items, delivery.Items)
 
 	mockRepo.AssertExpectations(t)
 }
 
 func TestUpdateDeliveryStatus(t *testing.T) {
 	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
-	service := NewDeliveryService(mockRepo, mockInventoryRepo, mockNotifyService)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
 
 	ctx := context.Background()
 	delivery := &models.Delivery{
 		ID:              1,
 		OrderID:         1,
-		Status:          "pending",
+		Status:          "scheduled",
 		FromWarehouseID: 1,
 		ToAddress:       "東京都渋谷区",
 		EstimatedTime:   time.Now().Add(24 * time.Hour),
 	}
 
+	deliveryID := int64(1)
+	expiresAt := time.Now().Add(time.Hour)
+	reservations := []*models.Reservation{
+		{
+			ID:         1,
+			ProductID:  1,
+			Location:   "東京倉庫",
+			Quantity:   10,
+			DeliveryID: &deliveryID,
+			Status:     models.ReservationStatusActive,
+			ExpiresAt:  &expiresAt,
+		},
+	}
+
 	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
 	mockRepo.On("UpdateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	// 出荷時は引当を無期限に切り替える
+	mockReservationRepo.On("UpdateReservationExpiry", ctx, int64(1), (*time.Time)(nil)).Return(nil)
+	mockRepo.On("CreateStatusHistory", ctx, mock.MatchedBy(func(h *models.DeliveryStatusHistory) bool {
+		return h.FromStatus == models.DeliveryStatusScheduled &&
+			h.ToStatus == models.DeliveryStatusInTransit &&
+			h.ChangedBy != nil && *h.ChangedBy == 7
+	})).Return(nil)
 	mockNotifyService.On("NotifyDeliveryStatusChange", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
-	err := service.UpdateDeliveryStatus(ctx, 1, "in_transit")
+	_, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{
+		Status: models.DeliveryStatusInTransit,
+	}, 7)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
 	mockNotifyService.AssertExpectations(t)
 }
 
+func TestUpdateDeliveryStatus_IllegalTransition(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{
+		ID:              1,
+		OrderID:         1,
+		Status:          models.DeliveryStatusCancelled,
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+
+	// キャンセル済みの配送は配送中に戻せない
+	_, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{
+		Status: models.DeliveryStatusInTransit,
+	}, 7)
+
+	var transitionErr *models.StatusTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, models.DeliveryStatusCancelled, transitionErr.From)
+	assert.Equal(t, models.DeliveryStatusInTransit, transitionErr.To)
+	assert.Equal(t, models.DeliveryStatusCancelled, delivery.Status)
+	mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateStatusHistory", mock.Anything, mock.Anything)
+	mockNotifyService.AssertNotCalled(t, "NotifyDeliveryStatusChange", mock.Anything, mock.Anything)
+}
+
+func TestUpdateDeliveryStatus_UnknownStatus(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: models.DeliveryStatusPending, FromWarehouseID: 1}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+
+	_, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{
+		Status: "lost",
+	}, 0)
+
+	var transitionErr *models.StatusTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestUpdateDeliveryStatus_CancelReleasesReservations(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{
+		ID:              1,
+		OrderID:         1,
+		Status:          "pending",
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	deliveryID := int64(1)
+	reservations := []*models.Reservation{
+		{ID: 1, ProductID: 1, Location: "東京倉庫", Quantity: 10, DeliveryID: &deliveryID, Status: models.ReservationStatusActive},
+		{ID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 5, DeliveryID: &deliveryID, Status: models.ReservationStatusExpired},
+	}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("UpdateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(1), models.ReservationStatusReleased).Return(nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return([]*models.DeliveryItem{
+		{ID: 1, DeliveryID: 1, ProductID: 1, Quantity: 10, Status: models.DeliveryItemStatusActive},
+		{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 5, CancelledQuantity: 5, Status: models.DeliveryItemStatusCancelled},
+	}, nil)
+	// キャンセル済みの明細は更新しない
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.MatchedBy(func(item *models.DeliveryItem) bool {
+		return item.ID == 1 && item.CancelledQuantity == 10 && item.Status == models.DeliveryItemStatusCancelled
+	})).Return(nil).Once()
+	mockRepo.On("CreateStatusHistory", ctx, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
+	mockNotifyService.On("NotifyDeliveryStatusChange", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+
+	_, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{
+		Status: models.DeliveryStatusCancelled,
+		Reason: "顧客都合",
+	}, 7)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+	mockReservationRepo.AssertNotCalled(t, "UpdateReservationStatus", ctx, int64(2), mock.Anything)
+	// 在庫数そのものは変更しない
+	mockInventoryRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestCompleteDelivery(t *testing.T) {
 	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
-	service := NewDeliveryService(mockRepo, mockInventoryRepo, mockNotifyService)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
 
 	ctx := context.Background()
 	delivery := &models.Delivery{
@@ -137,12 +392,15 @@ func TestCompleteDelivery(t *testing.T) {
 		EstimatedTime:   time.Now().Add(24 * time.Hour),
 	}
 
-	items := []*models.DeliveryItem{
+	deliveryID := int64(1)
+	reservations := []*models.Reservation{
 		{
 			ID:         1,
-			DeliveryID: 1,
 			ProductID:  1,
+			Location:   "東京倉庫",
 			Quantity:   10,
+			DeliveryID: &deliveryID,
+			Status:     models.ReservationStatusActive,
 		},
 	}
 
@@ -155,16 +413,195 @@ func TestCompleteDelivery(t *testing.T) {
 	}
 
 	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
-	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return(items, nil)
-	mockInventoryRepo.On("GetInventory", ctx, int64(1)).Return(inventory, nil)
-	mockInventoryRepo.On("UpdateInventory", ctx, mock.AnythingOfType("*models.Inventory")).Return(nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	// 引当の確定時に出庫の在庫移動を記録し、一度だけ在庫数を減らす
+	mockInventoryRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeOutbound && m.FromLocation == "東京倉庫" && m.Quantity == 10
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 40
+	}).Return(nil).Once()
+	mockInventoryRepo.On("UpdateQuantity", repository.WithLedgerMovement(ctx, 40), int64(1), 90, 0).Return(nil).Once()
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(1), models.ReservationStatusCommitted).Return(nil)
 	mockRepo.On("UpdateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateStatusHistory", ctx, mock.AnythingOfType("*models.DeliveryStatusHistory")).Return(nil)
 	mockNotifyService.On("NotifyDeliveryComplete", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
 
-	err := service.CompleteDelivery(ctx, 1)
+	err := service.CompleteDelivery(ctx, 1, 7)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 	mockInventoryRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
 	mockNotifyService.AssertExpectations(t)
 }
+
+func TestCancelDeliveryItem_Partial(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Allocations:  mockAllocationRepo,
+	})
+	service := NewDeliveryService(mockRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: "pending", FromWarehouseID: 1, Version: 3}
+	item := &models.DeliveryItem{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 10, Status: models.DeliveryItemStatusActive}
+
+	deliveryID := int64(1)
+	otherItemID := int64(1)
+	itemID := int64(2)
+	reservations := []*models.Reservation{
+		{ID: 1, ProductID: 1, Location: "東京倉庫", Quantity: 5, DeliveryID: &deliveryID, DeliveryItemID: &otherItemID, Status: models.ReservationStatusActive},
+		{ID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 10, DeliveryID: &deliveryID, DeliveryItemID: &itemID, Status: models.ReservationStatusActive},
+	}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	// 配送のバージョンを進めて、並行するステータス変更と直列化する
+	mockRepo.On("UpdateDelivery", ctx, delivery).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Delivery).Version++
+	}).Return(nil)
+	mockRepo.On("GetDeliveryItem", ctx, int64(2)).Return(item, nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	// 対象明細の引当のみ数量を減らす
+	mockReservationRepo.On("UpdateReservationQuantity", ctx, int64(2), 6).Return(nil)
+	// 在庫割当結果も対象明細の分のみ数量を減らす
+	mockAllocationRepo.On("ListDeliveryAllocations", ctx, int64(1)).Return([]*models.DeliveryAllocation{
+		{ID: 11, DeliveryID: 1, DeliveryItemID: 1, ProductID: 1, Location: "東京倉庫", Quantity: 5},
+		{ID: 12, DeliveryID: 1, DeliveryItemID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 10},
+	}, nil)
+	mockAllocationRepo.On("UpdateDeliveryAllocationQuantity", ctx, int64(12), 6).Return(nil)
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+
+	updatedDelivery, updated, err := service.CancelDeliveryItem(ctx, 1, 2, &models.CancelDeliveryItemRequest{Quantity: 4, ExpectedVersion: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, updatedDelivery.Version)
+	assert.Equal(t, 4, updated.CancelledQuantity)
+	assert.Equal(t, 6, updated.RemainingQuantity())
+	assert.Equal(t, models.DeliveryItemStatusActive, updated.Status)
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+	mockReservationRepo.AssertNotCalled(t, "UpdateReservationQuantity", ctx, int64(1), mock.Anything)
+	mockAllocationRepo.AssertExpectations(t)
+	mockAllocationRepo.AssertNotCalled(t, "UpdateDeliveryAllocationQuantity", ctx, int64(11), mock.Anything)
+}
+
+func TestCancelDeliveryItem_Full(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockAllocationRepo := new(mocks.MockAllocationRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Allocations:  mockAllocationRepo,
+	})
+	service := NewDeliveryService(mockRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: "pending", FromWarehouseID: 1}
+	item := &models.DeliveryItem{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 10, CancelledQuantity: 4, Status: models.DeliveryItemStatusActive}
+
+	deliveryID := int64(1)
+	itemID := int64(2)
+	reservations := []*models.Reservation{
+		{ID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 4, DeliveryID: &deliveryID, DeliveryItemID: &itemID, Status: models.ReservationStatusActive},
+		{ID: 3, ProductID: 2, Location: "大阪倉庫", Quantity: 2, DeliveryID: &deliveryID, DeliveryItemID: &itemID, Status: models.ReservationStatusActive},
+	}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("UpdateDelivery", ctx, delivery).Return(nil)
+	mockRepo.On("GetDeliveryItem", ctx, int64(2)).Return(item, nil)
+	mockReservationRepo.On("ListReservationsByDelivery", ctx, int64(1)).Return(reservations, nil)
+	// 残数量をすべてキャンセルする場合は引当を解放する
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(3), models.ReservationStatusReleased).Return(nil)
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(2), models.ReservationStatusReleased).Return(nil)
+	// 倉庫ごとに分割した在庫割当結果もすべて削除する
+	mockAllocationRepo.On("ListDeliveryAllocations", ctx, int64(1)).Return([]*models.DeliveryAllocation{
+		{ID: 12, DeliveryID: 1, DeliveryItemID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 4},
+		{ID: 13, DeliveryID: 1, DeliveryItemID: 2, ProductID: 2, Location: "大阪倉庫", Quantity: 2},
+	}, nil)
+	mockAllocationRepo.On("DeleteDeliveryAllocation", ctx, int64(13)).Return(nil)
+	mockAllocationRepo.On("DeleteDeliveryAllocation", ctx, int64(12)).Return(nil)
+	mockRepo.On("UpdateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+
+	_, updated, err := service.CancelDeliveryItem(ctx, 1, 2, &models.CancelDeliveryItemRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, updated.CancelledQuantity)
+	assert.Equal(t, models.DeliveryItemStatusCancelled, updated.Status)
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+	mockAllocationRepo.AssertExpectations(t)
+}
+
+func TestCancelDeliveryItem_AfterDispatch(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: "in_transit", FromWarehouseID: 1}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+
+	_, updated, err := service.CancelDeliveryItem(ctx, 1, 2, &models.CancelDeliveryItemRequest{Quantity: 1})
+
+	var statusErr *models.DeliveryItemCancelStatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateDeliveryItem", mock.Anything, mock.Anything)
+}
+
+func TestCancelDeliveryItem_ExceedsRemaining(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: "scheduled", FromWarehouseID: 1}
+	item := &models.DeliveryItem{ID: 2, DeliveryID: 1, ProductID: 2, Quantity: 10, CancelledQuantity: 7, Status: models.DeliveryItemStatusActive}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+	mockRepo.On("UpdateDelivery", ctx, delivery).Return(nil)
+	mockRepo.On("GetDeliveryItem", ctx, int64(2)).Return(item, nil)
+
+	_, updated, err := service.CancelDeliveryItem(ctx, 1, 2, &models.CancelDeliveryItemRequest{Quantity: 5})
+
+	var quantityErr *models.CancelQuantityError
+	assert.ErrorAs(t, err, &quantityErr)
+	assert.Equal(t, 3, quantityErr.Remaining)
+	assert.Nil(t, updated)
+	mockReservationRepo.AssertNotCalled(t, "ListReservationsByDelivery", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateDeliveryItem", mock.Anything, mock.Anything)
+}
+
+func TestCancelDeliveryItem_VersionConflict(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	service := newTestDeliveryService(mockRepo, mockInventoryRepo, mockReservationRepo, mockWarehouseRepo, mockNotifyService)
+
+	ctx := context.Background()
+	delivery := &models.Delivery{ID: 1, OrderID: 1, Status: "pending", FromWarehouseID: 1, Version: 5}
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(delivery, nil)
+
+	// 取得時（バージョン4）の後に配送が更新されている
+	_, updated, err := service.CancelDeliveryItem(ctx, 1, 2, &models.CancelDeliveryItemRequest{Quantity: 1, ExpectedVersion: 4})
+
+	var conflictErr *models.VersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, 5, conflictErr.CurrentVersion)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "GetDeliveryItem", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateDeliveryItem", mock.Anything, mock.Anything)
+}
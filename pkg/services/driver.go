@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 運転手サービス
+ * 運転手（ロールがdriverのユーザー）の免許・連絡先・勤務シフトの登録と更新を実装する
+ */
+
+var (
+	// ErrDriverNotFound 運転手が見つからない場合のエラー
+	ErrDriverNotFound = errors.New("運転手が見つかりません")
+	// ErrDriverUserNotFound 運転手として登録するユーザーが見つからない場合のエラー
+	ErrDriverUserNotFound = errors.New("ロールがdriverのユーザーが見つかりません")
+)
+
+// DriverService 運転手サービス
+type DriverService struct {
+	repo repository.DriverRepository
+}
+
+// NewDriverService 運転手サービスを作成する
+func NewDriverService(repo repository.DriverRepository) *DriverService {
+	return &DriverService{repo: repo}
+}
+
+// CreateDriver ロールがdriverのユーザーを運転手として登録する
+func (s *DriverService) CreateDriver(ctx context.Context, req *models.DriverRequest) (*models.Driver, error) {
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("ユーザーIDを指定してください")
+	}
+
+	driver := &models.Driver{UserID: req.UserID, Active: true}
+	if err := applyDriverRequest(driver, req); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.CreateDriver(ctx, driver)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDriverUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("運転手登録エラー: %v", err)
+	}
+
+	// 氏名・メールアドレスはユーザー情報から取得する
+	return s.GetDriver(ctx, driver.ID)
+}
+
+// GetDriver 運転手を取得する
+func (s *DriverService) GetDriver(ctx context.Context, id int64) (*models.Driver, error) {
+	driver, err := s.repo.GetDriver(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDriverNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("運転手取得エラー: %v", err)
+	}
+
+	return driver, nil
+}
+
+// ListDrivers 運転手一覧を取得する
+func (s *DriverService) ListDrivers(ctx context.Context, spec *repository.QuerySpec) ([]*models.Driver, *repository.PageInfo, error) {
+	drivers, page, err := s.repo.ListDrivers(ctx, spec)
+	if err != nil {
+		return nil, nil, wrapListError("運転手一覧取得エラー", err)
+	}
+
+	return drivers, page, nil
+}
+
+// UpdateDriver 運転手の免許・連絡先・勤務シフトを更新する
+// シフトの変更は割当済みの配送スケジュールには影響しない
+func (s *DriverService) UpdateDriver(ctx context.Context, id int64, req *models.DriverRequest) (*models.Driver, error) {
+	driver, err := s.GetDriver(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDriverRequest(driver, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateDriver(ctx, driver); err != nil {
+		return nil, fmt.Errorf("運転手更新エラー: %v", err)
+	}
+
+	return driver, nil
+}
+
+// applyDriverRequest 運転手登録・更新リクエストの内容を運転手に設定する
+func applyDriverRequest(driver *models.Driver, req *models.DriverRequest) error {
+	if _, err := models.ParseShiftTime(req.ShiftStart); err != nil {
+		return err
+	}
+	if _, err := models.ParseShiftTime(req.ShiftEnd); err != nil {
+		return err
+	}
+	if req.ShiftStart == req.ShiftEnd {
+		return fmt.Errorf("シフトの開始時刻と終了時刻に同じ時刻は指定できません")
+	}
+
+	driver.LicenseNumber = req.LicenseNumber
+	driver.LicenseExpiry = req.LicenseExpiry
+	driver.Phone = req.Phone
+	driver.ShiftStart = req.ShiftStart
+	driver.ShiftEnd = req.ShiftEnd
+	if req.Active != nil {
+		driver.Active = *req.Active
+	}
+	return nil
+}
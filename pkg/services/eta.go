@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 到着予定日時サービス
+ * 配送ルートの残りの訪問先・最新の追跡イベントの位置・区間の所要時間の実績から配送の到着予定日時を予測し、
+ * 配送追跡情報の到着予定日時の更新と、到着予定日時が遅れた場合の顧客への通知を実装する
+ */
+
+const (
+	// DefaultETADelayThreshold 顧客に到着予定日時の遅れを通知する既定の遅れの大きさ
+	DefaultETADelayThreshold = 30 * time.Minute
+	// etaHistoryDays 区間の所要時間の実績を集計する日数
+	etaHistoryDays = 30
+	// etaMinHistoryLegs 所要時間の実績で移動時間を補正するのに必要な区間数
+	etaMinHistoryLegs = 5
+	// etaMinDelayFactor・etaMaxDelayFactor 所要時間の実績による補正係数の範囲
+	etaMinDelayFactor = 0.5
+	etaMaxDelayFactor = 3.0
+)
+
+var (
+	// ErrETAUnavailable 到着予定日時を予測できない場合のエラー
+	ErrETAUnavailable = errors.New("到着予定日時を予測できません")
+	// ErrETATrackingNotFound 配送追跡が開始されていない場合のエラー
+	ErrETATrackingNotFound = errors.New("配送追跡が開始されていません")
+)
+
+// ETAService 到着予定日時サービス
+type ETAService struct {
+	repo          repository.ETARepository
+	deliveryRepo  repository.DeliveryRepository
+	routeRepo     repository.RouteRepository
+	planner       *RoutePlanner
+	notifyService NotificationService
+	threshold     time.Duration
+}
+
+// NewETAService 到着予定日時サービスを作成する
+// plannerがnilの場合は大円距離で移動時間を見積もり、thresholdが0以下の場合はDefaultETADelayThresholdを使用する
+func NewETAService(
+	repo repository.ETARepository,
+	deliveryRepo repository.DeliveryRepository,
+	routeRepo repository.RouteRepository,
+	planner *RoutePlanner,
+	notifyService NotificationService,
+	threshold time.Duration,
+) *ETAService {
+	if planner == nil {
+		planner = NewRoutePlanner(nil)
+	}
+	if threshold <= 0 {
+		threshold = DefaultETADelayThreshold
+	}
+	return &ETAService{
+		repo:          repo,
+		deliveryRepo:  deliveryRepo,
+		routeRepo:     routeRepo,
+		planner:       planner,
+		notifyService: notifyService,
+		threshold:     threshold,
+	}
+}
+
+// UpdateDeliveryETA 配送の到着予定日時を予測し、配送追跡情報の到着予定日時を更新する
+//
+// 配送ルートがある場合は、最新の追跡イベントの位置から残りの訪問先（先に訪問する未完了の配送と対象の配送）を
+// 訪問順に巡り、移動時間を車両の区間の所要時間の実績で補正して各訪問先で荷下ろしの時間を見込む。
+// 追跡イベントの位置がない場合は、最初の残りの訪問先の到着予定日時（過ぎている場合は現在）から巡る。
+// 配送ルートの到着予定日時は配送スケジュールの開始日時までの待機を含むため、それより早くは到着しないものとする。
+// 配送ルートがない場合は、追跡イベントの位置から配送先へ直行するものとする。
+//
+// 顧客に最後に通知した到着予定日時（未通知の場合は配送の到着予定日時）から遅延通知のしきい値以上遅れた場合は
+// 顧客に通知し、通知した到着予定日時を次の判定の基準とする
+func (s *ETAService) UpdateDeliveryETA(ctx context.Context, deliveryID int64, now time.Time) (*models.DeliveryETA, error) {
+	delivery, err := s.deliveryRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("配送取得エラー: %v", err)
+	}
+	if delivery.Status != models.DeliveryStatusScheduled && delivery.Status != models.DeliveryStatusInTransit {
+		return nil, ErrETAUnavailable
+	}
+
+	tracking, err := s.repo.GetDeliveryTracking(ctx, deliveryID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrETATrackingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送追跡情報取得エラー: %v", err)
+	}
+
+	return s.updateETA(ctx, delivery, tracking, now)
+}
+
+// UpdateActiveETAs 配送中・配送予定のすべての配送の到着予定日時を予測し直す
+// 予測できない配送は対象外とし、配送ごとのエラーはログに記録して処理を続ける
+func (s *ETAService) UpdateActiveETAs(ctx context.Context, now time.Time) ([]*models.DeliveryETA, error) {
+	trackings, err := s.repo.ListActiveTrackings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("配送追跡情報一覧取得エラー: %v", err)
+	}
+
+	etas := make([]*models.DeliveryETA, 0, len(trackings))
+	for _, tracking := range trackings {
+		delivery, err := s.deliveryRepo.GetDelivery(ctx, tracking.DeliveryID)
+		if err != nil {
+			logger.Error("到着予定日時の予測エラー", map[string]interface{}{
+				"error":       err.Error(),
+				"delivery_id": tracking.DeliveryID,
+			})
+			continue
+		}
+
+		eta, err := s.updateETA(ctx, delivery, tracking, now)
+		if errors.Is(err, ErrETAUnavailable) {
+			continue
+		}
+		if err != nil {
+			logger.Error("到着予定日時の予測エラー", map[string]interface{}{
+				"error":       err.Error(),
+				"delivery_id": tracking.DeliveryID,
+			})
+			continue
+		}
+		etas = append(etas, eta)
+	}
+
+	return etas, nil
+}
+
+// StartETAUpdater 到着予定日時の予測を定期実行する
+func (s *ETAService) StartETAUpdater(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("到着予定日時の予測を停止しました")
+			return
+		case <-ticker.C:
+			etas, err := s.UpdateActiveETAs(ctx, time.Now())
+			if err != nil {
+				logger.Error("到着予定日時の予測エラー", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+
+			notified := 0
+			for _, eta := range etas {
+				if eta.Notified {
+					notified++
+				}
+			}
+			if notified > 0 {
+				logger.Info("到着予定日時の遅れを通知しました", map[string]interface{}{
+					"updated_count":  len(etas),
+					"notified_count": notified,
+				})
+			}
+		}
+	}
+}
+
+// updateETA 配送の到着予定日時を予測して保存し、遅れが大きい場合は顧客に通知する
+func (s *ETAService) updateETA(ctx context.Context, delivery *models.Delivery, tracking *models.ETATracking, now time.Time) (*models.DeliveryETA, error) {
+	eta, err := s.predict(ctx, delivery, tracking, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 基準の到着予定日時がない場合は、最初の予測を基準とする（通知はしない）
+	var notified *time.Time
+	switch {
+	case tracking.NotifiedEstimatedTime != nil:
+		eta.BaselineTime = tracking.NotifiedEstimatedTime
+	case !delivery.EstimatedTime.IsZero():
+		eta.BaselineTime = &delivery.EstimatedTime
+	default:
+		notified = &eta.EstimatedTime
+	}
+
+	if eta.BaselineTime != nil {
+		delay := eta.EstimatedTime.Sub(*eta.BaselineTime)
+		eta.Delay = int(math.Round(delay.Minutes()))
+		if delay >= s.threshold && s.notify(ctx, delivery, eta) {
+			notified = &eta.EstimatedTime
+			eta.Notified = true
+		}
+	}
+
+	err = s.repo.UpdateEstimatedTime(ctx, tracking.TrackingID, eta.EstimatedTime, notified)
+	if err != nil {
+		return nil, fmt.Errorf("到着予定日時更新エラー: %v", err)
+	}
+
+	return eta, nil
+}
+
+// notify 到着予定日時の遅れを顧客に通知する
+// 通知エラーはログに記録するだけで、次の予測で通知し直す
+func (s *ETAService) notify(ctx context.Context, delivery *models.Delivery, eta *models.DeliveryETA) bool {
+	if s.notifyService == nil {
+		return false
+	}
+
+	if err := s.notifyService.NotifyDeliveryDelayed(ctx, delivery, eta); err != nil {
+		logger.Error("到着遅延通知エラー", map[string]interface{}{
+			"error":       err.Error(),
+			"delivery_id": delivery.ID,
+		})
+		return false
+	}
+	return true
+}
+
+// predict 配送の到着予定日時を予測する
+func (s *ETAService) predict(ctx context.Context, delivery *models.Delivery, tracking *models.ETATracking, now time.Time) (*models.DeliveryETA, error) {
+	var position *geo.Point
+	event, err := s.repo.GetLatestPosition(ctx, tracking.TrackingID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("追跡イベント取得エラー: %v", err)
+	}
+	if event != nil {
+		position = &geo.Point{Latitude: event.Latitude, Longitude: event.Longitude}
+	}
+
+	eta := &models.DeliveryETA{DeliveryID: delivery.ID, TrackingID: tracking.TrackingID, DelayFactor: 1}
+
+	route, err := s.routeRepo.GetDeliveryRoute(ctx, delivery.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("配送ルート取得エラー: %v", err)
+	}
+
+	if route != nil && route.Status == models.RouteStatusPlanned {
+		stops, err := s.remainingStops(ctx, route)
+		if err != nil {
+			return nil, err
+		}
+		if eta.DelayFactor, err = s.delayFactor(ctx, route.VehicleID, now); err != nil {
+			return nil, err
+		}
+		if eta.EstimatedTime, err = s.estimateArrival(ctx, position, stops, now, eta.DelayFactor); err != nil {
+			return nil, err
+		}
+		eta.RemainingStops = len(stops)
+		eta.Basis = models.ETABasisPlan
+		if position != nil {
+			eta.Basis = models.ETABasisPosition
+		}
+		return eta, nil
+	}
+
+	if position == nil || !delivery.HasDestinationCoordinates() {
+		return nil, ErrETAUnavailable
+	}
+	destination := geo.Point{Latitude: *delivery.ToLatitude, Longitude: *delivery.ToLongitude}
+	_, duration, err := s.planner.distance.Travel(ctx, *position, destination)
+	if err != nil {
+		return nil, fmt.Errorf("距離取得エラー: %v", err)
+	}
+	eta.EstimatedTime = now.Add(duration)
+	eta.RemainingStops = 1
+	eta.Basis = models.ETABasisDirect
+	return eta, nil
+}
+
+// remainingStops 配送ルートのうち、配送の訪問先までに残っている訪問先を訪問順に返す（配送の訪問先を含む）
+// 配送完了・キャンセル・返品済みの配送と、位置情報がないため訪問順を計画していない訪問先は除く
+func (s *ETAService) remainingStops(ctx context.Context, target *models.Route) ([]*models.Route, error) {
+	routes, err := s.routeRepo.ListVehicleRoute(ctx, target.VehicleID, target.RouteDate)
+	if err != nil {
+		return nil, fmt.Errorf("配送ルート取得エラー: %v", err)
+	}
+
+	var stops []*models.Route
+	for _, route := range routes {
+		if route.Sequence > target.Sequence {
+			break
+		}
+		if route.Status != models.RouteStatusPlanned || route.Latitude == nil || route.Longitude == nil {
+			continue
+		}
+		if route.DeliveryID != target.DeliveryID {
+			delivery, err := s.deliveryRepo.GetDelivery(ctx, route.DeliveryID)
+			if err != nil {
+				return nil, fmt.Errorf("配送取得エラー: %v", err)
+			}
+			if delivery.Status != models.DeliveryStatusScheduled && delivery.Status != models.DeliveryStatusInTransit {
+				continue
+			}
+		}
+		stops = append(stops, route)
+	}
+
+	if len(stops) == 0 || stops[len(stops)-1].DeliveryID != target.DeliveryID {
+		return nil, ErrETAUnavailable
+	}
+	return stops, nil
+}
+
+// estimateArrival 現在地から残りの訪問先を訪問順に巡り、最後の訪問先への到着予定日時を返す
+// positionがnilの場合は最初の訪問先から出発する。移動時間と荷下ろしの時間はfactor倍する
+func (s *ETAService) estimateArrival(ctx context.Context, position *geo.Point, stops []*models.Route, now time.Time, factor float64) (time.Time, error) {
+	at := now
+	for i, stop := range stops {
+		point := geo.Point{Latitude: *stop.Latitude, Longitude: *stop.Longitude}
+		if position != nil {
+			_, duration, err := s.planner.distance.Travel(ctx, *position, point)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("距離取得エラー: %v", err)
+			}
+			at = at.Add(scaleDuration(duration, factor))
+		}
+
+		// 計画より早くは到着しない（配送スケジュールの開始日時までの待機を含む）
+		if at.Before(stop.ArrivalTime) {
+			at = stop.ArrivalTime
+		}
+		if i == len(stops)-1 {
+			break
+		}
+
+		at = at.Add(scaleDuration(s.planner.serviceTime, factor))
+		position = &point
+	}
+
+	return at, nil
+}
+
+// delayFactor 車両の区間の所要時間の実績から、計画に対する所要時間の比率を返す
+// 実績の区間数が少ない場合は補正しない（1）
+func (s *ETAService) delayFactor(ctx context.Context, vehicleID int64, now time.Time) (float64, error) {
+	stats, err := s.repo.GetLegDurationStats(ctx, vehicleID, now.AddDate(0, 0, -etaHistoryDays))
+	if err != nil {
+		return 0, fmt.Errorf("区間所要時間取得エラー: %v", err)
+	}
+	if stats.Legs < etaMinHistoryLegs || stats.PlannedMinutes <= 0 {
+		return 1, nil
+	}
+
+	factor := stats.ActualMinutes / stats.PlannedMinutes
+	factor = math.Max(etaMinDelayFactor, math.Min(etaMaxDelayFactor, factor))
+	return math.Round(factor*100) / 100, nil
+}
+
+// scaleDuration 時間をfactor倍する
+func scaleDuration(duration time.Duration, factor float64) time.Duration {
+	return time.Duration(float64(duration) * factor)
+}
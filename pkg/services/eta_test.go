@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 到着予定日時サービスのテスト
+ * 残りの訪問先・最新の追跡イベントの位置・区間の所要時間の実績による到着予定日時の予測と、遅延の通知を検証する
+ */
+
+// etaTestRepos 到着予定日時のテストで使用するモック
+type etaTestRepos struct {
+	eta        *mocks.MockETARepository
+	deliveries *mocks.MockDeliveryRepository
+	routes     *mocks.MockRouteRepository
+	notify     *mocks.MockNotificationService
+}
+
+func newETATestService() (*ETAService, *etaTestRepos) {
+	repos := &etaTestRepos{
+		eta:        new(mocks.MockETARepository),
+		deliveries: new(mocks.MockDeliveryRepository),
+		routes:     new(mocks.MockRouteRepository),
+		notify:     new(mocks.MockNotificationService),
+	}
+	service := NewETAService(repos.eta, repos.deliveries, repos.routes, NewRoutePlanner(planeDistance{}), repos.notify, 0)
+	return service, repos
+}
+
+// expectDelivery 配送を返すよう設定する
+func (r *etaTestRepos) expectDelivery(id int64, status models.DeliveryStatus, estimated time.Time) *models.Delivery {
+	delivery := &models.Delivery{ID: id, OrderID: 100 + id, Status: status, EstimatedTime: estimated}
+	r.deliveries.On("GetDelivery", mock.Anything, id).Return(delivery, nil)
+	return delivery
+}
+
+// expectRoute 車両7の配送ルート（配送1は配送完了、配送2・3は配送中）を返すよう設定する
+func (r *etaTestRepos) expectRoute() {
+	routes := []*models.Route{
+		{DeliveryID: 1, VehicleID: 7, RouteDate: "2024-05-01", Sequence: 1, Latitude: floatPtr(0), Longitude: floatPtr(1), ArrivalTime: localTime(1, 9, 2), Status: models.RouteStatusPlanned},
+		{DeliveryID: 2, VehicleID: 7, RouteDate: "2024-05-01", Sequence: 2, Latitude: floatPtr(0), Longitude: floatPtr(3), ArrivalTime: localTime(1, 9, 16), Status: models.RouteStatusPlanned},
+		{DeliveryID: 3, VehicleID: 7, RouteDate: "2024-05-01", Sequence: 3, Latitude: floatPtr(0), Longitude: floatPtr(6), ArrivalTime: localTime(1, 9, 32), Status: models.RouteStatusPlanned},
+	}
+	r.routes.On("GetDeliveryRoute", mock.Anything, int64(3)).Return(routes[2], nil)
+	r.routes.On("ListVehicleRoute", mock.Anything, int64(7), "2024-05-01").Return(routes, nil)
+	r.expectDelivery(1, models.DeliveryStatusDelivered, time.Time{})
+	r.expectDelivery(2, models.DeliveryStatusInTransit, time.Time{})
+}
+
+// expectTracking 配送3の配送追跡情報と最新の位置を返すよう設定する（latitude・longitudeがnilの場合は位置なし）
+func (r *etaTestRepos) expectTracking(notified *time.Time, latitude, longitude *float64) {
+	r.eta.On("GetDeliveryTracking", mock.Anything, int64(3)).Return(&models.ETATracking{
+		TrackingID:            "TRK-3",
+		DeliveryID:            3,
+		NotifiedEstimatedTime: notified,
+	}, nil)
+	if latitude == nil {
+		r.eta.On("GetLatestPosition", mock.Anything, "TRK-3").Return(nil, repository.ErrNotFound)
+		return
+	}
+	r.eta.On("GetLatestPosition", mock.Anything, "TRK-3").Return(&models.TrackingEvent{
+		TrackingID: "TRK-3",
+		Latitude:   *latitude,
+		Longitude:  *longitude,
+	}, nil)
+}
+
+// expectStats 車両7の区間の所要時間の実績を返すよう設定する
+func (r *etaTestRepos) expectStats(legs int, planned, actual float64) {
+	r.eta.On("GetLegDurationStats", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(&models.LegDurationStats{
+		Legs:           legs,
+		PlannedMinutes: planned,
+		ActualMinutes:  actual,
+	}, nil)
+}
+
+func TestUpdateDeliveryETA(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("最新の位置から残りの訪問先を巡って予測する", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 30))
+		repos.expectRoute()
+		repos.expectTracking(nil, floatPtr(0), floatPtr(2))
+		repos.expectStats(2, 30, 60)
+		// 配送2まで2分（計画の9:16より遅い9:32）、荷下ろし10分、配送3まで6分
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", localTime(1, 9, 48), (*time.Time)(nil)).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 30))
+		require.NoError(t, err)
+		assert.Equal(t, localTime(1, 9, 48), eta.EstimatedTime)
+		assert.Equal(t, models.ETABasisPosition, eta.Basis)
+		// 配送完了の配送1は残りの訪問先に含めない
+		assert.Equal(t, 2, eta.RemainingStops)
+		// 実績の区間が少ないため補正しない
+		assert.Equal(t, 1.0, eta.DelayFactor)
+		assert.Equal(t, 18, eta.Delay)
+		assert.False(t, eta.Notified)
+		repos.notify.AssertNotCalled(t, "NotifyDeliveryDelayed", mock.Anything, mock.Anything, mock.Anything)
+		repos.eta.AssertExpectations(t)
+	})
+
+	t.Run("所要時間の実績で補正し、しきい値以上遅れた場合は通知する", func(t *testing.T) {
+		service, repos := newETATestService()
+		delivery := repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 20))
+		repos.expectRoute()
+		repos.expectTracking(nil, floatPtr(0), floatPtr(2))
+		repos.expectStats(6, 100, 150)
+		repos.notify.On("NotifyDeliveryDelayed", mock.Anything, delivery, mock.AnythingOfType("*models.DeliveryETA")).Return(nil)
+		// 移動時間・荷下ろしの時間を1.5倍する: 3分 + 15分 + 9分
+		estimated := localTime(1, 9, 57)
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", estimated, &estimated).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 30))
+		require.NoError(t, err)
+		assert.Equal(t, estimated, eta.EstimatedTime)
+		assert.Equal(t, 1.5, eta.DelayFactor)
+		assert.Equal(t, 37, eta.Delay)
+		assert.True(t, eta.Notified)
+		repos.notify.AssertExpectations(t)
+		repos.eta.AssertExpectations(t)
+	})
+
+	t.Run("通知済みの到着予定日時からの遅れで判定する", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 0))
+		repos.expectRoute()
+		notified := localTime(1, 9, 40)
+		repos.expectTracking(&notified, floatPtr(0), floatPtr(2))
+		repos.expectStats(0, 0, 0)
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", localTime(1, 9, 48), (*time.Time)(nil)).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 30))
+		require.NoError(t, err)
+		assert.Equal(t, 8, eta.Delay)
+		assert.False(t, eta.Notified)
+		repos.notify.AssertNotCalled(t, "NotifyDeliveryDelayed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("通知に失敗した場合は通知済みの到着予定日時を更新しない", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 0))
+		repos.expectRoute()
+		repos.expectTracking(nil, floatPtr(0), floatPtr(2))
+		repos.expectStats(0, 0, 0)
+		repos.notify.On("NotifyDeliveryDelayed", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("通知作成エラー"))
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", localTime(1, 9, 48), (*time.Time)(nil)).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 30))
+		require.NoError(t, err)
+		assert.False(t, eta.Notified)
+		repos.eta.AssertExpectations(t)
+	})
+
+	t.Run("位置がない場合は配送ルートの到着予定日時から予測する", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusScheduled, localTime(1, 9, 30))
+		repos.expectRoute()
+		repos.expectTracking(nil, nil, nil)
+		repos.expectStats(0, 0, 0)
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", localTime(1, 9, 32), (*time.Time)(nil)).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 8, 0))
+		require.NoError(t, err)
+		assert.Equal(t, localTime(1, 9, 32), eta.EstimatedTime)
+		assert.Equal(t, models.ETABasisPlan, eta.Basis)
+	})
+
+	t.Run("配送ルートがない場合は配送先へ直行すると予測する", func(t *testing.T) {
+		service, repos := newETATestService()
+		delivery := repos.expectDelivery(3, models.DeliveryStatusInTransit, time.Time{})
+		delivery.ToLatitude, delivery.ToLongitude = floatPtr(0), floatPtr(5)
+		repos.routes.On("GetDeliveryRoute", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+		repos.expectTracking(nil, floatPtr(0), floatPtr(2))
+		// 基準の到着予定日時がない場合は最初の予測を基準とする
+		estimated := localTime(1, 9, 6)
+		repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", estimated, &estimated).Return(nil)
+
+		eta, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 0))
+		require.NoError(t, err)
+		assert.Equal(t, estimated, eta.EstimatedTime)
+		assert.Equal(t, models.ETABasisDirect, eta.Basis)
+		assert.False(t, eta.Notified)
+		repos.eta.AssertExpectations(t)
+	})
+
+	t.Run("配送ルートも位置もない場合は予測できない", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusScheduled, localTime(1, 9, 0))
+		repos.routes.On("GetDeliveryRoute", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+		repos.expectTracking(nil, nil, nil)
+
+		_, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 0))
+		assert.ErrorIs(t, err, ErrETAUnavailable)
+		repos.eta.AssertNotCalled(t, "UpdateEstimatedTime", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("配送完了の配送は予測しない", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusDelivered, localTime(1, 9, 0))
+
+		_, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 0))
+		assert.ErrorIs(t, err, ErrETAUnavailable)
+		repos.eta.AssertNotCalled(t, "GetDeliveryTracking", mock.Anything, mock.Anything)
+	})
+
+	t.Run("配送追跡が開始されていない", func(t *testing.T) {
+		service, repos := newETATestService()
+		repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 0))
+		repos.eta.On("GetDeliveryTracking", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+
+		_, err := service.UpdateDeliveryETA(ctx, 3, localTime(1, 9, 0))
+		assert.ErrorIs(t, err, ErrETATrackingNotFound)
+	})
+}
+
+func TestUpdateActiveETAs(t *testing.T) {
+	ctx := context.Background()
+	service, repos := newETATestService()
+
+	repos.eta.On("ListActiveTrackings", mock.Anything).Return([]*models.ETATracking{
+		{TrackingID: "TRK-3", DeliveryID: 3},
+		{TrackingID: "TRK-4", DeliveryID: 4},
+	}, nil)
+	repos.expectDelivery(3, models.DeliveryStatusInTransit, localTime(1, 9, 30))
+	repos.expectRoute()
+	repos.expectTracking(nil, floatPtr(0), floatPtr(2))
+	repos.expectStats(0, 0, 0)
+	repos.eta.On("UpdateEstimatedTime", mock.Anything, "TRK-3", localTime(1, 9, 48), (*time.Time)(nil)).Return(nil)
+	// 配送ルートも位置もない配送は対象外とする
+	repos.expectDelivery(4, models.DeliveryStatusScheduled, localTime(1, 9, 30))
+	repos.routes.On("GetDeliveryRoute", mock.Anything, int64(4)).Return(nil, repository.ErrNotFound)
+	repos.eta.On("GetLatestPosition", mock.Anything, "TRK-4").Return(nil, repository.ErrNotFound)
+
+	etas, err := service.UpdateActiveETAs(ctx, localTime(1, 9, 30))
+	require.NoError(t, err)
+	require.Len(t, etas, 1)
+	assert.Equal(t, int64(3), etas[0].DeliveryID)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 賞味期限監視サービス
+ * ロット在庫の賞味期限を監視し、期限切れ在庫の販売停止とマネージャーへの通知を実装する
+ */
+
+// DefaultExpiryLeadDays 通知日数が設定されていないカテゴリの既定の通知日数
+const DefaultExpiryLeadDays = 30
+
+// ErrExpiryPolicyNotFound 通知日数が設定されていない場合のエラー
+var ErrExpiryPolicyNotFound = errors.New("通知日数が設定されていません")
+
+// ExpiryService 賞味期限監視サービス
+type ExpiryService struct {
+	repo          repository.ExpiryRepository
+	notifyService NotificationService
+}
+
+// NewExpiryService 賞味期限監視サービスを作成する
+func NewExpiryService(repo repository.ExpiryRepository, notifyService NotificationService) *ExpiryService {
+	return &ExpiryService{
+		repo:          repo,
+		notifyService: notifyService,
+	}
+}
+
+// ListPolicies 商品カテゴリごとの通知日数一覧を取得する
+func (s *ExpiryService) ListPolicies(ctx context.Context) ([]*models.ExpiryAlertPolicy, error) {
+	policies, err := s.repo.ListExpiryPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("通知日数一覧取得エラー: %v", err)
+	}
+
+	return policies, nil
+}
+
+// SetPolicy 商品カテゴリの通知日数を設定する
+func (s *ExpiryService) SetPolicy(ctx context.Context, category string, req *models.SetExpiryAlertPolicyRequest) (*models.ExpiryAlertPolicy, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, fmt.Errorf("商品カテゴリを指定してください")
+	}
+	if req.LeadDays <= 0 {
+		return nil, fmt.Errorf("通知日数は1日以上を指定してください")
+	}
+
+	policy := &models.ExpiryAlertPolicy{
+		Category: category,
+		LeadDays: req.LeadDays,
+	}
+	if err := s.repo.SetExpiryPolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("通知日数設定エラー: %v", err)
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy 商品カテゴリの通知日数を削除する（既定の通知日数に戻す）
+func (s *ExpiryService) DeletePolicy(ctx context.Context, category string) error {
+	err := s.repo.DeleteExpiryPolicy(ctx, category)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrExpiryPolicyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("通知日数削除エラー: %v", err)
+	}
+
+	return nil
+}
+
+// ListExpiringStock 指定日数以内に賞味期限を迎える販売可能なロット在庫を取得する
+func (s *ExpiryService) ListExpiringStock(ctx context.Context, now time.Time, days int) ([]*models.ExpiringStock, error) {
+	stocks, err := s.repo.ListExpiringStock(ctx, now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, fmt.Errorf("賞味期限接近在庫取得エラー: %v", err)
+	}
+
+	return stocks, nil
+}
+
+// ScanExpiry ロット在庫の賞味期限を確認する
+// 賞味期限を過ぎた在庫は賞味期限切れ（販売不可）にし、カテゴリの通知日数以内に賞味期限を迎える在庫とあわせて通知する
+// 同じ在庫行への同じ種類の通知は一度だけ行う
+func (s *ExpiryService) ScanExpiry(ctx context.Context, now time.Time) (*models.ExpiryScanResult, error) {
+	policies, err := s.repo.ListExpiryPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("通知日数一覧取得エラー: %v", err)
+	}
+
+	leadDays := make(map[string]int, len(policies))
+	maxLeadDays := DefaultExpiryLeadDays
+	for _, policy := range policies {
+		leadDays[policy.Category] = policy.LeadDays
+		if policy.LeadDays > maxLeadDays {
+			maxLeadDays = policy.LeadDays
+		}
+	}
+
+	stocks, err := s.repo.ListExpiringStock(ctx, now.AddDate(0, 0, maxLeadDays))
+	if err != nil {
+		return nil, fmt.Errorf("賞味期限接近在庫取得エラー: %v", err)
+	}
+
+	result := &models.ExpiryScanResult{ScannedAt: now}
+	for _, stock := range stocks {
+		kind := models.ExpiryAlertApproaching
+		if !stock.BestBeforeDate.After(now) {
+			// 賞味期限を過ぎた在庫は販売不可にする
+			if err := s.repo.MarkExpired(ctx, stock.InventoryID); err != nil {
+				return result, fmt.Errorf("在庫ID %d: %v", stock.InventoryID, err)
+			}
+			stock.Status = models.InventoryStatusExpired
+			kind = models.ExpiryAlertExpired
+			result.Expired++
+		} else {
+			lead, ok := leadDays[stock.Category]
+			if !ok {
+				lead = DefaultExpiryLeadDays
+			}
+			if !stock.BestBeforeDate.Before(now.AddDate(0, 0, lead)) {
+				continue
+			}
+			result.Approaching++
+		}
+
+		recorded, err := s.repo.RecordExpiryAlert(ctx, stock.InventoryID, kind)
+		if err != nil {
+			return result, fmt.Errorf("在庫ID %d: %v", stock.InventoryID, err)
+		}
+		if recorded {
+			result.Alerts = append(result.Alerts, &models.ExpiryAlert{
+				Kind:          kind,
+				Stock:         stock,
+				DaysRemaining: stock.DaysRemaining(now),
+			})
+		}
+	}
+
+	s.notifyAlerts(ctx, result.Alerts)
+
+	return result, nil
+}
+
+// notifyAlerts 賞味期限通知をマネージャー・管理者に送信する
+// 通知エラーはログに記録するだけで、賞味期限の確認自体は成功とする
+func (s *ExpiryService) notifyAlerts(ctx context.Context, alerts []*models.ExpiryAlert) {
+	if s.notifyService == nil || len(alerts) == 0 {
+		return
+	}
+
+	recipients, err := s.repo.ListAlertRecipientIDs(ctx)
+	if err != nil {
+		logger.Error("賞味期限通知の送信先取得エラー", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	for _, alert := range alerts {
+		for _, userID := range recipients {
+			if err := s.notifyService.NotifyStockExpiry(ctx, userID, alert); err != nil {
+				logger.Error("賞味期限通知エラー", map[string]interface{}{
+					"error":        err.Error(),
+					"user_id":      userID,
+					"inventory_id": alert.Stock.InventoryID,
+				})
+			}
+		}
+	}
+}
+
+// StartExpiryMonitor 賞味期限の確認を定期実行する
+func (s *ExpiryService) StartExpiryMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("賞味期限の監視を停止しました")
+			return
+		case <-ticker.C:
+			result, err := s.ScanExpiry(ctx, time.Now())
+			if err != nil {
+				logger.Error("賞味期限の監視エラー", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if result.Expired > 0 || len(result.Alerts) > 0 {
+				logger.Info("賞味期限を確認しました", map[string]interface{}{
+					"expired_count":     result.Expired,
+					"approaching_count": result.Approaching,
+					"alert_count":       len(result.Alerts),
+				})
+			}
+		}
+	}
+}
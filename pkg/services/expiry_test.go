@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 賞味期限監視サービステスト
+ */
+
+func TestScanExpiry(t *testing.T) {
+	mockExpiryRepo := new(mocks.MockExpiryRepository)
+	mockNotifyService := new(mocks.MockNotificationService)
+	service := NewExpiryService(mockExpiryRepo, mockNotifyService)
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	expired := &models.ExpiringStock{InventoryID: 1, ProductID: 1, Category: "煎茶", Location: "東京倉庫", LotID: 3, LotNumber: "SZ-2025-01", BestBeforeDate: now.AddDate(0, 0, -1), Quantity: 20, Status: models.InventoryStatusAvailable}
+	// 抹茶は通知日数60日のため通知対象、煎茶は既定の30日のため対象外
+	matcha := &models.ExpiringStock{InventoryID: 2, ProductID: 2, Category: "抹茶", Location: "東京倉庫", LotID: 4, LotNumber: "UJ-2026-01", BestBeforeDate: now.AddDate(0, 0, 45), Quantity: 10, Status: models.InventoryStatusAvailable}
+	sencha := &models.ExpiringStock{InventoryID: 3, ProductID: 1, Category: "煎茶", Location: "静岡倉庫", LotID: 5, LotNumber: "SZ-2026-02", BestBeforeDate: now.AddDate(0, 0, 45), Quantity: 50, Status: models.InventoryStatusAvailable}
+
+	mockExpiryRepo.On("ListExpiryPolicies", ctx).Return([]*models.ExpiryAlertPolicy{{Category: "抹茶", LeadDays: 60}}, nil)
+	mockExpiryRepo.On("ListExpiringStock", ctx, now.AddDate(0, 0, 60)).Return([]*models.ExpiringStock{expired, matcha, sencha}, nil)
+	mockExpiryRepo.On("MarkExpired", ctx, int64(1)).Return(nil)
+	mockExpiryRepo.On("RecordExpiryAlert", ctx, int64(1), models.ExpiryAlertExpired).Return(true, nil)
+	// 抹茶の在庫は前回の確認で通知済み
+	mockExpiryRepo.On("RecordExpiryAlert", ctx, int64(2), models.ExpiryAlertApproaching).Return(false, nil)
+	mockExpiryRepo.On("ListAlertRecipientIDs", ctx).Return([]int64{7, 8}, nil)
+	mockNotifyService.On("NotifyStockExpiry", ctx, mock.Anything, mock.MatchedBy(func(alert *models.ExpiryAlert) bool {
+		return alert.Kind == models.ExpiryAlertExpired && alert.Stock.InventoryID == 1
+	})).Return(nil).Twice()
+
+	result, err := service.ScanExpiry(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Expired)
+	assert.Equal(t, 1, result.Approaching)
+	assert.Len(t, result.Alerts, 1)
+	assert.Equal(t, models.InventoryStatusExpired, expired.Status)
+	mockExpiryRepo.AssertNotCalled(t, "RecordExpiryAlert", ctx, int64(3), mock.Anything)
+	mockExpiryRepo.AssertExpectations(t)
+	mockNotifyService.AssertExpectations(t)
+}
+
+func TestGetAvailability_ExcludesExpiredStock(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryService(mockRepo, mockReservationRepo)
+
+	ctx := context.Background()
+	lotID := int64(3)
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 40, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+		{ID: 2, ProductID: 1, Quantity: 60, Location: "東京倉庫", LotID: &lotID, Status: models.InventoryStatusExpired},
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+
+	available, err := service.CheckAvailability(ctx, 1, "東京倉庫", 50)
+
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
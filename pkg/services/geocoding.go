@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+)
+
+/*
+ * 住所のジオコーディング
+ * 配送先・倉庫の住所の正規化と位置の特定、解釈できない住所の要確認への振り分けを実装する
+ */
+
+// addressLocation 住所の正規化と位置の特定の結果
+type addressLocation struct {
+	Address   string
+	Latitude  *float64
+	Longitude *float64
+	Status    models.AddressStatus
+	Note      string
+}
+
+// locateAddress 住所を正規化して位置を求める
+//
+// 緯度経度を指定した場合はその位置を使用し（manual）、指定しない場合は住所から求めた位置を使用する（geocoded）。
+// 住所を解釈できない場合は、住所を表記の正規化のみ行って要確認（needs_review）とし、理由をNoteに記録する。
+// geocoderがnilの場合は住所をそのまま使用し、緯度経度の指定がなければ未確認（unverified）とする
+func locateAddress(ctx context.Context, geocoder geo.Geocoder, address string, latitude, longitude *float64) *addressLocation {
+	location := &addressLocation{Address: address, Latitude: latitude, Longitude: longitude}
+	manual := latitude != nil && longitude != nil
+
+	if geocoder == nil {
+		location.Status = models.AddressStatusUnverified
+		if manual {
+			location.Status = models.AddressStatusManual
+		}
+		return location
+	}
+
+	result, err := geocoder.Geocode(ctx, address)
+	if err != nil {
+		var addressErr *geo.AddressError
+		if !errors.As(err, &addressErr) {
+			// ジオコーダの障害で登録を止めないよう、要確認として登録する
+			logger.Error("ジオコーディングエラー", map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			})
+		}
+		location.Address = geo.NormalizeAddress(address)
+		location.Status = models.AddressStatusNeedsReview
+		location.Note = err.Error()
+		return location
+	}
+
+	location.Address = result.Address
+	if manual {
+		location.Status = models.AddressStatusManual
+		return location
+	}
+
+	latitudeValue, longitudeValue := result.Point.Latitude, result.Point.Longitude
+	location.Latitude = &latitudeValue
+	location.Longitude = &longitudeValue
+	location.Status = models.AddressStatusGeocoded
+	return location
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 住所のジオコーディングのテスト
+ * 配送先・倉庫の住所の正規化・位置の特定と、解釈できない住所の要確認への振り分けを検証する
+ */
+
+// failingGeocoder 常に障害を返すジオコーダ
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(ctx context.Context, address string) (*geo.GeocodeResult, error) {
+	return nil, errors.New("ジオコーディングサービスに接続できません")
+}
+
+func newTestGazetteer(t *testing.T) *geo.Gazetteer {
+	gazetteer, err := geo.NewGazetteer()
+	require.NoError(t, err)
+	return gazetteer
+}
+
+func TestLocateAddress(t *testing.T) {
+	ctx := context.Background()
+	gazetteer := newTestGazetteer(t)
+
+	t.Run("住所を正規化して位置を求める", func(t *testing.T) {
+		location := locateAddress(ctx, gazetteer, "〒４２７－００２２　静岡県島田市本通１ー１", nil, nil)
+		assert.Equal(t, "〒427-0022 静岡県島田市本通1-1", location.Address)
+		assert.Equal(t, models.AddressStatusGeocoded, location.Status)
+		require.NotNil(t, location.Latitude)
+		assert.InDelta(t, 34.84, *location.Latitude, 0.05)
+		assert.InDelta(t, 138.18, *location.Longitude, 0.05)
+		assert.Empty(t, location.Note)
+	})
+
+	t.Run("緯度経度を指定した場合はその位置を使用する", func(t *testing.T) {
+		location := locateAddress(ctx, gazetteer, "静岡県島田市本通１ー１", floatPtr(34.83), floatPtr(138.17))
+		assert.Equal(t, "静岡県島田市本通1-1", location.Address)
+		assert.Equal(t, models.AddressStatusManual, location.Status)
+		assert.Equal(t, 34.83, *location.Latitude)
+	})
+
+	t.Run("解釈できない住所は要確認とする", func(t *testing.T) {
+		location := locateAddress(ctx, gazetteer, "〒４２７－００２２　大阪府大阪市北区", nil, nil)
+		assert.Equal(t, "〒427-0022 大阪府大阪市北区", location.Address)
+		assert.Equal(t, models.AddressStatusNeedsReview, location.Status)
+		assert.Contains(t, location.Note, "一致しません")
+		assert.Nil(t, location.Latitude)
+	})
+
+	t.Run("ジオコーダの障害時も要確認として登録する", func(t *testing.T) {
+		location := locateAddress(ctx, failingGeocoder{}, "静岡県島田市本通1-1", nil, nil)
+		assert.Equal(t, models.AddressStatusNeedsReview, location.Status)
+		assert.Contains(t, location.Note, "接続できません")
+	})
+
+	t.Run("ジオコーダがなければ住所をそのまま使用する", func(t *testing.T) {
+		location := locateAddress(ctx, nil, "静岡県島田市本通１ー１", nil, nil)
+		assert.Equal(t, "静岡県島田市本通１ー１", location.Address)
+		assert.Equal(t, models.AddressStatusUnverified, location.Status)
+
+		location = locateAddress(ctx, nil, "静岡県島田市本通１ー１", floatPtr(34.83), floatPtr(138.17))
+		assert.Equal(t, models.AddressStatusManual, location.Status)
+	})
+}
+
+func TestUpdateDeliveryAddress(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(t *testing.T, delivery *models.Delivery) (*DeliveryService, *mocks.MockDeliveryRepository) {
+		mockRepo := new(mocks.MockDeliveryRepository)
+		mockRepo.On("GetDelivery", ctx, delivery.ID).Return(delivery, nil)
+		uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{Deliveries: mockRepo})
+		return NewDeliveryService(mockRepo, new(mocks.MockInventoryRepository), uow, nil, nil, newTestGazetteer(t)), mockRepo
+	}
+
+	t.Run("要確認の住所を修正して位置を求め直す", func(t *testing.T) {
+		delivery := &models.Delivery{
+			ID:            1,
+			Status:        models.DeliveryStatusScheduled,
+			ToAddress:     "本通1-1",
+			AddressStatus: models.AddressStatusNeedsReview,
+			AddressNote:   "住所を解釈できません（都道府県を特定できません）: 本通1-1",
+			Version:       3,
+		}
+		service, mockRepo := newService(t, delivery)
+		mockRepo.On("UpdateDelivery", ctx, delivery).Return(nil)
+
+		updated, err := service.UpdateDeliveryAddress(ctx, 1, &models.UpdateAddressRequest{ToAddress: "静岡県島田市本通1-1", ExpectedVersion: 3})
+		require.NoError(t, err)
+		assert.Equal(t, "静岡県島田市本通1-1", updated.ToAddress)
+		assert.Equal(t, models.AddressStatusGeocoded, updated.AddressStatus)
+		assert.Empty(t, updated.AddressNote)
+		assert.True(t, updated.HasDestinationCoordinates())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("出荷後の配送の住所は修正できない", func(t *testing.T) {
+		delivery := &models.Delivery{ID: 1, Status: models.DeliveryStatusInTransit, ToAddress: "静岡県島田市本通1-1"}
+		service, mockRepo := newService(t, delivery)
+
+		_, err := service.UpdateDeliveryAddress(ctx, 1, &models.UpdateAddressRequest{ToAddress: "静岡県静岡市葵区追手町9-6"})
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+	})
+
+	t.Run("取得後に更新された配送は修正しない", func(t *testing.T) {
+		delivery := &models.Delivery{ID: 1, Status: models.DeliveryStatusPending, Version: 4}
+		service, _ := newService(t, delivery)
+
+		_, err := service.UpdateDeliveryAddress(ctx, 1, &models.UpdateAddressRequest{ToAddress: "静岡県島田市本通1-1", ExpectedVersion: 3})
+		var conflictErr *models.VersionConflictError
+		assert.ErrorAs(t, err, &conflictErr)
+	})
+}
+
+func TestCreateWarehouse_Geocodes(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockWarehouseRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{Warehouses: mockRepo})
+	service := NewWarehouseService(mockRepo, uow, newTestGazetteer(t))
+	mockRepo.On("CreateWarehouse", ctx, mock.AnythingOfType("*models.Warehouse")).Return(nil)
+
+	warehouse, err := service.CreateWarehouse(ctx, &models.CreateWarehouseRequest{
+		Name:     "牧之原倉庫",
+		Address:  "牧之原市静波９９１－１",
+		Capacity: 5000,
+	})
+	require.NoError(t, err)
+	// 市区町村名から都道府県名を補う
+	assert.Equal(t, "静岡県牧之原市静波991-1", warehouse.Address)
+	assert.Equal(t, models.AddressStatusGeocoded, warehouse.AddressStatus)
+	assert.True(t, warehouse.HasCoordinates())
+}
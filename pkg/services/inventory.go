@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"tea-logistics/pkg/logger"
 	"tea-logistics/pkg/models"
@@ -16,12 +17,22 @@ import (
 
 // InventoryService 在庫管理サービス
 type InventoryService struct {
-	repo repository.InventoryRepository
+	repo            repository.InventoryRepository
+	reservationRepo repository.ReservationRepository
+	uow             repository.UnitOfWork
 }
 
 // NewInventoryService 在庫管理サービスを作成する
-func NewInventoryService(repo repository.InventoryRepository) *InventoryService {
-	return &InventoryService{repo: repo}
+func NewInventoryService(
+	repo repository.InventoryRepository,
+	reservationRepo repository.ReservationRepository,
+	uow repository.UnitOfWork,
+) *InventoryService {
+	return &InventoryService{
+		repo:            repo,
+		reservationRepo: reservationRepo,
+		uow:             uow,
+	}
 }
 
 // CreateInventory 在庫を作成する
@@ -30,11 +41,38 @@ func (s *InventoryService) CreateInventory(ctx context.Context, req *models.Crea
 		ProductID: req.ProductID,
 		Quantity:  req.Quantity,
 		Location:  req.Location,
+		BinID:     req.BinID,
 		Status:    req.Status,
 	}
 
-	if err := s.repo.CreateInventory(ctx, inventory); err != nil {
-		return nil, fmt.Errorf("在庫作成エラー: %v", err)
+	if req.BinID == nil && req.LotNumber == "" {
+		if err := s.repo.CreateInventory(ctx, inventory); err != nil {
+			return nil, fmt.Errorf("在庫作成エラー: %v", err)
+		}
+		return inventory, nil
+	}
+
+	// ロット・ビンを指定する場合はロットとゾーンの保管ルールを確認してから作成する
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		lot, err := resolveLot(ctx, tx, req.ProductID, req.LotNumber)
+		if err != nil {
+			return err
+		}
+		inventory.LotID = lotIDOf(lot)
+
+		if req.BinID != nil {
+			if err := checkBinPlacement(ctx, tx, req.ProductID, req.Location, *req.BinID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Inventory.CreateInventory(ctx, inventory); err != nil {
+			return fmt.Errorf("在庫作成エラー: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return inventory, nil
@@ -51,13 +89,13 @@ func (s *InventoryService) GetInventory(ctx context.Context, id int64) (*models.
 }
 
 // ListInventories 在庫一覧を取得する
-func (s *InventoryService) ListInventories(ctx context.Context) ([]*models.Inventory, error) {
-	inventories, err := s.repo.ListInventories(ctx)
+func (s *InventoryService) ListInventories(ctx context.Context, spec *repository.QuerySpec) ([]*models.Inventory, *repository.PageInfo, error) {
+	inventories, page, err := s.repo.ListInventories(ctx, spec)
 	if err != nil {
-		return nil, fmt.Errorf("在庫一覧取得エラー: %v", err)
+		return nil, nil, wrapListError("在庫一覧取得エラー", err)
 	}
 
-	return inventories, nil
+	return inventories, page, nil
 }
 
 // UpdateInventory 在庫を更新する
@@ -72,7 +110,7 @@ func (s *InventoryService) UpdateInventory(ctx context.Context, id int64, req *m
 	inventory.Status = req.Status
 
 	if err := s.repo.UpdateInventory(ctx, inventory); err != nil {
-		return nil, fmt.Errorf("在庫更新エラー: %v", err)
+		return nil, wrapUpdateError("在庫更新エラー", err)
 	}
 
 	return inventory, nil
@@ -98,9 +136,15 @@ func (s *InventoryService) GetInventoryByProduct(ctx context.Context, productID
 }
 
 // UpdateQuantity 在庫数を更新する
+// 読み取り後に他の操作で更新されていた場合はバージョン競合エラーを返す
 func (s *InventoryService) UpdateQuantity(ctx context.Context, id int64, quantity int) error {
-	if err := s.repo.UpdateQuantity(ctx, id, quantity); err != nil {
-		return fmt.Errorf("在庫数更新エラー: %v", err)
+	inventory, err := s.repo.GetInventory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("在庫取得エラー: %v", err)
+	}
+
+	if err := s.repo.UpdateQuantity(ctx, id, quantity, inventory.Version); err != nil {
+		return wrapUpdateError("在庫数更新エラー", err)
 	}
 
 	return nil
@@ -123,110 +167,156 @@ func (s *InventoryService) CreateMovement(ctx context.Context, req *models.Creat
 		"from_location":    req.FromLocation,
 		"to_location":      req.ToLocation,
 		"quantity":         req.Quantity,
+		"unit":             req.Unit,
 		"movement_type":    req.MovementType,
 		"reference_number": req.ReferenceNumber,
 	})
 
-	// 移動元の在庫をロケーション単位で取得
-	fromInventory, err := s.GetProductInventory(ctx, req.ProductID, req.FromLocation)
+	// 移動元・移動先の在庫更新と移動記録を単一トランザクションで実行する
+	var movement *models.InventoryMovement
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		var err error
+		movement, err = s.createMovementTx(ctx, tx, req)
+		return err
+	})
 	if err != nil {
-		logger.Error("移動元在庫取得エラー", map[string]interface{}{
-			"product_id":    req.ProductID,
-			"from_location": req.FromLocation,
-			"error":         err.Error(),
-		})
-		return nil, fmt.Errorf("移動元在庫取得エラー: %v", err)
+		return nil, err
+	}
+
+	logger.Info("在庫移動処理完了", map[string]interface{}{
+		"movement_id":      movement.ID,
+		"product_id":       movement.ProductID,
+		"from_location":    movement.FromLocation,
+		"to_location":      movement.ToLocation,
+		"quantity":         movement.Quantity,
+		"movement_type":    movement.MovementType,
+		"reference_number": movement.ReferenceNumber,
+	})
+
+	return movement, nil
+}
+
+// createMovementTx トランザクション内で在庫移動を実行する
+// 移動元ビンを指定しない場合は、ロケーション内の在庫行（ビン未割当を優先）から払い出す
+// ロット番号を指定しない場合は、ロット管理していない在庫を移動する
+// 荷姿を指定した場合は、数量を基本単位に換算してから在庫を移動する
+func (s *InventoryService) createMovementTx(ctx context.Context, tx *repository.TxRepositories, req *models.CreateMovementRequest) (*models.InventoryMovement, error) {
+	repo := tx.Inventory
+
+	unitQuantity := req.Quantity
+	if !models.IsBaseUnit(req.Unit) {
+		base, _, err := toBaseQuantity(ctx, tx.Variants, req.ProductID, req.Unit, req.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		converted := *req
+		converted.Quantity = base
+		req = &converted
+	}
+
+	lot, err := resolveLot(ctx, tx, req.ProductID, req.LotNumber)
+	if err != nil {
+		return nil, err
+	}
+	lotID := lotIDOf(lot)
+
+	// 棚卸中のロケーションの在庫は移動できない
+	if err := checkLocationNotFrozen(ctx, tx, req.FromLocation, req.ToLocation); err != nil {
+		return nil, err
+	}
+
+	// 移動元の在庫を取得する（並行する引当と同じ在庫を使用しないよう、引当と同じロックを取得してから読む）
+	// 移動先への入庫もロックを取得するため、移動元・移動先のロックを一定の順序でまとめて取得する
+	if err := lockStocks(ctx, tx, []stockKey{
+		{ProductID: req.ProductID, Location: req.FromLocation},
+		{ProductID: req.ProductID, Location: req.ToLocation},
+	}); err != nil {
+		return nil, err
+	}
+	var fromStock, sellable *locationStock
+	if req.FromBinID != nil {
+		row, err := findStockRow(ctx, repo, req.ProductID, req.FromLocation, req.FromBinID, lotID, false)
+		if err != nil {
+			logger.Error("移動元在庫取得エラー", map[string]interface{}{
+				"product_id":    req.ProductID,
+				"from_location": req.FromLocation,
+				"from_bin_id":   *req.FromBinID,
+				"error":         err.Error(),
+			})
+			return nil, fmt.Errorf("移動元在庫取得エラー: %v", err)
+		}
+		fromStock = &locationStock{ProductID: req.ProductID, Location: req.FromLocation, Rows: []*models.Inventory{row}}
+	} else {
+		stock, err := loadLocationStock(ctx, repo, req.ProductID, req.FromLocation)
+		if err != nil {
+			logger.Error("移動元在庫取得エラー", map[string]interface{}{
+				"product_id":    req.ProductID,
+				"from_location": req.FromLocation,
+				"error":         err.Error(),
+			})
+			return nil, fmt.Errorf("移動元在庫取得エラー: %v", err)
+		}
+		fromStock, sellable = stock.ForLot(lotID), stock
 	}
 
 	logger.Info("移動元在庫情報", map[string]interface{}{
-		"inventory_id": fromInventory.ID,
-		"quantity":     fromInventory.Quantity,
-		"location":     fromInventory.Location,
+		"product_id": req.ProductID,
+		"quantity":   fromStock.OnHand(),
+		"location":   req.FromLocation,
 	})
 
 	// 在庫数のチェック
-	if fromInventory.Quantity < req.Quantity {
+	if fromStock.OnHand() < req.Quantity {
 		logger.Warn("在庫不足", map[string]interface{}{
 			"product_id":    req.ProductID,
 			"from_location": req.FromLocation,
 			"required":      req.Quantity,
-			"available":     fromInventory.Quantity,
+			"available":     fromStock.OnHand(),
 		})
 		return nil, fmt.Errorf("在庫が不足しています")
 	}
 
-	// 移動元の在庫を減らす
-	newFromQuantity := fromInventory.Quantity - req.Quantity
-	if err := s.repo.UpdateQuantity(ctx, fromInventory.ID, newFromQuantity); err != nil {
-		logger.Error("移動元在庫更新エラー", map[string]interface{}{
-			"inventory_id": fromInventory.ID,
-			"new_quantity": newFromQuantity,
-			"error":        err.Error(),
-		})
-		return nil, fmt.Errorf("移動元在庫更新エラー: %v", err)
+	// 引当中の在庫は、ロケーション外へ移動・出庫できない
+	if req.ToLocation != req.FromLocation {
+		if sellable == nil {
+			if sellable, err = loadLocationStock(ctx, repo, req.ProductID, req.FromLocation); err != nil {
+				return nil, fmt.Errorf("移動元在庫取得エラー: %v", err)
+			}
+		}
+		if err := checkUnreservedStock(ctx, tx, sellable, lotID, req.Quantity); err != nil {
+			return nil, err
+		}
 	}
 
-	logger.Info("移動元在庫更新完了", map[string]interface{}{
-		"inventory_id": fromInventory.ID,
-		"old_quantity": fromInventory.Quantity,
-		"new_quantity": newFromQuantity,
-	})
+	// 移動先倉庫の空き容量を確認
+	if err := checkWarehouseCapacity(ctx, tx, req.FromLocation, req.ToLocation, req.Quantity); err != nil {
+		return nil, err
+	}
 
-	// 移動先の在庫をロケーション単位で取得
-	toInventory, err := s.GetProductInventory(ctx, req.ProductID, req.ToLocation)
-	if err == nil {
-		// 既存の移動先在庫がある場合は加算
-		newToQuantity := toInventory.Quantity + req.Quantity
-		if err := s.repo.UpdateQuantity(ctx, toInventory.ID, newToQuantity); err != nil {
-			logger.Error("移動先在庫更新エラー", map[string]interface{}{
-				"inventory_id": toInventory.ID,
-				"new_quantity": newToQuantity,
-				"error":        err.Error(),
-			})
-			return nil, fmt.Errorf("移動先在庫更新エラー: %v", err)
-		}
-		logger.Info("移動先在庫更新完了", map[string]interface{}{
-			"inventory_id": toInventory.ID,
-			"old_quantity": toInventory.Quantity,
-			"new_quantity": newToQuantity,
-		})
-	} else {
-		// 移動先に在庫がない場合は新規作成
-		newInventory := &models.Inventory{
-			ProductID: req.ProductID,
-			Quantity:  req.Quantity,
-			Location:  req.ToLocation,
-			Status:    models.InventoryStatusAvailable,
-		}
-		if err := s.repo.CreateInventory(ctx, newInventory); err != nil {
-			logger.Error("移動先在庫作成エラー", map[string]interface{}{
-				"product_id":  req.ProductID,
-				"to_location": req.ToLocation,
-				"quantity":    req.Quantity,
-				"error":       err.Error(),
-			})
-			return nil, fmt.Errorf("移動先在庫作成エラー: %v", err)
+	// 移動先ビンのゾーン保管ルールを確認
+	if req.ToBinID != nil {
+		if err := checkBinPlacement(ctx, tx, req.ProductID, req.ToLocation, *req.ToBinID); err != nil {
+			return nil, err
 		}
-		logger.Info("移動先在庫新規作成完了", map[string]interface{}{
-			"inventory_id": newInventory.ID,
-			"product_id":   newInventory.ProductID,
-			"location":     newInventory.Location,
-			"quantity":     newInventory.Quantity,
-		})
 	}
 
-	// 在庫移動を記録
+	// 在庫移動を先に記録し、以降の在庫の増減を在庫元帳上でこの移動に紐付ける
 	movement := &models.InventoryMovement{
 		ProductID:       req.ProductID,
 		FromLocation:    req.FromLocation,
+		FromBinID:       req.FromBinID,
 		ToLocation:      req.ToLocation,
+		ToBinID:         req.ToBinID,
+		LotID:           lotID,
 		Quantity:        req.Quantity,
+		Unit:            unitOrBase(req.Unit),
+		UnitQuantity:    unitQuantity,
 		MovementType:    req.MovementType,
 		MovementDate:    req.MovementDate,
 		ReferenceNumber: req.ReferenceNumber,
 	}
 
-	if err := s.repo.CreateMovement(ctx, movement); err != nil {
+	if err := repo.CreateMovement(ctx, movement); err != nil {
 		logger.Error("在庫移動作成エラー", map[string]interface{}{
 			"product_id":       req.ProductID,
 			"from_location":    req.FromLocation,
@@ -239,14 +329,34 @@ func (s *InventoryService) CreateMovement(ctx context.Context, req *models.Creat
 		return nil, fmt.Errorf("在庫移動作成エラー: %v", err)
 	}
 
-	logger.Info("在庫移動処理完了", map[string]interface{}{
-		"movement_id":      movement.ID,
-		"product_id":       movement.ProductID,
-		"from_location":    movement.FromLocation,
-		"to_location":      movement.ToLocation,
-		"quantity":         movement.Quantity,
-		"movement_type":    movement.MovementType,
-		"reference_number": movement.ReferenceNumber,
+	ctx = repository.WithLedgerMovement(ctx, movement.ID)
+
+	// 移動元の在庫を減らす
+	if _, err := consumeLocationStock(ctx, repo, fromStock, req.Quantity); err != nil {
+		logger.Error("移動元在庫更新エラー", map[string]interface{}{
+			"product_id":    req.ProductID,
+			"from_location": req.FromLocation,
+			"error":         err.Error(),
+		})
+		return nil, wrapUpdateError("移動元在庫更新エラー", err)
+	}
+
+	// 移動先の在庫を増やす（在庫がない場合は新規作成）
+	toInventory, err := receiveStock(ctx, tx, req.ProductID, req.ToLocation, req.ToBinID, lotID, models.InventoryStatusAvailable, req.Quantity)
+	if err != nil {
+		logger.Error("移動先在庫更新エラー", map[string]interface{}{
+			"product_id":  req.ProductID,
+			"to_location": req.ToLocation,
+			"quantity":    req.Quantity,
+			"error":       err.Error(),
+		})
+		return nil, err
+	}
+
+	logger.Info("移動先在庫更新完了", map[string]interface{}{
+		"inventory_id": toInventory.ID,
+		"location":     toInventory.Location,
+		"new_quantity": toInventory.Quantity,
 	})
 
 	return movement, nil
@@ -264,84 +374,121 @@ func (s *InventoryService) ListMovements(ctx context.Context, productID int64) (
 
 // GetProductInventory 商品の在庫を取得する
 func (s *InventoryService) GetProductInventory(ctx context.Context, productID int64, location string) (*models.Inventory, error) {
-	inventories, err := s.repo.GetInventoryByLocation(ctx, location)
-	if err != nil {
-		return nil, fmt.Errorf("在庫取得エラー: %v", err)
-	}
+	return findProductInventory(ctx, s.repo, productID, location)
+}
 
-	for _, inv := range inventories {
-		if inv.ProductID == productID {
-			return inv, nil
+// findProductInventory 指定リポジトリから商品のビン未割当・ロット管理外の販売可能在庫を取得する
+func findProductInventory(ctx context.Context, repo repository.InventoryRepository, productID int64, location string) (*models.Inventory, error) {
+	return findStockRow(ctx, repo, productID, location, nil, nil, false)
+}
+
+// UpdateInventoryQuantity 在庫数を更新し、更新後の在庫を返す
+// 棚卸中のロケーションの在庫は更新できない
+// expectedVersionを指定した場合は、在庫のバージョンが一致しなければバージョン競合エラーを返す
+func (s *InventoryService) UpdateInventoryQuantity(ctx context.Context, productID int64, location string, quantity int, expectedVersion int) (*models.Inventory, error) {
+	var inventory *models.Inventory
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := checkLocationNotFrozen(ctx, tx, location); err != nil {
+			return err
 		}
-	}
 
-	return nil, fmt.Errorf("在庫が見つかりません")
-}
+		var err error
+		inventory, err = findProductInventory(ctx, tx.Inventory, productID, location)
+		if err != nil {
+			return err
+		}
+		if err := checkExpectedVersion("在庫", inventory.ID, expectedVersion, inventory.Version); err != nil {
+			return err
+		}
 
-// UpdateInventoryQuantity 在庫数を更新する
-func (s *InventoryService) UpdateInventoryQuantity(ctx context.Context, productID int64, location string, quantity int) error {
-	inventory, err := s.GetProductInventory(ctx, productID, location)
-	if err != nil {
-		return err
-	}
+		if err := tx.Inventory.UpdateQuantity(ctx, inventory.ID, quantity, inventory.Version); err != nil {
+			return wrapUpdateError("在庫数更新エラー", err)
+		}
+		inventory.Quantity = quantity
+		inventory.Version++
 
-	if err := s.repo.UpdateQuantity(ctx, inventory.ID, quantity); err != nil {
-		return fmt.Errorf("在庫数更新エラー: %v", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return inventory, nil
 }
 
 // TransferInventory 在庫を移動する
 func (s *InventoryService) TransferInventory(ctx context.Context, productID int64, fromLocation, toLocation string, quantity int) error {
-	// 移動元の在庫を確認
-	fromInventory, err := s.GetProductInventory(ctx, productID, fromLocation)
-	if err != nil {
-		return err
-	}
+	// 移動元の減算と移動先の加算を単一トランザクションで実行する
+	return s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := checkLocationNotFrozen(ctx, tx, fromLocation, toLocation); err != nil {
+			return err
+		}
 
-	if fromInventory.Quantity < quantity {
-		return fmt.Errorf("在庫が不足しています")
-	}
+		// 移動元・移動先の在庫のロックを一定の順序で取得してから移動元の在庫を確認する
+		// ロット管理している在庫はロットを指定して移動する
+		if err := lockStocks(ctx, tx, []stockKey{
+			{ProductID: productID, Location: fromLocation},
+			{ProductID: productID, Location: toLocation},
+		}); err != nil {
+			return err
+		}
+		stock, err := loadLocationStock(ctx, tx.Inventory, productID, fromLocation)
+		if err != nil {
+			return err
+		}
+		fromStock := stock.ForLot(nil)
 
-	// 移動先の在庫を確認
-	toInventory, err := s.GetProductInventory(ctx, productID, toLocation)
-	if err != nil {
-		// 移動先に在庫がない場合は新規作成
-		toInventory = &models.Inventory{
-			ProductID: productID,
-			Quantity:  0,
-			Location:  toLocation,
-			Status:    models.InventoryStatusAvailable,
+		if fromStock.OnHand() < quantity {
+			return fmt.Errorf("在庫が不足しています")
 		}
-		if err := s.repo.CreateInventory(ctx, toInventory); err != nil {
-			return fmt.Errorf("移動先在庫作成エラー: %v", err)
+
+		// 引当中の在庫は移動できない
+		if fromLocation != toLocation {
+			if err := checkUnreservedStock(ctx, tx, stock, nil, quantity); err != nil {
+				return err
+			}
 		}
-	}
 
-	// 移動元の在庫を減らす
-	if err := s.repo.UpdateQuantity(ctx, fromInventory.ID, fromInventory.Quantity-quantity); err != nil {
-		return fmt.Errorf("移動元在庫更新エラー: %v", err)
-	}
+		// 移動先倉庫の空き容量を確認
+		if err := checkWarehouseCapacity(ctx, tx, fromLocation, toLocation, quantity); err != nil {
+			return err
+		}
 
-	// 移動先の在庫を増やす
-	if err := s.repo.UpdateQuantity(ctx, toInventory.ID, toInventory.Quantity+quantity); err != nil {
-		// 移動先の更新に失敗した場合、移動元を元に戻す
-		if restoreErr := s.repo.UpdateQuantity(ctx, fromInventory.ID, fromInventory.Quantity); restoreErr != nil {
-			return fmt.Errorf("移動先在庫更新エラー: %v, 移動元在庫復元エラー: %v", err, restoreErr)
+		// 在庫移動を記録し、在庫の増減を在庫元帳上でこの移動に紐付ける
+		movement := &models.InventoryMovement{
+			ProductID:    productID,
+			FromLocation: fromLocation,
+			ToLocation:   toLocation,
+			Quantity:     quantity,
+			MovementType: models.MovementTypeTransfer,
+			MovementDate: time.Now(),
 		}
-		return fmt.Errorf("移動先在庫更新エラー: %v", err)
-	}
+		if err := tx.Inventory.CreateMovement(ctx, movement); err != nil {
+			return fmt.Errorf("在庫移動作成エラー: %v", err)
+		}
+		ctx = repository.WithLedgerMovement(ctx, movement.ID)
 
-	return nil
+		// 移動元の在庫を減らす
+		if _, err := consumeLocationStock(ctx, tx.Inventory, fromStock, quantity); err != nil {
+			return wrapUpdateError("移動元在庫更新エラー", err)
+		}
+
+		// 移動先の在庫を増やす（在庫がない場合は新規作成）
+		if _, err := receiveStock(ctx, tx, productID, toLocation, nil, nil, models.InventoryStatusAvailable, quantity); err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
 
 // CheckAvailability 在庫の利用可能性をチェックする
+// 引当中の数量を除いた引当可能数で判定する
 func (s *InventoryService) CheckAvailability(ctx context.Context, productID int64, location string, quantity int) (bool, error) {
-	inventory, err := s.GetProductInventory(ctx, productID, location)
+	availability, err := s.GetAvailability(ctx, productID, location)
 	if err != nil {
 		return false, err
 	}
 
-	return inventory.Quantity >= quantity, nil
+	return availability.AvailableToPromise >= quantity, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"tea-logistics/pkg/models"
 	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -38,12 +39,16 @@ func (m *MockInventoryRepository) GetInventory(ctx context.Context, id int64) (*
 	return args.Get(0).(*models.Inventory), args.Error(1)
 }
 
-func (m *MockInventoryRepository) ListInventories(ctx context.Context) ([]*models.Inventory, error) {
-	args := m.Called(ctx)
+func (m *MockInventoryRepository) ListInventories(ctx context.Context, spec *repository.QuerySpec) ([]*models.Inventory, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, page, args.Error(2)
 	}
-	return args.Get(0).([]*models.Inventory), args.Error(1)
+	return args.Get(0).([]*models.Inventory), page, args.Error(2)
 }
 
 func (m *MockInventoryRepository) UpdateInventory(ctx context.Context, inventory *models.Inventory) error {
@@ -64,8 +69,8 @@ func (m *MockInventoryRepository) GetInventoryByProduct(ctx context.Context, pro
 	return args.Get(0).(*models.Inventory), args.Error(1)
 }
 
-func (m *MockInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int) error {
-	args := m.Called(ctx, id, quantity)
+func (m *MockInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int, version int) error {
+	args := m.Called(ctx, id, quantity, version)
 	return args.Error(0)
 }
 
@@ -77,6 +82,22 @@ func (m *MockInventoryRepository) GetInventoryByLocation(ctx context.Context, lo
 	return args.Get(0).([]*models.Inventory), args.Error(1)
 }
 
+func (m *MockInventoryRepository) GetInventoryByProductLocation(ctx context.Context, productID int64, location string) ([]*models.Inventory, error) {
+	args := m.Called(ctx, productID, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Inventory), args.Error(1)
+}
+
+func (m *MockInventoryRepository) GetInventoryByStockKey(ctx context.Context, productID int64, location string, binID, lotID *int64, quarantined bool) (*models.Inventory, error) {
+	args := m.Called(ctx, productID, location, binID, lotID, quarantined)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Inventory), args.Error(1)
+}
+
 func (m *MockInventoryRepository) CreateMovement(ctx context.Context, movement *models.InventoryMovement) error {
 	args := m.Called(ctx, movement)
 	return args.Error(0)
@@ -90,9 +111,34 @@ func (m *MockInventoryRepository) ListMovements(ctx context.Context, productID i
 	return args.Get(0).([]*models.InventoryMovement), args.Error(1)
 }
 
+// newDefaultReservationRepo 商品・ロケーション単位の在庫のロックを常に取得できるモックを作成する
+func newDefaultReservationRepo() *mocks.MockReservationRepository {
+	mockReservationRepo := new(mocks.MockReservationRepository)
+	mockReservationRepo.On("LockStock", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return mockReservationRepo
+}
+
+func newTestInventoryService(mockRepo *MockInventoryRepository, mockReservationRepo *mocks.MockReservationRepository) *InventoryService {
+	return newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, new(mocks.MockWarehouseRepository))
+}
+
+func newTestInventoryServiceWithWarehouses(
+	mockRepo *MockInventoryRepository,
+	mockReservationRepo *mocks.MockReservationRepository,
+	mockWarehouseRepo *mocks.MockWarehouseRepository,
+) *InventoryService {
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:    mockRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+	return NewInventoryService(mockRepo, mockReservationRepo, uow)
+}
+
 func TestCreateInventory(t *testing.T) {
 	mockRepo := new(MockInventoryRepository)
-	service := NewInventoryService(mockRepo)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
 
 	ctx := context.Background()
 	req := &models.CreateInventoryRequest{
@@ -118,7 +164,7 @@ func TestCreateInventory(t *testing.T) {
 
 func TestGetInventory(t *testing.T) {
 	mockRepo := new(MockInventoryRepository)
-	service := NewInventoryService(mockRepo)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
 
 	ctx := context.Background()
 	expectedInventory := &models.Inventory{
@@ -148,7 +194,7 @@ func TestGetInventory(t *testing.T) {
 
 func TestListInventories(t *testing.T) {
 	mockRepo := new(MockInventoryRepository)
-	service := NewInventoryService(mockRepo)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
 
 	ctx := context.Background()
 	expectedInventories := []*models.Inventory{
@@ -172,9 +218,9 @@ func TestListInventories(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("ListInventories", ctx).Return(expectedInventories, nil)
+	mockRepo.On("ListInventories", ctx, (*repository.QuerySpec)(nil)).Return(expectedInventories, &repository.PageInfo{}, nil)
 
-	inventories, err := service.ListInventories(ctx)
+	inventories, _, err := service.ListInventories(ctx, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, inventories)
@@ -187,7 +233,7 @@ func TestListInventories(t *testing.T) {
 
 func TestUpdateInventory(t *testing.T) {
 	mockRepo := new(MockInventoryRepository)
-	service := NewInventoryService(mockRepo)
+	service := newTestInventoryService(mockRepo, newDefaultReservationRepo())
 
 	ctx := context.Background()
 	existingInventory := &models.Inventory{
@@ -222,7 +268,9 @@ func TestUpdateInventory(t *testing.T) {
 
 func TestCreateMovement(t *testing.T) {
 	mockRepo := new(MockInventoryRepository)
-	service := NewInventoryService(mockRepo)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, mockWarehouseRepo)
 
 	ctx := context.Background()
 	fromInventory := &models.Inventory{
@@ -244,13 +292,22 @@ func TestCreateMovement(t *testing.T) {
 	}
 
 	// FromLocation の在庫取得: ロケーションで取得し、対象商品が含まれるケース
-	mockRepo.On("GetInventoryByLocation", ctx, "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	// 移動元の引当済みの在庫はないケース
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	// 移動先倉庫の空き容量を確認
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(&models.Warehouse{ID: 2, Name: "大阪倉庫", Capacity: 1000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(2)).Return(900, nil)
+	// 在庫移動を先に記録し、以降の在庫の増減は在庫元帳上でこの移動に紐付ける
+	mockRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 10
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 10)
 	// 移動元在庫の数量を 100 -> 50 に更新
-	mockRepo.On("UpdateQuantity", ctx, int64(1), 50).Return(nil)
+	mockRepo.On("UpdateQuantity", movementCtx, int64(1), 50, 0).Return(nil)
 	// ToLocation の在庫取得: ロケーションで取得し、対象商品が存在しないケース（空配列を返す）
-	mockRepo.On("GetInventoryByLocation", ctx, "大阪倉庫").Return([]*models.Inventory{}, nil)
-	mockRepo.On("CreateInventory", ctx, mock.AnythingOfType("*models.Inventory")).Return(nil)
-	mockRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Return(nil)
+	mockRepo.On("GetInventoryByStockKey", movementCtx, int64(1), "大阪倉庫", (*int64)(nil), (*int64)(nil), false).Return(nil, repository.ErrNotFound)
+	mockRepo.On("CreateInventory", movementCtx, mock.AnythingOfType("*models.Inventory")).Return(nil)
 
 	movement, err := service.CreateMovement(ctx, req)
 
@@ -264,4 +321,234 @@ func TestCreateMovement(t *testing.T) {
 	assert.Equal(t, req.ReferenceNumber, movement.ReferenceNumber)
 
 	mockRepo.AssertExpectations(t)
+	mockWarehouseRepo.AssertExpectations(t)
+}
+
+func TestCreateMovement_ExceedsWarehouseCapacity(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, mockWarehouseRepo)
+
+	ctx := context.Background()
+	fromInventory := &models.Inventory{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	req := &models.CreateMovementRequest{
+		ProductID:       1,
+		FromLocation:    "東京倉庫",
+		ToLocation:      "大阪倉庫",
+		Quantity:        50,
+		MovementType:    models.MovementTypeTransfer,
+		MovementDate:    time.Now(),
+		ReferenceNumber: "TRF-002",
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(&models.Warehouse{ID: 2, Name: "大阪倉庫", Capacity: 1000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(2)).Return(980, nil)
+
+	movement, err := service.CreateMovement(ctx, req)
+
+	var capacityErr *models.WarehouseCapacityError
+	assert.ErrorAs(t, err, &capacityErr)
+	assert.Equal(t, 20, capacityErr.Remaining)
+	assert.Nil(t, movement)
+	mockRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+}
+
+func TestCreateMovement_ReservedStock(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, mockWarehouseRepo)
+
+	ctx := context.Background()
+	fromInventory := &models.Inventory{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	req := &models.CreateMovementRequest{
+		ProductID:       1,
+		FromLocation:    "東京倉庫",
+		ToLocation:      "大阪倉庫",
+		Quantity:        50,
+		MovementType:    models.MovementTypeTransfer,
+		MovementDate:    time.Now(),
+		ReferenceNumber: "TRF-003",
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	// 100個のうち60個が引当済みのため、引当済みの在庫を除くと40個しか移動できない
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(60, nil)
+
+	movement, err := service.CreateMovement(ctx, req)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "引当済みの在庫を除くと在庫が不足しています")
+	assert.Nil(t, movement)
+	mockReservationRepo.AssertCalled(t, "LockStock", ctx, int64(1), "東京倉庫")
+	mockRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+}
+
+func TestTransferInventory(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryServiceWithWarehouses(mockRepo, mockReservationRepo, mockWarehouseRepo)
+
+	ctx := context.Background()
+	fromInventory := &models.Inventory{
+		ID:        1,
+		ProductID: 1,
+		Quantity:  100,
+		Location:  "東京倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+	toInventory := &models.Inventory{
+		ID:        2,
+		ProductID: 1,
+		Quantity:  20,
+		Location:  "大阪倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	// 移動元の100個のうち60個が引当済みでも、残りの40個から30個を移動できる
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(60, nil)
+	// 移動先が倉庫として登録されていない場合は容量チェックを行わない
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(nil, repository.ErrNotFound)
+	// 移動の在庫移動を記録し、在庫の増減を在庫元帳上でこの移動に紐付ける
+	mockRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeTransfer && m.FromLocation == "東京倉庫" && m.ToLocation == "大阪倉庫" && m.Quantity == 30
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 11
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 11)
+	mockRepo.On("UpdateQuantity", movementCtx, int64(1), 70, 0).Return(nil)
+	mockRepo.On("GetInventoryByStockKey", movementCtx, int64(1), "大阪倉庫", (*int64)(nil), (*int64)(nil), false).Return(toInventory, nil)
+	mockRepo.On("UpdateQuantity", movementCtx, int64(2), 50, 0).Return(nil)
+
+	err := service.TransferInventory(ctx, 1, "東京倉庫", "大阪倉庫", 30)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockWarehouseRepo.AssertExpectations(t)
+}
+
+func TestReserve(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryService(mockRepo, mockReservationRepo)
+
+	ctx := context.Background()
+	inventory := &models.Inventory{
+		ID:        1,
+		ProductID: 1,
+		Quantity:  100,
+		Location:  "東京倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+
+	req := &models.CreateReservationRequest{
+		ProductID:       1,
+		Location:        "東京倉庫",
+		Quantity:        30,
+		ReferenceNumber: "ORD-001",
+		TTLMinutes:      30,
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(60, nil)
+	mockReservationRepo.On("CreateReservation", ctx, mock.AnythingOfType("*models.Reservation")).Return(nil)
+
+	reservation, err := service.Reserve(ctx, req)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, reservation)
+	assert.Equal(t, models.ReservationStatusActive, reservation.Status)
+	assert.Equal(t, 30, reservation.Quantity)
+	assert.NotNil(t, reservation.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), *reservation.ExpiresAt, time.Minute)
+
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestReserve_InsufficientStock(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryService(mockRepo, mockReservationRepo)
+
+	ctx := context.Background()
+	inventory := &models.Inventory{
+		ID:        1,
+		ProductID: 1,
+		Quantity:  100,
+		Location:  "東京倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(90, nil)
+
+	reservation, err := service.Reserve(ctx, &models.CreateReservationRequest{
+		ProductID:       1,
+		Location:        "東京倉庫",
+		Quantity:        20,
+		ReferenceNumber: "ORD-002",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, reservation)
+	mockReservationRepo.AssertNotCalled(t, "CreateReservation", mock.Anything, mock.Anything)
+}
+
+func TestExpireReservations(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryService(mockRepo, mockReservationRepo)
+
+	ctx := context.Background()
+	now := time.Now()
+	expired := []*models.Reservation{
+		{ID: 1, ProductID: 1, Location: "東京倉庫", Quantity: 10, Status: models.ReservationStatusActive},
+		{ID: 2, ProductID: 1, Location: "東京倉庫", Quantity: 5, Status: models.ReservationStatusActive},
+	}
+
+	mockReservationRepo.On("ListExpiredReservations", ctx, now).Return(expired, nil)
+	mockReservationRepo.On("ExpireReservation", ctx, int64(1), now).Return(nil)
+	// 並行して確定済みとなった引当や、有効期限が延長された引当はスキップする
+	mockReservationRepo.On("ExpireReservation", ctx, int64(2), now).Return(repository.ErrNotFound)
+
+	count, err := service.ExpireReservations(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestGetAvailability(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	service := newTestInventoryService(mockRepo, mockReservationRepo)
+
+	ctx := context.Background()
+	inventory := &models.Inventory{
+		ID:        1,
+		ProductID: 1,
+		Quantity:  100,
+		Location:  "東京倉庫",
+		Status:    models.InventoryStatusAvailable,
+	}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{inventory}, nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(35, nil)
+
+	availability, err := service.GetAvailability(ctx, 1, "東京倉庫")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, availability.OnHand)
+	assert.Equal(t, 35, availability.Reserved)
+	assert.Equal(t, 65, availability.AvailableToPromise)
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 在庫元帳サービス
+ * 在庫元帳の照会と、元帳から再計算した残高による在庫数の照合を実装する
+ */
+
+// LedgerService 在庫元帳サービス
+type LedgerService struct {
+	repo repository.LedgerRepository
+}
+
+// NewLedgerService 在庫元帳サービスを作成する
+func NewLedgerService(repo repository.LedgerRepository) *LedgerService {
+	return &LedgerService{repo: repo}
+}
+
+// ListLedgerEntries 在庫行の記帳一覧を取得する
+func (s *LedgerService) ListLedgerEntries(ctx context.Context, inventoryID int64) ([]*models.LedgerEntry, error) {
+	entries, err := s.repo.ListLedgerEntries(ctx, inventoryID)
+	if err != nil {
+		return nil, fmt.Errorf("在庫元帳取得エラー: %v", err)
+	}
+
+	return entries, nil
+}
+
+// Reconcile 在庫行の数量を元帳から再計算した残高と照合する
+// fixがtrueの場合、差異のある在庫行の数量を元帳残高に戻す
+// 照合後に並行して変更・削除された在庫行は戻さず、Skippedとして報告する
+func (s *LedgerService) Reconcile(ctx context.Context, fix bool, now time.Time) (*models.ReconciliationReport, error) {
+	balances, err := s.repo.ListLedgerBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("元帳残高取得エラー: %v", err)
+	}
+
+	report := &models.ReconciliationReport{
+		CheckedAt: now,
+		Checked:   len(balances),
+		Drifts:    []*models.LedgerBalance{},
+		Fixed:     fix,
+		Skipped:   []*models.LedgerBalance{},
+	}
+
+	for _, balance := range balances {
+		if balance.Drift() == 0 {
+			continue
+		}
+		report.Drifts = append(report.Drifts, balance)
+
+		logger.Warn("在庫元帳との差異", map[string]interface{}{
+			"inventory_id":   balance.InventoryID,
+			"product_id":     balance.ProductID,
+			"location":       balance.Location,
+			"quantity":       balance.Quantity,
+			"ledger_balance": balance.LedgerBalance,
+			"drift":          balance.Drift(),
+		})
+
+		if !fix {
+			continue
+		}
+		err := s.repo.RestoreQuantity(ctx, balance.InventoryID, balance.Version)
+		var conflictErr *models.VersionConflictError
+		if errors.As(err, &conflictErr) || errors.Is(err, repository.ErrNotFound) {
+			report.Skipped = append(report.Skipped, balance)
+			logger.Warn("照合後に変更された在庫行のため在庫数を戻しません", map[string]interface{}{
+				"inventory_id": balance.InventoryID,
+				"version":      balance.Version,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("在庫ID %d の在庫数復元エラー: %v", balance.InventoryID, err)
+		}
+	}
+
+	logger.Info("在庫元帳照合完了", map[string]interface{}{
+		"checked": report.Checked,
+		"drifts":  len(report.Drifts),
+		"fixed":   report.Fixed,
+		"skipped": len(report.Skipped),
+	})
+
+	return report, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 在庫元帳サービステスト
+ */
+
+func TestReconcile_ReportsDriftWithoutFix(t *testing.T) {
+	mockLedgerRepo := new(mocks.MockLedgerRepository)
+	service := NewLedgerService(mockLedgerRepo)
+
+	ctx := context.Background()
+	mockLedgerRepo.On("ListLedgerBalances", ctx).Return([]*models.LedgerBalance{
+		{InventoryID: 1, ProductID: 1, Location: "静岡倉庫", Quantity: 80, LedgerBalance: 80},
+		// 元帳を経由せずに数量が書き換えられた在庫行
+		{InventoryID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 45, LedgerBalance: 40},
+	}, nil)
+
+	report, err := service.Reconcile(ctx, false, time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.False(t, report.Fixed)
+	if assert.Len(t, report.Drifts, 1) {
+		assert.Equal(t, int64(2), report.Drifts[0].InventoryID)
+		assert.Equal(t, 5, report.Drifts[0].Drift())
+	}
+	mockLedgerRepo.AssertNotCalled(t, "RestoreQuantity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReconcile_FixRestoresLedgerBalance(t *testing.T) {
+	mockLedgerRepo := new(mocks.MockLedgerRepository)
+	service := NewLedgerService(mockLedgerRepo)
+
+	ctx := context.Background()
+	mockLedgerRepo.On("ListLedgerBalances", ctx).Return([]*models.LedgerBalance{
+		{InventoryID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 45, Version: 3, LedgerBalance: 40},
+	}, nil)
+	// 照合時のバージョンを条件に元帳残高へ戻す
+	mockLedgerRepo.On("RestoreQuantity", ctx, int64(2), 3).Return(nil)
+
+	report, err := service.Reconcile(ctx, true, time.Now())
+
+	assert.NoError(t, err)
+	assert.True(t, report.Fixed)
+	assert.Len(t, report.Drifts, 1)
+	assert.Empty(t, report.Skipped)
+	mockLedgerRepo.AssertExpectations(t)
+}
+
+func TestReconcile_FixSkipsConcurrentlyChangedRows(t *testing.T) {
+	mockLedgerRepo := new(mocks.MockLedgerRepository)
+	service := NewLedgerService(mockLedgerRepo)
+
+	ctx := context.Background()
+	mockLedgerRepo.On("ListLedgerBalances", ctx).Return([]*models.LedgerBalance{
+		{InventoryID: 2, ProductID: 2, Location: "東京倉庫", Quantity: 45, Version: 3, LedgerBalance: 40},
+		{InventoryID: 3, ProductID: 3, Location: "静岡倉庫", Quantity: 10, Version: 1, LedgerBalance: 12},
+		{InventoryID: 4, ProductID: 4, Location: "静岡倉庫", Quantity: 7, Version: 2, LedgerBalance: 5},
+	}, nil)
+	// 照合後に在庫移動で更新された在庫行と、削除された在庫行
+	mockLedgerRepo.On("RestoreQuantity", ctx, int64(2), 3).
+		Return(&models.VersionConflictError{Resource: "在庫", ID: 2, ExpectedVersion: 3, CurrentVersion: 4})
+	mockLedgerRepo.On("RestoreQuantity", ctx, int64(3), 1).Return(repository.ErrNotFound)
+	mockLedgerRepo.On("RestoreQuantity", ctx, int64(4), 2).Return(nil)
+
+	report, err := service.Reconcile(ctx, true, time.Now())
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Drifts, 3)
+	if assert.Len(t, report.Skipped, 2) {
+		assert.Equal(t, int64(2), report.Skipped[0].InventoryID)
+		assert.Equal(t, int64(3), report.Skipped[1].InventoryID)
+	}
+	mockLedgerRepo.AssertExpectations(t)
+}
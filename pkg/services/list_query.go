@@ -0,0 +1,23 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 一覧取得条件
+ * 一覧取得エラーの受け渡しを行う
+ */
+
+// wrapListError 一覧取得エラーにメッセージを付けて返す
+// 取得条件の誤りはハンドラで400に変換できるようそのまま返す
+func wrapListError(message string, err error) error {
+	var invalid *repository.InvalidQueryError
+	if errors.As(err, &invalid) {
+		return err
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * ロケーション管理サービス
+ * 倉庫内のゾーン・通路・ビンの管理とロケーション階層での在庫集計を実装する
+ */
+
+// LocationService ロケーション管理サービス
+type LocationService struct {
+	repo repository.LocationRepository
+}
+
+// NewLocationService ロケーション管理サービスを作成する
+func NewLocationService(repo repository.LocationRepository) *LocationService {
+	return &LocationService{repo: repo}
+}
+
+// CreateZone ゾーンを作成する
+func (s *LocationService) CreateZone(ctx context.Context, req *models.CreateZoneRequest) (*models.Zone, error) {
+	if !req.ZoneType.IsValid() {
+		return nil, fmt.Errorf("無効なゾーン種別です: %s", req.ZoneType)
+	}
+
+	zone := &models.Zone{
+		WarehouseID:       req.WarehouseID,
+		Code:              req.Code,
+		Name:              req.Name,
+		ZoneType:          req.ZoneType,
+		AllowedCategories: normalizeCategories(req.AllowedCategories),
+	}
+
+	if err := s.repo.CreateZone(ctx, zone); err != nil {
+		return nil, fmt.Errorf("ゾーン作成エラー: %v", err)
+	}
+
+	return zone, nil
+}
+
+// GetZone ゾーンを取得する
+func (s *LocationService) GetZone(ctx context.Context, id int64) (*models.Zone, error) {
+	zone, err := s.repo.GetZone(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン取得エラー: %v", err)
+	}
+
+	return zone, nil
+}
+
+// ListZones 倉庫のゾーン一覧を取得する
+func (s *LocationService) ListZones(ctx context.Context, warehouseID int64) ([]*models.Zone, error) {
+	zones, err := s.repo.ListZones(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン一覧取得エラー: %v", err)
+	}
+
+	return zones, nil
+}
+
+// UpdateZoneCategories ゾーンに保管できる商品カテゴリを更新する
+// 空の場合はすべてのカテゴリを保管できる
+func (s *LocationService) UpdateZoneCategories(ctx context.Context, id int64, req *models.UpdateZoneCategoriesRequest) (*models.Zone, error) {
+	zone, err := s.repo.GetZone(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("ゾーン取得エラー: %v", err)
+	}
+
+	categories := normalizeCategories(req.AllowedCategories)
+	if err := s.repo.SetZoneCategories(ctx, id, categories); err != nil {
+		return nil, fmt.Errorf("ゾーン保管カテゴリ更新エラー: %v", err)
+	}
+
+	zone.AllowedCategories = categories
+	return zone, nil
+}
+
+// CreateAisle 通路を作成する
+func (s *LocationService) CreateAisle(ctx context.Context, req *models.CreateAisleRequest) (*models.Aisle, error) {
+	aisle := &models.Aisle{
+		ZoneID: req.ZoneID,
+		Code:   req.Code,
+	}
+
+	if err := s.repo.CreateAisle(ctx, aisle); err != nil {
+		return nil, fmt.Errorf("通路作成エラー: %v", err)
+	}
+
+	return aisle, nil
+}
+
+// ListAisles ゾーンの通路一覧を取得する
+func (s *LocationService) ListAisles(ctx context.Context, zoneID int64) ([]*models.Aisle, error) {
+	aisles, err := s.repo.ListAisles(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("通路一覧取得エラー: %v", err)
+	}
+
+	return aisles, nil
+}
+
+// CreateBin ビンを作成する
+func (s *LocationService) CreateBin(ctx context.Context, req *models.CreateBinRequest) (*models.Bin, error) {
+	bin := &models.Bin{
+		AisleID: req.AisleID,
+		Code:    req.Code,
+	}
+
+	if err := s.repo.CreateBin(ctx, bin); err != nil {
+		return nil, fmt.Errorf("ビン作成エラー: %v", err)
+	}
+
+	return bin, nil
+}
+
+// ListBins 通路のビン一覧を取得する
+func (s *LocationService) ListBins(ctx context.Context, aisleID int64) ([]*models.Bin, error) {
+	bins, err := s.repo.ListBins(ctx, aisleID)
+	if err != nil {
+		return nil, fmt.Errorf("ビン一覧取得エラー: %v", err)
+	}
+
+	return bins, nil
+}
+
+// GetBinLocation ビンの階層上の位置を取得する
+func (s *LocationService) GetBinLocation(ctx context.Context, binID int64) (*models.BinLocation, error) {
+	location, err := s.repo.GetBinLocation(ctx, binID)
+	if err != nil {
+		return nil, fmt.Errorf("ビン取得エラー: %v", err)
+	}
+
+	return location, nil
+}
+
+// GetLocationStock ロケーション階層で在庫を集計する
+func (s *LocationService) GetLocationStock(ctx context.Context, query *models.LocationStockQuery) ([]*models.LocationStock, error) {
+	if query.ZoneType != "" && !query.ZoneType.IsValid() {
+		return nil, fmt.Errorf("無効なゾーン種別です: %s", query.ZoneType)
+	}
+
+	stocks, err := s.repo.ListLocationStock(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ロケーション在庫集計エラー: %v", err)
+	}
+
+	return stocks, nil
+}
+
+// normalizeCategories 商品カテゴリの前後の空白を除き、空文字と重複を取り除く
+func normalizeCategories(categories []string) []string {
+	seen := make(map[string]bool, len(categories))
+	normalized := make([]string, 0, len(categories))
+	for _, category := range categories {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		normalized = append(normalized, category)
+	}
+	return normalized
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * ロケーション管理サービステスト
+ */
+
+func newTestInventoryServiceWithLocations(
+	mockRepo *MockInventoryRepository,
+	mockWarehouseRepo *mocks.MockWarehouseRepository,
+	mockLocationRepo *mocks.MockLocationRepository,
+) *InventoryService {
+	mockReservationRepo := newDefaultReservationRepo()
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:    mockRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Locations:    mockLocationRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+	return NewInventoryService(mockRepo, mockReservationRepo, uow)
+}
+
+func TestCreateMovement_ZoneStorageRuleViolation(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	service := newTestInventoryServiceWithLocations(mockRepo, mockWarehouseRepo, mockLocationRepo)
+
+	ctx := context.Background()
+	binID := int64(5)
+	fromInventory := &models.Inventory{ID: 1, ProductID: 1, Quantity: 100, Location: "静岡倉庫", Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "静岡倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	mockLocationRepo.On("GetBinLocation", ctx, binID).Return(&models.BinLocation{
+		BinID: binID, BinCode: "01", AisleID: 3, AisleCode: "A", ZoneID: 2, ZoneCode: "C1",
+		ZoneType: models.ZoneTypeChilled, WarehouseID: 1, WarehouseName: "静岡倉庫",
+	}, nil)
+	mockLocationRepo.On("GetZone", ctx, int64(2)).Return(&models.Zone{
+		ID: 2, WarehouseID: 1, Code: "C1", ZoneType: models.ZoneTypeChilled, AllowedCategories: []string{"抹茶"},
+	}, nil)
+	mockLocationRepo.On("GetProductCategory", ctx, int64(1)).Return("ほうじ茶", nil)
+
+	movement, err := service.CreateMovement(ctx, &models.CreateMovementRequest{
+		ProductID:    1,
+		FromLocation: "静岡倉庫",
+		ToLocation:   "静岡倉庫",
+		ToBinID:      &binID,
+		Quantity:     10,
+		MovementType: models.MovementTypeTransfer,
+		MovementDate: time.Now(),
+	})
+
+	var ruleErr *models.ZoneStorageRuleError
+	assert.ErrorAs(t, err, &ruleErr)
+	assert.Equal(t, "ほうじ茶", ruleErr.Category)
+	assert.Nil(t, movement)
+	mockRepo.AssertNotCalled(t, "UpdateQuantity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+}
+
+func TestCreateMovement_IntoBin(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	service := newTestInventoryServiceWithLocations(mockRepo, mockWarehouseRepo, mockLocationRepo)
+
+	ctx := context.Background()
+	binID := int64(5)
+	// ビン未割当の在庫行から優先して払い出し、不足分をビンの在庫行から払い出す
+	unbinned := &models.Inventory{ID: 1, ProductID: 1, Quantity: 30, Location: "静岡倉庫", Status: models.InventoryStatusAvailable}
+	otherBinID := int64(9)
+	binned := &models.Inventory{ID: 2, ProductID: 1, Quantity: 50, Location: "静岡倉庫", BinID: &otherBinID, Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "静岡倉庫").Return([]*models.Inventory{binned, unbinned}, nil)
+	mockLocationRepo.On("GetBinLocation", ctx, binID).Return(&models.BinLocation{
+		BinID: binID, ZoneID: 2, ZoneCode: "C1", WarehouseID: 1, WarehouseName: "静岡倉庫",
+	}, nil)
+	mockLocationRepo.On("GetZone", ctx, int64(2)).Return(&models.Zone{ID: 2, Code: "C1", AllowedCategories: []string{"抹茶"}}, nil)
+	mockLocationRepo.On("GetProductCategory", ctx, int64(1)).Return("抹茶", nil)
+	mockRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 12
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 12)
+	mockRepo.On("UpdateQuantity", movementCtx, int64(1), 0, 0).Return(nil)
+	mockRepo.On("UpdateQuantity", movementCtx, int64(2), 10, 0).Return(nil)
+	mockRepo.On("GetInventoryByStockKey", movementCtx, int64(1), "静岡倉庫", &binID, (*int64)(nil), false).Return(nil, repository.ErrNotFound)
+	mockRepo.On("CreateInventory", movementCtx, mock.MatchedBy(func(inv *models.Inventory) bool {
+		return inv.BinID != nil && *inv.BinID == binID && inv.Quantity == 70
+	})).Return(nil)
+
+	movement, err := service.CreateMovement(ctx, &models.CreateMovementRequest{
+		ProductID:    1,
+		FromLocation: "静岡倉庫",
+		ToLocation:   "静岡倉庫",
+		ToBinID:      &binID,
+		Quantity:     70,
+		MovementType: models.MovementTypeTransfer,
+		MovementDate: time.Now(),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &binID, movement.ToBinID)
+	mockRepo.AssertExpectations(t)
+	mockLocationRepo.AssertExpectations(t)
+}
+
+func TestGetLocationStock(t *testing.T) {
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	service := NewLocationService(mockLocationRepo)
+
+	ctx := context.Background()
+	zoneID := int64(2)
+	query := &models.LocationStockQuery{WarehouseID: 1, ZoneType: models.ZoneTypeChilled, Category: "抹茶"}
+	mockLocationRepo.On("ListLocationStock", ctx, query).Return([]*models.LocationStock{
+		{WarehouseID: 1, WarehouseName: "静岡倉庫", ZoneID: &zoneID, ZoneCode: "C1", ZoneType: models.ZoneTypeChilled, ProductID: 1, Category: "抹茶", Quantity: 120},
+	}, nil)
+
+	stocks, err := service.GetLocationStock(ctx, query)
+
+	assert.NoError(t, err)
+	assert.Len(t, stocks, 1)
+	assert.Equal(t, 120, stocks[0].Quantity)
+
+	// 未定義のゾーン種別は集計しない
+	_, err = service.GetLocationStock(ctx, &models.LocationStockQuery{ZoneType: "frozen"})
+	assert.Error(t, err)
+	mockLocationRepo.AssertNumberOfCalls(t, "ListLocationStock", 1)
+}
+
+func TestUpdateZoneCategories(t *testing.T) {
+	mockLocationRepo := new(mocks.MockLocationRepository)
+	service := NewLocationService(mockLocationRepo)
+
+	ctx := context.Background()
+	mockLocationRepo.On("GetZone", ctx, int64(2)).Return(&models.Zone{ID: 2, Code: "C1", ZoneType: models.ZoneTypeChilled}, nil)
+	mockLocationRepo.On("SetZoneCategories", ctx, int64(2), []string{"抹茶", "玉露"}).Return(nil)
+
+	zone, err := service.UpdateZoneCategories(ctx, 2, &models.UpdateZoneCategoriesRequest{
+		AllowedCategories: []string{" 抹茶", "玉露", "", "抹茶"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"抹茶", "玉露"}, zone.AllowedCategories)
+	mockLocationRepo.AssertExpectations(t)
+}
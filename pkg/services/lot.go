@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * ロット管理サービス
+ * 収穫ロットの登録とロット単位のトレーサビリティを実装する
+ */
+
+// ErrLotNotFound ロットが見つからない場合のエラー
+var ErrLotNotFound = errors.New("ロットが見つかりません")
+
+// LotService ロット管理サービス
+type LotService struct {
+	repo repository.LotRepository
+}
+
+// NewLotService ロット管理サービスを作成する
+func NewLotService(repo repository.LotRepository) *LotService {
+	return &LotService{repo: repo}
+}
+
+// CreateLot ロットを作成する
+func (s *LotService) CreateLot(ctx context.Context, req *models.CreateLotRequest) (*models.Lot, error) {
+	if !req.HarvestFlush.IsValid() {
+		return nil, fmt.Errorf("無効な摘採時期です: %s", req.HarvestFlush)
+	}
+	if !req.BestBeforeDate.After(req.HarvestDate) {
+		return nil, fmt.Errorf("賞味期限は収穫日より後の日付を指定してください")
+	}
+
+	lot := &models.Lot{
+		LotNumber:      req.LotNumber,
+		ProductID:      req.ProductID,
+		HarvestFlush:   req.HarvestFlush,
+		HarvestDate:    req.HarvestDate,
+		OriginEstate:   req.OriginEstate,
+		Grade:          req.Grade,
+		BestBeforeDate: req.BestBeforeDate,
+	}
+
+	if err := s.repo.CreateLot(ctx, lot); err != nil {
+		return nil, fmt.Errorf("ロット作成エラー: %v", err)
+	}
+
+	return lot, nil
+}
+
+// GetLotByNumber ロット番号からロットを取得する
+func (s *LotService) GetLotByNumber(ctx context.Context, lotNumber string) (*models.Lot, error) {
+	lot, err := s.repo.GetLotByNumber(ctx, lotNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrLotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロット取得エラー: %v", err)
+	}
+
+	return lot, nil
+}
+
+// ListLots ロット一覧を取得する
+// productIDが0の場合はすべての商品のロットを取得する
+func (s *LotService) ListLots(ctx context.Context, productID int64) ([]*models.Lot, error) {
+	lots, err := s.repo.ListLots(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット一覧取得エラー: %v", err)
+	}
+
+	return lots, nil
+}
+
+// TraceLot ロットのトレーサビリティを取得する
+// 遡及として産地・移動履歴を、追跡として現在の保管場所と出荷先の配送を返す
+func (s *LotService) TraceLot(ctx context.Context, lotNumber string) (*models.LotTrace, error) {
+	lot, err := s.GetLotByNumber(ctx, lotNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.repo.ListLotMovements(ctx, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット移動履歴取得エラー: %v", err)
+	}
+
+	stock, err := s.repo.ListLotStock(ctx, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット在庫取得エラー: %v", err)
+	}
+
+	deliveries, err := s.repo.ListLotDeliveries(ctx, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ロット出荷先取得エラー: %v", err)
+	}
+
+	// JSONで空配列として返す
+	if movements == nil {
+		movements = []*models.InventoryMovement{}
+	}
+	if stock == nil {
+		stock = []*models.Inventory{}
+	}
+	if deliveries == nil {
+		deliveries = []*models.LotDelivery{}
+	}
+
+	return &models.LotTrace{
+		Lot:        lot,
+		Movements:  movements,
+		Stock:      stock,
+		Deliveries: deliveries,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * ロット管理サービステスト
+ */
+
+func TestTraceLot(t *testing.T) {
+	mockLotRepo := new(mocks.MockLotRepository)
+	service := NewLotService(mockLotRepo)
+
+	ctx := context.Background()
+	lotID := int64(3)
+	lot := &models.Lot{ID: lotID, LotNumber: "SZ-2026-01", ProductID: 1, HarvestFlush: models.HarvestFlushFirst, OriginEstate: "牧之原茶園"}
+	movements := []*models.InventoryMovement{
+		{ID: 1, ProductID: 1, FromLocation: "牧之原茶園", ToLocation: "静岡倉庫", LotID: &lotID, Quantity: 100, MovementType: models.MovementTypeInbound},
+	}
+	stock := []*models.Inventory{
+		{ID: 10, ProductID: 1, Quantity: 60, Location: "静岡倉庫", LotID: &lotID, Status: models.InventoryStatusAvailable},
+	}
+	deliveries := []*models.LotDelivery{
+		{DeliveryID: 5, DeliveryItemID: 8, OrderID: 2, Status: models.DeliveryStatusDelivered, ToAddress: "東京都渋谷区", Quantity: 40},
+	}
+
+	mockLotRepo.On("GetLotByNumber", ctx, "SZ-2026-01").Return(lot, nil)
+	mockLotRepo.On("ListLotMovements", ctx, lotID).Return(movements, nil)
+	mockLotRepo.On("ListLotStock", ctx, lotID).Return(stock, nil)
+	mockLotRepo.On("ListLotDeliveries", ctx, lotID).Return(deliveries, nil)
+
+	trace, err := service.TraceLot(ctx, "SZ-2026-01")
+
+	assert.NoError(t, err)
+	assert.Equal(t, lot, trace.Lot)
+	assert.Len(t, trace.Movements, 1)
+	assert.Equal(t, 60, trace.Stock[0].Quantity)
+	assert.Equal(t, int64(5), trace.Deliveries[0].DeliveryID)
+	mockLotRepo.AssertExpectations(t)
+}
+
+func TestTraceLot_NotFound(t *testing.T) {
+	mockLotRepo := new(mocks.MockLotRepository)
+	service := NewLotService(mockLotRepo)
+
+	ctx := context.Background()
+	mockLotRepo.On("GetLotByNumber", ctx, "UNKNOWN").Return(nil, repository.ErrNotFound)
+
+	trace, err := service.TraceLot(ctx, "UNKNOWN")
+
+	assert.ErrorIs(t, err, ErrLotNotFound)
+	assert.Nil(t, trace)
+}
+
+func TestCreateDelivery_LotInsufficient(t *testing.T) {
+	mockRepo, mockInventoryRepo, mockNotifyService := setupTest()
+	mockReservationRepo := newDefaultReservationRepo()
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockLotRepo := new(mocks.MockLotRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		Lots:         mockLotRepo,
+		Allocations:  newDefaultAllocationRepo(),
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+	service := NewDeliveryService(mockRepo, mockInventoryRepo, uow, mockNotifyService, nil, nil)
+
+	ctx := context.Background()
+	lotID := int64(3)
+	lot := &models.Lot{ID: lotID, LotNumber: "SZ-2026-01", ProductID: 1, BestBeforeDate: time.Now().AddDate(1, 0, 0)}
+	// 倉庫全体では足りているが、指定ロットの在庫は20しかない
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 100, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+		{ID: 2, ProductID: 1, Quantity: 20, Location: "東京倉庫", LotID: &lotID, Status: models.InventoryStatusAvailable},
+	}
+
+	mockWarehouseRepo.On("GetWarehouse", ctx, int64(1)).Return(&models.Warehouse{ID: 1, Name: "東京倉庫"}, nil)
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories, nil)
+	mockLotRepo.On("GetLotByNumber", ctx, "SZ-2026-01").Return(lot, nil)
+	mockRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+	mockRepo.On("CreateDeliveryItem", ctx, mock.AnythingOfType("*models.DeliveryItem")).Return(nil)
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	mockReservationRepo.On("SumActiveReservedByLot", ctx, lotID, "東京倉庫").Return(0, nil)
+
+	delivery, err := service.CreateDelivery(ctx, &models.CreateDeliveryRequest{
+		OrderID:         1,
+		Items:           []models.CreateDeliveryItemRequest{{ProductID: 1, Quantity: 30, LotNumber: "SZ-2026-01"}},
+		FromWarehouseID: 1,
+		ToAddress:       "東京都渋谷区",
+		EstimatedTime:   time.Now().Add(24 * time.Hour),
+	})
+
+	var stockErr *models.InsufficientStockError
+	assert.ErrorAs(t, err, &stockErr)
+	assert.Equal(t, &lotID, stockErr.LotID)
+	assert.Nil(t, delivery)
+	mockReservationRepo.AssertNotCalled(t, "CreateReservation", mock.Anything, mock.Anything)
+}
+
+func TestCommitReservation_RecordsDeliveryItemLots(t *testing.T) {
+	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	mockLotRepo := new(mocks.MockLotRepository)
+	tx := &repository.TxRepositories{
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		Lots:         mockLotRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	}
+
+	ctx := context.Background()
+	lotID := int64(3)
+	itemID := int64(8)
+	binID := int64(5)
+	// 同じロットがビン未割当とビンに分かれて保管されている
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 10, Location: "東京倉庫", LotID: &lotID, Status: models.InventoryStatusAvailable},
+		{ID: 2, ProductID: 1, Quantity: 50, Location: "東京倉庫", LotID: &lotID, BinID: &binID, Status: models.InventoryStatusAvailable},
+	}
+	reservation := &models.Reservation{
+		ID: 1, ProductID: 1, Location: "東京倉庫", LotID: &lotID, Quantity: 25,
+		DeliveryItemID: &itemID, Status: models.ReservationStatusActive,
+	}
+
+	mockInventoryRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return(inventories, nil)
+	// 出庫の在庫移動を記録し、払い出しを在庫元帳上でこの移動に紐付ける
+	mockInventoryRepo.On("CreateMovement", ctx, mock.MatchedBy(func(m *models.InventoryMovement) bool {
+		return m.MovementType == models.MovementTypeOutbound && m.FromLocation == "東京倉庫" && m.Quantity == 25 && m.LotID == &lotID
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 20
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 20)
+	mockInventoryRepo.On("UpdateQuantity", movementCtx, int64(1), 0, 0).Return(nil)
+	mockInventoryRepo.On("UpdateQuantity", movementCtx, int64(2), 35, 0).Return(nil)
+	mockLotRepo.On("CreateDeliveryItemLot", ctx, &models.DeliveryItemLot{DeliveryItemID: itemID, LotID: lotID, Quantity: 25}).Return(nil).Once()
+	mockReservationRepo.On("UpdateReservationStatus", ctx, int64(1), models.ReservationStatusCommitted).Return(nil)
+
+	err := commitReservation(ctx, tx, reservation)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationStatusCommitted, reservation.Status)
+	mockInventoryRepo.AssertExpectations(t)
+	mockLotRepo.AssertExpectations(t)
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -28,12 +29,16 @@ func (m *MockNotificationService) GetNotification(ctx context.Context, id int64)
 	return args.Get(0).(*models.Notification), args.Error(1)
 }
 
-func (m *MockNotificationService) ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error) {
-	args := m.Called(ctx, userID)
+func (m *MockNotificationService) ListNotifications(ctx context.Context, userID int64, spec *repository.QuerySpec) ([]*models.Notification, *repository.PageInfo, error) {
+	args := m.Called(ctx, userID, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, page, args.Error(2)
 	}
-	return args.Get(0).([]*models.Notification), args.Error(1)
+	return args.Get(0).([]*models.Notification), page, args.Error(2)
 }
 
 func (m *MockNotificationService) MarkAsRead(ctx context.Context, id int64) error {
@@ -60,3 +65,23 @@ func (m *MockNotificationService) NotifyDeliveryTracking(ctx context.Context, tr
 	args := m.Called(ctx, tracking)
 	return args.Error(0)
 }
+
+func (m *MockNotificationService) NotifyDeliveryReturned(ctx context.Context, delivery *models.Delivery, result *models.DeliveryReturn) error {
+	args := m.Called(ctx, delivery, result)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) NotifyDeliveryDelayed(ctx context.Context, delivery *models.Delivery, eta *models.DeliveryETA) error {
+	args := m.Called(ctx, delivery, eta)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) NotifyStockExpiry(ctx context.Context, userID int64, alert *models.ExpiryAlert) error {
+	args := m.Called(ctx, userID, alert)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) NotifyReplenishment(ctx context.Context, userID int64, proposal *models.ReplenishmentProposal) error {
+	args := m.Called(ctx, userID, proposal)
+	return args.Error(0)
+}
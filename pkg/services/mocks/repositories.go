@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"tea-logistics/pkg/models"
 	"tea-logistics/pkg/repository"
@@ -35,12 +36,16 @@ func (m *MockDeliveryRepository) GetDelivery(ctx context.Context, id int64) (*mo
 	return args.Get(0).(*models.Delivery), args.Error(1)
 }
 
-func (m *MockDeliveryRepository) ListDeliveries(ctx context.Context) ([]*models.Delivery, error) {
-	args := m.Called(ctx)
+func (m *MockDeliveryRepository) ListDeliveries(ctx context.Context, spec *repository.QuerySpec) ([]*models.Delivery, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, page, args.Error(2)
 	}
-	return args.Get(0).([]*models.Delivery), args.Error(1)
+	return args.Get(0).([]*models.Delivery), page, args.Error(2)
 }
 
 func (m *MockDeliveryRepository) UpdateDelivery(ctx context.Context, delivery *models.Delivery) error {
@@ -53,6 +58,14 @@ func (m *MockDeliveryRepository) CreateDeliveryItem(ctx context.Context, item *m
 	return args.Error(0)
 }
 
+func (m *MockDeliveryRepository) GetDeliveryItem(ctx context.Context, id int64) (*models.DeliveryItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeliveryItem), args.Error(1)
+}
+
 func (m *MockDeliveryRepository) ListDeliveryItems(ctx context.Context, deliveryID int64) ([]*models.DeliveryItem, error) {
 	args := m.Called(ctx, deliveryID)
 	if args.Get(0) == nil {
@@ -61,6 +74,11 @@ func (m *MockDeliveryRepository) ListDeliveryItems(ctx context.Context, delivery
 	return args.Get(0).([]*models.DeliveryItem), args.Error(1)
 }
 
+func (m *MockDeliveryRepository) UpdateDeliveryItem(ctx context.Context, item *models.DeliveryItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
 func (m *MockDeliveryRepository) CreateDeliveryTracking(ctx context.Context, tracking *models.DeliveryTracking) error {
 	args := m.Called(ctx, tracking)
 	return args.Error(0)
@@ -74,6 +92,19 @@ func (m *MockDeliveryRepository) ListDeliveryTrackings(ctx context.Context, deli
 	return args.Get(0).([]*models.DeliveryTracking), args.Error(1)
 }
 
+func (m *MockDeliveryRepository) CreateStatusHistory(ctx context.Context, history *models.DeliveryStatusHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) ListStatusHistory(ctx context.Context, deliveryID int64) ([]*models.DeliveryStatusHistory, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DeliveryStatusHistory), args.Error(1)
+}
+
 // MockInventoryRepository モック在庫リポジトリ
 type MockInventoryRepository struct {
 	mock.Mock
@@ -95,12 +126,16 @@ func (m *MockInventoryRepository) GetInventory(ctx context.Context, id int64) (*
 	return args.Get(0).(*models.Inventory), args.Error(1)
 }
 
-func (m *MockInventoryRepository) ListInventories(ctx context.Context) ([]*models.Inventory, error) {
-	args := m.Called(ctx)
+func (m *MockInventoryRepository) ListInventories(ctx context.Context, spec *repository.QuerySpec) ([]*models.Inventory, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, page, args.Error(2)
 	}
-	return args.Get(0).([]*models.Inventory), args.Error(1)
+	return args.Get(0).([]*models.Inventory), page, args.Error(2)
 }
 
 func (m *MockInventoryRepository) UpdateInventory(ctx context.Context, inventory *models.Inventory) error {
@@ -121,8 +156,8 @@ func (m *MockInventoryRepository) GetInventoryByProduct(ctx context.Context, pro
 	return args.Get(0).(*models.Inventory), args.Error(1)
 }
 
-func (m *MockInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int) error {
-	args := m.Called(ctx, id, quantity)
+func (m *MockInventoryRepository) UpdateQuantity(ctx context.Context, id int64, quantity int, version int) error {
+	args := m.Called(ctx, id, quantity, version)
 	return args.Error(0)
 }
 
@@ -134,6 +169,22 @@ func (m *MockInventoryRepository) GetInventoryByLocation(ctx context.Context, lo
 	return args.Get(0).([]*models.Inventory), args.Error(1)
 }
 
+func (m *MockInventoryRepository) GetInventoryByProductLocation(ctx context.Context, productID int64, location string) ([]*models.Inventory, error) {
+	args := m.Called(ctx, productID, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Inventory), args.Error(1)
+}
+
+func (m *MockInventoryRepository) GetInventoryByStockKey(ctx context.Context, productID int64, location string, binID, lotID *int64, quarantined bool) (*models.Inventory, error) {
+	args := m.Called(ctx, productID, location, binID, lotID, quarantined)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Inventory), args.Error(1)
+}
+
 func (m *MockInventoryRepository) CreateMovement(ctx context.Context, movement *models.InventoryMovement) error {
 	args := m.Called(ctx, movement)
 	return args.Error(0)
@@ -146,3 +197,1038 @@ func (m *MockInventoryRepository) ListMovements(ctx context.Context, productID i
 	}
 	return args.Get(0).([]*models.InventoryMovement), args.Error(1)
 }
+
+// MockReservationRepository モック在庫引当リポジトリ
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockReservationRepository implements ReservationRepository interface
+var _ repository.ReservationRepository = (*MockReservationRepository)(nil)
+
+func (m *MockReservationRepository) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	args := m.Called(ctx, reservation)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) GetReservation(ctx context.Context, id int64) (*models.Reservation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) ListReservationsByDelivery(ctx context.Context, deliveryID int64) ([]*models.Reservation, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) UpdateReservationStatus(ctx context.Context, id int64, status models.ReservationStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) UpdateReservationExpiry(ctx context.Context, id int64, expiresAt *time.Time) error {
+	args := m.Called(ctx, id, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) ExpireReservation(ctx context.Context, id int64, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) UpdateReservationQuantity(ctx context.Context, id int64, quantity int) error {
+	args := m.Called(ctx, id, quantity)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) SumActiveReserved(ctx context.Context, productID int64, location string) (int, error) {
+	args := m.Called(ctx, productID, location)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) SumActiveReservedByLot(ctx context.Context, lotID int64, location string) (int, error) {
+	args := m.Called(ctx, lotID, location)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) ListExpiredReservations(ctx context.Context, now time.Time) ([]*models.Reservation, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) LockStock(ctx context.Context, productID int64, location string) error {
+	args := m.Called(ctx, productID, location)
+	return args.Error(0)
+}
+
+// MockWarehouseRepository モック倉庫リポジトリ
+type MockWarehouseRepository struct {
+	mock.Mock
+}
+
+// Ensure MockWarehouseRepository implements WarehouseRepository interface
+var _ repository.WarehouseRepository = (*MockWarehouseRepository)(nil)
+
+func (m *MockWarehouseRepository) GetWarehouse(ctx context.Context, id int64) (*models.Warehouse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockWarehouseRepository) GetWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) LockWarehouseByName(ctx context.Context, name string) (*models.Warehouse, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) ListWarehouses(ctx context.Context) ([]*models.Warehouse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockWarehouseRepository) DeleteWarehouse(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWarehouseRepository) GetStoredQuantity(ctx context.Context, id int64) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) ListStoredQuantities(ctx context.Context) (map[int64]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]int), args.Error(1)
+}
+
+// MockLocationRepository モックロケーション階層リポジトリ
+type MockLocationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockLocationRepository implements LocationRepository interface
+var _ repository.LocationRepository = (*MockLocationRepository)(nil)
+
+func (m *MockLocationRepository) CreateZone(ctx context.Context, zone *models.Zone) error {
+	args := m.Called(ctx, zone)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) GetZone(ctx context.Context, id int64) (*models.Zone, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Zone), args.Error(1)
+}
+
+func (m *MockLocationRepository) ListZones(ctx context.Context, warehouseID int64) ([]*models.Zone, error) {
+	args := m.Called(ctx, warehouseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Zone), args.Error(1)
+}
+
+func (m *MockLocationRepository) SetZoneCategories(ctx context.Context, zoneID int64, categories []string) error {
+	args := m.Called(ctx, zoneID, categories)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) CreateAisle(ctx context.Context, aisle *models.Aisle) error {
+	args := m.Called(ctx, aisle)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) ListAisles(ctx context.Context, zoneID int64) ([]*models.Aisle, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Aisle), args.Error(1)
+}
+
+func (m *MockLocationRepository) CreateBin(ctx context.Context, bin *models.Bin) error {
+	args := m.Called(ctx, bin)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) ListBins(ctx context.Context, aisleID int64) ([]*models.Bin, error) {
+	args := m.Called(ctx, aisleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Bin), args.Error(1)
+}
+
+func (m *MockLocationRepository) GetBinLocation(ctx context.Context, binID int64) (*models.BinLocation, error) {
+	args := m.Called(ctx, binID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BinLocation), args.Error(1)
+}
+
+func (m *MockLocationRepository) GetProductCategory(ctx context.Context, productID int64) (string, error) {
+	args := m.Called(ctx, productID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLocationRepository) ListLocationStock(ctx context.Context, query *models.LocationStockQuery) ([]*models.LocationStock, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LocationStock), args.Error(1)
+}
+
+// MockLotRepository モックロットリポジトリ
+type MockLotRepository struct {
+	mock.Mock
+}
+
+// Ensure MockLotRepository implements LotRepository interface
+var _ repository.LotRepository = (*MockLotRepository)(nil)
+
+func (m *MockLotRepository) CreateLot(ctx context.Context, lot *models.Lot) error {
+	args := m.Called(ctx, lot)
+	return args.Error(0)
+}
+
+func (m *MockLotRepository) GetLot(ctx context.Context, id int64) (*models.Lot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lot), args.Error(1)
+}
+
+func (m *MockLotRepository) GetLotByNumber(ctx context.Context, lotNumber string) (*models.Lot, error) {
+	args := m.Called(ctx, lotNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lot), args.Error(1)
+}
+
+func (m *MockLotRepository) ListLots(ctx context.Context, productID int64) ([]*models.Lot, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Lot), args.Error(1)
+}
+
+func (m *MockLotRepository) CreateDeliveryItemLot(ctx context.Context, itemLot *models.DeliveryItemLot) error {
+	args := m.Called(ctx, itemLot)
+	return args.Error(0)
+}
+
+func (m *MockLotRepository) ListLotStock(ctx context.Context, lotID int64) ([]*models.Inventory, error) {
+	args := m.Called(ctx, lotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Inventory), args.Error(1)
+}
+
+func (m *MockLotRepository) ListLotMovements(ctx context.Context, lotID int64) ([]*models.InventoryMovement, error) {
+	args := m.Called(ctx, lotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.InventoryMovement), args.Error(1)
+}
+
+func (m *MockLotRepository) ListLotDeliveries(ctx context.Context, lotID int64) ([]*models.LotDelivery, error) {
+	args := m.Called(ctx, lotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LotDelivery), args.Error(1)
+}
+
+// MockAllocationRepository モック在庫割当リポジトリ
+type MockAllocationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockAllocationRepository implements AllocationRepository interface
+var _ repository.AllocationRepository = (*MockAllocationRepository)(nil)
+
+func (m *MockAllocationRepository) GetPolicyForProduct(ctx context.Context, productID int64) (*models.AllocationPolicy, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AllocationPolicy), args.Error(1)
+}
+
+func (m *MockAllocationRepository) ListPolicies(ctx context.Context) ([]*models.AllocationPolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AllocationPolicy), args.Error(1)
+}
+
+func (m *MockAllocationRepository) SetPolicy(ctx context.Context, policy *models.AllocationPolicy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockAllocationRepository) DeletePolicy(ctx context.Context, category string) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockAllocationRepository) CreateDeliveryAllocation(ctx context.Context, allocation *models.DeliveryAllocation) error {
+	args := m.Called(ctx, allocation)
+	return args.Error(0)
+}
+
+func (m *MockAllocationRepository) ListDeliveryAllocations(ctx context.Context, deliveryID int64) ([]*models.DeliveryAllocation, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DeliveryAllocation), args.Error(1)
+}
+
+func (m *MockAllocationRepository) UpdateDeliveryAllocationQuantity(ctx context.Context, id int64, quantity int) error {
+	args := m.Called(ctx, id, quantity)
+	return args.Error(0)
+}
+
+func (m *MockAllocationRepository) DeleteDeliveryAllocation(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockExpiryRepository モック賞味期限監視リポジトリ
+type MockExpiryRepository struct {
+	mock.Mock
+}
+
+// Ensure MockExpiryRepository implements ExpiryRepository interface
+var _ repository.ExpiryRepository = (*MockExpiryRepository)(nil)
+
+func (m *MockExpiryRepository) ListExpiryPolicies(ctx context.Context) ([]*models.ExpiryAlertPolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ExpiryAlertPolicy), args.Error(1)
+}
+
+func (m *MockExpiryRepository) SetExpiryPolicy(ctx context.Context, policy *models.ExpiryAlertPolicy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockExpiryRepository) DeleteExpiryPolicy(ctx context.Context, category string) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockExpiryRepository) ListExpiringStock(ctx context.Context, before time.Time) ([]*models.ExpiringStock, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ExpiringStock), args.Error(1)
+}
+
+func (m *MockExpiryRepository) MarkExpired(ctx context.Context, inventoryID int64) error {
+	args := m.Called(ctx, inventoryID)
+	return args.Error(0)
+}
+
+func (m *MockExpiryRepository) RecordExpiryAlert(ctx context.Context, inventoryID int64, kind models.ExpiryAlertKind) (bool, error) {
+	args := m.Called(ctx, inventoryID, kind)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockExpiryRepository) ListAlertRecipientIDs(ctx context.Context) ([]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// MockReplenishmentRepository モック在庫補充リポジトリ
+type MockReplenishmentRepository struct {
+	mock.Mock
+}
+
+// Ensure MockReplenishmentRepository implements ReplenishmentRepository interface
+var _ repository.ReplenishmentRepository = (*MockReplenishmentRepository)(nil)
+
+func (m *MockReplenishmentRepository) ListReorderSettings(ctx context.Context) ([]*models.ReorderSetting, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ReorderSetting), args.Error(1)
+}
+
+func (m *MockReplenishmentRepository) GetReorderSetting(ctx context.Context, productID int64, location string) (*models.ReorderSetting, error) {
+	args := m.Called(ctx, productID, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReorderSetting), args.Error(1)
+}
+
+func (m *MockReplenishmentRepository) SetReorderSetting(ctx context.Context, setting *models.ReorderSetting) error {
+	args := m.Called(ctx, setting)
+	return args.Error(0)
+}
+
+func (m *MockReplenishmentRepository) DeleteReorderSetting(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockReplenishmentRepository) CreateProposal(ctx context.Context, proposal *models.ReplenishmentProposal) error {
+	args := m.Called(ctx, proposal)
+	return args.Error(0)
+}
+
+func (m *MockReplenishmentRepository) GetProposal(ctx context.Context, id int64) (*models.ReplenishmentProposal, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReplenishmentProposal), args.Error(1)
+}
+
+func (m *MockReplenishmentRepository) ListProposals(ctx context.Context, status models.ReplenishmentProposalStatus) ([]*models.ReplenishmentProposal, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ReplenishmentProposal), args.Error(1)
+}
+
+func (m *MockReplenishmentRepository) GetOpenProposal(ctx context.Context, productID int64, location string) (*models.ReplenishmentProposal, error) {
+	args := m.Called(ctx, productID, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReplenishmentProposal), args.Error(1)
+}
+
+func (m *MockReplenishmentRepository) UpdateProposalStatus(ctx context.Context, id int64, status models.ReplenishmentProposalStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockReplenishmentRepository) ListAlertRecipientIDs(ctx context.Context) ([]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// MockPurchaseOrderRepository モック発注リポジトリ
+type MockPurchaseOrderRepository struct {
+	mock.Mock
+}
+
+// Ensure MockPurchaseOrderRepository implements PurchaseOrderRepository interface
+var _ repository.PurchaseOrderRepository = (*MockPurchaseOrderRepository)(nil)
+
+func (m *MockPurchaseOrderRepository) CreateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetSupplier(ctx context.Context, id int64) (*models.Supplier, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Supplier), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ListSuppliers(ctx context.Context) ([]*models.Supplier, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Supplier), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) UpdateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) CreatePurchaseOrder(ctx context.Context, order *models.PurchaseOrder) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetPurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ListPurchaseOrders(ctx context.Context, status models.PurchaseOrderStatus, supplierID int64) ([]*models.PurchaseOrder, error) {
+	args := m.Called(ctx, status, supplierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) UpdatePurchaseOrderStatus(ctx context.Context, order *models.PurchaseOrder) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) CreatePurchaseOrderLine(ctx context.Context, line *models.PurchaseOrderLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) ListPurchaseOrderLines(ctx context.Context, orderID int64) ([]*models.PurchaseOrderLine, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PurchaseOrderLine), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) UpdateReceivedQuantity(ctx context.Context, line *models.PurchaseOrderLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) CreateReceipt(ctx context.Context, receipt *models.PurchaseOrderReceiptLine) error {
+	args := m.Called(ctx, receipt)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) ListReceipts(ctx context.Context, orderID int64) ([]*models.PurchaseOrderReceiptLine, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PurchaseOrderReceiptLine), args.Error(1)
+}
+
+// MockStockCountRepository モック棚卸リポジトリ
+type MockStockCountRepository struct {
+	mock.Mock
+}
+
+// Ensure MockStockCountRepository implements StockCountRepository interface
+var _ repository.StockCountRepository = (*MockStockCountRepository)(nil)
+
+func (m *MockStockCountRepository) CreateStockCount(ctx context.Context, count *models.StockCount) error {
+	args := m.Called(ctx, count)
+	return args.Error(0)
+}
+
+func (m *MockStockCountRepository) GetStockCount(ctx context.Context, id int64) (*models.StockCount, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StockCount), args.Error(1)
+}
+
+func (m *MockStockCountRepository) ListStockCounts(ctx context.Context, status models.StockCountStatus) ([]*models.StockCount, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.StockCount), args.Error(1)
+}
+
+func (m *MockStockCountRepository) GetActiveStockCount(ctx context.Context, location string) (*models.StockCount, error) {
+	args := m.Called(ctx, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StockCount), args.Error(1)
+}
+
+func (m *MockStockCountRepository) UpdateStockCount(ctx context.Context, count *models.StockCount) error {
+	args := m.Called(ctx, count)
+	return args.Error(0)
+}
+
+func (m *MockStockCountRepository) CreateStockCountLine(ctx context.Context, line *models.StockCountLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockStockCountRepository) ListStockCountLines(ctx context.Context, countID int64) ([]*models.StockCountLine, error) {
+	args := m.Called(ctx, countID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.StockCountLine), args.Error(1)
+}
+
+func (m *MockStockCountRepository) UpdateStockCountLine(ctx context.Context, line *models.StockCountLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+// MockValuationRepository モック在庫評価リポジトリ
+type MockValuationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockValuationRepository implements ValuationRepository interface
+var _ repository.ValuationRepository = (*MockValuationRepository)(nil)
+
+func (m *MockValuationRepository) ListStockLevels(ctx context.Context) ([]*models.StockLevel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.StockLevel), args.Error(1)
+}
+
+func (m *MockValuationRepository) ListStockLocations(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockValuationRepository) ListMovementsSince(ctx context.Context, since time.Time) ([]*models.InventoryMovement, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.InventoryMovement), args.Error(1)
+}
+
+func (m *MockValuationRepository) ListCostLayers(ctx context.Context, before time.Time) ([]*models.CostLayer, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CostLayer), args.Error(1)
+}
+
+func (m *MockValuationRepository) ListProductCosts(ctx context.Context, before time.Time) ([]*models.ProductCost, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductCost), args.Error(1)
+}
+
+func (m *MockValuationRepository) CreateSnapshot(ctx context.Context, snapshot *models.InventorySnapshot) error {
+	args := m.Called(ctx, snapshot)
+	return args.Error(0)
+}
+
+func (m *MockValuationRepository) CreateSnapshotLine(ctx context.Context, snapshotDate time.Time, level *models.StockLevel) error {
+	args := m.Called(ctx, snapshotDate, level)
+	return args.Error(0)
+}
+
+func (m *MockValuationRepository) GetSnapshot(ctx context.Context, snapshotDate time.Time) (*models.InventorySnapshot, error) {
+	args := m.Called(ctx, snapshotDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.InventorySnapshot), args.Error(1)
+}
+
+func (m *MockValuationRepository) ListSnapshotLines(ctx context.Context, snapshotDate time.Time) ([]*models.StockLevel, error) {
+	args := m.Called(ctx, snapshotDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.StockLevel), args.Error(1)
+}
+
+// MockLedgerRepository モック在庫元帳リポジトリ
+type MockLedgerRepository struct {
+	mock.Mock
+}
+
+var _ repository.LedgerRepository = (*MockLedgerRepository)(nil)
+
+func (m *MockLedgerRepository) ListLedgerEntries(ctx context.Context, inventoryID int64) ([]*models.LedgerEntry, error) {
+	args := m.Called(ctx, inventoryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LedgerEntry), args.Error(1)
+}
+
+func (m *MockLedgerRepository) ListLedgerBalances(ctx context.Context) ([]*models.LedgerBalance, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LedgerBalance), args.Error(1)
+}
+
+func (m *MockLedgerRepository) RestoreQuantity(ctx context.Context, inventoryID int64, version int) error {
+	args := m.Called(ctx, inventoryID, version)
+	return args.Error(0)
+}
+
+// MockProductVariantRepository モック荷姿リポジトリ
+type MockProductVariantRepository struct {
+	mock.Mock
+}
+
+// Ensure MockProductVariantRepository implements ProductVariantRepository interface
+var _ repository.ProductVariantRepository = (*MockProductVariantRepository)(nil)
+
+func (m *MockProductVariantRepository) CreateVariant(ctx context.Context, variant *models.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockProductVariantRepository) GetVariant(ctx context.Context, id int64) (*models.ProductVariant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) GetVariantByUnit(ctx context.Context, productID int64, unit string) (*models.ProductVariant, error) {
+	args := m.Called(ctx, productID, unit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) ListVariants(ctx context.Context, productID int64) ([]*models.ProductVariant, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) UpdateVariant(ctx context.Context, variant *models.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockProductVariantRepository) DeleteVariant(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockVehicleRepository モック車両リポジトリ
+type MockVehicleRepository struct {
+	mock.Mock
+}
+
+// Ensure MockVehicleRepository implements VehicleRepository interface
+var _ repository.VehicleRepository = (*MockVehicleRepository)(nil)
+
+func (m *MockVehicleRepository) CreateVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	args := m.Called(ctx, vehicle)
+	return args.Error(0)
+}
+
+func (m *MockVehicleRepository) GetVehicle(ctx context.Context, id int64) (*models.Vehicle, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Vehicle), args.Error(1)
+}
+
+func (m *MockVehicleRepository) LockVehicle(ctx context.Context, id int64) (*models.Vehicle, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Vehicle), args.Error(1)
+}
+
+func (m *MockVehicleRepository) ListVehicles(ctx context.Context, spec *repository.QuerySpec) ([]*models.Vehicle, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
+	if args.Get(0) == nil {
+		return nil, page, args.Error(2)
+	}
+	return args.Get(0).([]*models.Vehicle), page, args.Error(2)
+}
+
+func (m *MockVehicleRepository) UpdateVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	args := m.Called(ctx, vehicle)
+	return args.Error(0)
+}
+
+func (m *MockVehicleRepository) CreateMaintenance(ctx context.Context, maintenance *models.VehicleMaintenance) error {
+	args := m.Called(ctx, maintenance)
+	return args.Error(0)
+}
+
+func (m *MockVehicleRepository) ListMaintenance(ctx context.Context, vehicleID int64) ([]*models.VehicleMaintenance, error) {
+	args := m.Called(ctx, vehicleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.VehicleMaintenance), args.Error(1)
+}
+
+func (m *MockVehicleRepository) ListMaintenanceInPeriod(ctx context.Context, start, end time.Time) ([]*models.VehicleMaintenance, error) {
+	args := m.Called(ctx, start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.VehicleMaintenance), args.Error(1)
+}
+
+// MockScheduleRepository モック配送スケジュールリポジトリ
+type MockScheduleRepository struct {
+	mock.Mock
+}
+
+// Ensure MockScheduleRepository implements ScheduleRepository interface
+var _ repository.ScheduleRepository = (*MockScheduleRepository)(nil)
+
+func (m *MockScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.DeliverySchedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) GetSchedule(ctx context.Context, id int64) (*models.DeliverySchedule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeliverySchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) UpdateScheduleStatus(ctx context.Context, id int64, status models.ScheduleStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) CancelDeliverySchedules(ctx context.Context, deliveryID int64) ([]*models.DeliverySchedule, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DeliverySchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ListVehicleSchedules(ctx context.Context, vehicleID int64, from, to time.Time) ([]*models.VehicleScheduleEntry, error) {
+	args := m.Called(ctx, vehicleID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.VehicleScheduleEntry), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ListSchedulesInPeriod(ctx context.Context, start, end time.Time) ([]*models.DeliverySchedule, error) {
+	args := m.Called(ctx, start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DeliverySchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ListDriverSchedules(ctx context.Context, driverID int64, start, end time.Time) ([]*models.DeliverySchedule, error) {
+	args := m.Called(ctx, driverID, start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DeliverySchedule), args.Error(1)
+}
+
+// MockDriverRepository モック運転手リポジトリ
+type MockDriverRepository struct {
+	mock.Mock
+}
+
+// Ensure MockDriverRepository implements DriverRepository interface
+var _ repository.DriverRepository = (*MockDriverRepository)(nil)
+
+func (m *MockDriverRepository) CreateDriver(ctx context.Context, driver *models.Driver) error {
+	args := m.Called(ctx, driver)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) GetDriver(ctx context.Context, id int64) (*models.Driver, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) LockDriver(ctx context.Context, id int64) (*models.Driver, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) ListDrivers(ctx context.Context, spec *repository.QuerySpec) ([]*models.Driver, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
+	if args.Get(0) == nil {
+		return nil, page, args.Error(2)
+	}
+	return args.Get(0).([]*models.Driver), page, args.Error(2)
+}
+
+func (m *MockDriverRepository) UpdateDriver(ctx context.Context, driver *models.Driver) error {
+	args := m.Called(ctx, driver)
+	return args.Error(0)
+}
+
+// MockRouteRepository モック配送ルートリポジトリ
+type MockRouteRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRouteRepository implements RouteRepository interface
+var _ repository.RouteRepository = (*MockRouteRepository)(nil)
+
+func (m *MockRouteRepository) ReplaceVehicleRoute(ctx context.Context, vehicleID int64, date string, routes []*models.Route) error {
+	args := m.Called(ctx, vehicleID, date, routes)
+	return args.Error(0)
+}
+
+func (m *MockRouteRepository) ListVehicleRoute(ctx context.Context, vehicleID int64, date string) ([]*models.Route, error) {
+	args := m.Called(ctx, vehicleID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Route), args.Error(1)
+}
+
+func (m *MockRouteRepository) GetDeliveryRoute(ctx context.Context, deliveryID int64) (*models.Route, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Route), args.Error(1)
+}
+
+// MockETARepository モック到着予定日時リポジトリ
+type MockETARepository struct {
+	mock.Mock
+}
+
+// Ensure MockETARepository implements ETARepository interface
+var _ repository.ETARepository = (*MockETARepository)(nil)
+
+func (m *MockETARepository) ListActiveTrackings(ctx context.Context) ([]*models.ETATracking, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ETATracking), args.Error(1)
+}
+
+func (m *MockETARepository) GetDeliveryTracking(ctx context.Context, deliveryID int64) (*models.ETATracking, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ETATracking), args.Error(1)
+}
+
+func (m *MockETARepository) GetLatestPosition(ctx context.Context, trackingID string) (*models.TrackingEvent, error) {
+	args := m.Called(ctx, trackingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TrackingEvent), args.Error(1)
+}
+
+func (m *MockETARepository) UpdateEstimatedTime(ctx context.Context, trackingID string, estimated time.Time, notified *time.Time) error {
+	args := m.Called(ctx, trackingID, estimated, notified)
+	return args.Error(0)
+}
+
+func (m *MockETARepository) GetLegDurationStats(ctx context.Context, vehicleID int64, since time.Time) (*models.LegDurationStats, error) {
+	args := m.Called(ctx, vehicleID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LegDurationStats), args.Error(1)
+}
+
+// MockUnitOfWork モックユニットオブワーク
+// トランザクションを張らずに、保持しているリポジトリでそのまま処理を実行する
+type MockUnitOfWork struct {
+	Repos *repository.TxRepositories
+}
+
+// Ensure MockUnitOfWork implements UnitOfWork interface
+var _ repository.UnitOfWork = (*MockUnitOfWork)(nil)
+
+// NewMockUnitOfWork モックユニットオブワークを作成する
+func NewMockUnitOfWork(repos *repository.TxRepositories) *MockUnitOfWork {
+	return &MockUnitOfWork{Repos: repos}
+}
+
+func (m *MockUnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context, repos *repository.TxRepositories) error) error {
+	return fn(ctx, m.Repos)
+}
@@ -18,12 +18,16 @@ import (
 type NotificationService interface {
 	CreateNotification(ctx context.Context, req *models.CreateNotificationRequest) (*models.Notification, error)
 	GetNotification(ctx context.Context, id int64) (*models.Notification, error)
-	ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error)
+	ListNotifications(ctx context.Context, userID int64, spec *repository.QuerySpec) ([]*models.Notification, *repository.PageInfo, error)
 	MarkAsRead(ctx context.Context, id int64) error
 	DeleteNotification(ctx context.Context, id int64) error
 	NotifyDeliveryStatusChange(ctx context.Context, delivery *models.Delivery) error
 	NotifyDeliveryComplete(ctx context.Context, delivery *models.Delivery) error
 	NotifyDeliveryTracking(ctx context.Context, tracking *models.DeliveryTracking) error
+	NotifyDeliveryReturned(ctx context.Context, delivery *models.Delivery, result *models.DeliveryReturn) error
+	NotifyDeliveryDelayed(ctx context.Context, delivery *models.Delivery, eta *models.DeliveryETA) error
+	NotifyStockExpiry(ctx context.Context, userID int64, alert *models.ExpiryAlert) error
+	NotifyReplenishment(ctx context.Context, userID int64, proposal *models.ReplenishmentProposal) error
 }
 
 // NotificationServiceImpl 通知サービス実装
@@ -71,13 +75,13 @@ func (s *NotificationServiceImpl) GetNotification(ctx context.Context, id int64)
 }
 
 // ListNotifications ユーザーの通知一覧を取得する
-func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, userID int64) ([]*models.Notification, error) {
-	notifications, err := s.repo.ListNotifications(ctx, userID)
+func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, userID int64, spec *repository.QuerySpec) ([]*models.Notification, *repository.PageInfo, error) {
+	notifications, page, err := s.repo.ListNotifications(ctx, userID, spec)
 	if err != nil {
-		return nil, fmt.Errorf("通知一覧取得エラー: %v", err)
+		return nil, nil, wrapListError("通知一覧取得エラー", err)
 	}
 
-	return notifications, nil
+	return notifications, page, nil
 }
 
 // MarkAsRead 通知を既読にする
@@ -167,3 +171,124 @@ func (s *NotificationServiceImpl) NotifyDeliveryTracking(ctx context.Context, tr
 
 	return nil
 }
+
+// NotifyDeliveryReturned 返品の入庫を通知する
+func (s *NotificationServiceImpl) NotifyDeliveryReturned(ctx context.Context, delivery *models.Delivery, result *models.DeliveryReturn) error {
+	quantity := 0
+	for _, movement := range result.Movements {
+		quantity += movement.Quantity
+	}
+
+	req := &models.CreateNotificationRequest{
+		Type:    models.NotificationTypeDeliveryReturn,
+		Title:   "返品を受け付けました",
+		Message: fmt.Sprintf("配送ID: %d の商品 %d 点を「%s」に返品入庫しました", delivery.ID, quantity, result.Location),
+		Data: map[string]interface{}{
+			"delivery_id": delivery.ID,
+			"location":    result.Location,
+			"quantity":    quantity,
+		},
+		UserID: delivery.OrderID, // 注文IDをユーザーIDとして使用
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	if err != nil {
+		return fmt.Errorf("返品通知エラー: %v", err)
+	}
+
+	return nil
+}
+
+// NotifyDeliveryDelayed 到着予定日時の遅れを通知する
+func (s *NotificationServiceImpl) NotifyDeliveryDelayed(ctx context.Context, delivery *models.Delivery, eta *models.DeliveryETA) error {
+	req := &models.CreateNotificationRequest{
+		Type:    models.NotificationTypeDeliveryDelayed,
+		Title:   "到着予定時刻が遅れています",
+		Message: fmt.Sprintf("配送ID: %d の到着予定時刻は %s の見込みです（約%d分の遅れ）", delivery.ID, eta.EstimatedTime.Format("2006-01-02 15:04"), eta.Delay),
+		Data: map[string]interface{}{
+			"delivery_id":    delivery.ID,
+			"tracking_id":    eta.TrackingID,
+			"estimated_time": eta.EstimatedTime,
+			"baseline_time":  eta.BaselineTime,
+			"delay":          eta.Delay,
+		},
+		UserID: delivery.OrderID, // 注文IDをユーザーIDとして使用
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	if err != nil {
+		return fmt.Errorf("到着遅延通知エラー: %v", err)
+	}
+
+	return nil
+}
+
+// NotifyStockExpiry ロット在庫の賞味期限接近・賞味期限切れを通知する
+func (s *NotificationServiceImpl) NotifyStockExpiry(ctx context.Context, userID int64, alert *models.ExpiryAlert) error {
+	stock := alert.Stock
+	req := &models.CreateNotificationRequest{
+		Type:  models.NotificationTypeStockExpiring,
+		Title: "賞味期限が近づいています",
+		Message: fmt.Sprintf("「%s」のロット「%s」（%s・%d点）の賞味期限まで残り%d日です",
+			stock.ProductName, stock.LotNumber, stock.Location, stock.Quantity, alert.DaysRemaining),
+		Data: map[string]interface{}{
+			"inventory_id":     stock.InventoryID,
+			"product_id":       stock.ProductID,
+			"location":         stock.Location,
+			"lot_number":       stock.LotNumber,
+			"best_before_date": stock.BestBeforeDate.Format("2006-01-02"),
+			"quantity":         stock.Quantity,
+		},
+		UserID: userID,
+	}
+	if alert.Kind == models.ExpiryAlertExpired {
+		req.Type = models.NotificationTypeStockExpired
+		req.Title = "賞味期限切れの在庫を販売停止にしました"
+		req.Message = fmt.Sprintf("「%s」のロット「%s」（%s・%d点）は賞味期限を過ぎたため販売不可にしました",
+			stock.ProductName, stock.LotNumber, stock.Location, stock.Quantity)
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	if err != nil {
+		return fmt.Errorf("賞味期限通知エラー: %v", err)
+	}
+
+	return nil
+}
+
+// NotifyReplenishment 在庫補充提案を通知する
+func (s *NotificationServiceImpl) NotifyReplenishment(ctx context.Context, userID int64, proposal *models.ReplenishmentProposal) error {
+	title := "在庫が発注点を下回りました"
+	if proposal.BelowSafetyStock() {
+		title = "在庫が安全在庫を下回りました"
+	}
+
+	message := fmt.Sprintf("商品ID: %d の「%s」の引当可能数が %d になりました。%d 点の発注を提案します",
+		proposal.ProductID, proposal.Location, proposal.AvailableToPromise, proposal.Quantity)
+	if proposal.Type == models.ReplenishmentProposalTransfer {
+		message = fmt.Sprintf("商品ID: %d の「%s」の引当可能数が %d になりました。「%s」から %d 点の移動を提案します",
+			proposal.ProductID, proposal.Location, proposal.AvailableToPromise, proposal.SourceLocation, proposal.Quantity)
+	}
+
+	req := &models.CreateNotificationRequest{
+		Type:    models.NotificationTypeReplenishment,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"proposal_id":     proposal.ID,
+			"product_id":      proposal.ProductID,
+			"location":        proposal.Location,
+			"type":            proposal.Type,
+			"source_location": proposal.SourceLocation,
+			"quantity":        proposal.Quantity,
+		},
+		UserID: userID,
+	}
+
+	_, err := s.CreateNotification(ctx, req)
+	if err != nil {
+		return fmt.Errorf("在庫補充提案通知エラー: %v", err)
+	}
+
+	return nil
+}
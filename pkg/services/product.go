@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"tea-logistics/pkg/models"
@@ -52,21 +53,25 @@ func (s *ProductService) GetProduct(ctx context.Context, id int64) (*models.Prod
 }
 
 // ListProducts 商品一覧を取得する
-func (s *ProductService) ListProducts(ctx context.Context) ([]*models.Product, error) {
-	products, err := s.repo.ListProducts(ctx)
+func (s *ProductService) ListProducts(ctx context.Context, spec *repository.QuerySpec) ([]*models.Product, *repository.PageInfo, error) {
+	products, page, err := s.repo.ListProducts(ctx, spec)
 	if err != nil {
-		return nil, fmt.Errorf("商品一覧取得エラー: %v", err)
+		return nil, nil, wrapListError("商品一覧取得エラー", err)
 	}
 
-	return products, nil
+	return products, page, nil
 }
 
 // UpdateProduct 商品を更新する
+// req.ExpectedVersionを指定した場合は、商品のバージョンが一致しなければ*models.VersionConflictErrorを返す
 func (s *ProductService) UpdateProduct(ctx context.Context, id int64, req *models.UpdateProductRequest) (*models.Product, error) {
 	product, err := s.repo.GetProduct(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("商品取得エラー: %v", err)
 	}
+	if err := checkExpectedVersion("商品", product.ID, req.ExpectedVersion, product.Version); err != nil {
+		return nil, err
+	}
 
 	product.Name = req.Name
 	product.Description = req.Description
@@ -76,15 +81,21 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id int64, req *model
 	product.Status = req.Status
 
 	if err := s.repo.UpdateProduct(ctx, product); err != nil {
-		return nil, fmt.Errorf("商品更新エラー: %v", err)
+		return nil, wrapUpdateError("商品更新エラー", err)
 	}
 
 	return product, nil
 }
 
 // DeleteProduct 商品を削除する
+// 在庫・在庫元帳などから参照されている場合は*models.ProductInUseErrorを返す
 func (s *ProductService) DeleteProduct(ctx context.Context, id int64) error {
-	if err := s.repo.DeleteProduct(ctx, id); err != nil {
+	err := s.repo.DeleteProduct(ctx, id)
+	var inUseErr *models.ProductInUseError
+	if errors.As(err, &inUseErr) {
+		return err
+	}
+	if err != nil {
 		return fmt.Errorf("商品削除エラー: %v", err)
 	}
 
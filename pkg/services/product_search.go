@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+/*
+ * 商品検索サービス
+ * 検索語の正規化と、検索結果の一致箇所のハイライトを実装する
+ */
+
+const (
+	// maxSearchTerms 検索語の最大数
+	maxSearchTerms = 10
+	// snippetContext 説明の抜粋で一致箇所の前後に含める文字数
+	snippetContext = 30
+)
+
+// SearchProducts 商品を検索し、一致箇所をハイライトした結果を関連度の高い順に返す
+// 検索語は空白（全角を含む）で区切り、すべての語を含む商品を返す
+func (s *ProductService) SearchProducts(ctx context.Context, query string, spec *repository.QuerySpec) (*models.ProductSearchResult, *repository.PageInfo, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil, &repository.InvalidQueryError{Field: "q", Message: "検索語を入力してください"}
+	}
+
+	result, page, err := s.repo.SearchProducts(ctx, terms, spec)
+	if err != nil {
+		return nil, nil, wrapListError("商品検索エラー", err)
+	}
+
+	for _, hit := range result.Hits {
+		hit.Highlights = productHighlights(hit.Product, terms)
+	}
+
+	return result, page, nil
+}
+
+// searchTerms 検索文字列を検索語に分割する
+// 全角英数字・半角カナをNFKCで正規化し、重複する語を除く
+func searchTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(norm.NFKC.String(query), unicode.IsSpace) {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// productHighlights 商品の項目のうち検索語に一致したものをハイライトして返す
+func productHighlights(product *models.Product, terms []string) map[string]string {
+	highlights := make(map[string]string)
+	for field, value := range map[string]string{
+		"name":     product.Name,
+		"sku":      product.SKU,
+		"category": product.Category,
+	} {
+		if text, ok := highlight(value, terms, 0); ok {
+			highlights[field] = text
+		}
+	}
+	if text, ok := highlight(product.Description, terms, snippetContext); ok {
+		highlights["description"] = text
+	}
+	return highlights
+}
+
+// highlight 文字列中の検索語に一致する箇所を<mark>で囲み、HTMLエスケープして返す
+// aroundが0より大きい場合は、最初の一致箇所の前後around文字だけを抜粋する
+// 検索と同じくNFKC正規化・小文字化した文字列で照合し、一致箇所は元の文字列の文字で囲む
+// 一致箇所がない場合はfalseを返す
+func highlight(value string, terms []string, around int) (string, bool) {
+	text := []rune(value)
+	normalized, from, to := searchRunes(value)
+
+	// 元の文字列の各文字が検索語の一致箇所に含まれるかどうか
+	marked := make([]bool, len(text))
+	first, firstEnd := -1, -1
+	for _, term := range terms {
+		pattern, _, _ := searchRunes(term)
+		if len(pattern) == 0 {
+			continue
+		}
+		for i := 0; i+len(pattern) <= len(normalized); i++ {
+			if string(normalized[i:i+len(pattern)]) != string(pattern) {
+				continue
+			}
+			start, end := from[i], to[i+len(pattern)-1]
+			for j := start; j < end; j++ {
+				marked[j] = true
+			}
+			if first < 0 || start < first {
+				first, firstEnd = start, end
+			}
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if around > 0 {
+		if first-around > start {
+			start = first - around
+		}
+		if firstEnd+around < end {
+			end = firstEnd + around
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(text[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + segment + "</mark>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// searchRunes 文字列をNFKC正規化・小文字化した文字と、各文字の元になった元の文字列の文字の範囲[from, to)を返す
+// 半角カナと濁点のように複数の文字が1文字に、合字のように1文字が複数の文字に正規化されるため、正規化の単位ごとに対応付ける
+func searchRunes(value string) (runes []rune, from, to []int) {
+	pos := 0
+	for rest := value; rest != ""; {
+		n := norm.NFKC.NextBoundaryInString(rest, true)
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(rest)
+		}
+		segment := rest[:n]
+		width := utf8.RuneCountInString(segment)
+		for _, r := range norm.NFKC.String(segment) {
+			runes = append(runes, unicode.ToLower(r))
+			from = append(from, pos)
+			to = append(to, pos+width)
+		}
+		pos += width
+		rest = rest[n:]
+	}
+	return runes, from, to
+}
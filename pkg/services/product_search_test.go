@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 商品検索サービステスト
+ */
+
+func TestSearchProducts_NormalizesTermsAndHighlights(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	ctx := context.Background()
+	product := &models.Product{
+		ID:          1,
+		Name:        "宇治抹茶ラテ",
+		SKU:         "MAT-001",
+		Category:    "抹茶",
+		Description: "京都府南部の宇治地域で覆いをかけて栽培した一番茶の碾茶を、石臼でゆっくり挽いた<抹茶>を使用しています。牛乳に溶かすだけで本格的な味わいをご家庭で楽しめます。",
+	}
+	// 全角スペース・全角英数字・半角カナは正規化し、重複する語は除く
+	mockRepo.On("SearchProducts", ctx, []string{"抹茶", "MAT", "ラテ"}, (*repository.QuerySpec)(nil)).
+		Return(&models.ProductSearchResult{
+			Terms: []string{"抹茶", "MAT", "ラテ"},
+			Hits:  []*models.ProductSearchHit{{Product: product, Score: 12.5}},
+		}, &repository.PageInfo{}, nil)
+
+	result, _, err := service.SearchProducts(ctx, "抹茶　ＭＡＴ ﾗﾃ 抹茶", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"name":        "宇治<mark>抹茶ラテ</mark>",
+		"sku":         "<mark>MAT</mark>-001",
+		"category":    "<mark>抹茶</mark>",
+		"description": "…で覆いをかけて栽培した一番茶の碾茶を、石臼でゆっくり挽いた&lt;<mark>抹茶</mark>&gt;を使用しています。牛乳に溶かすだけで本格的な味わいをご家庭…",
+	}, result.Hits[0].Highlights)
+}
+
+func TestSearchProducts_HighlightsOriginalTextOfNormalizedMatches(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	ctx := context.Background()
+	// 登録値が半角カナ・全角英数字のままでも、正規化した検索語に一致した元の文字をハイライトする
+	product := &models.Product{
+		ID:       2,
+		Name:     "ｸﾞﾘｰﾝﾃｨｰ<ﾎｯﾄ>",
+		SKU:      "ＧＲＮ－００２",
+		Category: "緑茶",
+	}
+	mockRepo.On("SearchProducts", ctx, []string{"グリーン", "grn-002"}, (*repository.QuerySpec)(nil)).
+		Return(&models.ProductSearchResult{
+			Terms: []string{"グリーン", "grn-002"},
+			Hits:  []*models.ProductSearchHit{{Product: product, Score: 7}},
+		}, &repository.PageInfo{}, nil)
+
+	result, _, err := service.SearchProducts(ctx, "グリーン grn-002", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"name": "<mark>ｸﾞﾘｰﾝ</mark>ﾃｨｰ&lt;ﾎｯﾄ&gt;",
+		"sku":  "<mark>ＧＲＮ－００２</mark>",
+	}, result.Hits[0].Highlights)
+}
+
+func TestSearchProducts_RequiresQuery(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	_, _, err := service.SearchProducts(context.Background(), " 　", nil)
+
+	var invalid *repository.InvalidQueryError
+	assert.ErrorAs(t, err, &invalid)
+	mockRepo.AssertNotCalled(t, "SearchProducts", mock.Anything, mock.Anything, mock.Anything)
+}
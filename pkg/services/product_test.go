@@ -38,12 +38,28 @@ func (m *MockProductRepository) GetProduct(ctx context.Context, id int64) (*mode
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) ListProducts(ctx context.Context) ([]*models.Product, error) {
-	args := m.Called(ctx)
+func (m *MockProductRepository) ListProducts(ctx context.Context, spec *repository.QuerySpec) ([]*models.Product, *repository.PageInfo, error) {
+	args := m.Called(ctx, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return nil, page, args.Error(2)
 	}
-	return args.Get(0).([]*models.Product), args.Error(1)
+	return args.Get(0).([]*models.Product), page, args.Error(2)
+}
+
+func (m *MockProductRepository) SearchProducts(ctx context.Context, terms []string, spec *repository.QuerySpec) (*models.ProductSearchResult, *repository.PageInfo, error) {
+	args := m.Called(ctx, terms, spec)
+	var page *repository.PageInfo
+	if args.Get(1) != nil {
+		page = args.Get(1).(*repository.PageInfo)
+	}
+	if args.Get(0) == nil {
+		return nil, page, args.Error(2)
+	}
+	return args.Get(0).(*models.ProductSearchResult), page, args.Error(2)
 }
 
 func (m *MockProductRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
@@ -150,11 +166,18 @@ func TestListProducts(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("ListProducts", ctx).Return(expectedProducts, nil)
+	spec := &repository.QuerySpec{
+		Filters: []repository.Filter{{Field: "category", Operator: repository.FilterEqual, Value: "テストカテゴリ"}},
+		Limit:   2,
+	}
+	total := int64(5)
+	expectedPage := &repository.PageInfo{Limit: 2, Total: &total, HasMore: true, NextCursor: "Mg"}
+	mockRepo.On("ListProducts", ctx, spec).Return(expectedProducts, expectedPage, nil)
 
-	products, err := service.ListProducts(ctx)
+	products, page, err := service.ListProducts(ctx, spec)
 
 	assert.NoError(t, err)
+	assert.Equal(t, expectedPage, page)
 	assert.NotNil(t, products)
 	assert.Len(t, products, 2)
 	assert.Equal(t, expectedProducts[0].ID, products[0].ID)
@@ -219,3 +242,19 @@ func TestDeleteProduct(t *testing.T) {
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestDeleteProduct_InUse(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	ctx := context.Background()
+
+	// 在庫元帳に記帳のある商品は削除できない
+	mockRepo.On("DeleteProduct", ctx, int64(1)).Return(&models.ProductInUseError{ProductID: 1})
+
+	err := service.DeleteProduct(ctx, 1)
+
+	var inUseErr *models.ProductInUseError
+	assert.ErrorAs(t, err, &inUseErr)
+	assert.Equal(t, int64(1), inUseErr.ProductID)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 荷姿サービス
+ * 商品の荷姿（包装単位）の管理と、荷姿・基本単位（グラム）間の数量換算を実装する
+ */
+
+// ErrVariantNotFound 荷姿が見つからない場合のエラー
+var ErrVariantNotFound = errors.New("荷姿が見つかりません")
+
+// ProductVariantService 荷姿サービス
+type ProductVariantService struct {
+	repo        repository.ProductVariantRepository
+	productRepo repository.ProductRepository
+}
+
+// NewProductVariantService 荷姿サービスを作成する
+func NewProductVariantService(repo repository.ProductVariantRepository, productRepo repository.ProductRepository) *ProductVariantService {
+	return &ProductVariantService{repo: repo, productRepo: productRepo}
+}
+
+// CreateVariant 商品の荷姿を作成する
+func (s *ProductVariantService) CreateVariant(ctx context.Context, productID int64, req *models.ProductVariantRequest) (*models.ProductVariant, error) {
+	if err := validateVariantRequest(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.productRepo.GetProduct(ctx, productID); err != nil {
+		return nil, fmt.Errorf("商品取得エラー: %v", err)
+	}
+
+	variant := &models.ProductVariant{ProductID: productID}
+	applyVariantRequest(variant, req)
+
+	if err := s.repo.CreateVariant(ctx, variant); err != nil {
+		return nil, fmt.Errorf("荷姿作成エラー: %v", err)
+	}
+
+	return variant, nil
+}
+
+// ListVariants 商品の荷姿一覧を取得する
+func (s *ProductVariantService) ListVariants(ctx context.Context, productID int64) ([]*models.ProductVariant, error) {
+	variants, err := s.repo.ListVariants(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("荷姿一覧取得エラー: %v", err)
+	}
+
+	return variants, nil
+}
+
+// UpdateVariant 商品の荷姿を更新する
+// 換算係数の変更は以後の換算にのみ適用し、記録済みの在庫移動・配送明細の数量は変更しない
+func (s *ProductVariantService) UpdateVariant(ctx context.Context, productID, id int64, req *models.ProductVariantRequest) (*models.ProductVariant, error) {
+	if err := validateVariantRequest(req); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.getVariant(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+	applyVariantRequest(variant, req)
+
+	if err := s.repo.UpdateVariant(ctx, variant); err != nil {
+		return nil, fmt.Errorf("荷姿更新エラー: %v", err)
+	}
+
+	return variant, nil
+}
+
+// DeleteVariant 商品の荷姿を削除する
+func (s *ProductVariantService) DeleteVariant(ctx context.Context, productID, id int64) error {
+	if _, err := s.getVariant(ctx, productID, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteVariant(ctx, id); err != nil {
+		return fmt.Errorf("荷姿削除エラー: %v", err)
+	}
+
+	return nil
+}
+
+// ConvertQuantity 商品の数量を荷姿・基本単位の間で換算する
+// 換算先の単位に満たない端数はRemainderに基本単位の数量で返す
+func (s *ProductVariantService) ConvertQuantity(ctx context.Context, productID int64, quantity int, from, to string) (*models.UnitConversion, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("数量は1以上を指定してください")
+	}
+
+	base, _, err := toBaseQuantity(ctx, s.repo, productID, from, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	conversion := &models.UnitConversion{
+		ProductID:    productID,
+		From:         unitOrBase(from),
+		To:           unitOrBase(to),
+		Quantity:     quantity,
+		BaseQuantity: base,
+		Result:       base,
+	}
+	if !models.IsBaseUnit(to) {
+		variant, err := findVariant(ctx, s.repo, productID, to)
+		if err != nil {
+			return nil, err
+		}
+		conversion.Result = base / variant.ConversionFactor
+		conversion.Remainder = base % variant.ConversionFactor
+	}
+
+	return conversion, nil
+}
+
+// getVariant 商品の荷姿を取得する（他の商品の荷姿は見つからないものとする）
+func (s *ProductVariantService) getVariant(ctx context.Context, productID, id int64) (*models.ProductVariant, error) {
+	variant, err := s.repo.GetVariant(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("荷姿取得エラー: %v", err)
+	}
+	if variant.ProductID != productID {
+		return nil, ErrVariantNotFound
+	}
+
+	return variant, nil
+}
+
+// validateVariantRequest 荷姿作成・更新リクエストを検証する
+func validateVariantRequest(req *models.ProductVariantRequest) error {
+	if models.IsBaseUnit(req.Unit) {
+		return fmt.Errorf("荷姿コード「%s」は基本単位のため使用できません", models.BaseUnit)
+	}
+	if req.ConversionFactor <= 0 {
+		return fmt.Errorf("換算係数は1以上を指定してください")
+	}
+	if req.Price < 0 || req.WeightGrams < 0 || req.VolumeML < 0 {
+		return fmt.Errorf("価格・重量・容積に負の値は指定できません")
+	}
+	return nil
+}
+
+// applyVariantRequest 荷姿作成・更新リクエストの内容を荷姿に設定する
+func applyVariantRequest(variant *models.ProductVariant, req *models.ProductVariantRequest) {
+	variant.Unit = req.Unit
+	variant.Name = req.Name
+	variant.SKU = req.SKU
+	variant.ConversionFactor = req.ConversionFactor
+	variant.Price = req.Price
+	variant.WeightGrams = req.WeightGrams
+	variant.VolumeML = req.VolumeML
+}
+
+// toBaseQuantity 荷姿の数量を基本単位の数量に換算する
+// 基本単位を指定した場合は数量をそのまま返し、荷姿はnilを返す
+func toBaseQuantity(ctx context.Context, variants repository.ProductVariantRepository, productID int64, unit string, quantity int) (int, *models.ProductVariant, error) {
+	if models.IsBaseUnit(unit) {
+		return quantity, nil, nil
+	}
+
+	variant, err := findVariant(ctx, variants, productID, unit)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return variant.ToBase(quantity), variant, nil
+}
+
+// findVariant 商品と荷姿コードから荷姿を取得する
+// 登録されていない場合は*models.UnknownUnitErrorを返す
+func findVariant(ctx context.Context, variants repository.ProductVariantRepository, productID int64, unit string) (*models.ProductVariant, error) {
+	variant, err := variants.GetVariantByUnit(ctx, productID, unit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, &models.UnknownUnitError{ProductID: productID, Unit: unit}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("荷姿取得エラー: %v", err)
+	}
+
+	return variant, nil
+}
+
+// unitOrBase 単位の指定を省略した場合は基本単位を返す
+func unitOrBase(unit string) string {
+	if models.IsBaseUnit(unit) {
+		return models.BaseUnit
+	}
+	return unit
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 荷姿サービスのテスト
+ * 荷姿の登録と、在庫移動・配送での基本単位への換算を検証する
+ */
+
+// 煎茶の荷姿（100g袋・1kg缶・20kg袋）
+var (
+	bagVariant  = &models.ProductVariant{ID: 1, ProductID: 1, Unit: "bag100g", ConversionFactor: 100, WeightGrams: 110, VolumeML: 300}
+	tinVariant  = &models.ProductVariant{ID: 2, ProductID: 1, Unit: "tin1kg", ConversionFactor: 1000, WeightGrams: 1150, VolumeML: 1500}
+	sackVariant = &models.ProductVariant{ID: 3, ProductID: 1, Unit: "sack20kg", ConversionFactor: 20000}
+)
+
+// newTeaVariantRepo 煎茶の荷姿を返すモックを作成する
+func newTeaVariantRepo() *mocks.MockProductVariantRepository {
+	mockVariantRepo := new(mocks.MockProductVariantRepository)
+	for _, variant := range []*models.ProductVariant{bagVariant, tinVariant, sackVariant} {
+		mockVariantRepo.On("GetVariantByUnit", mock.Anything, int64(1), variant.Unit).Return(variant, nil).Maybe()
+	}
+	mockVariantRepo.On("GetVariantByUnit", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound).Maybe()
+	return mockVariantRepo
+}
+
+func TestProductVariantService_CreateVariant(t *testing.T) {
+	mockVariantRepo := new(mocks.MockProductVariantRepository)
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductVariantService(mockVariantRepo, mockProductRepo)
+	ctx := context.Background()
+
+	// 基本単位の荷姿コードは登録できない
+	_, err := service.CreateVariant(ctx, 1, &models.ProductVariantRequest{Unit: "g", Name: "グラム", SKU: "SEN-G", ConversionFactor: 1})
+	assert.Error(t, err)
+
+	mockProductRepo.On("GetProduct", ctx, int64(1)).Return(&models.Product{ID: 1, Name: "煎茶"}, nil)
+	mockVariantRepo.On("CreateVariant", ctx, mock.AnythingOfType("*models.ProductVariant")).Return(nil)
+
+	variant, err := service.CreateVariant(ctx, 1, &models.ProductVariantRequest{
+		Unit:             "tin1kg",
+		Name:             "1kg缶",
+		SKU:              "SEN-001-1KG",
+		ConversionFactor: 1000,
+		Price:            5800,
+		WeightGrams:      1150,
+		VolumeML:         1500,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), variant.ProductID)
+	assert.Equal(t, 1000, variant.ConversionFactor)
+	mockVariantRepo.AssertExpectations(t)
+}
+
+func TestProductVariantService_ConvertQuantity(t *testing.T) {
+	service := NewProductVariantService(newTeaVariantRepo(), new(MockProductRepository))
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		quantity  int
+		from, to  string
+		base      int
+		result    int
+		remainder int
+	}{
+		{"缶から袋", 3, "tin1kg", "bag100g", 3000, 30, 0},
+		{"缶から大袋（端数あり）", 25, "tin1kg", "sack20kg", 25000, 1, 5000},
+		{"大袋から基本単位", 2, "sack20kg", "", 40000, 40000, 0},
+		{"基本単位から袋", 250, "g", "bag100g", 250, 2, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversion, err := service.ConvertQuantity(ctx, 1, tt.quantity, tt.from, tt.to)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.base, conversion.BaseQuantity)
+			assert.Equal(t, tt.result, conversion.Result)
+			assert.Equal(t, tt.remainder, conversion.Remainder)
+		})
+	}
+
+	_, err := service.ConvertQuantity(ctx, 1, 1, "crate", "g")
+	var unitErr *models.UnknownUnitError
+	assert.ErrorAs(t, err, &unitErr)
+}
+
+func TestCreateMovement_ConvertsVariantToBaseUnit(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockWarehouseRepo := new(mocks.MockWarehouseRepository)
+	mockReservationRepo := newDefaultReservationRepo()
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:    mockRepo,
+		Reservations: mockReservationRepo,
+		Warehouses:   mockWarehouseRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+		Variants:     newTeaVariantRepo(),
+	})
+	service := NewInventoryService(mockRepo, newDefaultReservationRepo(), uow)
+
+	ctx := context.Background()
+	fromInventory := &models.Inventory{ID: 1, ProductID: 1, Quantity: 5000, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	mockRepo.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{fromInventory}, nil)
+	// 引当済みの在庫は基本単位で比較する
+	mockReservationRepo.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(3000, nil)
+	mockWarehouseRepo.On("LockWarehouseByName", ctx, "大阪倉庫").Return(&models.Warehouse{ID: 2, Name: "大阪倉庫", Capacity: 100000}, nil)
+	mockWarehouseRepo.On("GetStoredQuantity", ctx, int64(2)).Return(0, nil)
+	mockRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 10
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 10)
+	// 1kg缶2缶を基本単位の2000gに換算して移動する
+	mockRepo.On("UpdateQuantity", movementCtx, int64(1), 3000, 0).Return(nil)
+	mockRepo.On("GetInventoryByStockKey", movementCtx, int64(1), "大阪倉庫", (*int64)(nil), (*int64)(nil), false).Return(nil, repository.ErrNotFound)
+	mockRepo.On("CreateInventory", movementCtx, mock.MatchedBy(func(inv *models.Inventory) bool {
+		return inv.Quantity == 2000
+	})).Return(nil)
+
+	movement, err := service.CreateMovement(ctx, &models.CreateMovementRequest{
+		ProductID:       1,
+		FromLocation:    "東京倉庫",
+		ToLocation:      "大阪倉庫",
+		Quantity:        2,
+		Unit:            "tin1kg",
+		MovementType:    models.MovementTypeTransfer,
+		MovementDate:    time.Now(),
+		ReferenceNumber: "TRF-100",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2000, movement.Quantity)
+	assert.Equal(t, "tin1kg", movement.Unit)
+	assert.Equal(t, 2, movement.UnitQuantity)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateMovement_UnknownUnit(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory: mockRepo,
+		Variants:  newTeaVariantRepo(),
+	})
+	service := NewInventoryService(mockRepo, newDefaultReservationRepo(), uow)
+
+	_, err := service.CreateMovement(context.Background(), &models.CreateMovementRequest{
+		ProductID:    1,
+		FromLocation: "東京倉庫",
+		ToLocation:   "大阪倉庫",
+		Quantity:     1,
+		Unit:         "crate",
+		MovementType: models.MovementTypeTransfer,
+	})
+
+	var unitErr *models.UnknownUnitError
+	assert.ErrorAs(t, err, &unitErr)
+	mockRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+}
+
+func TestDeliveryService_GetShipment(t *testing.T) {
+	mockRepo := new(mocks.MockDeliveryRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries: mockRepo,
+		Variants:   newTeaVariantRepo(),
+	})
+	service := NewDeliveryService(mockRepo, new(mocks.MockInventoryRepository), uow, nil, nil, nil)
+	ctx := context.Background()
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(&models.Delivery{ID: 1}, nil)
+	mockRepo.On("ListDeliveryItems", ctx, int64(1)).Return([]*models.DeliveryItem{
+		// 1kg缶3缶のうち1缶をキャンセル
+		{ID: 1, ProductID: 1, Quantity: 3000, CancelledQuantity: 1000, Unit: "tin1kg", UnitQuantity: 3},
+		// 100g袋5袋のうち150gをキャンセル（残り350gは4袋に切り上げる）
+		{ID: 2, ProductID: 1, Quantity: 500, CancelledQuantity: 150, Unit: "bag100g", UnitQuantity: 5},
+		// 重量を登録していない大袋は正味重量で計算する
+		{ID: 3, ProductID: 1, Quantity: 20000, Unit: "sack20kg", UnitQuantity: 1},
+		// 基本単位の明細
+		{ID: 4, ProductID: 1, Quantity: 250, Unit: "g", UnitQuantity: 250},
+	}, nil)
+
+	shipment, err := service.GetShipment(ctx, 1)
+
+	require.NoError(t, err)
+	require.Len(t, shipment.Lines, 4)
+	assert.Equal(t, 2, shipment.Lines[0].UnitQuantity)
+	assert.Equal(t, 2300, shipment.Lines[0].WeightGrams)
+	assert.Equal(t, 4, shipment.Lines[1].UnitQuantity)
+	assert.Equal(t, 440, shipment.Lines[1].WeightGrams)
+	assert.Equal(t, 20000, shipment.Lines[2].WeightGrams)
+	assert.Equal(t, 250, shipment.Lines[3].WeightGrams)
+	assert.Equal(t, 2300+440+20000+250, shipment.WeightGrams)
+	assert.Equal(t, 2*1500+4*300, shipment.VolumeML)
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 発注・入荷サービス
+ * 仕入先・発注書の管理と、発注に対する入荷（入庫）を実装する
+ */
+
+// OverReceiptTolerance 発注数量に対して受け入れる過剰入荷の割合
+const OverReceiptTolerance = 0.1
+
+// ErrSupplierNotFound 仕入先が見つからない場合のエラー
+var ErrSupplierNotFound = errors.New("仕入先が見つかりません")
+
+// ErrPurchaseOrderNotFound 発注書が見つからない場合のエラー
+var ErrPurchaseOrderNotFound = errors.New("発注書が見つかりません")
+
+// PurchaseOrderService 発注・入荷サービス
+type PurchaseOrderService struct {
+	repo repository.PurchaseOrderRepository
+	uow  repository.UnitOfWork
+}
+
+// NewPurchaseOrderService 発注・入荷サービスを作成する
+func NewPurchaseOrderService(repo repository.PurchaseOrderRepository, uow repository.UnitOfWork) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// CreateSupplier 仕入先を作成する
+func (s *PurchaseOrderService) CreateSupplier(ctx context.Context, req *models.CreateSupplierRequest) (*models.Supplier, error) {
+	supplier := &models.Supplier{
+		Code:        strings.TrimSpace(req.Code),
+		Name:        strings.TrimSpace(req.Name),
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Address:     req.Address,
+	}
+
+	if err := s.repo.CreateSupplier(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("仕入先作成エラー: %v", err)
+	}
+
+	return supplier, nil
+}
+
+// GetSupplier 仕入先を取得する
+func (s *PurchaseOrderService) GetSupplier(ctx context.Context, id int64) (*models.Supplier, error) {
+	supplier, err := s.repo.GetSupplier(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSupplierNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("仕入先取得エラー: %v", err)
+	}
+
+	return supplier, nil
+}
+
+// ListSuppliers 仕入先一覧を取得する
+func (s *PurchaseOrderService) ListSuppliers(ctx context.Context) ([]*models.Supplier, error) {
+	suppliers, err := s.repo.ListSuppliers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("仕入先一覧取得エラー: %v", err)
+	}
+
+	return suppliers, nil
+}
+
+// UpdateSupplier 仕入先を更新する
+func (s *PurchaseOrderService) UpdateSupplier(ctx context.Context, id int64, req *models.UpdateSupplierRequest) (*models.Supplier, error) {
+	supplier, err := s.GetSupplier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	supplier.Name = strings.TrimSpace(req.Name)
+	supplier.ContactName = req.ContactName
+	supplier.Email = req.Email
+	supplier.Phone = req.Phone
+	supplier.Address = req.Address
+
+	if err := s.repo.UpdateSupplier(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("仕入先更新エラー: %v", err)
+	}
+
+	return supplier, nil
+}
+
+// CreatePurchaseOrder 発注書を作成する
+// 入荷先のロケーションは登録済みの倉庫である必要があり、同じ商品の明細は1行にまとめる
+func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, req *models.CreatePurchaseOrderRequest, createdBy int64) (*models.PurchaseOrder, error) {
+	seen := make(map[int64]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		if seen[line.ProductID] {
+			return nil, fmt.Errorf("商品ID %d の明細が重複しています", line.ProductID)
+		}
+		seen[line.ProductID] = true
+	}
+
+	order := &models.PurchaseOrder{
+		PONumber:        strings.TrimSpace(req.PONumber),
+		SupplierID:      req.SupplierID,
+		Location:        strings.TrimSpace(req.Location),
+		Status:          models.PurchaseOrderStatusOpen,
+		ExpectedArrival: req.ExpectedArrival,
+		Notes:           req.Notes,
+		CreatedBy:       createdBy,
+	}
+
+	// 発注書と明細を単一トランザクションで作成する
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if _, err := tx.PurchaseOrders.GetSupplier(ctx, req.SupplierID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrSupplierNotFound
+			}
+			return fmt.Errorf("仕入先取得エラー: %v", err)
+		}
+
+		if _, err := tx.Warehouses.GetWarehouseByName(ctx, order.Location); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("入荷先の倉庫が見つかりません: %s", order.Location)
+			}
+			return fmt.Errorf("入荷先倉庫取得エラー: %v", err)
+		}
+
+		if err := tx.PurchaseOrders.CreatePurchaseOrder(ctx, order); err != nil {
+			return err
+		}
+
+		for _, r := range req.Lines {
+			line := &models.PurchaseOrderLine{
+				PurchaseOrderID: order.ID,
+				ProductID:       r.ProductID,
+				OrderedQuantity: r.Quantity,
+			}
+			if err := tx.PurchaseOrders.CreatePurchaseOrderLine(ctx, line); err != nil {
+				return err
+			}
+			order.Lines = append(order.Lines, line)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("発注書作成完了", map[string]interface{}{
+		"purchase_order_id": order.ID,
+		"po_number":         order.PONumber,
+		"supplier_id":       order.SupplierID,
+		"location":          order.Location,
+		"line_count":        len(order.Lines),
+	})
+
+	return order, nil
+}
+
+// GetPurchaseOrder 発注書を明細・入荷実績とともに取得する
+func (s *PurchaseOrderService) GetPurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error) {
+	order, err := s.repo.GetPurchaseOrder(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("発注書取得エラー: %v", err)
+	}
+
+	order.Lines, err = s.repo.ListPurchaseOrderLines(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("発注明細取得エラー: %v", err)
+	}
+
+	order.Receipts, err = s.repo.ListReceipts(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("入荷実績取得エラー: %v", err)
+	}
+
+	return order, nil
+}
+
+// ListPurchaseOrders 発注書一覧を取得する
+// statusが空・supplierIDが0の場合は絞り込まない
+func (s *PurchaseOrderService) ListPurchaseOrders(ctx context.Context, status models.PurchaseOrderStatus, supplierID int64) ([]*models.PurchaseOrder, error) {
+	orders, err := s.repo.ListPurchaseOrders(ctx, status, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("発注書一覧取得エラー: %v", err)
+	}
+
+	return orders, nil
+}
+
+// CancelPurchaseOrder 入荷前の発注をキャンセルする
+func (s *PurchaseOrderService) CancelPurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error) {
+	return s.finishPurchaseOrder(ctx, id, models.PurchaseOrderStatusOpen, models.PurchaseOrderStatusCancelled, "キャンセル")
+}
+
+// ClosePurchaseOrder 一部入荷の発注について、未入荷の残数量を打ち切って完了する
+func (s *PurchaseOrderService) ClosePurchaseOrder(ctx context.Context, id int64) (*models.PurchaseOrder, error) {
+	return s.finishPurchaseOrder(ctx, id, models.PurchaseOrderStatusPartiallyReceived, models.PurchaseOrderStatusClosed, "完了")
+}
+
+// finishPurchaseOrder ステータスがfromの発注をtoに変更して終了する
+func (s *PurchaseOrderService) finishPurchaseOrder(
+	ctx context.Context,
+	id int64,
+	from, to models.PurchaseOrderStatus,
+	action string,
+) (*models.PurchaseOrder, error) {
+	order, err := s.repo.GetPurchaseOrder(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("発注書取得エラー: %v", err)
+	}
+
+	if order.Status != from {
+		return nil, &models.PurchaseOrderStatusError{PONumber: order.PONumber, Status: order.Status, Action: action}
+	}
+
+	now := time.Now()
+	order.Status = to
+	order.ClosedAt = &now
+	if err := s.repo.UpdatePurchaseOrderStatus(ctx, order); err != nil {
+		return nil, fmt.Errorf("発注書更新エラー: %v", err)
+	}
+
+	return order, nil
+}
+
+// ReceivePurchaseOrder 発注に対する入荷を処理する
+// 明細ごとに入庫と入庫の在庫移動（参照番号は発注番号）を記録し、予定数量と入荷数量の差を入荷実績に残す
+// 過剰入荷は発注数量のOverReceiptToleranceまで受け入れ、全明細の入荷が揃った時点で発注を完了する
+// CloseShortを指定した場合は未入荷の残数量を打ち切って完了する
+func (s *PurchaseOrderService) ReceivePurchaseOrder(
+	ctx context.Context,
+	id int64,
+	req *models.ReceivePurchaseOrderRequest,
+	receivedBy int64,
+) (*models.PurchaseOrderReceipt, error) {
+	var result *models.PurchaseOrderReceipt
+
+	// 入庫・在庫移動・入荷実績・発注ステータスを単一トランザクションで更新する
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		order, err := tx.PurchaseOrders.GetPurchaseOrder(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPurchaseOrderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("発注書取得エラー: %v", err)
+		}
+
+		if !order.Status.IsReceivable() {
+			return &models.PurchaseOrderStatusError{PONumber: order.PONumber, Status: order.Status, Action: "入荷"}
+		}
+
+		if err := checkLocationNotFrozen(ctx, tx, order.Location); err != nil {
+			return err
+		}
+
+		supplier, err := tx.PurchaseOrders.GetSupplier(ctx, order.SupplierID)
+		if err != nil {
+			return fmt.Errorf("仕入先取得エラー: %v", err)
+		}
+
+		lines, err := tx.PurchaseOrders.ListPurchaseOrderLines(ctx, id)
+		if err != nil {
+			return fmt.Errorf("発注明細取得エラー: %v", err)
+		}
+
+		linesByID := make(map[int64]*models.PurchaseOrderLine, len(lines))
+		for _, line := range lines {
+			linesByID[line.ID] = line
+		}
+
+		result = &models.PurchaseOrderReceipt{
+			PurchaseOrderID: order.ID,
+			PONumber:        order.PONumber,
+			Lines:           []*models.PurchaseOrderReceiptLine{},
+			Movements:       []*models.InventoryMovement{},
+		}
+
+		// 発注明細の商品の在庫のロックを一定の順序で取得してから入庫する
+		keys := make([]stockKey, 0, len(lines))
+		for _, line := range lines {
+			keys = append(keys, stockKey{ProductID: line.ProductID, Location: order.Location})
+		}
+		if err := lockStocks(ctx, tx, keys); err != nil {
+			return err
+		}
+
+		// 同じ明細をロット・ビン別に複数行で入荷できる
+		for _, r := range req.Lines {
+			line, ok := linesByID[r.LineID]
+			if !ok {
+				return fmt.Errorf("明細ID %d はこの発注の明細ではありません", r.LineID)
+			}
+
+			receipt, movement, err := receivePurchaseOrderLine(ctx, tx, order, supplier, line, r, receivedBy)
+			if err != nil {
+				return err
+			}
+			result.Lines = append(result.Lines, receipt)
+			result.Movements = append(result.Movements, movement)
+		}
+
+		order.Status = purchaseOrderStatusAfterReceipt(lines, req.CloseShort)
+		if order.Status == models.PurchaseOrderStatusClosed {
+			now := time.Now()
+			order.ClosedAt = &now
+		}
+		if err := tx.PurchaseOrders.UpdatePurchaseOrderStatus(ctx, order); err != nil {
+			return fmt.Errorf("発注書更新エラー: %v", err)
+		}
+		result.Status = order.Status
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("入荷処理完了", map[string]interface{}{
+		"purchase_order_id": result.PurchaseOrderID,
+		"po_number":         result.PONumber,
+		"status":            result.Status,
+		"line_count":        len(result.Lines),
+	})
+
+	return result, nil
+}
+
+// receivePurchaseOrderLine トランザクション内で発注明細の入荷を入庫し、在庫移動と入荷実績を記録する
+func receivePurchaseOrderLine(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	order *models.PurchaseOrder,
+	supplier *models.Supplier,
+	line *models.PurchaseOrderLine,
+	req models.ReceivePurchaseOrderLineRequest,
+	receivedBy int64,
+) (*models.PurchaseOrderReceiptLine, *models.InventoryMovement, error) {
+	maxQuantity := line.OrderedQuantity + int(float64(line.OrderedQuantity)*OverReceiptTolerance)
+	if line.ReceivedQuantity+req.Quantity > maxQuantity {
+		return nil, nil, fmt.Errorf("明細ID %d の入荷数量が許容数量(%d)を超えています", line.ID, maxQuantity-line.ReceivedQuantity)
+	}
+
+	lot, err := resolveLot(ctx, tx, line.ProductID, req.LotNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	lotID := lotIDOf(lot)
+
+	// 入荷先倉庫の空き容量とビンのゾーン保管ルールを確認
+	if err := checkWarehouseCapacity(ctx, tx, supplier.Name, order.Location, req.Quantity); err != nil {
+		return nil, nil, err
+	}
+	if req.BinID != nil {
+		if err := checkBinPlacement(ctx, tx, line.ProductID, order.Location, *req.BinID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	movement := &models.InventoryMovement{
+		ProductID:       line.ProductID,
+		FromLocation:    supplier.Name,
+		ToLocation:      order.Location,
+		ToBinID:         req.BinID,
+		LotID:           lotID,
+		Quantity:        req.Quantity,
+		MovementType:    models.MovementTypeInbound,
+		MovementDate:    time.Now(),
+		ReferenceNumber: order.PONumber,
+	}
+	if err := tx.Inventory.CreateMovement(ctx, movement); err != nil {
+		return nil, nil, fmt.Errorf("入荷在庫移動作成エラー: %v", err)
+	}
+
+	// 入庫を在庫元帳上で入荷の在庫移動に紐付ける
+	stockCtx := repository.WithLedgerMovement(ctx, movement.ID)
+	inventory, err := receiveStock(stockCtx, tx, line.ProductID, order.Location, req.BinID, lotID, models.InventoryStatusAvailable, req.Quantity)
+	if err != nil {
+		return nil, nil, wrapUpdateError("入荷在庫入庫エラー", err)
+	}
+
+	// 在庫切れの在庫行は入荷により利用可能に戻す
+	if inventory.Status == models.InventoryStatusOutOfStock {
+		inventory.Status = models.InventoryStatusAvailable
+		if err := tx.Inventory.UpdateInventory(stockCtx, inventory); err != nil {
+			return nil, nil, wrapUpdateError("在庫ステータス更新エラー", err)
+		}
+	}
+
+	receipt := &models.PurchaseOrderReceiptLine{
+		PurchaseOrderID:  order.ID,
+		LineID:           line.ID,
+		ProductID:        line.ProductID,
+		LotID:            lotID,
+		ExpectedQuantity: line.OutstandingQuantity(),
+		ReceivedQuantity: req.Quantity,
+		ReceivedBy:       receivedBy,
+	}
+	receipt.Variance = receipt.ReceivedQuantity - receipt.ExpectedQuantity
+	if err := tx.PurchaseOrders.CreateReceipt(ctx, receipt); err != nil {
+		return nil, nil, err
+	}
+
+	if receipt.Variance > 0 {
+		logger.Warn("過剰入荷", map[string]interface{}{
+			"po_number":  order.PONumber,
+			"line_id":    line.ID,
+			"product_id": line.ProductID,
+			"expected":   receipt.ExpectedQuantity,
+			"received":   receipt.ReceivedQuantity,
+		})
+	}
+
+	line.ReceivedQuantity += req.Quantity
+	if err := tx.PurchaseOrders.UpdateReceivedQuantity(ctx, line); err != nil {
+		return nil, nil, fmt.Errorf("発注明細更新エラー: %v", err)
+	}
+
+	return receipt, movement, nil
+}
+
+// purchaseOrderStatusAfterReceipt 入荷後の発注ステータスを判定する
+// 全明細の入荷が揃った場合、または残数量を打ち切る場合は完了とする
+func purchaseOrderStatusAfterReceipt(lines []*models.PurchaseOrderLine, closeShort bool) models.PurchaseOrderStatus {
+	if closeShort {
+		return models.PurchaseOrderStatusClosed
+	}
+	for _, line := range lines {
+		if line.OutstandingQuantity() > 0 {
+			return models.PurchaseOrderStatusPartiallyReceived
+		}
+	}
+	return models.PurchaseOrderStatusClosed
+}
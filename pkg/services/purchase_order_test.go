@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 発注・入荷サービステスト
+ */
+
+type purchaseOrderTestRepos struct {
+	orders     *mocks.MockPurchaseOrderRepository
+	inventory  *mocks.MockInventoryRepository
+	warehouses *mocks.MockWarehouseRepository
+}
+
+func newTestPurchaseOrderService() (*PurchaseOrderService, *purchaseOrderTestRepos) {
+	repos := &purchaseOrderTestRepos{
+		orders:     new(mocks.MockPurchaseOrderRepository),
+		inventory:  new(mocks.MockInventoryRepository),
+		warehouses: new(mocks.MockWarehouseRepository),
+	}
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:      repos.inventory,
+		Reservations:   newDefaultReservationRepo(),
+		Warehouses:     repos.warehouses,
+		PurchaseOrders: repos.orders,
+		StockCounts:    newDefaultStockCountRepo(),
+	})
+	return NewPurchaseOrderService(repos.orders, uow), repos
+}
+
+// expectReceivable 入荷可能な発注と入荷先倉庫のモックを設定する
+func (r *purchaseOrderTestRepos) expectReceivable(ctx context.Context, order *models.PurchaseOrder, lines []*models.PurchaseOrderLine) {
+	r.orders.On("GetPurchaseOrder", ctx, order.ID).Return(order, nil)
+	r.orders.On("GetSupplier", ctx, order.SupplierID).Return(&models.Supplier{ID: order.SupplierID, Name: "牧之原製茶"}, nil)
+	r.orders.On("ListPurchaseOrderLines", ctx, order.ID).Return(lines, nil)
+	r.warehouses.On("LockWarehouseByName", ctx, order.Location).Return(&models.Warehouse{ID: 1, Name: order.Location, Capacity: 10000}, nil)
+	r.warehouses.On("GetStoredQuantity", ctx, int64(1)).Return(0, nil)
+	// 入荷の在庫移動を記録してから、入庫を在庫元帳上でこの移動に紐付ける
+	r.inventory.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.InventoryMovement).ID = 50
+	}).Return(nil)
+	movementCtx := repository.WithLedgerMovement(ctx, 50)
+	r.inventory.On("GetInventoryByStockKey", movementCtx, mock.Anything, order.Location, (*int64)(nil), mock.Anything, false).Return(nil, repository.ErrNotFound)
+	r.inventory.On("CreateInventory", movementCtx, mock.AnythingOfType("*models.Inventory")).Return(nil)
+	r.orders.On("CreateReceipt", ctx, mock.AnythingOfType("*models.PurchaseOrderReceiptLine")).Return(nil)
+	r.orders.On("UpdateReceivedQuantity", ctx, mock.AnythingOfType("*models.PurchaseOrderLine")).Return(nil)
+	r.orders.On("UpdatePurchaseOrderStatus", ctx, order).Return(nil)
+}
+
+func TestReceivePurchaseOrder_ShortReceiptKeepsOrderOpen(t *testing.T) {
+	service, repos := newTestPurchaseOrderService()
+
+	ctx := context.Background()
+	order := &models.PurchaseOrder{ID: 1, PONumber: "PO-000001", SupplierID: 2, Location: "静岡倉庫", Status: models.PurchaseOrderStatusOpen}
+	lines := []*models.PurchaseOrderLine{
+		{ID: 11, PurchaseOrderID: 1, ProductID: 1, OrderedQuantity: 100},
+		{ID: 12, PurchaseOrderID: 1, ProductID: 2, OrderedQuantity: 50},
+	}
+	repos.expectReceivable(ctx, order, lines)
+
+	receipt, err := service.ReceivePurchaseOrder(ctx, 1, &models.ReceivePurchaseOrderRequest{
+		Lines: []models.ReceivePurchaseOrderLineRequest{
+			{LineID: 11, Quantity: 100},
+			{LineID: 12, Quantity: 30},
+		},
+	}, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusPartiallyReceived, receipt.Status)
+	assert.Nil(t, order.ClosedAt)
+	if assert.Len(t, receipt.Lines, 2) {
+		assert.Equal(t, 0, receipt.Lines[0].Variance)
+		assert.Equal(t, 50, receipt.Lines[1].ExpectedQuantity)
+		assert.Equal(t, -20, receipt.Lines[1].Variance)
+	}
+	if assert.Len(t, receipt.Movements, 2) {
+		movement := receipt.Movements[1]
+		assert.Equal(t, models.MovementTypeInbound, movement.MovementType)
+		assert.Equal(t, "PO-000001", movement.ReferenceNumber)
+		assert.Equal(t, "牧之原製茶", movement.FromLocation)
+		assert.Equal(t, "静岡倉庫", movement.ToLocation)
+	}
+	assert.Equal(t, 30, lines[1].ReceivedQuantity)
+}
+
+func TestReceivePurchaseOrder_OverReceiptClosesOrder(t *testing.T) {
+	service, repos := newTestPurchaseOrderService()
+
+	ctx := context.Background()
+	order := &models.PurchaseOrder{ID: 1, PONumber: "PO-000001", SupplierID: 2, Location: "静岡倉庫", Status: models.PurchaseOrderStatusPartiallyReceived}
+	lines := []*models.PurchaseOrderLine{
+		{ID: 11, PurchaseOrderID: 1, ProductID: 1, OrderedQuantity: 100, ReceivedQuantity: 60},
+	}
+	repos.expectReceivable(ctx, order, lines)
+
+	receipt, err := service.ReceivePurchaseOrder(ctx, 1, &models.ReceivePurchaseOrderRequest{
+		Lines: []models.ReceivePurchaseOrderLineRequest{{LineID: 11, Quantity: 45}},
+	}, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusClosed, receipt.Status)
+	assert.NotNil(t, order.ClosedAt)
+	assert.Equal(t, 40, receipt.Lines[0].ExpectedQuantity)
+	assert.Equal(t, 5, receipt.Lines[0].Variance)
+}
+
+func TestReceivePurchaseOrder_CloseShort(t *testing.T) {
+	service, repos := newTestPurchaseOrderService()
+
+	ctx := context.Background()
+	order := &models.PurchaseOrder{ID: 1, PONumber: "PO-000001", SupplierID: 2, Location: "静岡倉庫", Status: models.PurchaseOrderStatusOpen}
+	lines := []*models.PurchaseOrderLine{
+		{ID: 11, PurchaseOrderID: 1, ProductID: 1, OrderedQuantity: 100},
+	}
+	repos.expectReceivable(ctx, order, lines)
+
+	receipt, err := service.ReceivePurchaseOrder(ctx, 1, &models.ReceivePurchaseOrderRequest{
+		Lines:      []models.ReceivePurchaseOrderLineRequest{{LineID: 11, Quantity: 80}},
+		CloseShort: true,
+	}, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusClosed, receipt.Status)
+	assert.Equal(t, -20, receipt.Lines[0].Variance)
+}
+
+func TestReceivePurchaseOrder_ExceedsTolerance(t *testing.T) {
+	service, repos := newTestPurchaseOrderService()
+
+	ctx := context.Background()
+	order := &models.PurchaseOrder{ID: 1, PONumber: "PO-000001", SupplierID: 2, Location: "静岡倉庫", Status: models.PurchaseOrderStatusOpen}
+	lines := []*models.PurchaseOrderLine{
+		{ID: 11, PurchaseOrderID: 1, ProductID: 1, OrderedQuantity: 100},
+	}
+	repos.expectReceivable(ctx, order, lines)
+
+	receipt, err := service.ReceivePurchaseOrder(ctx, 1, &models.ReceivePurchaseOrderRequest{
+		Lines: []models.ReceivePurchaseOrderLineRequest{{LineID: 11, Quantity: 111}},
+	}, 5)
+
+	assert.Error(t, err)
+	assert.Nil(t, receipt)
+	repos.inventory.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+	repos.orders.AssertNotCalled(t, "UpdatePurchaseOrderStatus", mock.Anything, mock.Anything)
+}
+
+func TestReceivePurchaseOrder_ClosedOrder(t *testing.T) {
+	service, repos := newTestPurchaseOrderService()
+
+	ctx := context.Background()
+	order := &models.PurchaseOrder{ID: 1, PONumber: "PO-000001", SupplierID: 2, Location: "静岡倉庫", Status: models.PurchaseOrderStatusClosed}
+	repos.orders.On("GetPurchaseOrder", ctx, int64(1)).Return(order, nil)
+
+	receipt, err := service.ReceivePurchaseOrder(ctx, 1, &models.ReceivePurchaseOrderRequest{
+		Lines: []models.ReceivePurchaseOrderLineRequest{{LineID: 11, Quantity: 10}},
+	}, 5)
+
+	var statusErr *models.PurchaseOrderStatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Nil(t, receipt)
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 在庫補充サービス
+ * 発注点による在庫の評価と、在庫移動・発注の補充提案を実装する
+ */
+
+// ErrReorderSettingNotFound 発注点設定が見つからない場合のエラー
+var ErrReorderSettingNotFound = errors.New("発注点設定が見つかりません")
+
+// ErrReplenishmentProposalNotFound 在庫補充提案が見つからない場合のエラー
+var ErrReplenishmentProposalNotFound = errors.New("在庫補充提案が見つかりません")
+
+// ReplenishmentService 在庫補充サービス
+type ReplenishmentService struct {
+	repo          repository.ReplenishmentRepository
+	uow           repository.UnitOfWork
+	notifyService NotificationService
+}
+
+// NewReplenishmentService 在庫補充サービスを作成する
+func NewReplenishmentService(
+	repo repository.ReplenishmentRepository,
+	uow repository.UnitOfWork,
+	notifyService NotificationService,
+) *ReplenishmentService {
+	return &ReplenishmentService{
+		repo:          repo,
+		uow:           uow,
+		notifyService: notifyService,
+	}
+}
+
+// ListSettings 発注点設定一覧を取得する
+func (s *ReplenishmentService) ListSettings(ctx context.Context) ([]*models.ReorderSetting, error) {
+	settings, err := s.repo.ListReorderSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("発注点設定一覧取得エラー: %v", err)
+	}
+
+	return settings, nil
+}
+
+// SetSetting 商品・ロケーションの発注点を設定する
+func (s *ReplenishmentService) SetSetting(ctx context.Context, req *models.SetReorderSettingRequest) (*models.ReorderSetting, error) {
+	location := strings.TrimSpace(req.Location)
+	if location == "" {
+		return nil, fmt.Errorf("ロケーションを指定してください")
+	}
+	if req.ReorderPoint < 0 || req.SafetyStock < 0 {
+		return nil, fmt.Errorf("発注点・安全在庫は0以上を指定してください")
+	}
+	if req.ReorderQuantity <= 0 {
+		return nil, fmt.Errorf("発注数量は1以上を指定してください")
+	}
+	if req.SafetyStock > req.ReorderPoint {
+		return nil, fmt.Errorf("安全在庫は発注点以下を指定してください")
+	}
+
+	setting := &models.ReorderSetting{
+		ProductID:       req.ProductID,
+		Location:        location,
+		ReorderPoint:    req.ReorderPoint,
+		SafetyStock:     req.SafetyStock,
+		ReorderQuantity: req.ReorderQuantity,
+	}
+	if err := s.repo.SetReorderSetting(ctx, setting); err != nil {
+		return nil, fmt.Errorf("発注点設定エラー: %v", err)
+	}
+
+	return setting, nil
+}
+
+// DeleteSetting 発注点設定を削除する
+func (s *ReplenishmentService) DeleteSetting(ctx context.Context, id int64) error {
+	err := s.repo.DeleteReorderSetting(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrReorderSettingNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("発注点設定削除エラー: %v", err)
+	}
+
+	return nil
+}
+
+// ListProposals 在庫補充提案一覧を取得する
+// statusが空の場合はすべての提案を取得する
+func (s *ReplenishmentService) ListProposals(ctx context.Context, status models.ReplenishmentProposalStatus) ([]*models.ReplenishmentProposal, error) {
+	proposals, err := s.repo.ListProposals(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("在庫補充提案一覧取得エラー: %v", err)
+	}
+
+	return proposals, nil
+}
+
+// UpdateProposalStatus 未対応の在庫補充提案を採用・見送りにする
+func (s *ReplenishmentService) UpdateProposalStatus(ctx context.Context, id int64, req *models.UpdateReplenishmentProposalRequest) (*models.ReplenishmentProposal, error) {
+	switch req.Status {
+	case models.ReplenishmentProposalAccepted, models.ReplenishmentProposalDismissed:
+	default:
+		return nil, fmt.Errorf("無効な在庫補充提案ステータスです: %s", req.Status)
+	}
+
+	proposal, err := s.repo.GetProposal(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReplenishmentProposalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫補充提案取得エラー: %v", err)
+	}
+	if proposal.Status != models.ReplenishmentProposalOpen {
+		return nil, fmt.Errorf("未対応の在庫補充提案のみ更新できます")
+	}
+
+	if err := s.repo.UpdateProposalStatus(ctx, id, req.Status); err != nil {
+		return nil, fmt.Errorf("在庫補充提案更新エラー: %v", err)
+	}
+
+	proposal.Status = req.Status
+	return proposal, nil
+}
+
+// Evaluate 発注点設定のある商品・ロケーションの在庫を評価する
+// 販売可能在庫がなくなったロケーションの在庫行は在庫切れにし、在庫が戻った場合は利用可能に戻す
+// 引当可能数が発注点以下になった場合は在庫補充を提案し、マネージャー・管理者に通知する
+func (s *ReplenishmentService) Evaluate(ctx context.Context, now time.Time) (*models.ReplenishmentEvaluation, error) {
+	settings, err := s.repo.ListReorderSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("発注点設定一覧取得エラー: %v", err)
+	}
+
+	result := &models.ReplenishmentEvaluation{EvaluatedAt: now}
+	for _, setting := range settings {
+		err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+			proposal, outOfStock, err := evaluateReorderSetting(ctx, tx, setting)
+			if err != nil {
+				return err
+			}
+			if outOfStock {
+				result.OutOfStock++
+			}
+			if proposal != nil {
+				result.Proposals = append(result.Proposals, proposal)
+			}
+			return nil
+		})
+		if err != nil {
+			// 1件の評価エラーで他の商品・ロケーションの評価を止めない
+			logger.Error("発注点評価エラー", map[string]interface{}{
+				"error":      err.Error(),
+				"product_id": setting.ProductID,
+				"location":   setting.Location,
+			})
+			continue
+		}
+		result.Evaluated++
+	}
+
+	s.notifyProposals(ctx, result.Proposals)
+
+	return result, nil
+}
+
+// evaluateReorderSetting トランザクション内で商品・ロケーションの在庫を発注点で評価する
+// 未対応の在庫補充提案がある場合は新たに提案しない
+func evaluateReorderSetting(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	setting *models.ReorderSetting,
+) (*models.ReplenishmentProposal, bool, error) {
+	stock, err := loadLocationStock(ctx, tx.Inventory, setting.ProductID, setting.Location)
+	if errors.Is(err, errStockNotFound) {
+		stock = &locationStock{ProductID: setting.ProductID, Location: setting.Location}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	outOfStock := stock.OnHand() == 0
+	if err := updateStockAvailability(ctx, tx.Inventory, stock, outOfStock); err != nil {
+		return nil, outOfStock, err
+	}
+
+	reserved, err := tx.Reservations.SumActiveReserved(ctx, setting.ProductID, setting.Location)
+	if err != nil {
+		return nil, outOfStock, fmt.Errorf("引当数量取得エラー: %v", err)
+	}
+	available := stock.OnHand() - reserved
+	if available > setting.ReorderPoint {
+		return nil, outOfStock, nil
+	}
+
+	_, err = tx.Replenishment.GetOpenProposal(ctx, setting.ProductID, setting.Location)
+	if err == nil {
+		return nil, outOfStock, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, outOfStock, fmt.Errorf("在庫補充提案取得エラー: %v", err)
+	}
+
+	// 発注数量を基本とし、発注点と安全在庫の合計までの不足分が多い場合は不足分を補充する
+	quantity := setting.ReorderQuantity
+	if shortfall := setting.ReorderPoint + setting.SafetyStock - available; shortfall > quantity {
+		quantity = shortfall
+	}
+
+	proposal := &models.ReplenishmentProposal{
+		ProductID:          setting.ProductID,
+		Location:           setting.Location,
+		Type:               models.ReplenishmentProposalPurchase,
+		Quantity:           quantity,
+		AvailableToPromise: available,
+		ReorderPoint:       setting.ReorderPoint,
+		SafetyStock:        setting.SafetyStock,
+		Status:             models.ReplenishmentProposalOpen,
+	}
+
+	source, err := findTransferSource(ctx, tx, setting, quantity)
+	if err != nil {
+		return nil, outOfStock, err
+	}
+	if source != "" {
+		proposal.Type = models.ReplenishmentProposalTransfer
+		proposal.SourceLocation = source
+	}
+
+	if err := tx.Replenishment.CreateProposal(ctx, proposal); err != nil {
+		return nil, outOfStock, fmt.Errorf("在庫補充提案作成エラー: %v", err)
+	}
+
+	return proposal, outOfStock, nil
+}
+
+// updateStockAvailability ロケーションの販売可能在庫の有無に応じて在庫行のステータスを切り替える
+// 在庫がない場合は利用可能な在庫行を在庫切れにし、在庫がある場合は在庫切れの在庫行を利用可能に戻す
+func updateStockAvailability(ctx context.Context, repo repository.InventoryRepository, stock *locationStock, outOfStock bool) error {
+	from, to := models.InventoryStatusOutOfStock, models.InventoryStatusAvailable
+	if outOfStock {
+		from, to = models.InventoryStatusAvailable, models.InventoryStatusOutOfStock
+	}
+
+	for _, row := range stock.Rows {
+		if row.Status != from {
+			continue
+		}
+		row.Status = to
+		if err := repo.UpdateInventory(ctx, row); err != nil {
+			return wrapUpdateError("在庫ステータス更新エラー", err)
+		}
+	}
+
+	return nil
+}
+
+// transferSource 在庫移動元の候補
+type transferSource struct {
+	Location   string
+	Surplus    int
+	DistanceKm float64
+}
+
+// findTransferSource トランザクション内で補充数量を移動できる稼働中の倉庫を探す
+// 移動元は自身の発注点・安全在庫を下回らない余剰分から移動できる倉庫とし、近い倉庫・余剰の多い倉庫を優先する
+// 移動できる倉庫がない場合は空文字を返す
+func findTransferSource(ctx context.Context, tx *repository.TxRepositories, setting *models.ReorderSetting, quantity int) (string, error) {
+	warehouses, err := tx.Warehouses.ListWarehouses(ctx)
+	if err != nil {
+		return "", fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+
+	var destination *models.Warehouse
+	for _, w := range warehouses {
+		if w.Name == setting.Location {
+			destination = w
+		}
+	}
+
+	var sources []transferSource
+	for _, w := range warehouses {
+		if w.Name == setting.Location || w.Status != models.WarehouseStatusActive {
+			continue
+		}
+
+		stock, err := loadLocationStock(ctx, tx.Inventory, setting.ProductID, w.Name)
+		if errors.Is(err, errStockNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		reserved, err := tx.Reservations.SumActiveReserved(ctx, setting.ProductID, w.Name)
+		if err != nil {
+			return "", fmt.Errorf("引当数量取得エラー: %v", err)
+		}
+
+		keep := 0
+		own, err := tx.Replenishment.GetReorderSetting(ctx, setting.ProductID, w.Name)
+		if err == nil {
+			keep = own.ReorderPoint
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return "", fmt.Errorf("発注点設定取得エラー: %v", err)
+		}
+
+		surplus := stock.OnHand() - reserved - keep
+		if surplus < quantity {
+			continue
+		}
+
+		distance := math.Inf(1)
+		if destination != nil {
+			distance = warehouseDistance(destination, w)
+		}
+		sources = append(sources, transferSource{Location: w.Name, Surplus: surplus, DistanceKm: distance})
+	}
+
+	if len(sources) == 0 {
+		return "", nil
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].DistanceKm != sources[j].DistanceKm {
+			return sources[i].DistanceKm < sources[j].DistanceKm
+		}
+		return sources[i].Surplus > sources[j].Surplus
+	})
+
+	return sources[0].Location, nil
+}
+
+// notifyProposals 在庫補充提案をマネージャー・管理者に通知する
+// 通知エラーはログに記録するだけで、評価自体は成功とする
+func (s *ReplenishmentService) notifyProposals(ctx context.Context, proposals []*models.ReplenishmentProposal) {
+	if s.notifyService == nil || len(proposals) == 0 {
+		return
+	}
+
+	recipients, err := s.repo.ListAlertRecipientIDs(ctx)
+	if err != nil {
+		logger.Error("在庫補充提案の通知先取得エラー", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	for _, proposal := range proposals {
+		for _, userID := range recipients {
+			if err := s.notifyService.NotifyReplenishment(ctx, userID, proposal); err != nil {
+				logger.Error("在庫補充提案通知エラー", map[string]interface{}{
+					"error":       err.Error(),
+					"user_id":     userID,
+					"proposal_id": proposal.ID,
+				})
+			}
+		}
+	}
+}
+
+// StartReplenishmentEvaluator 発注点による在庫の評価を定期実行する
+func (s *ReplenishmentService) StartReplenishmentEvaluator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("発注点による在庫評価を停止しました")
+			return
+		case <-ticker.C:
+			result, err := s.Evaluate(ctx, time.Now())
+			if err != nil {
+				logger.Error("発注点による在庫評価エラー", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if result.OutOfStock > 0 || len(result.Proposals) > 0 {
+				logger.Info("発注点による在庫評価を実行しました", map[string]interface{}{
+					"evaluated_count":    result.Evaluated,
+					"out_of_stock_count": result.OutOfStock,
+					"proposal_count":     len(result.Proposals),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 在庫補充サービステスト
+ */
+
+type replenishmentTestRepos struct {
+	inventory     *mocks.MockInventoryRepository
+	reservations  *mocks.MockReservationRepository
+	warehouses    *mocks.MockWarehouseRepository
+	replenishment *mocks.MockReplenishmentRepository
+	notify        *mocks.MockNotificationService
+}
+
+func newTestReplenishmentService() (*ReplenishmentService, *replenishmentTestRepos) {
+	repos := &replenishmentTestRepos{
+		inventory:     new(mocks.MockInventoryRepository),
+		reservations:  newDefaultReservationRepo(),
+		warehouses:    new(mocks.MockWarehouseRepository),
+		replenishment: new(mocks.MockReplenishmentRepository),
+		notify:        new(mocks.MockNotificationService),
+	}
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:     repos.inventory,
+		Reservations:  repos.reservations,
+		Warehouses:    repos.warehouses,
+		Replenishment: repos.replenishment,
+		StockCounts:   newDefaultStockCountRepo(),
+	})
+	return NewReplenishmentService(repos.replenishment, uow, repos.notify), repos
+}
+
+func TestEvaluate_ProposesTransferFromNearestWarehouse(t *testing.T) {
+	service, repos := newTestReplenishmentService()
+
+	ctx := context.Background()
+	setting := &models.ReorderSetting{ID: 1, ProductID: 1, Location: "東京倉庫", ReorderPoint: 30, SafetyStock: 10, ReorderQuantity: 50}
+	lat := func(v float64) *float64 { return &v }
+	warehouses := []*models.Warehouse{
+		{ID: 1, Name: "東京倉庫", Status: models.WarehouseStatusActive, Latitude: lat(35.68), Longitude: lat(139.76)},
+		{ID: 2, Name: "静岡倉庫", Status: models.WarehouseStatusActive, Latitude: lat(34.97), Longitude: lat(138.38)},
+		{ID: 3, Name: "横浜倉庫", Status: models.WarehouseStatusActive, Latitude: lat(35.44), Longitude: lat(139.64)},
+	}
+
+	repos.replenishment.On("ListReorderSettings", ctx).Return([]*models.ReorderSetting{setting}, nil)
+	repos.inventory.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 25, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	repos.inventory.On("GetInventoryByProductLocation", ctx, int64(1), "静岡倉庫").Return([]*models.Inventory{
+		{ID: 2, ProductID: 1, Quantity: 500, Location: "静岡倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	// 横浜倉庫は近いが、自身の発注点を下回らない余剰が足りない
+	repos.inventory.On("GetInventoryByProductLocation", ctx, int64(1), "横浜倉庫").Return([]*models.Inventory{
+		{ID: 3, ProductID: 1, Quantity: 80, Location: "横浜倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	repos.reservations.On("SumActiveReserved", ctx, int64(1), mock.Anything).Return(5, nil)
+	repos.replenishment.On("GetOpenProposal", ctx, int64(1), "東京倉庫").Return(nil, repository.ErrNotFound)
+	repos.warehouses.On("ListWarehouses", ctx).Return(warehouses, nil)
+	repos.replenishment.On("GetReorderSetting", ctx, int64(1), "静岡倉庫").Return(nil, repository.ErrNotFound)
+	repos.replenishment.On("GetReorderSetting", ctx, int64(1), "横浜倉庫").Return(&models.ReorderSetting{ReorderPoint: 40}, nil)
+	repos.replenishment.On("CreateProposal", ctx, mock.AnythingOfType("*models.ReplenishmentProposal")).Return(nil)
+	repos.replenishment.On("ListAlertRecipientIDs", ctx).Return([]int64{7}, nil)
+	repos.notify.On("NotifyReplenishment", ctx, int64(7), mock.AnythingOfType("*models.ReplenishmentProposal")).Return(nil)
+
+	result, err := service.Evaluate(ctx, time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Evaluated)
+	if assert.Len(t, result.Proposals, 1) {
+		proposal := result.Proposals[0]
+		assert.Equal(t, models.ReplenishmentProposalTransfer, proposal.Type)
+		assert.Equal(t, "静岡倉庫", proposal.SourceLocation)
+		// 引当可能数20は発注点30と安全在庫10の合計まで20不足するが、発注数量50のほうが多い
+		assert.Equal(t, 50, proposal.Quantity)
+		assert.Equal(t, 20, proposal.AvailableToPromise)
+	}
+	repos.notify.AssertExpectations(t)
+}
+
+func TestEvaluate_MarksOutOfStockAndProposesPurchase(t *testing.T) {
+	service, repos := newTestReplenishmentService()
+
+	ctx := context.Background()
+	setting := &models.ReorderSetting{ID: 1, ProductID: 1, Location: "東京倉庫", ReorderPoint: 30, SafetyStock: 10, ReorderQuantity: 20}
+	row := &models.Inventory{ID: 1, ProductID: 1, Quantity: 0, Location: "東京倉庫", Status: models.InventoryStatusAvailable}
+
+	repos.replenishment.On("ListReorderSettings", ctx).Return([]*models.ReorderSetting{setting}, nil)
+	repos.inventory.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{row}, nil)
+	repos.inventory.On("UpdateInventory", ctx, row).Return(nil)
+	repos.reservations.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	repos.replenishment.On("GetOpenProposal", ctx, int64(1), "東京倉庫").Return(nil, repository.ErrNotFound)
+	repos.warehouses.On("ListWarehouses", ctx).Return([]*models.Warehouse{{ID: 1, Name: "東京倉庫", Status: models.WarehouseStatusActive}}, nil)
+	repos.replenishment.On("CreateProposal", ctx, mock.MatchedBy(func(p *models.ReplenishmentProposal) bool {
+		return p.Type == models.ReplenishmentProposalPurchase && p.Quantity == 40
+	})).Return(nil)
+	repos.replenishment.On("ListAlertRecipientIDs", ctx).Return([]int64{}, nil)
+
+	result, err := service.Evaluate(ctx, time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.OutOfStock)
+	assert.Equal(t, models.InventoryStatusOutOfStock, row.Status)
+	assert.Len(t, result.Proposals, 1)
+	repos.replenishment.AssertExpectations(t)
+}
+
+func TestEvaluate_SkipsWhenOpenProposalExists(t *testing.T) {
+	service, repos := newTestReplenishmentService()
+
+	ctx := context.Background()
+	setting := &models.ReorderSetting{ID: 1, ProductID: 1, Location: "東京倉庫", ReorderPoint: 30, ReorderQuantity: 20}
+
+	repos.replenishment.On("ListReorderSettings", ctx).Return([]*models.ReorderSetting{setting}, nil)
+	repos.inventory.On("GetInventoryByProductLocation", ctx, int64(1), "東京倉庫").Return([]*models.Inventory{
+		{ID: 1, ProductID: 1, Quantity: 10, Location: "東京倉庫", Status: models.InventoryStatusAvailable},
+	}, nil)
+	repos.reservations.On("SumActiveReserved", ctx, int64(1), "東京倉庫").Return(0, nil)
+	repos.replenishment.On("GetOpenProposal", ctx, int64(1), "東京倉庫").Return(&models.ReplenishmentProposal{ID: 9, Status: models.ReplenishmentProposalOpen}, nil)
+
+	result, err := service.Evaluate(ctx, time.Now())
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Proposals)
+	repos.replenishment.AssertNotCalled(t, "CreateProposal", mock.Anything, mock.Anything)
+	repos.notify.AssertNotCalled(t, "NotifyReplenishment", mock.Anything, mock.Anything, mock.Anything)
+}
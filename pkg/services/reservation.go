@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 在庫引当サービス
+ * 受注から出荷までの在庫引当（確保・解放・確定・期限切れ）を実装する
+ */
+
+// DefaultReservationTTL 在庫引当の既定有効期間（配送に紐づかない引当に適用する）
+const DefaultReservationTTL = 72 * time.Hour
+
+// stockKey 在庫のロックの単位（商品・ロケーション）
+type stockKey struct {
+	ProductID int64
+	Location  string
+}
+
+// lockStocks トランザクション内で複数の商品・ロケーションの在庫のロックを取得する
+// 並行するトランザクションがロックを待ち合ってデッドロックしないよう、商品ID・ロケーションの順に並べて1つずつ取得する
+func lockStocks(ctx context.Context, tx *repository.TxRepositories, keys []stockKey) error {
+	sorted := append([]stockKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ProductID != sorted[j].ProductID {
+			return sorted[i].ProductID < sorted[j].ProductID
+		}
+		return sorted[i].Location < sorted[j].Location
+	})
+
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		if err := tx.Reservations.LockStock(ctx, key.ProductID, key.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockLocationStock トランザクション内で商品・ロケーション単位の在庫引当・出庫を直列化し、販売可能在庫を取得する
+// ロックの取得後に在庫を読むことで、並行する引当・出庫と同じ在庫を引当可能数として数えないようにする
+func lockLocationStock(ctx context.Context, tx *repository.TxRepositories, productID int64, location string) (*locationStock, error) {
+	if err := tx.Reservations.LockStock(ctx, productID, location); err != nil {
+		return nil, err
+	}
+	return loadLocationStock(ctx, tx.Inventory, productID, location)
+}
+
+// checkUnreservedStock ロケーションの販売可能在庫から、引当中の数量を除いて数量を払い出せるか確認する
+// stockはロケーション全体の販売可能在庫で、lotIDを指定した場合はロット単位の引当可能数も確認する
+func checkUnreservedStock(ctx context.Context, tx *repository.TxRepositories, stock *locationStock, lotID *int64, quantity int) error {
+	reserved, err := tx.Reservations.SumActiveReserved(ctx, stock.ProductID, stock.Location)
+	if err != nil {
+		return fmt.Errorf("引当数量取得エラー: %v", err)
+	}
+	if stock.OnHand()-reserved < quantity {
+		logger.Warn("引当済み在庫の不足", map[string]interface{}{
+			"product_id": stock.ProductID,
+			"location":   stock.Location,
+			"on_hand":    stock.OnHand(),
+			"reserved":   reserved,
+			"required":   quantity,
+		})
+		return fmt.Errorf("引当済みの在庫を除くと在庫が不足しています")
+	}
+
+	if lotID != nil {
+		lotReserved, err := tx.Reservations.SumActiveReservedByLot(ctx, *lotID, stock.Location)
+		if err != nil {
+			return fmt.Errorf("ロット引当数量取得エラー: %v", err)
+		}
+		if stock.ForLot(lotID).OnHand()-lotReserved < quantity {
+			return fmt.Errorf("引当済みの在庫を除くとロットの在庫が不足しています")
+		}
+	}
+
+	return nil
+}
+
+// reserveStock トランザクション内で在庫を引き当てる
+// stockはlockLocationStockでロックしてから取得しておくこと
+// reservationには数量・ロット・紐づけ先・有効期限を設定しておき、商品とロケーションは在庫から設定する
+// 引当可能数（在庫数 - 引当中数量）が不足している場合はエラーを返す
+func reserveStock(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	stock *locationStock,
+	reservation *models.Reservation,
+) error {
+	reserved, err := tx.Reservations.SumActiveReserved(ctx, stock.ProductID, stock.Location)
+	if err != nil {
+		return fmt.Errorf("引当数量取得エラー: %v", err)
+	}
+
+	if stock.OnHand()-reserved < reservation.Quantity {
+		logger.Warn("引当可能数不足", map[string]interface{}{
+			"product_id": stock.ProductID,
+			"location":   stock.Location,
+			"on_hand":    stock.OnHand(),
+			"reserved":   reserved,
+			"required":   reservation.Quantity,
+		})
+		return &models.InsufficientStockError{
+			ProductID: stock.ProductID,
+			Required:  reservation.Quantity,
+			Available: stock.OnHand() - reserved,
+		}
+	}
+
+	// ロットを指定する場合はロット単位の引当可能数も確認する
+	if reservation.LotID != nil {
+		lotReserved, err := tx.Reservations.SumActiveReservedByLot(ctx, *reservation.LotID, stock.Location)
+		if err != nil {
+			return fmt.Errorf("ロット引当数量取得エラー: %v", err)
+		}
+
+		lotOnHand := stock.ForLot(reservation.LotID).OnHand()
+		if lotOnHand-lotReserved < reservation.Quantity {
+			logger.Warn("ロット引当可能数不足", map[string]interface{}{
+				"product_id": stock.ProductID,
+				"location":   stock.Location,
+				"lot_id":     *reservation.LotID,
+				"on_hand":    lotOnHand,
+				"reserved":   lotReserved,
+				"required":   reservation.Quantity,
+			})
+			return &models.InsufficientStockError{
+				ProductID: stock.ProductID,
+				LotID:     reservation.LotID,
+				Required:  reservation.Quantity,
+				Available: lotOnHand - lotReserved,
+			}
+		}
+	}
+
+	reservation.ProductID = stock.ProductID
+	reservation.Location = stock.Location
+	reservation.Status = models.ReservationStatusActive
+
+	if err := tx.Reservations.CreateReservation(ctx, reservation); err != nil {
+		return fmt.Errorf("在庫引当作成エラー: %v", err)
+	}
+
+	return nil
+}
+
+// releaseReservation トランザクション内で在庫引当を解放する
+func releaseReservation(ctx context.Context, tx *repository.TxRepositories, reservation *models.Reservation) error {
+	if reservation.Status != models.ReservationStatusActive {
+		return fmt.Errorf("引当中の在庫引当のみ解放できます")
+	}
+
+	if err := tx.Reservations.UpdateReservationStatus(ctx, reservation.ID, models.ReservationStatusReleased); err != nil {
+		return fmt.Errorf("在庫引当解放エラー: %v", err)
+	}
+
+	reservation.Status = models.ReservationStatusReleased
+	return nil
+}
+
+// reduceReservation トランザクション内で在庫引当の数量を減らす
+// 数量が0になる場合は在庫引当を解放する
+func reduceReservation(ctx context.Context, tx *repository.TxRepositories, reservation *models.Reservation, quantity int) error {
+	if reservation.Status != models.ReservationStatusActive {
+		return fmt.Errorf("引当中の在庫引当のみ変更できます")
+	}
+
+	if quantity >= reservation.Quantity {
+		return releaseReservation(ctx, tx, reservation)
+	}
+
+	if err := tx.Reservations.UpdateReservationQuantity(ctx, reservation.ID, reservation.Quantity-quantity); err != nil {
+		return fmt.Errorf("在庫引当数量更新エラー: %v", err)
+	}
+
+	reservation.Quantity -= quantity
+	return nil
+}
+
+// commitReservation トランザクション内で在庫引当を確定し、出庫の在庫移動を記録して在庫数を減らす
+// 配送明細の引当でロット管理している在庫を払い出した場合は、出荷ロットを記録する
+func commitReservation(ctx context.Context, tx *repository.TxRepositories, reservation *models.Reservation) error {
+	if reservation.Status != models.ReservationStatusActive {
+		return fmt.Errorf("引当中の在庫引当のみ確定できます")
+	}
+
+	// 棚卸中のロケーションからは出荷できない
+	if err := checkLocationNotFrozen(ctx, tx, reservation.Location); err != nil {
+		return err
+	}
+
+	stock, err := loadLocationStock(ctx, tx.Inventory, reservation.ProductID, reservation.Location)
+	if err != nil {
+		return fmt.Errorf("引当在庫取得エラー: %v", err)
+	}
+	if reservation.LotID != nil {
+		stock = stock.ForLot(reservation.LotID)
+	}
+
+	// 出庫の在庫移動を記録し、払い出しを在庫元帳上でこの移動に紐付ける
+	movement := &models.InventoryMovement{
+		ProductID:       reservation.ProductID,
+		FromLocation:    reservation.Location,
+		LotID:           reservation.LotID,
+		Quantity:        reservation.Quantity,
+		MovementType:    models.MovementTypeOutbound,
+		MovementDate:    time.Now(),
+		ReferenceNumber: reservation.ReferenceNumber,
+	}
+	if err := tx.Inventory.CreateMovement(ctx, movement); err != nil {
+		return fmt.Errorf("出庫在庫移動作成エラー: %v", err)
+	}
+
+	draws, err := consumeLocationStock(repository.WithLedgerMovement(ctx, movement.ID), tx.Inventory, stock, reservation.Quantity)
+	if err != nil {
+		return err
+	}
+
+	if reservation.DeliveryItemID != nil {
+		lotIDs, quantities := lotQuantities(draws)
+		for _, lotID := range lotIDs {
+			itemLot := &models.DeliveryItemLot{
+				DeliveryItemID: *reservation.DeliveryItemID,
+				LotID:          lotID,
+				Quantity:       quantities[lotID],
+			}
+			if err := tx.Lots.CreateDeliveryItemLot(ctx, itemLot); err != nil {
+				return fmt.Errorf("出荷ロット記録エラー: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Reservations.UpdateReservationStatus(ctx, reservation.ID, models.ReservationStatusCommitted); err != nil {
+		return fmt.Errorf("在庫引当確定エラー: %v", err)
+	}
+
+	reservation.Status = models.ReservationStatusCommitted
+	return nil
+}
+
+// Reserve 在庫を引き当てる
+func (s *InventoryService) Reserve(ctx context.Context, req *models.CreateReservationRequest) (*models.Reservation, error) {
+	ttl := DefaultReservationTTL
+	if req.TTLMinutes > 0 {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	var reservation *models.Reservation
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		stock, err := lockLocationStock(ctx, tx, req.ProductID, req.Location)
+		if err != nil {
+			return err
+		}
+
+		reservation = &models.Reservation{
+			Quantity:        req.Quantity,
+			ReferenceNumber: req.ReferenceNumber,
+			ExpiresAt:       &expiresAt,
+		}
+		return reserveStock(ctx, tx, stock, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("在庫引当完了", map[string]interface{}{
+		"reservation_id":   reservation.ID,
+		"product_id":       reservation.ProductID,
+		"location":         reservation.Location,
+		"quantity":         reservation.Quantity,
+		"reference_number": reservation.ReferenceNumber,
+		"expires_at":       expiresAt,
+	})
+
+	return reservation, nil
+}
+
+// Release 在庫引当を解放する
+func (s *InventoryService) Release(ctx context.Context, id int64) error {
+	return s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		reservation, err := tx.Reservations.GetReservation(ctx, id)
+		if err != nil {
+			return fmt.Errorf("在庫引当取得エラー: %v", err)
+		}
+
+		return releaseReservation(ctx, tx, reservation)
+	})
+}
+
+// Commit 在庫引当を確定する
+func (s *InventoryService) Commit(ctx context.Context, id int64) error {
+	return s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		reservation, err := tx.Reservations.GetReservation(ctx, id)
+		if err != nil {
+			return fmt.Errorf("在庫引当取得エラー: %v", err)
+		}
+
+		if reservation.IsExpired(time.Now()) {
+			return fmt.Errorf("在庫引当の有効期限が切れています")
+		}
+
+		return commitReservation(ctx, tx, reservation)
+	})
+}
+
+// ExpireReservations 有効期限を過ぎた在庫引当を期限切れにする
+func (s *InventoryService) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	reservations, err := s.reservationRepo.ListExpiredReservations(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("期限切れ引当取得エラー: %v", err)
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		if err := s.reservationRepo.ExpireReservation(ctx, reservation.ID, now); err != nil {
+			// 並行して解放・確定された引当や、有効期限が延長・解除された引当は対象外とする
+			if err == repository.ErrNotFound {
+				continue
+			}
+			return expired, fmt.Errorf("在庫引当期限切れ更新エラー: %v", err)
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// StartReservationExpiry 在庫引当の期限切れ処理を定期実行する
+func (s *InventoryService) StartReservationExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("在庫引当の期限切れ処理を停止しました")
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireReservations(ctx, time.Now())
+			if err != nil {
+				logger.Error("在庫引当の期限切れ処理エラー", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if expired > 0 {
+				logger.Info("在庫引当を期限切れにしました", map[string]interface{}{
+					"expired_count": expired,
+				})
+			}
+		}
+	}
+}
+
+// GetAvailability ロケーション単位の在庫可用性を取得する
+func (s *InventoryService) GetAvailability(ctx context.Context, productID int64, location string) (*models.InventoryAvailability, error) {
+	stock, err := loadLocationStock(ctx, s.repo, productID, location)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.reservationRepo.SumActiveReserved(ctx, productID, location)
+	if err != nil {
+		return nil, fmt.Errorf("引当数量取得エラー: %v", err)
+	}
+
+	return &models.InventoryAvailability{
+		ProductID:          productID,
+		Location:           location,
+		OnHand:             stock.OnHand(),
+		Reserved:           reserved,
+		AvailableToPromise: stock.OnHand() - reserved,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 配送ルートサービス
+ * 車両の1日の配送ルートの取得と再計算を実装する
+ */
+
+// RouteService 配送ルートサービス
+type RouteService struct {
+	repo           repository.RouteRepository
+	planner        *RoutePlanner
+	vehicleService *VehicleService
+	uow            repository.UnitOfWork
+}
+
+// NewRouteService 配送ルートサービスを作成する
+func NewRouteService(
+	repo repository.RouteRepository,
+	planner *RoutePlanner,
+	vehicleService *VehicleService,
+	uow repository.UnitOfWork,
+) *RouteService {
+	return &RouteService{
+		repo:           repo,
+		planner:        planner,
+		vehicleService: vehicleService,
+		uow:            uow,
+	}
+}
+
+// GetVehicleRoute 車両の指定した日（dateのタイムゾーン）の保存済みの配送ルートを取得する
+func (s *RouteService) GetVehicleRoute(ctx context.Context, vehicleID int64, date time.Time) (*models.VehicleRoute, error) {
+	if _, err := s.vehicleService.GetVehicle(ctx, vehicleID); err != nil {
+		return nil, err
+	}
+
+	day := date.Format("2006-01-02")
+	routes, err := s.repo.ListVehicleRoute(ctx, vehicleID, day)
+	if err != nil {
+		return nil, fmt.Errorf("配送ルート取得エラー: %v", err)
+	}
+
+	return newVehicleRoute(vehicleID, day, routes), nil
+}
+
+// PlanVehicleRoute 車両の指定した日の配送ルートを計算し直して保存する
+// 配送先の位置情報や距離プロバイダの結果が変わった場合など、割当の変更以外の理由で計算し直すために使用する
+func (s *RouteService) PlanVehicleRoute(ctx context.Context, vehicleID int64, date time.Time) (*models.VehicleRoute, error) {
+	var route *models.VehicleRoute
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		// 車両の行をロックし、割当の変更による再計算と直列化する
+		if _, err := lockVehicle(ctx, tx, vehicleID); err != nil {
+			return err
+		}
+
+		var err error
+		route, err = s.planner.Replan(ctx, tx, vehicleID, date)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
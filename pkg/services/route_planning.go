@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 配送ルート計画
+ * 車両の1日の配送先の訪問順を最近傍法と2-optで求め、到着予定日時とともに配送ルートとして保存する
+ */
+
+const (
+	// DefaultRouteSpeedKmh 大円距離から移動時間を見積もる際の既定の平均速度（km/h）
+	DefaultRouteSpeedKmh = 30.0
+	// DefaultStopServiceTime 配送先1件あたりの既定の荷下ろし・受け渡し時間
+	DefaultStopServiceTime = 10 * time.Minute
+)
+
+// DistanceProvider 地点間の距離と移動時間を提供する
+// 道路距離を返す外部の経路検索サービスなどに差し替えられる
+type DistanceProvider interface {
+	// Travel 出発地から目的地までの距離（km）と移動時間を返す
+	Travel(ctx context.Context, from, to geo.Point) (float64, time.Duration, error)
+}
+
+// HaversineDistanceProvider 大円距離と平均速度から移動時間を見積もる距離プロバイダ
+// 外部サービスを使用しないため、オフラインでも動作する
+type HaversineDistanceProvider struct {
+	// SpeedKmh 平均速度（km/h、0以下の場合はDefaultRouteSpeedKmh）
+	SpeedKmh float64
+}
+
+// Travel 2地点間の大円距離と、平均速度で移動した場合の移動時間を返す
+func (p HaversineDistanceProvider) Travel(ctx context.Context, from, to geo.Point) (float64, time.Duration, error) {
+	speed := p.SpeedKmh
+	if speed <= 0 {
+		speed = DefaultRouteSpeedKmh
+	}
+	km := geo.DistanceKm(from, to)
+	return km, time.Duration(km / speed * float64(time.Hour)), nil
+}
+
+// RoutePlanner 配送ルート計画
+type RoutePlanner struct {
+	distance    DistanceProvider
+	serviceTime time.Duration
+}
+
+// NewRoutePlanner 配送ルート計画を作成する
+// distanceがnilの場合は大円距離（HaversineDistanceProvider）を使用する
+func NewRoutePlanner(distance DistanceProvider) *RoutePlanner {
+	if distance == nil {
+		distance = HaversineDistanceProvider{}
+	}
+	return &RoutePlanner{distance: distance, serviceTime: DefaultStopServiceTime}
+}
+
+// routeStop 配送ルートの訪問先の候補
+type routeStop struct {
+	schedule *models.DeliverySchedule
+	delivery *models.Delivery
+}
+
+// routeLeg 地点間の距離と移動時間
+type routeLeg struct {
+	km       float64
+	duration time.Duration
+}
+
+// Replan トランザクション内で車両の指定した日（サーバーのタイムゾーン）の配送ルートを計算し直して保存する
+//
+// 対象はその日に開始する予定中の配送スケジュールの配送で、出荷元倉庫（最も早い配送スケジュールの配送の
+// 出荷元、位置情報がない場合は最初の訪問先）から最も早い配送スケジュールの開始日時に出発する。
+// 到着が配送スケジュールの開始日時より早い訪問先では開始日時まで待機し、各訪問先で荷下ろしの時間を見込む。
+// 配送先の位置情報がない配送は訪問順の最後にunlocatedとして記録する
+func (p *RoutePlanner) Replan(ctx context.Context, tx *repository.TxRepositories, vehicleID int64, day time.Time) (*models.VehicleRoute, error) {
+	local := day.Local()
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	date := from.Format("2006-01-02")
+
+	located, unlocated, err := p.routeStops(ctx, tx, vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*models.Route
+	if len(located) > 0 {
+		if routes, err = p.planStops(ctx, tx, located); err != nil {
+			return nil, err
+		}
+	}
+	for _, stop := range unlocated {
+		routes = append(routes, &models.Route{
+			DeliveryID: stop.delivery.ID,
+			Sequence:   len(routes) + 1,
+			Location:   stop.delivery.ToAddress,
+			Status:     models.RouteStatusUnlocated,
+		})
+	}
+
+	if err := tx.Routes.ReplaceVehicleRoute(ctx, vehicleID, date, routes); err != nil {
+		return nil, fmt.Errorf("配送ルート保存エラー: %v", err)
+	}
+
+	return newVehicleRoute(vehicleID, date, routes), nil
+}
+
+// routeStops 車両の期間内に開始する予定中の配送スケジュールの配送を、位置情報の有無で分けて開始日時の順に返す
+// 同じ配送の配送スケジュールが複数ある場合は最も早いものを使用する
+func (p *RoutePlanner) routeStops(ctx context.Context, tx *repository.TxRepositories, vehicleID int64, from, to time.Time) ([]*routeStop, []*routeStop, error) {
+	entries, err := tx.Schedules.ListVehicleSchedules(ctx, vehicleID, from, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+
+	var located, unlocated []*routeStop
+	seen := make(map[int64]bool)
+	for _, entry := range entries {
+		schedule := entry.DeliverySchedule
+		if schedule.Status != models.ScheduleStatusScheduled || schedule.StartTime.Before(from) || !schedule.StartTime.Before(to) {
+			continue
+		}
+		if seen[schedule.DeliveryID] {
+			continue
+		}
+		seen[schedule.DeliveryID] = true
+
+		delivery, err := tx.Deliveries.GetDelivery(ctx, schedule.DeliveryID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("配送取得エラー: %v", err)
+		}
+
+		stop := &routeStop{schedule: schedule, delivery: delivery}
+		if delivery.HasDestinationCoordinates() {
+			located = append(located, stop)
+		} else {
+			unlocated = append(unlocated, stop)
+		}
+	}
+
+	return located, unlocated, nil
+}
+
+// planStops 訪問先の訪問順を決め、出発地からの距離・移動時間と到着予定日時を計算する
+func (p *RoutePlanner) planStops(ctx context.Context, tx *repository.TxRepositories, stops []*routeStop) ([]*models.Route, error) {
+	points := make([]geo.Point, 0, len(stops)+1)
+	origin, err := routeOrigin(ctx, tx, stops)
+	if err != nil {
+		return nil, err
+	}
+	points = append(points, origin)
+	departure := stops[0].schedule.StartTime
+	for _, stop := range stops {
+		points = append(points, geo.Point{Latitude: *stop.delivery.ToLatitude, Longitude: *stop.delivery.ToLongitude})
+		if stop.schedule.StartTime.Before(departure) {
+			departure = stop.schedule.StartTime
+		}
+	}
+
+	legs := make([][]routeLeg, len(points))
+	costs := make([][]float64, len(points))
+	for i := range points {
+		legs[i] = make([]routeLeg, len(points))
+		costs[i] = make([]float64, len(points))
+		for j := range points {
+			if i == j {
+				continue
+			}
+			km, duration, err := p.distance.Travel(ctx, points[i], points[j])
+			if err != nil {
+				return nil, fmt.Errorf("距離取得エラー: %v", err)
+			}
+			legs[i][j] = routeLeg{km: km, duration: duration}
+			costs[i][j] = km
+		}
+	}
+
+	order := orderStops(costs)
+	routes := make([]*models.Route, 0, len(stops))
+	at := departure
+	previous := 0
+	for _, index := range order[1:] {
+		stop := stops[index-1]
+		leg := legs[previous][index]
+
+		at = at.Add(leg.duration)
+		if at.Before(stop.schedule.StartTime) {
+			at = stop.schedule.StartTime
+		}
+
+		latitude, longitude := *stop.delivery.ToLatitude, *stop.delivery.ToLongitude
+		routes = append(routes, &models.Route{
+			DeliveryID:  stop.delivery.ID,
+			Sequence:    len(routes) + 1,
+			Location:    stop.delivery.ToAddress,
+			Latitude:    &latitude,
+			Longitude:   &longitude,
+			ArrivalTime: at,
+			Distance:    math.Round(leg.km*100) / 100,
+			Duration:    int(math.Ceil(leg.duration.Minutes())),
+			Status:      models.RouteStatusPlanned,
+		})
+
+		at = at.Add(p.serviceTime)
+		previous = index
+	}
+
+	return routes, nil
+}
+
+// routeOrigin 配送ルートの出発地を返す
+// 最も早い配送スケジュールの配送の出荷元倉庫とし、倉庫の位置情報がない場合は最初の訪問先とする
+func routeOrigin(ctx context.Context, tx *repository.TxRepositories, stops []*routeStop) (geo.Point, error) {
+	first := stops[0]
+	for _, stop := range stops[1:] {
+		if stop.schedule.StartTime.Before(first.schedule.StartTime) {
+			first = stop
+		}
+	}
+
+	warehouse, err := tx.Warehouses.GetWarehouse(ctx, first.delivery.FromWarehouseID)
+	if err != nil {
+		return geo.Point{}, fmt.Errorf("出荷元倉庫取得エラー: %v", err)
+	}
+	if warehouse.HasCoordinates() {
+		return geo.Point{Latitude: *warehouse.Latitude, Longitude: *warehouse.Longitude}, nil
+	}
+	return geo.Point{Latitude: *first.delivery.ToLatitude, Longitude: *first.delivery.ToLongitude}, nil
+}
+
+// orderStops 出発地（0）から訪問先（1〜n）を巡る訪問順を返す（出発地には戻らない）
+// 最近傍法で初期解を作り、2-optで区間を反転して総距離が短くなる限り改善する
+// costs[i][j]はiからjへの距離で、非対称でもよい
+func orderStops(costs [][]float64) []int {
+	n := len(costs)
+	order := make([]int, 0, n)
+	order = append(order, 0)
+	visited := make([]bool, n)
+	visited[0] = true
+
+	// 最近傍法（距離が同じ場合は番号の小さい訪問先を選ぶ）
+	for len(order) < n {
+		current := order[len(order)-1]
+		next := -1
+		for j := 1; j < n; j++ {
+			if !visited[j] && (next < 0 || costs[current][j] < costs[current][next]) {
+				next = j
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+	}
+
+	// 2-opt（出発地は固定）
+	best := pathCost(costs, order)
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				candidate := append([]int(nil), order...)
+				reverse(candidate[i : k+1])
+				if cost := pathCost(costs, candidate); cost < best-1e-9 {
+					order, best, improved = candidate, cost, true
+				}
+			}
+		}
+	}
+
+	return order
+}
+
+// pathCost 訪問順の総距離を返す
+func pathCost(costs [][]float64, order []int) float64 {
+	total := 0.0
+	for i := 1; i < len(order); i++ {
+		total += costs[order[i-1]][order[i]]
+	}
+	return total
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// newVehicleRoute 訪問先から車両の1日の配送ルートを作成する
+func newVehicleRoute(vehicleID int64, date string, routes []*models.Route) *models.VehicleRoute {
+	result := &models.VehicleRoute{VehicleID: vehicleID, Date: date, Stops: []*models.Route{}}
+	for _, route := range routes {
+		if route.Status == models.RouteStatusPlanned {
+			result.TotalDistance += route.Distance
+			result.TotalDuration += route.Duration
+		}
+		result.Stops = append(result.Stops, route)
+	}
+	result.TotalDistance = math.Round(result.TotalDistance*100) / 100
+	return result
+}
+
+// replanRoutes トランザクション内で車両の行をロックし、配送スケジュールの車両・日の配送ルートを計算し直す
+// plannerがnilの場合は何もしない
+func replanRoutes(ctx context.Context, tx *repository.TxRepositories, planner *RoutePlanner, schedules ...*models.DeliverySchedule) error {
+	if planner == nil {
+		return nil
+	}
+
+	type routeKey struct {
+		vehicleID int64
+		date      string
+	}
+	keys := make(map[routeKey]time.Time)
+	for _, schedule := range schedules {
+		day := schedule.StartTime.Local()
+		keys[routeKey{schedule.VehicleID, day.Format("2006-01-02")}] = day
+	}
+
+	// 行ロックの順序を一定にするため、車両・日付の順に計算する
+	sorted := make([]routeKey, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].vehicleID != sorted[j].vehicleID {
+			return sorted[i].vehicleID < sorted[j].vehicleID
+		}
+		return sorted[i].date < sorted[j].date
+	})
+
+	for _, key := range sorted {
+		if _, err := lockVehicle(ctx, tx, key.vehicleID); err != nil {
+			return err
+		}
+		if _, err := planner.Replan(ctx, tx, key.vehicleID, keys[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 配送ルート計画のテスト
+ * 最近傍法と2-optによる訪問順、到着予定日時の計算と、割当の変更時の再計算を検証する
+ */
+
+// planeDistance 緯度・経度を平面の座標（km）とみなし、1kmあたり2分で移動する距離プロバイダ
+type planeDistance struct{}
+
+func (planeDistance) Travel(ctx context.Context, from, to geo.Point) (float64, time.Duration, error) {
+	km := math.Hypot(to.Latitude-from.Latitude, to.Longitude-from.Longitude)
+	return km, time.Duration(km * 2 * float64(time.Minute)), nil
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// routeTestRepos 配送ルート計画のテストで使用するモック
+type routeTestRepos struct {
+	deliveries *mocks.MockDeliveryRepository
+	warehouses *mocks.MockWarehouseRepository
+	vehicles   *mocks.MockVehicleRepository
+	schedules  *mocks.MockScheduleRepository
+	routes     *mocks.MockRouteRepository
+}
+
+func newRouteTestRepos() *routeTestRepos {
+	return &routeTestRepos{
+		deliveries: new(mocks.MockDeliveryRepository),
+		warehouses: new(mocks.MockWarehouseRepository),
+		vehicles:   new(mocks.MockVehicleRepository),
+		schedules:  new(mocks.MockScheduleRepository),
+		routes:     new(mocks.MockRouteRepository),
+	}
+}
+
+func (r *routeTestRepos) tx() *repository.TxRepositories {
+	return &repository.TxRepositories{
+		Deliveries: r.deliveries,
+		Warehouses: r.warehouses,
+		Vehicles:   r.vehicles,
+		Schedules:  r.schedules,
+		Routes:     r.routes,
+	}
+}
+
+// expectStop 配送先の位置情報（nilの場合は未登録）を持つ配送を返すよう設定し、配送スケジュールを返す
+func (r *routeTestRepos) expectStop(id int64, latitude, longitude *float64, start time.Time) *models.VehicleScheduleEntry {
+	r.deliveries.On("GetDelivery", mock.Anything, id).Return(&models.Delivery{
+		ID:              id,
+		Status:          models.DeliveryStatusScheduled,
+		FromWarehouseID: 1,
+		ToAddress:       "配送先" + string(rune('A'+id-1)),
+		ToLatitude:      latitude,
+		ToLongitude:     longitude,
+	}, nil)
+	return &models.VehicleScheduleEntry{DeliverySchedule: &models.DeliverySchedule{
+		ID:         id * 10,
+		DeliveryID: id,
+		VehicleID:  7,
+		StartTime:  start,
+		EndTime:    start.Add(3 * time.Hour),
+		Status:     models.ScheduleStatusScheduled,
+	}}
+}
+
+func TestOrderStops(t *testing.T) {
+	points := [][2]float64{{0, 0}, {-2, -3}, {0, -3}, {3, 3}, {3, 2}}
+	costs := make([][]float64, len(points))
+	for i, a := range points {
+		costs[i] = make([]float64, len(points))
+		for j, b := range points {
+			costs[i][j] = math.Hypot(a[0]-b[0], a[1]-b[1])
+		}
+	}
+
+	// 最近傍法では0→2→1→4→3（約13.07）となり、2-optで交差を解消して最短の0→1→2→4→3（約12.44）にする
+	order := orderStops(costs)
+	assert.Equal(t, []int{0, 1, 2, 4, 3}, order)
+	assert.InDelta(t, 12.44, pathCost(costs, order), 0.01)
+
+	// 訪問先がない場合・1件の場合
+	assert.Equal(t, []int{0}, orderStops([][]float64{{0}}))
+	assert.Equal(t, []int{0, 1}, orderStops([][]float64{{0, 5}, {5, 0}}))
+}
+
+func TestRoutePlanner_Replan(t *testing.T) {
+	ctx := context.Background()
+	planner := NewRoutePlanner(planeDistance{})
+
+	t.Run("近い順に訪問し、開始日時まで待機した到着予定日時を保存する", func(t *testing.T) {
+		repos := newRouteTestRepos()
+		repos.warehouses.On("GetWarehouse", mock.Anything, int64(1)).Return(&models.Warehouse{ID: 1, Latitude: floatPtr(0), Longitude: floatPtr(0)}, nil)
+		entries := []*models.VehicleScheduleEntry{
+			repos.expectStop(1, floatPtr(0), floatPtr(3), localTime(1, 9, 0)),
+			repos.expectStop(2, floatPtr(0), floatPtr(1), localTime(1, 9, 0)),
+			repos.expectStop(3, floatPtr(0), floatPtr(2), localTime(1, 10, 0)),
+			repos.expectStop(4, nil, nil, localTime(1, 9, 0)),
+		}
+		// 取消済み・前日から続く配送スケジュールは対象外とする
+		entries = append(entries,
+			&models.VehicleScheduleEntry{DeliverySchedule: &models.DeliverySchedule{ID: 50, DeliveryID: 5, VehicleID: 7, StartTime: localTime(1, 9, 0), Status: models.ScheduleStatusCancelled}},
+			&models.VehicleScheduleEntry{DeliverySchedule: &models.DeliverySchedule{ID: 60, DeliveryID: 6, VehicleID: 7, StartTime: localTime(0, 22, 0), Status: models.ScheduleStatusScheduled}},
+		)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), localTime(1, 0, 0), localTime(2, 0, 0)).Return(entries, nil)
+		var saved []*models.Route
+		repos.routes.On("ReplaceVehicleRoute", mock.Anything, int64(7), "2024-05-01", mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(3).([]*models.Route)
+		}).Return(nil)
+
+		route, err := planner.Replan(ctx, repos.tx(), 7, localTime(1, 15, 0))
+		require.NoError(t, err)
+		require.Len(t, saved, 4)
+		assert.Equal(t, saved, route.Stops)
+
+		var ids []int64
+		for _, stop := range saved {
+			ids = append(ids, stop.DeliveryID)
+		}
+		assert.Equal(t, []int64{2, 3, 1, 4}, ids)
+
+		// 9:00に出発し、1km（2分）ごとに移動して各訪問先で10分の荷下ろしを見込む
+		assert.Equal(t, localTime(1, 9, 2), saved[0].ArrivalTime)
+		// 9:14に到着するが、配送スケジュールの開始日時の10:00まで待機する
+		assert.Equal(t, localTime(1, 10, 0), saved[1].ArrivalTime)
+		assert.Equal(t, localTime(1, 10, 12), saved[2].ArrivalTime)
+		for i, stop := range saved[:3] {
+			assert.Equal(t, i+1, stop.Sequence)
+			assert.Equal(t, models.RouteStatusPlanned, stop.Status)
+			assert.Equal(t, 1.0, stop.Distance)
+			assert.Equal(t, 2, stop.Duration)
+		}
+
+		// 位置情報のない配送は最後に到着予定日時なしで記録する
+		assert.Equal(t, 4, saved[3].Sequence)
+		assert.Equal(t, models.RouteStatusUnlocated, saved[3].Status)
+		assert.True(t, saved[3].ArrivalTime.IsZero())
+		assert.Nil(t, saved[3].Latitude)
+
+		assert.Equal(t, 3.0, route.TotalDistance)
+		assert.Equal(t, 6, route.TotalDuration)
+		repos.deliveries.AssertNotCalled(t, "GetDelivery", mock.Anything, int64(5))
+	})
+
+	t.Run("倉庫の位置情報がない場合は最初の配送先から出発する", func(t *testing.T) {
+		repos := newRouteTestRepos()
+		repos.warehouses.On("GetWarehouse", mock.Anything, int64(1)).Return(&models.Warehouse{ID: 1}, nil)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), mock.Anything, mock.Anything).Return([]*models.VehicleScheduleEntry{
+			repos.expectStop(1, floatPtr(0), floatPtr(4), localTime(1, 9, 0)),
+			repos.expectStop(2, floatPtr(0), floatPtr(1), localTime(1, 9, 30)),
+		}, nil)
+		repos.routes.On("ReplaceVehicleRoute", mock.Anything, int64(7), "2024-05-01", mock.Anything).Return(nil)
+
+		route, err := planner.Replan(ctx, repos.tx(), 7, localTime(1, 0, 0))
+		require.NoError(t, err)
+		require.Len(t, route.Stops, 2)
+		assert.Equal(t, int64(1), route.Stops[0].DeliveryID)
+		assert.Equal(t, 0.0, route.Stops[0].Distance)
+		assert.Equal(t, localTime(1, 9, 0), route.Stops[0].ArrivalTime)
+		// 9:10に出発して6分で到着するが、開始日時の9:30まで待機する
+		assert.Equal(t, localTime(1, 9, 30), route.Stops[1].ArrivalTime)
+		assert.Equal(t, 3.0, route.TotalDistance)
+	})
+
+	t.Run("配送スケジュールがなければ配送ルートを空にする", func(t *testing.T) {
+		repos := newRouteTestRepos()
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), mock.Anything, mock.Anything).Return([]*models.VehicleScheduleEntry{}, nil)
+		repos.routes.On("ReplaceVehicleRoute", mock.Anything, int64(7), "2024-05-01", []*models.Route(nil)).Return(nil)
+
+		route, err := planner.Replan(ctx, repos.tx(), 7, localTime(1, 12, 0))
+		require.NoError(t, err)
+		assert.Empty(t, route.Stops)
+		repos.routes.AssertExpectations(t)
+	})
+}
+
+func TestHaversineDistanceProvider(t *testing.T) {
+	tokyo := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+	shizuoka := geo.Point{Latitude: 34.9756, Longitude: 138.3828}
+
+	km, duration, err := HaversineDistanceProvider{}.Travel(context.Background(), tokyo, shizuoka)
+	require.NoError(t, err)
+	assert.InDelta(t, 147, km, 2)
+	// 既定の平均速度（30km/h）で約4.9時間
+	assert.InDelta(t, km/DefaultRouteSpeedKmh, duration.Hours(), 0.001)
+}
+
+func TestDeliveryService_CancelReplansRoute(t *testing.T) {
+	ctx := context.Background()
+	repos := newRouteTestRepos()
+	reservations := newDefaultReservationRepo()
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries:   repos.deliveries,
+		Reservations: reservations,
+		Warehouses:   repos.warehouses,
+		Vehicles:     repos.vehicles,
+		Schedules:    repos.schedules,
+		Routes:       repos.routes,
+	})
+	service := NewDeliveryService(repos.deliveries, new(mocks.MockInventoryRepository), uow, nil, NewRoutePlanner(planeDistance{}), nil)
+
+	delivery := &models.Delivery{ID: 1, Status: models.DeliveryStatusScheduled, FromWarehouseID: 1}
+	repos.deliveries.On("GetDelivery", mock.Anything, int64(1)).Return(delivery, nil)
+	reservations.On("ListReservationsByDelivery", mock.Anything, int64(1)).Return([]*models.Reservation{}, nil)
+	repos.deliveries.On("ListDeliveryItems", mock.Anything, int64(1)).Return([]*models.DeliveryItem{}, nil)
+	repos.deliveries.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+	repos.deliveries.On("CreateStatusHistory", mock.Anything, mock.Anything).Return(nil)
+	repos.schedules.On("CancelDeliverySchedules", mock.Anything, int64(1)).Return([]*models.DeliverySchedule{
+		{ID: 10, DeliveryID: 1, VehicleID: 7, StartTime: localTime(1, 9, 0), Status: models.ScheduleStatusCancelled},
+	}, nil)
+	repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7}, nil)
+	// 残りの配送の配送ルートを計算し直す
+	repos.warehouses.On("GetWarehouse", mock.Anything, int64(1)).Return(&models.Warehouse{ID: 1, Latitude: floatPtr(0), Longitude: floatPtr(0)}, nil)
+	repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), localTime(1, 0, 0), localTime(2, 0, 0)).Return([]*models.VehicleScheduleEntry{
+		repos.expectStop(2, floatPtr(0), floatPtr(1), localTime(1, 9, 0)),
+	}, nil)
+	repos.routes.On("ReplaceVehicleRoute", mock.Anything, int64(7), "2024-05-01", mock.MatchedBy(func(routes []*models.Route) bool {
+		return len(routes) == 1 && routes[0].DeliveryID == 2
+	})).Return(nil)
+
+	result, err := service.UpdateDeliveryStatus(ctx, 1, &models.UpdateDeliveryStatusRequest{Status: models.DeliveryStatusCancelled}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusCancelled, result.Status)
+	repos.vehicles.AssertExpectations(t)
+	repos.routes.AssertExpectations(t)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 配送スケジューリングサービス
+ * 配送への運転手・車両の割当（重複割当・シフト時間外の検出）と、運転手の1日の配送予定表を実装する
+ */
+
+// SchedulingService 配送スケジューリングサービス
+type SchedulingService struct {
+	scheduleRepo    repository.ScheduleRepository
+	driverService   *DriverService
+	vehicleService  *VehicleService
+	deliveryService *DeliveryService
+	uow             repository.UnitOfWork
+}
+
+// NewSchedulingService 配送スケジューリングサービスを作成する
+func NewSchedulingService(
+	scheduleRepo repository.ScheduleRepository,
+	driverService *DriverService,
+	vehicleService *VehicleService,
+	deliveryService *DeliveryService,
+	uow repository.UnitOfWork,
+) *SchedulingService {
+	return &SchedulingService{
+		scheduleRepo:    scheduleRepo,
+		driverService:   driverService,
+		vehicleService:  vehicleService,
+		deliveryService: deliveryService,
+		uow:             uow,
+	}
+}
+
+// ScheduleDelivery 配送に運転手と車両を割り当て、配送スケジュールを作成する
+// 運転手の確認に加えて、車両への割当と同じ確認（ステータス・設備・整備予定・積載量）を行う
+//
+// 同じトランザクション内で車両のその日の配送ルートを計算し直す
+//
+// 運転手が同じ時間帯に別の車両で乗務する場合と、車両が同じ時間帯に別の運転手の乗務に
+// 割り当てられている場合は*models.DoubleBookingErrorを返す（同じ運転手・車両の組み合わせなら
+// 時間帯が重なっても同じ便での複数配送とみなす）。期間が運転手のシフトに収まらない場合は
+// *models.ShiftViolationErrorを、運転手が無効化・免許の期限切れの場合は*models.DriverUnavailableErrorを返す
+func (s *SchedulingService) ScheduleDelivery(ctx context.Context, req *models.ScheduleDeliveryRequest) (*models.DeliverySchedule, error) {
+	schedule, err := s.vehicleService.prepareSchedule(ctx, req.DeliveryID, req.VehicleID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	driverID := req.DriverID
+	schedule.DriverID = &driverID
+
+	// 運転手・車両の順に行をロックし、同じ運転手・車両への割当を直列化する
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := checkDriverAssignment(ctx, tx, schedule); err != nil {
+			return err
+		}
+		if err := s.vehicleService.checkVehicleAssignment(ctx, tx, schedule, req.RequiresRefrigeration); err != nil {
+			return err
+		}
+
+		if err := tx.Schedules.CreateSchedule(ctx, schedule); err != nil {
+			return fmt.Errorf("配送スケジュール作成エラー: %v", err)
+		}
+		return replanRoutes(ctx, tx, s.vehicleService.planner, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetSchedule 配送スケジュールを取得する
+func (s *SchedulingService) GetSchedule(ctx context.Context, id int64) (*models.DeliverySchedule, error) {
+	schedule, err := s.scheduleRepo.GetSchedule(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+
+	return schedule, nil
+}
+
+// GetManifest 運転手の指定した日の配送予定表を取得する
+// 対象は指定した日（dateのタイムゾーン）と、その日に始まるシフトの期間に重なる、取消されていない配送スケジュール
+func (s *SchedulingService) GetManifest(ctx context.Context, driverID int64, date time.Time) (*models.DriverManifest, error) {
+	driver, err := s.driverService.GetDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	shiftStart, shiftEnd, err := driver.ShiftOn(date)
+	if err != nil {
+		return nil, err
+	}
+
+	// 日をまたぐシフトの翌日分も含める
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	to := from.AddDate(0, 0, 1)
+	if shiftEnd.After(to) {
+		to = shiftEnd
+	}
+
+	schedules, err := s.scheduleRepo.ListDriverSchedules(ctx, driverID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+
+	manifest := &models.DriverManifest{
+		Driver:     driver,
+		Date:       from.Format("2006-01-02"),
+		ShiftStart: shiftStart,
+		ShiftEnd:   shiftEnd,
+		Stops:      []*models.ManifestStop{},
+	}
+	vehicles := make(map[int64]*models.Vehicle)
+	for _, schedule := range schedules {
+		if schedule.Status == models.ScheduleStatusCancelled {
+			continue
+		}
+
+		vehicle, ok := vehicles[schedule.VehicleID]
+		if !ok {
+			if vehicle, err = s.vehicleService.GetVehicle(ctx, schedule.VehicleID); err != nil {
+				return nil, err
+			}
+			vehicles[schedule.VehicleID] = vehicle
+		}
+
+		delivery, err := s.deliveryService.GetDelivery(ctx, schedule.DeliveryID)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Stops = append(manifest.Stops, &models.ManifestStop{
+			Sequence:      len(manifest.Stops) + 1,
+			Schedule:      schedule,
+			VehicleNumber: vehicle.VehicleNumber,
+			Delivery:      delivery,
+		})
+		manifest.TotalWeightGrams += schedule.WeightGrams
+		manifest.TotalVolumeML += schedule.VolumeML
+	}
+
+	return manifest, nil
+}
+
+// checkDriverAssignment トランザクション内で運転手の行をロックし、配送スケジュールを運転手に割り当てられることを確認する
+func checkDriverAssignment(ctx context.Context, tx *repository.TxRepositories, schedule *models.DeliverySchedule) error {
+	driver, err := tx.Drivers.LockDriver(ctx, *schedule.DriverID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDriverNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("運転手取得エラー: %v", err)
+	}
+
+	if !driver.Active {
+		return &models.DriverUnavailableError{DriverID: driver.ID, Name: driver.Name, Reason: "無効化されています"}
+	}
+	if !driver.LicenseValidAt(schedule.EndTime) {
+		return &models.DriverUnavailableError{DriverID: driver.ID, Name: driver.Name, Reason: "運転免許の有効期限が切れています"}
+	}
+
+	covered, err := driver.CoversSlot(schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return err
+	}
+	if !covered {
+		return &models.ShiftViolationError{
+			DriverID:   driver.ID,
+			Name:       driver.Name,
+			ShiftStart: driver.ShiftStart,
+			ShiftEnd:   driver.ShiftEnd,
+			StartTime:  schedule.StartTime,
+			EndTime:    schedule.EndTime,
+		}
+	}
+
+	booked, err := tx.Schedules.ListDriverSchedules(ctx, driver.ID, schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+	for _, other := range booked {
+		if other.Status != models.ScheduleStatusScheduled || other.VehicleID == schedule.VehicleID {
+			continue
+		}
+		return &models.DoubleBookingError{
+			Resource:   "運転手",
+			ResourceID: driver.ID,
+			ScheduleID: other.ID,
+			DeliveryID: other.DeliveryID,
+			StartTime:  other.StartTime,
+			EndTime:    other.EndTime,
+		}
+	}
+
+	return nil
+}
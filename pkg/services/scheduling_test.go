@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 配送スケジューリングサービスのテスト
+ * 運転手・車両の重複割当とシフト時間外の検出、運転手の配送予定表を検証する
+ */
+
+// schedulingTestRepos 配送スケジューリングサービスのテストで使用するモック
+type schedulingTestRepos struct {
+	*vehicleTestRepos
+	drivers *mocks.MockDriverRepository
+}
+
+func newTestSchedulingService() (*SchedulingService, *schedulingTestRepos) {
+	repos := &schedulingTestRepos{
+		vehicleTestRepos: &vehicleTestRepos{
+			deliveries: new(mocks.MockDeliveryRepository),
+			vehicles:   new(mocks.MockVehicleRepository),
+			schedules:  new(mocks.MockScheduleRepository),
+		},
+		drivers: new(mocks.MockDriverRepository),
+	}
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries: repos.deliveries,
+		Variants:   new(mocks.MockProductVariantRepository),
+		Vehicles:   repos.vehicles,
+		Schedules:  repos.schedules,
+		Drivers:    repos.drivers,
+	})
+	deliveryService := NewDeliveryService(repos.deliveries, new(mocks.MockInventoryRepository), uow, new(mocks.MockNotificationService), nil, nil)
+	vehicleService := NewVehicleService(repos.vehicles, repos.schedules, deliveryService, nil, uow)
+	service := NewSchedulingService(repos.schedules, NewDriverService(repos.drivers), vehicleService, deliveryService, uow)
+	return service, repos
+}
+
+// localTime サーバーのタイムゾーンの日時を作成する（シフトはサーバーのタイムゾーンで解釈する）
+func localTime(day, hour, min int) time.Time {
+	return time.Date(2024, 5, day, hour, min, 0, 0, time.Local)
+}
+
+// expectAssignableVehicle 整備予定・割当のない車両を返すよう設定する
+func (r *schedulingTestRepos) expectAssignableVehicle(vehicleID int64, entries []*models.VehicleScheduleEntry) {
+	r.vehicles.On("LockVehicle", mock.Anything, vehicleID).Return(&models.Vehicle{ID: vehicleID, VehicleNumber: "静岡100あ1234", Capacity: 1000, Status: models.VehicleStatusAvailable}, nil)
+	r.vehicles.On("ListMaintenance", mock.Anything, vehicleID).Return([]*models.VehicleMaintenance{}, nil)
+	r.schedules.On("ListVehicleSchedules", mock.Anything, vehicleID, mock.Anything, mock.Anything).Return(entries, nil)
+}
+
+func TestSchedulingService_ScheduleDelivery(t *testing.T) {
+	ctx := context.Background()
+	dayShift := &models.Driver{ID: 3, UserID: 30, Name: "山田太郎", ShiftStart: "08:00", ShiftEnd: "17:00", Active: true}
+	req := &models.ScheduleDeliveryRequest{
+		DeliveryID: 1,
+		DriverID:   3,
+		VehicleID:  7,
+		StartTime:  localTime(1, 9, 0),
+		EndTime:    localTime(1, 12, 0),
+	}
+
+	t.Run("シフト内で重複がなければ割り当てる", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(dayShift, nil)
+		// 同じ車両での乗務は同じ便での複数配送とみなす
+		repos.schedules.On("ListDriverSchedules", mock.Anything, int64(3), req.StartTime, req.EndTime).Return([]*models.DeliverySchedule{
+			{ID: 20, DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusScheduled, StartTime: req.StartTime, EndTime: req.EndTime},
+		}, nil)
+		repos.expectAssignableVehicle(7, []*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{ID: 20, DeliveryID: 2, DriverID: &dayShift.ID, VehicleID: 7, Status: models.ScheduleStatusScheduled}},
+		})
+		repos.schedules.On("CreateSchedule", mock.Anything, mock.AnythingOfType("*models.DeliverySchedule")).Return(nil)
+
+		schedule, err := service.ScheduleDelivery(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, schedule.DriverID)
+		assert.Equal(t, int64(3), *schedule.DriverID)
+		assert.Equal(t, int64(7), schedule.VehicleID)
+		assert.Equal(t, 5000, schedule.WeightGrams)
+		repos.schedules.AssertExpectations(t)
+	})
+
+	t.Run("シフト時間外は割り当てない", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(dayShift, nil)
+
+		late := *req
+		late.StartTime = localTime(1, 15, 0)
+		late.EndTime = localTime(1, 18, 0)
+		_, err := service.ScheduleDelivery(ctx, &late)
+		var shiftErr *models.ShiftViolationError
+		require.ErrorAs(t, err, &shiftErr)
+		assert.Equal(t, "17:00", shiftErr.ShiftEnd)
+		repos.schedules.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("日をまたぐシフトの翌日分に割り当てる", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		nightShift := &models.Driver{ID: 3, Name: "佐藤花子", ShiftStart: "22:00", ShiftEnd: "06:00", Active: true}
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(nightShift, nil)
+		repos.schedules.On("ListDriverSchedules", mock.Anything, int64(3), mock.Anything, mock.Anything).Return([]*models.DeliverySchedule{}, nil)
+		repos.expectAssignableVehicle(7, nil)
+		repos.schedules.On("CreateSchedule", mock.Anything, mock.Anything).Return(nil)
+
+		night := *req
+		night.StartTime = localTime(2, 1, 0)
+		night.EndTime = localTime(2, 5, 30)
+		_, err := service.ScheduleDelivery(ctx, &night)
+		require.NoError(t, err)
+	})
+
+	t.Run("運転手が同じ時間帯に別の車両で乗務する場合は割り当てない", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(dayShift, nil)
+		repos.schedules.On("ListDriverSchedules", mock.Anything, int64(3), req.StartTime, req.EndTime).Return([]*models.DeliverySchedule{
+			{ID: 21, DeliveryID: 4, VehicleID: 8, Status: models.ScheduleStatusCancelled},
+			{ID: 22, DeliveryID: 5, VehicleID: 8, Status: models.ScheduleStatusScheduled},
+		}, nil)
+
+		_, err := service.ScheduleDelivery(ctx, req)
+		var bookingErr *models.DoubleBookingError
+		require.ErrorAs(t, err, &bookingErr)
+		assert.Equal(t, "運転手", bookingErr.Resource)
+		assert.Equal(t, int64(22), bookingErr.ScheduleID)
+		repos.vehicles.AssertNotCalled(t, "LockVehicle", mock.Anything, mock.Anything)
+	})
+
+	t.Run("車両が同じ時間帯に別の運転手の乗務に割り当てられている場合は割り当てない", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(dayShift, nil)
+		repos.schedules.On("ListDriverSchedules", mock.Anything, int64(3), req.StartTime, req.EndTime).Return([]*models.DeliverySchedule{}, nil)
+		otherDriver := int64(4)
+		repos.expectAssignableVehicle(7, []*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{ID: 23, DeliveryID: 6, DriverID: &otherDriver, VehicleID: 7, Status: models.ScheduleStatusScheduled}},
+		})
+
+		_, err := service.ScheduleDelivery(ctx, req)
+		var bookingErr *models.DoubleBookingError
+		require.ErrorAs(t, err, &bookingErr)
+		assert.Equal(t, "車両", bookingErr.Resource)
+		repos.schedules.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("無効化された運転手と免許の期限切れの運転手には割り当てない", func(t *testing.T) {
+		expired := localTime(1, 0, 0).AddDate(0, 0, -1)
+		for _, driver := range []*models.Driver{
+			{ID: 3, ShiftStart: "08:00", ShiftEnd: "17:00", Active: false},
+			{ID: 3, ShiftStart: "08:00", ShiftEnd: "17:00", Active: true, LicenseExpiry: &expired},
+		} {
+			service, repos := newTestSchedulingService()
+			repos.expectDelivery(1, 5000)
+			repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(driver, nil)
+
+			_, err := service.ScheduleDelivery(ctx, req)
+			var unavailableErr *models.DriverUnavailableError
+			require.ErrorAs(t, err, &unavailableErr)
+		}
+	})
+
+	t.Run("存在しない運転手", func(t *testing.T) {
+		service, repos := newTestSchedulingService()
+		repos.expectDelivery(1, 5000)
+		repos.drivers.On("LockDriver", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+
+		_, err := service.ScheduleDelivery(ctx, req)
+		assert.ErrorIs(t, err, ErrDriverNotFound)
+	})
+}
+
+func TestSchedulingService_GetManifest(t *testing.T) {
+	service, repos := newTestSchedulingService()
+	driver := &models.Driver{ID: 3, UserID: 30, Name: "佐藤花子", ShiftStart: "18:00", ShiftEnd: "03:00", Active: true}
+	repos.drivers.On("GetDriver", mock.Anything, int64(3)).Return(driver, nil)
+
+	// 当日0時から、日をまたぐシフトの終了（翌日3時）までの配送スケジュールを取得する
+	repos.schedules.On("ListDriverSchedules", mock.Anything, int64(3), localTime(1, 0, 0), localTime(2, 3, 0)).Return([]*models.DeliverySchedule{
+		{ID: 30, DeliveryID: 1, VehicleID: 7, Status: models.ScheduleStatusScheduled, StartTime: localTime(1, 18, 0), EndTime: localTime(1, 20, 0), WeightGrams: 3000, VolumeML: 4000},
+		{ID: 31, DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusCancelled, StartTime: localTime(1, 20, 0), EndTime: localTime(1, 21, 0), WeightGrams: 9000},
+		{ID: 32, DeliveryID: 3, VehicleID: 7, Status: models.ScheduleStatusCompleted, StartTime: localTime(2, 0, 30), EndTime: localTime(2, 2, 0), WeightGrams: 2000},
+	}, nil)
+	repos.vehicles.On("GetVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, VehicleNumber: "静岡100あ1234"}, nil).Once()
+	repos.expectDelivery(1, 3000)
+	repos.expectDelivery(3, 2000)
+
+	manifest, err := service.GetManifest(context.Background(), 3, localTime(1, 0, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-05-01", manifest.Date)
+	assert.Equal(t, localTime(1, 18, 0), manifest.ShiftStart)
+	assert.Equal(t, localTime(2, 3, 0), manifest.ShiftEnd)
+	require.Len(t, manifest.Stops, 2)
+	assert.Equal(t, 1, manifest.Stops[0].Sequence)
+	assert.Equal(t, int64(1), manifest.Stops[0].Delivery.ID)
+	assert.Len(t, manifest.Stops[0].Delivery.Items, 1)
+	assert.Equal(t, "静岡100あ1234", manifest.Stops[0].VehicleNumber)
+	assert.Equal(t, 2, manifest.Stops[1].Sequence)
+	assert.Equal(t, int64(32), manifest.Stops[1].Schedule.ID)
+	assert.Equal(t, 5000, manifest.TotalWeightGrams)
+	assert.Equal(t, 4000, manifest.TotalVolumeML)
+	repos.vehicles.AssertExpectations(t)
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * ロケーション在庫操作
+ * 倉庫内のビン別在庫行をまとめて扱う共通処理を実装する
+ */
+
+// locationStock ロケーション内の商品の販売可能在庫
+// ビン未割当の在庫行とビン別の在庫行をまとめて扱う
+type locationStock struct {
+	ProductID int64
+	Location  string
+	Rows      []*models.Inventory
+}
+
+// OnHand ロケーション内の在庫数の合計を返す
+func (l *locationStock) OnHand() int {
+	total := 0
+	for _, row := range l.Rows {
+		total += row.Quantity
+	}
+	return total
+}
+
+// ForLot 指定ロットの在庫行に絞り込んだロケーション在庫を返す
+// lotIDがnilの場合はロット管理していない在庫行を対象とする
+func (l *locationStock) ForLot(lotID *int64) *locationStock {
+	filtered := &locationStock{ProductID: l.ProductID, Location: l.Location}
+	for _, row := range l.Rows {
+		if sameID(row.LotID, lotID) {
+			filtered.Rows = append(filtered.Rows, row)
+		}
+	}
+	return filtered
+}
+
+// errStockNotFound ロケーションに商品の販売可能在庫がない
+var errStockNotFound = errors.New("在庫が見つかりません")
+
+// stockDraw 在庫行からの払い出し
+type stockDraw struct {
+	Row      *models.Inventory
+	Quantity int
+}
+
+// loadLocationStock 指定リポジトリからロケーション内の商品の販売可能在庫を取得する
+// 隔離中・賞味期限切れの在庫は含めない。在庫行がない場合はエラーを返す
+func loadLocationStock(ctx context.Context, repo repository.InventoryRepository, productID int64, location string) (*locationStock, error) {
+	inventories, err := repo.GetInventoryByProductLocation(ctx, productID, location)
+	if err != nil {
+		return nil, fmt.Errorf("在庫取得エラー: %v", err)
+	}
+
+	stock := &locationStock{ProductID: productID, Location: location}
+	// ビン未割当の在庫行を先頭にし、払い出し時に優先して使用する
+	var binned []*models.Inventory
+	for _, inv := range inventories {
+		if !inv.Status.IsSellable() {
+			continue
+		}
+		if inv.BinID == nil {
+			stock.Rows = append(stock.Rows, inv)
+		} else {
+			binned = append(binned, inv)
+		}
+	}
+	stock.Rows = append(stock.Rows, binned...)
+
+	if len(stock.Rows) == 0 {
+		return nil, errStockNotFound
+	}
+
+	return stock, nil
+}
+
+// consumeLocationStock ロケーション在庫から数量を払い出す
+// 在庫行の並び順に減らし、合計が不足している場合はエラーを返す
+func consumeLocationStock(ctx context.Context, repo repository.InventoryRepository, stock *locationStock, quantity int) ([]stockDraw, error) {
+	if stock.OnHand() < quantity {
+		return nil, fmt.Errorf("在庫が不足しています")
+	}
+
+	var draws []stockDraw
+	remaining := quantity
+	for _, row := range stock.Rows {
+		if remaining == 0 {
+			break
+		}
+		if row.Quantity == 0 {
+			continue
+		}
+
+		take := row.Quantity
+		if take > remaining {
+			take = remaining
+		}
+		if err := repo.UpdateQuantity(ctx, row.ID, row.Quantity-take, row.Version); err != nil {
+			return nil, wrapUpdateError("在庫更新エラー", err)
+		}
+		row.Quantity -= take
+		row.Version++
+		remaining -= take
+		draws = append(draws, stockDraw{Row: row, Quantity: take})
+	}
+
+	return draws, nil
+}
+
+// lotQuantities 払い出しをロットごとに集計する
+// ロット管理していない在庫行からの払い出しは含めない
+func lotQuantities(draws []stockDraw) ([]int64, map[int64]int) {
+	var lotIDs []int64
+	quantities := make(map[int64]int)
+	for _, draw := range draws {
+		if draw.Row.LotID == nil {
+			continue
+		}
+		lotID := *draw.Row.LotID
+		if _, ok := quantities[lotID]; !ok {
+			lotIDs = append(lotIDs, lotID)
+		}
+		quantities[lotID] += draw.Quantity
+	}
+	return lotIDs, quantities
+}
+
+// findStockRow 商品・ロケーション・ビン・ロット・隔離区分が一致する在庫行を取得する
+// binID・lotIDがnilの場合はビン未割当・ロット管理していない在庫行を対象とする
+func findStockRow(
+	ctx context.Context,
+	repo repository.InventoryRepository,
+	productID int64,
+	location string,
+	binID *int64,
+	lotID *int64,
+	quarantined bool,
+) (*models.Inventory, error) {
+	inventory, err := repo.GetInventoryByStockKey(ctx, productID, location, binID, lotID, quarantined)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errStockNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("在庫取得エラー: %v", err)
+	}
+
+	return inventory, nil
+}
+
+// matchesStockStatus 在庫ステータスが隔離区分に一致するかどうかを判定する
+// 隔離中でない在庫行として賞味期限切れの在庫行は対象としない
+func matchesStockStatus(status models.InventoryStatus, quarantined bool) bool {
+	if quarantined {
+		return status == models.InventoryStatusQuarantined
+	}
+	return status.IsSellable()
+}
+
+// receiveStock トランザクション内で在庫行に数量を入庫する
+// 該当する在庫行がない場合は指定ステータスで作成する
+// 同じ在庫行を同時に作成しないよう、商品・ロケーションの在庫のロックを取得してから在庫行を探す
+// 複数の在庫に入庫する場合は、呼び出し元でlockStocksにより先にロックを取得しておくこと
+func receiveStock(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	productID int64,
+	location string,
+	binID *int64,
+	lotID *int64,
+	status models.InventoryStatus,
+	quantity int,
+) (*models.Inventory, error) {
+	if err := tx.Reservations.LockStock(ctx, productID, location); err != nil {
+		return nil, err
+	}
+
+	repo := tx.Inventory
+	quarantined := status == models.InventoryStatusQuarantined
+	inventory, err := findStockRow(ctx, repo, productID, location, binID, lotID, quarantined)
+	if err != nil && !errors.Is(err, errStockNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		if err := repo.UpdateQuantity(ctx, inventory.ID, inventory.Quantity+quantity, inventory.Version); err != nil {
+			return nil, wrapUpdateError("入庫先在庫更新エラー", err)
+		}
+		inventory.Quantity += quantity
+		inventory.Version++
+		return inventory, nil
+	}
+
+	// 入庫先に在庫がない場合は新規作成
+	inventory = &models.Inventory{
+		ProductID: productID,
+		Quantity:  quantity,
+		Location:  location,
+		BinID:     binID,
+		LotID:     lotID,
+		Status:    status,
+	}
+	if err := repo.CreateInventory(ctx, inventory); err != nil {
+		return nil, fmt.Errorf("入庫先在庫作成エラー: %v", err)
+	}
+
+	return inventory, nil
+}
+
+// checkBinPlacement トランザクション内でビンへの格納可否を確認する
+// ビンが指定ロケーション（倉庫）に属し、ゾーンの保管ルールで商品カテゴリが許可されている必要がある
+func checkBinPlacement(ctx context.Context, tx *repository.TxRepositories, productID int64, location string, binID int64) error {
+	binLocation, err := tx.Locations.GetBinLocation(ctx, binID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("ビンが見つかりません: %d", binID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if binLocation.WarehouseName != location {
+		return fmt.Errorf("ビン「%s」はロケーション「%s」に属していません", binLocation.Path(), location)
+	}
+
+	zone, err := tx.Locations.GetZone(ctx, binLocation.ZoneID)
+	if err != nil {
+		return fmt.Errorf("ゾーン取得エラー: %v", err)
+	}
+
+	category, err := tx.Locations.GetProductCategory(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("商品カテゴリ取得エラー: %v", err)
+	}
+
+	if !zone.AllowsCategory(category) {
+		return &models.ZoneStorageRuleError{
+			ZoneID:   zone.ID,
+			ZoneCode: zone.Code,
+			Category: category,
+		}
+	}
+
+	return nil
+}
+
+// resolveLot トランザクション内でロット番号からロットを取得し、商品と一致するか確認する
+// ロット番号が空の場合はnilを返す
+func resolveLot(ctx context.Context, tx *repository.TxRepositories, productID int64, lotNumber string) (*models.Lot, error) {
+	if lotNumber == "" {
+		return nil, nil
+	}
+
+	lot, err := tx.Lots.GetLotByNumber(ctx, lotNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, &models.InvalidLotError{LotNumber: lotNumber, Reason: "が見つかりません"}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロット取得エラー: %v", err)
+	}
+
+	if lot.ProductID != productID {
+		return nil, &models.InvalidLotError{LotNumber: lotNumber, Reason: fmt.Sprintf("は商品ID %d のロットではありません", productID)}
+	}
+
+	return lot, nil
+}
+
+// lotIDOf ロットのIDを返す（ロットがnilの場合はnil）
+func lotIDOf(lot *models.Lot) *int64 {
+	if lot == nil {
+		return nil
+	}
+	return &lot.ID
+}
+
+// sameID IDが一致するかどうかを判定する（nil同士も一致とみなす）
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
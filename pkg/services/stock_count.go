@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 棚卸サービス
+ * ロケーションを凍結して実数を入力し、マネージャーの承認後に差異を調整として在庫へ反映する
+ */
+
+// ErrStockCountNotFound 棚卸が見つからない場合のエラー
+var ErrStockCountNotFound = errors.New("棚卸が見つかりません")
+
+// StockCountService 棚卸サービス
+type StockCountService struct {
+	repo repository.StockCountRepository
+	uow  repository.UnitOfWork
+}
+
+// NewStockCountService 棚卸サービスを作成する
+func NewStockCountService(repo repository.StockCountRepository, uow repository.UnitOfWork) *StockCountService {
+	return &StockCountService{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// StartStockCount ロケーションの棚卸を開始する
+// 開始時点のロケーションの全在庫行を帳簿数量として明細に記録し、棚卸が終わるまでロケーションを凍結する
+func (s *StockCountService) StartStockCount(ctx context.Context, req *models.CreateStockCountRequest, createdBy int64) (*models.StockCount, error) {
+	count := &models.StockCount{
+		Location:  strings.TrimSpace(req.Location),
+		Status:    models.StockCountStatusOpen,
+		Notes:     req.Notes,
+		CreatedBy: createdBy,
+	}
+
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := checkLocationNotFrozen(ctx, tx, count.Location); err != nil {
+			return err
+		}
+
+		inventories, err := tx.Inventory.GetInventoryByLocation(ctx, count.Location)
+		if err != nil {
+			return fmt.Errorf("在庫取得エラー: %v", err)
+		}
+
+		if err := tx.StockCounts.CreateStockCount(ctx, count); err != nil {
+			return err
+		}
+
+		for _, inv := range inventories {
+			inventoryID := inv.ID
+			line := &models.StockCountLine{
+				StockCountID:     count.ID,
+				InventoryID:      &inventoryID,
+				ProductID:        inv.ProductID,
+				BinID:            inv.BinID,
+				LotID:            inv.LotID,
+				Status:           inv.Status,
+				ExpectedQuantity: inv.Quantity,
+			}
+			if err := tx.StockCounts.CreateStockCountLine(ctx, line); err != nil {
+				return err
+			}
+			count.Lines = append(count.Lines, line)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("棚卸開始", map[string]interface{}{
+		"stock_count_id": count.ID,
+		"location":       count.Location,
+		"line_count":     len(count.Lines),
+	})
+
+	return count, nil
+}
+
+// GetStockCount 棚卸を明細とともに取得する
+func (s *StockCountService) GetStockCount(ctx context.Context, id int64) (*models.StockCount, error) {
+	count, err := getStockCount(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	count.Lines, err = s.repo.ListStockCountLines(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("棚卸明細取得エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// ListStockCounts 棚卸一覧を取得する（statusが空の場合はすべて）
+func (s *StockCountService) ListStockCounts(ctx context.Context, status models.StockCountStatus) ([]*models.StockCount, error) {
+	counts, err := s.repo.ListStockCounts(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("棚卸一覧取得エラー: %v", err)
+	}
+
+	return counts, nil
+}
+
+// RecordCounts 棚卸中の明細に実数を入力する
+// 帳簿にない在庫を発見した場合は、商品・ビン・ロットを指定して帳簿数量0の明細を追加する
+func (s *StockCountService) RecordCounts(ctx context.Context, id int64, req *models.RecordStockCountRequest) (*models.StockCount, error) {
+	var count *models.StockCount
+
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		var err error
+		count, err = getStockCount(ctx, tx.StockCounts, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.StockCountStatusOpen {
+			return &models.StockCountStatusError{StockCountID: id, Status: count.Status, Action: "実数入力"}
+		}
+
+		count.Lines, err = tx.StockCounts.ListStockCountLines(ctx, id)
+		if err != nil {
+			return fmt.Errorf("棚卸明細取得エラー: %v", err)
+		}
+
+		for _, entry := range req.Entries {
+			if entry.ReasonCode != "" && !entry.ReasonCode.IsValid() {
+				return fmt.Errorf("無効な調整理由です: %s", entry.ReasonCode)
+			}
+
+			line, err := resolveStockCountLine(ctx, tx, count, entry)
+			if err != nil {
+				return err
+			}
+
+			counted := entry.CountedQuantity
+			line.CountedQuantity = &counted
+			line.ReasonCode = entry.ReasonCode
+
+			if line.ID == 0 {
+				if err := tx.StockCounts.CreateStockCountLine(ctx, line); err != nil {
+					return err
+				}
+				count.Lines = append(count.Lines, line)
+				continue
+			}
+			if err := tx.StockCounts.UpdateStockCountLine(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// resolveStockCountLine トランザクション内で実数入力の対象となる明細を取得する
+// 帳簿にない在庫の場合は未保存（IDが0）の明細を返す
+func resolveStockCountLine(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	count *models.StockCount,
+	entry models.StockCountEntry,
+) (*models.StockCountLine, error) {
+	if entry.LineID != 0 {
+		for _, line := range count.Lines {
+			if line.ID == entry.LineID {
+				return line, nil
+			}
+		}
+		return nil, fmt.Errorf("明細ID %d はこの棚卸の明細ではありません", entry.LineID)
+	}
+
+	if entry.ProductID == 0 {
+		return nil, fmt.Errorf("明細IDまたは商品IDを指定してください")
+	}
+
+	lot, err := resolveLot(ctx, tx, entry.ProductID, entry.LotNumber)
+	if err != nil {
+		return nil, err
+	}
+	lotID := lotIDOf(lot)
+
+	for _, line := range count.Lines {
+		if line.ProductID == entry.ProductID && matchesStockStatus(line.Status, false) &&
+			sameID(line.BinID, entry.BinID) && sameID(line.LotID, lotID) {
+			return line, nil
+		}
+	}
+
+	if entry.BinID != nil {
+		if err := checkBinPlacement(ctx, tx, entry.ProductID, count.Location, *entry.BinID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.StockCountLine{
+		StockCountID: count.ID,
+		ProductID:    entry.ProductID,
+		BinID:        entry.BinID,
+		LotID:        lotID,
+		Status:       models.InventoryStatusAvailable,
+	}, nil
+}
+
+// SubmitStockCount 棚卸を承認待ちにする
+// 全明細の実数が入力済みで、差異のある明細には調整理由が必要
+func (s *StockCountService) SubmitStockCount(ctx context.Context, id int64) (*models.StockCount, error) {
+	count, err := s.GetStockCount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != models.StockCountStatusOpen {
+		return nil, &models.StockCountStatusError{StockCountID: id, Status: count.Status, Action: "提出"}
+	}
+
+	for _, line := range count.Lines {
+		if line.CountedQuantity == nil {
+			return nil, fmt.Errorf("明細ID %d の実数が入力されていません", line.ID)
+		}
+		if line.Variance() != 0 && !line.ReasonCode.IsValid() {
+			return nil, fmt.Errorf("明細ID %d は差異(%d)があるため調整理由が必要です", line.ID, line.Variance())
+		}
+	}
+
+	count.Status = models.StockCountStatusSubmitted
+	if err := s.repo.UpdateStockCount(ctx, count); err != nil {
+		return nil, fmt.Errorf("棚卸更新エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// ApproveStockCount 承認待ちの棚卸を承認し、差異を調整の在庫移動として在庫へ反映する
+func (s *StockCountService) ApproveStockCount(ctx context.Context, id int64, reviewedBy int64) (*models.StockCountResult, error) {
+	result := &models.StockCountResult{Movements: []*models.InventoryMovement{}}
+
+	// 在庫数・調整の在庫移動・棚卸ステータスを単一トランザクションで更新する
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		count, err := getStockCount(ctx, tx.StockCounts, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.StockCountStatusSubmitted {
+			return &models.StockCountStatusError{StockCountID: id, Status: count.Status, Action: "承認"}
+		}
+
+		count.Lines, err = tx.StockCounts.ListStockCountLines(ctx, id)
+		if err != nil {
+			return fmt.Errorf("棚卸明細取得エラー: %v", err)
+		}
+
+		// 差異のある商品の在庫のロックを一定の順序で取得してから在庫へ反映する
+		var keys []stockKey
+		for _, line := range count.Lines {
+			if line.Variance() != 0 {
+				keys = append(keys, stockKey{ProductID: line.ProductID, Location: count.Location})
+			}
+		}
+		if err := lockStocks(ctx, tx, keys); err != nil {
+			return err
+		}
+
+		for _, line := range count.Lines {
+			if line.Variance() == 0 {
+				continue
+			}
+			movement, err := applyStockCountAdjustment(ctx, tx, count, line)
+			if err != nil {
+				return err
+			}
+			result.Movements = append(result.Movements, movement)
+		}
+
+		now := time.Now()
+		count.Status = models.StockCountStatusApproved
+		count.ReviewedBy = reviewedBy
+		count.ReviewedAt = &now
+		if err := tx.StockCounts.UpdateStockCount(ctx, count); err != nil {
+			return fmt.Errorf("棚卸更新エラー: %v", err)
+		}
+
+		result.StockCount = count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("棚卸承認完了", map[string]interface{}{
+		"stock_count_id": id,
+		"location":       result.StockCount.Location,
+		"reviewed_by":    reviewedBy,
+		"adjustments":    len(result.Movements),
+	})
+
+	return result, nil
+}
+
+// applyStockCountAdjustment トランザクション内で棚卸明細の差異を在庫へ反映し、調整の在庫移動を記録する
+// 減少はロケーションからの払い出し、増加はロケーションへの入庫として記録する
+func applyStockCountAdjustment(
+	ctx context.Context,
+	tx *repository.TxRepositories,
+	count *models.StockCount,
+	line *models.StockCountLine,
+) (*models.InventoryMovement, error) {
+	variance := line.Variance()
+
+	movement := &models.InventoryMovement{
+		ProductID:       line.ProductID,
+		LotID:           line.LotID,
+		Quantity:        variance,
+		MovementType:    models.MovementTypeAdjustment,
+		MovementDate:    time.Now(),
+		ReferenceNumber: count.Reference(),
+		ReasonCode:      string(line.ReasonCode),
+	}
+	if variance < 0 {
+		movement.Quantity = -variance
+		movement.FromLocation = count.Location
+		movement.FromBinID = line.BinID
+	} else {
+		movement.ToLocation = count.Location
+		movement.ToBinID = line.BinID
+	}
+
+	if err := tx.Inventory.CreateMovement(ctx, movement); err != nil {
+		return nil, fmt.Errorf("調整在庫移動作成エラー: %v", err)
+	}
+
+	// 在庫の増減を在庫元帳上で調整の在庫移動に紐付ける
+	ctx = repository.WithLedgerMovement(ctx, movement.ID)
+
+	if line.InventoryID != nil {
+		inventory, err := tx.Inventory.GetInventory(ctx, *line.InventoryID)
+		if err != nil {
+			return nil, fmt.Errorf("在庫取得エラー: %v", err)
+		}
+		// ロケーションは凍結しているため帳簿数量は開始時点から変わらないが、差異分を現在の数量に反映する
+		quantity := inventory.Quantity + variance
+		if quantity < 0 {
+			quantity = 0
+		}
+		if err := tx.Inventory.UpdateQuantity(ctx, inventory.ID, quantity, inventory.Version); err != nil {
+			return nil, wrapUpdateError("在庫数更新エラー", err)
+		}
+	} else {
+		if _, err := receiveStock(ctx, tx, line.ProductID, count.Location, line.BinID, line.LotID, line.Status, variance); err != nil {
+			return nil, err
+		}
+	}
+
+	return movement, nil
+}
+
+// RejectStockCount 承認待ちの棚卸を却下し、在庫に反映せずにロケーションの凍結を解除する
+func (s *StockCountService) RejectStockCount(ctx context.Context, id int64, req *models.ReviewStockCountRequest, reviewedBy int64) (*models.StockCount, error) {
+	count, err := getStockCount(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != models.StockCountStatusSubmitted {
+		return nil, &models.StockCountStatusError{StockCountID: id, Status: count.Status, Action: "却下"}
+	}
+
+	now := time.Now()
+	count.Status = models.StockCountStatusRejected
+	count.ReviewedBy = reviewedBy
+	count.ReviewedAt = &now
+	count.ReviewNote = req.Reason
+	if err := s.repo.UpdateStockCount(ctx, count); err != nil {
+		return nil, fmt.Errorf("棚卸更新エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// CancelStockCount 進行中の棚卸を中止し、ロケーションの凍結を解除する
+func (s *StockCountService) CancelStockCount(ctx context.Context, id int64) (*models.StockCount, error) {
+	count, err := getStockCount(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if !count.Status.FreezesLocation() {
+		return nil, &models.StockCountStatusError{StockCountID: id, Status: count.Status, Action: "中止"}
+	}
+
+	count.Status = models.StockCountStatusCancelled
+	if err := s.repo.UpdateStockCount(ctx, count); err != nil {
+		return nil, fmt.Errorf("棚卸更新エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// getStockCount 指定リポジトリから棚卸を取得する
+func getStockCount(ctx context.Context, repo repository.StockCountRepository, id int64) (*models.StockCount, error) {
+	count, err := repo.GetStockCount(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrStockCountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("棚卸取得エラー: %v", err)
+	}
+
+	return count, nil
+}
+
+// checkLocationNotFrozen トランザクション内でロケーションが棚卸で凍結されていないことを確認する
+func checkLocationNotFrozen(ctx context.Context, tx *repository.TxRepositories, locations ...string) error {
+	for _, location := range locations {
+		count, err := tx.StockCounts.GetActiveStockCount(ctx, location)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("棚卸状況確認エラー: %v", err)
+		}
+		return &models.LocationFrozenError{Location: location, StockCountID: count.ID}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 棚卸サービステスト
+ */
+
+// newDefaultStockCountRepo 棚卸中のロケーションがない状態のモックを作成する
+func newDefaultStockCountRepo() *mocks.MockStockCountRepository {
+	mockStockCountRepo := new(mocks.MockStockCountRepository)
+	mockStockCountRepo.On("GetActiveStockCount", mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound).Maybe()
+	return mockStockCountRepo
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestApproveStockCount_CreatesReasonCodedAdjustments(t *testing.T) {
+	mockStockCountRepo := new(mocks.MockStockCountRepository)
+	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:    mockInventoryRepo,
+		Reservations: newDefaultReservationRepo(),
+		StockCounts:  mockStockCountRepo,
+	})
+	service := NewStockCountService(mockStockCountRepo, uow)
+
+	ctx := context.Background()
+	inventoryID := int64(21)
+	count := &models.StockCount{ID: 7, Location: "静岡倉庫", Status: models.StockCountStatusSubmitted}
+	lines := []*models.StockCountLine{
+		{ID: 1, StockCountID: 7, InventoryID: &inventoryID, ProductID: 1, Status: models.InventoryStatusAvailable, ExpectedQuantity: 100, CountedQuantity: intPtr(95), ReasonCode: models.AdjustmentReasonDamaged},
+		{ID: 2, StockCountID: 7, ProductID: 2, Status: models.InventoryStatusAvailable, ExpectedQuantity: 0, CountedQuantity: intPtr(12), ReasonCode: models.AdjustmentReasonFound},
+		{ID: 3, StockCountID: 7, ProductID: 3, Status: models.InventoryStatusAvailable, ExpectedQuantity: 40, CountedQuantity: intPtr(40)},
+	}
+
+	mockStockCountRepo.On("GetStockCount", ctx, int64(7)).Return(count, nil)
+	mockStockCountRepo.On("ListStockCountLines", ctx, int64(7)).Return(lines, nil)
+	mockStockCountRepo.On("UpdateStockCount", ctx, count).Return(nil)
+	// 調整の在庫移動を記録してから、在庫の増減を在庫元帳上でこの移動に紐付ける
+	mockInventoryRepo.On("CreateMovement", ctx, mock.AnythingOfType("*models.InventoryMovement")).Run(func(args mock.Arguments) {
+		movement := args.Get(1).(*models.InventoryMovement)
+		movement.ID = 60 + movement.ProductID
+	}).Return(nil)
+	shrinkCtx := repository.WithLedgerMovement(ctx, 61)
+	foundCtx := repository.WithLedgerMovement(ctx, 62)
+	mockInventoryRepo.On("GetInventory", shrinkCtx, inventoryID).Return(&models.Inventory{ID: inventoryID, ProductID: 1, Quantity: 100, Location: "静岡倉庫"}, nil)
+	mockInventoryRepo.On("UpdateQuantity", shrinkCtx, inventoryID, 95, 0).Return(nil)
+	mockInventoryRepo.On("GetInventoryByStockKey", foundCtx, int64(2), "静岡倉庫", (*int64)(nil), (*int64)(nil), false).Return(nil, repository.ErrNotFound)
+	mockInventoryRepo.On("CreateInventory", foundCtx, mock.AnythingOfType("*models.Inventory")).Return(nil)
+
+	result, err := service.ApproveStockCount(ctx, 7, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StockCountStatusApproved, result.StockCount.Status)
+	assert.Equal(t, int64(3), result.StockCount.ReviewedBy)
+	if assert.Len(t, result.Movements, 2) {
+		shrink := result.Movements[0]
+		assert.Equal(t, models.MovementTypeAdjustment, shrink.MovementType)
+		assert.Equal(t, 5, shrink.Quantity)
+		assert.Equal(t, "静岡倉庫", shrink.FromLocation)
+		assert.Equal(t, "damaged", shrink.ReasonCode)
+		assert.Equal(t, "CNT-7", shrink.ReferenceNumber)
+
+		found := result.Movements[1]
+		assert.Equal(t, 12, found.Quantity)
+		assert.Equal(t, "静岡倉庫", found.ToLocation)
+		assert.Equal(t, "found", found.ReasonCode)
+	}
+	mockInventoryRepo.AssertCalled(t, "CreateInventory", foundCtx, mock.MatchedBy(func(inv *models.Inventory) bool {
+		return inv.ProductID == 2 && inv.Quantity == 12
+	}))
+}
+
+func TestSubmitStockCount_RequiresReasonForVariance(t *testing.T) {
+	mockStockCountRepo := new(mocks.MockStockCountRepository)
+	service := NewStockCountService(mockStockCountRepo, mocks.NewMockUnitOfWork(&repository.TxRepositories{}))
+
+	ctx := context.Background()
+	mockStockCountRepo.On("GetStockCount", ctx, int64(7)).Return(&models.StockCount{ID: 7, Location: "静岡倉庫", Status: models.StockCountStatusOpen}, nil)
+	mockStockCountRepo.On("ListStockCountLines", ctx, int64(7)).Return([]*models.StockCountLine{
+		{ID: 1, ProductID: 1, ExpectedQuantity: 100, CountedQuantity: intPtr(98)},
+	}, nil)
+
+	count, err := service.SubmitStockCount(ctx, 7)
+
+	assert.Error(t, err)
+	assert.Nil(t, count)
+	mockStockCountRepo.AssertNotCalled(t, "UpdateStockCount", mock.Anything, mock.Anything)
+}
+
+func TestCreateMovement_BlockedByStockCount(t *testing.T) {
+	mockInventoryRepo := new(MockInventoryRepository)
+	mockStockCountRepo := new(mocks.MockStockCountRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Inventory:    mockInventoryRepo,
+		Reservations: newDefaultReservationRepo(),
+		StockCounts:  mockStockCountRepo,
+	})
+	service := NewInventoryService(mockInventoryRepo, newDefaultReservationRepo(), uow)
+
+	ctx := context.Background()
+	mockStockCountRepo.On("GetActiveStockCount", ctx, "東京倉庫").Return(nil, repository.ErrNotFound)
+	mockStockCountRepo.On("GetActiveStockCount", ctx, "大阪倉庫").Return(&models.StockCount{ID: 7, Location: "大阪倉庫", Status: models.StockCountStatusOpen}, nil)
+
+	movement, err := service.CreateMovement(ctx, &models.CreateMovementRequest{
+		ProductID:    1,
+		FromLocation: "東京倉庫",
+		ToLocation:   "大阪倉庫",
+		Quantity:     10,
+		MovementType: models.MovementTypeTransfer,
+	})
+
+	var frozenErr *models.LocationFrozenError
+	assert.ErrorAs(t, err, &frozenErr)
+	assert.Equal(t, "大阪倉庫", frozenErr.Location)
+	assert.Nil(t, movement)
+	mockInventoryRepo.AssertNotCalled(t, "CreateMovement", mock.Anything, mock.Anything)
+}
@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/logger"
 	"tea-logistics/pkg/models"
 	"tea-logistics/pkg/repository"
 )
@@ -17,11 +20,15 @@ import (
 // TrackingService 配送追跡サービス
 type TrackingService struct {
 	trackingRepo *repository.TrackingRepository
+	geocoder     geo.Geocoder
+	etaService   *ETAService
 }
 
 // NewTrackingService 配送追跡サービスを作成する
-func NewTrackingService(trackingRepo *repository.TrackingRepository) *TrackingService {
-	return &TrackingService{trackingRepo: trackingRepo}
+// geocoderがnilの場合は、追跡イベントの場所から緯度経度を求めない
+// etaServiceがnilの場合は、追跡イベントの追加時に到着予定日時を予測し直さない
+func NewTrackingService(trackingRepo *repository.TrackingRepository, geocoder geo.Geocoder, etaService *ETAService) *TrackingService {
+	return &TrackingService{trackingRepo: trackingRepo, geocoder: geocoder, etaService: etaService}
 }
 
 // InitializeTracking 配送追跡を初期化する
@@ -45,10 +52,15 @@ func (s *TrackingService) InitializeTracking(ctx context.Context, deliveryID int
 		Location:    fromLocation,
 		Description: "配送追跡が開始されました",
 	}
+	s.locateEvent(ctx, event)
 	if err := s.trackingRepo.AddTrackingEvent(ctx, event); err != nil {
 		return nil, err
 	}
 
+	if eta := s.refreshDeliveryETA(ctx, deliveryID); eta != nil {
+		tracking.EstimatedTime = &eta.EstimatedTime
+	}
+
 	return tracking, nil
 }
 
@@ -66,10 +78,15 @@ func (s *TrackingService) UpdateTrackingStatus(ctx context.Context, trackingID s
 		Location:    location,
 		Description: description,
 	}
+	s.locateEvent(ctx, event)
 	if err := s.trackingRepo.AddTrackingEvent(ctx, event); err != nil {
 		return err
 	}
 
+	if status != models.TrackingStatusDelivered {
+		s.refreshETA(ctx, trackingID)
+	}
+
 	return nil
 }
 
@@ -82,9 +99,13 @@ func (s *TrackingService) AddTrackingEvent(ctx context.Context, event *models.Tr
 	}
 
 	// イベントの追加
+	s.locateEvent(ctx, event)
 	if err := s.trackingRepo.AddTrackingEvent(ctx, event); err != nil {
 		return err
 	}
+	if event.Latitude != 0 || event.Longitude != 0 {
+		s.refreshDeliveryETA(ctx, tracking.DeliveryID)
+	}
 
 	// 条件チェック
 	condition, err := s.trackingRepo.GetTrackingCondition(ctx, tracking.ID)
@@ -129,3 +150,55 @@ func (s *TrackingService) SetTrackingCondition(ctx context.Context, condition *m
 func (s *TrackingService) GetTrackingCondition(ctx context.Context, trackingID string) (*models.TrackingCondition, error) {
 	return s.trackingRepo.GetTrackingCondition(ctx, trackingID)
 }
+
+// locateEvent 緯度経度のない追跡イベントに、場所の住所から求めた緯度経度を設定する
+// 場所を解釈できない場合は緯度経度を設定しない
+func (s *TrackingService) locateEvent(ctx context.Context, event *models.TrackingEvent) {
+	if s.geocoder == nil || event.Location == "" || event.Latitude != 0 || event.Longitude != 0 {
+		return
+	}
+
+	result, err := s.geocoder.Geocode(ctx, event.Location)
+	if err != nil {
+		return
+	}
+	event.Latitude = result.Point.Latitude
+	event.Longitude = result.Point.Longitude
+}
+
+// refreshETA 配送追跡の配送の到着予定日時を予測し直す
+func (s *TrackingService) refreshETA(ctx context.Context, trackingID string) {
+	if s.etaService == nil {
+		return
+	}
+
+	tracking, err := s.trackingRepo.GetTracking(ctx, trackingID)
+	if err != nil {
+		logger.Warn("到着予定日時の予測エラー", map[string]interface{}{
+			"error":       err.Error(),
+			"tracking_id": trackingID,
+		})
+		return
+	}
+	s.refreshDeliveryETA(ctx, tracking.DeliveryID)
+}
+
+// refreshDeliveryETA 配送の到着予定日時を予測し直し、予測できない場合はnilを返す
+// 予測のエラーはログに記録するだけで、追跡イベントの追加自体は成功とする
+func (s *TrackingService) refreshDeliveryETA(ctx context.Context, deliveryID int64) *models.DeliveryETA {
+	if s.etaService == nil {
+		return nil
+	}
+
+	eta, err := s.etaService.UpdateDeliveryETA(ctx, deliveryID, time.Now())
+	if err != nil {
+		if !errors.Is(err, ErrETAUnavailable) && !errors.Is(err, ErrETATrackingNotFound) {
+			logger.Warn("到着予定日時の予測エラー", map[string]interface{}{
+				"error":       err.Error(),
+				"delivery_id": deliveryID,
+			})
+		}
+		return nil
+	}
+	return eta
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 在庫評価サービス
+ * 在庫移動を台帳として評価日時点の在庫数量を求め、総平均法・先入先出法で在庫を評価する
+ */
+
+// ErrFutureValuationDate 評価日に未来の日付が指定された場合のエラー
+var ErrFutureValuationDate = errors.New("評価日に未来の日付は指定できません")
+
+// ErrSnapshotDateNotClosed 締まっていない日（当日以降）の日次スナップショットを作成しようとした場合のエラー
+var ErrSnapshotDateNotClosed = errors.New("当日以降の日次スナップショットは作成できません")
+
+// ValuationService 在庫評価サービス
+type ValuationService struct {
+	repo repository.ValuationRepository
+	uow  repository.UnitOfWork
+}
+
+// NewValuationService 在庫評価サービスを作成する
+func NewValuationService(repo repository.ValuationRepository, uow repository.UnitOfWork) *ValuationService {
+	return &ValuationService{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// GetStockAsOf 評価日の終了時点の在庫数量を取得する
+// 評価日の日次スナップショットがあればそれを使用し、なければ在庫移動から算出する
+func (s *ValuationService) GetStockAsOf(ctx context.Context, date time.Time, now time.Time) (*models.StockAsOfReport, error) {
+	date = dateOf(date)
+	if date.After(dateOf(now)) {
+		return nil, ErrFutureValuationDate
+	}
+
+	levels, source, err := s.stockLevelsAsOf(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, err := s.productCostsAsOf(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.StockAsOfReport{
+		Date:   date.Format("2006-01-02"),
+		Source: source,
+		Lines:  make([]*models.StockAsOfLine, 0, len(levels)),
+	}
+	for _, level := range levels {
+		report.Lines = append(report.Lines, stockAsOfLine(level, costs[level.ProductID]))
+	}
+
+	return report, nil
+}
+
+// GetValuation 評価日の終了時点の在庫を指定した評価方法で評価する
+// 単価は商品単位で算出し（ロケーションによらず同一）、ロケーション・カテゴリでの絞り込みは明細と集計にのみ適用する
+func (s *ValuationService) GetValuation(
+	ctx context.Context,
+	date time.Time,
+	method models.ValuationMethod,
+	filter models.ValuationFilter,
+	now time.Time,
+) (*models.ValuationReport, error) {
+	if !method.IsValid() {
+		return nil, fmt.Errorf("無効な評価方法です: %s", method)
+	}
+	date = dateOf(date)
+	if date.After(dateOf(now)) {
+		return nil, ErrFutureValuationDate
+	}
+
+	levels, source, err := s.stockLevelsAsOf(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, err := s.productCostsAsOf(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := s.repo.ListCostLayers(ctx, date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	layersByProduct := make(map[int64][]*models.CostLayer)
+	for _, layer := range layers {
+		layersByProduct[layer.ProductID] = append(layersByProduct[layer.ProductID], layer)
+	}
+
+	// 先入先出法では商品全体の在庫数量に対して残っている入庫を求めるため、絞り込み前に数量を集計する
+	productQuantities := make(map[int64]int)
+	for _, level := range levels {
+		productQuantities[level.ProductID] += level.Quantity
+	}
+	unitCosts := make(map[int64]float64, len(productQuantities))
+	for productID, quantity := range productQuantities {
+		var price float64
+		if cost := costs[productID]; cost != nil {
+			price = cost.Price
+		}
+		unitCosts[productID] = unitCostOf(method, layersByProduct[productID], quantity, price)
+	}
+
+	report := &models.ValuationReport{
+		Date:   date.Format("2006-01-02"),
+		Method: method,
+		Source: source,
+		Lines:  []*models.ValuationLine{},
+	}
+	byWarehouse := make(map[string]*models.ValuationSummary)
+	byCategory := make(map[string]*models.ValuationSummary)
+	for _, level := range levels {
+		asOf := stockAsOfLine(level, costs[level.ProductID])
+		if filter.Location != "" && asOf.Location != filter.Location {
+			continue
+		}
+		if filter.Category != "" && asOf.Category != filter.Category {
+			continue
+		}
+
+		unitCost := unitCosts[level.ProductID]
+		line := &models.ValuationLine{
+			StockAsOfLine: *asOf,
+			UnitCost:      roundCurrency(unitCost),
+			Value:         roundCurrency(unitCost * float64(level.Quantity)),
+		}
+		report.Lines = append(report.Lines, line)
+		report.TotalQuantity += line.Quantity
+		report.TotalValue += line.Value
+
+		addValuationSummary(byWarehouse, line.Location, line)
+		addValuationSummary(byCategory, line.Category, line)
+	}
+	report.TotalValue = roundCurrency(report.TotalValue)
+	report.ByWarehouse = sortedValuationSummaries(byWarehouse)
+	report.ByCategory = sortedValuationSummaries(byCategory)
+
+	return report, nil
+}
+
+// TakeSnapshot 指定日の終了時点の在庫数量を日次スナップショットとして保存する
+// 既に作成済みの場合は既存のスナップショットを返す
+func (s *ValuationService) TakeSnapshot(ctx context.Context, date time.Time, now time.Time) (*models.InventorySnapshot, error) {
+	date = dateOf(date)
+	if !date.Before(dateOf(now)) {
+		return nil, ErrSnapshotDateNotClosed
+	}
+
+	var snapshot *models.InventorySnapshot
+	created := false
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		existing, err := tx.Valuation.GetSnapshot(ctx, date)
+		if err == nil {
+			snapshot = existing
+			return nil
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		levels, err := stockLevelsFromLedger(ctx, tx.Valuation, date)
+		if err != nil {
+			return err
+		}
+
+		snapshot = &models.InventorySnapshot{
+			SnapshotDate: date,
+			LineCount:    len(levels),
+			Lines:        levels,
+		}
+		if err := tx.Valuation.CreateSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+		for _, level := range levels {
+			if err := tx.Valuation.CreateSnapshotLine(ctx, date, level); err != nil {
+				return err
+			}
+		}
+
+		created = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		logger.Info("在庫スナップショット作成", map[string]interface{}{
+			"snapshot_date": date.Format("2006-01-02"),
+			"line_count":    snapshot.LineCount,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// StartSnapshotScheduler 前日分の日次スナップショットの作成を定期実行する
+func (s *ValuationService) StartSnapshotScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("在庫スナップショットの作成を停止しました")
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if _, err := s.TakeSnapshot(ctx, dateOf(now).AddDate(0, 0, -1), now); err != nil {
+				logger.Error("在庫スナップショット作成エラー", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// stockLevelsAsOf 評価日の終了時点の在庫数量と算出元を取得する
+func (s *ValuationService) stockLevelsAsOf(ctx context.Context, date time.Time) ([]*models.StockLevel, models.StockSource, error) {
+	_, err := s.repo.GetSnapshot(ctx, date)
+	if err == nil {
+		levels, err := s.repo.ListSnapshotLines(ctx, date)
+		if err != nil {
+			return nil, "", err
+		}
+		return levels, models.StockSourceSnapshot, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, "", err
+	}
+
+	levels, err := stockLevelsFromLedger(ctx, s.repo, date)
+	if err != nil {
+		return nil, "", err
+	}
+	return levels, models.StockSourceLedger, nil
+}
+
+// productCostsAsOf 評価日の終了時点の商品情報と単価を商品IDごとに取得する
+func (s *ValuationService) productCostsAsOf(ctx context.Context, date time.Time) (map[int64]*models.ProductCost, error) {
+	costs, err := s.repo.ListProductCosts(ctx, date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[int64]*models.ProductCost, len(costs))
+	for _, cost := range costs {
+		byProduct[cost.ProductID] = cost
+	}
+	return byProduct, nil
+}
+
+// stockLevelsFromLedger 指定リポジトリから、現在の在庫数量に評価日翌日以降の在庫移動を戻して評価日の終了時点の在庫数量を算出する
+func stockLevelsFromLedger(ctx context.Context, repo repository.ValuationRepository, date time.Time) ([]*models.StockLevel, error) {
+	current, err := repo.ListStockLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := repo.ListMovementsSince(ctx, date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := repo.ListStockLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rollbackStockLevels(current, movements, locations), nil
+}
+
+// rollbackStockLevels 現在の在庫数量から在庫移動を取り消した在庫数量を返す
+// 仕入先・配送先など在庫を保管しないロケーションへの移動は数量に含めない
+// 台帳に記録されていない在庫の変更により0以下となった行は除外する
+func rollbackStockLevels(current []*models.StockLevel, movements []*models.InventoryMovement, locations []string) []*models.StockLevel {
+	type stockKey struct {
+		productID int64
+		location  string
+	}
+
+	stockLocations := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		stockLocations[location] = true
+	}
+
+	quantities := make(map[stockKey]int)
+	for _, level := range current {
+		quantities[stockKey{level.ProductID, level.Location}] += level.Quantity
+	}
+	for _, movement := range movements {
+		if stockLocations[movement.ToLocation] {
+			quantities[stockKey{movement.ProductID, movement.ToLocation}] -= movement.Quantity
+		}
+		if stockLocations[movement.FromLocation] {
+			quantities[stockKey{movement.ProductID, movement.FromLocation}] += movement.Quantity
+		}
+	}
+
+	levels := make([]*models.StockLevel, 0, len(quantities))
+	for key, quantity := range quantities {
+		if quantity <= 0 {
+			continue
+		}
+		levels = append(levels, &models.StockLevel{ProductID: key.productID, Location: key.location, Quantity: quantity})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].ProductID != levels[j].ProductID {
+			return levels[i].ProductID < levels[j].ProductID
+		}
+		return levels[i].Location < levels[j].Location
+	})
+
+	return levels
+}
+
+// unitCostOf 評価方法に応じた商品の単価を返す
+// layersは評価日までの入庫を古い順に並べたもの、priceは入庫がない場合に使用する評価日時点の商品単価
+func unitCostOf(method models.ValuationMethod, layers []*models.CostLayer, quantity int, price float64) float64 {
+	if method == models.ValuationMethodFIFO {
+		return fifoUnitCost(layers, quantity, price)
+	}
+	return weightedAverageUnitCost(layers, price)
+}
+
+// weightedAverageUnitCost 入庫原価の加重平均単価を返す
+func weightedAverageUnitCost(layers []*models.CostLayer, price float64) float64 {
+	quantity := 0
+	total := 0.0
+	for _, layer := range layers {
+		if layer.Quantity <= 0 {
+			continue
+		}
+		quantity += layer.Quantity
+		total += float64(layer.Quantity) * layer.UnitCost
+	}
+	if quantity == 0 {
+		return price
+	}
+	return total / float64(quantity)
+}
+
+// fifoUnitCost 先入先出法の単価を返す
+// 古い入庫から払い出されたとみなし、在庫数量分を新しい入庫から順に割り当てる
+// 入庫の合計が在庫数量に満たない分は評価日時点の商品単価で評価する
+func fifoUnitCost(layers []*models.CostLayer, quantity int, price float64) float64 {
+	if quantity <= 0 {
+		return price
+	}
+
+	remaining := quantity
+	total := 0.0
+	for i := len(layers) - 1; i >= 0 && remaining > 0; i-- {
+		if layers[i].Quantity <= 0 {
+			continue
+		}
+		take := min(remaining, layers[i].Quantity)
+		total += float64(take) * layers[i].UnitCost
+		remaining -= take
+	}
+	total += float64(remaining) * price
+
+	return total / float64(quantity)
+}
+
+// stockAsOfLine 在庫数量に商品情報を付加する
+func stockAsOfLine(level *models.StockLevel, cost *models.ProductCost) *models.StockAsOfLine {
+	line := &models.StockAsOfLine{
+		ProductID: level.ProductID,
+		Location:  level.Location,
+		Quantity:  level.Quantity,
+	}
+	if cost != nil {
+		line.ProductName = cost.Name
+		line.SKU = cost.SKU
+		line.Category = cost.Category
+	}
+	return line
+}
+
+// addValuationSummary 集計キーごとに在庫数量と評価額を加算する
+func addValuationSummary(summaries map[string]*models.ValuationSummary, key string, line *models.ValuationLine) {
+	summary, ok := summaries[key]
+	if !ok {
+		summary = &models.ValuationSummary{Key: key}
+		summaries[key] = summary
+	}
+	summary.Quantity += line.Quantity
+	summary.Value = roundCurrency(summary.Value + line.Value)
+}
+
+// sortedValuationSummaries 集計をキーの順に並べて返す
+func sortedValuationSummaries(summaries map[string]*models.ValuationSummary) []*models.ValuationSummary {
+	sorted := make([]*models.ValuationSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		sorted = append(sorted, summary)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+// dateOf 日時の日付部分（同じタイムゾーンの0時）を返す
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// roundCurrency 金額を小数点以下2桁に丸める
+func roundCurrency(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * 在庫評価サービステスト
+ */
+
+func newTestValuationService() (*ValuationService, *mocks.MockValuationRepository) {
+	mockValuationRepo := new(mocks.MockValuationRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{Valuation: mockValuationRepo})
+	return NewValuationService(mockValuationRepo, uow), mockValuationRepo
+}
+
+func TestGetStockAsOf_RollsBackLaterMovements(t *testing.T) {
+	service, mockValuationRepo := newTestValuationService()
+
+	ctx := context.Background()
+	date := time.Date(2026, 3, 31, 0, 0, 0, 0, time.Local)
+	cutoff := date.AddDate(0, 0, 1)
+
+	mockValuationRepo.On("GetSnapshot", ctx, date).Return(nil, repository.ErrNotFound)
+	mockValuationRepo.On("ListStockLevels", ctx).Return([]*models.StockLevel{
+		{ProductID: 1, Location: "静岡倉庫", Quantity: 80},
+		{ProductID: 1, Location: "東京倉庫", Quantity: 30},
+	}, nil)
+	mockValuationRepo.On("ListMovementsSince", ctx, cutoff).Return([]*models.InventoryMovement{
+		// 4月の入荷（仕入先は在庫を保管するロケーションではない）
+		{ProductID: 1, FromLocation: "牧之原製茶", ToLocation: "静岡倉庫", Quantity: 50, MovementType: models.MovementTypeInbound},
+		// 4月の倉庫間移動（東京倉庫の在庫は3月末時点ではなかった）
+		{ProductID: 1, FromLocation: "静岡倉庫", ToLocation: "東京倉庫", Quantity: 30, MovementType: models.MovementTypeTransfer},
+		// 4月の出荷（配送先は在庫を保管するロケーションではない）
+		{ProductID: 1, FromLocation: "静岡倉庫", ToLocation: "東京都港区1-1", Quantity: 10, MovementType: models.MovementTypeOutbound},
+	}, nil)
+	mockValuationRepo.On("ListStockLocations", ctx).Return([]string{"静岡倉庫", "東京倉庫"}, nil)
+	mockValuationRepo.On("ListProductCosts", ctx, cutoff).Return([]*models.ProductCost{
+		{ProductID: 1, Name: "静岡煎茶", SKU: "SEN-001", Category: "煎茶", Price: 1200},
+	}, nil)
+
+	report, err := service.GetStockAsOf(ctx, date, time.Date(2026, 4, 15, 9, 0, 0, 0, time.Local))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-31", report.Date)
+	assert.Equal(t, models.StockSourceLedger, report.Source)
+	if assert.Len(t, report.Lines, 1) {
+		assert.Equal(t, "静岡倉庫", report.Lines[0].Location)
+		assert.Equal(t, 70, report.Lines[0].Quantity)
+		assert.Equal(t, "煎茶", report.Lines[0].Category)
+	}
+}
+
+func TestGetValuation_WeightedAverageAndFIFO(t *testing.T) {
+	service, mockValuationRepo := newTestValuationService()
+
+	ctx := context.Background()
+	date := time.Date(2026, 3, 31, 0, 0, 0, 0, time.Local)
+	cutoff := date.AddDate(0, 0, 1)
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+
+	mockValuationRepo.On("GetSnapshot", ctx, date).Return(&models.InventorySnapshot{SnapshotDate: date, LineCount: 2}, nil)
+	mockValuationRepo.On("ListSnapshotLines", ctx, date).Return([]*models.StockLevel{
+		{ProductID: 1, Location: "静岡倉庫", Quantity: 60},
+		{ProductID: 1, Location: "東京倉庫", Quantity: 20},
+	}, nil)
+	mockValuationRepo.On("ListProductCosts", ctx, cutoff).Return([]*models.ProductCost{
+		{ProductID: 1, Name: "静岡煎茶", SKU: "SEN-001", Category: "煎茶", Price: 1300},
+	}, nil)
+	mockValuationRepo.On("ListCostLayers", ctx, cutoff).Return([]*models.CostLayer{
+		{ProductID: 1, Quantity: 100, UnitCost: 1000},
+		{ProductID: 1, Quantity: 50, UnitCost: 1300},
+	}, nil)
+
+	average, err := service.GetValuation(ctx, date, models.ValuationMethodWeightedAverage, models.ValuationFilter{}, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StockSourceSnapshot, average.Source)
+	// (100×1000 + 50×1300) ÷ 150 = 1100
+	assert.Equal(t, 1100.0, average.Lines[0].UnitCost)
+	assert.Equal(t, 80, average.TotalQuantity)
+	assert.Equal(t, 88000.0, average.TotalValue)
+
+	fifo, err := service.GetValuation(ctx, date, models.ValuationMethodFIFO, models.ValuationFilter{Location: "東京倉庫"}, now)
+
+	assert.NoError(t, err)
+	// 残っている80は直近の入庫50×1300と前回の入庫30×1000 = 95000（単価1187.5）
+	if assert.Len(t, fifo.Lines, 1) {
+		assert.Equal(t, 1187.5, fifo.Lines[0].UnitCost)
+		assert.Equal(t, 23750.0, fifo.Lines[0].Value)
+	}
+	if assert.Len(t, fifo.ByWarehouse, 1) {
+		assert.Equal(t, "東京倉庫", fifo.ByWarehouse[0].Key)
+	}
+	assert.Equal(t, 23750.0, fifo.TotalValue)
+}
+
+func TestTakeSnapshot_RejectsOpenDay(t *testing.T) {
+	service, mockValuationRepo := newTestValuationService()
+
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	snapshot, err := service.TakeSnapshot(context.Background(), now, now)
+
+	assert.ErrorIs(t, err, ErrSnapshotDateNotClosed)
+	assert.Nil(t, snapshot)
+	mockValuationRepo.AssertNotCalled(t, "CreateSnapshot")
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 車両管理サービス
+ * 配送車両の登録・整備予定・空き状況の管理と、積載量を確認した配送の割当を実装する
+ */
+
+var (
+	// ErrVehicleNotFound 車両が見つからない場合のエラー
+	ErrVehicleNotFound = errors.New("車両が見つかりません")
+	// ErrScheduleNotFound 配送スケジュールが見つからない場合のエラー
+	ErrScheduleNotFound = errors.New("配送スケジュールが見つかりません")
+)
+
+// VehicleService 車両管理サービス
+type VehicleService struct {
+	repo            repository.VehicleRepository
+	scheduleRepo    repository.ScheduleRepository
+	deliveryService *DeliveryService
+	planner         *RoutePlanner
+	uow             repository.UnitOfWork
+}
+
+// NewVehicleService 車両管理サービスを作成する
+// plannerがnilの場合は、割当の変更時に配送ルートを計算し直さない
+func NewVehicleService(
+	repo repository.VehicleRepository,
+	scheduleRepo repository.ScheduleRepository,
+	deliveryService *DeliveryService,
+	planner *RoutePlanner,
+	uow repository.UnitOfWork,
+) *VehicleService {
+	return &VehicleService{
+		repo:            repo,
+		scheduleRepo:    scheduleRepo,
+		deliveryService: deliveryService,
+		planner:         planner,
+		uow:             uow,
+	}
+}
+
+// CreateVehicle 車両を登録する
+func (s *VehicleService) CreateVehicle(ctx context.Context, req *models.VehicleRequest) (*models.Vehicle, error) {
+	vehicle := &models.Vehicle{Status: models.VehicleStatusAvailable}
+	applyVehicleRequest(vehicle, req)
+
+	if err := s.repo.CreateVehicle(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("車両登録エラー: %v", err)
+	}
+
+	return vehicle, nil
+}
+
+// GetVehicle 車両を取得する
+func (s *VehicleService) GetVehicle(ctx context.Context, id int64) (*models.Vehicle, error) {
+	vehicle, err := s.repo.GetVehicle(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrVehicleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("車両取得エラー: %v", err)
+	}
+
+	return vehicle, nil
+}
+
+// ListVehicles 車両一覧を取得する
+func (s *VehicleService) ListVehicles(ctx context.Context, spec *repository.QuerySpec) ([]*models.Vehicle, *repository.PageInfo, error) {
+	vehicles, page, err := s.repo.ListVehicles(ctx, spec)
+	if err != nil {
+		return nil, nil, wrapListError("車両一覧取得エラー", err)
+	}
+
+	return vehicles, page, nil
+}
+
+// UpdateVehicle 車両を更新する
+// ステータスを省略した場合は変更しない
+func (s *VehicleService) UpdateVehicle(ctx context.Context, id int64, req *models.VehicleRequest) (*models.Vehicle, error) {
+	vehicle, err := s.GetVehicle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	applyVehicleRequest(vehicle, req)
+
+	if err := s.repo.UpdateVehicle(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("車両更新エラー: %v", err)
+	}
+
+	return vehicle, nil
+}
+
+// UpdateVehicleStatus 車両のステータスと最終位置を更新する
+func (s *VehicleService) UpdateVehicleStatus(ctx context.Context, id int64, req *models.UpdateVehicleStatusRequest) (*models.Vehicle, error) {
+	if !req.Status.IsValid() {
+		return nil, fmt.Errorf("無効な車両ステータスです: %s", req.Status)
+	}
+
+	vehicle, err := s.GetVehicle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	vehicle.Status = req.Status
+	if req.LastLocation != "" {
+		vehicle.LastLocation = req.LastLocation
+	}
+
+	if err := s.repo.UpdateVehicle(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("車両更新エラー: %v", err)
+	}
+
+	return vehicle, nil
+}
+
+// CreateMaintenance 車両の整備予定を登録する
+// 期間中に予定中の配送の割当がある場合は*models.VehicleUnavailableErrorを返す
+func (s *VehicleService) CreateMaintenance(ctx context.Context, vehicleID int64, req *models.CreateMaintenanceRequest) (*models.VehicleMaintenance, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("終了日時は開始日時より後の日時を指定してください")
+	}
+
+	maintenance := &models.VehicleMaintenance{
+		VehicleID: vehicleID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    req.Reason,
+	}
+
+	// 配送の割当と同じく車両の行をロックし、割当との競合を防ぐ
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		vehicle, err := lockVehicle(ctx, tx, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		entries, err := tx.Schedules.ListVehicleSchedules(ctx, vehicleID, req.StartTime, req.EndTime)
+		if err != nil {
+			return fmt.Errorf("配送スケジュール取得エラー: %v", err)
+		}
+		for _, entry := range entries {
+			if entry.Status == models.ScheduleStatusScheduled {
+				return &models.VehicleUnavailableError{
+					VehicleID:     vehicle.ID,
+					VehicleNumber: vehicle.VehicleNumber,
+					Reason:        fmt.Sprintf("整備期間中に配送ID %d の割当があります", entry.DeliveryID),
+				}
+			}
+		}
+
+		if err := tx.Vehicles.CreateMaintenance(ctx, maintenance); err != nil {
+			return fmt.Errorf("整備予定登録エラー: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return maintenance, nil
+}
+
+// ListMaintenance 車両の整備予定を取得する
+func (s *VehicleService) ListMaintenance(ctx context.Context, vehicleID int64) ([]*models.VehicleMaintenance, error) {
+	if _, err := s.GetVehicle(ctx, vehicleID); err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.ListMaintenance(ctx, vehicleID)
+	if err != nil {
+		return nil, fmt.Errorf("整備予定取得エラー: %v", err)
+	}
+
+	return list, nil
+}
+
+// ListAvailableVehicles 期間中に配送を割り当てられる車両と積載量の空きを取得する
+// 整備予定と重なる車両、割当できないステータスの車両、指定した重量・容積を積載できない車両は除く
+func (s *VehicleService) ListAvailableVehicles(ctx context.Context, query *models.VehicleAvailabilityQuery) ([]*models.VehicleAvailability, error) {
+	if !query.EndTime.After(query.StartTime) {
+		return nil, fmt.Errorf("終了日時は開始日時より後の日時を指定してください")
+	}
+
+	vehicles, _, err := s.repo.ListVehicles(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("車両一覧取得エラー: %v", err)
+	}
+
+	maintenance, err := s.repo.ListMaintenanceInPeriod(ctx, query.StartTime, query.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("整備予定取得エラー: %v", err)
+	}
+	inMaintenance := make(map[int64]bool, len(maintenance))
+	for _, m := range maintenance {
+		inMaintenance[m.VehicleID] = true
+	}
+
+	schedules, err := s.scheduleRepo.ListSchedulesInPeriod(ctx, query.StartTime, query.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+	loads := make(map[int64][]*models.DeliverySchedule)
+	for _, schedule := range schedules {
+		loads[schedule.VehicleID] = append(loads[schedule.VehicleID], schedule)
+	}
+
+	available := []*models.VehicleAvailability{}
+	for _, vehicle := range vehicles {
+		if !vehicle.Status.IsAssignable() || inMaintenance[vehicle.ID] {
+			continue
+		}
+		if query.Refrigerated && !vehicle.Refrigerated {
+			continue
+		}
+
+		availability := vehicleAvailability(vehicle, loads[vehicle.ID])
+		if availability.RemainingGrams < query.WeightGrams {
+			continue
+		}
+		if availability.RemainingVolumeML != nil && *availability.RemainingVolumeML < query.VolumeML {
+			continue
+		}
+		available = append(available, availability)
+	}
+
+	return available, nil
+}
+
+// AssignDelivery 車両に配送を割り当て、配送スケジュールを作成する
+// 同じトランザクション内で車両のその日の配送ルートを計算し直す
+// 割当済みの配送の重量・容積との合計が車両の積載量を超える場合は*models.VehicleCapacityErrorを、
+// 車両が整備中・廃車・設備不足などで割り当てられない場合は*models.VehicleUnavailableErrorを返す
+func (s *VehicleService) AssignDelivery(ctx context.Context, vehicleID int64, req *models.AssignVehicleRequest) (*models.DeliverySchedule, error) {
+	schedule, err := s.prepareSchedule(ctx, req.DeliveryID, vehicleID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// 車両の行をロックし、同じ車両への割当を直列化して積載量の超過を防ぐ
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := s.checkVehicleAssignment(ctx, tx, schedule, req.RequiresRefrigeration); err != nil {
+			return err
+		}
+
+		if err := tx.Schedules.CreateSchedule(ctx, schedule); err != nil {
+			return fmt.Errorf("配送スケジュール作成エラー: %v", err)
+		}
+		return replanRoutes(ctx, tx, s.planner, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// prepareSchedule 割り当てる配送の状態を確認し、出荷重量・容積を設定した配送スケジュールを作成する（保存はしない）
+func (s *VehicleService) prepareSchedule(ctx context.Context, deliveryID, vehicleID int64, start, end time.Time) (*models.DeliverySchedule, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("終了日時は開始日時より後の日時を指定してください")
+	}
+
+	delivery, err := s.deliveryService.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != models.DeliveryStatusPending && delivery.Status != models.DeliveryStatusScheduled {
+		return nil, fmt.Errorf("ステータスが「%s」の配送は車両に割り当てられません", delivery.Status)
+	}
+
+	shipment, err := s.deliveryService.GetShipment(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeliverySchedule{
+		DeliveryID:  deliveryID,
+		VehicleID:   vehicleID,
+		StartTime:   start,
+		EndTime:     end,
+		Status:      models.ScheduleStatusScheduled,
+		WeightGrams: shipment.WeightGrams,
+		VolumeML:    shipment.VolumeML,
+	}, nil
+}
+
+// checkVehicleAssignment トランザクション内で車両の行をロックし、配送スケジュールを車両に割り当てられることを確認する
+// 運転手を指定した配送スケジュールは、同じ時間帯に別の運転手が乗務する車両には割り当てない（*models.DoubleBookingError）
+func (s *VehicleService) checkVehicleAssignment(ctx context.Context, tx *repository.TxRepositories, schedule *models.DeliverySchedule, requiresRefrigeration bool) error {
+	vehicle, err := lockVehicle(ctx, tx, schedule.VehicleID)
+	if err != nil {
+		return err
+	}
+
+	unavailable := func(reason string) error {
+		return &models.VehicleUnavailableError{VehicleID: vehicle.ID, VehicleNumber: vehicle.VehicleNumber, Reason: reason}
+	}
+	if !vehicle.Status.IsAssignable() {
+		return unavailable(fmt.Sprintf("車両ステータスが「%s」です", vehicle.Status))
+	}
+	if requiresRefrigeration && !vehicle.Refrigerated {
+		return unavailable("冷蔵・冷凍設備がありません")
+	}
+
+	maintenance, err := tx.Vehicles.ListMaintenance(ctx, vehicle.ID)
+	if err != nil {
+		return fmt.Errorf("整備予定取得エラー: %v", err)
+	}
+	for _, m := range maintenance {
+		if m.StartTime.Before(schedule.EndTime) && schedule.StartTime.Before(m.EndTime) {
+			return unavailable(fmt.Sprintf("整備予定（%s）と重なっています", m.Reason))
+		}
+	}
+
+	entries, err := tx.Schedules.ListVehicleSchedules(ctx, vehicle.ID, schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+	var loaded []*models.DeliverySchedule
+	for _, entry := range entries {
+		if entry.Status != models.ScheduleStatusScheduled {
+			continue
+		}
+		if entry.DeliveryID == schedule.DeliveryID {
+			return unavailable(fmt.Sprintf("配送ID %d は期間中に割当済みです", schedule.DeliveryID))
+		}
+		if schedule.DriverID != nil && entry.DriverID != nil && *entry.DriverID != *schedule.DriverID {
+			return &models.DoubleBookingError{
+				Resource:   "車両",
+				ResourceID: vehicle.ID,
+				ScheduleID: entry.ID,
+				DeliveryID: entry.DeliveryID,
+				StartTime:  entry.StartTime,
+				EndTime:    entry.EndTime,
+			}
+		}
+		loaded = append(loaded, entry.DeliverySchedule)
+	}
+
+	return checkVehicleCapacity(vehicle, loaded, schedule)
+}
+
+// CancelAssignment 車両への配送の割当を取り消し、車両のその日の配送ルートを計算し直す
+func (s *VehicleService) CancelAssignment(ctx context.Context, vehicleID, scheduleID int64) (*models.DeliverySchedule, error) {
+	schedule, err := s.scheduleRepo.GetSchedule(ctx, scheduleID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+	if schedule.VehicleID != vehicleID {
+		return nil, ErrScheduleNotFound
+	}
+	if schedule.Status != models.ScheduleStatusScheduled {
+		return nil, fmt.Errorf("ステータスが「%s」の配送スケジュールは取り消せません", schedule.Status)
+	}
+
+	err = s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		if err := tx.Schedules.UpdateScheduleStatus(ctx, scheduleID, models.ScheduleStatusCancelled); err != nil {
+			return fmt.Errorf("配送スケジュール更新エラー: %v", err)
+		}
+		schedule.Status = models.ScheduleStatusCancelled
+		return replanRoutes(ctx, tx, s.planner, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetVehicleHistory 車両が担当した配送スケジュールと整備履歴を取得する
+// fromまたはtoがゼロ値の場合は、その側の期間を限定しない
+func (s *VehicleService) GetVehicleHistory(ctx context.Context, vehicleID int64, from, to time.Time) (*models.VehicleHistory, error) {
+	vehicle, err := s.GetVehicle(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.scheduleRepo.ListVehicleSchedules(ctx, vehicleID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("配送スケジュール取得エラー: %v", err)
+	}
+
+	maintenance, err := s.repo.ListMaintenance(ctx, vehicleID)
+	if err != nil {
+		return nil, fmt.Errorf("整備予定取得エラー: %v", err)
+	}
+
+	history := &models.VehicleHistory{
+		Vehicle:     vehicle,
+		Schedules:   []*models.VehicleScheduleEntry{},
+		Maintenance: []*models.VehicleMaintenance{},
+	}
+	if schedules != nil {
+		history.Schedules = schedules
+	}
+	for _, m := range maintenance {
+		if (from.IsZero() || m.EndTime.After(from)) && (to.IsZero() || m.StartTime.Before(to)) {
+			history.Maintenance = append(history.Maintenance, m)
+		}
+	}
+
+	return history, nil
+}
+
+// lockVehicle トランザクション内で車両を取得し、行をロックする
+func lockVehicle(ctx context.Context, tx *repository.TxRepositories, id int64) (*models.Vehicle, error) {
+	vehicle, err := tx.Vehicles.LockVehicle(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrVehicleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("車両取得エラー: %v", err)
+	}
+	return vehicle, nil
+}
+
+// checkVehicleCapacity 割当済みの配送と新しい配送の重量・容積の合計が車両の積載量を超えないことを確認する
+// 割当済みの配送は期間の一部でも重なれば同時に積載しているものとみなす
+func checkVehicleCapacity(vehicle *models.Vehicle, loaded []*models.DeliverySchedule, schedule *models.DeliverySchedule) error {
+	availability := vehicleAvailability(vehicle, loaded)
+	if schedule.WeightGrams > availability.RemainingGrams {
+		return &models.VehicleCapacityError{
+			VehicleID:     vehicle.ID,
+			VehicleNumber: vehicle.VehicleNumber,
+			Resource:      "積載重量(g)",
+			Remaining:     availability.RemainingGrams,
+			Requested:     schedule.WeightGrams,
+		}
+	}
+	if availability.RemainingVolumeML != nil && schedule.VolumeML > *availability.RemainingVolumeML {
+		return &models.VehicleCapacityError{
+			VehicleID:     vehicle.ID,
+			VehicleNumber: vehicle.VehicleNumber,
+			Resource:      "荷室容積(mL)",
+			Remaining:     *availability.RemainingVolumeML,
+			Requested:     schedule.VolumeML,
+		}
+	}
+	return nil
+}
+
+// vehicleAvailability 割当済みの配送から車両の積載量の空きを計算する
+func vehicleAvailability(vehicle *models.Vehicle, loaded []*models.DeliverySchedule) *models.VehicleAvailability {
+	availability := &models.VehicleAvailability{Vehicle: vehicle}
+	for _, schedule := range loaded {
+		availability.LoadedWeightGrams += schedule.WeightGrams
+		availability.LoadedVolumeML += schedule.VolumeML
+	}
+	availability.RemainingGrams = vehicle.CapacityGrams() - availability.LoadedWeightGrams
+	if vehicle.VolumeCapacity > 0 {
+		remaining := vehicle.VolumeCapacityML() - availability.LoadedVolumeML
+		availability.RemainingVolumeML = &remaining
+	}
+	return availability
+}
+
+// applyVehicleRequest 車両登録・更新リクエストの内容を車両に設定する
+func applyVehicleRequest(vehicle *models.Vehicle, req *models.VehicleRequest) {
+	vehicle.VehicleNumber = req.VehicleNumber
+	vehicle.Type = req.Type
+	vehicle.Capacity = req.Capacity
+	vehicle.VolumeCapacity = req.VolumeCapacity
+	vehicle.Refrigerated = req.Refrigerated
+	vehicle.LastLocation = req.LastLocation
+	if req.Status != "" {
+		vehicle.Status = req.Status
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * 車両管理サービスのテスト
+ * 整備予定・空き状況と、積載量を確認した配送の割当を検証する
+ */
+
+var (
+	assignStart = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	assignEnd   = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// vehicleTestRepos 車両管理サービスのテストで使用するモック
+type vehicleTestRepos struct {
+	deliveries *mocks.MockDeliveryRepository
+	vehicles   *mocks.MockVehicleRepository
+	schedules  *mocks.MockScheduleRepository
+}
+
+func newTestVehicleService() (*VehicleService, *vehicleTestRepos) {
+	repos := &vehicleTestRepos{
+		deliveries: new(mocks.MockDeliveryRepository),
+		vehicles:   new(mocks.MockVehicleRepository),
+		schedules:  new(mocks.MockScheduleRepository),
+	}
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Deliveries: repos.deliveries,
+		Variants:   new(mocks.MockProductVariantRepository),
+		Vehicles:   repos.vehicles,
+		Schedules:  repos.schedules,
+	})
+	deliveryService := NewDeliveryService(repos.deliveries, new(mocks.MockInventoryRepository), uow, new(mocks.MockNotificationService), nil, nil)
+	return NewVehicleService(repos.vehicles, repos.schedules, deliveryService, nil, uow), repos
+}
+
+// expectDelivery 基本単位(g)の明細を持つ予定中の配送を返すよう設定する
+func (r *vehicleTestRepos) expectDelivery(id int64, grams int) {
+	r.deliveries.On("GetDelivery", mock.Anything, id).Return(&models.Delivery{ID: id, Status: models.DeliveryStatusPending}, nil)
+	r.deliveries.On("ListDeliveryItems", mock.Anything, id).Return([]*models.DeliveryItem{
+		{ID: id * 10, DeliveryID: id, ProductID: 1, Quantity: grams, Unit: models.BaseUnit, UnitQuantity: grams},
+	}, nil)
+}
+
+func TestVehicleService_AssignDelivery(t *testing.T) {
+	ctx := context.Background()
+	req := &models.AssignVehicleRequest{DeliveryID: 1, StartTime: assignStart, EndTime: assignEnd}
+
+	t.Run("積載量の空きがあれば割り当てる", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 300000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, VehicleNumber: "静岡100あ1234", Capacity: 1000, Status: models.VehicleStatusAvailable}, nil)
+		repos.vehicles.On("ListMaintenance", mock.Anything, int64(7)).Return([]*models.VehicleMaintenance{
+			{VehicleID: 7, StartTime: assignEnd, EndTime: assignEnd.Add(2 * time.Hour), Reason: "車検"},
+		}, nil)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), assignStart, assignEnd).Return([]*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusScheduled, WeightGrams: 600000}},
+			{DeliverySchedule: &models.DeliverySchedule{DeliveryID: 3, VehicleID: 7, Status: models.ScheduleStatusCancelled, WeightGrams: 900000}},
+		}, nil)
+		repos.schedules.On("CreateSchedule", mock.Anything, mock.AnythingOfType("*models.DeliverySchedule")).Return(nil)
+
+		schedule, err := service.AssignDelivery(ctx, 7, req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), schedule.VehicleID)
+		assert.Equal(t, 300000, schedule.WeightGrams)
+		assert.Equal(t, models.ScheduleStatusScheduled, schedule.Status)
+		repos.schedules.AssertExpectations(t)
+	})
+
+	t.Run("積載量を超える場合は割り当てない", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 500000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Capacity: 1000, Status: models.VehicleStatusInService}, nil)
+		repos.vehicles.On("ListMaintenance", mock.Anything, int64(7)).Return([]*models.VehicleMaintenance{}, nil)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), assignStart, assignEnd).Return([]*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusScheduled, WeightGrams: 600000}},
+		}, nil)
+
+		_, err := service.AssignDelivery(ctx, 7, req)
+		var capacityErr *models.VehicleCapacityError
+		require.ErrorAs(t, err, &capacityErr)
+		assert.Equal(t, 400000, capacityErr.Remaining)
+		assert.Equal(t, 500000, capacityErr.Requested)
+		repos.schedules.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("整備予定と重なる場合は割り当てない", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 1000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Capacity: 1000, Status: models.VehicleStatusAvailable}, nil)
+		repos.vehicles.On("ListMaintenance", mock.Anything, int64(7)).Return([]*models.VehicleMaintenance{
+			{VehicleID: 7, StartTime: assignStart.Add(time.Hour), EndTime: assignEnd.Add(time.Hour), Reason: "オイル交換"},
+		}, nil)
+
+		_, err := service.AssignDelivery(ctx, 7, req)
+		var unavailableErr *models.VehicleUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+		repos.schedules.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("冷蔵設備のない車両には要冷蔵の配送を割り当てない", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 1000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Capacity: 1000, Status: models.VehicleStatusAvailable}, nil)
+
+		cold := *req
+		cold.RequiresRefrigeration = true
+		_, err := service.AssignDelivery(ctx, 7, &cold)
+		var unavailableErr *models.VehicleUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+	})
+
+	t.Run("整備中の車両には割り当てない", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 1000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Capacity: 1000, Status: models.VehicleStatusMaintenance}, nil)
+
+		_, err := service.AssignDelivery(ctx, 7, req)
+		var unavailableErr *models.VehicleUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+	})
+
+	t.Run("存在しない車両", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.expectDelivery(1, 1000)
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(99)).Return(nil, repository.ErrNotFound)
+
+		_, err := service.AssignDelivery(ctx, 99, req)
+		assert.ErrorIs(t, err, ErrVehicleNotFound)
+	})
+}
+
+func TestVehicleService_CreateMaintenance(t *testing.T) {
+	ctx := context.Background()
+	req := &models.CreateMaintenanceRequest{StartTime: assignStart, EndTime: assignEnd, Reason: "車検"}
+
+	t.Run("割当と重なる整備予定は登録しない", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Status: models.VehicleStatusAvailable}, nil)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), assignStart, assignEnd).Return([]*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusScheduled}},
+		}, nil)
+
+		_, err := service.CreateMaintenance(ctx, 7, req)
+		var unavailableErr *models.VehicleUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+		repos.vehicles.AssertNotCalled(t, "CreateMaintenance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("取消済みの割当だけなら登録する", func(t *testing.T) {
+		service, repos := newTestVehicleService()
+		repos.vehicles.On("LockVehicle", mock.Anything, int64(7)).Return(&models.Vehicle{ID: 7, Status: models.VehicleStatusAvailable}, nil)
+		repos.schedules.On("ListVehicleSchedules", mock.Anything, int64(7), assignStart, assignEnd).Return([]*models.VehicleScheduleEntry{
+			{DeliverySchedule: &models.DeliverySchedule{DeliveryID: 2, VehicleID: 7, Status: models.ScheduleStatusCancelled}},
+		}, nil)
+		repos.vehicles.On("CreateMaintenance", mock.Anything, mock.AnythingOfType("*models.VehicleMaintenance")).Return(nil)
+
+		maintenance, err := service.CreateMaintenance(ctx, 7, req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), maintenance.VehicleID)
+	})
+}
+
+func TestVehicleService_ListAvailableVehicles(t *testing.T) {
+	service, repos := newTestVehicleService()
+	repos.vehicles.On("ListVehicles", mock.Anything, (*repository.QuerySpec)(nil)).Return([]*models.Vehicle{
+		{ID: 1, Capacity: 1000, Status: models.VehicleStatusAvailable},
+		{ID: 2, Capacity: 1000, Status: models.VehicleStatusAvailable},
+		{ID: 3, Capacity: 1000, Status: models.VehicleStatusRetired},
+		{ID: 4, Capacity: 2000, VolumeCapacity: 10, Refrigerated: true, Status: models.VehicleStatusInService},
+		{ID: 5, Capacity: 2000, Status: models.VehicleStatusAvailable},
+	}, nil, nil)
+	repos.vehicles.On("ListMaintenanceInPeriod", mock.Anything, assignStart, assignEnd).Return([]*models.VehicleMaintenance{
+		{VehicleID: 2, StartTime: assignStart, EndTime: assignEnd},
+	}, nil)
+	repos.schedules.On("ListSchedulesInPeriod", mock.Anything, assignStart, assignEnd).Return([]*models.DeliverySchedule{
+		{VehicleID: 4, WeightGrams: 500000, VolumeML: 4000},
+		{VehicleID: 5, WeightGrams: 1900000},
+	}, nil)
+
+	available, err := service.ListAvailableVehicles(context.Background(), &models.VehicleAvailabilityQuery{
+		StartTime:   assignStart,
+		EndTime:     assignEnd,
+		WeightGrams: 200000,
+	})
+	require.NoError(t, err)
+
+	// 整備予定と重なる車両2・廃車の車両3・空きが足りない車両5は除く
+	require.Len(t, available, 2)
+	assert.Equal(t, int64(1), available[0].Vehicle.ID)
+	assert.Equal(t, 1000000, available[0].RemainingGrams)
+	assert.Nil(t, available[0].RemainingVolumeML)
+	assert.Equal(t, int64(4), available[1].Vehicle.ID)
+	assert.Equal(t, 1500000, available[1].RemainingGrams)
+	require.NotNil(t, available[1].RemainingVolumeML)
+	assert.Equal(t, 6000, *available[1].RemainingVolumeML)
+
+	refrigerated, err := service.ListAvailableVehicles(context.Background(), &models.VehicleAvailabilityQuery{
+		StartTime:    assignStart,
+		EndTime:      assignEnd,
+		Refrigerated: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, refrigerated, 1)
+	assert.Equal(t, int64(4), refrigerated[0].Vehicle.ID)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tea-logistics/pkg/geo"
+	"tea-logistics/pkg/logger"
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+)
+
+/*
+ * 倉庫管理サービス
+ * 倉庫の管理と保管容量に関するビジネスロジックを実装する
+ */
+
+// WarehouseService 倉庫管理サービス
+type WarehouseService struct {
+	repo     repository.WarehouseRepository
+	uow      repository.UnitOfWork
+	geocoder geo.Geocoder
+}
+
+// NewWarehouseService 倉庫管理サービスを作成する
+// geocoderがnilの場合は、住所の正規化・位置の特定を行わない
+func NewWarehouseService(repo repository.WarehouseRepository, uow repository.UnitOfWork, geocoder geo.Geocoder) *WarehouseService {
+	return &WarehouseService{
+		repo:     repo,
+		uow:      uow,
+		geocoder: geocoder,
+	}
+}
+
+// CreateWarehouse 倉庫を作成する
+// 住所は正規化して位置を求め、解釈できない住所は要確認として登録する
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, req *models.CreateWarehouseRequest) (*models.Warehouse, error) {
+	status := req.Status
+	if status == "" {
+		status = models.WarehouseStatusActive
+	}
+
+	location := locateAddress(ctx, s.geocoder, req.Address, req.Latitude, req.Longitude)
+	warehouse := &models.Warehouse{
+		Name:          req.Name,
+		Address:       location.Address,
+		Capacity:      req.Capacity,
+		Status:        status,
+		Latitude:      location.Latitude,
+		Longitude:     location.Longitude,
+		AddressStatus: location.Status,
+		AddressNote:   location.Note,
+	}
+
+	if err := s.repo.CreateWarehouse(ctx, warehouse); err != nil {
+		return nil, fmt.Errorf("倉庫作成エラー: %v", err)
+	}
+
+	return warehouse, nil
+}
+
+// GetWarehouse 倉庫を取得する
+func (s *WarehouseService) GetWarehouse(ctx context.Context, id int64) (*models.Warehouse, error) {
+	warehouse, err := s.repo.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫取得エラー: %v", err)
+	}
+
+	return warehouse, nil
+}
+
+// ListWarehouses 倉庫一覧を取得する
+func (s *WarehouseService) ListWarehouses(ctx context.Context) ([]*models.Warehouse, error) {
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+
+	return warehouses, nil
+}
+
+// UpdateWarehouse 倉庫を更新する
+// 住所は作成時と同様に正規化して位置を求め直す
+// 保管中の在庫数量を下回る容量には変更できない
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, id int64, req *models.UpdateWarehouseRequest) (*models.Warehouse, error) {
+	location := locateAddress(ctx, s.geocoder, req.Address, req.Latitude, req.Longitude)
+
+	var warehouse *models.Warehouse
+	// 倉庫と在庫ロケーションの更新を単一トランザクションで実行する
+	err := s.uow.WithinTx(ctx, func(ctx context.Context, tx *repository.TxRepositories) error {
+		var err error
+		warehouse, err = tx.Warehouses.GetWarehouse(ctx, id)
+		if err != nil {
+			return fmt.Errorf("倉庫取得エラー: %v", err)
+		}
+
+		used, err := tx.Warehouses.GetStoredQuantity(ctx, id)
+		if err != nil {
+			return err
+		}
+		if req.Capacity < used {
+			return fmt.Errorf("保管中の在庫数量(%d)を下回る容量には変更できません", used)
+		}
+
+		// 倉庫名を変更する場合は、旧倉庫名での引当・出庫と直列化するため在庫のロックを取得してから書き換える
+		if req.Name != warehouse.Name {
+			if err := lockWarehouseStocks(ctx, tx, warehouse.Name); err != nil {
+				return err
+			}
+		}
+
+		warehouse.Name = req.Name
+		warehouse.Address = location.Address
+		warehouse.Capacity = req.Capacity
+		warehouse.Status = req.Status
+		warehouse.Latitude = location.Latitude
+		warehouse.Longitude = location.Longitude
+		warehouse.AddressStatus = location.Status
+		warehouse.AddressNote = location.Note
+
+		if err := tx.Warehouses.UpdateWarehouse(ctx, warehouse); err != nil {
+			return fmt.Errorf("倉庫更新エラー: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warehouse, nil
+}
+
+// lockWarehouseStocks トランザクション内で倉庫に保管しているすべての商品の在庫ロックを取得する
+func lockWarehouseStocks(ctx context.Context, tx *repository.TxRepositories, name string) error {
+	inventories, err := tx.Inventory.GetInventoryByLocation(ctx, name)
+	if err != nil {
+		return fmt.Errorf("在庫取得エラー: %v", err)
+	}
+
+	keys := make([]stockKey, 0, len(inventories))
+	for _, inventory := range inventories {
+		keys = append(keys, stockKey{ProductID: inventory.ProductID, Location: name})
+	}
+	return lockStocks(ctx, tx, keys)
+}
+
+// DeleteWarehouse 倉庫を削除する
+// 在庫が残っている倉庫は削除できない
+func (s *WarehouseService) DeleteWarehouse(ctx context.Context, id int64) error {
+	used, err := s.repo.GetStoredQuantity(ctx, id)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("在庫が残っている倉庫は削除できません")
+	}
+
+	if err := s.repo.DeleteWarehouse(ctx, id); err != nil {
+		return fmt.Errorf("倉庫削除エラー: %v", err)
+	}
+
+	return nil
+}
+
+// GetUtilization 倉庫の使用率を取得する
+func (s *WarehouseService) GetUtilization(ctx context.Context, id int64) (*models.WarehouseUtilization, error) {
+	warehouse, err := s.repo.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫取得エラー: %v", err)
+	}
+
+	used, err := s.repo.GetStoredQuantity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewWarehouseUtilization(warehouse, used), nil
+}
+
+// ListUtilization 全倉庫の使用率を取得する
+func (s *WarehouseService) ListUtilization(ctx context.Context) ([]*models.WarehouseUtilization, error) {
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("倉庫一覧取得エラー: %v", err)
+	}
+
+	quantities, err := s.repo.ListStoredQuantities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	utilizations := make([]*models.WarehouseUtilization, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		utilizations = append(utilizations, models.NewWarehouseUtilization(warehouse, quantities[warehouse.ID]))
+	}
+
+	return utilizations, nil
+}
+
+// checkWarehouseCapacity トランザクション内で移動先倉庫の空き容量を確認する
+// 移動先が倉庫でない場合（配送先住所など）や同一倉庫内の移動は対象外とする
+// 同じ倉庫への入庫が同時に容量を確認して超過しないよう、移動先倉庫の行をロックしてから保管数量を集計する
+// 在庫のロックより後に取得するため、呼び出し元は在庫のロックを取得してから呼び出すこと
+func checkWarehouseCapacity(ctx context.Context, tx *repository.TxRepositories, fromLocation, toLocation string, quantity int) error {
+	if fromLocation == toLocation {
+		return nil
+	}
+
+	warehouse, err := tx.Warehouses.LockWarehouseByName(ctx, toLocation)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("移動先倉庫取得エラー: %v", err)
+	}
+
+	used, err := tx.Warehouses.GetStoredQuantity(ctx, warehouse.ID)
+	if err != nil {
+		return err
+	}
+
+	if remaining := warehouse.Capacity - used; quantity > remaining {
+		logger.Warn("倉庫容量超過", map[string]interface{}{
+			"warehouse_id": warehouse.ID,
+			"capacity":     warehouse.Capacity,
+			"used":         used,
+			"requested":    quantity,
+		})
+		return &models.WarehouseCapacityError{
+			WarehouseID: warehouse.ID,
+			Name:        warehouse.Name,
+			Remaining:   remaining,
+			Requested:   quantity,
+		}
+	}
+
+	return nil
+}
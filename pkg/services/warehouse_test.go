@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tea-logistics/pkg/models"
+	"tea-logistics/pkg/repository"
+	"tea-logistics/pkg/services/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+/*
+ * 倉庫管理サービステスト
+ */
+
+func newTestWarehouseService(mockRepo *mocks.MockWarehouseRepository) *WarehouseService {
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Warehouses:  mockRepo,
+		StockCounts: newDefaultStockCountRepo(),
+	})
+	return NewWarehouseService(mockRepo, uow, nil)
+}
+
+func TestCreateWarehouse(t *testing.T) {
+	mockRepo := new(mocks.MockWarehouseRepository)
+	service := newTestWarehouseService(mockRepo)
+
+	ctx := context.Background()
+	mockRepo.On("CreateWarehouse", ctx, mock.AnythingOfType("*models.Warehouse")).Return(nil)
+
+	warehouse, err := service.CreateWarehouse(ctx, &models.CreateWarehouseRequest{
+		Name:     "静岡倉庫",
+		Address:  "静岡県静岡市",
+		Capacity: 5000,
+	})
+
+	assert.NoError(t, err)
+	// ステータス省略時は稼働中とする
+	assert.Equal(t, models.WarehouseStatusActive, warehouse.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateWarehouse_CapacityBelowStoredQuantity(t *testing.T) {
+	mockRepo := new(mocks.MockWarehouseRepository)
+	service := newTestWarehouseService(mockRepo)
+
+	ctx := context.Background()
+	warehouse := &models.Warehouse{ID: 1, Name: "東京倉庫", Capacity: 1000, Status: models.WarehouseStatusActive}
+	mockRepo.On("GetWarehouse", ctx, int64(1)).Return(warehouse, nil)
+	mockRepo.On("GetStoredQuantity", ctx, int64(1)).Return(800, nil)
+
+	updated, err := service.UpdateWarehouse(ctx, 1, &models.UpdateWarehouseRequest{
+		Name:     "東京倉庫",
+		Address:  "東京都江東区",
+		Capacity: 500,
+		Status:   models.WarehouseStatusActive,
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "UpdateWarehouse", mock.Anything, mock.Anything)
+}
+
+func TestUpdateWarehouse_RenameLocksStock(t *testing.T) {
+	mockRepo := new(mocks.MockWarehouseRepository)
+	mockInventoryRepo := new(mocks.MockInventoryRepository)
+	mockReservationRepo := new(mocks.MockReservationRepository)
+	uow := mocks.NewMockUnitOfWork(&repository.TxRepositories{
+		Warehouses:   mockRepo,
+		Inventory:    mockInventoryRepo,
+		Reservations: mockReservationRepo,
+		StockCounts:  newDefaultStockCountRepo(),
+	})
+	service := NewWarehouseService(mockRepo, uow, nil)
+
+	ctx := context.Background()
+	warehouse := &models.Warehouse{ID: 1, Name: "東京倉庫", Capacity: 1000, Status: models.WarehouseStatusActive}
+	inventories := []*models.Inventory{
+		{ID: 1, ProductID: 2, Quantity: 10, Location: "東京倉庫"},
+		{ID: 2, ProductID: 1, Quantity: 20, Location: "東京倉庫"},
+		{ID: 3, ProductID: 2, Quantity: 5, Location: "東京倉庫"},
+	}
+	mockRepo.On("GetWarehouse", ctx, int64(1)).Return(warehouse, nil)
+	mockRepo.On("GetStoredQuantity", ctx, int64(1)).Return(35, nil)
+	mockInventoryRepo.On("GetInventoryByLocation", ctx, "東京倉庫").Return(inventories, nil)
+	// 旧倉庫名の在庫ロックを商品ID順に1回ずつ取得してから倉庫名を書き換える
+	lock1 := mockReservationRepo.On("LockStock", ctx, int64(1), "東京倉庫").Return(nil).Once()
+	lock2 := mockReservationRepo.On("LockStock", ctx, int64(2), "東京倉庫").Return(nil).Once().NotBefore(lock1)
+	mockRepo.On("UpdateWarehouse", ctx, mock.MatchedBy(func(w *models.Warehouse) bool {
+		return w.Name == "東京第一倉庫"
+	})).Return(nil).NotBefore(lock2)
+
+	updated, err := service.UpdateWarehouse(ctx, 1, &models.UpdateWarehouseRequest{
+		Name:     "東京第一倉庫",
+		Address:  "東京都江東区",
+		Capacity: 1000,
+		Status:   models.WarehouseStatusActive,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "東京第一倉庫", updated.Name)
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestDeleteWarehouse_WithStock(t *testing.T) {
+	mockRepo := new(mocks.MockWarehouseRepository)
+	service := newTestWarehouseService(mockRepo)
+
+	ctx := context.Background()
+	mockRepo.On("GetStoredQuantity", ctx, int64(1)).Return(10, nil)
+
+	err := service.DeleteWarehouse(ctx, 1)
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "DeleteWarehouse", mock.Anything, mock.Anything)
+}
+
+func TestListUtilization(t *testing.T) {
+	mockRepo := new(mocks.MockWarehouseRepository)
+	service := newTestWarehouseService(mockRepo)
+
+	ctx := context.Background()
+	warehouses := []*models.Warehouse{
+		{ID: 1, Name: "東京倉庫", Capacity: 1000},
+		{ID: 2, Name: "大阪倉庫", Capacity: 500},
+	}
+	mockRepo.On("ListWarehouses", ctx).Return(warehouses, nil)
+	// 在庫のない倉庫は集計結果に含まれない
+	mockRepo.On("ListStoredQuantities", ctx).Return(map[int64]int{1: 250}, nil)
+
+	utilizations, err := service.ListUtilization(ctx)
+
+	assert.NoError(t, err)
+	assert.Len(t, utilizations, 2)
+	assert.Equal(t, 250, utilizations[0].UsedQuantity)
+	assert.Equal(t, 750, utilizations[0].RemainingCapacity)
+	assert.InDelta(t, 0.25, utilizations[0].UtilizationRate, 0.0001)
+	assert.Equal(t, 0, utilizations[1].UsedQuantity)
+	assert.Equal(t, 500, utilizations[1].RemainingCapacity)
+}
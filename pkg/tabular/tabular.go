@@ -0,0 +1,179 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+ * 表形式ファイル
+ * 一括取込・出力に使用するCSV・Excel（XLSX）ファイルの読み書きを提供する
+ */
+
+// Format ファイル形式
+type Format string
+
+const (
+	// FormatCSV CSV（UTF-8）
+	FormatCSV Format = "csv"
+	// FormatXLSX Excelブック
+	FormatXLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat 対応していないファイル形式
+var ErrUnsupportedFormat = errors.New("ファイル形式はcsvまたはxlsxを指定してください")
+
+// ParseFormat 形式名を解釈する
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// FormatFromFilename ファイル名の拡張子から形式を判定する
+func FormatFromFilename(filename string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ContentType 形式に対応するContent-Typeを返す
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read ファイルを行の配列として読み込む
+// XLSXは先頭のシートを読み込む。CSVの先頭にBOMがある場合は取り除く
+func Read(r io.Reader, format Format) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		// 行によって列数が異なるファイル（末尾の空欄を省略したものなど）も受け付ける
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("CSV読み込みエラー: %v", err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case FormatXLSX:
+		book, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("Excel読み込みエラー: %v", err)
+		}
+		defer book.Close()
+
+		sheets := book.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+		rows, err := book.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("Excel読み込みエラー: %v", err)
+		}
+		return rows, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Write 行の配列をファイルとして書き出す
+// XLSXはsheetの名前のシート1枚のブックとして書き出す
+func Write(w io.Writer, format Format, sheet string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return fmt.Errorf("CSV書き込みエラー: %v", err)
+		}
+		return nil
+	case FormatXLSX:
+		book := excelize.NewFile()
+		defer book.Close()
+
+		if err := book.SetSheetName(book.GetSheetName(0), sheet); err != nil {
+			return fmt.Errorf("Excel書き込みエラー: %v", err)
+		}
+		writer, err := book.NewStreamWriter(sheet)
+		if err != nil {
+			return fmt.Errorf("Excel書き込みエラー: %v", err)
+		}
+		for i, row := range rows {
+			cells := make([]interface{}, len(row))
+			for j, value := range row {
+				cells[j] = value
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := writer.SetRow(cell, cells); err != nil {
+				return fmt.Errorf("Excel書き込みエラー: %v", err)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("Excel書き込みエラー: %v", err)
+		}
+		if err := book.Write(w); err != nil {
+			return fmt.Errorf("Excel書き込みエラー: %v", err)
+		}
+		return nil
+	}
+	return ErrUnsupportedFormat
+}
+
+// Header 見出し行の列名から列番号を引く
+type Header map[string]int
+
+// NewHeader 見出し行から列名と列番号の対応を作成する
+// 列名は前後の空白を除き、大文字・小文字を区別しない
+func NewHeader(row []string) Header {
+	header := make(Header, len(row))
+	for i, name := range row {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := header[key]; !ok && key != "" {
+			header[key] = i
+		}
+	}
+	return header
+}
+
+// Missing 見出し行にない必須列を返す
+func (h Header) Missing(required ...string) []string {
+	var missing []string
+	for _, name := range required {
+		if _, ok := h[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Value 行から列の値を取得する（前後の空白は除く）
+// 列が見出し行にない場合や行が短い場合は空文字を返す
+func (h Header) Value(row []string, name string) string {
+	i, ok := h[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// IsBlank 行のすべての列が空かどうかを判定する
+func IsBlank(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package tabular
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * 表形式ファイルテスト
+ */
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "name", "price"},
+		{"SEN-001", "煎茶, 特上", "1200"},
+	}
+
+	for _, format := range []Format{FormatCSV, FormatXLSX} {
+		var buf bytes.Buffer
+		assert.NoError(t, Write(&buf, format, "products", rows))
+
+		got, err := Read(&buf, format)
+		assert.NoError(t, err)
+		assert.Equal(t, rows, got, format)
+	}
+}
+
+func TestRead_CSVWithBOMAndShortRows(t *testing.T) {
+	rows, err := Read(strings.NewReader("\ufeffSKU,Name,Price\nSEN-001,煎茶\n"), FormatCSV)
+	assert.NoError(t, err)
+
+	header := NewHeader(rows[0])
+	assert.Empty(t, header.Missing("sku", "name", "price"))
+	assert.Equal(t, "SEN-001", header.Value(rows[1], "sku"))
+	assert.Equal(t, "", header.Value(rows[1], "price"))
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := FormatFromFilename("inventory.XLSX")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+
+	_, err = ParseFormat("xls")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -36,9 +36,10 @@ func setupTestServer() (*gin.Engine, sqlmock.Sqlmock, *sql.DB) {
 
 	// リポジトリの初期化
 	inventoryRepo := repository.NewInventoryRepository(db)
+	reservationRepo := repository.NewSQLReservationRepository(repository.NewSQLDatabase(db))
 
 	// サービスの初期化
-	inventoryService := services.NewInventoryService(inventoryRepo)
+	inventoryService := services.NewInventoryService(inventoryRepo, reservationRepo, repository.NewSQLUnitOfWork(db))
 
 	// ハンドラの初期化
 	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
@@ -69,16 +70,27 @@ func TestInventoryAPI_UpdateInventory(t *testing.T) {
 	defer db.Close()
 
 	t.Run("正常な在庫更新", func(t *testing.T) {
+		// 在庫更新はトランザクション内で実行される
+		mock.ExpectBegin()
+
+		// 棚卸中のロケーションではない
+		mock.ExpectQuery(`SELECT (.+) FROM stock_counts WHERE location = \$1`).
+			WithArgs("東京倉庫", models.StockCountStatusOpen, models.StockCountStatusSubmitted).
+			WillReturnError(sql.ErrNoRows)
+
 		// モックの設定
-		rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"}).
-			AddRow(1, 1, 100, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now())
-		mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE location = \$1 ORDER BY id`).
-			WithArgs("東京倉庫").
+		rows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"}).
+			AddRow(1, 1, 100, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1)
+		mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE product_id = \$1 AND location = \$2\s+AND COALESCE\(bin_id, 0\)`).
+			WithArgs(1, "東京倉庫", nil, nil, models.InventoryStatusExpired, models.InventoryStatusQuarantined, false).
 			WillReturnRows(rows)
 
-		mock.ExpectExec(`UPDATE inventory SET quantity = \$1, updated_at = \$2 WHERE id = \$3`).
-			WithArgs(150, sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		// 在庫数の更新と在庫元帳への記帳（在庫移動には紐付かない）
+		mock.ExpectQuery(`UPDATE inventory i\s+SET quantity = \$1, updated_at = \$2`).
+			WithArgs(150, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, nil, 0, "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(1, 2))
+
+		mock.ExpectCommit()
 
 		// リクエストボディの作成
 		requestBody := map[string]interface{}{
@@ -139,34 +151,71 @@ func TestInventoryAPI_CreateMovement(t *testing.T) {
 	defer db.Close()
 
 	t.Run("正常な在庫移動作成", func(t *testing.T) {
+		// 在庫移動はトランザクション内で実行される
+		mock.ExpectBegin()
+
+		// 移動元・移動先とも棚卸中のロケーションではない
+		for _, location := range []string{"東京倉庫", "大阪倉庫"} {
+			mock.ExpectQuery(`SELECT (.+) FROM stock_counts WHERE location = \$1`).
+				WithArgs(location, models.StockCountStatusOpen, models.StockCountStatusSubmitted).
+				WillReturnError(sql.ErrNoRows)
+		}
+
+		// 移動元・移動先の商品・ロケーション単位のロック（デッドロックを避けるためキー順に取得する）
+		for _, key := range []string{"stock:1:大阪倉庫", "stock:1:東京倉庫"} {
+			mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+				WithArgs(key).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+
 		// モックの設定 - 移動元在庫（商品ID=1が東京倉庫にある）
-		fromRows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"}).
-			AddRow(1, 1, 100, "東京倉庫", models.InventoryStatusAvailable, time.Now(), time.Now())
-		mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE location = \$1 ORDER BY id`).
-			WithArgs("東京倉庫").
+		fromRows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "warehouse_id", "bin_id", "lot_id", "status", "created_at", "updated_at", "version"}).
+			AddRow(1, 1, 100, "東京倉庫", 1, nil, nil, models.InventoryStatusAvailable, time.Now(), time.Now(), 1)
+		mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE product_id = \$1 AND location = \$2 ORDER BY id`).
+			WithArgs(1, "東京倉庫").
 			WillReturnRows(fromRows)
 
-		// 移動元在庫の更新（最初に実行される）
-		mock.ExpectExec(`UPDATE inventory SET quantity = \$1, updated_at = \$2 WHERE id = \$3`).
-			WithArgs(50, sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		// 移動元の引当中数量（引当なし）
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\)\s+FROM stock_reservations`).
+			WithArgs(1, "東京倉庫", models.ReservationStatusActive).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
 
-		// 移動先在庫（商品ID=1が大阪倉庫にない）
-		toRows := sqlmock.NewRows([]string{"id", "product_id", "quantity", "location", "status", "created_at", "updated_at"})
-		mock.ExpectQuery(`SELECT id, product_id, quantity, location, status, created_at, updated_at FROM inventory WHERE location = \$1 ORDER BY id`).
+		// 移動先倉庫の空き容量確認（大阪倉庫は容量1000、保管数量900）
+		warehouseRows := sqlmock.NewRows([]string{"id", "name", "address", "capacity", "status", "latitude", "longitude", "address_status", "address_note", "created_at", "updated_at"}).
+			AddRow(2, "大阪倉庫", "大阪府大阪市", 1000, models.WarehouseStatusActive, nil, nil, models.AddressStatusUnverified, "", time.Now(), time.Now())
+		mock.ExpectQuery(`SELECT id, name, address, capacity, status, latitude, longitude, address_status, address_note, created_at, updated_at FROM warehouses WHERE name = \$1 FOR UPDATE`).
 			WithArgs("大阪倉庫").
-			WillReturnRows(toRows)
+			WillReturnRows(warehouseRows)
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\) FROM inventory WHERE warehouse_id = \$1`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(900))
 
-		// 移動先在庫の作成
-		mock.ExpectQuery(`INSERT INTO inventory`).
-			WithArgs(1, 50, "大阪倉庫", models.InventoryStatusAvailable, sqlmock.AnyArg()).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
-
-		// 在庫移動の記録
+		// 在庫移動の記録（在庫の増減より先に記録し、在庫元帳の記帳を紐付ける）
 		mock.ExpectQuery(`INSERT INTO inventory_movements`).
-			WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg()).
+			WithArgs(1, "東京倉庫", "大阪倉庫", 50, models.MovementTypeTransfer, sqlmock.AnyArg(), "TRF-001", sqlmock.AnyArg(), nil, nil, nil, "", "g", 50).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
+		// 移動元在庫の更新
+		mock.ExpectQuery(`UPDATE inventory i\s+SET quantity = \$1, updated_at = \$2`).
+			WithArgs(50, sqlmock.AnyArg(), 1, 1, models.LedgerEntryTypeChange, int64(1), 0, "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "version"}).AddRow(1, 2))
+
+		// 移動先在庫（商品ID=1が大阪倉庫にない）
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+			WithArgs("stock:1:大阪倉庫").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT id, product_id, quantity, location, warehouse_id, bin_id, lot_id, status, created_at, updated_at, version FROM inventory WHERE product_id = \$1 AND location = \$2\s+AND COALESCE\(bin_id, 0\)`).
+			WithArgs(1, "大阪倉庫", nil, nil, models.InventoryStatusExpired, models.InventoryStatusQuarantined, false).
+			WillReturnError(sql.ErrNoRows)
+
+		// 移動先在庫の作成
+		mock.ExpectQuery(`INSERT INTO inventory \(`).
+			WithArgs(1, 50, "大阪倉庫", nil, nil, models.InventoryStatusAvailable, sqlmock.AnyArg(),
+				models.LedgerEntryTypeCreate, int64(1), 0, "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "warehouse_id"}).AddRow(2, 2))
+
+		mock.ExpectCommit()
+
 		// リクエストボディの作成
 		requestBody := map[string]interface{}{
 			"product_id":       1,
@@ -94,7 +94,7 @@ func (s *NotificationIntegrationTestSuite) TestDeliveryStatusChangeNotification(
 	assert.NoError(s.T(), err)
 
 	// 3. 通知の確認
-	notifications, err := s.notifyService.ListNotifications(ctx, delivery.OrderID)
+	notifications, _, err := s.notifyService.ListNotifications(ctx, delivery.OrderID, nil)
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), notifications)
 	assert.Equal(s.T(), models.NotificationTypeDeliveryStatus, notifications[0].Type)
@@ -120,7 +120,7 @@ func (s *NotificationIntegrationTestSuite) TestDeliveryCompleteNotification() {
 	assert.NoError(s.T(), err)
 
 	// 3. 通知の確認
-	notifications, err := s.notifyService.ListNotifications(ctx, delivery.OrderID)
+	notifications, _, err := s.notifyService.ListNotifications(ctx, delivery.OrderID, nil)
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), notifications)
 	assert.Equal(s.T(), models.NotificationTypeDeliveryComplete, notifications[0].Type)
@@ -155,7 +155,7 @@ func (s *NotificationIntegrationTestSuite) TestDeliveryTrackingNotification() {
 	assert.NoError(s.T(), err)
 
 	// 4. 通知の確認
-	notifications, err := s.notifyService.ListNotifications(ctx, delivery.OrderID) // OrderIDを使用
+	notifications, _, err := s.notifyService.ListNotifications(ctx, delivery.OrderID, nil) // OrderIDを使用
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), notifications)
 	assert.Equal(s.T(), models.NotificationTypeDeliveryTracking, notifications[0].Type)